@@ -13,6 +13,7 @@ import (
 	"github.com/orizon-lang/orizon/internal/hir"
 	"github.com/orizon-lang/orizon/internal/lexer"
 	p "github.com/orizon-lang/orizon/internal/parser"
+	"github.com/orizon-lang/orizon/internal/testrunner/snapshot"
 )
 
 func main() {
@@ -179,17 +180,33 @@ func processFile(filename, outDir string, doMIR, doLIR, doX64 bool, goldenDir st
 						return err
 					}
 				} else {
-					return fmt.Errorf("missing golden: %s", gp)
+					if err := snapshot.WritePending(gp, o.content); err != nil {
+						return err
+					}
+
+					return fmt.Errorf("missing golden: %s (pending %s)", gp, snapshot.PendingPath(gp))
 				}
-			} else if string(gb) != o.content {
+			} else if diff := snapshot.Compare(gp, string(gb), o.content); diff != "" {
+				// MIR/LIR goldens are compared per function and block with
+				// temporaries renumbered, so counter shifts are not a diff.
 				if update {
 					if err := os.WriteFile(gp, []byte(o.content), 0o644); err != nil {
 						return err
 					}
 				} else {
-					return fmt.Errorf("golden mismatch: %s", gp)
+					if err := snapshot.WritePending(gp, o.content); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(os.Stderr, "golden mismatch: %s\n%s", gp, diff)
+
+					return fmt.Errorf("golden mismatch: %s (pending %s, accept with orizon test --review)", gp, snapshot.PendingPath(gp))
 				}
 			}
+
+			if err := snapshot.ClearPending(gp); err != nil {
+				return err
+			}
 		}
 	}
 
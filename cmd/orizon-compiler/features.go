@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+	p "github.com/orizon-lang/orizon/internal/parser"
+)
+
+// packageManifest is the part of orizon.json the compiler needs.
+type packageManifest struct {
+	Features map[string][]string `json:"features,omitempty"`
+}
+
+// buildCfg computes the flags #[cfg(...)] attributes are evaluated against.
+// Requested features are expanded through the feature table of the nearest
+// orizon.json above the input file; the default feature is included unless
+// noDefault is set. extra adds raw cfg flags (name or name="value").
+func buildCfg(inputFile string, features []string, noDefault bool, extra []string) (p.CfgSet, error) {
+	man, found, err := findPackageManifest(filepath.Dir(inputFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []string
+
+	if found {
+		enabled, err := packagemanager.ExpandFeatures(man.Features, features, !noDefault)
+		if err != nil {
+			return nil, err
+		}
+
+		flags = packagemanager.FeatureCfg(man.Features, enabled)
+	} else {
+		for _, f := range features {
+			flags = append(flags, fmt.Sprintf("feature=%q", f))
+		}
+	}
+
+	return p.NewCfgSet(append(flags, extra...)...), nil
+}
+
+// findPackageManifest looks for orizon.json in dir and its parents.
+func findPackageManifest(dir string) (packageManifest, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return packageManifest{}, false, err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "orizon.json"))
+		if err == nil {
+			var man packageManifest
+			if err := json.Unmarshal(data, &man); err != nil {
+				return packageManifest{}, false, fmt.Errorf("parse %s: %w", filepath.Join(dir, "orizon.json"), err)
+			}
+
+			return man, true, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return packageManifest{}, false, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return packageManifest{}, false, nil
+		}
+
+		dir = parent
+	}
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
@@ -17,7 +17,9 @@ import (
 	"github.com/orizon-lang/orizon/internal/debug"
 	"github.com/orizon-lang/orizon/internal/hir"
 	"github.com/orizon-lang/orizon/internal/lexer"
+	"github.com/orizon-lang/orizon/internal/mir"
 	p "github.com/orizon-lang/orizon/internal/parser"
+	"github.com/orizon-lang/orizon/internal/testrunner/coverage"
 )
 
 var (
@@ -46,6 +48,13 @@ func main() {
 		x64Out  = flag.String("x64-out", "", "write diagnostic x64 assembly to a file instead of stdout")
 		emitMIR = flag.Bool("emit-mir", false, "emit MIR textual dump (stdout)")
 		emitLIR = flag.Bool("emit-lir", false, "emit LIR textual dump (stdout)")
+		// Coverage instrumentation.
+		cover    = flag.Bool("cover", false, "lower with a coverage counter per basic block; the program writes its counters to $ORIZON_COVERPROFILE (default orizon.cover) at exit")
+		coverDir = flag.String("cover-dir", "coverage", "with --cover, directory for the source map the counters map through")
+		// Conditional compilation.
+		features   = flag.String("features", "", "comma separated package features to enable")
+		noDefaults = flag.Bool("no-default-features", false, "do not enable the default feature")
+		cfgFlags   = flag.String("cfg", "", "comma separated extra cfg flags (name or name=\"value\")")
 	)
 
 	flag.Parse()
@@ -132,7 +141,12 @@ func main() {
 		// }
 	}
 
-	if err := compileFile(inputFile, *debugLexer, *doParse, *optLevel, *emitDebug, *emitSrcMap, *debugOut, *smOut, *dwarfDir, *outELF, *outCOFF, *outMachO, *emitMIR, *emitLIR, *emitX64, *x64Out); err != nil {
+	cfg, err := buildCfg(inputFile, splitList(*features), *noDefaults, splitList(*cfgFlags))
+	if err != nil {
+		log.Fatalf("Invalid features: %v", err)
+	}
+
+	if err := compileFile(inputFile, cfg, *debugLexer, *doParse, *optLevel, *emitDebug, *emitSrcMap, *debugOut, *smOut, *dwarfDir, *outELF, *outCOFF, *outMachO, *emitMIR, *emitLIR, *emitX64, *x64Out, *cover, *coverDir); err != nil {
 		log.Fatalf("Compilation failed: %v", err)
 	}
 }
@@ -160,15 +174,22 @@ func showUsage() {
 	fmt.Println("    --emit-lir       Emit LIR textual dump")
 	fmt.Println("    --emit-x64       Emit diagnostic x64-like assembly text")
 	fmt.Println("    --x64-out PATH   Write diagnostic x64 assembly to PATH")
+	fmt.Println("    --cover          Lower with coverage counters; the program writes them to $ORIZON_COVERPROFILE at exit")
+	fmt.Println("    --cover-dir DIR  With --cover, write the counters' source map to DIR (default coverage)")
+	fmt.Println("    --features a,b   Enable package features for #[cfg(feature = \"x\")]")
+	fmt.Println("    --no-default-features  Do not enable the default feature")
+	fmt.Println("    --cfg a,b=\"v\"    Set extra cfg flags")
 	fmt.Println("    env ORIZON_DEBUG_OBJ_OUT, ORIZON_DEBUG_OBJ_FORMAT={auto|elf|coff|macho} can auto-emit when not specified")
 	fmt.Println()
 	fmt.Println("EXAMPLES:")
 	fmt.Println("    orizon-compiler hello.oriz")
 	fmt.Println("    orizon-compiler --emit-debug hello.oriz")
+	fmt.Println("    orizon-compiler --parse --features net,json src/main.oriz")
 	fmt.Println("    orizon-compiler --emit-debug --debug-out dbg.json --dwarf-out-dir out/dwarf hello.oriz")
+	fmt.Println("    orizon-compiler --cover --cover-dir cov --x64-out hello.s hello.oriz")
 }
 
-func compileFile(filename string, debugLexer bool, doParse bool, optLevel string, emitDebug bool, emitSrcMap bool, debugOut string, smOut string, dwarfDir string, outELF string, outCOFF string, outMachO string, emitMIR bool, emitLIR bool, emitX64 bool, x64Out string) error {
+func compileFile(filename string, cfg p.CfgSet, debugLexer bool, doParse bool, optLevel string, emitDebug bool, emitSrcMap bool, debugOut string, smOut string, dwarfDir string, outELF string, outCOFF string, outMachO string, emitMIR bool, emitLIR bool, emitX64 bool, x64Out string, cover bool, coverDir string) error {
 	// ファイル存在チェック.
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		return fmt.Errorf("file not found: %s", filename)
@@ -203,7 +224,7 @@ func compileFile(filename string, debugLexer bool, doParse bool, optLevel string
 		}
 
 		fmt.Println(strings.Repeat("=", 50))
-	} else if !doParse && optLevel == "" && !(emitDebug || emitSrcMap || emitMIR || emitLIR) {
+	} else if !doParse && optLevel == "" && !(emitDebug || emitSrcMap || emitMIR || emitLIR || cover) {
 		// 通常のコンパイル（現在は字句解析のみ）.
 		tokenCount := 0
 
@@ -226,6 +247,7 @@ func compileFile(filename string, debugLexer bool, doParse bool, optLevel string
 	} else {
 		// Parse phase (optional) and optional optimization via AST bridge.
 		pr := p.NewParser(lexer.NewWithFilename(string(source), filename), filename)
+		pr.SetCfg(cfg)
 
 		program, parseErrors := pr.Parse()
 		if len(parseErrors) > 0 {
@@ -254,7 +276,7 @@ func compileFile(filename string, debugLexer bool, doParse bool, optLevel string
 		}
 
 		// Convert parser AST -> internal AST -> HIR (once) for debug/codegen artifacts
-		if emitDebug || emitSrcMap || emitMIR || emitLIR || emitX64 || (x64Out != "") {
+		if emitDebug || emitSrcMap || emitMIR || emitLIR || emitX64 || (x64Out != "") || cover {
 			astProg, err := astbridge.FromParserProgram(program)
 			if err != nil {
 				return fmt.Errorf("ast bridge failed: %w", err)
@@ -264,8 +286,19 @@ func compileFile(filename string, debugLexer bool, doParse bool, optLevel string
 			hirProg, _ := conv.ConvertProgram(astProg)
 
 			// Optional MIR/LIR/x64 dumps using stub lowering pipeline
-			if emitMIR || emitLIR || emitX64 || (x64Out != "") {
-				mirMod := codegen.LowerToMIR(hirProg)
+			if emitMIR || emitLIR || emitX64 || (x64Out != "") || cover {
+				var (
+					mirMod   *mir.Module
+					counters int
+				)
+
+				if cover {
+					if mirMod, counters, err = lowerWithCoverage(filename, program, coverDir); err != nil {
+						return err
+					}
+				} else {
+					mirMod = codegen.LowerToMIR(hirProg)
+				}
 
 				if emitMIR {
 					fmt.Println("--- MIR ---")
@@ -282,6 +315,10 @@ func compileFile(filename string, debugLexer bool, doParse bool, optLevel string
 
 					if emitX64 || (x64Out != "") {
 						asm := codegen.EmitX64(lirMod)
+						if cover {
+							asm += codegen.EmitCoverageCounters(counters)
+						}
+
 						if x64Out != "" {
 							if err := os.WriteFile(x64Out, []byte(asm), 0o644); err != nil {
 								return fmt.Errorf("write x64 failed: %w", err)
@@ -447,3 +484,36 @@ func compileFile(filename string, debugLexer bool, doParse bool, optLevel string
 // 	}.
 // 	return result.
 // }.
+
+// lowerWithCoverage lowers program with a coverage counter per basic block
+// and writes the source map of the counters to dir, where the reports of
+// the profile the program writes at exit look for it.
+func lowerWithCoverage(filename string, program *p.Program, dir string) (*mir.Module, int, error) {
+	m, sm, skipped := coverage.Lower(filename, program)
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "coverage: %s:%d %s not instrumented: %s\n", s.File, s.Line, s.Function, s.Reason)
+	}
+
+	if m == nil {
+		return nil, 0, fmt.Errorf("coverage: no function of %s could be instrumented", filename)
+	}
+
+	if !codegen.InsertCoverageInit(m, len(sm.Blocks)) {
+		fmt.Fprintf(os.Stderr, "coverage: %s has no main function; counters are only written by programs that start in it\n", filename)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, 0, fmt.Errorf("coverage: %w", err)
+	}
+
+	js, err := debug.SerializeSourceMap(sm)
+	if err != nil {
+		return nil, 0, fmt.Errorf("coverage: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, coverage.SourceMapFile), append(js, '\n'), 0o644); err != nil {
+		return nil, 0, fmt.Errorf("coverage: %w", err)
+	}
+
+	return m, len(sm.Blocks), nil
+}
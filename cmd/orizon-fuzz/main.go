@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"flag"
@@ -16,6 +17,7 @@ import (
 	"github.com/orizon-lang/orizon/internal/lexer"
 	"github.com/orizon-lang/orizon/internal/parser"
 	"github.com/orizon-lang/orizon/internal/testrunner/fuzz"
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
 )
 
 func main() {
@@ -32,6 +34,9 @@ func main() {
 		lang        string
 		minimize    string
 		targetKind  string
+		mutatorKind string
+		grammarPath string
+		genDepth    int
 		covOut      string
 		covStats    bool
 		per         time.Duration
@@ -61,7 +66,10 @@ func main() {
 	flag.StringVar(&crashDir, "crash-dir", "", "optional directory to save each crashing input as a file")
 	flag.StringVar(&lang, "lang", "en", "message language (ja|en)")
 	flag.StringVar(&minimize, "minimize", "", "minimize a crashing input from file to --out (skips fuzz loop)")
-	flag.StringVar(&targetKind, "target", "noop", "target selector (noop|parser|lexer|astbridge|hir|astbridge-hir|custom)")
+	flag.StringVar(&targetKind, "target", "noop", "target selector (noop|parser|parser-lax|lexer|astbridge|hir|astbridge-hir|interp|custom)")
+	flag.StringVar(&mutatorKind, "mutator", "bytes", "input mutator (bytes|grammar|ast|typed)")
+	flag.StringVar(&grammarPath, "grammar", "", "grammar file for the grammar and ast mutators (default: built-in Orizon grammar)")
+	flag.IntVar(&genDepth, "gen-depth", fuzz.DefaultGenDepth, "nesting depth budget for generated programs")
 	flag.StringVar(&covOut, "covout", "", "write token-edge coverage to file during fuzzing")
 	flag.BoolVar(&covStats, "covstats", false, "print coverage summary (unique token-edge count)")
 	flag.DurationVar(&per, "per", 0, "per-input timeout (0=none)")
@@ -73,7 +81,7 @@ func main() {
 	flag.StringVar(&jsonStats, "json-stats", "", "write execution/crash stats as JSON to file")
 	flag.Float64Var(&intensity, "intensity", 0, "mutation intensity factor (1.0=default). 0=auto")
 	flag.BoolVar(&autotune, "autotune", false, "enable adaptive mutation intensity")
-	flag.StringVar(&covMode, "cov-mode", "weighted", "coverage mode (edge|weighted|trigram|both)")
+	flag.StringVar(&covMode, "cov-mode", "weighted", "coverage mode (edge|weighted|trigram|both|go); go needs a -cover -covermode=atomic build")
 	flag.Uint64Var(&maxExecs, "max-execs", 0, "stop after this many executions (0=unlimited)")
 	flag.BoolVar(&showVersion, "version", false, "show version information")
 	flag.BoolVar(&showHelp, "help", false, "show help information")
@@ -90,11 +98,21 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  parser      Fuzz the parser\n")
 		fmt.Fprintf(os.Stderr, "  astbridge   Fuzz AST bridge\n")
 		fmt.Fprintf(os.Stderr, "  hir         Fuzz HIR generation\n")
+		fmt.Fprintf(os.Stderr, "  interp      Parse, then run every function in the native test interpreter\n")
 		fmt.Fprintf(os.Stderr, "  custom      Custom target implementation\n")
+		fmt.Fprintf(os.Stderr, "\nMUTATORS:\n")
+		fmt.Fprintf(os.Stderr, "  bytes       Random byte edits (adaptive with -intensity or -autotune)\n")
+		fmt.Fprintf(os.Stderr, "  grammar     Derive a fresh program from the grammar for every input\n")
+		fmt.Fprintf(os.Stderr, "  ast         Swap, splice and regrow subtrees and change literals\n")
+		fmt.Fprintf(os.Stderr, "  typed       Generate well-typed programs that run to completion\n")
 		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
 		fmt.Fprintf(os.Stderr, "  %s -target parser -duration 1m     # Fuzz parser for 1 minute\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -target lexer -corpus corp.txt  # Fuzz lexer with corpus\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -p 4 -autotune -stats           # Parallel fuzzing with stats\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -target parser -mutator ast     # Structured fuzzing of the parser\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -target interp -mutator typed   # Run generated well-typed programs\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\nGo coverage feedback (build with -cover -covermode=atomic -coverpkg=./cmd/orizon-fuzz,./internal/...):\n")
+		fmt.Fprintf(os.Stderr, "  %s -target parser -cov-mode go -corpus-out corp/ -covstats\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -213,6 +231,10 @@ func main() {
 
 			return nil
 		}
+	case "interp":
+		// Syntax errors are crashes, since the structured mutators only
+		// produce valid programs; run-time failures of the program are not.
+		target = interpTarget(per)
 	case "astbridge-hir":
 		// Parse -> AST bridge optimize -> back to parser -> HIR transform -> HIR validate.
 		target = func(data []byte) error {
@@ -248,6 +270,19 @@ func main() {
 		}
 	}
 
+	// Go coverage counters of an instrumented build replace the token
+	// approximations and steer the fuzzing loop.
+	var gocov *fuzz.GoCoverage
+
+	if strings.EqualFold(covMode, "go") {
+		c, err := fuzz.NewGoCoverage()
+		if err != nil {
+			fatal(L, "--cov-mode go needs a binary built with -cover -covermode=atomic: ", err)
+		}
+
+		gocov = c
+	}
+
 	if minimize != "" {
 		if outPath == "" {
 			fatal(L, "--minimize requires --out destination")
@@ -258,7 +293,7 @@ func main() {
 			fatal(L, "failed to read input: ", err)
 		}
 
-		min := fuzz.Minimize(seed, b, target, dur)
+		min := fuzz.MinimizeCoverage(seed, b, target, gocov, dur)
 		if err := os.WriteFile(outPath, min, 0o644); err != nil {
 			fatal(L, "failed to write output: ", err)
 		}
@@ -332,19 +367,19 @@ func main() {
 
 	covSeen := make(map[uint64]struct{})
 
-	if covOut != "" || covStats || corpusOut != "" {
-		var logf io.Writer
-
-		if covOut != "" {
-			f, err := os.Create(covOut)
-			if err != nil {
-				fatal(L, "failed to open covout: ", err)
-			}
+	var logf io.Writer
 
-			defer f.Close()
-			logf = f
+	if covOut != "" {
+		f, err := os.Create(covOut)
+		if err != nil {
+			fatal(L, "failed to open covout: ", err)
 		}
 
+		defer f.Close()
+		logf = f
+	}
+
+	if gocov == nil && (covOut != "" || covStats || corpusOut != "") {
 		wrapped = func(data []byte) error {
 			edges := fuzz.ComputeCoverage(covMode, string(data))
 
@@ -377,15 +412,7 @@ func main() {
 				covMu.Unlock()
 
 				if grew {
-					// Deduplicate by input hash and persist once.
-					sum := sha256.Sum256(data)
-					hexname := hex.EncodeToString(sum[:]) + ".bin"
-					_ = os.MkdirAll(corpusOut, 0o755)
-					path := filepath.Join(corpusOut, hexname)
-
-					if _, err := os.Stat(path); err != nil {
-						_ = os.WriteFile(path, data, 0o644)
-					}
+					saveInteresting(corpusOut, data)
 				}
 			} else if covStats {
 				covMu.Lock()
@@ -427,7 +454,7 @@ func main() {
 			// On crash, optionally minimize and persist minimized input.
 			if err != nil && minOnCrash {
 				_ = os.MkdirAll(minDir, 0o755)
-				min := fuzz.Minimize(seed, data, baseTarget, minBudget)
+				min := fuzz.MinimizeCoverage(seed, data, baseTarget, gocov, minBudget)
 				name := time.Now().Format("20060102_150405.000000000") + ".min"
 				_ = os.WriteFile(filepath.Join(minDir, name), min, 0o644)
 			}
@@ -458,11 +485,38 @@ func main() {
 		crashWriter = &crashFileWriter{base: w, dir: crashDir}
 	}
 
+	mut, err := selectMutator(mutatorKind, grammarPath, genDepth, corpus)
+	if err != nil {
+		fatal(L, "invalid --mutator: ", err)
+	}
+
+	if mut != nil {
+		mut = fuzz.BoundedMutator(mut, max)
+	}
+
+	if gocov != nil {
+		opts.Coverage = gocov
+		opts.OnNewCoverage = func(data []byte, fresh []uint64) {
+			if corpusOut != "" {
+				saveInteresting(corpusOut, data)
+			}
+
+			if logf != nil {
+				for _, f := range fresh {
+					fmt.Fprintf(logf, "%016x\n", f)
+				}
+			}
+		}
+	}
+
 	start := time.Now()
-	stats := fuzz.RunWithStats(opts, corpus, effective, nil, crashWriter)
+	stats := fuzz.RunWithStats(opts, corpus, effective, mut, crashWriter)
 	elapsed := time.Since(start)
 
-	if covStats {
+	switch {
+	case covStats && gocov != nil:
+		fmt.Println(L.gocov(gocov.Features(), stats.NewCoverage))
+	case covStats:
 		covMu.Lock()
 		n := len(covSeen)
 		covMu.Unlock()
@@ -476,15 +530,103 @@ func main() {
 	}
 
 	if jsonStats != "" {
-		_ = os.WriteFile(jsonStats, []byte(fmt.Sprintf("{\"executions\":%d,\"crashes\":%d,\"duration_ms\":%d}\n", stats.Executions, stats.Crashes, elapsed.Milliseconds())), 0o644)
+		_ = os.WriteFile(jsonStats, []byte(fmt.Sprintf("{\"executions\":%d,\"crashes\":%d,\"new_coverage\":%d,\"duration_ms\":%d}\n", stats.Executions, stats.Crashes, stats.NewCoverage, elapsed.Milliseconds())), 0o644)
 	}
 
 	println(L.done())
 }
 
+// selectMutator returns the mutator named by kind. It returns nil for the
+// byte mutators, which the fuzzing loop picks itself.
+func selectMutator(kind, grammarPath string, depth int, corpus []fuzz.CorpusEntry) (fuzz.Mutator, error) {
+	kind = strings.ToLower(kind)
+	if kind == "" || kind == "bytes" {
+		return nil, nil
+	}
+
+	g := fuzz.OrizonGrammar()
+
+	if grammarPath != "" {
+		src, err := os.ReadFile(grammarPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if g, err = fuzz.ParseGrammar(string(src)); err != nil {
+			return nil, fmt.Errorf("%s: %w", grammarPath, err)
+		}
+	}
+
+	switch kind {
+	case "grammar":
+		return fuzz.GrammarMutator(g, depth), nil
+	case "ast":
+		return fuzz.ASTMutator(corpus, g, depth), nil
+	case "typed":
+		return fuzz.TypedMutator(depth), nil
+	default:
+		return nil, fmt.Errorf("unknown mutator %q", kind)
+	}
+}
+
+// interpTarget parses the input and runs each of its functions in the
+// native test interpreter. Only syntax errors and panics of the front end
+// or the interpreter are crashes; the program failing at run time, calling
+// a function with the wrong arguments or running out of budget is not.
+func interpTarget(budget time.Duration) fuzz.Target {
+	if budget <= 0 {
+		budget = 100 * time.Millisecond
+	}
+
+	return func(data []byte) error {
+		src := string(data)
+		ps := parser.NewParser(lexer.NewWithFilename(src, "fuzz_interp.oriz"), "fuzz_interp.oriz")
+
+		if _, errs := ps.Parse(); len(errs) > 0 {
+			return fmt.Errorf("parse failed: %w", errs[0])
+		}
+
+		pkg, err := native.LoadSource("fuzz_interp.oriz", src)
+		if err != nil {
+			// redeclared functions and the like.
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), budget)
+		defer cancel()
+
+		in, err := pkg.NewInterpreter(ctx, io.Discard)
+		if err != nil {
+			return nil
+		}
+
+		for _, name := range pkg.FunctionNames() {
+			if ctx.Err() != nil {
+				break
+			}
+
+			_, _ = in.Call(name)
+		}
+
+		return nil
+	}
+}
+
+// saveInteresting stores data in dir under its hash, once.
+func saveInteresting(dir string, data []byte) {
+	sum := sha256.Sum256(data)
+	_ = os.MkdirAll(dir, 0o755)
+	path := filepath.Join(dir, hex.EncodeToString(sum[:])+".bin")
+
+	if _, err := os.Stat(path); err != nil {
+		_ = os.WriteFile(path, data, 0o644)
+	}
+}
+
 type locale struct {
-	done func() string
-	cov  func(n int) string
+	done  func() string
+	cov   func(n int) string
+	gocov func(features int, inputs uint64) string
 }
 
 // crashFileWriter writes crash lines to an underlying writer and also extracts.
@@ -552,11 +694,17 @@ func getLocale(lang string) locale {
 		return locale{
 			done: func() string { return "ファズ終了" },
 			cov:  func(n int) string { return fmt.Sprintf("カバレッジユニークエッジ数: %d", n) },
+			gocov: func(f int, n uint64) string {
+				return fmt.Sprintf("Goカバレッジ特徴数: %d (新規カバレッジ入力: %d)", f, n)
+			},
 		}
 	default:
 		return locale{
 			done: func() string { return "Fuzzing finished" },
 			cov:  func(n int) string { return fmt.Sprintf("Coverage unique edges: %d", n) },
+			gocov: func(f int, n uint64) string {
+				return fmt.Sprintf("Go coverage features: %d (inputs with new coverage: %d)", f, n)
+			},
 		}
 	}
 }
@@ -21,13 +21,13 @@ func getLocale(lang string) locale {
 		return locale{
 			okGenerated: func(dest string) string { return "モックを生成しました: " + dest },
 			errMsg:      func(msg string) string { return "エラー: " + msg },
-			usage:       "使用方法: orizon-mockgen -interface <名前> [-pkg <生成パッケージ>] [-out <出力先>] [-source <パターン,カンマ区切り>] [-tags <ビルドタグ,カンマ区切り>] [-lang ja|en]",
+			usage:       "使用方法: orizon-mockgen -interface <名前> [-pkg <生成パッケージ>] [-out <出力先>] [-source <パターン,カンマ区切り>] [-tags <ビルドタグ,カンマ区切り>] [-lang ja|en]\n       orizon-mockgen -trait <名前> [-mock <構造体名>] [-out <出力先>] <ファイル.oriz>...",
 		}
 	default:
 		return locale{
 			okGenerated: func(dest string) string { return "Mock generated: " + dest },
 			errMsg:      func(msg string) string { return "Error: " + msg },
-			usage:       "Usage: orizon-mockgen -interface <name> [-pkg <generated package>] [-out <destination>] [-source <patterns,comma-separated>] [-tags <build-tags,comma-separated>] [-lang ja|en]",
+			usage:       "Usage: orizon-mockgen -interface <name> [-pkg <generated package>] [-out <destination>] [-source <patterns,comma-separated>] [-tags <build-tags,comma-separated>] [-lang ja|en]\n       orizon-mockgen -trait <name> [-mock <struct name>] [-out <destination>] <file.oriz>...",
 		}
 	}
 }
@@ -40,20 +40,42 @@ func main() {
 		sources string
 		tags    string
 		lang    string
+		trait   string
+		mock    string
 	)
 
-	flag.StringVar(&iface, "interface", "", "interface name to mock (required)")
+	flag.StringVar(&iface, "interface", "", "Go interface name to mock")
 	flag.StringVar(&genPkg, "pkg", "", "generated package name (default: <src pkg>mock)")
 	flag.StringVar(&out, "out", "", "destination file path (writes to file when set)")
 	flag.StringVar(&sources, "source", "./...", "source package patterns (comma-separated)")
 	flag.StringVar(&tags, "tags", "", "build tags (comma-separated)")
 	flag.StringVar(&lang, "lang", "en", "message language (ja|en)")
+	flag.StringVar(&trait, "trait", "", "Orizon trait name to mock; sources are the .oriz files given as arguments")
+	flag.StringVar(&mock, "mock", "", "generated mock struct name for -trait (default: <trait>Mock)")
 	flag.Parse()
 
 	L := getLocale(lang)
 
+	if strings.TrimSpace(trait) != "" {
+		if flag.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, L.errMsg("-trait needs at least one .oriz file"))
+			fmt.Fprintln(os.Stderr, L.usage)
+			os.Exit(2)
+		}
+
+		code, err := mockgen.GenerateTrait(mockgen.TraitOptions{
+			TraitName:   trait,
+			MockName:    mock,
+			Destination: out,
+			Sources:     flag.Args(),
+		})
+		report(L, code, out, err)
+
+		return
+	}
+
 	if strings.TrimSpace(iface) == "" {
-		fmt.Fprintln(os.Stderr, L.errMsg("-interface is required"))
+		fmt.Fprintln(os.Stderr, L.errMsg("-interface or -trait is required"))
 		fmt.Fprintln(os.Stderr, L.usage)
 		os.Exit(2)
 	}
@@ -83,6 +105,10 @@ func main() {
 		SourcePatterns: src,
 		BuildTags:      tagSlice,
 	})
+	report(L, code, out, err)
+}
+
+func report(L locale, code, out string, err error) {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, L.errMsg(err.Error()))
 		os.Exit(1)
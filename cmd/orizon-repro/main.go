@@ -5,11 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/orizon-lang/orizon/internal/lexer"
 	"github.com/orizon-lang/orizon/internal/parser"
+	"github.com/orizon-lang/orizon/internal/testrunner/concurrency"
 	"github.com/orizon-lang/orizon/internal/testrunner/fuzz"
 )
 
@@ -23,6 +26,7 @@ func main() {
 		lang       string
 		dur        time.Duration
 		targetKind string
+		schedule   string
 	)
 
 	flag.StringVar(&in, "in", "", "input file to reproduce")
@@ -33,10 +37,17 @@ func main() {
 	flag.StringVar(&lang, "lang", "en", "message language (ja|en)")
 	flag.DurationVar(&dur, "budget", 3*time.Second, "minimization time budget")
 	flag.StringVar(&targetKind, "target", "parser", "target selector (noop|parser|lexer|astbridge|hir|astbridge-hir)")
+	flag.StringVar(&schedule, "schedule", "", "schedule trace (JSON) of a failed concurrency test to replay")
 	flag.Parse()
 
 	L := getLocale(lang)
 
+	if schedule != "" {
+		replaySchedule(L, schedule)
+
+		return
+	}
+
 	var b []byte
 
 	if logPath != "" {
@@ -252,6 +263,40 @@ func main() {
 	fmt.Println(L.ok())
 }
 
+// replaySchedule reruns the test that recorded a schedule trace with the
+// trace in concurrency.ScheduleEnv, so its explorer follows the same
+// interleaving.
+func replaySchedule(L locale, path string) {
+	tr, err := concurrency.ReadTrace(path)
+	if err != nil {
+		fatal(L, "failed to read schedule: ", err)
+	}
+
+	if tr.Test == "" {
+		fatal(L, "schedule has no test name: ", path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		fatal(L, "failed to resolve schedule: ", err)
+	}
+
+	cmd := exec.Command("go", "test", "-count=1", "-run", "^"+tr.Test+"$", ".")
+	cmd.Dir = tr.Dir
+	cmd.Env = append(os.Environ(), concurrency.ScheduleEnv+"="+abs)
+
+	outb, err := cmd.CombinedOutput()
+	os.Stdout.Write(outb)
+
+	if err != nil {
+		fmt.Println(L.fail(fmt.Sprintf("%s: %s", tr.Test, tr.Error)))
+
+		return
+	}
+
+	fmt.Println(L.ok())
+}
+
 type locale struct {
 	ok      func() string
 	fail    func(msg string) string
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/orizon-lang/orizon/internal/cli"
+	"github.com/orizon-lang/orizon/internal/testrunner/coverage"
 )
 
 type testAttempt struct {
@@ -76,6 +77,8 @@ func main() {
 		outPath     string
 		title       string
 		coverPath   string
+		orizonCover string
+		coverOut    string
 		showVersion bool
 		showHelp    bool
 		jsonOutput  bool
@@ -86,6 +89,8 @@ func main() {
 	flag.StringVar(&outPath, "out", "", "optional output markdown path")
 	flag.StringVar(&title, "title", "Orizon Project Summary", "summary title")
 	flag.StringVar(&coverPath, "cover", "", "optional path to coverage summary (cover.txt)")
+	flag.StringVar(&orizonCover, "coverage", "", "comma-separated Orizon coverage reports (LCOV, Cobertura XML or orizon.cover profiles) to merge")
+	flag.StringVar(&coverOut, "coverage-out", "", "with --coverage, write the merged report here (.xml: Cobertura, .html: HTML, else LCOV)")
 	flag.BoolVar(&showVersion, "version", false, "show version information")
 	flag.BoolVar(&showHelp, "help", false, "show help information")
 	flag.BoolVar(&jsonOutput, "json", false, "output version in JSON format")
@@ -99,6 +104,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s --junit-summary tests.json         # Test summary only\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --cover coverage.txt --out sum.md  # Coverage to markdown\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --stats fuzz1.json,fuzz2.json     # Multiple fuzz results\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --coverage a/lcov.info,b/cobertura.xml --coverage-out all.info  # Merge Orizon coverage\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -203,6 +209,20 @@ func main() {
 		}
 	}
 
+	if strings.TrimSpace(orizonCover) != "" {
+		rep := mergeCoverage(orizonCover)
+		if len(rep.Files) > 0 {
+			writeOrizonCoverage(&sb, rep)
+		}
+
+		if coverOut != "" {
+			if err := writeCoverageReport(rep, coverOut); err != nil {
+				fmt.Fprintf(os.Stderr, "coverage: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
 	out := sb.String()
 
 	if outPath != "" {
@@ -212,3 +232,52 @@ func main() {
 
 	fmt.Print(out)
 }
+
+// mergeCoverage reads and merges the comma-separated coverage reports in
+// list. Reports that cannot be read are skipped with a warning.
+func mergeCoverage(list string) *coverage.Report {
+	rep := coverage.NewReport()
+
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		r, err := coverage.ReadReport(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping coverage report %s: %v\n", p, err)
+
+			continue
+		}
+
+		rep.Merge(r)
+	}
+
+	return rep
+}
+
+func writeOrizonCoverage(sb *strings.Builder, rep *coverage.Report) {
+	sb.WriteString("#### Orizon Coverage\n")
+	fmt.Fprintf(sb, "- total: %s\n", rep.Totals())
+
+	for _, path := range rep.Paths() {
+		t := rep.Files[path].Totals()
+		fmt.Fprintf(sb, "- %s: lines %.1f%% (%d/%d), branches %d/%d\n", path, 100*t.LineRate(), t.LinesHit, t.Lines, t.BranchesHit, t.Branches)
+	}
+
+	sb.WriteString("\n")
+}
+
+// writeCoverageReport writes rep in the format chosen by the extension of
+// path.
+func writeCoverageReport(rep *coverage.Report, path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return rep.WriteCobertura(path)
+	case ".html", ".htm":
+		return rep.WriteHTML(path)
+	default:
+		return rep.WriteLCOV(path)
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/orizon-lang/orizon/internal/cli"
 	"github.com/orizon-lang/orizon/internal/testrunner"
+	"github.com/orizon-lang/orizon/internal/testrunner/mutate"
+	"github.com/orizon-lang/orizon/internal/testrunner/snapshot"
 )
 
 func main() {
@@ -40,6 +42,16 @@ func main() {
 		showVersion      bool
 		showHelp         bool
 		jsonOutput       bool
+		nativeTests      bool
+		benchPat         string
+		benchTime        time.Duration
+		mutateRun        bool
+		mutateJSON       string
+		mutateHTML       string
+		mutateTimeout    time.Duration
+		review           bool
+		cover            bool
+		coverDir         string
 	)
 
 	flag.StringVar(&pkgs, "packages", "./...", "comma-separated package patterns (e.g. ./...,./internal/...)")
@@ -68,6 +80,17 @@ func main() {
 	flag.BoolVar(&showVersion, "version", false, "show version information")
 	flag.BoolVar(&showHelp, "help", false, "show help information")
 	flag.BoolVar(&jsonOutput, "json-format", false, "output version in JSON format")
+	flag.BoolVar(&nativeTests, "native", false, "run #[test] functions of .oriz files instead of go test")
+	flag.StringVar(&benchPat, "bench", "", "with --native, regex to select #[bench] functions to run")
+	flag.DurationVar(&benchTime, "benchtime", time.Second, "with --native, minimum run time of each benchmark")
+	flag.BoolVar(&mutateRun, "mutate", false, "run mutation testing instead of the tests (Orizon sources with --native, Go packages otherwise)")
+	flag.StringVar(&mutateJSON, "mutate-json", "", "with --mutate, path of the JSON mutation report")
+	flag.StringVar(&mutateHTML, "mutate-html", "", "with --mutate, path of the HTML mutation report")
+	flag.DurationVar(&mutateTimeout, "mutate-timeout", mutate.DefaultTimeout, "with --mutate, upper bound for the tests of one mutant")
+	flag.BoolVar(&review, "review", false, "interactively accept or reject pending .new snapshots under the given directories (default .)")
+
+	flag.BoolVar(&cover, "cover", false, "measure line and branch coverage of Orizon code (implies --native)")
+	flag.StringVar(&coverDir, "cover-dir", "coverage", "with --cover, directory for the LCOV, Cobertura and HTML reports")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS]\n\n", os.Args[0])
@@ -79,6 +102,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -packages ./internal   # Run tests in internal packages\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -retries 3 -race       # Run with race detection and 3 retries\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -json -junit out.xml   # Output JSON and JUnit XML\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -native -bench .      # Run Orizon #[test] and #[bench] functions\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -native -mutate -mutate-html mutants.html  # Report surviving mutants\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -review test/golden       # Review changed snapshots and goldens\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -cover -cover-dir cov     # Write Orizon coverage to cov/lcov.info, cobertura.xml, index.html\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -97,6 +124,63 @@ func main() {
 	env := splitNonEmpty(envList, ";")
 	extras := splitNonEmpty(extra, " ")
 
+	if review {
+		roots := flag.Args()
+		if len(roots) == 0 {
+			roots = []string{"."}
+		}
+
+		pending, err := snapshot.FindPending(roots...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("no pending snapshots")
+
+			return
+		}
+
+		res, err := snapshot.Review(pending, os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("\naccepted %d, rejected %d, still pending %d\n", len(res.Accepted), len(res.Rejected), len(res.Skipped))
+
+		return
+	}
+
+	// Coverage is measured on Orizon code, which only runs natively.
+	if cover {
+		nativeTests = true
+	} else {
+		coverDir = ""
+	}
+
+	if mutateRun {
+		rep, err := mutate.Run(context.Background(), mutate.Options{
+			Packages: pkgsArr,
+			Native:   nativeTests,
+			Parallel: par,
+			Timeout:  mutateTimeout,
+			JSONPath: mutateJSON,
+			HTMLPath: mutateHTML,
+		}, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if len(rep.Survivors()) > 0 {
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	runner := testrunner.New(testrunner.Options{
 		Packages:         pkgsArr,
 		RunPattern:       runPat,
@@ -121,6 +205,10 @@ func main() {
 		SnapshotDir:      snapshotDir,
 		CleanupSnapshots: cleanupSnapshots,
 		GoldenTests:      goldenTests,
+		Native:           nativeTests,
+		BenchPattern:     benchPat,
+		BenchTime:        benchTime,
+		CoverDir:         coverDir,
 	})
 	ctx := context.Background()
 
@@ -3,11 +3,16 @@
 package commands
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"os"
+
+	semver "github.com/Masterminds/semver/v3"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
 )
 
 // AddCommand handles dependency addition operations.
@@ -21,7 +26,7 @@ func NewAddCommand() *AddCommand {
 	return &AddCommand{
 		BaseCommand: NewBaseCommand(
 			"Add a dependency to the package manifest",
-			"usage: orizon pkg add --dep name@constraint",
+			"usage: orizon pkg add --dep name@constraint [--optional]",
 		),
 	}
 }
@@ -30,13 +35,14 @@ func NewAddCommand() *AddCommand {
 func (c *AddCommand) Execute(ctx types.RegistryContext, args []string) error {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
 	dep := fs.String("dep", "", "dependency in form name@constraint (e.g., foo@^1.2.0)")
+	optional := fs.Bool("optional", false, "add an optional dependency enabled by a feature of the same name")
 
 	if err := fs.Parse(args); err != nil {
 		return fmt.Errorf("failed to parse add flags: %w", err)
 	}
 
 	if *dep == "" {
-		return fmt.Errorf("usage: orizon pkg add --dep name@constraint")
+		return fmt.Errorf("usage: orizon pkg add --dep name@constraint [--optional]")
 	}
 
 	// Read current manifest
@@ -48,11 +54,23 @@ func (c *AddCommand) Execute(ctx types.RegistryContext, args []string) error {
 	// Parse dependency specification
 	name, constraint := utils.SplitAt(*dep)
 
-	// Add dependency to manifest
-	if manifest.Dependencies == nil {
-		manifest.Dependencies = make(map[string]string)
+	// Add dependency to manifest; an optional one gets a feature that enables it
+	if *optional {
+		if manifest.OptionalDependencies == nil {
+			manifest.OptionalDependencies = make(map[string]string)
+		}
+		if manifest.Features == nil {
+			manifest.Features = make(map[string][]string)
+		}
+		delete(manifest.Dependencies, name)
+		manifest.OptionalDependencies[name] = constraint
+		if _, ok := manifest.Features[name]; !ok {
+			manifest.Features[name] = []string{"dep:" + name}
+		}
+	} else {
+		delete(manifest.OptionalDependencies, name)
+		manifest.Dependencies[name] = constraint
 	}
-	manifest.Dependencies[name] = constraint
 
 	// Write updated manifest
 	if err := utils.WriteManifest(manifest); err != nil {
@@ -60,5 +78,46 @@ func (c *AddCommand) Execute(ctx types.RegistryContext, args []string) error {
 	}
 
 	fmt.Printf("added %s -> %s\n", name, constraint)
+	warnReleaseStatus(ctx.Registry, name, constraint)
 	return nil
 }
+
+// warnReleaseStatus prints a warning when the added package is deprecated or
+// when every version matching the constraint has been yanked.
+// Registry errors are ignored; resolution will report them.
+func warnReleaseStatus(reg packagemanager.Registry, name, constraint string) {
+	if reg == nil || packagemanager.IsPathDependency(constraint) {
+		return
+	}
+
+	manifests, err := reg.List(context.Background(), packagemanager.PackageID(name))
+	if err != nil || len(manifests) == 0 {
+		return
+	}
+
+	if msg := manifests[len(manifests)-1].Deprecated; msg != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s is deprecated: %s\n", name, msg)
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return
+	}
+
+	matched, yanked := 0, 0
+	for _, mf := range manifests {
+		sv, err := semver.NewVersion(string(mf.Version))
+		if err != nil || !c.Check(sv) {
+			continue
+		}
+
+		matched++
+		if mf.Yanked {
+			yanked++
+		}
+	}
+
+	if matched > 0 && matched == yanked {
+		fmt.Fprintf(os.Stderr, "warning: every version of %s matching %s has been yanked\n", name, constraint)
+	}
+}
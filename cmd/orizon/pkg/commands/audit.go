@@ -4,13 +4,23 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
 )
 
 // AuditCommand handles security audit operations.
-// It performs vulnerability scanning and security checks on dependencies.
+// It matches the locked dependencies against the registry's signed advisory database.
 type AuditCommand struct {
 	*BaseCommand
 }
@@ -19,37 +29,156 @@ type AuditCommand struct {
 func NewAuditCommand() *AuditCommand {
 	return &AuditCommand{
 		BaseCommand: NewBaseCommand(
-			"Perform security audit on dependencies",
-			"usage: orizon pkg audit",
+			"Check locked dependencies against the advisory database",
+			"usage: orizon pkg audit [--trust <root-cert.json>] [--fail-on <low|medium|high|critical>]",
 		),
 	}
 }
 
 // Execute implements the CommandHandler interface for audit operations.
 func (c *AuditCommand) Execute(ctx types.RegistryContext, args []string) error {
-	// List all manifests in registry for audit
-	manifests, err := ctx.Registry.All(context.Background())
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	trust := fs.String("trust", os.Getenv("ORIZON_ADVISORY_ROOT"), "root certificate (JSON) trusted to sign advisories")
+	failOn := fs.String("fail-on", "high", "exit non-zero for findings at or above this severity")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse audit flags: %w", err)
+	}
+
+	threshold, err := packagemanager.ParseSeverity(*failOn)
 	if err != nil {
-		return fmt.Errorf("failed to list packages for audit: %w", err)
+		return fmt.Errorf("invalid --fail-on: %w", err)
 	}
 
-	// Create advisory scanner (placeholder for demo)
-	// scanner := packagemanager.NewInMemoryAdvisoryScanner()
+	if strings.TrimSpace(*trust) == "" {
+		return fmt.Errorf("no advisory trust root configured: pass --trust or set ORIZON_ADVISORY_ROOT")
+	}
 
-	// Perform audit (this is a placeholder implementation)
-	// In a real implementation, this would check for known vulnerabilities,
-	// license issues, and other security concerns
-	auditCount := len(manifests)
+	trustStore, err := loadTrustRoot(*trust)
+	if err != nil {
+		return err
+	}
 
-	// Report results
-	fmt.Printf("audited %d packages: no advisories configured\n", auditCount)
+	manifest, err := utils.ReadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	lockfile, err := utils.ReadLockfile()
+	if err != nil {
+		return err
+	}
 
-	// Note: This is a demo implementation. A real audit system would:
-	// 1. Check for known vulnerabilities in CVE databases
-	// 2. Validate package signatures
-	// 3. Check for license compliance issues
-	// 4. Analyze dependency chains for security risks
-	// 5. Report detailed findings with severity levels
+	cachePath := filepath.Join(utils.DefaultCachePath, "advisories.json")
+
+	db, source, err := packagemanager.LoadAdvisories(context.Background(), ctx.Registry, trustStore, cachePath)
+	if err != nil {
+		return fmt.Errorf("failed to load advisories: %w", err)
+	}
+
+	if source == packagemanager.AdvisoryFromCache {
+		fmt.Fprintf(os.Stderr, "warning: registry unavailable, using cached advisories from %s\n", db.Generated.Format("2006-01-02 15:04"))
+	}
+
+	roots := make([]packagemanager.PackageID, 0, len(manifest.Dependencies))
+	for _, name := range utils.GetRootDependencies(manifest) {
+		roots = append(roots, packagemanager.PackageID(name))
+	}
+
+	findings := packagemanager.AuditLockfile(packagemanager.Lockfile{Entries: lockfile.Entries}, roots, db)
+	if len(findings) == 0 {
+		fmt.Printf("audited %d packages against %d advisories: no known vulnerabilities\n", len(lockfile.Entries), len(db.Advisories))
+		return nil
+	}
+
+	failing := 0
+	updates := make(map[string]bool)
+
+	for _, f := range findings {
+		if f.Advisory.Severity >= threshold {
+			failing++
+		}
+
+		fmt.Printf("%s [%s] %s@%s: %s\n", f.Advisory.ID, f.Advisory.Severity, f.Package, f.Version, f.Advisory.Title)
+		fmt.Printf("  path: %s\n", joinPath(f.Path))
+
+		if f.FixedIn == "" {
+			fmt.Println("  fix:  no patched version available")
+			continue
+		}
+
+		root := string(f.Path[0])
+		updates[root] = true
+
+		note := ""
+		if string(f.Package) == root && !constraintAllows(manifest.Dependencies[root], f.FixedIn) {
+			note = fmt.Sprintf(" (widen the %q constraint in %s first)", manifest.Dependencies[root], utils.DefaultManifestPath)
+		}
+
+		fmt.Printf("  fix:  upgrade %s to %s%s\n", f.Package, f.FixedIn, note)
+	}
+
+	if len(updates) > 0 {
+		names := make([]string, 0, len(updates))
+		for name := range updates {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		fmt.Printf("\nsuggested: orizon pkg update --dep %s\n", strings.Join(names, ","))
+	}
+
+	fmt.Printf("%d vulnerabilities found in %d packages\n", len(findings), len(lockfile.Entries))
+
+	if failing > 0 {
+		return fmt.Errorf("%d advisories at or above %s severity", failing, threshold)
+	}
 
 	return nil
 }
+
+// loadTrustRoot reads a root certificate and returns a trust store containing it.
+func loadTrustRoot(path string) (*packagemanager.TrustStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust root: %w", err)
+	}
+
+	var cert packagemanager.Certificate
+	if err := json.Unmarshal(data, &cert); err != nil {
+		return nil, fmt.Errorf("failed to parse trust root: %w", err)
+	}
+
+	if err := packagemanager.VerifyCertificate(cert, cert.PublicKey); err != nil {
+		return nil, fmt.Errorf("invalid trust root: %w", err)
+	}
+
+	ts := packagemanager.NewTrustStore()
+	ts.AddRoot(cert.PublicKey)
+
+	return ts, nil
+}
+
+func joinPath(path []packagemanager.PackageID) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = string(p)
+	}
+
+	return strings.Join(parts, " -> ")
+}
+
+// constraintAllows reports whether version v satisfies the manifest constraint.
+func constraintAllows(constraint string, v packagemanager.Version) bool {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+
+	sv, err := semver.NewVersion(string(v))
+	if err != nil {
+		return false
+	}
+
+	return c.Check(sv)
+}
@@ -0,0 +1,63 @@
+// Package commands provides the deprecate command implementation for package management.
+// This handles attaching deprecation notices to published packages.
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+)
+
+// DeprecateCommand handles setting and clearing package deprecation notices.
+// The notice is shown by add and outdated.
+type DeprecateCommand struct {
+	*BaseCommand
+}
+
+// NewDeprecateCommand creates a new deprecate command handler.
+func NewDeprecateCommand() *DeprecateCommand {
+	return &DeprecateCommand{
+		BaseCommand: NewBaseCommand(
+			"Mark a package as deprecated",
+			"usage: orizon pkg deprecate --name <pkg> [--message <text>]",
+		),
+	}
+}
+
+// Execute implements the CommandHandler interface for deprecate operations.
+func (c *DeprecateCommand) Execute(ctx types.RegistryContext, args []string) error {
+	fs := flag.NewFlagSet("deprecate", flag.ExitOnError)
+	name := fs.String("name", "", "package name")
+	message := fs.String("message", "", "deprecation notice; empty clears it")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse deprecate flags: %w", err)
+	}
+
+	if strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	admin, err := utils.PackageAdmin(ctx.Registry)
+	if err != nil {
+		return err
+	}
+
+	msg := strings.TrimSpace(*message)
+	if err := admin.Deprecate(context.Background(), packagemanager.PackageID(*name), msg); err != nil {
+		return fmt.Errorf("failed to deprecate %s: %w", *name, err)
+	}
+
+	if msg == "" {
+		fmt.Printf("cleared deprecation of %s\n", *name)
+		return nil
+	}
+
+	fmt.Printf("deprecated %s: %s\n", *name, msg)
+	return nil
+}
@@ -57,6 +57,21 @@ func (c *GraphCommand) Execute(ctx types.RegistryContext, args []string) error {
 		return fmt.Errorf("failed to build dependency graph: %w", err)
 	}
 
+	// Workspace members are the roots of a workspace graph
+	roots := utils.GetRootDependencies(manifest)
+
+	ws, err := utils.LoadWorkspace(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to load workspace: %w", err)
+	}
+
+	if ws != nil {
+		roots = nil
+		for _, key := range utils.AddWorkspaceMembers(graph, ws, resolved) {
+			roots = append(roots, key[:strings.IndexByte(key, '@')])
+		}
+	}
+
 	var output strings.Builder
 
 	if *dotFormat {
@@ -64,7 +79,6 @@ func (c *GraphCommand) Execute(ctx types.RegistryContext, args []string) error {
 		output.WriteString("digraph deps {\n")
 		output.WriteString("  rankdir=LR;\n")
 
-		roots := utils.GetRootDependencies(manifest)
 		rootSet := make(map[string]bool)
 		for _, root := range roots {
 			rootSet[root] = true
@@ -0,0 +1,112 @@
+// Package commands provides the licenses command implementation for package management.
+// This handles checking the licenses of the resolved dependency graph against a policy.
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+)
+
+// DefaultLicensePolicyPath is the license policy used when --policy is not given.
+const DefaultLicensePolicyPath = "license-policy.json"
+
+// LicensesCommand handles license compliance checks.
+// It lists the license of every resolved package and evaluates them against an allow/deny policy.
+type LicensesCommand struct {
+	*BaseCommand
+}
+
+// NewLicensesCommand creates a new licenses command handler.
+func NewLicensesCommand() *LicensesCommand {
+	return &LicensesCommand{
+		BaseCommand: NewBaseCommand(
+			"Check dependency licenses against a policy",
+			"usage: orizon pkg licenses [--policy <license-policy.json>]",
+		),
+	}
+}
+
+// Execute implements the CommandHandler interface for licenses operations.
+func (c *LicensesCommand) Execute(ctx types.RegistryContext, args []string) error {
+	fs := flag.NewFlagSet("licenses", flag.ExitOnError)
+	policyPath := fs.String("policy", os.Getenv("ORIZON_LICENSE_POLICY"), "JSON policy with allow, deny, allow_unknown and ignore lists")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse licenses flags: %w", err)
+	}
+
+	// Fall back to the policy next to the manifest; without one every declared license passes
+	path := strings.TrimSpace(*policyPath)
+	if path == "" && utils.FileExists(DefaultLicensePolicyPath) {
+		path = DefaultLicensePolicyPath
+	}
+
+	var policy packagemanager.LicensePolicy
+	if path != "" {
+		var err error
+		if policy, err = packagemanager.LoadLicensePolicy(path); err != nil {
+			return fmt.Errorf("failed to load license policy: %w", err)
+		}
+	}
+
+	manifest, err := utils.ReadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if manifest.License != "" {
+		if err := packagemanager.ValidateLicense(manifest.License); err != nil {
+			return fmt.Errorf("%s: %w", utils.DefaultManifestPath, err)
+		}
+	}
+
+	// Resolve the graph and collect each package's declared license
+	resolved, err := utils.ResolveCurrent(context.Background(), ctx.Registry, manifest)
+	if err != nil {
+		return utils.ReportResolutionFailure(err)
+	}
+
+	resolution := make(packagemanager.Resolution, len(resolved))
+	for name, info := range resolved {
+		resolution[name] = info.Version
+	}
+
+	lock, _, err := packagemanager.GenerateLockfile(context.Background(), ctx.Registry, resolution)
+	if err != nil {
+		return fmt.Errorf("failed to read package licenses: %w", err)
+	}
+
+	fmt.Println("name  version  license")
+	for _, e := range lock.Entries {
+		license := e.License
+		if license == "" {
+			license = "-"
+		}
+		fmt.Printf("%s  %s  %s\n", e.Name, e.Version, license)
+	}
+
+	findings := packagemanager.CheckLicenses(lock, policy)
+	if len(findings) == 0 {
+		if path == "" {
+			fmt.Printf("%d packages, no license policy configured\n", len(lock.Entries))
+			return nil
+		}
+
+		fmt.Printf("%d packages comply with %s\n", len(lock.Entries), path)
+		return nil
+	}
+
+	fmt.Println()
+	for _, f := range findings {
+		fmt.Printf("%s@%s: %s\n", f.Name, f.Version, f.Reason)
+	}
+
+	return fmt.Errorf("%d packages violate the license policy", len(findings))
+}
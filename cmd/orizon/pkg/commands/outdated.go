@@ -43,11 +43,17 @@ func (c *OutdatedCommand) Execute(ctx types.RegistryContext, args []string) erro
 		return fmt.Errorf("failed to resolve dependencies: %w", err)
 	}
 
+	// In a workspace, report the registry dependencies of every member
+	dependencies, err := utils.RegistryDependencies(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to load workspace: %w", err)
+	}
+
 	// Print header
 	fmt.Println("name  current  allowed  latest")
 
 	// Check each dependency
-	for name, constraint := range manifest.Dependencies {
+	for name, constraint := range dependencies {
 		current := string(resolved[packagemanager.PackageID(name)].Version)
 
 		// Parse constraint
@@ -64,11 +70,20 @@ func (c *OutdatedCommand) Execute(ctx types.RegistryContext, args []string) erro
 			continue
 		}
 
-		var bestAllowed, bestOverall string
+		var bestAllowed, bestOverall, deprecated string
 		var bestAllowedVer, bestOverallVer *semver.Version
+		currentYanked := false
 
-		// Find best versions
+		// Find best versions, skipping yanked releases
 		for _, mf := range manifests {
+			if mf.Deprecated != "" {
+				deprecated = mf.Deprecated
+			}
+			if mf.Yanked {
+				currentYanked = currentYanked || string(mf.Version) == current
+				continue
+			}
+
 			sv, err := semver.NewVersion(string(mf.Version))
 			if err != nil {
 				continue
@@ -98,6 +113,12 @@ func (c *OutdatedCommand) Execute(ctx types.RegistryContext, args []string) erro
 		}
 
 		fmt.Printf("%s  %s  %s  %s\n", name, current, bestAllowed, bestOverall)
+		if currentYanked {
+			fmt.Printf("  note: %s@%s has been yanked\n", name, current)
+		}
+		if deprecated != "" {
+			fmt.Printf("  note: %s is deprecated: %s\n", name, deprecated)
+		}
 	}
 
 	return nil
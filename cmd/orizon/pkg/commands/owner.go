@@ -0,0 +1,98 @@
+// Package commands provides the owner command implementation for package management.
+// This handles the list of users allowed to publish and manage a package.
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+)
+
+// OwnerCommand handles package ownership operations.
+// It supports listing, adding and removing the owners of a package.
+type OwnerCommand struct {
+	*BaseCommand
+}
+
+// NewOwnerCommand creates a new owner command handler.
+func NewOwnerCommand() *OwnerCommand {
+	return &OwnerCommand{
+		BaseCommand: NewBaseCommand(
+			"Manage package owners",
+			"usage: orizon pkg owner <list|add|remove> --name <pkg> [--user <user>]",
+		),
+	}
+}
+
+// Execute implements the CommandHandler interface for owner operations.
+func (c *OwnerCommand) Execute(ctx types.RegistryContext, args []string) error {
+	if len(args) < 1 {
+		c.PrintUsage()
+		return nil
+	}
+
+	sub := args[0]
+	switch sub {
+	case "list", "add", "remove":
+	default:
+		return fmt.Errorf("unknown owner subcommand: %s", sub)
+	}
+
+	fs := flag.NewFlagSet("owner "+sub, flag.ExitOnError)
+	name := fs.String("name", "", "package name")
+	user := fs.String("user", "", "user to add or remove")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("failed to parse owner flags: %w", err)
+	}
+
+	if strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	if sub != "list" && strings.TrimSpace(*user) == "" {
+		return fmt.Errorf("--user is required")
+	}
+
+	admin, err := utils.PackageAdmin(ctx.Registry)
+	if err != nil {
+		return err
+	}
+
+	pkg := packagemanager.PackageID(*name)
+	switch sub {
+	case "add":
+		if err := admin.AddOwner(context.Background(), pkg, *user); err != nil {
+			return fmt.Errorf("failed to add owner: %w", err)
+		}
+
+		fmt.Printf("added %s as owner of %s\n", *user, *name)
+	case "remove":
+		if err := admin.RemoveOwner(context.Background(), pkg, *user); err != nil {
+			return fmt.Errorf("failed to remove owner: %w", err)
+		}
+
+		fmt.Printf("removed %s from owners of %s\n", *user, *name)
+	default:
+		owners, err := admin.Owners(context.Background(), pkg)
+		if err != nil {
+			return fmt.Errorf("failed to list owners: %w", err)
+		}
+
+		if len(owners) == 0 {
+			fmt.Printf("%s has no owners\n", *name)
+			return nil
+		}
+
+		for _, owner := range owners {
+			fmt.Println(owner)
+		}
+	}
+
+	return nil
+}
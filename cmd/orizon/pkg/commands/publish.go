@@ -4,11 +4,15 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
 	"github.com/orizon-lang/orizon/internal/packagemanager"
 )
 
@@ -23,7 +27,7 @@ func NewPublishCommand() *PublishCommand {
 	return &PublishCommand{
 		BaseCommand: NewBaseCommand(
 			"Publish a package to the registry",
-			"usage: orizon pkg publish --name <id> --version <semver> --file <path>",
+			"usage: orizon pkg publish [--dir <path>] [--name <id>] [--version <semver>] [--license <spdx>] | --name <id> --version <semver> --file <path> [--license <spdx>]",
 		),
 	}
 }
@@ -31,39 +35,177 @@ func NewPublishCommand() *PublishCommand {
 // Execute implements the CommandHandler interface for publish operations.
 func (c *PublishCommand) Execute(ctx types.RegistryContext, args []string) error {
 	fs := flag.NewFlagSet("publish", flag.ExitOnError)
-	name := fs.String("name", "", "package name")
-	version := fs.String("version", "", "package version (semver)")
-	file := fs.String("file", "", "payload file to publish")
+	name := fs.String("name", "", "package name (defaults to the manifest name)")
+	version := fs.String("version", "", "package version (defaults to the manifest version)")
+	file := fs.String("file", "", "opaque payload file to publish instead of a package archive")
+	dir := fs.String("dir", ".", "package directory to archive")
+	license := fs.String("license", "", "SPDX license expression (defaults to the manifest license)")
 
 	if err := fs.Parse(args); err != nil {
 		return fmt.Errorf("failed to parse publish flags: %w", err)
 	}
 
-	if *name == "" || *version == "" || *file == "" {
-		return fmt.Errorf("usage: orizon pkg publish --name <id> --version <semver> --file <path>")
+	*license = strings.TrimSpace(*license)
+
+	var blob packagemanager.PackageBlob
+
+	if *file != "" {
+		if *name == "" || *version == "" {
+			return fmt.Errorf("usage: orizon pkg publish --name <id> --version <semver> --file <path>")
+		}
+
+		// Read package data
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return fmt.Errorf("failed to read package file: %w", err)
+		}
+
+		blob = packagemanager.PackageBlob{
+			Manifest: packagemanager.PackageManifest{
+				Name:    packagemanager.PackageID(*name),
+				Version: packagemanager.Version(*version),
+				License: *license,
+			},
+			Data: data,
+		}
+	} else {
+		var err error
+		if blob, err = buildPackageBlob(*dir, *name, *version, *license); err != nil {
+			return err
+		}
 	}
 
-	// Read package data
-	data, err := os.ReadFile(*file)
-	if err != nil {
-		return fmt.Errorf("failed to read package file: %w", err)
+	// Reject malformed license expressions before uploading
+	if blob.Manifest.License != "" {
+		if err := packagemanager.ValidateLicense(blob.Manifest.License); err != nil {
+			return fmt.Errorf("invalid license for %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+		}
 	}
 
-	// Create package blob
-	blob := packagemanager.PackageBlob{
-		Manifest: packagemanager.PackageManifest{
-			Name:    packagemanager.PackageID(*name),
-			Version: packagemanager.Version(*version),
-		},
-		Data: data,
+	// Feature tables may only refer to declared dependencies
+	if err := packagemanager.ValidateFeatures(blob.Manifest.Features, blob.Manifest.Dependencies); err != nil {
+		return fmt.Errorf("invalid features for %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
 	}
 
-	// Publish package
-	cid, err := ctx.Registry.Publish(context.Background(), blob)
+	// Publish package, collecting the transparency log receipt when the registry keeps a log
+	var (
+		cid     packagemanager.CID
+		receipt *packagemanager.LogReceipt
+		err     error
+	)
+	if rp, ok := ctx.Registry.(packagemanager.ReceiptPublisher); ok {
+		cid, receipt, err = rp.PublishWithReceipt(context.Background(), blob)
+	} else {
+		cid, err = ctx.Registry.Publish(context.Background(), blob)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to publish package: %w", err)
 	}
 
-	fmt.Printf("published %s@%s cid=%s\n", *name, *version, cid)
+	fmt.Printf("published %s@%s cid=%s files=%d\n", blob.Manifest.Name, blob.Manifest.Version, cid, len(blob.Manifest.Files))
+
+	if receipt != nil {
+		if err := receipt.Inclusion.Verify(receipt.Head.RootHash); err != nil {
+			return fmt.Errorf("registry returned an invalid log receipt: %w", err)
+		}
+
+		fmt.Printf("logged as entry %d of tree size %d\n", receipt.Inclusion.Index, receipt.Head.TreeSize)
+	}
 	return nil
 }
+
+// buildPackageBlob packs dir into the canonical package archive, taking the
+// package identity, dependencies and include/exclude lists from its manifest.
+// A non-empty license replaces the manifest's, in the archive as well.
+func buildPackageBlob(dir, name, version, license string) (packagemanager.PackageBlob, error) {
+	data, err := os.ReadFile(filepath.Join(dir, utils.DefaultManifestPath))
+	if err != nil {
+		return packagemanager.PackageBlob{}, fmt.Errorf("failed to read package manifest: %w", err)
+	}
+
+	var manifest types.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return packagemanager.PackageBlob{}, fmt.Errorf("failed to parse package manifest: %w", err)
+	}
+
+	if name == "" {
+		name = manifest.Name
+	}
+
+	if version == "" {
+		version = manifest.Version
+	}
+
+	if name == "" || version == "" {
+		return packagemanager.PackageBlob{}, fmt.Errorf("package name and version are required")
+	}
+
+	deps := utils.ManifestPackage(manifest).Dependencies
+
+	// The archived manifest is rewritten when publishing changes it.
+	rewrite := false
+
+	// Path dependencies only exist inside a workspace; publish them as
+	// requirements on the registry versions of the local packages.
+	if utils.UsesWorkspace(manifest) {
+		root, err := packagemanager.FindWorkspaceRoot(dir)
+		if err != nil {
+			return packagemanager.PackageBlob{}, fmt.Errorf("failed to load workspace: %w", err)
+		}
+
+		ws, err := packagemanager.LoadWorkspace(root)
+		if err != nil {
+			return packagemanager.PackageBlob{}, fmt.Errorf("failed to load workspace: %w", err)
+		}
+
+		if deps, err = ws.PublishDependencies(packagemanager.PackageID(manifest.Name)); err != nil {
+			return packagemanager.PackageBlob{}, err
+		}
+
+		manifest.Dependencies = make(map[string]string, len(deps))
+		for _, dep := range deps {
+			manifest.Dependencies[string(dep.Name)] = dep.Constraint
+		}
+
+		deps = append(deps, utils.OptionalDependencies(manifest)...)
+
+		manifest.Workspace = nil
+		rewrite = true
+	}
+
+	if license != "" && license != manifest.License {
+		manifest.License = license
+		rewrite = true
+	}
+
+	var overrides map[string][]byte
+	if rewrite {
+		rewritten, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return packagemanager.PackageBlob{}, fmt.Errorf("failed to rewrite package manifest: %w", err)
+		}
+
+		overrides = map[string][]byte{utils.DefaultManifestPath: rewritten}
+	}
+
+	archive, files, err := packagemanager.BuildArchive(dir, packagemanager.ArchiveOptions{
+		Include:   manifest.Include,
+		Exclude:   manifest.Exclude,
+		Overrides: overrides,
+	})
+	if err != nil {
+		return packagemanager.PackageBlob{}, fmt.Errorf("failed to build package archive: %w", err)
+	}
+
+	return packagemanager.PackageBlob{
+		Manifest: packagemanager.PackageManifest{
+			Name:         packagemanager.PackageID(name),
+			Version:      packagemanager.Version(version),
+			Dependencies: deps,
+			Files:        files,
+			License:      manifest.License,
+			Features:     manifest.Features,
+		},
+		Data: archive,
+	}, nil
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
 )
 
 // Registry manages all available package management commands.
@@ -41,6 +42,11 @@ func NewRegistry() *Registry {
 	registry.register("sign", NewSignCommand())
 	registry.register("verify-sig", NewVerifySigCommand())
 	registry.register("audit", NewAuditCommand())
+	registry.register("yank", NewYankCommand())
+	registry.register("deprecate", NewDeprecateCommand())
+	registry.register("owner", NewOwnerCommand())
+	registry.register("licenses", NewLicensesCommand())
+	registry.register("sbom", NewSBOMCommand())
 
 	return registry
 }
@@ -79,7 +85,37 @@ func (r *Registry) ListCommands() {
 }
 
 // ExecuteCommand executes a command by name with the given context and arguments.
+// The global --offline flag may precede the subcommand or appear among its
+// arguments; it switches ctx to a registry that only reads the local cache.
 func (r *Registry) ExecuteCommand(name string, ctx types.RegistryContext, args []string) error {
+	offline := false
+	if isOfflineFlag(name) && len(args) > 0 {
+		offline = true
+		name, args = args[0], args[1:]
+	}
+
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if isOfflineFlag(arg) {
+			offline = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	args = rest
+
+	if offline {
+		if err := utils.SetOffline(); err != nil {
+			return fmt.Errorf("failed to enable offline mode: %w", err)
+		}
+
+		registry, err := utils.CreateRegistry()
+		if err != nil {
+			return fmt.Errorf("failed to create offline registry: %w", err)
+		}
+		ctx.Registry = registry
+	}
+
 	command, exists := r.GetCommand(name)
 	if !exists {
 		return fmt.Errorf("unknown subcommand: %s", name)
@@ -87,3 +123,8 @@ func (r *Registry) ExecuteCommand(name string, ctx types.RegistryContext, args [
 
 	return command.Execute(ctx, args)
 }
+
+// isOfflineFlag reports whether arg is the global --offline flag.
+func isOfflineFlag(arg string) bool {
+	return arg == "--offline" || arg == "-offline"
+}
@@ -48,11 +48,14 @@ func (c *RemoveCommand) Execute(ctx types.RegistryContext, args []string) error
 	}
 
 	// Remove dependency from manifest
-	if _, exists := manifest.Dependencies[*depName]; !exists {
+	_, required := manifest.Dependencies[*depName]
+	_, optional := manifest.OptionalDependencies[*depName]
+	if !required && !optional {
 		return fmt.Errorf("dependency %s not found in manifest", *depName)
 	}
 
 	delete(manifest.Dependencies, *depName)
+	delete(manifest.OptionalDependencies, *depName)
 
 	// Write updated manifest
 	if err := utils.WriteManifest(manifest); err != nil {
@@ -38,7 +38,7 @@ func (c *ResolveCommand) Execute(ctx types.RegistryContext, args []string) error
 	// Resolve dependencies
 	resolved, err := utils.ResolveCurrent(context.Background(), ctx.Registry, manifest)
 	if err != nil {
-		return fmt.Errorf("failed to resolve dependencies: %w", err)
+		return utils.ReportResolutionFailure(err)
 	}
 
 	// Convert to JSON output format
@@ -0,0 +1,103 @@
+// Package commands provides the sbom command implementation for package management.
+// This handles generating software bills of materials from the lockfile.
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+)
+
+// SBOMCommand handles SBOM generation.
+// It describes every locked package with its CID digest, license and dependencies.
+type SBOMCommand struct {
+	*BaseCommand
+}
+
+// NewSBOMCommand creates a new sbom command handler.
+func NewSBOMCommand() *SBOMCommand {
+	return &SBOMCommand{
+		BaseCommand: NewBaseCommand(
+			"Generate a software bill of materials from the lockfile",
+			"usage: orizon pkg sbom [--format spdx-json|cyclonedx-json] [--out <file>]",
+		),
+	}
+}
+
+// Execute implements the CommandHandler interface for sbom operations.
+func (c *SBOMCommand) Execute(ctx types.RegistryContext, args []string) error {
+	fs := flag.NewFlagSet("sbom", flag.ExitOnError)
+	format := fs.String("format", packagemanager.SBOMFormatSPDX, "output format: spdx-json or cyclonedx-json")
+	out := fs.String("out", "", "write the SBOM to this file instead of stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse sbom flags: %w", err)
+	}
+
+	manifest, err := utils.ReadManifest()
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	lockfile, err := utils.ReadLockfile()
+	if err != nil {
+		return err
+	}
+
+	// Lockfiles written before licenses were recorded lack them; look them up
+	lock := packagemanager.Lockfile{Entries: lockfile.Entries}
+	for i, e := range lock.Entries {
+		if e.License != "" || ctx.Registry == nil {
+			continue
+		}
+
+		if blob, err := ctx.Registry.Fetch(context.Background(), e.CID); err == nil {
+			lock.Entries[i].License = blob.Manifest.License
+		}
+	}
+
+	subject := packagemanager.SBOMSubject{
+		Name:    packagemanager.PackageID(manifest.Name),
+		Version: packagemanager.Version(manifest.Version),
+		License: manifest.License,
+	}
+	for _, name := range utils.GetRootDependencies(manifest) {
+		subject.Dependencies = append(subject.Dependencies, packagemanager.PackageID(name))
+	}
+
+	data, err := packagemanager.GenerateSBOM(subject, lock, *format, sbomTimestamp())
+	if err != nil {
+		return fmt.Errorf("failed to generate SBOM: %w", err)
+	}
+
+	if *out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write SBOM: %w", err)
+	}
+
+	fmt.Printf("wrote %s SBOM for %d packages to %s\n", *format, len(lock.Entries), *out)
+	return nil
+}
+
+// sbomTimestamp honours SOURCE_DATE_EPOCH so SBOMs can be reproduced bit for bit.
+func sbomTimestamp() time.Time {
+	if v := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH")); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+
+	return time.Now().UTC().Truncate(time.Second)
+}
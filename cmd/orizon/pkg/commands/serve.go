@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
@@ -14,7 +15,8 @@ import (
 )
 
 // ServeCommand handles HTTP registry server operations.
-// It starts a local HTTP server to serve packages from a file registry.
+// It starts a local HTTP server to serve packages from a file registry,
+// or a pull-through proxy of an upstream registry with --mirror.
 type ServeCommand struct {
 	*BaseCommand
 }
@@ -24,7 +26,7 @@ func NewServeCommand() *ServeCommand {
 	return &ServeCommand{
 		BaseCommand: NewBaseCommand(
 			"Start HTTP registry server",
-			"usage: orizon pkg serve [--addr <addr>] [--token <token>] [--tls-cert <cert>] [--tls-key <key>]",
+			"usage: orizon pkg serve [--addr <addr>] [--token <token>] [--users <file>] [--tls-cert <cert>] [--tls-key <key>] [--mirror <upstream-url> | --log-dir <dir>]",
 		),
 	}
 }
@@ -35,8 +37,11 @@ func (c *ServeCommand) Execute(ctx types.RegistryContext, args []string) error {
 	fs := flag.NewFlagSet("serve", flag.ExitOnError)
 	addr := fs.String("addr", ":9321", "listen address")
 	token := fs.String("token", "", "optional bearer token")
+	usersFile := fs.String("users", "", "JSON file mapping user names to tokens; users may only publish packages they own")
 	tlsCert := fs.String("tls-cert", "", "path to TLS certificate (PEM)")
 	tlsKey := fs.String("tls-key", "", "path to TLS private key (PEM)")
+	mirror := fs.String("mirror", "", "upstream registry URL to proxy, caching packages in the local registry")
+	logDir := fs.String("log-dir", "", "directory of the transparency log recording every publish")
 
 	if err := fs.Parse(args); err != nil {
 		return fmt.Errorf("failed to parse serve flags: %w", err)
@@ -54,6 +59,29 @@ func (c *ServeCommand) Execute(ctx types.RegistryContext, args []string) error {
 		return fmt.Errorf("failed to create file registry: %w", err)
 	}
 
+	// Serve the local registry, or proxy the upstream through it
+	var reg packagemanager.Registry = fileReg
+	source := "root=" + regPath
+	if upstream := strings.TrimSpace(*mirror); upstream != "" {
+		reg = packagemanager.NewCachingRegistry(packagemanager.NewHTTPRegistry(upstream), fileReg)
+		source = fmt.Sprintf("mirror=%s cache=%s", upstream, regPath)
+	}
+
+	// Record publishes in a transparency log; a mirror serves its upstream's log instead
+	if dir := strings.TrimSpace(*logDir); dir != "" {
+		if strings.TrimSpace(*mirror) != "" {
+			return fmt.Errorf("--log-dir cannot be combined with --mirror")
+		}
+
+		tlog, err := packagemanager.OpenTransparencyLog(dir)
+		if err != nil {
+			return fmt.Errorf("failed to open transparency log: %w", err)
+		}
+
+		reg = packagemanager.NewLoggedRegistry(fileReg, tlog)
+		source += fmt.Sprintf(" log=%s entries=%d trust-root=%s", dir, tlog.Size(), filepath.Join(dir, "root.json"))
+	}
+
 	// Set token environment variable if provided
 	if *token != "" {
 		if err := os.Setenv("ORIZON_REGISTRY_TOKEN", *token); err != nil {
@@ -61,15 +89,30 @@ func (c *ServeCommand) Execute(ctx types.RegistryContext, args []string) error {
 		}
 	}
 
+	// Enable per-user tokens and package ownership if a users file is provided
+	if path := strings.TrimSpace(*usersFile); path != "" {
+		users, err := packagemanager.LoadRegistryUsers(path)
+		if err != nil {
+			return fmt.Errorf("failed to load registry users: %w", err)
+		}
+
+		if err := os.Setenv("ORIZON_REGISTRY_USERS", path); err != nil {
+			return fmt.Errorf("failed to set registry users: %w", err)
+		}
+
+		source += fmt.Sprintf(" users=%d", len(users))
+	}
+	authOn := os.Getenv("ORIZON_REGISTRY_TOKEN") != "" || os.Getenv("ORIZON_REGISTRY_USERS") != ""
+
 	// Check if TLS is requested
 	useTLS := strings.TrimSpace(*tlsCert) != "" && strings.TrimSpace(*tlsKey) != ""
 
 	if useTLS {
 		// Start HTTPS server
-		fmt.Printf("serving registry on https://%s (root=%s) auth=%v\n",
-			*addr, regPath, os.Getenv("ORIZON_REGISTRY_TOKEN") != "")
+		fmt.Printf("serving registry on https://%s (%s) auth=%v\n",
+			*addr, source, authOn)
 
-		if err := packagemanager.StartHTTPServerTLS(fileReg, *addr, *tlsCert, *tlsKey); err != nil {
+		if err := packagemanager.StartHTTPServerTLS(reg, *addr, *tlsCert, *tlsKey); err != nil {
 			return fmt.Errorf("failed to start HTTPS server: %w", err)
 		}
 
@@ -77,10 +120,10 @@ func (c *ServeCommand) Execute(ctx types.RegistryContext, args []string) error {
 	}
 
 	// Start HTTP server
-	fmt.Printf("serving registry on http://%s (root=%s) auth=%v\n",
-		*addr, regPath, os.Getenv("ORIZON_REGISTRY_TOKEN") != "")
+	fmt.Printf("serving registry on http://%s (%s) auth=%v\n",
+		*addr, source, authOn)
 
-	if err := packagemanager.StartHTTPServer(fileReg, *addr); err != nil {
+	if err := packagemanager.StartHTTPServer(reg, *addr); err != nil {
 		return fmt.Errorf("failed to start HTTP server: %w", err)
 	}
 
@@ -68,7 +68,13 @@ func (c *UpdateCommand) Execute(ctx types.RegistryContext, args []string) error
 
 // updateAllDependencies updates all dependencies in the manifest.
 func (c *UpdateCommand) updateAllDependencies(ctx context.Context, reg packagemanager.Registry, manifest types.Manifest) error {
-	if err := utils.WriteLockFromManifest(ctx, reg, manifest); err != nil {
+	// Updating moves off yanked versions, even locked ones
+	resolved, err := utils.ResolveLatest(ctx, reg, manifest)
+	if err != nil {
+		return fmt.Errorf("failed to update dependencies: %w", err)
+	}
+
+	if err := utils.WriteLock(ctx, reg, resolved); err != nil {
 		return fmt.Errorf("failed to update dependencies: %w", err)
 	}
 
@@ -101,38 +107,26 @@ func (c *UpdateCommand) updateSpecificDependencies(ctx context.Context, reg pack
 		}
 	}
 
-	// Build requirements with selective updates
-	reqs := make([]packagemanager.Requirement, 0, len(manifest.Dependencies))
+	// Pin direct registry dependencies that are not being updated
+	direct, err := utils.RegistryDependencies(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to load workspace: %w", err)
+	}
+
 	updateSet := make(map[string]bool)
 	for _, dep := range deps {
 		updateSet[dep] = true
 	}
 
-	for name := range manifest.Dependencies {
-		if updateSet[name] {
-			// Use manifest constraint for packages being updated
-			reqs = append(reqs, packagemanager.Requirement{
-				Name:      packagemanager.PackageID(name),
-				Constraint: manifest.Dependencies[name],
-			})
-		} else if version, ok := locked[name]; ok {
-			// Pin to current version for packages not being updated
-			reqs = append(reqs, packagemanager.Requirement{
-				Name:      packagemanager.PackageID(name),
-				Constraint: "=" + version,
-			})
-		} else {
-			// Fallback to manifest constraint
-			reqs = append(reqs, packagemanager.Requirement{
-				Name:      packagemanager.PackageID(name),
-				Constraint: manifest.Dependencies[name],
-			})
+	pins := make(map[string]string)
+	for name := range direct {
+		if version, ok := locked[name]; ok && !updateSet[name] {
+			pins[name] = version
 		}
 	}
 
 	// Resolve with new requirements
-	manager := packagemanager.NewManager(reg)
-	out, err := manager.ResolveAndFetch(ctx, reqs, true)
+	out, err := utils.ResolvePinned(ctx, reg, manifest, pins)
 	if err != nil {
 		return fmt.Errorf("failed to resolve updated dependencies: %w", err)
 	}
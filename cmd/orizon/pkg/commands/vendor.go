@@ -4,16 +4,18 @@ package commands
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
 )
 
 // VendorCommand handles dependency vendoring operations.
-// It downloads all lockfile entries into a local vendor directory for offline builds.
+// It unpacks all lockfile entries into a local vendor directory for offline builds.
 type VendorCommand struct {
 	*BaseCommand
 }
@@ -23,13 +25,20 @@ func NewVendorCommand() *VendorCommand {
 	return &VendorCommand{
 		BaseCommand: NewBaseCommand(
 			"Download dependencies into vendor directory",
-			"usage: orizon pkg vendor",
+			"usage: orizon pkg vendor [--verify]",
 		),
 	}
 }
 
 // Execute implements the CommandHandler interface for vendor operations.
 func (c *VendorCommand) Execute(ctx types.RegistryContext, args []string) error {
+	fs := flag.NewFlagSet("vendor", flag.ExitOnError)
+	verifyOnly := fs.Bool("verify", false, "re-verify vendored contents without rewriting them")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse vendor flags: %w", err)
+	}
+
 	// Read lockfile
 	lockfile, err := utils.ReadLockfile()
 	if err != nil {
@@ -42,24 +51,77 @@ func (c *VendorCommand) Execute(ctx types.RegistryContext, args []string) error
 		return fmt.Errorf("failed to create vendor directory: %w", err)
 	}
 
-	// Download each package
 	for _, entry := range lockfile.Entries {
-		// Fetch package data
+		// Fetch package data and check it against the lockfile pin
 		blob, err := ctx.Registry.Fetch(context.Background(), entry.CID)
 		if err != nil {
 			return fmt.Errorf("failed to fetch %s: %w", entry.Name, err)
 		}
 
-		// Create output filename
-		filename := fmt.Sprintf("%s-%s.blob", entry.Name, entry.Version)
-		outputPath := filepath.Join(vendorPath, filename)
+		if err := packagemanager.VerifyBlob(entry.CID, blob); err != nil {
+			return fmt.Errorf("integrity check failed: %w", err)
+		}
+
+		if len(blob.Manifest.Files) == 0 {
+			// Opaque blobs are stored as-is.
+			outputPath := filepath.Join(vendorPath, fmt.Sprintf("%s-%s.blob", entry.Name, entry.Version))
+			if *verifyOnly {
+				if err := verifyOpaqueBlob(outputPath, entry.CID); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := os.WriteFile(outputPath, blob.Data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", outputPath, err)
+			}
+
+			continue
+		}
+
+		pkgDir := filepath.Join(vendorPath, fmt.Sprintf("%s@%s", entry.Name, entry.Version))
+		if *verifyOnly {
+			if err := packagemanager.VerifyDir(pkgDir, blob.Manifest.Files); err != nil {
+				return fmt.Errorf("vendored %s@%s does not match: %w", entry.Name, entry.Version, err)
+			}
+
+			continue
+		}
+
+		// Unpack into a fresh directory so stale files cannot survive.
+		if err := os.RemoveAll(pkgDir); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", pkgDir, err)
+		}
+
+		if err := packagemanager.ExtractArchive(blob.Data, blob.Manifest.Files, pkgDir); err != nil {
+			return fmt.Errorf("failed to unpack %s@%s: %w", entry.Name, entry.Version, err)
+		}
 
-		// Write package data
-		if err := os.WriteFile(outputPath, blob.Data, 0644); err != nil {
-			return fmt.Errorf("failed to write %s: %w", filename, err)
+		if err := packagemanager.VerifyDir(pkgDir, blob.Manifest.Files); err != nil {
+			return fmt.Errorf("vendored %s@%s does not match: %w", entry.Name, entry.Version, err)
 		}
 	}
 
+	if *verifyOnly {
+		fmt.Printf("verified %d vendored packages in %s\n", len(lockfile.Entries), vendorPath)
+		return nil
+	}
+
 	fmt.Printf("vendored %d packages into %s\n", len(lockfile.Entries), vendorPath)
 	return nil
 }
+
+// verifyOpaqueBlob checks a vendored opaque blob against its CID.
+func verifyOpaqueBlob(path string, cid packagemanager.CID) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if packagemanager.ComputeCID(data) != cid {
+		return fmt.Errorf("vendored %s does not match %s", path, cid)
+	}
+
+	return nil
+}
@@ -4,7 +4,13 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
 	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
@@ -12,7 +18,8 @@ import (
 )
 
 // VerifyCommand handles lockfile verification operations.
-// It verifies the integrity of lockfiles against the registry.
+// It verifies the integrity of lockfiles against the registry and, given a
+// trusted log root, against the registry's transparency log.
 type VerifyCommand struct {
 	*BaseCommand
 }
@@ -22,13 +29,21 @@ func NewVerifyCommand() *VerifyCommand {
 	return &VerifyCommand{
 		BaseCommand: NewBaseCommand(
 			"Verify lockfile integrity",
-			"usage: orizon pkg verify",
+			"usage: orizon pkg verify [--log-root <cert>] [--witness <tree-head>]",
 		),
 	}
 }
 
 // Execute implements the CommandHandler interface for verify operations.
 func (c *VerifyCommand) Execute(ctx types.RegistryContext, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	logRoot := fs.String("log-root", os.Getenv("ORIZON_LOG_ROOT"), "root certificate (JSON) trusted to sign transparency log heads")
+	witness := fs.String("witness", "", "tree head (JSON) observed elsewhere that the registry's log must be consistent with")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse verify flags: %w", err)
+	}
+
 	// Read lockfile
 	data, err := utils.ReadLockfile()
 	if err != nil {
@@ -46,5 +61,92 @@ func (c *VerifyCommand) Execute(ctx types.RegistryContext, args []string) error
 	}
 
 	fmt.Println("lockfile verified")
+
+	if strings.TrimSpace(*logRoot) == "" {
+		if *witness != "" {
+			return fmt.Errorf("--witness requires a log root: pass --log-root or set ORIZON_LOG_ROOT")
+		}
+		return nil
+	}
+
+	return verifyTransparencyLog(ctx.Registry, lockfile, *logRoot, *witness)
+}
+
+// verifyTransparencyLog checks the lockfile against the registry's log and
+// records the verified tree head for the next run.
+func verifyTransparencyLog(reg packagemanager.Registry, lockfile packagemanager.Lockfile, logRoot, witness string) error {
+	trustStore, err := loadTrustRoot(logRoot)
+	if err != nil {
+		return err
+	}
+
+	provider, ok := reg.(packagemanager.TransparencyLogProvider)
+	if !ok {
+		return fmt.Errorf("registry does not keep a transparency log")
+	}
+
+	var seen []packagemanager.SignedTreeHead
+
+	statePath := filepath.Join(".orizon", "log-head.json")
+	for _, path := range []string{statePath, witness} {
+		if path == "" {
+			continue
+		}
+
+		head, err := readTreeHead(path)
+		if errors.Is(err, os.ErrNotExist) && path == statePath {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		seen = append(seen, head)
+	}
+
+	head, err := packagemanager.VerifyLockfileInLog(context.Background(), provider, trustStore, lockfile, seen...)
+	if errors.Is(err, packagemanager.ErrNotFound) {
+		return fmt.Errorf("registry does not keep a transparency log")
+	}
+
+	if errors.Is(err, packagemanager.ErrSplitView) {
+		return fmt.Errorf("transparency log check failed, the registry may be showing different logs to different users: %w", err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("transparency log check failed: %w", err)
+	}
+
+	data, err := json.MarshalIndent(head, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tree head: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(statePath), err)
+	}
+
+	if err := os.WriteFile(statePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save tree head: %w", err)
+	}
+
+	fmt.Printf("transparency log verified: %d entries included in tree of size %d\n", len(lockfile.Entries), head.TreeSize)
 	return nil
 }
+
+// readTreeHead loads a signed tree head saved as JSON.
+func readTreeHead(path string) (packagemanager.SignedTreeHead, error) {
+	var head packagemanager.SignedTreeHead
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return head, err
+	}
+
+	if err := json.Unmarshal(data, &head); err != nil {
+		return head, fmt.Errorf("failed to parse tree head %s: %w", path, err)
+	}
+
+	return head, nil
+}
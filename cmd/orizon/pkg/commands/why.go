@@ -54,7 +54,7 @@ func (c *WhyCommand) Execute(ctx types.RegistryContext, args []string) error {
 	// Resolve current dependencies
 	resolved, err := utils.ResolveCurrent(context.Background(), ctx.Registry, manifest)
 	if err != nil {
-		return fmt.Errorf("failed to resolve dependencies: %w", err)
+		return utils.ReportResolutionFailure(err)
 	}
 
 	// Build dependency graph
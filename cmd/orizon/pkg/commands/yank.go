@@ -0,0 +1,63 @@
+// Package commands provides the yank command implementation for package management.
+// This handles withdrawing published versions from new resolutions.
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/utils"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+)
+
+// YankCommand handles yanking and un-yanking published versions.
+// Yanked versions are skipped by new resolutions but stay usable from existing lockfiles.
+type YankCommand struct {
+	*BaseCommand
+}
+
+// NewYankCommand creates a new yank command handler.
+func NewYankCommand() *YankCommand {
+	return &YankCommand{
+		BaseCommand: NewBaseCommand(
+			"Yank a published version so new resolutions skip it",
+			"usage: orizon pkg yank --name <pkg> --version <ver> [--undo]",
+		),
+	}
+}
+
+// Execute implements the CommandHandler interface for yank operations.
+func (c *YankCommand) Execute(ctx types.RegistryContext, args []string) error {
+	fs := flag.NewFlagSet("yank", flag.ExitOnError)
+	name := fs.String("name", "", "package name")
+	version := fs.String("version", "", "version to yank")
+	undo := fs.Bool("undo", false, "restore a yanked version")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse yank flags: %w", err)
+	}
+
+	if strings.TrimSpace(*name) == "" || strings.TrimSpace(*version) == "" {
+		return fmt.Errorf("--name and --version are required")
+	}
+
+	admin, err := utils.PackageAdmin(ctx.Registry)
+	if err != nil {
+		return err
+	}
+
+	if err := admin.Yank(context.Background(), packagemanager.PackageID(*name), packagemanager.Version(*version), !*undo); err != nil {
+		return fmt.Errorf("failed to yank %s@%s: %w", *name, *version, err)
+	}
+
+	if *undo {
+		fmt.Printf("restored %s@%s\n", *name, *version)
+		return nil
+	}
+
+	fmt.Printf("yanked %s@%s\n", *name, *version)
+	return nil
+}
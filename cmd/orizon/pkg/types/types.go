@@ -11,10 +11,30 @@ import (
 type Manifest struct {
 	// Dependencies maps package names to their version constraints
 	Dependencies map[string]string `json:"dependencies,omitempty"`
+	// OptionalDependencies are only used when an enabled feature pulls them in
+	OptionalDependencies map[string]string `json:"optional_dependencies,omitempty"`
+	// Features maps feature names to the features, optional dependencies
+	// ("dep:name"), dependency features ("name/feature") and cfg flags
+	// ("cfg:flag") they enable
+	Features map[string][]string `json:"features,omitempty"`
 	// Name is the package identifier
 	Name string `json:"name"`
 	// Version is the semantic version of the package
 	Version string `json:"version"`
+	// License is the SPDX license expression of the package
+	License string `json:"license,omitempty"`
+	// Include limits the published archive to matching paths (all files when empty)
+	Include []string `json:"include,omitempty"`
+	// Exclude removes matching paths from the published archive
+	Exclude []string `json:"exclude,omitempty"`
+	// Workspace makes this manifest a workspace root when set
+	Workspace *WorkspaceConfig `json:"workspace,omitempty"`
+}
+
+// WorkspaceConfig lists the member packages of a workspace root manifest.
+type WorkspaceConfig struct {
+	// Members are directory glob patterns relative to the workspace root
+	Members []string `json:"members"`
 }
 
 // Lockfile represents a resolved dependency tree with exact versions and content IDs.
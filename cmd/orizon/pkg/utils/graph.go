@@ -83,15 +83,53 @@ func BuildDependencyGraph(ctx context.Context, reg packagemanager.Registry, reso
 }
 
 // GetRootDependencies extracts root dependencies from a manifest.
-// These are the direct dependencies declared in the package manifest.
+// These are the direct registry dependencies of the package or of every workspace member.
 func GetRootDependencies(manifest types.Manifest) []string {
-	roots := make([]string, 0, len(manifest.Dependencies))
-	for name := range manifest.Dependencies {
-		roots = append(roots, name)
+	deps, err := RegistryDependencies(manifest)
+	if err != nil {
+		deps = manifest.Dependencies
+	}
+
+	roots := make([]string, 0, len(deps))
+	for name, spec := range deps {
+		if !packagemanager.IsPathDependency(spec) {
+			roots = append(roots, name)
+		}
 	}
 	return roots
 }
 
+// AddWorkspaceMembers adds a node for every workspace member to graph, with
+// edges to the members and resolved registry packages it depends on.
+// It returns the member keys.
+func AddWorkspaceMembers(graph map[string][]string, ws *packagemanager.Workspace, resolved map[packagemanager.PackageID]struct {
+	Version packagemanager.Version
+	CID     packagemanager.CID
+}) []string {
+	keys := make([]string, 0, len(ws.Members))
+	for _, member := range ws.Members {
+		key := fmt.Sprintf("%s@%s", member.Name, member.Version)
+		keys = append(keys, key)
+
+		dependencies := make([]string, 0, len(member.Dependencies)+len(member.PathDependencies))
+		for _, dep := range member.PathDependencies {
+			if target, ok := ws.Member(dep.Name); ok {
+				dependencies = append(dependencies, fmt.Sprintf("%s@%s", target.Name, target.Version))
+			}
+		}
+		for _, dep := range member.Dependencies {
+			if info, ok := resolved[dep.Name]; ok {
+				dependencies = append(dependencies, fmt.Sprintf("%s@%s", dep.Name, info.Version))
+			} else if target, ok := ws.Member(dep.Name); ok {
+				dependencies = append(dependencies, fmt.Sprintf("%s@%s", target.Name, target.Version))
+			}
+		}
+
+		graph[key] = dependencies
+	}
+	return keys
+}
+
 // FindDependencyPath performs a breadth-first search to find a path from root to target.
 // It returns the path as a slice of package names if found, or nil if no path exists.
 func FindDependencyPath(graph map[string][]string, roots []string, target string) []string {
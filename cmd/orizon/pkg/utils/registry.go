@@ -15,6 +15,9 @@ import (
 
 // CreateRegistry creates and initializes a package registry based on environment configuration.
 // It supports both HTTP and local file-based registries with automatic detection.
+// HTTP registries are wrapped in a pull-through cache under DefaultRegistryCachePath,
+// and in offline mode (see IsOffline) only that cache is consulted. Their sparse
+// index files are kept under DefaultIndexCachePath and revalidated with ETags.
 func CreateRegistry() (packagemanager.Registry, error) {
 	regEnv := strings.TrimSpace(os.Getenv("ORIZON_REGISTRY"))
 
@@ -24,8 +27,19 @@ func CreateRegistry() (packagemanager.Registry, error) {
 	// Check if it's an HTTP registry
 	if strings.HasPrefix(strings.ToLower(regEnv), "http://") ||
 		strings.HasPrefix(strings.ToLower(regEnv), "https://") {
+		cache, err := packagemanager.NewFileRegistry(DefaultRegistryCachePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open registry cache: %w", err)
+		}
+
+		if IsOffline() {
+			return packagemanager.NewOfflineRegistry(cache), nil
+		}
+
 		// HTTP client will pick ORIZON_REGISTRY_TOKEN automatically or from credentials.json
-		reg = packagemanager.NewHTTPRegistry(regEnv)
+		client := packagemanager.NewHTTPRegistry(regEnv)
+		client.SetIndexCacheDir(DefaultIndexCachePath)
+		reg = packagemanager.NewCachingRegistry(client, cache)
 	} else {
 		// Local file registry
 		regPath := regEnv
@@ -47,6 +61,18 @@ func CreateRegistry() (packagemanager.Registry, error) {
 	return reg, nil
 }
 
+// IsOffline reports whether network access is disabled, either by the
+// --offline flag or by setting ORIZON_OFFLINE to 1 or true.
+func IsOffline() bool {
+	v := strings.TrimSpace(os.Getenv("ORIZON_OFFLINE"))
+	return v == "1" || strings.EqualFold(v, "true")
+}
+
+// SetOffline enables offline mode for registries created afterwards.
+func SetOffline() error {
+	return os.Setenv("ORIZON_OFFLINE", "1")
+}
+
 // CreateRegistryContext creates a complete registry context with registry and signature store.
 // This is the main entry point for initializing package management operations.
 func CreateRegistryContext() (types.RegistryContext, error) {
@@ -134,3 +160,14 @@ func SaveCredentials(credentials map[string]struct {
 
 	return nil
 }
+
+// PackageAdmin returns the release management of reg, for yanking,
+// deprecating and managing owners.
+func PackageAdmin(reg packagemanager.Registry) (packagemanager.PackageAdmin, error) {
+	admin, ok := reg.(packagemanager.PackageAdmin)
+	if !ok {
+		return nil, fmt.Errorf("registry does not support package administration: %w", packagemanager.ErrNotSupported)
+	}
+
+	return admin, nil
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -32,6 +33,12 @@ const DefaultSignaturePath = ".orizon/signatures"
 // DefaultCachePath defines the default cache directory for downloaded packages
 const DefaultCachePath = ".orizon/cache"
 
+// DefaultRegistryCachePath defines where packages fetched from remote registries are cached
+const DefaultRegistryCachePath = ".orizon/cache/registry"
+
+// DefaultIndexCachePath defines where sparse index files of remote registries are cached
+const DefaultIndexCachePath = ".orizon/cache/index"
+
 // ReadManifest reads and parses a package manifest from the default location.
 // If the file doesn't exist, it returns a default manifest structure.
 func ReadManifest() (types.Manifest, error) {
@@ -61,6 +68,37 @@ func ReadManifest() (types.Manifest, error) {
 	return manifest, nil
 }
 
+// ManifestPackage returns the manifest as an index entry: its dependencies,
+// optional ones included, sorted by name and its feature table.
+func ManifestPackage(manifest types.Manifest) packagemanager.PackageVersion {
+	deps := make([]packagemanager.Dependency, 0, len(manifest.Dependencies)+len(manifest.OptionalDependencies))
+	for name, constraint := range manifest.Dependencies {
+		deps = append(deps, packagemanager.Dependency{Name: packagemanager.PackageID(name), Constraint: constraint})
+	}
+
+	deps = append(deps, OptionalDependencies(manifest)...)
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+
+	return packagemanager.PackageVersion{
+		Name:         packagemanager.PackageID(manifest.Name),
+		Version:      packagemanager.Version(manifest.Version),
+		Dependencies: deps,
+		Features:     manifest.Features,
+	}
+}
+
+// OptionalDependencies returns the optional dependencies of the manifest sorted by name.
+func OptionalDependencies(manifest types.Manifest) []packagemanager.Dependency {
+	deps := make([]packagemanager.Dependency, 0, len(manifest.OptionalDependencies))
+	for name, constraint := range manifest.OptionalDependencies {
+		deps = append(deps, packagemanager.Dependency{Name: packagemanager.PackageID(name), Constraint: constraint, Optional: true})
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+
+	return deps
+}
+
 // WriteManifest writes a package manifest to the default location with proper formatting.
 func WriteManifest(manifest types.Manifest) error {
 	data, err := json.MarshalIndent(manifest, "", "  ")
@@ -145,21 +183,52 @@ func GetSignatureStore() (packagemanager.SignatureStore, error) {
 }
 
 // ResolveCurrent resolves manifest dependencies and returns pinned versions.
-// This function handles the core dependency resolution logic.
+// In a workspace the registry dependencies of all members are resolved together.
+// Versions recorded in the current lockfile stay selectable even if they have
+// since been yanked.
 func ResolveCurrent(ctx context.Context, reg packagemanager.Registry, manifest types.Manifest) (map[packagemanager.PackageID]struct {
 	Version packagemanager.Version
 	CID     packagemanager.CID
 }, error) {
-	reqs := make([]packagemanager.Requirement, 0, len(manifest.Dependencies))
-	for name, constraint := range manifest.Dependencies {
-		reqs = append(reqs, packagemanager.Requirement{
-			Name:      packagemanager.PackageID(name),
-			Constraint: constraint,
-		})
+	return resolveManifest(ctx, reg, manifest, nil, LockedVersions())
+}
+
+// ResolveLatest resolves manifest dependencies ignoring every yanked version,
+// including the ones in the current lockfile.
+func ResolveLatest(ctx context.Context, reg packagemanager.Registry, manifest types.Manifest) (map[packagemanager.PackageID]struct {
+	Version packagemanager.Version
+	CID     packagemanager.CID
+}, error) {
+	return resolveManifest(ctx, reg, manifest, nil, nil)
+}
+
+// LockedVersions returns the versions recorded in the current lockfile, or
+// nil if there is none.
+func LockedVersions() map[packagemanager.PackageID]packagemanager.Version {
+	lockfile, err := ReadLockfile()
+	if err != nil {
+		return nil
 	}
 
-	manager := packagemanager.NewManager(reg)
-	return manager.ResolveAndFetch(ctx, reqs, true)
+	locked := make(map[packagemanager.PackageID]packagemanager.Version, len(lockfile.Entries))
+	for _, entry := range lockfile.Entries {
+		locked[entry.Name] = entry.Version
+	}
+
+	return locked
+}
+
+// ReportResolutionFailure prints the derivation behind a failed resolution to
+// stderr and returns a short error; other errors are returned wrapped.
+func ReportResolutionFailure(err error) error {
+	var conflict *packagemanager.ConflictError
+	if !errors.As(err, &conflict) || conflict.Explanation == "" {
+		return fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, conflict.Explanation)
+
+	return fmt.Errorf("failed to resolve dependencies: %s", conflict.Reason)
 }
 
 // WriteLockFromManifest re-resolves dependencies and writes a new lockfile.
@@ -169,8 +238,16 @@ func WriteLockFromManifest(ctx context.Context, reg packagemanager.Registry, man
 		return fmt.Errorf("failed to resolve dependencies: %w", err)
 	}
 
-	resolution := make(packagemanager.Resolution, len(pinned))
-	for name, info := range pinned {
+	return WriteLock(ctx, reg, pinned)
+}
+
+// WriteLock generates a lockfile for the resolved packages and writes it.
+func WriteLock(ctx context.Context, reg packagemanager.Registry, resolved map[packagemanager.PackageID]struct {
+	Version packagemanager.Version
+	CID     packagemanager.CID
+}) error {
+	resolution := make(packagemanager.Resolution, len(resolved))
+	for name, info := range resolved {
 		resolution[name] = info.Version
 	}
 
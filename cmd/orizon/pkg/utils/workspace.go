@@ -0,0 +1,140 @@
+// Package utils provides workspace helpers for package management.
+// A workspace resolves several local packages together against one lockfile.
+package utils
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/orizon-lang/orizon/cmd/orizon/pkg/types"
+	"github.com/orizon-lang/orizon/internal/packagemanager"
+)
+
+// UsesWorkspace reports whether the manifest lists workspace members or
+// declares path dependencies on other local packages.
+func UsesWorkspace(manifest types.Manifest) bool {
+	if manifest.Workspace != nil {
+		return true
+	}
+
+	for _, spec := range manifest.Dependencies {
+		if packagemanager.IsPathDependency(spec) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadWorkspace loads the workspace the current directory belongs to, whose
+// root may be a parent directory listing it as a member. It returns nil when
+// the directory is not a member of another workspace and the manifest does
+// not use workspace features.
+func LoadWorkspace(manifest types.Manifest) (*packagemanager.Workspace, error) {
+	root, err := packagemanager.FindWorkspaceRoot(".")
+	if err != nil {
+		return nil, err
+	}
+
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		return nil, err
+	}
+
+	if root == cwd && !UsesWorkspace(manifest) {
+		return nil, nil
+	}
+
+	return packagemanager.LoadWorkspace(root)
+}
+
+// RegistryDependencies returns the registry dependencies that the lockfile
+// covers: the manifest's own, or those of every member of a workspace.
+func RegistryDependencies(manifest types.Manifest) (map[string]string, error) {
+	ws, err := LoadWorkspace(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if ws == nil {
+		return manifest.Dependencies, nil
+	}
+
+	deps := make(map[string]string)
+	for name, constraint := range ws.RegistryDependencies() {
+		deps[string(name)] = constraint
+	}
+
+	return deps, nil
+}
+
+// ResolvePinned resolves like ResolveLatest but additionally pins the given
+// packages (name -> version) to exact versions. Pinned versions may be yanked.
+func ResolvePinned(ctx context.Context, reg packagemanager.Registry, manifest types.Manifest, pins map[string]string) (map[packagemanager.PackageID]struct {
+	Version packagemanager.Version
+	CID     packagemanager.CID
+}, error) {
+	allowed := make(map[packagemanager.PackageID]packagemanager.Version, len(pins))
+	for name, version := range pins {
+		allowed[packagemanager.PackageID(name)] = packagemanager.Version(version)
+	}
+
+	return resolveManifest(ctx, reg, manifest, pins, allowed)
+}
+
+// resolveManifest resolves the manifest with pins applied; yanked versions
+// are only considered where allowYanked names them.
+func resolveManifest(ctx context.Context, reg packagemanager.Registry, manifest types.Manifest, pins map[string]string, allowYanked map[packagemanager.PackageID]packagemanager.Version) (map[packagemanager.PackageID]struct {
+	Version packagemanager.Version
+	CID     packagemanager.CID
+}, error) {
+	manager := packagemanager.NewManager(reg).AllowYanked(allowYanked)
+
+	ws, err := LoadWorkspace(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if ws != nil {
+		extra := make([]packagemanager.Requirement, 0, len(pins))
+		for name, version := range pins {
+			extra = append(extra, packagemanager.Requirement{
+				Name:       packagemanager.PackageID(name),
+				Constraint: "=" + version,
+			})
+		}
+
+		return manager.ResolveWorkspace(ctx, ws, extra, true)
+	}
+
+	if len(manifest.OptionalDependencies) > 0 || len(manifest.Features) > 0 {
+		pkg := ManifestPackage(manifest)
+		for i, dep := range pkg.Dependencies {
+			if version, ok := pins[string(dep.Name)]; ok {
+				pkg.Dependencies[i].Constraint = "=" + version
+			}
+		}
+
+		// the lockfile covers every feature so that any feature set builds from it
+		features := make([]string, 0, len(manifest.Features))
+		for name := range manifest.Features {
+			features = append(features, name)
+		}
+
+		return manager.ResolvePackage(ctx, pkg, features, true)
+	}
+
+	reqs := make([]packagemanager.Requirement, 0, len(manifest.Dependencies))
+	for name, constraint := range manifest.Dependencies {
+		if version, ok := pins[name]; ok {
+			constraint = "=" + version
+		}
+
+		reqs = append(reqs, packagemanager.Requirement{
+			Name:       packagemanager.PackageID(name),
+			Constraint: constraint,
+		})
+	}
+
+	return manager.ResolveAndFetch(ctx, reqs, true)
+}
@@ -0,0 +1,235 @@
+// Package ast - mutation operators for mutation testing.
+// Each operator is a small, semantics-changing rewrite of a single node. Sites
+// are enumerated in a deterministic pre-order walk so that a mutant can be
+// identified by its index and regenerated from a fresh tree.
+package ast
+
+import (
+	"fmt"
+
+	"github.com/orizon-lang/orizon/internal/position"
+)
+
+// MutationKind classifies a mutation operator.
+type MutationKind int
+
+const (
+	// MutationComparison negates a comparison operator (== to !=, < to >=, ...).
+	MutationComparison MutationKind = iota
+	// MutationArithmetic swaps an arithmetic operator (+ to -, * to /, ...).
+	MutationArithmetic
+	// MutationStatement removes an expression statement from its block.
+	MutationStatement
+	// MutationConstant changes a literal (n to n+1, true to false).
+	MutationConstant
+)
+
+// String returns the operator name used in reports.
+func (k MutationKind) String() string {
+	switch k {
+	case MutationComparison:
+		return "flip-comparison"
+	case MutationArithmetic:
+		return "swap-arithmetic"
+	case MutationStatement:
+		return "drop-statement"
+	case MutationConstant:
+		return "change-constant"
+	default:
+		return "unknown"
+	}
+}
+
+// Mutation describes one mutation site.
+type Mutation struct {
+	Original    string
+	Replacement string
+	Span        position.Span
+	Kind        MutationKind
+}
+
+// comparisonFlips maps each comparison to its negation.
+var comparisonFlips = map[Operator]Operator{
+	OpEq: OpNe, OpNe: OpEq,
+	OpLt: OpGe, OpGe: OpLt,
+	OpGt: OpLe, OpLe: OpGt,
+}
+
+// arithmeticSwaps maps arithmetic operators, including their compound
+// assignment forms, to a replacement of the same arity.
+var arithmeticSwaps = map[Operator]Operator{
+	OpAdd: OpSub, OpSub: OpAdd,
+	OpMul: OpDiv, OpDiv: OpMul, OpMod: OpMul,
+	OpAddAssign: OpSubAssign, OpSubAssign: OpAddAssign,
+	OpMulAssign: OpDivAssign, OpDivAssign: OpMulAssign, OpModAssign: OpMulAssign,
+}
+
+// mutationSite pairs a mutation with the rewrite that applies it.
+type mutationSite struct {
+	apply func()
+	Mutation
+}
+
+// MutationSites lists every mutation site under node in walk order.
+func MutationSites(node Node) []Mutation {
+	sites := collectMutationSites(node)
+	out := make([]Mutation, len(sites))
+
+	for i, s := range sites {
+		out[i] = s.Mutation
+	}
+
+	return out
+}
+
+// MutationTransformer applies the Index-th site reported by MutationSites.
+// The rewrite happens in place, so callers mutate a fresh copy of the tree
+// for each mutant.
+type MutationTransformer struct {
+	// Applied is set to the mutation performed by the last Transform call.
+	Applied *Mutation
+	Index   int
+}
+
+// Transform implements the Transformer interface for mutation testing.
+func (mt *MutationTransformer) Transform(node Node) (Node, error) {
+	sites := collectMutationSites(node)
+	if mt.Index < 0 || mt.Index >= len(sites) {
+		return nil, NewTransformationError(fmt.Sprintf("mutation %d out of range (%d sites)", mt.Index, len(sites)), node.GetSpan())
+	}
+
+	site := sites[mt.Index]
+	site.apply()
+	mt.Applied = &site.Mutation
+
+	return node, nil
+}
+
+func collectMutationSites(node Node) []mutationSite {
+	var sites []mutationSite
+
+	var walkExpr func(e Expression)
+
+	var walkStmt func(s Statement)
+
+	walkBlock := func(b *BlockStatement) {
+		if b == nil {
+			return
+		}
+
+		for i, st := range b.Statements {
+			if es, ok := st.(*ExpressionStatement); ok {
+				idx := i
+				sites = append(sites, mutationSite{
+					Mutation: Mutation{Kind: MutationStatement, Span: es.Span, Original: es.String(), Replacement: ""},
+					apply: func() {
+						b.Statements = append(b.Statements[:idx:idx], b.Statements[idx+1:]...)
+					},
+				})
+			}
+
+			walkStmt(st)
+		}
+	}
+
+	walkStmt = func(s Statement) {
+		switch n := s.(type) {
+		case *BlockStatement:
+			walkBlock(n)
+		case *ExpressionStatement:
+			walkExpr(n.Expression)
+		case *ReturnStatement:
+			walkExpr(n.Value)
+		case *IfStatement:
+			walkExpr(n.Condition)
+			walkBlock(n.ThenBlock)
+			walkStmt(n.ElseBlock)
+		case *WhileStatement:
+			walkExpr(n.Condition)
+			walkBlock(n.Body)
+		case *VariableDeclaration:
+			walkExpr(n.Value)
+		}
+	}
+
+	walkExpr = func(e Expression) {
+		switch n := e.(type) {
+		case *BinaryExpression:
+			if repl, ok := comparisonFlips[n.Operator]; ok {
+				sites = append(sites, operatorSite(n, MutationComparison, repl))
+			} else if repl, ok := arithmeticSwaps[n.Operator]; ok {
+				sites = append(sites, operatorSite(n, MutationArithmetic, repl))
+			}
+
+			walkExpr(n.Left)
+			walkExpr(n.Right)
+		case *UnaryExpression:
+			walkExpr(n.Operand)
+		case *CallExpression:
+			walkExpr(n.Function)
+
+			for _, a := range n.Arguments {
+				walkExpr(a)
+			}
+		case *MemberExpression:
+			walkExpr(n.Object)
+		case *Literal:
+			if site, ok := constantSite(n); ok {
+				sites = append(sites, site)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, d := range n.Declarations {
+			switch decl := d.(type) {
+			case *FunctionDeclaration:
+				walkBlock(decl.Body)
+			case *VariableDeclaration:
+				walkExpr(decl.Value)
+			}
+		}
+	case *FunctionDeclaration:
+		walkBlock(n.Body)
+	case Statement:
+		walkStmt(n)
+	case Expression:
+		walkExpr(n)
+	}
+
+	return sites
+}
+
+func operatorSite(n *BinaryExpression, kind MutationKind, repl Operator) mutationSite {
+	return mutationSite{
+		Mutation: Mutation{Kind: kind, Span: n.Span, Original: n.Operator.String(), Replacement: repl.String()},
+		apply:    func() { n.Operator = repl },
+	}
+}
+
+func constantSite(n *Literal) (mutationSite, bool) {
+	var (
+		value interface{}
+		raw   string
+	)
+
+	switch v := n.Value.(type) {
+	case bool:
+		value, raw = !v, fmt.Sprintf("%t", !v)
+	case int:
+		value, raw = v+1, fmt.Sprintf("%d", v+1)
+	case int64:
+		value, raw = v+1, fmt.Sprintf("%d", v+1)
+	default:
+		return mutationSite{}, false
+	}
+
+	return mutationSite{
+		Mutation: Mutation{Kind: MutationConstant, Span: n.Span, Original: n.String(), Replacement: raw},
+		apply: func() {
+			n.Value = value
+			n.Raw = raw
+		},
+	}, true
+}
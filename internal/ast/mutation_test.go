@@ -0,0 +1,94 @@
+package ast
+
+import (
+	"testing"
+)
+
+// buildMutationSubject builds:
+//
+//	if n < 10 { total += n; log(n); }
+//	return flag;
+func buildMutationSubject() *BlockStatement {
+	span := createTestSpanTransform(1, 1)
+	n := &Identifier{Span: span, Value: "n"}
+
+	return &BlockStatement{Span: span, Statements: []Statement{
+		&IfStatement{
+			Span:      span,
+			Condition: &BinaryExpression{Span: span, Left: n, Operator: OpLt, Right: &Literal{Span: span, Kind: LiteralInteger, Value: int64(10), Raw: "10"}},
+			ThenBlock: &BlockStatement{Span: span, Statements: []Statement{
+				&ExpressionStatement{Span: span, Expression: &BinaryExpression{Span: span, Left: &Identifier{Span: span, Value: "total"}, Operator: OpAddAssign, Right: n}},
+				&ExpressionStatement{Span: span, Expression: &CallExpression{Span: span, Function: &Identifier{Span: span, Value: "log"}, Arguments: []Expression{n}}},
+			}},
+		},
+		&ReturnStatement{Span: span, Value: &Literal{Span: span, Kind: LiteralBoolean, Value: true, Raw: "true"}},
+	}}
+}
+
+func TestMutationSites(t *testing.T) {
+	sites := MutationSites(buildMutationSubject())
+
+	want := []struct {
+		orig, repl string
+		kind       MutationKind
+	}{
+		{"<", ">=", MutationComparison},
+		{"10", "11", MutationConstant},
+		{"(total += n);", "", MutationStatement},
+		{"+=", "-=", MutationArithmetic},
+		{"log(n);", "", MutationStatement},
+		{"true", "false", MutationConstant},
+	}
+
+	if len(sites) != len(want) {
+		t.Fatalf("got %d sites, want %d: %+v", len(sites), len(want), sites)
+	}
+
+	for i, w := range want {
+		s := sites[i]
+		if s.Kind != w.kind || s.Original != w.orig || s.Replacement != w.repl {
+			t.Errorf("site %d = %s %q -> %q, want %s %q -> %q", i, s.Kind, s.Original, s.Replacement, w.kind, w.orig, w.repl)
+		}
+	}
+}
+
+func TestMutationTransformer(t *testing.T) {
+	// Each mutant is applied to a fresh tree.
+	block := buildMutationSubject()
+	mt := &MutationTransformer{Index: 0}
+
+	if _, err := mt.Transform(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if cond := block.Statements[0].(*IfStatement).Condition.(*BinaryExpression); cond.Operator != OpGe {
+		t.Errorf("comparison not flipped: %s", cond.Operator)
+	}
+
+	if mt.Applied == nil || mt.Applied.Kind != MutationComparison {
+		t.Errorf("applied mutation not recorded: %+v", mt.Applied)
+	}
+
+	block = buildMutationSubject()
+	if _, err := (&MutationTransformer{Index: 4}).Transform(block); err != nil {
+		t.Fatal(err)
+	}
+
+	then := block.Statements[0].(*IfStatement).ThenBlock
+	if len(then.Statements) != 1 || then.Statements[0].String() != "(total += n);" {
+		t.Errorf("statement not dropped: %v", then.Statements)
+	}
+
+	block = buildMutationSubject()
+	if _, err := (&MutationTransformer{Index: 5}).Transform(block); err != nil {
+		t.Fatal(err)
+	}
+
+	if lit := block.Statements[1].(*ReturnStatement).Value.(*Literal); lit.Value != false {
+		t.Errorf("constant not changed: %v", lit.Value)
+	}
+
+	if _, err := (&MutationTransformer{Index: 6}).Transform(buildMutationSubject()); err == nil {
+		t.Error("expected out of range error")
+	}
+}
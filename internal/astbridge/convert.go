@@ -67,6 +67,18 @@ func ToParserProgram(src *ast.Program) (*p.Program, error) {
 
 // fromParserProgram performs the actual parser to AST program conversion.
 // This internal method implements the core conversion logic with proper error handling.
+// FromParserBlock converts a statement block, such as a function body, to
+// its ast.BlockStatement form. Program conversion keeps function bodies
+// empty; this is the entry point for tools that rewrite bodies.
+func FromParserBlock(src *p.BlockStatement) (*ast.BlockStatement, error) {
+	return NewASTBridge().stmtConverter.fromParserBlockStatement(src)
+}
+
+// ToParserBlock converts an ast.BlockStatement back to the parser form.
+func ToParserBlock(src *ast.BlockStatement) (*p.BlockStatement, error) {
+	return NewASTBridge().stmtConverter.toParserBlockStatement(src)
+}
+
 func (ab *ASTBridge) fromParserProgram(src *p.Program) (*ast.Program, error) {
 	// Create the target AST program with proper capacity allocation.
 	dst := &ast.Program{
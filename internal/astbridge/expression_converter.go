@@ -44,6 +44,8 @@ func (ec *ExpressionConverter) FromParserExpression(expr p.Expression) (ast.Expr
 		return ec.fromParserCallExpression(concrete)
 	case *p.MemberExpression:
 		return ec.fromParserMemberExpression(concrete)
+	case *p.AssignmentExpression:
+		return ec.fromParserAssignmentExpression(concrete)
 	default:
 		return nil, fmt.Errorf("unsupported parser expression type: %T", expr)
 	}
@@ -186,36 +188,261 @@ func (ec *ExpressionConverter) mapASTLiteralKind(kind ast.LiteralKind) (p.Litera
 	}
 }
 
-// Stub implementations for complex expressions (to be expanded).
+// Parser member access is a BinaryExpression with a "." operator and an
+// identifier on the right; it maps to ast.MemberExpression and back.
+const memberOperator = "."
 
-func (ec *ExpressionConverter) fromParserBinaryExpression(expr *p.BinaryExpression) (*ast.BinaryExpression, error) {
-	return nil, fmt.Errorf("binary expression conversion not yet implemented")
+// binaryOperators maps parser operator spellings to AST operators. Assignment
+// operators are included because ast has no separate assignment node.
+var binaryOperators = map[string]ast.Operator{
+	"+": ast.OpAdd, "-": ast.OpSub, "*": ast.OpMul, "/": ast.OpDiv, "%": ast.OpMod, "**": ast.OpPow,
+	"==": ast.OpEq, "!=": ast.OpNe, "<": ast.OpLt, "<=": ast.OpLe, ">": ast.OpGt, ">=": ast.OpGe,
+	"&&": ast.OpAnd, "||": ast.OpOr,
+	"&": ast.OpBitAnd, "|": ast.OpBitOr, "^": ast.OpBitXor, "<<": ast.OpShl, ">>": ast.OpShr,
+	"=": ast.OpAssign, "+=": ast.OpAddAssign, "-=": ast.OpSubAssign, "*=": ast.OpMulAssign,
+	"/=": ast.OpDivAssign, "%=": ast.OpModAssign,
 }
 
-func (ec *ExpressionConverter) toParserBinaryExpression(expr *ast.BinaryExpression) (*p.BinaryExpression, error) {
-	return nil, fmt.Errorf("binary expression conversion not yet implemented")
+// unaryOperators maps parser prefix operators to AST operators.
+var unaryOperators = map[string]ast.Operator{
+	"-": ast.OpSub, "!": ast.OpNot, "~": ast.OpBitNot,
+}
+
+// isAssignmentOperator reports whether op is one of the assignment operators.
+func isAssignmentOperator(op ast.Operator) bool {
+	switch op {
+	case ast.OpAssign, ast.OpAddAssign, ast.OpSubAssign, ast.OpMulAssign, ast.OpDivAssign, ast.OpModAssign:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ec *ExpressionConverter) fromParserBinaryExpression(expr *p.BinaryExpression) (ast.Expression, error) {
+	if expr == nil || expr.Operator == nil {
+		return nil, fmt.Errorf("cannot convert nil parser binary expression")
+	}
+
+	if expr.Operator.Value == memberOperator {
+		member, ok := expr.Right.(*p.Identifier)
+		if !ok {
+			return nil, fmt.Errorf("member access with non-identifier member %T", expr.Right)
+		}
+
+		return ec.fromParserMemberExpression(&p.MemberExpression{Object: expr.Left, Member: member, Span: expr.Span})
+	}
+
+	return ec.fromParserOperands(expr.Left, expr.Operator, expr.Right, expr.Span)
+}
+
+func (ec *ExpressionConverter) fromParserAssignmentExpression(expr *p.AssignmentExpression) (*ast.BinaryExpression, error) {
+	if expr == nil || expr.Operator == nil {
+		return nil, fmt.Errorf("cannot convert nil parser assignment expression")
+	}
+
+	return ec.fromParserOperands(expr.Left, expr.Operator, expr.Right, expr.Span)
+}
+
+func (ec *ExpressionConverter) fromParserOperands(left p.Expression, op *p.Operator, right p.Expression, span p.Span) (*ast.BinaryExpression, error) {
+	astOp, ok := binaryOperators[op.Value]
+	if !ok {
+		return nil, fmt.Errorf("unsupported binary operator %q", op.Value)
+	}
+
+	l, err := ec.FromParserExpression(left)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert left operand: %w", err)
+	}
+
+	r, err := ec.FromParserExpression(right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert right operand: %w", err)
+	}
+
+	return &ast.BinaryExpression{
+		Span:     fromParserSpan(span),
+		Left:     l,
+		Operator: astOp,
+		Right:    r,
+	}, nil
+}
+
+func (ec *ExpressionConverter) toParserBinaryExpression(expr *ast.BinaryExpression) (p.Expression, error) {
+	if expr == nil {
+		return nil, fmt.Errorf("cannot convert nil AST binary expression")
+	}
+
+	l, err := ec.ToParserExpression(expr.Left)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert left operand: %w", err)
+	}
+
+	r, err := ec.ToParserExpression(expr.Right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert right operand: %w", err)
+	}
+
+	span := toParserSpan(expr.Span)
+
+	if isAssignmentOperator(expr.Operator) {
+		return &p.AssignmentExpression{
+			Span:     span,
+			Left:     l,
+			Operator: p.NewOperator(span, expr.Operator.String(), 0, p.RightAssociative, p.AssignmentOp),
+			Right:    r,
+		}, nil
+	}
+
+	return &p.BinaryExpression{
+		Span:     span,
+		Left:     l,
+		Operator: p.NewOperator(span, expr.Operator.String(), 0, p.LeftAssociative, p.BinaryOp),
+		Right:    r,
+	}, nil
 }
 
 func (ec *ExpressionConverter) fromParserUnaryExpression(expr *p.UnaryExpression) (*ast.UnaryExpression, error) {
-	return nil, fmt.Errorf("unary expression conversion not yet implemented")
+	if expr == nil || expr.Operator == nil {
+		return nil, fmt.Errorf("cannot convert nil parser unary expression")
+	}
+
+	op, ok := unaryOperators[expr.Operator.Value]
+	if !ok {
+		return nil, fmt.Errorf("unsupported unary operator %q", expr.Operator.Value)
+	}
+
+	operand, err := ec.FromParserExpression(expr.Operand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert unary operand: %w", err)
+	}
+
+	return &ast.UnaryExpression{
+		Span:     fromParserSpan(expr.Span),
+		Operator: op,
+		Operand:  operand,
+	}, nil
 }
 
 func (ec *ExpressionConverter) toParserUnaryExpression(expr *ast.UnaryExpression) (*p.UnaryExpression, error) {
-	return nil, fmt.Errorf("unary expression conversion not yet implemented")
+	if expr == nil {
+		return nil, fmt.Errorf("cannot convert nil AST unary expression")
+	}
+
+	operand, err := ec.ToParserExpression(expr.Operand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert unary operand: %w", err)
+	}
+
+	span := toParserSpan(expr.Span)
+
+	return &p.UnaryExpression{
+		Span:     span,
+		Operator: p.NewOperator(span, expr.Operator.String(), 0, p.RightAssociative, p.UnaryOp),
+		Operand:  operand,
+	}, nil
 }
 
 func (ec *ExpressionConverter) fromParserCallExpression(expr *p.CallExpression) (*ast.CallExpression, error) {
-	return nil, fmt.Errorf("call expression conversion not yet implemented")
+	if expr == nil {
+		return nil, fmt.Errorf("cannot convert nil parser call expression")
+	}
+
+	fn, err := ec.FromParserExpression(expr.Function)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert callee: %w", err)
+	}
+
+	args := make([]ast.Expression, 0, len(expr.Arguments))
+
+	for i, arg := range expr.Arguments {
+		a, err := ec.FromParserExpression(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert argument %d: %w", i, err)
+		}
+
+		args = append(args, a)
+	}
+
+	return &ast.CallExpression{
+		Span:      fromParserSpan(expr.Span),
+		Function:  fn,
+		Arguments: args,
+	}, nil
 }
 
 func (ec *ExpressionConverter) toParserCallExpression(expr *ast.CallExpression) (*p.CallExpression, error) {
-	return nil, fmt.Errorf("call expression conversion not yet implemented")
+	if expr == nil {
+		return nil, fmt.Errorf("cannot convert nil AST call expression")
+	}
+
+	fn, err := ec.ToParserExpression(expr.Function)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert callee: %w", err)
+	}
+
+	args := make([]p.Expression, 0, len(expr.Arguments))
+
+	for i, arg := range expr.Arguments {
+		a, err := ec.ToParserExpression(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert argument %d: %w", i, err)
+		}
+
+		args = append(args, a)
+	}
+
+	return &p.CallExpression{
+		Span:      toParserSpan(expr.Span),
+		Function:  fn,
+		Arguments: args,
+	}, nil
 }
 
 func (ec *ExpressionConverter) fromParserMemberExpression(expr *p.MemberExpression) (*ast.MemberExpression, error) {
-	return nil, fmt.Errorf("member expression conversion not yet implemented")
+	if expr == nil || expr.Member == nil {
+		return nil, fmt.Errorf("cannot convert nil parser member expression")
+	}
+
+	obj, err := ec.FromParserExpression(expr.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert member object: %w", err)
+	}
+
+	member, err := ec.fromParserIdentifier(expr.Member)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.MemberExpression{
+		Span:   fromParserSpan(expr.Span),
+		Object: obj,
+		Member: member,
+	}, nil
 }
 
-func (ec *ExpressionConverter) toParserMemberExpression(expr *ast.MemberExpression) (*p.MemberExpression, error) {
-	return nil, fmt.Errorf("member expression conversion not yet implemented")
+// toParserMemberExpression produces the BinaryExpression form the parser
+// itself emits for member access.
+func (ec *ExpressionConverter) toParserMemberExpression(expr *ast.MemberExpression) (*p.BinaryExpression, error) {
+	if expr == nil || expr.Member == nil {
+		return nil, fmt.Errorf("cannot convert nil AST member expression")
+	}
+
+	obj, err := ec.ToParserExpression(expr.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert member object: %w", err)
+	}
+
+	member, err := ec.toParserIdentifier(expr.Member)
+	if err != nil {
+		return nil, err
+	}
+
+	span := toParserSpan(expr.Span)
+
+	return &p.BinaryExpression{
+		Span:     span,
+		Left:     obj,
+		Operator: p.NewOperator(span, memberOperator, 0, p.LeftAssociative, p.BinaryOp),
+		Right:    member,
+	}, nil
 }
@@ -214,28 +214,198 @@ func (sc *StatementConverter) toParserReturnStatement(retStmt *ast.ReturnStateme
 	}, nil
 }
 
-// Stub implementations for complex statements (to be expanded).
+// fromParserBody converts a branch or loop body, wrapping a lone statement
+// into a block since the AST requires block bodies.
+func (sc *StatementConverter) fromParserBody(body p.Statement) (*ast.BlockStatement, error) {
+	if block, ok := body.(*p.BlockStatement); ok {
+		return sc.fromParserBlockStatement(block)
+	}
+
+	stmt, err := sc.FromParserStatement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.BlockStatement{Span: stmt.GetSpan(), Statements: []ast.Statement{stmt}}, nil
+}
 
 func (sc *StatementConverter) fromParserIfStatement(ifStmt *p.IfStatement) (*ast.IfStatement, error) {
-	return nil, fmt.Errorf("if statement conversion not yet implemented")
+	if ifStmt == nil {
+		return nil, fmt.Errorf("cannot convert nil parser if statement")
+	}
+
+	cond, err := sc.exprConverter.FromParserExpression(ifStmt.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert if condition: %w", err)
+	}
+
+	then, err := sc.fromParserBody(ifStmt.ThenStmt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert then branch: %w", err)
+	}
+
+	var elseStmt ast.Statement
+
+	if ifStmt.ElseStmt != nil {
+		elseStmt, err = sc.FromParserStatement(ifStmt.ElseStmt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert else branch: %w", err)
+		}
+	}
+
+	return &ast.IfStatement{
+		Span:      fromParserSpan(ifStmt.Span),
+		Condition: cond,
+		ThenBlock: then,
+		ElseBlock: elseStmt,
+	}, nil
 }
 
 func (sc *StatementConverter) toParserIfStatement(ifStmt *ast.IfStatement) (*p.IfStatement, error) {
-	return nil, fmt.Errorf("if statement conversion not yet implemented")
+	if ifStmt == nil {
+		return nil, fmt.Errorf("cannot convert nil AST if statement")
+	}
+
+	cond, err := sc.exprConverter.ToParserExpression(ifStmt.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert if condition: %w", err)
+	}
+
+	then, err := sc.toParserBlockStatement(ifStmt.ThenBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert then branch: %w", err)
+	}
+
+	var elseStmt p.Statement
+
+	if ifStmt.ElseBlock != nil {
+		elseStmt, err = sc.ToParserStatement(ifStmt.ElseBlock)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert else branch: %w", err)
+		}
+	}
+
+	return &p.IfStatement{
+		Span:      toParserSpan(ifStmt.Span),
+		Condition: cond,
+		ThenStmt:  then,
+		ElseStmt:  elseStmt,
+	}, nil
 }
 
 func (sc *StatementConverter) fromParserWhileStatement(whileStmt *p.WhileStatement) (*ast.WhileStatement, error) {
-	return nil, fmt.Errorf("while statement conversion not yet implemented")
+	if whileStmt == nil {
+		return nil, fmt.Errorf("cannot convert nil parser while statement")
+	}
+
+	cond, err := sc.exprConverter.FromParserExpression(whileStmt.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert while condition: %w", err)
+	}
+
+	body, err := sc.fromParserBody(whileStmt.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert while body: %w", err)
+	}
+
+	return &ast.WhileStatement{
+		Span:      fromParserSpan(whileStmt.Span),
+		Condition: cond,
+		Body:      body,
+	}, nil
 }
 
 func (sc *StatementConverter) toParserWhileStatement(whileStmt *ast.WhileStatement) (*p.WhileStatement, error) {
-	return nil, fmt.Errorf("while statement conversion not yet implemented")
+	if whileStmt == nil {
+		return nil, fmt.Errorf("cannot convert nil AST while statement")
+	}
+
+	cond, err := sc.exprConverter.ToParserExpression(whileStmt.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert while condition: %w", err)
+	}
+
+	body, err := sc.toParserBlockStatement(whileStmt.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert while body: %w", err)
+	}
+
+	return &p.WhileStatement{
+		Span:      toParserSpan(whileStmt.Span),
+		Condition: cond,
+		Body:      body,
+	}, nil
 }
 
+// fromParserVariableDeclaration converts a local let/var binding. The type
+// annotation is optional for locals, so a missing one stays nil.
 func (sc *StatementConverter) fromParserVariableDeclaration(varDecl *p.VariableDeclaration) (*ast.VariableDeclaration, error) {
-	return nil, fmt.Errorf("variable declaration conversion not yet implemented")
+	if varDecl == nil || varDecl.Name == nil {
+		return nil, fmt.Errorf("cannot convert nil parser variable declaration")
+	}
+
+	var (
+		typ   ast.Type
+		value ast.Expression
+		err   error
+	)
+
+	if varDecl.TypeSpec != nil {
+		typ, err = sc.typeConverter.FromParserType(varDecl.TypeSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert variable type: %w", err)
+		}
+	}
+
+	if varDecl.Initializer != nil {
+		value, err = sc.exprConverter.FromParserExpression(varDecl.Initializer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert variable initializer: %w", err)
+		}
+	}
+
+	return &ast.VariableDeclaration{
+		Span:       fromParserSpan(varDecl.Span),
+		Name:       &ast.Identifier{Span: fromParserSpan(varDecl.Name.Span), Value: varDecl.Name.Value},
+		Type:       typ,
+		Value:      value,
+		Kind:       ast.VarKindLet,
+		IsMutable:  varDecl.IsMutable,
+		IsExported: varDecl.IsPublic,
+	}, nil
 }
 
 func (sc *StatementConverter) toParserVariableDeclaration(varDecl *ast.VariableDeclaration) (*p.VariableDeclaration, error) {
-	return nil, fmt.Errorf("variable declaration conversion not yet implemented")
+	if varDecl == nil || varDecl.Name == nil {
+		return nil, fmt.Errorf("cannot convert nil AST variable declaration")
+	}
+
+	var (
+		typ   p.Type
+		value p.Expression
+		err   error
+	)
+
+	if varDecl.Type != nil {
+		typ, err = sc.typeConverter.ToParserType(varDecl.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert variable type: %w", err)
+		}
+	}
+
+	if varDecl.Value != nil {
+		value, err = sc.exprConverter.ToParserExpression(varDecl.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert variable initializer: %w", err)
+		}
+	}
+
+	return &p.VariableDeclaration{
+		Span:        toParserSpan(varDecl.Span),
+		Name:        &p.Identifier{Value: varDecl.Name.Value, Span: toParserSpan(varDecl.Name.Span)},
+		TypeSpec:    typ,
+		Initializer: value,
+		IsMutable:   varDecl.IsMutable || varDecl.Kind == ast.VarKindVar,
+		IsPublic:    varDecl.IsExported,
+	}, nil
 }
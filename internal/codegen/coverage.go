@@ -0,0 +1,246 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/orizon-lang/orizon/internal/debug"
+	"github.com/orizon-lang/orizon/internal/hir"
+	"github.com/orizon-lang/orizon/internal/mir"
+	"github.com/orizon-lang/orizon/internal/position"
+)
+
+// CoverageHit is the runtime routine called at the start of every basic
+// block of code lowered with coverage, with the block's counter as argument.
+// The test interpreter counts the blocks with a tracer instead.
+const CoverageHit = "orizon_cov_hit"
+
+// CoverageInit is the runtime routine a program built with coverage calls
+// on entry to main, with its number of counters. The runtime writes the
+// counters out when the program exits.
+const CoverageInit = "orizon_cov_init"
+
+// CoverageCounters is the symbol of the counters CoverageHit increments in
+// assembly output, one quadword per counter.
+const CoverageCounters = "orizon_cov_counters"
+
+// InsertCoverageInit prepends the call to CoverageInit with n counters to
+// the entry block of main. It reports whether m has a main function.
+func InsertCoverageInit(m *mir.Module, n int) bool {
+	for _, f := range m.Functions {
+		if f.Name != "main" || len(f.Blocks) == 0 {
+			continue
+		}
+
+		entry := f.Blocks[0]
+		init := mir.Call{Callee: CoverageInit, Args: []mir.Value{{Kind: mir.ValConstInt, Int64: int64(n), Class: mir.ClassInt}}}
+		entry.Instr = append([]mir.Instr{init}, entry.Instr...)
+
+		return true
+	}
+
+	return false
+}
+
+// EmitCoverageCounters emits the zeroed storage of n counters for assembly
+// output of code lowered with coverage.
+func EmitCoverageCounters(n int) string {
+	return fmt.Sprintf("%s:\n  times %d dq 0\n", CoverageCounters, n)
+}
+
+// LowerToMIRWithCoverage lowers p like LowerToMIR and inserts a call to
+// CoverageHit at the start of every reachable basic block. Counters are
+// numbered on from len(sm.Blocks) and their blocks appended to sm, so one
+// source map can collect the counters of several programs.
+func LowerToMIRWithCoverage(p *hir.HIRProgram, sm *debug.SourceMap) *mir.Module {
+	return lowerToMIR(p, &coverageRecorder{
+		sm:    sm,
+		lines: make(map[*mir.BasicBlock][]position.Position),
+		conds: make(map[*mir.BasicBlock]position.Position),
+	})
+}
+
+// coverageRecorder remembers which statements and conditions were lowered
+// into which block until the function is instrumented.
+type coverageRecorder struct {
+	sm    *debug.SourceMap
+	lines map[*mir.BasicBlock][]position.Position
+	conds map[*mir.BasicBlock]position.Position
+}
+
+func (c *lowerCtx) coverage() *coverageRecorder {
+	if c == nil {
+		return nil
+	}
+
+	return c.cov
+}
+
+// mark attributes the first line of statement or condition n to bb.
+func (c *lowerCtx) mark(bb *mir.BasicBlock, n hir.HIRNode) {
+	if c.coverage() == nil || bb == nil || n == nil {
+		return
+	}
+
+	// A block's lines are those of its statements.
+	if _, ok := n.(*hir.HIRBlockStatement); ok {
+		return
+	}
+
+	if start := n.GetSpan().Start; start.Line > 0 {
+		c.cov.lines[bb] = append(c.cov.lines[bb], start)
+	}
+}
+
+// markCond records the operand each conditional branch emitted for e tests.
+// lowerCondToBr branches on the operands of !, && and || from left to right,
+// the first in first and each following one in the next block it added.
+func (c *lowerCtx) markCond(e hir.HIRExpression, first *mir.BasicBlock, added []*mir.BasicBlock) {
+	if c.coverage() == nil {
+		return
+	}
+
+	bbs := append([]*mir.BasicBlock{first}, added...)
+
+	for i, leaf := range conditionLeaves(e, nil) {
+		if i >= len(bbs) {
+			break
+		}
+
+		bb := bbs[i]
+		if len(bb.Instr) == 0 {
+			continue
+		}
+
+		if _, ok := bb.Instr[len(bb.Instr)-1].(mir.CondBr); ok {
+			c.cov.conds[bb] = leaf.GetSpan().Start
+		}
+	}
+}
+
+// conditionLeaves lists the operands of e that lowerCondToBr branches on.
+func conditionLeaves(e hir.HIRExpression, out []hir.HIRExpression) []hir.HIRExpression {
+	switch x := e.(type) {
+	case nil:
+		return out
+	case *hir.HIRUnaryExpression:
+		if x.Operator == "!" {
+			return conditionLeaves(x.Operand, out)
+		}
+	case *hir.HIRBinaryExpression:
+		if x.Operator == "&&" || x.Operator == "||" {
+			return conditionLeaves(x.Right, conditionLeaves(x.Left, out))
+		}
+	}
+
+	return append(out, e)
+}
+
+// instrument numbers the reachable blocks of f, records them in the source
+// map and prepends the counter call to each.
+func (r *coverageRecorder) instrument(f *mir.Function) {
+	if r == nil || len(f.Blocks) == 0 {
+		return
+	}
+
+	reachable := reachableBlocks(f)
+	ids := make(map[string]int)
+
+	for _, bb := range f.Blocks {
+		if !reachable[bb.Name] {
+			continue
+		}
+
+		ids[bb.Name] = len(r.sm.Blocks)
+		r.sm.Blocks = append(r.sm.Blocks, r.blockRange(f, bb))
+	}
+
+	for _, bb := range f.Blocks {
+		id, ok := ids[bb.Name]
+		if !ok {
+			continue
+		}
+
+		br := &r.sm.Blocks[id]
+
+		for _, target := range successors(bb) {
+			if sid, ok := ids[target]; ok {
+				br.Succs = append(br.Succs, sid)
+			}
+		}
+
+		hit := mir.Call{Callee: CoverageHit, Args: []mir.Value{{Kind: mir.ValConstInt, Int64: int64(id), Class: mir.ClassInt}}}
+		bb.Instr = append([]mir.Instr{hit}, bb.Instr...)
+	}
+}
+
+func (r *coverageRecorder) blockRange(f *mir.Function, bb *mir.BasicBlock) debug.BlockRange {
+	br := debug.BlockRange{Function: f.Name, Block: bb.Name, Counter: len(r.sm.Blocks)}
+
+	seen := make(map[int]bool)
+
+	for _, pos := range r.lines[bb] {
+		if br.File == "" {
+			br.File = pos.Filename
+		}
+
+		if !seen[pos.Line] {
+			seen[pos.Line] = true
+			br.Lines = append(br.Lines, pos.Line)
+		}
+	}
+
+	sort.Ints(br.Lines)
+
+	if pos, ok := r.conds[bb]; ok {
+		br.Cond = &debug.LineEntry{File: pos.Filename, Line: pos.Line, Column: pos.Column}
+		if br.File == "" {
+			br.File = pos.Filename
+		}
+	}
+
+	return br
+}
+
+// successors returns the targets of bb's terminator: the target of a jump,
+// or the true and false targets of a conditional branch.
+func successors(bb *mir.BasicBlock) []string {
+	if len(bb.Instr) == 0 {
+		return nil
+	}
+
+	switch t := bb.Instr[len(bb.Instr)-1].(type) {
+	case mir.Br:
+		return []string{t.Target}
+	case mir.CondBr:
+		return []string{t.True, t.False}
+	default:
+		return nil
+	}
+}
+
+// reachableBlocks returns the names of the blocks reachable from the entry
+// block. Lowering leaves unreachable continuation blocks after returns.
+func reachableBlocks(f *mir.Function) map[string]bool {
+	byName := make(map[string]*mir.BasicBlock, len(f.Blocks))
+	for _, bb := range f.Blocks {
+		byName[bb.Name] = bb
+	}
+
+	seen := map[string]bool{f.Blocks[0].Name: true}
+	work := []*mir.BasicBlock{f.Blocks[0]}
+
+	for len(work) > 0 {
+		bb := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		for _, target := range successors(bb) {
+			if next, ok := byName[target]; ok && !seen[target] {
+				seen[target] = true
+				work = append(work, next)
+			}
+		}
+	}
+
+	return seen
+}
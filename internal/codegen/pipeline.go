@@ -42,6 +42,11 @@ func getBinOpFromAssignOp(assignOp string) (mir.BinOpKind, bool) {
 // Currently supports lowering function declarations with return statements of literal values.
 // TODO: Extend to full expression/statement coverage, control flow, and SSA values.
 func LowerToMIR(p *hir.HIRProgram) *mir.Module {
+	return lowerToMIR(p, nil)
+}
+
+// lowerToMIR implements LowerToMIR; a non-nil cov inserts coverage counters.
+func lowerToMIR(p *hir.HIRProgram, cov *coverageRecorder) *mir.Module {
 	if p == nil {
 		return &mir.Module{Name: "<nil>"}
 	}
@@ -103,6 +108,9 @@ func LowerToMIR(p *hir.HIRProgram) *mir.Module {
 			// 関数本文の lowering.
 			if fd.Body != nil {
 				var ctx *lowerCtx = nil
+				if cov != nil {
+					ctx = &lowerCtx{cov: cov}
+				}
 
 				lowerHIRStmtBlock(fd.Body, &blocks, &entry, newTemp, env, ctx)
 			}
@@ -111,6 +119,7 @@ func LowerToMIR(p *hir.HIRProgram) *mir.Module {
 			ensureTerminator(entry)
 
 			f.Blocks = blocks
+			cov.instrument(f)
 			m.Functions = append(m.Functions, f)
 		}
 	}
@@ -848,12 +857,15 @@ func newBlockLabel(prefix string, newTemp func() string) string {
 
 // loop context for break/continue targets.
 type lowerCtx struct {
+	cov           *coverageRecorder
 	breakLabel    string
 	continueLabel string
 }
 
 // lowerHIRStmt lowers a single HIR statement into MIR.
 func lowerHIRStmt(st hir.HIRStatement, blocks *[]*mir.BasicBlock, cur **mir.BasicBlock, newTemp func() string, env map[string]bool, ctx *lowerCtx) {
+	ctx.mark(*cur, st)
+
 	switch s := st.(type) {
 	case *hir.HIRReturnStatement:
 		var retVal *mir.Value
@@ -1124,7 +1136,9 @@ func lowerHIRStmt(st hir.HIRStatement, blocks *[]*mir.BasicBlock, cur **mir.Basi
 		elseLbl := newBlockLabel("else", newTemp)
 		endLbl := newBlockLabel("endif", newTemp)
 		// cond (with short-circuit CFG).
+		condBB, n := *cur, len(*blocks)
 		lowerCondToBr(s.Condition, blocks, cur, newTemp, env, thenLbl, elseLbl)
+		ctx.markCond(s.Condition, condBB, (*blocks)[n:])
 		thenBB := &mir.BasicBlock{Name: thenLbl}
 		*blocks = append(*blocks, thenBB)
 		// lower then.
@@ -1161,14 +1175,17 @@ func lowerHIRStmt(st hir.HIRStatement, blocks *[]*mir.BasicBlock, cur **mir.Basi
 		headBB := &mir.BasicBlock{Name: head}
 		*blocks = append(*blocks, headBB)
 		// cond in head (with short-circuit CFG).
+		ctx.mark(headBB, s.Condition)
+		condBB, n := headBB, len(*blocks)
 		lowerCondToBr(s.Condition, blocks, &headBB, newTemp, env, body, tail)
+		ctx.markCond(s.Condition, condBB, (*blocks)[n:])
 		// body.
 		bodyBB := &mir.BasicBlock{Name: body}
 		*blocks = append(*blocks, bodyBB)
 		curBody := bodyBB
 
 		if s.Body != nil {
-			loopCtx := &lowerCtx{breakLabel: tail, continueLabel: head}
+			loopCtx := &lowerCtx{breakLabel: tail, continueLabel: head, cov: ctx.coverage()}
 			lowerHIRStmt(s.Body, blocks, &curBody, newTemp, env, loopCtx)
 		}
 		// loop back.
@@ -1193,7 +1210,10 @@ func lowerHIRStmt(st hir.HIRStatement, blocks *[]*mir.BasicBlock, cur **mir.Basi
 		*blocks = append(*blocks, headBB)
 		// cond (optional, with short-circuit CFG).
 		if s.Condition != nil {
+			ctx.mark(headBB, s.Condition)
+			condBB, n := headBB, len(*blocks)
 			lowerCondToBr(s.Condition, blocks, &headBB, newTemp, env, body, tail)
+			ctx.markCond(s.Condition, condBB, (*blocks)[n:])
 		} else {
 			headBB.Instr = append(headBB.Instr, mir.Br{Target: body})
 		}
@@ -1207,7 +1227,7 @@ func lowerHIRStmt(st hir.HIRStatement, blocks *[]*mir.BasicBlock, cur **mir.Basi
 			contTarget = update
 		}
 
-		loopCtx := &lowerCtx{breakLabel: tail, continueLabel: contTarget}
+		loopCtx := &lowerCtx{breakLabel: tail, continueLabel: contTarget, cov: ctx.coverage()}
 		if s.Body != nil {
 			lowerHIRStmt(s.Body, blocks, &curBody, newTemp, env, loopCtx)
 		}
@@ -13,7 +13,24 @@ import (
 type SourceMap struct {
 	Files     []string         `json:"files"`
 	Functions []FunctionRanges `json:"functions"`
-	Version   int              `json:"version"`
+	// Blocks is filled in when the MIR is lowered with coverage counters,
+	// one entry per counter.
+	Blocks  []BlockRange `json:"blocks,omitempty"`
+	Version int          `json:"version"`
+}
+
+// BlockRange maps the coverage counter of a MIR basic block back to the
+// source lines of the statements lowered into it. Succs lists the counters
+// of the successor blocks: one for a jump, true then false target for a
+// conditional branch, whose tested condition starts at Cond.
+type BlockRange struct {
+	Cond     *LineEntry `json:"cond,omitempty"`
+	Function string     `json:"function"`
+	Block    string     `json:"block"`
+	File     string     `json:"file,omitempty"`
+	Lines    []int      `json:"lines,omitempty"`
+	Succs    []int      `json:"succs,omitempty"`
+	Counter  int        `json:"counter"`
 }
 
 // FunctionRanges captures the source line ranges that belong to a function per file.
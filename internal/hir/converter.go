@@ -187,9 +187,19 @@ func (c *ASTToHIRConverter) convertFunctionDeclaration(astFunc *ast.FunctionDecl
 	}
 
 	// Build parameter types for function signature.
+	supported := hirReturnType != nil
 	paramTypes := make([]HIRType, len(astFunc.Parameters))
+
 	for i, param := range astFunc.Parameters {
 		paramTypes[i] = c.convertType(param.Type)
+		if paramTypes[i] == nil {
+			supported = false
+		}
+	}
+
+	// Unsupported signature types have been reported by convertType.
+	if !supported {
+		return nil
 	}
 
 	// Build function type and add to global symbol table.
@@ -323,7 +333,10 @@ func (c *ASTToHIRConverter) convertVariableDeclaration(astVar *ast.VariableDecla
 	// Convert type.
 	var hirType HIRType
 	if astVar.Type != nil {
-		hirType = c.convertType(astVar.Type)
+		// An unsupported type has been reported by convertType.
+		if hirType = c.convertType(astVar.Type); hirType == nil {
+			return nil
+		}
 	} else {
 		// Type inference from initializer.
 		if astVar.Value != nil {
@@ -849,6 +862,13 @@ func (c *ASTToHIRConverter) convertType(astType ast.Type) HIRType {
 	switch typ := astType.(type) {
 	case *ast.BasicType:
 		return c.typeBuilder.BuildBasicType(typ.Kind.String(), typ.GetSpan())
+	case nil:
+		c.addError(ConversionError{
+			Message: "missing type",
+			Kind:    ErrorKindTypeError,
+		})
+
+		return nil
 	default:
 		c.addError(ConversionError{
 			Message: fmt.Sprintf("unsupported type: %T", typ),
@@ -0,0 +1,223 @@
+package packagemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ErrNotSupported is returned when a registry cannot perform an operation,
+// such as release management on a registry without package metadata.
+var ErrNotSupported = errors.New("not supported by this registry")
+
+// PackageAdmin is implemented by registries that keep release metadata:
+// yanked versions, deprecation notices and the owners allowed to publish a
+// package. Yanked versions stay fetchable, so existing lockfiles keep working,
+// but new resolutions skip them.
+type PackageAdmin interface {
+	// Yank marks name@version as yanked, or restores it when yanked is false.
+	Yank(ctx context.Context, name PackageID, version Version, yanked bool) error
+	// Deprecate sets the deprecation notice of a package; an empty message clears it.
+	Deprecate(ctx context.Context, name PackageID, message string) error
+	// Owners lists the users allowed to publish and manage a package.
+	Owners(ctx context.Context, name PackageID) ([]string, error)
+	AddOwner(ctx context.Context, name PackageID, owner string) error
+	// RemoveOwner refuses to remove the last owner.
+	RemoveOwner(ctx context.Context, name PackageID, owner string) error
+}
+
+// packageMeta is the release metadata a FileRegistry keeps per package.
+type packageMeta struct {
+	Yanked     map[Version]bool `json:"yanked,omitempty"`
+	Deprecated string           `json:"deprecated,omitempty"`
+	Owners     []string         `json:"owners,omitempty"`
+}
+
+// IsOwner reports whether user is one of owners.
+func IsOwner(owners []string, user string) bool {
+	for _, o := range owners {
+		if o == user {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *FileRegistry) metaPath() string {
+	return filepath.Join(r.baseDir, "packages.json")
+}
+
+// loadMeta reads packages.json; a missing file means no metadata.
+func (r *FileRegistry) loadMeta() error {
+	b, err := os.ReadFile(r.metaPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, &r.meta); err != nil {
+		return fmt.Errorf("parse %s: %w", r.metaPath(), err)
+	}
+
+	return nil
+}
+
+// updateMeta applies fn to the metadata of name and persists the result.
+func (r *FileRegistry) updateMeta(name PackageID, fn func(m *packageMeta) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.meta[name]
+	if m == nil {
+		m = &packageMeta{}
+	}
+
+	if err := fn(m); err != nil {
+		return err
+	}
+
+	r.meta[name] = m
+
+	b, err := json.MarshalIndent(r.meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.metaPath(), b, 0o644)
+}
+
+// withMeta returns pv as a manifest carrying its release metadata; callers hold the read lock.
+func (r *FileRegistry) withMeta(pv PackageVersion) PackageManifest {
+	mf := pv.manifest()
+	if m := r.meta[pv.Name]; m != nil {
+		mf.Yanked = m.Yanked[pv.Version]
+		mf.Deprecated = m.Deprecated
+	}
+
+	return mf
+}
+
+// Yank marks name@version as yanked, or restores it.
+func (r *FileRegistry) Yank(ctx context.Context, name PackageID, version Version, yanked bool) error {
+	if _, ok := r.lookupCID(name, version); !ok {
+		return fmt.Errorf("yank %s@%s: %w", name, version, ErrNotFound)
+	}
+
+	return r.updateMeta(name, func(m *packageMeta) error {
+		if !yanked {
+			delete(m.Yanked, version)
+
+			return nil
+		}
+
+		if m.Yanked == nil {
+			m.Yanked = make(map[Version]bool)
+		}
+
+		m.Yanked[version] = true
+
+		return nil
+	})
+}
+
+// Deprecate sets or clears the deprecation notice of name.
+func (r *FileRegistry) Deprecate(ctx context.Context, name PackageID, message string) error {
+	if !r.hasPackage(name) {
+		return fmt.Errorf("deprecate %s: %w", name, ErrNotFound)
+	}
+
+	return r.updateMeta(name, func(m *packageMeta) error {
+		m.Deprecated = message
+
+		return nil
+	})
+}
+
+// Owners lists the owners of name; a package nobody has claimed has none.
+func (r *FileRegistry) Owners(ctx context.Context, name PackageID) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if m := r.meta[name]; m != nil {
+		return append([]string(nil), m.Owners...), nil
+	}
+
+	return nil, nil
+}
+
+// AddOwner adds owner to name, which must have been published.
+func (r *FileRegistry) AddOwner(ctx context.Context, name PackageID, owner string) error {
+	if owner == "" {
+		return errors.New("owner name required")
+	}
+
+	if !r.hasPackage(name) {
+		return fmt.Errorf("add owner to %s: %w", name, ErrNotFound)
+	}
+
+	return r.updateMeta(name, func(m *packageMeta) error {
+		if !IsOwner(m.Owners, owner) {
+			m.Owners = append(m.Owners, owner)
+			sort.Strings(m.Owners)
+		}
+
+		return nil
+	})
+}
+
+// RemoveOwner removes owner from name, keeping at least one owner.
+func (r *FileRegistry) RemoveOwner(ctx context.Context, name PackageID, owner string) error {
+	return r.updateMeta(name, func(m *packageMeta) error {
+		if !IsOwner(m.Owners, owner) {
+			return fmt.Errorf("%s is not an owner of %s", owner, name)
+		}
+
+		if len(m.Owners) == 1 {
+			return fmt.Errorf("cannot remove %s, the last owner of %s", owner, name)
+		}
+
+		kept := m.Owners[:0]
+		for _, o := range m.Owners {
+			if o != owner {
+				kept = append(kept, o)
+			}
+		}
+
+		m.Owners = kept
+
+		return nil
+	})
+}
+
+func (r *FileRegistry) lookupCID(name PackageID, version Version) (CID, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.rev[string(name)+"@"+string(version)]
+
+	return id, ok
+}
+
+func (r *FileRegistry) hasPackage(name PackageID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.index[name]) > 0
+}
+
+// adminOf returns reg's release management, or ErrNotSupported.
+func adminOf(reg Registry) (PackageAdmin, error) {
+	if a, ok := reg.(PackageAdmin); ok {
+		return a, nil
+	}
+
+	return nil, ErrNotSupported
+}
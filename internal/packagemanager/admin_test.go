@@ -0,0 +1,198 @@
+package packagemanager
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYank_SkippedByNewResolutionsButAllowedFromLockfile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	fr, err := NewFileRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []Version{"1.0.0", "1.1.0"} {
+		if _, err := fr.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: v}, Data: []byte("lib-" + v)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := fr.Yank(ctx, "lib", "1.1.0", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fr.Deprecate(ctx, "lib", "use lib2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Metadata survives a restart.
+	fr, err = NewFileRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := fr.List(ctx, "lib")
+	if err != nil || len(list) != 2 || list[0].Yanked || !list[1].Yanked || list[1].Deprecated != "use lib2" {
+		t.Fatalf("list: %+v %v", list, err)
+	}
+
+	reqs := []Requirement{{Name: "lib", Constraint: "^1.0.0"}}
+
+	res, err := NewManager(fr).ResolveAndFetch(ctx, reqs, true)
+	if err != nil || res["lib"].Version != "1.0.0" {
+		t.Fatalf("new resolution picked %v: %v", res, err)
+	}
+
+	res, err = NewManager(fr).AllowYanked(map[PackageID]Version{"lib": "1.1.0"}).ResolveAndFetch(ctx, reqs, true)
+	if err != nil || res["lib"].Version != "1.1.0" {
+		t.Fatalf("locked resolution picked %v: %v", res, err)
+	}
+
+	_, err = NewManager(fr).ResolveAndFetch(ctx, []Requirement{{Name: "lib", Constraint: "^1.1.0"}}, true)
+
+	var ce *ConflictError
+	if !errors.As(err, &ce) || !strings.Contains(ce.Explanation, "Yanked versions of lib were not considered: 1.1.0.") {
+		t.Fatalf("expected conflict naming the yanked version, got %v", err)
+	}
+
+	if err := fr.Yank(ctx, "lib", "9.9.9", true); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected yanking an unknown version to fail, got %v", err)
+	}
+}
+
+func TestOwners_EnforcedOnPublish(t *testing.T) {
+	ctx := context.Background()
+
+	users := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(users, []byte(`{"alice":"tok-alice","bob":"tok-bob"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ORIZON_REGISTRY_TOKEN", "")
+	t.Setenv("ORIZON_REGISTRY_USERS", users)
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(buildHTTPMux(fr))
+	defer srv.Close()
+
+	alice := NewHTTPRegistryWithAuth(srv.URL, "tok-alice")
+	bob := NewHTTPRegistryWithAuth(srv.URL, "tok-bob")
+	blob := func(v Version) PackageBlob {
+		return PackageBlob{Manifest: PackageManifest{Name: "lib", Version: v}, Data: []byte("lib-" + v)}
+	}
+
+	if _, err := NewHTTPRegistryWithAuth(srv.URL, "wrong").Publish(ctx, blob("1.0.0")); err == nil {
+		t.Fatalf("expected unknown token to be rejected")
+	}
+
+	if _, err := alice.Publish(ctx, blob("1.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	owners, err := bob.Owners(ctx, "lib")
+	if err != nil || len(owners) != 1 || owners[0] != "alice" {
+		t.Fatalf("first publisher should own the package: %v %v", owners, err)
+	}
+
+	if _, err := bob.Publish(ctx, blob("1.1.0")); err == nil || !strings.Contains(err.Error(), "not an owner") {
+		t.Fatalf("expected non-owner publish to be rejected, got %v", err)
+	}
+
+	if err := bob.Yank(ctx, "lib", "1.0.0", true); err == nil {
+		t.Fatalf("expected non-owner yank to be rejected")
+	}
+
+	if err := alice.AddOwner(ctx, "lib", "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bob.Publish(ctx, blob("1.1.0")); err != nil {
+		t.Fatalf("co-owner publish: %v", err)
+	}
+
+	if err := bob.Yank(ctx, "lib", "1.1.0", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, mf, err := alice.Find(ctx, "lib", nil); err != nil || mf.Version != "1.0.0" {
+		t.Fatalf("find should skip the yanked release: %+v %v", mf, err)
+	}
+
+	if err := bob.RemoveOwner(ctx, "lib", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bob.RemoveOwner(ctx, "lib", "bob"); err == nil {
+		t.Fatalf("expected removing the last owner to fail")
+	}
+}
+
+func TestOwners_UnclaimedPackagesAreAdminOnly(t *testing.T) {
+	ctx := context.Background()
+
+	users := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(users, []byte(`{"bob":"tok-bob"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("ORIZON_REGISTRY_TOKEN", "tok-admin")
+	t.Setenv("ORIZON_REGISTRY_USERS", users)
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(buildHTTPMux(fr))
+	defer srv.Close()
+
+	admin := NewHTTPRegistryWithAuth(srv.URL, "tok-admin")
+	bob := NewHTTPRegistryWithAuth(srv.URL, "tok-bob")
+
+	if _, err := admin.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: "1.0.0"}, Data: []byte("lib")}); err != nil {
+		t.Fatal(err)
+	}
+
+	forbidden := func(what string, err error) {
+		t.Helper()
+
+		if err == nil || !strings.Contains(err.Error(), "only the registry admin may manage it") {
+			t.Errorf("%s: expected 403, got %v", what, err)
+		}
+	}
+
+	forbidden("yank", bob.Yank(ctx, "lib", "1.0.0", true))
+	forbidden("deprecate", bob.Deprecate(ctx, "lib", "gone"))
+	forbidden("claim", bob.AddOwner(ctx, "lib", "bob"))
+
+	_, err = bob.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: "1.1.0"}, Data: []byte("lib-1.1")})
+	forbidden("publish", err)
+
+	if owners, err := fr.Owners(ctx, "lib"); err != nil || len(owners) != 0 {
+		t.Fatalf("lib should stay unclaimed: %v %v", owners, err)
+	}
+
+	if err := admin.AddOwner(ctx, "squatted", "bob"); err == nil {
+		t.Fatalf("expected adding an owner to an unknown package to fail")
+	}
+
+	if err := admin.AddOwner(ctx, "lib", "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bob.Yank(ctx, "lib", "1.0.0", true); err != nil {
+		t.Fatalf("owner yank: %v", err)
+	}
+}
@@ -0,0 +1,459 @@
+package packagemanager
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// Severity ranks how serious an advisory is.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = [...]string{"unknown", "low", "medium", "high", "critical"}
+
+// String returns the lower-case severity name.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return severityNames[0]
+	}
+
+	return severityNames[s]
+}
+
+// ParseSeverity parses a severity name (case-insensitive).
+func ParseSeverity(name string) (Severity, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	for i, v := range severityNames {
+		if v == n {
+			return Severity(i), nil
+		}
+	}
+
+	return SeverityUnknown, fmt.Errorf("unknown severity %q", name)
+}
+
+// MarshalJSON encodes the severity by name.
+func (s Severity) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+// UnmarshalJSON decodes a severity name.
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+
+	v, err := ParseSeverity(name)
+	if err != nil {
+		return err
+	}
+
+	*s = v
+
+	return nil
+}
+
+// Advisory describes a known vulnerability in a range of package versions.
+type Advisory struct {
+	Published   time.Time `json:"published"`
+	ID          string    `json:"id"`
+	Package     PackageID `json:"package"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	// Affected lists semver constraints; a version matching any of them is vulnerable.
+	Affected []string `json:"affected"`
+	// Patched lists the first fixed versions, in any order.
+	Patched    []Version `json:"patched,omitempty"`
+	References []string  `json:"references,omitempty"`
+	Severity   Severity  `json:"severity"`
+}
+
+// Validate checks that the advisory is well-formed.
+func (a Advisory) Validate() error {
+	if a.ID == "" || a.Package == "" {
+		return errors.New("advisory requires id and package")
+	}
+
+	if len(a.Affected) == 0 {
+		return fmt.Errorf("advisory %s: no affected ranges", a.ID)
+	}
+
+	for _, r := range a.Affected {
+		if _, err := semver.NewConstraint(r); err != nil {
+			return fmt.Errorf("advisory %s: affected range %q: %w", a.ID, r, err)
+		}
+	}
+
+	for _, v := range a.Patched {
+		if _, err := semver.NewVersion(string(v)); err != nil {
+			return fmt.Errorf("advisory %s: patched version %q: %w", a.ID, v, err)
+		}
+	}
+
+	return nil
+}
+
+// Affects reports whether version v of the advisory's package is vulnerable.
+func (a Advisory) Affects(v Version) bool {
+	sv, err := semver.NewVersion(string(v))
+	if err != nil {
+		return false
+	}
+
+	for _, r := range a.Affected {
+		c, err := semver.NewConstraint(r)
+		if err == nil && c.Check(sv) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FixedIn returns the lowest patched version above v that is not itself
+// affected, or "" if the advisory lists none.
+func (a Advisory) FixedIn(v Version) Version {
+	cur, err := semver.NewVersion(string(v))
+	if err != nil {
+		return ""
+	}
+
+	var best *semver.Version
+
+	for _, p := range a.Patched {
+		pv, err := semver.NewVersion(string(p))
+		if err != nil || !pv.GreaterThan(cur) || a.Affects(p) {
+			continue
+		}
+
+		if best == nil || pv.LessThan(best) {
+			best = pv
+		}
+	}
+
+	if best == nil {
+		return ""
+	}
+
+	return Version(best.Original())
+}
+
+// AdvisoryDB is a snapshot of the advisory database.
+type AdvisoryDB struct {
+	Generated  time.Time  `json:"generated"`
+	Advisories []Advisory `json:"advisories"`
+}
+
+// SignedAdvisoryDB carries the exact signed JSON encoding of an AdvisoryDB
+// together with the signature bundle of the publishing key.
+type SignedAdvisoryDB struct {
+	Payload   []byte          `json:"payload"`
+	Signature SignatureBundle `json:"signature"`
+}
+
+// advisoryKeyUsage must be present on the leaf certificate that signs a database.
+const advisoryKeyUsage = "advisory-sign"
+
+// SignAdvisoryDB validates and signs db with the given key and certificate chain.
+// Advisories are sorted by package and ID so equal databases sign identically.
+func SignAdvisoryDB(db AdvisoryDB, signerPriv ed25519.PrivateKey, chain []Certificate) (SignedAdvisoryDB, error) {
+	advs := append([]Advisory(nil), db.Advisories...)
+	for _, a := range advs {
+		if err := a.Validate(); err != nil {
+			return SignedAdvisoryDB{}, err
+		}
+	}
+
+	sort.Slice(advs, func(i, j int) bool {
+		if advs[i].Package != advs[j].Package {
+			return advs[i].Package < advs[j].Package
+		}
+
+		return advs[i].ID < advs[j].ID
+	})
+
+	if db.Generated.IsZero() {
+		db.Generated = time.Now().UTC()
+	}
+
+	payload, err := json.Marshal(AdvisoryDB{Generated: db.Generated, Advisories: advs})
+	if err != nil {
+		return SignedAdvisoryDB{}, err
+	}
+
+	bundle, err := signBytes(payload, signerPriv, chain)
+	if err != nil {
+		return SignedAdvisoryDB{}, err
+	}
+
+	return SignedAdvisoryDB{Payload: payload, Signature: bundle}, nil
+}
+
+// VerifyAdvisoryDB checks the signature of a signed database against the trust
+// store and returns the decoded database.
+func (ts *TrustStore) VerifyAdvisoryDB(signed SignedAdvisoryDB) (AdvisoryDB, error) {
+	if err := ts.verifyBytes(signed.Payload, signed.Signature); err != nil {
+		return AdvisoryDB{}, fmt.Errorf("advisory database: %w", err)
+	}
+
+	if !hasUsage(signed.Signature.Chain[0], advisoryKeyUsage) {
+		return AdvisoryDB{}, fmt.Errorf("advisory database: signing certificate lacks %q usage", advisoryKeyUsage)
+	}
+
+	var db AdvisoryDB
+	if err := json.Unmarshal(signed.Payload, &db); err != nil {
+		return AdvisoryDB{}, fmt.Errorf("advisory database: %w", err)
+	}
+
+	return db, nil
+}
+
+func hasUsage(c Certificate, usage string) bool {
+	for _, u := range c.KeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AdvisoryProvider is implemented by registries that publish an advisory database.
+type AdvisoryProvider interface {
+	// Advisories returns the current signed database, or ErrNotFound if none is published.
+	Advisories(ctx context.Context) (SignedAdvisoryDB, error)
+}
+
+// advisoriesPath is where a FileRegistry keeps its signed advisory database.
+func (r *FileRegistry) advisoriesPath() string {
+	return filepath.Join(r.baseDir, "advisories.json")
+}
+
+// Advisories reads the signed advisory database stored with the registry.
+func (r *FileRegistry) Advisories(ctx context.Context) (SignedAdvisoryDB, error) {
+	return readSignedAdvisories(r.advisoriesPath())
+}
+
+// PublishAdvisories replaces the registry's advisory database. The database is
+// stored as-is; clients verify it against their own trust roots.
+func (r *FileRegistry) PublishAdvisories(signed SignedAdvisoryDB) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return writeSignedAdvisories(r.advisoriesPath(), signed)
+}
+
+func readSignedAdvisories(path string) (SignedAdvisoryDB, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return SignedAdvisoryDB{}, ErrNotFound
+		}
+
+		return SignedAdvisoryDB{}, err
+	}
+
+	var signed SignedAdvisoryDB
+	if err := json.Unmarshal(b, &signed); err != nil {
+		return SignedAdvisoryDB{}, fmt.Errorf("advisory database %s: %w", path, err)
+	}
+
+	return signed, nil
+}
+
+func writeSignedAdvisories(path string, signed SignedAdvisoryDB) error {
+	b, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// AdvisorySource describes where LoadAdvisories obtained its database.
+type AdvisorySource int
+
+const (
+	AdvisoryFromRegistry AdvisorySource = iota
+	AdvisoryFromCache
+)
+
+// LoadAdvisories fetches the advisory database from reg, verifies it and
+// refreshes the local cache at cachePath. If the registry does not provide
+// advisories or cannot be reached, the verified cached copy is used instead.
+// A registry database older than the cached one is rejected to prevent
+// rollback to a snapshot that predates known advisories.
+func LoadAdvisories(ctx context.Context, reg Registry, ts *TrustStore, cachePath string) (AdvisoryDB, AdvisorySource, error) {
+	var (
+		cached    AdvisoryDB
+		haveCache bool
+	)
+
+	if cachePath != "" {
+		if signed, err := readSignedAdvisories(cachePath); err == nil {
+			if db, err := ts.VerifyAdvisoryDB(signed); err == nil {
+				cached, haveCache = db, true
+			}
+		}
+	}
+
+	fetchErr := errors.New("registry does not provide advisories")
+
+	if p, ok := reg.(AdvisoryProvider); ok {
+		signed, err := p.Advisories(ctx)
+		if err == nil {
+			db, verr := ts.VerifyAdvisoryDB(signed)
+			if verr != nil {
+				return AdvisoryDB{}, AdvisoryFromRegistry, verr
+			}
+
+			if haveCache && db.Generated.Before(cached.Generated) {
+				return AdvisoryDB{}, AdvisoryFromRegistry, fmt.Errorf("advisory database from registry (%s) is older than cached copy (%s)",
+					db.Generated.Format(time.RFC3339), cached.Generated.Format(time.RFC3339))
+			}
+
+			if cachePath != "" {
+				if err := writeSignedAdvisories(cachePath, signed); err != nil {
+					return AdvisoryDB{}, AdvisoryFromRegistry, fmt.Errorf("cache advisories: %w", err)
+				}
+			}
+
+			return db, AdvisoryFromRegistry, nil
+		}
+
+		fetchErr = err
+	}
+
+	if haveCache {
+		return cached, AdvisoryFromCache, nil
+	}
+
+	return AdvisoryDB{}, AdvisoryFromCache, fmt.Errorf("no advisory database available: %w", fetchErr)
+}
+
+// AuditFinding reports one advisory that matches a locked package.
+type AuditFinding struct {
+	Advisory Advisory
+	Package  PackageID
+	Version  Version
+	// FixedIn is the lowest unaffected patched version, if any.
+	FixedIn Version
+	// Path leads from a root dependency to Package.
+	Path []PackageID
+}
+
+// AuditLockfile matches every locked package against db. Findings are ordered
+// by descending severity, then package name and advisory ID.
+func AuditLockfile(lock Lockfile, roots []PackageID, db AdvisoryDB) []AuditFinding {
+	byPkg := make(map[PackageID][]Advisory)
+	for _, a := range db.Advisories {
+		byPkg[a.Package] = append(byPkg[a.Package], a)
+	}
+
+	graph := make(map[PackageID][]PackageID, len(lock.Entries))
+	for _, e := range lock.Entries {
+		for _, d := range e.Dependencies {
+			graph[e.Name] = append(graph[e.Name], d.Name)
+		}
+	}
+
+	var out []AuditFinding
+
+	for _, e := range lock.Entries {
+		for _, a := range byPkg[e.Name] {
+			if !a.Affects(e.Version) {
+				continue
+			}
+
+			out = append(out, AuditFinding{
+				Advisory: a,
+				Package:  e.Name,
+				Version:  e.Version,
+				FixedIn:  a.FixedIn(e.Version),
+				Path:     lockPath(graph, roots, e.Name),
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Advisory.Severity != out[j].Advisory.Severity {
+			return out[i].Advisory.Severity > out[j].Advisory.Severity
+		}
+
+		if out[i].Package != out[j].Package {
+			return out[i].Package < out[j].Package
+		}
+
+		return out[i].Advisory.ID < out[j].Advisory.ID
+	})
+
+	return out
+}
+
+// lockPath returns the shortest dependency path from a root to target.
+func lockPath(graph map[PackageID][]PackageID, roots []PackageID, target PackageID) []PackageID {
+	sorted := append([]PackageID(nil), roots...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	prev := make(map[PackageID]PackageID)
+	seen := make(map[PackageID]bool)
+	queue := make([]PackageID, 0, len(sorted))
+
+	for _, r := range sorted {
+		if !seen[r] {
+			seen[r] = true
+
+			queue = append(queue, r)
+		}
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur == target {
+			path := []PackageID{cur}
+			for p, ok := prev[cur]; ok; p, ok = prev[p] {
+				path = append([]PackageID{p}, path...)
+			}
+
+			return path
+		}
+
+		for _, next := range graph[cur] {
+			if !seen[next] {
+				seen[next] = true
+				prev[next] = cur
+
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return []PackageID{target}
+}
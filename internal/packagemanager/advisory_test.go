@@ -0,0 +1,148 @@
+package packagemanager
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func advisorySigner(t *testing.T) (*TrustStore, func(AdvisoryDB) SignedAdvisoryDB) {
+	t.Helper()
+
+	rootPub, rootPriv, err := GenerateEd25519Keypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootCert, err := SelfSignRoot("Advisory Root", rootPub, rootPriv, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafPub, leafPriv, err := GenerateEd25519Keypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafCert, err := IssueChild(rootCert, rootPriv, leafPub, "Security Team", time.Hour, []string{"advisory-sign"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := NewTrustStore()
+	ts.AddRoot(rootPub)
+
+	return ts, func(db AdvisoryDB) SignedAdvisoryDB {
+		signed, err := SignAdvisoryDB(db, leafPriv, []Certificate{leafCert, rootCert})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signed
+	}
+}
+
+func TestAdvisories_ServedVerifiedCachedAndAudited(t *testing.T) {
+	ctx := context.Background()
+	ts, sign := advisorySigner(t)
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	db := AdvisoryDB{Generated: now, Advisories: []Advisory{
+		{ID: "OZ-2026-0001", Package: "zlib", Title: "heap overflow", Affected: []string{">=1.0.0, <1.2.4"}, Patched: []Version{"1.2.4", "2.0.0"}, Severity: SeverityCritical},
+		{ID: "OZ-2026-0002", Package: "http", Title: "header smuggling", Affected: []string{"<3.0.0"}, Severity: SeverityLow},
+		{ID: "OZ-2026-0003", Package: "zlib", Title: "fixed long ago", Affected: []string{"<0.9.0"}, Severity: SeverityHigh},
+	}}
+
+	if err := fr.PublishAdvisories(sign(db)); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(buildHTTPMux(fr))
+	defer srv.Close()
+
+	client := NewHTTPRegistryWithAuth(srv.URL, "")
+	cache := filepath.Join(t.TempDir(), "advisories.json")
+
+	got, src, err := LoadAdvisories(ctx, client, ts, cache)
+	if err != nil || src != AdvisoryFromRegistry || len(got.Advisories) != 3 {
+		t.Fatalf("load: %v src=%v n=%d", err, src, len(got.Advisories))
+	}
+
+	// The cached copy is used when the registry is unreachable.
+	srv.Close()
+
+	got, src, err = LoadAdvisories(ctx, NewHTTPRegistryWithAuth(srv.URL, ""), ts, cache)
+	if err != nil || src != AdvisoryFromCache || !got.Generated.Equal(now) {
+		t.Fatalf("cache fallback: %v src=%v", err, src)
+	}
+
+	lock := Lockfile{Entries: []LockEntry{
+		{Name: "app-core", Version: "1.0.0", Dependencies: []Dependency{{Name: "codec", Constraint: "^2.0.0"}}},
+		{Name: "codec", Version: "2.1.0", Dependencies: []Dependency{{Name: "zlib", Constraint: "^1.0.0"}}},
+		{Name: "http", Version: "3.1.0"},
+		{Name: "zlib", Version: "1.2.3"},
+	}}
+
+	findings := AuditLockfile(lock, []PackageID{"app-core", "http"}, got)
+	if len(findings) != 1 {
+		t.Fatalf("expected one finding, got %+v", findings)
+	}
+
+	f := findings[0]
+	if f.Advisory.ID != "OZ-2026-0001" || f.FixedIn != "1.2.4" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+
+	if len(f.Path) != 3 || f.Path[0] != "app-core" || f.Path[1] != "codec" || f.Path[2] != "zlib" {
+		t.Fatalf("unexpected path: %v", f.Path)
+	}
+}
+
+func TestAdvisories_RejectTamperingAndRollback(t *testing.T) {
+	ctx := context.Background()
+	ts, sign := advisorySigner(t)
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := filepath.Join(t.TempDir(), "advisories.json")
+	newer := AdvisoryDB{Generated: time.Now().UTC(), Advisories: []Advisory{{ID: "A-2", Package: "p", Affected: []string{"*"}, Severity: SeverityHigh}}}
+
+	if err := fr.PublishAdvisories(sign(newer)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := LoadAdvisories(ctx, fr, ts, cache); err != nil {
+		t.Fatal(err)
+	}
+
+	older := sign(AdvisoryDB{Generated: newer.Generated.Add(-time.Hour)})
+	if err := fr.PublishAdvisories(older); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := LoadAdvisories(ctx, fr, ts, cache); err == nil {
+		t.Fatalf("expected rollback to an older database to be rejected")
+	}
+
+	tampered := sign(newer)
+	tampered.Payload = append([]byte(nil), tampered.Payload...)
+	tampered.Payload[len(tampered.Payload)-3] ^= 1
+
+	if _, err := ts.VerifyAdvisoryDB(tampered); err == nil {
+		t.Fatalf("expected tampered database to fail verification")
+	}
+
+	if _, err := NewTrustStore().VerifyAdvisoryDB(sign(newer)); err == nil {
+		t.Fatalf("expected untrusted root to fail verification")
+	}
+}
@@ -0,0 +1,423 @@
+package packagemanager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileDigest records the content hash of one file inside a package archive.
+type FileDigest struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	// Mode is 0o644 for archives built now; older archives may record
+	// 0o755 for executables. No other permission bits are carried.
+	Mode uint32 `json:"mode"`
+}
+
+// ArchiveOptions selects which files of a directory go into a package archive.
+// Patterns use path.Match syntax on slash-separated paths relative to the root.
+// A pattern without a slash matches any path element (so "*.tmp" or ".git"
+// work at any depth); a pattern with a slash matches the path or one of its
+// leading directories.
+type ArchiveOptions struct {
+	// Include limits the archive to matching files; empty includes everything.
+	Include []string
+	// Exclude removes matching files; it takes precedence over Include.
+	Exclude []string
+	// Overrides replaces the contents of selected archived files, keyed by
+	// slash-separated path; publishing uses it to rewrite the manifest.
+	Overrides map[string][]byte
+}
+
+// defaultArchiveExcludes are never packaged.
+var defaultArchiveExcludes = []string{".git", ".hg", ".svn", ".orizon", "orizon.lock"}
+
+// archiveEpoch is the modification time stamped on every archive entry.
+var archiveEpoch = time.Unix(0, 0).UTC()
+
+// archiveFileMode is the permission stored for every archived file.
+const archiveFileMode fs.FileMode = 0o644
+
+// maxArchiveFileSize bounds a single unpacked file.
+const maxArchiveFileSize = 256 << 20
+
+// ArchiveFile is one regular file read back from a package archive.
+type ArchiveFile struct {
+	Path string
+	Data []byte
+	Mode uint32
+}
+
+// BuildArchive packs the regular files under root into the canonical package
+// archive: a gzip-compressed tar with entries sorted by path, zeroed owners
+// and timestamps, and every file stored as 0644. The same tree always produces
+// the same bytes, and therefore the same CID, on every platform: the
+// executable bit is not recorded since Windows has none to read.
+func BuildArchive(root string, opts ArchiveOptions) ([]byte, []FileDigest, error) {
+	files, err := collectArchiveFiles(root, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+
+	gz, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tw := tar.NewWriter(gz)
+	digests := make([]FileDigest, 0, len(files))
+
+	for _, rel := range files {
+		data, ok := opts.Overrides[rel]
+		if !ok {
+			if data, err = os.ReadFile(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		mode := uint32(archiveFileMode)
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     rel,
+			Size:     int64(len(data)),
+			Mode:     int64(mode),
+			ModTime:  archiveEpoch,
+			Format:   tar.FormatPAX,
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, nil, fmt.Errorf("archive %s: %w", rel, err)
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			return nil, nil, fmt.Errorf("archive %s: %w", rel, err)
+		}
+
+		sum := sha256.Sum256(data)
+		digests = append(digests, FileDigest{Path: rel, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data)), Mode: mode})
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), digests, nil
+}
+
+func collectArchiveFiles(root string, opts ArchiveOptions) ([]string, error) {
+	excludes := append(append([]string(nil), defaultArchiveExcludes...), opts.Exclude...)
+
+	var files []string
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if matchAny(excludes, rel) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			// Symlinks and special files are not portable; leave them out.
+			return nil
+		}
+
+		if matchAny(excludes, rel) || (len(opts.Include) > 0 && !matchAny(opts.Include, rel)) {
+			return nil
+		}
+
+		files = append(files, rel)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// matchAny reports whether rel matches one of the archive patterns.
+func matchAny(patterns []string, rel string) bool {
+	parts := strings.Split(rel, "/")
+
+	for _, pat := range patterns {
+		pat = strings.Trim(filepath.ToSlash(pat), "/")
+		if pat == "" {
+			continue
+		}
+
+		if !strings.Contains(pat, "/") {
+			for _, part := range parts {
+				if ok, _ := path.Match(pat, part); ok {
+					return true
+				}
+			}
+
+			continue
+		}
+
+		for i := len(parts); i > 0; i-- {
+			if ok, _ := path.Match(pat, strings.Join(parts[:i], "/")); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func normalizeMode(m fs.FileMode) uint32 {
+	if m.Perm()&0o111 != 0 {
+		return 0o755
+	}
+
+	return 0o644
+}
+
+// ReadArchive decodes a package archive. Entries must be regular files with
+// clean relative paths, listed in sorted order without duplicates.
+func ReadArchive(data []byte) ([]ArchiveFile, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	var out []ArchiveFile
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("archive: %s: unsupported entry type %q", hdr.Name, hdr.Typeflag)
+		}
+
+		if !validArchivePath(hdr.Name) {
+			return nil, fmt.Errorf("archive: unsafe path %q", hdr.Name)
+		}
+
+		if n := len(out); n > 0 && out[n-1].Path >= hdr.Name {
+			return nil, fmt.Errorf("archive: entries not in canonical order at %q", hdr.Name)
+		}
+
+		if hdr.Size > maxArchiveFileSize {
+			return nil, fmt.Errorf("archive: %s exceeds %d bytes", hdr.Name, maxArchiveFileSize)
+		}
+
+		b, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return nil, fmt.Errorf("archive: %s: %w", hdr.Name, err)
+		}
+
+		out = append(out, ArchiveFile{Path: hdr.Name, Data: b, Mode: normalizeMode(fs.FileMode(hdr.Mode))})
+	}
+
+	return out, nil
+}
+
+func validArchivePath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") || path.Clean(p) != p {
+		return false
+	}
+
+	return p != ".." && !strings.HasPrefix(p, "../")
+}
+
+// VerifyArchive checks that data unpacks to exactly the files listed in digests.
+func VerifyArchive(data []byte, digests []FileDigest) error {
+	files, err := ReadArchive(data)
+	if err != nil {
+		return err
+	}
+
+	return compareDigests(digestFiles(files), digests)
+}
+
+func digestFiles(files []ArchiveFile) []FileDigest {
+	out := make([]FileDigest, len(files))
+	for i, f := range files {
+		sum := sha256.Sum256(f.Data)
+		out[i] = FileDigest{Path: f.Path, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(f.Data)), Mode: f.Mode}
+	}
+
+	return out
+}
+
+// compareDigests reports the first difference between actual and expected.
+func compareDigests(actual, expected []FileDigest) error {
+	want := make(map[string]FileDigest, len(expected))
+	for _, d := range expected {
+		want[d.Path] = d
+	}
+
+	for _, got := range actual {
+		exp, ok := want[got.Path]
+		if !ok {
+			return fmt.Errorf("unexpected file %s", got.Path)
+		}
+
+		if exp.SHA256 != got.SHA256 || exp.Size != got.Size {
+			return fmt.Errorf("hash mismatch for %s", got.Path)
+		}
+
+		if exp.Mode != 0 && got.Mode != 0 && exp.Mode != got.Mode {
+			return fmt.Errorf("mode mismatch for %s: %o != %o", got.Path, got.Mode, exp.Mode)
+		}
+
+		delete(want, got.Path)
+	}
+
+	if len(want) > 0 {
+		missing := make([]string, 0, len(want))
+		for p := range want {
+			missing = append(missing, p)
+		}
+
+		sort.Strings(missing)
+
+		return fmt.Errorf("missing file %s", missing[0])
+	}
+
+	return nil
+}
+
+// VerifyBlob checks that blob hashes to id and, for archives that record file
+// digests in their manifest, that the archive matches them.
+func VerifyBlob(id CID, blob PackageBlob) error {
+	if got := ComputeCID(blob.Data); got != id {
+		return fmt.Errorf("%s@%s: content hash %s does not match %s", blob.Manifest.Name, blob.Manifest.Version, got, id)
+	}
+
+	if len(blob.Manifest.Files) == 0 {
+		return nil
+	}
+
+	if err := VerifyArchive(blob.Data, blob.Manifest.Files); err != nil {
+		return fmt.Errorf("%s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+	}
+
+	return nil
+}
+
+// ExtractArchive verifies data against digests and unpacks it into dir.
+func ExtractArchive(data []byte, digests []FileDigest, dir string) error {
+	files, err := ReadArchive(data)
+	if err != nil {
+		return err
+	}
+
+	if err := compareDigests(digestFiles(files), digests); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(dst, f.Data, fs.FileMode(f.Mode)); err != nil {
+			return err
+		}
+		// WriteFile leaves the mode of existing files alone.
+		if err := os.Chmod(dst, fs.FileMode(f.Mode)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifyDir re-hashes an unpacked package directory against digests. Extra or
+// missing files are reported as errors.
+func VerifyDir(dir string, digests []FileDigest) error {
+	var actual []FileDigest
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		// Windows reports no executable bit, so modes are only compared
+		// where the file system records one.
+		var mode uint32
+		if runtime.GOOS != "windows" {
+			mode = normalizeMode(info.Mode())
+		}
+
+		sum := sha256.Sum256(data)
+		actual = append(actual, FileDigest{Path: filepath.ToSlash(rel), SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data)), Mode: mode})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return compareDigests(actual, digests)
+}
@@ -0,0 +1,161 @@
+package packagemanager
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, body := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestArchive_DeterministicAcrossTrees(t *testing.T) {
+	files := map[string]string{
+		"orizon.json":       `{"name":"demo"}`,
+		"src/main.oz":       "fn main() {}",
+		"src/util/str.oz":   "fn trim() {}",
+		"docs/guide.md":     "# guide",
+		"build/out.o":       "junk",
+		"src/scratch.tmp":   "junk",
+		".git/HEAD":         "ref: refs/heads/main",
+		"scripts/gen.sh":    "#!/bin/sh",
+		"src/util/keep.tmp": "junk",
+	}
+	opts := ArchiveOptions{Exclude: []string{"build", "*.tmp", "docs/guide.md"}}
+
+	a, b := t.TempDir(), t.TempDir()
+	writeTree(t, a, files)
+	writeTree(t, b, files)
+
+	if err := os.Chmod(filepath.Join(a, "scripts/gen.sh"), 0o775); err != nil {
+		t.Fatal(err)
+	}
+
+	// b lacks the executable bit, as on Windows.
+	if err := os.Chmod(filepath.Join(b, "scripts/gen.sh"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Different mtimes must not leak into the archive.
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(b, "src/main.oz"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	dataA, digests, err := BuildArchive(a, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataB, _, err := BuildArchive(b, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(dataA, dataB) || ComputeCID(dataA) != ComputeCID(dataB) {
+		t.Fatalf("archives of identical trees differ")
+	}
+
+	var paths []string
+	for _, d := range digests {
+		paths = append(paths, d.Path)
+	}
+
+	want := []string{"orizon.json", "scripts/gen.sh", "src/main.oz", "src/util/str.oz"}
+	if len(paths) != len(want) {
+		t.Fatalf("unexpected files %v", paths)
+	}
+
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("unexpected files %v", paths)
+		}
+	}
+
+	if digests[1].Mode != 0o644 || digests[0].Mode != 0o644 {
+		t.Fatalf("modes not normalized: %+v", digests)
+	}
+
+	opts.Include = []string{"src/*.oz"}
+
+	_, only, err := BuildArchive(a, opts)
+	if err != nil || len(only) != 1 || only[0].Path != "src/main.oz" {
+		t.Fatalf("include filter: %v %+v", err, only)
+	}
+}
+
+func TestArchive_VerifyOnPublishFetchAndExtract(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.oz": "A", "lib/b.oz": "B"})
+
+	data, digests, err := BuildArchive(src, ArchiveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	man := PackageManifest{Name: "pkg", Version: "1.0.0", Files: digests}
+
+	bad := append([]FileDigest(nil), digests...)
+	bad[0].SHA256 = bad[1].SHA256
+
+	if _, err := reg.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "pkg", Version: "1.0.0", Files: bad}, Data: data}); err == nil {
+		t.Fatalf("expected publish with wrong digests to fail")
+	}
+
+	cid, err := reg.Publish(ctx, PackageBlob{Manifest: man, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := reg.Fetch(ctx, cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyBlob(cid, blob); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	blob.Manifest.Files = bad
+	if err := VerifyBlob(cid, blob); err == nil {
+		t.Fatalf("expected digest mismatch")
+	}
+
+	dst := t.TempDir()
+	if err := ExtractArchive(data, digests, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyDir(dst, digests); err != nil {
+		t.Fatalf("verify dir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dst, "lib/b.oz"), []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyDir(dst, digests); err == nil {
+		t.Fatalf("expected tampered vendor dir to fail verification")
+	}
+}
@@ -0,0 +1,328 @@
+package packagemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// ErrOffline is returned (wrapped) when an offline registry is asked for
+// something that is not in its cache.
+var ErrOffline = errors.New("not available offline")
+
+// CachingRegistry layers a FileRegistry cache over an upstream Registry.
+// Fetched blobs are verified and stored in the cache, so every package that
+// was resolved once can later be resolved again without the upstream. When
+// the upstream fails with anything other than ErrNotFound, reads fall back to
+// the cache. Without an upstream the registry is offline: it answers purely
+// from the cache and reports misses with ErrOffline.
+type CachingRegistry struct {
+	upstream Registry
+	cache    *FileRegistry
+}
+
+// NewCachingRegistry returns a pull-through cache of upstream stored in cache.
+func NewCachingRegistry(upstream Registry, cache *FileRegistry) *CachingRegistry {
+	return &CachingRegistry{upstream: upstream, cache: cache}
+}
+
+// NewOfflineRegistry returns a registry that serves only what cache holds.
+func NewOfflineRegistry(cache *FileRegistry) *CachingRegistry {
+	return &CachingRegistry{cache: cache}
+}
+
+// Offline reports whether the registry has no upstream.
+func (r *CachingRegistry) Offline() bool { return r.upstream == nil }
+
+// Cache returns the underlying cache.
+func (r *CachingRegistry) Cache() *FileRegistry { return r.cache }
+
+// Publish publishes to the upstream and keeps a copy in the cache.
+func (r *CachingRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, error) {
+	id, _, err := r.PublishWithReceipt(ctx, blob)
+
+	return id, err
+}
+
+// PublishWithReceipt publishes to the upstream, passing on its transparency
+// log receipt if it returns one, and keeps a copy in the cache.
+func (r *CachingRegistry) PublishWithReceipt(ctx context.Context, blob PackageBlob) (CID, *LogReceipt, error) {
+	if r.Offline() {
+		return "", nil, fmt.Errorf("publish %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, ErrOffline)
+	}
+
+	var (
+		id      CID
+		receipt *LogReceipt
+		err     error
+	)
+
+	if rp, ok := r.upstream.(ReceiptPublisher); ok {
+		id, receipt, err = rp.PublishWithReceipt(ctx, blob)
+	} else {
+		id, err = r.upstream.Publish(ctx, blob)
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, err := r.cache.Publish(ctx, blob); err != nil {
+		return "", nil, fmt.Errorf("cache %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+	}
+
+	return id, receipt, nil
+}
+
+// Fetch serves the blob from the cache, fetching and caching it on a miss.
+// Cached blobs are re-verified; a corrupt entry is treated as a miss.
+func (r *CachingRegistry) Fetch(ctx context.Context, id CID) (PackageBlob, error) {
+	blob, err := r.cache.Fetch(ctx, id)
+	if err == nil && VerifyBlob(id, blob) == nil {
+		return blob, nil
+	}
+
+	if r.Offline() {
+		return PackageBlob{}, fmt.Errorf("blob %s is not cached: %w", id, ErrOffline)
+	}
+
+	blob, err = r.upstream.Fetch(ctx, id)
+	if err != nil {
+		return PackageBlob{}, err
+	}
+
+	if err := VerifyBlob(id, blob); err != nil {
+		return PackageBlob{}, err
+	}
+
+	if _, err := r.cache.Publish(ctx, blob); err != nil {
+		return PackageBlob{}, fmt.Errorf("cache %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+	}
+
+	return blob, nil
+}
+
+// Find asks the upstream first so new releases are seen, and the cache when
+// offline or when the upstream is unreachable.
+func (r *CachingRegistry) Find(ctx context.Context, name PackageID, constraint *semver.Constraints) (CID, PackageManifest, error) {
+	if !r.Offline() {
+		id, mf, err := r.upstream.Find(ctx, name, constraint)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return id, mf, err
+		}
+
+		if id, mf, cerr := r.cache.Find(ctx, name, constraint); cerr == nil {
+			return id, mf, nil
+		}
+
+		return "", PackageManifest{}, err
+	}
+
+	id, mf, err := r.cache.Find(ctx, name, constraint)
+	if errors.Is(err, ErrNotFound) {
+		want := "*"
+		if constraint != nil {
+			want = constraint.String()
+		}
+
+		return "", PackageManifest{}, fmt.Errorf("no cached version of %s matches %s: %w", name, want, ErrOffline)
+	}
+
+	return id, mf, err
+}
+
+// List returns the upstream versions of name, or the cached ones when offline
+// or when the upstream is unreachable. Offline, a package with no cached
+// version at all is reported as a miss rather than as an empty list.
+func (r *CachingRegistry) List(ctx context.Context, name PackageID) ([]PackageManifest, error) {
+	if !r.Offline() {
+		list, err := r.upstream.List(ctx, name)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return list, err
+		}
+
+		if cached, cerr := r.cache.List(ctx, name); cerr == nil && len(cached) > 0 {
+			return cached, nil
+		}
+
+		return nil, err
+	}
+
+	list, err := r.cache.List(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("package %s is not cached: %w", name, ErrOffline)
+	}
+
+	return list, nil
+}
+
+// CIDs returns the upstream CIDs of every version of name, or the cached ones
+// when offline or when the upstream is unreachable, like List; see CIDLister.
+func (r *CachingRegistry) CIDs(ctx context.Context, name PackageID) (map[Version]CID, error) {
+	if !r.Offline() {
+		l, ok := r.upstream.(CIDLister)
+		if !ok {
+			return nil, nil
+		}
+
+		cids, err := l.CIDs(ctx, name)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return cids, err
+		}
+
+		if cached, cerr := r.cache.CIDs(ctx, name); cerr == nil && len(cached) > 0 {
+			return cached, nil
+		}
+
+		return nil, err
+	}
+
+	return r.cache.CIDs(ctx, name)
+}
+
+// All returns every upstream manifest, or the cached ones when offline or
+// when the upstream is unreachable.
+func (r *CachingRegistry) All(ctx context.Context) ([]PackageManifest, error) {
+	if !r.Offline() {
+		all, err := r.upstream.All(ctx)
+		if err == nil {
+			return all, nil
+		}
+
+		if cached, cerr := r.cache.All(ctx); cerr == nil && len(cached) > 0 {
+			return cached, nil
+		}
+
+		return nil, err
+	}
+
+	return r.cache.All(ctx)
+}
+
+// Advisories returns the upstream advisory database and keeps a copy in the
+// cache, falling back to that copy when offline or when the upstream has none.
+// The database is signed, so the cached copy needs no further protection.
+func (r *CachingRegistry) Advisories(ctx context.Context) (SignedAdvisoryDB, error) {
+	if p, ok := r.upstream.(AdvisoryProvider); ok && !r.Offline() {
+		signed, err := p.Advisories(ctx)
+		if err == nil {
+			if err := r.cache.PublishAdvisories(signed); err != nil {
+				return SignedAdvisoryDB{}, err
+			}
+
+			return signed, nil
+		}
+	}
+
+	return r.cache.Advisories(ctx)
+}
+
+// logProvider returns the upstream transparency log. Log checks are never
+// answered from the cache: a proof is only meaningful if it is fresh.
+func (r *CachingRegistry) logProvider() (TransparencyLogProvider, error) {
+	if r.Offline() {
+		return nil, fmt.Errorf("transparency log: %w", ErrOffline)
+	}
+
+	if p, ok := r.upstream.(TransparencyLogProvider); ok {
+		return p, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// TreeHead returns the upstream's signed tree head.
+func (r *CachingRegistry) TreeHead(ctx context.Context) (SignedTreeHead, error) {
+	p, err := r.logProvider()
+	if err != nil {
+		return SignedTreeHead{}, err
+	}
+
+	return p.TreeHead(ctx)
+}
+
+// InclusionProof returns the upstream's inclusion proof for name@version.
+func (r *CachingRegistry) InclusionProof(ctx context.Context, name PackageID, version Version, treeSize uint64) (InclusionProof, error) {
+	p, err := r.logProvider()
+	if err != nil {
+		return InclusionProof{}, err
+	}
+
+	return p.InclusionProof(ctx, name, version, treeSize)
+}
+
+// ConsistencyProof returns the upstream's consistency proof between two tree sizes.
+func (r *CachingRegistry) ConsistencyProof(ctx context.Context, first, second uint64) (ConsistencyProof, error) {
+	p, err := r.logProvider()
+	if err != nil {
+		return ConsistencyProof{}, err
+	}
+
+	return p.ConsistencyProof(ctx, first, second)
+}
+
+// admin returns the upstream's release management. Release metadata is
+// changed at the source only, never in the cache.
+func (r *CachingRegistry) admin() (PackageAdmin, error) {
+	if r.Offline() {
+		return nil, fmt.Errorf("package administration: %w", ErrOffline)
+	}
+
+	return adminOf(r.upstream)
+}
+
+// Yank yanks or restores name@version upstream.
+func (r *CachingRegistry) Yank(ctx context.Context, name PackageID, version Version, yanked bool) error {
+	a, err := r.admin()
+	if err != nil {
+		return err
+	}
+
+	return a.Yank(ctx, name, version, yanked)
+}
+
+// Deprecate sets or clears the upstream deprecation notice of name.
+func (r *CachingRegistry) Deprecate(ctx context.Context, name PackageID, message string) error {
+	a, err := r.admin()
+	if err != nil {
+		return err
+	}
+
+	return a.Deprecate(ctx, name, message)
+}
+
+// Owners lists the upstream owners of name.
+func (r *CachingRegistry) Owners(ctx context.Context, name PackageID) ([]string, error) {
+	a, err := r.admin()
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Owners(ctx, name)
+}
+
+// AddOwner adds an upstream owner of name.
+func (r *CachingRegistry) AddOwner(ctx context.Context, name PackageID, owner string) error {
+	a, err := r.admin()
+	if err != nil {
+		return err
+	}
+
+	return a.AddOwner(ctx, name, owner)
+}
+
+// RemoveOwner removes an upstream owner of name.
+func (r *CachingRegistry) RemoveOwner(ctx context.Context, name PackageID, owner string) error {
+	a, err := r.admin()
+	if err != nil {
+		return err
+	}
+
+	return a.RemoveOwner(ctx, name, owner)
+}
@@ -0,0 +1,96 @@
+package packagemanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// unreachableRegistry fails every call like a registry behind a dead network.
+type unreachableRegistry struct{}
+
+var errUnreachable = errors.New("dial tcp: network is unreachable")
+
+func (unreachableRegistry) Publish(context.Context, PackageBlob) (CID, error) {
+	return "", errUnreachable
+}
+
+func (unreachableRegistry) Fetch(context.Context, CID) (PackageBlob, error) {
+	return PackageBlob{}, errUnreachable
+}
+
+func (unreachableRegistry) Find(context.Context, PackageID, *semver.Constraints) (CID, PackageManifest, error) {
+	return "", PackageManifest{}, errUnreachable
+}
+
+func (unreachableRegistry) List(context.Context, PackageID) ([]PackageManifest, error) {
+	return nil, errUnreachable
+}
+
+func (unreachableRegistry) All(context.Context) ([]PackageManifest, error) {
+	return nil, errUnreachable
+}
+
+func TestCachingRegistry_WarmsCacheForOfflineUse(t *testing.T) {
+	ctx := context.Background()
+	upstream := NewInMemoryRegistry()
+
+	for _, blob := range []PackageBlob{
+		{Manifest: PackageManifest{Name: "B", Version: "1.0.0"}, Data: []byte("B-1.0.0")},
+		{Manifest: PackageManifest{Name: "B", Version: "1.1.0"}, Data: []byte("B-1.1.0")},
+		{Manifest: PackageManifest{Name: "A", Version: "1.0.0", Dependencies: []Dependency{{Name: "B", Constraint: "^1.0.0"}}}, Data: []byte("A-1.0.0")},
+		{Manifest: PackageManifest{Name: "C", Version: "1.0.0"}, Data: []byte("C-1.0.0")},
+	} {
+		if _, err := upstream.Publish(ctx, blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cacheDir := t.TempDir()
+
+	cache, err := NewFileRegistry(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := []Requirement{{Name: "A", Constraint: "^1.0.0"}}
+
+	online, err := NewManager(NewCachingRegistry(upstream, cache)).ResolveAndFetch(ctx, reqs, true)
+	if err != nil {
+		t.Fatalf("online resolve: %v", err)
+	}
+
+	// Reopen the cache from disk, as a later offline build would.
+	cache, err = NewFileRegistry(cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline := NewOfflineRegistry(cache)
+
+	res, err := NewManager(offline).ResolveAndFetch(ctx, reqs, true)
+	if err != nil {
+		t.Fatalf("offline resolve: %v", err)
+	}
+
+	if res["A"] != online["A"] || res["B"] != online["B"] || res["B"].Version != "1.1.0" {
+		t.Fatalf("offline resolution differs: %v vs %v", res, online)
+	}
+
+	_, err = NewManager(offline).ResolveAndFetch(ctx, []Requirement{{Name: "C", Constraint: "*"}}, true)
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected offline miss for C, got %v", err)
+	}
+
+	if _, err := offline.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "D", Version: "1.0.0"}, Data: []byte("D")}); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected offline publish to fail, got %v", err)
+	}
+
+	// An unreachable upstream falls back to what is cached.
+	res, err = NewManager(NewCachingRegistry(unreachableRegistry{}, cache)).ResolveAndFetch(ctx, reqs, true)
+	if err != nil || res["B"] != online["B"] {
+		t.Fatalf("fallback resolve: %v %v", res, err)
+	}
+}
@@ -0,0 +1,454 @@
+package packagemanager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultFeature names the feature that is enabled on every package unless
+// the package does not declare it.
+const DefaultFeature = "default"
+
+// ErrUnknownFeature is returned (wrapped) when a feature is requested that
+// the package does not declare.
+var ErrUnknownFeature = errors.New("unknown feature")
+
+// Feature table entries. A feature lists other features of the same package
+// ("std"), optional dependencies it pulls in ("dep:tls"), features of a
+// dependency ("tls/async", which also pulls tls in if it is optional; the weak
+// form "tls?/async" only applies when tls is enabled by something else) and
+// cfg flags passed to the compiler ("cfg:has_net").
+const (
+	featureDepPrefix = "dep:"
+	featureCfgPrefix = "cfg:"
+)
+
+// FeatureSet maps each package of a resolved graph to its enabled features.
+// Features are unified: a package pulled in several times gets the union of
+// everything requested on it.
+type FeatureSet map[PackageID][]string
+
+// Has reports whether feature is enabled on name.
+func (fs FeatureSet) Has(name PackageID, feature string) bool {
+	for _, f := range fs[name] {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateFeatures checks a feature table against the dependencies it may
+// refer to.
+func ValidateFeatures(features map[string][]string, deps []Dependency) error {
+	declared := make(map[PackageID]Dependency, len(deps))
+	for _, d := range deps {
+		declared[d.Name] = d
+	}
+
+	names := make([]string, 0, len(features))
+	for name := range features {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := validateFeatureName(name); err != nil {
+			return err
+		}
+
+		for _, entry := range features[name] {
+			if err := validateFeatureEntry(entry, features, declared); err != nil {
+				return fmt.Errorf("feature %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateFeatureName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/:?\"") || strings.TrimSpace(name) != name {
+		return fmt.Errorf("invalid feature name %q", name)
+	}
+
+	return nil
+}
+
+func validateFeatureEntry(entry string, features map[string][]string, deps map[PackageID]Dependency) error {
+	switch {
+	case strings.HasPrefix(entry, featureCfgPrefix):
+		if strings.TrimPrefix(entry, featureCfgPrefix) == "" {
+			return fmt.Errorf("empty cfg flag in %q", entry)
+		}
+	case strings.HasPrefix(entry, featureDepPrefix):
+		d, ok := deps[PackageID(strings.TrimPrefix(entry, featureDepPrefix))]
+		if !ok || !d.Optional {
+			return fmt.Errorf("%q does not name an optional dependency", entry)
+		}
+	case strings.Contains(entry, "/"):
+		dep, feature, _ := strings.Cut(entry, "/")
+		if _, ok := deps[PackageID(strings.TrimSuffix(dep, "?"))]; !ok || feature == "" {
+			return fmt.Errorf("%q does not name a dependency feature", entry)
+		}
+	default:
+		if _, ok := features[entry]; !ok {
+			return fmt.Errorf("%w %q", ErrUnknownFeature, entry)
+		}
+	}
+
+	return nil
+}
+
+// FeatureCfg returns the cfg flags for a package with the given feature
+// table and enabled features: feature="x" for every enabled feature plus the
+// flags named by their cfg: entries, sorted.
+func FeatureCfg(features map[string][]string, enabled []string) []string {
+	seen := make(map[string]bool)
+
+	for _, f := range enabled {
+		seen[fmt.Sprintf("feature=%q", f)] = true
+
+		for _, entry := range features[f] {
+			if flag, ok := strings.CutPrefix(entry, featureCfgPrefix); ok {
+				seen[flag] = true
+			}
+		}
+	}
+
+	out := make([]string, 0, len(seen))
+	for flag := range seen {
+		out = append(out, flag)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// ExpandFeatures returns requested plus the default feature and every
+// feature of the same package they imply, sorted. It is what a package
+// built on its own (without a dependency graph) sees.
+func ExpandFeatures(features map[string][]string, requested []string, defaults bool) ([]string, error) {
+	enabled := make(map[string]bool)
+
+	var enable func(f string) error
+
+	enable = func(f string) error {
+		if enabled[f] {
+			return nil
+		}
+
+		if _, ok := features[f]; !ok {
+			if f == DefaultFeature {
+				return nil
+			}
+
+			return fmt.Errorf("%w %q", ErrUnknownFeature, f)
+		}
+
+		enabled[f] = true
+
+		for _, entry := range features[f] {
+			if strings.HasPrefix(entry, featureCfgPrefix) || strings.HasPrefix(entry, featureDepPrefix) || strings.Contains(entry, "/") {
+				continue
+			}
+
+			if err := enable(entry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if defaults {
+		requested = append([]string{DefaultFeature}, requested...)
+	}
+
+	for _, f := range requested {
+		if err := enable(f); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]string, 0, len(enabled))
+	for f := range enabled {
+		out = append(out, f)
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
+
+// optionalDeps records, per package, the optional dependencies that enabled
+// features pull in.
+type optionalDeps map[PackageID]map[PackageID]bool
+
+// merge adds other to d and reports whether anything was new.
+func (d optionalDeps) merge(other optionalDeps) bool {
+	grew := false
+
+	for pkg, deps := range other {
+		for dep := range deps {
+			if d[pkg][dep] {
+				continue
+			}
+
+			if d[pkg] == nil {
+				d[pkg] = make(map[PackageID]bool)
+			}
+
+			d[pkg][dep] = true
+			grew = true
+		}
+	}
+
+	return grew
+}
+
+// withOptional returns idx with the optional dependencies that are not
+// enabled removed.
+func (idx PackageIndex) withOptional(enabled optionalDeps) PackageIndex {
+	out := make(PackageIndex, len(idx))
+
+	for name, list := range idx {
+		pvs := make([]PackageVersion, len(list))
+
+		for i, pv := range list {
+			deps := make([]Dependency, 0, len(pv.Dependencies))
+
+			for _, d := range pv.Dependencies {
+				if !d.Optional || enabled[name][d.Name] {
+					deps = append(deps, d)
+				}
+			}
+
+			pv.Dependencies = deps
+			pvs[i] = pv
+		}
+
+		out[name] = pvs
+	}
+
+	return out
+}
+
+// activateFeatures unifies the features requested by reqs and by the
+// feature tables of the resolved versions. It returns the enabled features
+// and the optional dependencies they pull in.
+func activateFeatures(idx PackageIndex, res Resolution, reqs []Requirement) (FeatureSet, optionalDeps, error) {
+	a := &activation{
+		idx:     idx,
+		res:     res,
+		enabled: make(map[PackageID]map[string]bool),
+		deps:    make(optionalDeps),
+		visited: make(map[PackageID]bool),
+		weak:    make(map[PackageID]map[PackageID][]string),
+	}
+
+	for _, q := range reqs {
+		if err := a.visit(q.Name); err != nil {
+			return nil, nil, err
+		}
+
+		for _, f := range q.Features {
+			if err := a.enable(q.Name, f); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	fs := make(FeatureSet, len(a.enabled))
+
+	for pkg, set := range a.enabled {
+		for f := range set {
+			fs[pkg] = append(fs[pkg], f)
+		}
+
+		sort.Strings(fs[pkg])
+	}
+
+	return fs, a.deps, nil
+}
+
+type activation struct {
+	idx     PackageIndex
+	res     Resolution
+	enabled map[PackageID]map[string]bool
+	deps    optionalDeps
+	visited map[PackageID]bool
+	// weak holds "dep?/feature" requests waiting for dep to be enabled.
+	weak map[PackageID]map[PackageID][]string
+}
+
+// version returns the resolved index entry of pkg; ok is false for packages
+// the resolution does not contain, e.g. optional dependencies that are only
+// enabled in this round.
+func (a *activation) version(pkg PackageID) (PackageVersion, bool) {
+	v, ok := a.res[pkg]
+	if !ok {
+		return PackageVersion{}, false
+	}
+
+	for _, pv := range a.idx[pkg] {
+		if pv.Version == v {
+			return pv, true
+		}
+	}
+
+	return PackageVersion{}, false
+}
+
+// visit enables the default feature of pkg and of its required dependencies.
+func (a *activation) visit(pkg PackageID) error {
+	if a.visited[pkg] {
+		return nil
+	}
+
+	a.visited[pkg] = true
+
+	pv, ok := a.version(pkg)
+	if !ok {
+		return nil
+	}
+
+	if err := a.enable(pkg, DefaultFeature); err != nil {
+		return err
+	}
+
+	for _, d := range pv.Dependencies {
+		if d.Optional && !a.deps[pkg][d.Name] {
+			continue
+		}
+
+		if err := a.visit(d.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *activation) enable(pkg PackageID, feature string) error {
+	if a.enabled[pkg][feature] {
+		return nil
+	}
+
+	pv, ok := a.version(pkg)
+	if !ok {
+		return nil
+	}
+
+	entries, declared := pv.Features[feature]
+	if !declared {
+		if feature == DefaultFeature {
+			return nil
+		}
+
+		return fmt.Errorf("%s@%s: %w %q", pkg, pv.Version, ErrUnknownFeature, feature)
+	}
+
+	if a.enabled[pkg] == nil {
+		a.enabled[pkg] = make(map[string]bool)
+	}
+
+	a.enabled[pkg][feature] = true
+
+	for _, entry := range entries {
+		if err := a.apply(pv, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply activates one feature table entry of pv.
+func (a *activation) apply(pv PackageVersion, entry string) error {
+	switch {
+	case strings.HasPrefix(entry, featureCfgPrefix):
+		return nil
+	case strings.HasPrefix(entry, featureDepPrefix):
+		return a.enableDep(pv, PackageID(strings.TrimPrefix(entry, featureDepPrefix)))
+	case strings.Contains(entry, "/"):
+		dep, feature, _ := strings.Cut(entry, "/")
+		if name, ok := strings.CutSuffix(dep, "?"); ok {
+			if !a.isEnabledDep(pv, PackageID(name)) {
+				if a.weak[pv.Name] == nil {
+					a.weak[pv.Name] = make(map[PackageID][]string)
+				}
+
+				a.weak[pv.Name][PackageID(name)] = append(a.weak[pv.Name][PackageID(name)], feature)
+
+				return nil
+			}
+
+			dep = name
+		}
+
+		if err := a.enableDep(pv, PackageID(dep)); err != nil {
+			return err
+		}
+
+		return a.enable(PackageID(dep), feature)
+	default:
+		return a.enable(pv.Name, entry)
+	}
+}
+
+// isEnabledDep reports whether dep is a required or enabled optional
+// dependency of pv.
+func (a *activation) isEnabledDep(pv PackageVersion, dep PackageID) bool {
+	for _, d := range pv.Dependencies {
+		if d.Name == dep {
+			return !d.Optional || a.deps[pv.Name][dep]
+		}
+	}
+
+	return false
+}
+
+// enableDep pulls in dep as a dependency of pv and replays the weak feature
+// requests that were waiting for it.
+func (a *activation) enableDep(pv PackageVersion, dep PackageID) error {
+	found := false
+
+	for _, d := range pv.Dependencies {
+		if d.Name == dep {
+			found = true
+
+			if d.Optional && !a.deps[pv.Name][dep] {
+				if a.deps[pv.Name] == nil {
+					a.deps[pv.Name] = make(map[PackageID]bool)
+				}
+
+				a.deps[pv.Name][dep] = true
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%s@%s: feature refers to unknown dependency %s", pv.Name, pv.Version, dep)
+	}
+
+	if err := a.visit(dep); err != nil {
+		return err
+	}
+
+	pending := a.weak[pv.Name][dep]
+	delete(a.weak[pv.Name], dep)
+
+	for _, f := range pending {
+		if err := a.enable(dep, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package packagemanager
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveFeatures_OptionalDependenciesAndUnification(t *testing.T) {
+	// app enables "net" on http, which pulls in the optional tls dependency
+	// and its "async" feature; cli asks for http's "json" feature. tls pulls
+	// in its own optional "ring" only when "fast" is enabled, which nobody does.
+	idx := PackageIndex{
+		"app": {{Name: "app", Version: "1.0.0", Dependencies: []Dependency{
+			{Name: "http", Constraint: "^1.0.0"},
+			{Name: "cli", Constraint: "^1.0.0"},
+		}, Features: map[string][]string{"default": {"http/net"}}}},
+		"cli": {{Name: "cli", Version: "1.0.0", Dependencies: []Dependency{
+			{Name: "http", Constraint: "^1.0.0"},
+		}, Features: map[string][]string{"default": {"http/json"}}}},
+		"http": {{Name: "http", Version: "1.2.0", Dependencies: []Dependency{
+			{Name: "tls", Constraint: "^2.0.0", Optional: true},
+			{Name: "serde", Constraint: "^1.0.0", Optional: true},
+		}, Features: map[string][]string{
+			"net":  {"dep:tls", "tls/async", "cfg:has_net"},
+			"json": {"serde?/derive"},
+		}}},
+		"tls": {{Name: "tls", Version: "2.1.0", Dependencies: []Dependency{
+			{Name: "ring", Constraint: "^1.0.0", Optional: true},
+		}, Features: map[string][]string{"async": {}, "fast": {"dep:ring"}}}},
+		"serde": {{Name: "serde", Version: "1.0.0", Features: map[string][]string{"derive": {}}}},
+	}
+
+	res, fs, err := NewResolver(idx, ResolveOptions{PreferHigher: true}).ResolveFeatures([]Requirement{{Name: "app", Constraint: "^1.0.0"}})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	if res["tls"] != "2.1.0" {
+		t.Fatalf("expected optional tls to be resolved, got %v", res)
+	}
+
+	if _, ok := res["serde"]; ok {
+		t.Fatalf("weak serde?/derive must not pull serde in: %v", res)
+	}
+
+	if _, ok := res["ring"]; ok {
+		t.Fatalf("ring is only enabled by tls/fast: %v", res)
+	}
+
+	if got := fs["http"]; !reflect.DeepEqual(got, []string{"json", "net"}) {
+		t.Fatalf("http features not unified: %v", got)
+	}
+
+	if !fs.Has("tls", "async") || fs.Has("tls", "fast") {
+		t.Fatalf("unexpected tls features: %v", fs["tls"])
+	}
+
+	cfg := FeatureCfg(idx["http"][0].Features, fs["http"])
+	if want := []string{`feature="json"`, `feature="net"`, "has_net"}; !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("cfg = %v, want %v", cfg, want)
+	}
+}
+
+func TestResolveFeatures_RequirementFeatures(t *testing.T) {
+	idx := PackageIndex{
+		"lib": {{Name: "lib", Version: "1.0.0", Dependencies: []Dependency{
+			{Name: "zlib", Constraint: "^1.0.0", Optional: true},
+		}, Features: map[string][]string{"compress": {"dep:zlib"}}}},
+		"zlib": {{Name: "zlib", Version: "1.3.0"}},
+	}
+	r := NewResolver(idx, ResolveOptions{PreferHigher: true})
+
+	res, err := r.Resolve([]Requirement{{Name: "lib", Constraint: "^1.0.0"}})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	if _, ok := res["zlib"]; ok {
+		t.Fatalf("zlib resolved without its feature: %v", res)
+	}
+
+	res, err = r.Resolve([]Requirement{{Name: "lib", Constraint: "^1.0.0", Features: []string{"compress"}}})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	if res["zlib"] != "1.3.0" {
+		t.Fatalf("zlib not resolved with compress: %v", res)
+	}
+
+	_, err = r.Resolve([]Requirement{{Name: "lib", Constraint: "^1.0.0", Features: []string{"nope"}}})
+	if !errors.Is(err, ErrUnknownFeature) {
+		t.Fatalf("expected ErrUnknownFeature, got %v", err)
+	}
+}
+
+func TestValidateFeatures(t *testing.T) {
+	deps := []Dependency{{Name: "tls", Constraint: "^1", Optional: true}, {Name: "log", Constraint: "^1"}}
+
+	good := map[string][]string{"default": {"net"}, "net": {"dep:tls", "log/color", "cfg:net"}}
+	if err := ValidateFeatures(good, deps); err != nil {
+		t.Fatalf("valid table rejected: %v", err)
+	}
+
+	for _, bad := range []map[string][]string{
+		{"net": {"dep:log"}},
+		{"net": {"missing"}},
+		{"net": {"other/x"}},
+		{"a/b": {}},
+	} {
+		if err := ValidateFeatures(bad, deps); err == nil {
+			t.Fatalf("expected %v to be rejected", bad)
+		}
+	}
+
+	got, err := ExpandFeatures(good, nil, true)
+	if err != nil || !reflect.DeepEqual(got, []string{"default", "net"}) {
+		t.Fatalf("ExpandFeatures = %v, %v", got, err)
+	}
+}
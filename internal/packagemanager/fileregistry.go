@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -19,6 +20,7 @@ type FileRegistry struct {
 	blobs   map[CID]PackageBlob
 	index   map[PackageID][]PackageVersion
 	rev     map[string]CID
+	meta    map[PackageID]*packageMeta
 	baseDir string
 	mu      sync.RWMutex
 }
@@ -38,22 +40,26 @@ func NewFileRegistry(baseDir string) (*FileRegistry, error) {
 		return nil, err
 	}
 
-	fr := &FileRegistry{baseDir: baseDir, blobs: make(map[CID]PackageBlob), index: make(map[PackageID][]PackageVersion), rev: make(map[string]CID)}
+	fr := &FileRegistry{baseDir: baseDir, blobs: make(map[CID]PackageBlob), index: make(map[PackageID][]PackageVersion), rev: make(map[string]CID), meta: make(map[PackageID]*packageMeta)}
+	if err := fr.loadMeta(); err != nil {
+		return nil, err
+	}
 	// fast path: try reading index.json
 	if b, err := os.ReadFile(filepath.Join(baseDir, "index.json")); err == nil {
 		// index file schema.
 		var idx struct {
 			Entries []struct {
-				Name         PackageID    `json:"name"`
-				Version      Version      `json:"version"`
-				CID          CID          `json:"cid"`
-				Dependencies []Dependency `json:"dependencies,omitempty"`
+				Name         PackageID           `json:"name"`
+				Version      Version             `json:"version"`
+				CID          CID                 `json:"cid"`
+				Dependencies []Dependency        `json:"dependencies,omitempty"`
+				Features     map[string][]string `json:"features,omitempty"`
 			} `json:"entries"`
 		}
 
 		if json.Unmarshal(b, &idx) == nil {
 			for _, e := range idx.Entries {
-				fr.index[e.Name] = append(fr.index[e.Name], PackageVersion{Name: e.Name, Version: e.Version, Dependencies: e.Dependencies})
+				fr.index[e.Name] = append(fr.index[e.Name], PackageVersion{Name: e.Name, Version: e.Version, Dependencies: e.Dependencies, Features: e.Features})
 				fr.rev[string(e.Name)+"@"+string(e.Version)] = e.CID
 			}
 
@@ -88,7 +94,7 @@ func NewFileRegistry(baseDir string) (*FileRegistry, error) {
 		// populate index if absent.
 		key := string(fb.Manifest.Name) + "@" + string(fb.Manifest.Version)
 		if _, ok := fr.rev[key]; !ok {
-			fr.index[fb.Manifest.Name] = append(fr.index[fb.Manifest.Name], PackageVersion{Name: fb.Manifest.Name, Version: fb.Manifest.Version, Dependencies: fb.Manifest.Dependencies})
+			fr.index[fb.Manifest.Name] = append(fr.index[fb.Manifest.Name], PackageVersion{Name: fb.Manifest.Name, Version: fb.Manifest.Version, Dependencies: fb.Manifest.Dependencies, Features: fb.Manifest.Features})
 			sort.Sort(versionList(fr.index[fb.Manifest.Name]))
 			fr.rev[key] = cid
 		}
@@ -114,7 +120,26 @@ func (r *FileRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, erro
 		return "", errors.New("empty data")
 	}
 
+	if blob.Manifest.License != "" {
+		if err := ValidateLicense(blob.Manifest.License); err != nil {
+			return "", fmt.Errorf("publish %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+		}
+	}
+
+	if err := ValidateFeatures(blob.Manifest.Features, blob.Manifest.Dependencies); err != nil {
+		return "", fmt.Errorf("publish %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+	}
+
+	if len(blob.Manifest.Files) > 0 {
+		if err := VerifyArchive(blob.Data, blob.Manifest.Files); err != nil {
+			return "", fmt.Errorf("publish %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+		}
+	}
+
+	// release metadata is kept by the registry, never taken from the publisher.
+	blob.Manifest.Yanked, blob.Manifest.Deprecated = false, ""
 	id := ComputeCID(blob.Data)
+	added := false
 
 	r.mu.Lock()
 	if _, exists := r.blobs[id]; !exists {
@@ -135,13 +160,17 @@ func (r *FileRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, erro
 		}
 
 		r.blobs[id] = blob
-		pv := PackageVersion{Name: blob.Manifest.Name, Version: blob.Manifest.Version, Dependencies: blob.Manifest.Dependencies}
+		pv := PackageVersion{Name: blob.Manifest.Name, Version: blob.Manifest.Version, Dependencies: blob.Manifest.Dependencies, Features: blob.Manifest.Features}
 		r.index[blob.Manifest.Name] = append(r.index[blob.Manifest.Name], pv)
 		sort.Sort(versionList(r.index[blob.Manifest.Name]))
 		r.rev[string(blob.Manifest.Name)+"@"+string(blob.Manifest.Version)] = id
-		_ = r.persistIndex()
+		added = true
 	}
 	r.mu.Unlock()
+	// persistIndex takes the read lock itself.
+	if added {
+		_ = r.persistIndex()
+	}
 
 	return id, nil
 }
@@ -191,7 +220,7 @@ func (r *FileRegistry) Fetch(ctx context.Context, id CID) (PackageBlob, error) {
 	}
 
 	if !found {
-		r.index[fb.Manifest.Name] = append(r.index[fb.Manifest.Name], PackageVersion{Name: fb.Manifest.Name, Version: fb.Manifest.Version, Dependencies: fb.Manifest.Dependencies})
+		r.index[fb.Manifest.Name] = append(r.index[fb.Manifest.Name], PackageVersion{Name: fb.Manifest.Name, Version: fb.Manifest.Version, Dependencies: fb.Manifest.Dependencies, Features: fb.Manifest.Features})
 		sort.Sort(versionList(r.index[fb.Manifest.Name]))
 	}
 	r.mu.Unlock()
@@ -207,8 +236,14 @@ func (r *FileRegistry) Find(ctx context.Context, name PackageID, constraint *sem
 	for k, v := range r.rev {
 		rev[k] = v
 	}
+
+	var yanked map[Version]bool
+	if m := r.meta[name]; m != nil {
+		yanked = m.Yanked
+	}
 	r.mu.RUnlock()
-	// pick highest that satisfies.
+	// pick highest that satisfies, preferring versions that are not yanked;
+	// a yanked version is only returned when nothing else matches.
 	bestIdx := -1
 
 	var bestVer *semver.Version
@@ -219,7 +254,14 @@ func (r *FileRegistry) Find(ctx context.Context, name PackageID, constraint *sem
 			continue
 		}
 
-		if bestIdx == -1 || sv.GreaterThan(bestVer) {
+		if bestIdx == -1 {
+			bestIdx, bestVer = i, sv
+
+			continue
+		}
+
+		cur, cand := yanked[list[bestIdx].Version], yanked[list[i].Version]
+		if (cur && !cand) || (cur == cand && sv.GreaterThan(bestVer)) {
 			bestIdx, bestVer = i, sv
 		}
 	}
@@ -249,18 +291,44 @@ func (r *FileRegistry) Find(ctx context.Context, name PackageID, constraint *sem
 			return "", PackageManifest{}, ErrNotFound
 		}
 	}
-	// manifest.
-	return id, PackageManifest(pv), nil
+	r.mu.RLock()
+	mf := r.withMeta(pv)
+	r.mu.RUnlock()
+
+	return id, mf, nil
 }
 
 func (r *FileRegistry) List(ctx context.Context, name PackageID) ([]PackageManifest, error) {
 	r.mu.RLock()
-	list := append([]PackageVersion(nil), r.index[name]...)
-	r.mu.RUnlock()
+	defer r.mu.RUnlock()
+
+	out := make([]PackageManifest, 0, len(r.index[name]))
+	for _, pv := range r.index[name] {
+		out = append(out, r.withMeta(pv))
+	}
 
-	out := make([]PackageManifest, 0, len(list))
-	for _, pv := range list {
-		out = append(out, PackageManifest(pv))
+	return out, nil
+}
+
+// CIDs returns the CID of every version of name; see CIDLister.
+func (r *FileRegistry) CIDs(ctx context.Context, name PackageID) (map[Version]CID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[Version]CID, len(r.index[name]))
+	for _, pv := range r.index[name] {
+		if id, ok := r.rev[string(name)+"@"+string(pv.Version)]; ok {
+			out[pv.Version] = id
+		}
+	}
+
+	// fallback: search blobs for versions missing from the rev map.
+	if len(out) < len(r.index[name]) {
+		for cid, b := range r.blobs {
+			if _, ok := out[b.Manifest.Version]; b.Manifest.Name == name && !ok {
+				out[b.Manifest.Version] = cid
+			}
+		}
 	}
 
 	return out, nil
@@ -272,7 +340,8 @@ func (r *FileRegistry) All(ctx context.Context) ([]PackageManifest, error) {
 
 	for name, vers := range r.index {
 		for _, pv := range vers {
-			out = append(out, PackageManifest{Name: name, Version: pv.Version, Dependencies: pv.Dependencies})
+			pv.Name = name
+			out = append(out, r.withMeta(pv))
 		}
 	}
 	r.mu.RUnlock()
@@ -295,10 +364,11 @@ func (r *FileRegistry) All(ctx context.Context) ([]PackageManifest, error) {
 func (r *FileRegistry) persistIndex() error {
 	r.mu.RLock()
 	entries := make([]struct {
-		Name         PackageID    `json:"name"`
-		Version      Version      `json:"version"`
-		CID          CID          `json:"cid"`
-		Dependencies []Dependency `json:"dependencies,omitempty"`
+		Name         PackageID           `json:"name"`
+		Version      Version             `json:"version"`
+		CID          CID                 `json:"cid"`
+		Dependencies []Dependency        `json:"dependencies,omitempty"`
+		Features     map[string][]string `json:"features,omitempty"`
 	}, 0)
 
 	for name, vers := range r.index {
@@ -306,11 +376,12 @@ func (r *FileRegistry) persistIndex() error {
 			key := string(name) + "@" + string(pv.Version)
 			cid := r.rev[key]
 			entries = append(entries, struct {
-				Name         PackageID    `json:"name"`
-				Version      Version      `json:"version"`
-				CID          CID          `json:"cid"`
-				Dependencies []Dependency `json:"dependencies,omitempty"`
-			}{Name: name, Version: pv.Version, CID: cid, Dependencies: pv.Dependencies})
+				Name         PackageID           `json:"name"`
+				Version      Version             `json:"version"`
+				CID          CID                 `json:"cid"`
+				Dependencies []Dependency        `json:"dependencies,omitempty"`
+				Features     map[string][]string `json:"features,omitempty"`
+			}{Name: name, Version: pv.Version, CID: cid, Dependencies: pv.Dependencies, Features: pv.Features})
 		}
 	}
 	r.mu.RUnlock()
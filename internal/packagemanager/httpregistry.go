@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -11,8 +12,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	semver "github.com/Masterminds/semver/v3"
@@ -39,6 +42,17 @@ type HTTPRegistry struct {
 	}
 	ttl time.Duration
 	sf  singleflight.Group
+	// sparse index state (see sparseindex.go): fetched files, the optional
+	// on-disk cache, dependencies already queued for prefetch and whether
+	// the server turned out not to serve /index at all.
+	indexCache map[PackageID]struct {
+		at   time.Time
+		file IndexFile
+	}
+	indexDir    string
+	prefetched  map[PackageID]bool
+	prefetchSem chan struct{}
+	noSparse    atomic.Bool
 }
 
 // NewHTTPRegistry creates a client. It will use ORIZON_REGISTRY_TOKEN env as Bearer token if present.
@@ -77,6 +91,12 @@ func NewHTTPRegistry(baseURL string) *HTTPRegistry {
 			etag string
 		}),
 		ttl: 30 * time.Second,
+		indexCache: make(map[PackageID]struct {
+			at   time.Time
+			file IndexFile
+		}),
+		prefetched:  make(map[PackageID]bool),
+		prefetchSem: make(chan struct{}, ioConcurrency()),
 	}
 }
 
@@ -109,6 +129,12 @@ func NewHTTPRegistryWithAuth(baseURL, token string) *HTTPRegistry {
 			etag string
 		}),
 		ttl: 30 * time.Second,
+		indexCache: make(map[PackageID]struct {
+			at   time.Time
+			file IndexFile
+		}),
+		prefetched:  make(map[PackageID]bool),
+		prefetchSem: make(chan struct{}, ioConcurrency()),
 	}
 }
 
@@ -156,6 +182,14 @@ func (r *HTTPRegistry) doWithRetry(req *http.Request) (*http.Response, error) {
 }
 
 func (r *HTTPRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, error) {
+	id, _, err := r.PublishWithReceipt(ctx, blob)
+
+	return id, err
+}
+
+// PublishWithReceipt publishes blob and returns the server's transparency log
+// receipt, which is nil when the server keeps no log.
+func (r *HTTPRegistry) PublishWithReceipt(ctx context.Context, blob PackageBlob) (CID, *LogReceipt, error) {
 	fb := struct {
 		Manifest PackageManifest `json:"manifest"`
 		Data     []byte          `json:"data"`
@@ -170,7 +204,7 @@ func (r *HTTPRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, erro
 
 	resp, err := r.doWithRetry(req)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	defer resp.Body.Close()
@@ -178,18 +212,21 @@ func (r *HTTPRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, erro
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
 
-		return "", fmt.Errorf("publish failed: %s", string(body))
+		return "", nil, fmt.Errorf("publish failed: %s", string(body))
 	}
 
 	var out struct {
-		CID CID `json:"cid"`
+		Receipt *LogReceipt `json:"receipt,omitempty"`
+		CID     CID         `json:"cid"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return out.CID, nil
+	r.dropIndex(blob.Manifest.Name)
+
+	return out.CID, out.Receipt, nil
 }
 
 func (r *HTTPRegistry) Fetch(ctx context.Context, id CID) (PackageBlob, error) {
@@ -226,10 +263,21 @@ func (r *HTTPRegistry) Fetch(ctx context.Context, id CID) (PackageBlob, error) {
 		return PackageBlob{}, err
 	}
 
-	return PackageBlob{Manifest: fb.Manifest, Data: fb.Data}, nil
+	blob := PackageBlob{Manifest: fb.Manifest, Data: fb.Data}
+	if err := VerifyBlob(id, blob); err != nil {
+		return PackageBlob{}, err
+	}
+
+	return blob, nil
 }
 
 func (r *HTTPRegistry) Find(ctx context.Context, name PackageID, constraint *semver.Constraints) (CID, PackageManifest, error) {
+	if f, err := r.sparseIndex(ctx, name); err == nil {
+		return f.Find(constraint)
+	} else if !errors.Is(err, errNoSparseIndex) {
+		return "", PackageManifest{}, err
+	}
+
 	// cache key: name|constraint.
 	key := string(name) + "|"
 	if constraint != nil {
@@ -329,6 +377,12 @@ func (r *HTTPRegistry) Find(ctx context.Context, name PackageID, constraint *sem
 }
 
 func (r *HTTPRegistry) List(ctx context.Context, name PackageID) ([]PackageManifest, error) {
+	if f, err := r.sparseIndex(ctx, name); err == nil {
+		return f.Manifests(), nil
+	} else if !errors.Is(err, errNoSparseIndex) {
+		return nil, err
+	}
+
 	r.mu.RLock()
 	if c, ok := r.listCache[name]; ok && time.Since(c.at) < r.ttl {
 		r.mu.RUnlock()
@@ -422,3 +476,191 @@ func (r *HTTPRegistry) All(ctx context.Context) ([]PackageManifest, error) {
 
 	return out, nil
 }
+
+// Advisories downloads the registry's signed advisory database.
+func (r *HTTPRegistry) Advisories(ctx context.Context) (SignedAdvisoryDB, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/advisories", http.NoBody)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return SignedAdvisoryDB{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return SignedAdvisoryDB{}, ErrNotFound
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+
+		return SignedAdvisoryDB{}, fmt.Errorf("advisories failed: %s", string(body))
+	}
+
+	var out SignedAdvisoryDB
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return SignedAdvisoryDB{}, err
+	}
+
+	return out, nil
+}
+
+// TreeHead downloads the registry's current signed tree head.
+func (r *HTTPRegistry) TreeHead(ctx context.Context) (SignedTreeHead, error) {
+	var out SignedTreeHead
+
+	return out, r.getLog(ctx, "head", nil, &out)
+}
+
+// InclusionProof downloads the inclusion proof for name@version in the tree of the given size.
+func (r *HTTPRegistry) InclusionProof(ctx context.Context, name PackageID, version Version, treeSize uint64) (InclusionProof, error) {
+	q := url.Values{}
+	q.Set("name", string(name))
+	q.Set("version", string(version))
+	q.Set("tree_size", strconv.FormatUint(treeSize, 10))
+
+	var out InclusionProof
+
+	return out, r.getLog(ctx, "proof", q, &out)
+}
+
+// ConsistencyProof downloads the consistency proof between two tree sizes.
+func (r *HTTPRegistry) ConsistencyProof(ctx context.Context, first, second uint64) (ConsistencyProof, error) {
+	q := url.Values{}
+	q.Set("first", strconv.FormatUint(first, 10))
+	q.Set("second", strconv.FormatUint(second, 10))
+
+	var out ConsistencyProof
+
+	return out, r.getLog(ctx, "consistency", q, &out)
+}
+
+// getLog fetches a transparency log resource; 404 maps to ErrNotFound.
+func (r *HTTPRegistry) getLog(ctx context.Context, resource string, q url.Values, out any) error {
+	u := r.base + "/log/" + resource
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("log %s failed: %s", resource, string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Yank yanks or restores name@version on the server.
+func (r *HTTPRegistry) Yank(ctx context.Context, name PackageID, version Version, yanked bool) error {
+	return r.postAdmin(ctx, "yank", adminRequest{Name: name, Version: version, Yanked: yanked})
+}
+
+// Deprecate sets or clears the server's deprecation notice of name.
+func (r *HTTPRegistry) Deprecate(ctx context.Context, name PackageID, message string) error {
+	return r.postAdmin(ctx, "deprecate", adminRequest{Name: name, Message: message})
+}
+
+// Owners lists the owners of name on the server.
+func (r *HTTPRegistry) Owners(ctx context.Context, name PackageID) ([]string, error) {
+	q := url.Values{}
+	q.Set("name", string(name))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/owners?"+q.Encode(), http.NoBody)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotSupported
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("owners failed: %s", strings.TrimSpace(string(body)))
+	}
+
+	var out []string
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// AddOwner adds an owner of name on the server.
+func (r *HTTPRegistry) AddOwner(ctx context.Context, name PackageID, owner string) error {
+	return r.postAdmin(ctx, "owners", adminRequest{Name: name, Add: owner})
+}
+
+// RemoveOwner removes an owner of name on the server.
+func (r *HTTPRegistry) RemoveOwner(ctx context.Context, name PackageID, owner string) error {
+	return r.postAdmin(ctx, "owners", adminRequest{Name: name, Remove: owner})
+}
+
+// postAdmin sends a release management request and drops the cached
+// lookups of the package so the change is seen immediately.
+func (r *HTTPRegistry) postAdmin(ctx context.Context, endpoint string, body adminRequest) error {
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, r.base+"/"+endpoint, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		msg, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("%s failed (%d): %s", endpoint, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	r.dropIndex(body.Name)
+
+	r.mu.Lock()
+	delete(r.listCache, body.Name)
+
+	for k := range r.findCache {
+		if strings.HasPrefix(k, string(body.Name)+"|") {
+			delete(r.findCache, k)
+		}
+	}
+	r.mu.Unlock()
+
+	return nil
+}
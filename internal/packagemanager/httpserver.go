@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -316,10 +317,22 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 		mode = "write"
 	}
 
+	// optional per-user tokens via ORIZON_REGISTRY_USERS; users may only
+	// publish and manage the packages they own, the admin token may do anything.
+	users, err := LoadRegistryUsers(os.Getenv("ORIZON_REGISTRY_USERS"))
+	if err != nil {
+		log.Printf("registry users: %v; only the admin token is accepted", err)
+	}
+
+	authOn := token != "" || os.Getenv("ORIZON_REGISTRY_USERS") != ""
+
 	maxPublish := getMaxPublishBytes()
-	authOK := func(r *http.Request) bool {
-		if token == "" {
-			return true
+	// identify returns the authenticated user, whether the caller holds the
+	// admin token, and whether authentication succeeded at all. Without any
+	// tokens configured every caller is treated as admin.
+	identify := func(r *http.Request) (string, bool, bool) {
+		if !authOn {
+			return "", true, true
 		}
 
 		ah := r.Header.Get("Authorization")
@@ -334,11 +347,21 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 				"path":   r.URL.Path,
 			})
 
-			return false
+			return "", false, false
 		}
 		// Use constant-time comparison to prevent timing attacks.
 		providedToken := ah[len(p):]
-		if !SecureCompare(providedToken, token) {
+		user, admin := "", token != "" && SecureCompare(providedToken, token)
+
+		if !admin {
+			for name, t := range users {
+				if SecureCompare(providedToken, t) {
+					user = name
+				}
+			}
+		}
+
+		if !admin && user == "" {
 			// Log failed authentication attempt.
 			globalSecurityLogger.LogAuthenticationAttempt(false, r.Header.Get("User-Agent"), r.RemoteAddr, map[string]interface{}{
 				"reason": "invalid_token",
@@ -346,15 +369,71 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 				"path":   r.URL.Path,
 			})
 
-			return false
+			return "", false, false
 		}
 		// Log successful authentication.
 		globalSecurityLogger.LogAuthenticationAttempt(true, r.Header.Get("User-Agent"), r.RemoteAddr, map[string]interface{}{
 			"method": r.Method,
 			"path":   r.URL.Path,
+			"user":   user,
 		})
 
-		return true
+		return user, admin, true
+	}
+	authOK := func(r *http.Request) bool {
+		_, _, ok := identify(r)
+
+		return ok
+	}
+	// mayManage reports whether the caller may publish or administer name:
+	// the admin token always may, a user only if they are one of its owners.
+	// A package without owners is managed by the admin token alone, except
+	// that publishing a new one (claim) makes the publisher its owner. It
+	// writes the error response itself.
+	mayManage := func(w http.ResponseWriter, r *http.Request, name PackageID, claim bool) (string, bool) {
+		user, admin, ok := identify(r)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return "", false
+		}
+
+		a, isAdmin := reg.(PackageAdmin)
+		if admin || !isAdmin {
+			return user, true
+		}
+
+		owners, err := a.Owners(r.Context(), name)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+
+			return "", false
+		}
+
+		if len(owners) > 0 {
+			if !IsOwner(owners, user) {
+				http.Error(w, fmt.Sprintf("%s is not an owner of %s", user, name), http.StatusForbidden)
+
+				return "", false
+			}
+
+			return user, true
+		}
+
+		existing, err := reg.List(r.Context(), name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+
+			return "", false
+		}
+
+		if !claim || len(existing) > 0 {
+			http.Error(w, fmt.Sprintf("%s has no owners; only the registry admin may manage it", name), http.StatusForbidden)
+
+			return "", false
+		}
+
+		return user, true
 	}
 	// simple health endpoint.
 	mux.HandleFunc("/healthz", m.wrap("healthz", cors, func(w http.ResponseWriter, r *http.Request) {
@@ -382,8 +461,8 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 
 			return
 		}
-		// publish is always protected when token is set.
-		if token != "" && !authOK(r) {
+		// publish is always protected when tokens are set.
+		if authOn && !authOK(r) {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 
 			return
@@ -456,6 +535,24 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 			return
 		}
 
+		if fb.Manifest.License != "" {
+			if err := ValidateLicense(fb.Manifest.License); err != nil {
+				globalSecurityLogger.LogInputValidationFailure("package_license", err.Error(), fb.Manifest.License)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				closeIfGzip(w)
+
+				return
+			}
+		}
+
+		if err := ValidateFeatures(fb.Manifest.Features, fb.Manifest.Dependencies); err != nil {
+			globalSecurityLogger.LogInputValidationFailure("package_features", err.Error(), string(fb.Manifest.Name))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			closeIfGzip(w)
+
+			return
+		}
+
 		// Validate package data size.
 		if len(fb.Data) > int(maxPublish) {
 			http.Error(w, "package data too large", http.StatusRequestEntityTooLarge)
@@ -463,18 +560,46 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 			return
 		}
 
-		cid, err := reg.Publish(r.Context(), PackageBlob{Manifest: fb.Manifest, Data: fb.Data})
+		// only owners may publish new versions; a new package is claimed by its publisher.
+		user, ok := mayManage(w, r, fb.Manifest.Name, true)
+		if !ok {
+			closeIfGzip(w)
+
+			return
+		}
+
+		var (
+			cid     CID
+			receipt *LogReceipt
+		)
+
+		blob := PackageBlob{Manifest: fb.Manifest, Data: fb.Data}
+		if rp, ok := reg.(ReceiptPublisher); ok {
+			cid, receipt, err = rp.PublishWithReceipt(r.Context(), blob)
+		} else {
+			cid, err = reg.Publish(r.Context(), blob)
+		}
+
 		if err != nil {
 			log.Printf("Publish error: %v", err)
 			http.Error(w, "internal server error", 500)
 
 			return
 		}
+		// the first user to publish a package becomes its owner.
+		if a, ok := reg.(PackageAdmin); ok && user != "" {
+			if owners, err := a.Owners(r.Context(), fb.Manifest.Name); err == nil && len(owners) == 0 {
+				if err := a.AddOwner(r.Context(), fb.Manifest.Name, user); err != nil {
+					log.Printf("Owner error for package %s: %v", fb.Manifest.Name, err)
+				}
+			}
+		}
 		// No-store for publish responses.
 		w.Header().Set("Cache-Control", "no-store")
 		writeJSONWithETag(w, r, struct {
-			CID CID `json:"cid"`
-		}{CID: cid})
+			Receipt *LogReceipt `json:"receipt,omitempty"`
+			CID     CID         `json:"cid"`
+		}{CID: cid, Receipt: receipt})
 	}))
 	mux.HandleFunc("/fetch", m.wrap("fetch", cors, func(w http.ResponseWriter, r *http.Request) {
 		if rl != nil && !rl.Allow(1) {
@@ -485,7 +610,7 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 			return
 		}
 		// protect reads only in readwrite mode.
-		if token != "" && mode == "readwrite" && !authOK(r) {
+		if authOn && mode == "readwrite" && !authOK(r) {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 
 			return
@@ -509,7 +634,7 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 		}
 
 		w = maybeGzip(w, r)
-		if token != "" && mode == "readwrite" {
+		if authOn && mode == "readwrite" {
 			w.Header().Add("Vary", "Authorization")
 		}
 
@@ -547,7 +672,7 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 			return
 		}
 
-		if token != "" && mode == "readwrite" && !authOK(r) {
+		if authOn && mode == "readwrite" && !authOK(r) {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 
 			return
@@ -571,7 +696,7 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 		}
 
 		w = maybeGzip(w, r)
-		if token != "" && mode == "readwrite" {
+		if authOn && mode == "readwrite" {
 			w.Header().Add("Vary", "Authorization")
 		}
 
@@ -632,14 +757,14 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 			return
 		}
 
-		if token != "" && mode == "readwrite" && !authOK(r) {
+		if authOn && mode == "readwrite" && !authOK(r) {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 
 			return
 		}
 
 		w = maybeGzip(w, r)
-		if token != "" && mode == "readwrite" {
+		if authOn && mode == "readwrite" {
 			w.Header().Add("Vary", "Authorization")
 		}
 
@@ -658,6 +783,45 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 
 		writeJSONWithETag(w, r, out)
 	}))
+	// sparse index: one document per package that clients revalidate with
+	// If-None-Match instead of calling /list and /find.
+	mux.HandleFunc("/index/", m.wrap("index", cors, func(w http.ResponseWriter, r *http.Request) {
+		if rl != nil && !rl.Allow(1) {
+			w.Header().Set("Retry-After", "1")
+			atomic.AddUint64(&m.rlDrops, 1)
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+			return
+		}
+
+		if authOn && mode == "readwrite" && !authOK(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		nameParam := strings.TrimPrefix(r.URL.Path, "/index/")
+		if err := NewInputValidator().ValidatePackageID(nameParam); err != nil {
+			http.Error(w, "invalid package name", http.StatusBadRequest)
+
+			return
+		}
+
+		f, err := BuildIndexFile(r.Context(), reg, PackageID(nameParam))
+		if err != nil {
+			log.Printf("Index error for package %s: %v", nameParam, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+
+			return
+		}
+
+		w = maybeGzip(w, r)
+		if authOn && mode == "readwrite" {
+			w.Header().Add("Vary", "Authorization")
+		}
+
+		writeJSONWithETag(w, r, f)
+	}))
 	mux.HandleFunc("/all", m.wrap("all", cors, func(w http.ResponseWriter, r *http.Request) {
 		if rl != nil && !rl.Allow(1) {
 			w.Header().Set("Retry-After", "1")
@@ -667,14 +831,14 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 			return
 		}
 
-		if token != "" && mode == "readwrite" && !authOK(r) {
+		if authOn && mode == "readwrite" && !authOK(r) {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 
 			return
 		}
 
 		w = maybeGzip(w, r)
-		if token != "" && mode == "readwrite" {
+		if authOn && mode == "readwrite" {
 			w.Header().Add("Vary", "Authorization")
 		}
 
@@ -691,6 +855,284 @@ func buildHTTPMux(reg Registry) *http.ServeMux {
 
 		writeJSONWithETag(w, r, out)
 	}))
+	// signed advisory database, when the backing registry publishes one.
+	mux.HandleFunc("/advisories", m.wrap("advisories", cors, func(w http.ResponseWriter, r *http.Request) {
+		if rl != nil && !rl.Allow(1) {
+			w.Header().Set("Retry-After", "1")
+			atomic.AddUint64(&m.rlDrops, 1)
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if authOn && mode == "readwrite" && !authOK(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		p, ok := reg.(AdvisoryProvider)
+		if !ok {
+			http.Error(w, "advisories not available", http.StatusNotFound)
+
+			return
+		}
+
+		signed, err := p.Advisories(r.Context())
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "advisories not available", http.StatusNotFound)
+
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+
+			return
+		}
+
+		w = maybeGzip(w, r)
+		if authOn && mode == "readwrite" {
+			w.Header().Add("Vary", "Authorization")
+		}
+
+		writeJSONWithETag(w, r, signed)
+	}))
+	// transparency log: /log/head, /log/proof and /log/consistency, when the backing registry keeps one.
+	mux.HandleFunc("/log/", m.wrap("log", cors, func(w http.ResponseWriter, r *http.Request) {
+		if rl != nil && !rl.Allow(1) {
+			w.Header().Set("Retry-After", "1")
+			atomic.AddUint64(&m.rlDrops, 1)
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if authOn && mode == "readwrite" && !authOK(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		p, ok := reg.(TransparencyLogProvider)
+		if !ok {
+			http.Error(w, "transparency log not available", http.StatusNotFound)
+
+			return
+		}
+
+		q := r.URL.Query()
+		parseSize := func(key string) (uint64, bool) {
+			v := q.Get(key)
+			if v == "" {
+				return 0, true
+			}
+
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid "+key+" parameter", http.StatusBadRequest)
+
+				return 0, false
+			}
+
+			return n, true
+		}
+
+		var (
+			out any
+			err error
+		)
+
+		switch strings.TrimPrefix(r.URL.Path, "/log/") {
+		case "head":
+			out, err = p.TreeHead(r.Context())
+		case "proof":
+			validator := NewInputValidator()
+			if err := validator.ValidatePackageID(q.Get("name")); err != nil {
+				http.Error(w, "invalid name parameter", http.StatusBadRequest)
+
+				return
+			}
+
+			if err := validator.ValidateVersion(q.Get("version")); err != nil {
+				http.Error(w, "invalid version parameter", http.StatusBadRequest)
+
+				return
+			}
+
+			size, ok := parseSize("tree_size")
+			if !ok {
+				return
+			}
+
+			out, err = p.InclusionProof(r.Context(), PackageID(q.Get("name")), Version(q.Get("version")), size)
+		case "consistency":
+			first, ok := parseSize("first")
+			if !ok {
+				return
+			}
+
+			second, ok := parseSize("second")
+			if !ok {
+				return
+			}
+
+			out, err = p.ConsistencyProof(r.Context(), first, second)
+		default:
+			http.NotFound(w, r)
+
+			return
+		}
+
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+
+			return
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		w = maybeGzip(w, r)
+		// Tree heads change with every publish.
+		w.Header().Set("Cache-Control", "no-cache")
+
+		if authOn && mode == "readwrite" {
+			w.Header().Add("Vary", "Authorization")
+		}
+
+		writeJSONWithETag(w, r, out)
+	}))
+	// release management: /owners, /yank and /deprecate, when the backing registry supports it.
+	adminHandler := func(name string, h func(w http.ResponseWriter, r *http.Request, a PackageAdmin)) http.HandlerFunc {
+		return m.wrap(name, cors, func(w http.ResponseWriter, r *http.Request) {
+			if rl != nil && !rl.Allow(1) {
+				w.Header().Set("Retry-After", "1")
+				atomic.AddUint64(&m.rlDrops, 1)
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+				return
+			}
+
+			a, ok := reg.(PackageAdmin)
+			if !ok {
+				http.Error(w, "package administration not available", http.StatusNotFound)
+
+				return
+			}
+
+			w.Header().Set("Cache-Control", "no-store")
+			h(w, r, a)
+		})
+	}
+	// decodeAdmin decodes a POST body and checks that the caller may manage req.Name.
+	decodeAdmin := func(w http.ResponseWriter, r *http.Request) (adminRequest, bool) {
+		var req adminRequest
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+			return req, false
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "JSON decode error", http.StatusBadRequest)
+
+			return req, false
+		}
+
+		if err := NewInputValidator().ValidatePackageID(string(req.Name)); err != nil {
+			http.Error(w, "invalid package name", http.StatusBadRequest)
+
+			return req, false
+		}
+
+		_, ok := mayManage(w, r, req.Name, false)
+
+		return req, ok
+	}
+	adminResult := func(w http.ResponseWriter, r *http.Request, err error) {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			writeJSONWithETag(w, r, struct {
+				OK bool `json:"ok"`
+			}{OK: true})
+		}
+	}
+	mux.HandleFunc("/owners", adminHandler("owners", func(w http.ResponseWriter, r *http.Request, a PackageAdmin) {
+		if r.Method == http.MethodGet {
+			if authOn && mode == "readwrite" && !authOK(r) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+				return
+			}
+
+			owners, err := a.Owners(r.Context(), PackageID(r.URL.Query().Get("name")))
+			if err != nil {
+				http.Error(w, err.Error(), 500)
+
+				return
+			}
+
+			writeJSONWithETag(w, r, owners)
+
+			return
+		}
+
+		req, ok := decodeAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		var err error
+
+		switch {
+		case req.Add != "":
+			err = a.AddOwner(r.Context(), req.Name, req.Add)
+		case req.Remove != "":
+			err = a.RemoveOwner(r.Context(), req.Name, req.Remove)
+		default:
+			err = errors.New("add or remove required")
+		}
+
+		adminResult(w, r, err)
+	}))
+	mux.HandleFunc("/yank", adminHandler("yank", func(w http.ResponseWriter, r *http.Request, a PackageAdmin) {
+		req, ok := decodeAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		adminResult(w, r, a.Yank(r.Context(), req.Name, req.Version, req.Yanked))
+	}))
+	mux.HandleFunc("/deprecate", adminHandler("deprecate", func(w http.ResponseWriter, r *http.Request, a PackageAdmin) {
+		req, ok := decodeAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		adminResult(w, r, a.Deprecate(r.Context(), req.Name, req.Message))
+	}))
 	// metrics endpoint (no rate limiting).
 	mux.HandleFunc("/metrics", m.wrap("metrics", cors, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodHead {
@@ -761,6 +1203,42 @@ func StartHTTPServerTLSGraceful(ctx context.Context, reg Registry, addr, certFil
 	}
 }
 
+// adminRequest is the body of the /owners, /yank and /deprecate endpoints.
+type adminRequest struct {
+	Name    PackageID `json:"name"`
+	Version Version   `json:"version,omitempty"`
+	Yanked  bool      `json:"yanked,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Add     string    `json:"add,omitempty"`
+	Remove  string    `json:"remove,omitempty"`
+}
+
+// LoadRegistryUsers reads a JSON object mapping user names to bearer tokens.
+// An empty path means no users.
+func LoadRegistryUsers(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var users map[string]string
+	if err := json.Unmarshal(b, &users); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	for name, t := range users {
+		if name == "" || t == "" {
+			return nil, fmt.Errorf("%s: user names and tokens must be non-empty", path)
+		}
+	}
+
+	return users, nil
+}
+
 // httpTokenEnv returns a bearer token from ORIZON_REGISTRY_TOKEN if set.
 func httpTokenEnv() string { return os.Getenv("ORIZON_REGISTRY_TOKEN") }
 
@@ -271,14 +271,15 @@ func (iv *InputValidator) ValidateCID(cid string) error {
 		return fmt.Errorf("invalid CID: %w", err)
 	}
 
-	// CID should be hex-encoded hash.
-	if len(cid) != 64 { // SHA-256 hex length
-		return fmt.Errorf("invalid CID length: %d (expected: 64)", len(cid))
+	// CID should be hex-encoded hash, optionally with the prefix ComputeCID adds.
+	digest := strings.TrimPrefix(cid, "oz1-")
+	if len(digest) != 64 { // SHA-256 hex length
+		return fmt.Errorf("invalid CID length: %d (expected: 64)", len(digest))
 	}
 
 	// Check if valid hex.
 	hexPattern := regexp.MustCompile(`^[a-fA-F0-9]+$`)
-	if !hexPattern.MatchString(cid) {
+	if !hexPattern.MatchString(digest) {
 		return fmt.Errorf("CID contains non-hex characters: %s", cid)
 	}
 
@@ -0,0 +1,355 @@
+package packagemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidLicense is returned (wrapped) for license strings that are not
+// valid SPDX license expressions.
+var ErrInvalidLicense = errors.New("invalid SPDX license expression")
+
+// spdxLicenses is the subset of the SPDX license list the registry accepts
+// by identifier. Anything else must be declared as LicenseRef-<name>.
+var spdxLicenses = map[string]bool{
+	"0BSD": true, "AFL-3.0": true, "AGPL-1.0-only": true, "AGPL-1.0-or-later": true,
+	"AGPL-3.0-only": true, "AGPL-3.0-or-later": true, "Apache-1.0": true, "Apache-1.1": true,
+	"Apache-2.0": true, "APSL-2.0": true, "Artistic-1.0": true, "Artistic-2.0": true,
+	"BlueOak-1.0.0": true, "BSD-1-Clause": true, "BSD-2-Clause": true, "BSD-2-Clause-Patent": true,
+	"BSD-3-Clause": true, "BSD-3-Clause-Clear": true, "BSD-4-Clause": true, "BSL-1.0": true,
+	"BUSL-1.1": true, "CAL-1.0": true, "CC-BY-3.0": true, "CC-BY-4.0": true,
+	"CC-BY-NC-4.0": true, "CC-BY-NC-SA-4.0": true, "CC-BY-ND-4.0": true, "CC-BY-SA-3.0": true,
+	"CC-BY-SA-4.0": true, "CC0-1.0": true, "CDDL-1.0": true, "CDDL-1.1": true,
+	"CECILL-2.1": true, "CPAL-1.0": true, "CPL-1.0": true, "ECL-2.0": true,
+	"EFL-2.0": true, "EPL-1.0": true, "EPL-2.0": true, "EUPL-1.1": true,
+	"EUPL-1.2": true, "GFDL-1.3-only": true, "GFDL-1.3-or-later": true, "GPL-1.0-only": true,
+	"GPL-1.0-or-later": true, "GPL-2.0-only": true, "GPL-2.0-or-later": true, "GPL-3.0-only": true,
+	"GPL-3.0-or-later": true, "HPND": true, "ICU": true, "IJG": true,
+	"IPL-1.0": true, "ISC": true, "LGPL-2.0-only": true, "LGPL-2.0-or-later": true,
+	"LGPL-2.1-only": true, "LGPL-2.1-or-later": true, "LGPL-3.0-only": true, "LGPL-3.0-or-later": true,
+	"LPL-1.02": true, "LPPL-1.3c": true, "MirOS": true, "MIT": true,
+	"MIT-0": true, "MIT-CMU": true, "MPL-1.1": true, "MPL-2.0": true,
+	"MPL-2.0-no-copyleft-exception": true, "MS-PL": true, "MS-RL": true, "MulanPSL-2.0": true,
+	"NCSA": true, "OFL-1.1": true, "OpenSSL": true, "OSL-3.0": true,
+	"PHP-3.01": true, "PostgreSQL": true, "PSF-2.0": true, "Python-2.0": true,
+	"QPL-1.0": true, "Ruby": true, "SSPL-1.0": true, "Unicode-3.0": true,
+	"Unicode-DFS-2016": true, "Unlicense": true, "UPL-1.0": true, "Vim": true,
+	"W3C": true, "WTFPL": true, "X11": true, "Zlib": true,
+	"ZPL-2.1": true,
+}
+
+// spdxExceptions is the subset of the SPDX exception list accepted after WITH.
+var spdxExceptions = map[string]bool{
+	"Autoconf-exception-3.0": true, "Bison-exception-2.2": true, "Classpath-exception-2.0": true,
+	"GCC-exception-3.1": true, "LLVM-exception": true, "OpenJDK-assembly-exception-1.0": true,
+	"Qt-LGPL-exception-1.1": true, "Swift-exception": true, "Universal-FOSS-exception-1.0": true,
+	"WxWindows-exception-3.1": true, "eCos-exception-2.0": true, "Font-exception-2.0": true,
+}
+
+var licenseRefPattern = regexp.MustCompile(`^(DocumentRef-[A-Za-z0-9.\-]+:)?LicenseRef-[A-Za-z0-9.\-]+$`)
+
+// LicenseExpr is a parsed SPDX license expression. A leaf names a single
+// license (ID, optionally with "+" and a WITH exception); inner nodes combine
+// their Args with Op, which is "AND" or "OR".
+type LicenseExpr struct {
+	Op        string
+	ID        string
+	Exception string
+	OrLater   bool
+	Args      []*LicenseExpr
+}
+
+// String renders the expression in canonical SPDX form.
+func (e *LicenseExpr) String() string {
+	if e.Op == "" {
+		s := e.ID
+		if e.OrLater {
+			s += "+"
+		}
+
+		if e.Exception != "" {
+			s += " WITH " + e.Exception
+		}
+
+		return s
+	}
+
+	parts := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		parts[i] = a.String()
+		// AND binds tighter than OR, so only OR inside AND needs parentheses.
+		if e.Op == "AND" && a.Op == "OR" {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// ParseLicense parses and validates an SPDX license expression such as
+// "MIT OR (Apache-2.0 AND BSD-3-Clause)". Operators are case-insensitive;
+// license identifiers must be on the SPDX list or be LicenseRef-s.
+func ParseLicense(s string) (*LicenseExpr, error) {
+	toks := tokenizeLicense(s)
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidLicense)
+	}
+
+	p := &licenseParser{toks: toks}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrInvalidLicense, s, err)
+	}
+
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("%w: %q: unexpected %q", ErrInvalidLicense, s, p.toks[p.pos])
+	}
+
+	return e, nil
+}
+
+// ValidateLicense reports whether s is a valid SPDX license expression.
+func ValidateLicense(s string) error {
+	_, err := ParseLicense(s)
+
+	return err
+}
+
+func tokenizeLicense(s string) []string {
+	return strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s))
+}
+
+type licenseParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *licenseParser) peekOp(op string) bool {
+	return p.pos < len(p.toks) && strings.EqualFold(p.toks[p.pos], op)
+}
+
+func (p *licenseParser) parseOr() (*LicenseExpr, error) {
+	return p.parseBinary("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (*LicenseExpr, error) {
+	return p.parseBinary("AND", p.parseTerm)
+}
+
+// parseBinary parses operands joined by op into one flat node.
+func (p *licenseParser) parseBinary(op string, operand func() (*LicenseExpr, error)) (*LicenseExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []*LicenseExpr{first}
+
+	for p.peekOp(op) {
+		p.pos++
+
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		if next.Op == op {
+			args = append(args, next.Args...)
+		} else {
+			args = append(args, next)
+		}
+	}
+
+	if len(args) == 1 {
+		return first, nil
+	}
+
+	return &LicenseExpr{Op: op, Args: args}, nil
+}
+
+func (p *licenseParser) parseTerm() (*LicenseExpr, error) {
+	if p.pos >= len(p.toks) {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	tok := p.toks[p.pos]
+	p.pos++
+
+	if tok == "(" {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.pos >= len(p.toks) || p.toks[p.pos] != ")" {
+			return nil, errors.New("missing )")
+		}
+
+		p.pos++
+
+		return e, nil
+	}
+
+	if tok == ")" || strings.EqualFold(tok, "AND") || strings.EqualFold(tok, "OR") || strings.EqualFold(tok, "WITH") {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+
+	leaf := &LicenseExpr{ID: tok}
+	if strings.HasSuffix(tok, "+") {
+		leaf.ID, leaf.OrLater = strings.TrimSuffix(tok, "+"), true
+	}
+
+	if !spdxLicenses[leaf.ID] && !licenseRefPattern.MatchString(leaf.ID) {
+		return nil, fmt.Errorf("unknown license identifier %q", leaf.ID)
+	}
+
+	if p.peekOp("WITH") {
+		p.pos++
+
+		if p.pos >= len(p.toks) || !spdxExceptions[p.toks[p.pos]] {
+			return nil, errors.New("WITH must be followed by a known license exception")
+		}
+
+		leaf.Exception = p.toks[p.pos]
+		p.pos++
+	}
+
+	return leaf, nil
+}
+
+// LicensePolicy decides which licenses may appear in a dependency graph.
+// Deny always wins; when Allow is empty every license that is not denied is
+// accepted. Entries are license identifiers ("MIT", "GPL-3.0-only") or full
+// terms ("GPL-2.0-only WITH Classpath-exception-2.0").
+type LicensePolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+	// AllowUnknown accepts packages that declare no license at all.
+	AllowUnknown bool `json:"allow_unknown,omitempty"`
+	// Ignore lists packages exempt from the policy, e.g. internal ones.
+	Ignore []PackageID `json:"ignore,omitempty"`
+}
+
+// LoadLicensePolicy reads a JSON license policy file.
+func LoadLicensePolicy(path string) (LicensePolicy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return LicensePolicy{}, err
+	}
+
+	var p LicensePolicy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return LicensePolicy{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// leafAllowed checks a single license term against the policy.
+func (p LicensePolicy) leafAllowed(e *LicenseExpr) bool {
+	full := e.String()
+
+	for _, d := range p.Deny {
+		if d == e.ID || d == full {
+			return false
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return true
+	}
+
+	for _, a := range p.Allow {
+		if a == e.ID || a == full {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Satisfied reports whether the expression can be complied with under the
+// policy: some choice of OR branches uses only permitted licenses.
+func (p LicensePolicy) Satisfied(e *LicenseExpr) bool {
+	switch e.Op {
+	case "AND":
+		for _, a := range e.Args {
+			if !p.Satisfied(a) {
+				return false
+			}
+		}
+
+		return true
+	case "OR":
+		for _, a := range e.Args {
+			if p.Satisfied(a) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return p.leafAllowed(e)
+	}
+}
+
+// LicenseFinding reports a locked package whose license violates a policy.
+type LicenseFinding struct {
+	Name    PackageID `json:"name"`
+	Version Version   `json:"version"`
+	License string    `json:"license,omitempty"`
+	Reason  string    `json:"reason"`
+}
+
+// CheckLicenses evaluates every entry of lf against the policy and returns
+// the violations, sorted by package name.
+func CheckLicenses(lf Lockfile, policy LicensePolicy) []LicenseFinding {
+	ignored := make(map[PackageID]bool, len(policy.Ignore))
+	for _, name := range policy.Ignore {
+		ignored[name] = true
+	}
+
+	var findings []LicenseFinding
+
+	for _, e := range lf.Entries {
+		if ignored[e.Name] {
+			continue
+		}
+
+		f := LicenseFinding{Name: e.Name, Version: e.Version, License: e.License}
+
+		if e.License == "" {
+			if policy.AllowUnknown {
+				continue
+			}
+
+			f.Reason = "no license declared"
+			findings = append(findings, f)
+
+			continue
+		}
+
+		expr, err := ParseLicense(e.License)
+		if err != nil {
+			f.Reason = err.Error()
+			findings = append(findings, f)
+
+			continue
+		}
+
+		if !policy.Satisfied(expr) {
+			f.Reason = "license not permitted by policy"
+			findings = append(findings, f)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Name < findings[j].Name })
+
+	return findings
+}
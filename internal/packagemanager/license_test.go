@@ -0,0 +1,134 @@
+package packagemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLicense(t *testing.T) {
+	for in, want := range map[string]string{
+		"MIT":                                  "MIT",
+		"mit or Apache-2.0":                    "",
+		"MIT OR Apache-2.0":                    "MIT OR Apache-2.0",
+		"(MIT or Apache-2.0) and BSD-3-Clause": "(MIT OR Apache-2.0) AND BSD-3-Clause",
+		"GPL-2.0-only WITH Classpath-exception-2.0": "GPL-2.0-only WITH Classpath-exception-2.0",
+		"LicenseRef-Acme-Internal OR MIT":           "LicenseRef-Acme-Internal OR MIT",
+		"Apache-2.0 AND (MIT OR (ISC OR Zlib))":     "Apache-2.0 AND (MIT OR ISC OR Zlib)",
+		"":                                          "",
+		"MIT AND":                                   "",
+		"(MIT":                                      "",
+		"GPL-2.0-only WITH Bogus-exception":         "",
+	} {
+		e, err := ParseLicense(in)
+		if want == "" {
+			if err == nil || !errors.Is(err, ErrInvalidLicense) {
+				t.Errorf("%q: expected invalid, got %v", in, e)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+
+			continue
+		}
+
+		if e.String() != want {
+			t.Errorf("%q: got %q, want %q", in, e.String(), want)
+		}
+	}
+}
+
+func TestCheckLicenses_Policy(t *testing.T) {
+	lf := Lockfile{Entries: []LockEntry{
+		{Name: "dual", Version: "1.0.0", License: "GPL-3.0-only OR MIT"},
+		{Name: "copyleft", Version: "1.0.0", License: "GPL-3.0-only"},
+		{Name: "both", Version: "1.0.0", License: "MIT AND Apache-2.0"},
+		{Name: "anon", Version: "1.0.0"},
+		{Name: "internal", Version: "1.0.0", License: "LicenseRef-Proprietary"},
+	}}
+
+	policy := LicensePolicy{Allow: []string{"MIT", "Apache-2.0", "GPL-3.0-only"}, Deny: []string{"GPL-3.0-only"}, Ignore: []PackageID{"internal"}}
+
+	got := CheckLicenses(lf, policy)
+	if len(got) != 2 || got[0].Name != "anon" || got[1].Name != "copyleft" {
+		t.Fatalf("unexpected findings: %+v", got)
+	}
+
+	policy.AllowUnknown = true
+	if got := CheckLicenses(lf, policy); len(got) != 1 || got[0].Name != "copyleft" {
+		t.Fatalf("unexpected findings with unknown allowed: %+v", got)
+	}
+}
+
+func TestGenerateSBOM_UsesCIDDigestsAndIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	reg := NewInMemoryRegistry()
+
+	for _, blob := range []PackageBlob{
+		{Manifest: PackageManifest{Name: "zlib", Version: "1.2.4", License: "Zlib"}, Data: []byte("zlib")},
+		{Manifest: PackageManifest{Name: "codec", Version: "2.1.0", License: "MIT OR Apache-2.0", Dependencies: []Dependency{{Name: "zlib", Constraint: "^1.0.0"}}}, Data: []byte("codec")},
+	} {
+		if _, err := reg.Publish(ctx, blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lf, _, err := GenerateLockfile(ctx, reg, Resolution{"zlib": "1.2.4", "codec": "2.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lf.Entries[0].License != "MIT OR Apache-2.0" {
+		t.Fatalf("lockfile should record licenses: %+v", lf.Entries)
+	}
+
+	subject := SBOMSubject{Name: "app", Version: "0.1.0", License: "MIT", Dependencies: []PackageID{"codec"}}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	spdx, err := GenerateSBOM(subject, lf, SBOMFormatSPDX, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc spdxDoc
+	if err := json.Unmarshal(spdx, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.Packages) != 3 || doc.Packages[2].Checksums[0].ChecksumValue != strings.TrimPrefix(string(lf.Entries[1].CID), "oz1-") {
+		t.Fatalf("unexpected SPDX packages: %+v", doc.Packages)
+	}
+
+	if len(doc.Relationships) != 3 || doc.Relationships[2].SPDXElementID != spdxID("codec", "2.1.0") {
+		t.Fatalf("unexpected SPDX relationships: %+v", doc.Relationships)
+	}
+
+	cdx, err := GenerateSBOM(subject, lf, SBOMFormatCycloneDX, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := GenerateSBOM(subject, lf, SBOMFormatCycloneDX, created)
+	if string(cdx) != string(again) {
+		t.Fatalf("SBOM output is not deterministic")
+	}
+
+	var bom cdxDoc
+	if err := json.Unmarshal(cdx, &bom); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bom.Components) != 2 || bom.Components[1].Hashes[0].Content != strings.TrimPrefix(string(lf.Entries[1].CID), "oz1-") || bom.Components[0].Licenses[0].Expression != "MIT OR Apache-2.0" {
+		t.Fatalf("unexpected CycloneDX components: %+v", bom.Components)
+	}
+
+	if _, err := GenerateSBOM(subject, lf, "xml", created); err == nil {
+		t.Fatalf("expected unknown format to fail")
+	}
+}
@@ -17,6 +17,7 @@ type LockEntry struct {
 	Version      Version      `json:"version"`
 	CID          CID          `json:"cid"`
 	SHA256       string       `json:"sha256"`
+	License      string       `json:"license,omitempty"`
 	Dependencies []Dependency `json:"dependencies,omitempty"`
 }
 
@@ -68,6 +69,7 @@ func GenerateLockfile(ctx context.Context, reg Registry, res Resolution) (Lockfi
 			Version:      ver,
 			CID:          cid,
 			SHA256:       hex.EncodeToString(sum[:]),
+			License:      blob.Manifest.License,
 			Dependencies: deps,
 		})
 	}
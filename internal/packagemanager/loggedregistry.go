@@ -0,0 +1,162 @@
+package packagemanager
+
+import (
+	"context"
+	"fmt"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// LoggedRegistry records every publish of the wrapped registry in a
+// transparency log and serves the log's heads and proofs.
+type LoggedRegistry struct {
+	reg Registry
+	log *TransparencyLog
+}
+
+// NewLoggedRegistry wraps reg so that publishes are appended to log.
+func NewLoggedRegistry(reg Registry, log *TransparencyLog) *LoggedRegistry {
+	return &LoggedRegistry{reg: reg, log: log}
+}
+
+// Log returns the underlying transparency log.
+func (r *LoggedRegistry) Log() *TransparencyLog { return r.log }
+
+// Publish publishes blob and appends it to the log.
+func (r *LoggedRegistry) Publish(ctx context.Context, blob PackageBlob) (CID, error) {
+	id, _, err := r.PublishWithReceipt(ctx, blob)
+
+	return id, err
+}
+
+// PublishWithReceipt publishes blob, appends it to the log and returns the
+// inclusion proof against a freshly signed tree head. A name@version that is
+// already logged with different content is rejected before publishing.
+func (r *LoggedRegistry) PublishWithReceipt(ctx context.Context, blob PackageBlob) (CID, *LogReceipt, error) {
+	entry := LogEntry{Name: blob.Manifest.Name, Version: blob.Manifest.Version, CID: ComputeCID(blob.Data)}
+	if prev, _, ok := r.log.Lookup(entry.Name, entry.Version); ok && prev.CID != entry.CID {
+		return "", nil, fmt.Errorf("publish %s@%s: already logged as %s", entry.Name, entry.Version, prev.CID)
+	}
+
+	id, err := r.reg.Publish(ctx, blob)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entry.CID = id
+	if _, err := r.log.Append(entry); err != nil {
+		return "", nil, err
+	}
+
+	head, err := r.log.Head()
+	if err != nil {
+		return "", nil, err
+	}
+
+	proof, err := r.log.InclusionProof(entry.Name, entry.Version, head.TreeSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, &LogReceipt{Inclusion: proof, Head: head}, nil
+}
+
+func (r *LoggedRegistry) Fetch(ctx context.Context, id CID) (PackageBlob, error) {
+	return r.reg.Fetch(ctx, id)
+}
+
+func (r *LoggedRegistry) Find(ctx context.Context, name PackageID, constraint *semver.Constraints) (CID, PackageManifest, error) {
+	return r.reg.Find(ctx, name, constraint)
+}
+
+func (r *LoggedRegistry) List(ctx context.Context, name PackageID) ([]PackageManifest, error) {
+	return r.reg.List(ctx, name)
+}
+
+func (r *LoggedRegistry) All(ctx context.Context) ([]PackageManifest, error) {
+	return r.reg.All(ctx)
+}
+
+// CIDs passes through to the wrapped registry; see CIDLister.
+func (r *LoggedRegistry) CIDs(ctx context.Context, name PackageID) (map[Version]CID, error) {
+	if l, ok := r.reg.(CIDLister); ok {
+		return l.CIDs(ctx, name)
+	}
+
+	return nil, nil
+}
+
+// Advisories passes through the wrapped registry's advisory database.
+func (r *LoggedRegistry) Advisories(ctx context.Context) (SignedAdvisoryDB, error) {
+	if p, ok := r.reg.(AdvisoryProvider); ok {
+		return p.Advisories(ctx)
+	}
+
+	return SignedAdvisoryDB{}, ErrNotFound
+}
+
+// TreeHead returns the current signed tree head.
+func (r *LoggedRegistry) TreeHead(ctx context.Context) (SignedTreeHead, error) {
+	return r.log.Head()
+}
+
+// InclusionProof proves the entry for name@version against the tree of the given size.
+func (r *LoggedRegistry) InclusionProof(ctx context.Context, name PackageID, version Version, treeSize uint64) (InclusionProof, error) {
+	return r.log.InclusionProof(name, version, treeSize)
+}
+
+// ConsistencyProof proves that the tree of size first is a prefix of the tree of size second.
+func (r *LoggedRegistry) ConsistencyProof(ctx context.Context, first, second uint64) (ConsistencyProof, error) {
+	return r.log.ConsistencyProof(first, second)
+}
+
+// Yank passes through to the wrapped registry. Yanking never changes the log:
+// the entry stays valid, it is only skipped by new resolutions.
+func (r *LoggedRegistry) Yank(ctx context.Context, name PackageID, version Version, yanked bool) error {
+	a, err := adminOf(r.reg)
+	if err != nil {
+		return err
+	}
+
+	return a.Yank(ctx, name, version, yanked)
+}
+
+// Deprecate passes through to the wrapped registry.
+func (r *LoggedRegistry) Deprecate(ctx context.Context, name PackageID, message string) error {
+	a, err := adminOf(r.reg)
+	if err != nil {
+		return err
+	}
+
+	return a.Deprecate(ctx, name, message)
+}
+
+// Owners passes through to the wrapped registry.
+func (r *LoggedRegistry) Owners(ctx context.Context, name PackageID) ([]string, error) {
+	a, err := adminOf(r.reg)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Owners(ctx, name)
+}
+
+// AddOwner passes through to the wrapped registry.
+func (r *LoggedRegistry) AddOwner(ctx context.Context, name PackageID, owner string) error {
+	a, err := adminOf(r.reg)
+	if err != nil {
+		return err
+	}
+
+	return a.AddOwner(ctx, name, owner)
+}
+
+// RemoveOwner passes through to the wrapped registry.
+func (r *LoggedRegistry) RemoveOwner(ctx context.Context, name PackageID, owner string) error {
+	a, err := adminOf(r.reg)
+	if err != nil {
+		return err
+	}
+
+	return a.RemoveOwner(ctx, name, owner)
+}
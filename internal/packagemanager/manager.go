@@ -2,11 +2,13 @@ package packagemanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
 	semver "github.com/Masterminds/semver/v3"
@@ -15,12 +17,25 @@ import (
 
 // Manager ties Resolver and Registry to resolve and fetch packages.
 type Manager struct {
-	registry Registry
+	registry    Registry
+	allowYanked map[PackageID]Version
+	features    FeatureSet
 }
 
 // NewManager constructs a Manager with the provided registry.
 func NewManager(reg Registry) *Manager { return &Manager{registry: reg} }
 
+// AllowYanked lets resolution select the given yanked versions, typically the
+// ones already recorded in a lockfile. Other yanked versions are ignored.
+func (m *Manager) AllowYanked(versions map[PackageID]Version) *Manager {
+	m.allowYanked = versions
+
+	return m
+}
+
+// Features returns the features unified by the last successful resolution.
+func (m *Manager) Features() FeatureSet { return m.features }
+
 // ResolveAndFetch resolves requirements against given index and returns CIDs for fetched packages.
 // It first resolves versions using Resolver on a synthetic index derived from registry manifests,.
 // then fetches blobs and returns a mapping of package -> (version, cid).
@@ -28,21 +43,66 @@ func (m *Manager) ResolveAndFetch(ctx context.Context, reqs []Requirement, prefe
 	Version Version
 	CID     CID
 }, error,
+) {
+	return m.resolveAndFetch(ctx, reqs, nil, preferHigher)
+}
+
+// ResolvePackage resolves the dependencies of the local package pkg with the
+// given features enabled on it. pkg is neither fetched nor part of the result.
+func (m *Manager) ResolvePackage(ctx context.Context, pkg PackageVersion, features []string, preferHigher bool) (map[PackageID]struct {
+	Version Version
+	CID     CID
+}, error,
+) {
+	reqs := []Requirement{{Name: pkg.Name, Constraint: "=" + string(pkg.Version), Features: features}}
+
+	return m.resolveAndFetch(ctx, reqs, PackageIndex{pkg.Name: {pkg}}, preferHigher)
+}
+
+// resolveAndFetch resolves reqs with the packages in local taking the place of
+// any registry versions of the same name. Local packages are neither fetched
+// nor returned.
+func (m *Manager) resolveAndFetch(ctx context.Context, reqs []Requirement, local PackageIndex, preferHigher bool) (map[PackageID]struct {
+	Version Version
+	CID     CID
+}, error,
 ) {
 	// Build a minimal index lazily by walking only the transitive closure of roots.
 	// This avoids expensive Registry.All() on remote registries.
 	idx := make(PackageIndex)
 	loaded := make(map[PackageID]bool)
+	skipped := make(map[PackageID][]Version)
 	// seed queue with roots.
 	queue := make([]PackageID, 0, len(reqs))
 
-	for _, r := range reqs {
-		if !loaded[r.Name] {
-			loaded[r.Name] = true
+	var enqueue func(name PackageID)
 
-			queue = append(queue, r.Name)
+	enqueue = func(name PackageID) {
+		if loaded[name] {
+			return
 		}
+
+		loaded[name] = true
+
+		if pvs, ok := local[name]; ok {
+			idx[name] = pvs
+			// local packages are never listed; walk their dependencies directly.
+			for _, pv := range pvs {
+				for _, d := range pv.Dependencies {
+					enqueue(d.Name)
+				}
+			}
+
+			return
+		}
+
+		queue = append(queue, name)
 	}
+
+	for _, r := range reqs {
+		enqueue(r.Name)
+	}
+
 	// parallel List(name) with bounded concurrency.
 	type listRes struct {
 		err  error
@@ -82,7 +142,13 @@ func (m *Manager) ResolveAndFetch(ctx context.Context, reqs []Requirement, prefe
 			}
 
 			for _, mf := range r.mans {
-				idx[mf.Name] = append(idx[mf.Name], PackageVersion(mf))
+				if mf.Yanked && m.allowYanked[mf.Name] != mf.Version {
+					skipped[mf.Name] = append(skipped[mf.Name], mf.Version)
+
+					continue
+				}
+
+				idx[mf.Name] = append(idx[mf.Name], PackageVersion{Name: mf.Name, Version: mf.Version, Dependencies: mf.Dependencies, Features: mf.Features})
 
 				for _, d := range mf.Dependencies {
 					if !loaded[d.Name] {
@@ -95,19 +161,24 @@ func (m *Manager) ResolveAndFetch(ctx context.Context, reqs []Requirement, prefe
 		}
 
 		for n := range next {
-			loaded[n] = true
-
-			queue = append(queue, n)
+			enqueue(n)
 		}
 	}
 
 	r := NewResolver(idx, ResolveOptions{PreferHigher: preferHigher})
 
-	res, err := r.Resolve(reqs)
+	res, features, err := r.ResolveFeatures(reqs)
 	if err != nil {
+		var ce *ConflictError
+		if errors.As(err, &ce) && len(skipped) > 0 {
+			ce.Explanation = strings.TrimSpace(ce.Explanation + "\n" + yankedNote(skipped))
+		}
+
 		return nil, err
 	}
 
+	m.features = features
+
 	out := make(map[PackageID]struct {
 		Version Version
 		CID     CID
@@ -122,6 +193,10 @@ func (m *Manager) ResolveAndFetch(ctx context.Context, reqs []Requirement, prefe
 	sem := make(chan struct{}, limit)
 
 	for name, ver := range res {
+		if _, ok := local[name]; ok {
+			continue
+		}
+
 		name := name
 		ver := ver
 
@@ -141,7 +216,12 @@ func (m *Manager) ResolveAndFetch(ctx context.Context, reqs []Requirement, prefe
 				return fmt.Errorf("resolve ok but fetch missing %s@%s: %w", name, ver, err)
 			}
 
-			if _, err := m.registry.Fetch(gctx, cid); err != nil {
+			blob, err := m.registry.Fetch(gctx, cid)
+			if err != nil {
+				return err
+			}
+
+			if err := VerifyBlob(cid, blob); err != nil {
 				return err
 			}
 
@@ -163,6 +243,28 @@ func (m *Manager) ResolveAndFetch(ctx context.Context, reqs []Requirement, prefe
 	return out, nil
 }
 
+// yankedNote lists the yanked versions that resolution ignored.
+func yankedNote(skipped map[PackageID][]Version) string {
+	names := make([]PackageID, 0, len(skipped))
+	for name := range skipped {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		vs := make([]string, 0, len(skipped[name]))
+		for _, v := range skipped[name] {
+			vs = append(vs, string(v))
+		}
+
+		lines = append(lines, fmt.Sprintf("Yanked versions of %s were not considered: %s.", name, strings.Join(vs, ", ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // ioConcurrency returns the concurrency for I/O bound tasks.
 // It reads ORIZON_MAX_CONCURRENCY if set, otherwise uses GOMAXPROCS*8.
 func ioConcurrency() int {
@@ -0,0 +1,666 @@
+package packagemanager
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// PubGrub version solving.
+//
+// The solver follows the algorithm described in Natalie Weizenbaum's PubGrub
+// write-up: unit propagation over incompatibilities, decisions on the most
+// constrained package, and conflict-driven clause learning that backjumps to
+// the decision responsible for a conflict. Because every index is finite, a
+// term is represented exactly as the set of outcomes it allows: one bit per
+// published version plus one bit for "package not selected". Negation,
+// intersection and subset tests are then plain bit operations.
+
+// rootPackage is the synthetic package whose single version depends on the
+// resolution requirements.
+const rootPackage PackageID = "$root"
+
+// outcomes is a bitset over a package's versions; the last bit stands for
+// the package not being selected at all.
+type outcomes struct {
+	w []uint64
+	n int
+}
+
+func newOutcomes(n int) outcomes { return outcomes{w: make([]uint64, (n+63)/64), n: n} }
+
+func (o outcomes) has(i int) bool { return o.w[i/64]&(1<<(uint(i)%64)) != 0 }
+
+func (o *outcomes) set(i int) { o.w[i/64] |= 1 << (uint(i) % 64) }
+
+func (o outcomes) and(p outcomes) outcomes {
+	r := newOutcomes(o.n)
+	for i := range r.w {
+		r.w[i] = o.w[i] & p.w[i]
+	}
+
+	return r
+}
+
+func (o outcomes) not() outcomes {
+	r := newOutcomes(o.n)
+	for i := range r.w {
+		r.w[i] = ^o.w[i]
+	}
+
+	if rem := o.n % 64; rem != 0 {
+		r.w[len(r.w)-1] &= (1 << uint(rem)) - 1
+	}
+
+	return r
+}
+
+func (o outcomes) empty() bool {
+	for _, x := range o.w {
+		if x != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o outcomes) full() bool { return o.not().empty() }
+
+func (o outcomes) subsetOf(p outcomes) bool {
+	for i := range o.w {
+		if o.w[i]&^p.w[i] != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (o outcomes) count() int {
+	c := 0
+	for _, x := range o.w {
+		c += bits.OnesCount64(x)
+	}
+
+	return c
+}
+
+func (o outcomes) key() string { return fmt.Sprint(o.w) }
+
+// universe holds every published version of one package, ascending.
+type universe struct {
+	labels   map[string]string
+	name     PackageID
+	versions []*semver.Version
+	entries  []PackageVersion
+}
+
+// none is the index of the "not selected" outcome.
+func (u *universe) none() int { return len(u.versions) }
+
+func (u *universe) any() outcomes { return newOutcomes(len(u.versions) + 1).not() }
+
+// allowing returns the positive term set of versions matching constraint.
+func (u *universe) allowing(constraint string) outcomes {
+	o := newOutcomes(len(u.versions) + 1)
+
+	c, err := parseConstraint(constraint)
+	if err == nil {
+		for i, v := range u.versions {
+			if c.Check(v) {
+				o.set(i)
+			}
+		}
+	}
+
+	if strings.TrimSpace(constraint) != "" {
+		u.labels[o.key()] = strings.TrimSpace(constraint)
+	}
+
+	return o
+}
+
+// term asserts that a package's outcome lies in set.
+type term struct {
+	pkg PackageID
+	set outcomes
+}
+
+type incompatKind int
+
+const (
+	kindRoot incompatKind = iota
+	kindDependency
+	kindDerived
+)
+
+// incompatibility is a set of terms that must not all hold at once.
+type incompatibility struct {
+	left, right *incompatibility
+	// dependency details for kindDependency.
+	depender   PackageID
+	dependency Dependency
+	terms      []term
+	kind       incompatKind
+}
+
+type assignment struct {
+	cause    *incompatibility
+	term     term
+	level    int
+	decision bool
+}
+
+type solver struct {
+	unis        map[PackageID]*universe
+	incompats   map[PackageID][]*incompatibility
+	acc         map[PackageID]outcomes
+	decided     map[PackageID]int
+	depsAdded   map[string]bool
+	assignments []assignment
+	level       int
+	preferHigh  bool
+}
+
+func newSolver(index PackageIndex, root []Dependency, preferHigher bool) (*solver, error) {
+	s := &solver{
+		unis:       make(map[PackageID]*universe, len(index)+1),
+		incompats:  make(map[PackageID][]*incompatibility),
+		acc:        make(map[PackageID]outcomes),
+		decided:    make(map[PackageID]int),
+		depsAdded:  make(map[string]bool),
+		preferHigh: preferHigher,
+	}
+
+	for name, list := range index {
+		u := &universe{name: name, labels: make(map[string]string)}
+
+		for _, pv := range list {
+			sv, err := semver.NewVersion(string(pv.Version))
+			if err != nil {
+				return nil, fmt.Errorf("%s@%s: invalid version: %w", name, pv.Version, err)
+			}
+
+			u.versions = append(u.versions, sv)
+			u.entries = append(u.entries, pv)
+		}
+
+		sort.Sort(byVersion{u})
+		s.unis[name] = u
+	}
+
+	rootVersion := semver.MustParse("0.0.0")
+	s.unis[rootPackage] = &universe{
+		name:     rootPackage,
+		labels:   make(map[string]string),
+		versions: []*semver.Version{rootVersion},
+		entries:  []PackageVersion{{Name: rootPackage, Version: "0.0.0", Dependencies: root}},
+	}
+
+	return s, nil
+}
+
+type byVersion struct{ u *universe }
+
+func (b byVersion) Len() int           { return len(b.u.versions) }
+func (b byVersion) Less(i, j int) bool { return b.u.versions[i].LessThan(b.u.versions[j]) }
+func (b byVersion) Swap(i, j int) {
+	b.u.versions[i], b.u.versions[j] = b.u.versions[j], b.u.versions[i]
+	b.u.entries[i], b.u.entries[j] = b.u.entries[j], b.u.entries[i]
+}
+
+func (s *solver) uni(pkg PackageID) *universe {
+	u, ok := s.unis[pkg]
+	if !ok {
+		u = &universe{name: pkg, labels: make(map[string]string)}
+		s.unis[pkg] = u
+	}
+
+	return u
+}
+
+// newIncompatibility normalizes terms: terms on the same package are
+// intersected and terms that always hold are dropped.
+func (s *solver) newIncompatibility(kind incompatKind, terms []term) *incompatibility {
+	byPkg := make(map[PackageID]outcomes, len(terms))
+	order := make([]PackageID, 0, len(terms))
+
+	for _, t := range terms {
+		if prev, ok := byPkg[t.pkg]; ok {
+			byPkg[t.pkg] = prev.and(t.set)
+		} else {
+			byPkg[t.pkg] = t.set
+			order = append(order, t.pkg)
+		}
+	}
+
+	inc := &incompatibility{kind: kind}
+
+	for _, p := range order {
+		if !byPkg[p].full() {
+			inc.terms = append(inc.terms, term{pkg: p, set: byPkg[p]})
+		}
+	}
+
+	return inc
+}
+
+func (s *solver) addIncompatibility(inc *incompatibility) {
+	for _, t := range inc.terms {
+		s.incompats[t.pkg] = append(s.incompats[t.pkg], inc)
+	}
+}
+
+func (s *solver) accOf(pkg PackageID) outcomes {
+	if o, ok := s.acc[pkg]; ok {
+		return o
+	}
+
+	return s.uni(pkg).any()
+}
+
+func (s *solver) assign(t term, cause *incompatibility, decision bool) {
+	s.assignments = append(s.assignments, assignment{term: t, level: s.level, cause: cause, decision: decision})
+	s.acc[t.pkg] = s.accOf(t.pkg).and(t.set)
+}
+
+func (s *solver) decide(pkg PackageID, idx int) {
+	s.level++
+	o := newOutcomes(len(s.uni(pkg).versions) + 1)
+	o.set(idx)
+	s.assign(term{pkg: pkg, set: o}, nil, true)
+	s.decided[pkg] = idx
+}
+
+func (s *solver) backtrack(level int) {
+	kept := s.assignments[:0]
+
+	for _, a := range s.assignments {
+		if a.level <= level {
+			kept = append(kept, a)
+		}
+	}
+
+	s.assignments = kept
+	s.level = level
+	s.acc = make(map[PackageID]outcomes)
+	s.decided = make(map[PackageID]int)
+
+	for _, a := range s.assignments {
+		s.acc[a.term.pkg] = s.accOf(a.term.pkg).and(a.term.set)
+
+		if a.decision {
+			for i := 0; i < a.term.set.n-1; i++ {
+				if a.term.set.has(i) {
+					s.decided[a.term.pkg] = i
+				}
+			}
+		}
+	}
+}
+
+// satisfier returns the index of the earliest assignment after which the
+// partial solution satisfies t. Conflict resolution only asks for terms the
+// solution satisfies, so an error means the solver state is inconsistent.
+func (s *solver) satisfier(t term) (int, error) {
+	acc := s.uni(t.pkg).any()
+
+	for i, a := range s.assignments {
+		if a.term.pkg != t.pkg {
+			continue
+		}
+
+		acc = acc.and(a.term.set)
+		if acc.subsetOf(t.set) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("pubgrub: internal error: no satisfier for %s", t.pkg)
+}
+
+type relation int
+
+const (
+	relSatisfied relation = iota
+	relContradicted
+	relInconclusive
+	relAlmost
+)
+
+// relate classifies inc against the partial solution. For relAlmost the
+// returned term is the only one not yet satisfied.
+func (s *solver) relate(inc *incompatibility) (relation, *term) {
+	var unsatisfied *term
+
+	for i := range inc.terms {
+		t := &inc.terms[i]
+		acc := s.accOf(t.pkg)
+
+		switch {
+		case acc.subsetOf(t.set):
+		case acc.and(t.set).empty():
+			return relContradicted, nil
+		default:
+			if unsatisfied != nil {
+				return relInconclusive, nil
+			}
+
+			unsatisfied = t
+		}
+	}
+
+	if unsatisfied == nil {
+		return relSatisfied, nil
+	}
+
+	return relAlmost, unsatisfied
+}
+
+func (s *solver) isFailure(inc *incompatibility) bool {
+	if len(inc.terms) == 0 {
+		return true
+	}
+
+	// "root is selected" being incompatible means nothing can be selected.
+	return len(inc.terms) == 1 && inc.terms[0].pkg == rootPackage && !inc.terms[0].set.has(s.uni(rootPackage).none())
+}
+
+// propagate runs unit propagation starting from pkg.
+func (s *solver) propagate(pkg PackageID) (*incompatibility, error) {
+	changed := []PackageID{pkg}
+
+	for len(changed) > 0 {
+		p := changed[len(changed)-1]
+		changed = changed[:len(changed)-1]
+
+		list := s.incompats[p]
+		for i := len(list) - 1; i >= 0; i-- {
+			inc := list[i]
+
+			rel, t := s.relate(inc)
+			if rel == relSatisfied {
+				root, failed, err := s.resolveConflict(inc)
+				if err != nil {
+					return nil, err
+				}
+
+				if failed {
+					return root, nil
+				}
+
+				// The learned incompatibility is almost satisfied after backjumping.
+				if rel2, t2 := s.relate(root); rel2 == relAlmost {
+					s.assign(term{pkg: t2.pkg, set: t2.set.not()}, root, false)
+					changed = []PackageID{t2.pkg}
+				}
+
+				break
+			}
+
+			if rel == relAlmost {
+				s.assign(term{pkg: t.pkg, set: t.set.not()}, inc, false)
+				changed = append(changed, t.pkg)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// resolveConflict derives the root cause of a satisfied incompatibility and
+// backjumps. It reports failed when the conflict cannot be resolved.
+func (s *solver) resolveConflict(inc *incompatibility) (*incompatibility, bool, error) {
+	learned := false
+
+	for {
+		if s.isFailure(inc) {
+			return inc, true, nil
+		}
+
+		var (
+			recentTerm  *term
+			recentIdx   = -1
+			difference  *term
+			prevLevel   = 1
+			satisfierAt = make([]int, len(inc.terms))
+		)
+
+		for i := range inc.terms {
+			idx, err := s.satisfier(inc.terms[i])
+			if err != nil {
+				return nil, false, err
+			}
+
+			satisfierAt[i] = idx
+		}
+
+		for i := range inc.terms {
+			t := &inc.terms[i]
+			idx := satisfierAt[i]
+
+			if recentIdx < idx {
+				if recentIdx >= 0 {
+					prevLevel = maxInt(prevLevel, s.assignments[recentIdx].level)
+				}
+
+				recentTerm, recentIdx, difference = t, idx, nil
+			} else {
+				prevLevel = maxInt(prevLevel, s.assignments[idx].level)
+			}
+
+			if recentTerm == t {
+				diff := s.assignments[recentIdx].term.set.and(recentTerm.set.not())
+				if !diff.empty() {
+					difference = &term{pkg: t.pkg, set: diff}
+
+					prev, err := s.satisfier(term{pkg: t.pkg, set: diff.not()})
+					if err != nil {
+						return nil, false, err
+					}
+
+					prevLevel = maxInt(prevLevel, s.assignments[prev].level)
+				}
+			}
+		}
+
+		satisfier := s.assignments[recentIdx]
+		if prevLevel < satisfier.level || satisfier.decision {
+			s.backtrack(prevLevel)
+
+			if learned {
+				s.addIncompatibility(inc)
+			}
+
+			return inc, false, nil
+		}
+
+		terms := make([]term, 0, len(inc.terms)+len(satisfier.cause.terms))
+
+		for _, t := range inc.terms {
+			if t.pkg != recentTerm.pkg {
+				terms = append(terms, t)
+			}
+		}
+
+		for _, t := range satisfier.cause.terms {
+			if t.pkg != satisfier.term.pkg {
+				terms = append(terms, t)
+			}
+		}
+
+		if difference != nil {
+			terms = append(terms, term{pkg: difference.pkg, set: difference.set.not()})
+		}
+
+		next := s.newIncompatibility(kindDerived, terms)
+		next.left, next.right = inc, satisfier.cause
+		inc = next
+		learned = true
+	}
+}
+
+// choose picks the next package to decide, or reports done.
+func (s *solver) choose() (PackageID, bool) {
+	var (
+		best      PackageID
+		bestCount = -1
+	)
+
+	for pkg, acc := range s.acc {
+		if _, ok := s.decided[pkg]; ok || acc.has(s.uni(pkg).none()) {
+			continue
+		}
+
+		c := acc.count()
+		if bestCount < 0 || c < bestCount || (c == bestCount && pkg < best) {
+			best, bestCount = pkg, c
+		}
+	}
+
+	if bestCount < 0 {
+		return "", true
+	}
+
+	u := s.uni(best)
+	acc := s.acc[best]
+
+	idx := -1
+
+	for i := range u.versions {
+		j := i
+		if s.preferHigh {
+			j = len(u.versions) - 1 - i
+		}
+
+		if acc.has(j) {
+			idx = j
+
+			break
+		}
+	}
+
+	// Register the chosen version's dependencies; if one of them already
+	// conflicts, let propagation pick another version instead of deciding.
+	conflict := false
+
+	for _, inc := range s.dependencyIncompatibilities(best, idx) {
+		s.addIncompatibility(inc)
+
+		if !conflict {
+			conflict = s.otherTermsSatisfied(inc, best)
+		}
+	}
+
+	if !conflict {
+		s.decide(best, idx)
+	}
+
+	return best, false
+}
+
+// otherTermsSatisfied reports whether every term of inc except the one on
+// pkg already holds.
+func (s *solver) otherTermsSatisfied(inc *incompatibility, pkg PackageID) bool {
+	for _, t := range inc.terms {
+		if t.pkg == pkg {
+			continue
+		}
+
+		if !s.accOf(t.pkg).subsetOf(t.set) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dependencyIncompatibilities returns "pkg in S depends on dep" clauses for
+// the dependencies of version idx, where S covers every version of pkg that
+// declares the same constraint. Clauses are created once per constraint.
+func (s *solver) dependencyIncompatibilities(pkg PackageID, idx int) []*incompatibility {
+	u := s.uni(pkg)
+
+	var out []*incompatibility
+
+	for _, d := range u.entries[idx].Dependencies {
+		key := string(pkg) + "\x00" + string(d.Name) + "\x00" + d.Constraint
+		if s.depsAdded[key] {
+			continue
+		}
+
+		s.depsAdded[key] = true
+
+		self := newOutcomes(len(u.versions) + 1)
+
+		for i, e := range u.entries {
+			for _, ed := range e.Dependencies {
+				if ed.Name == d.Name && ed.Constraint == d.Constraint {
+					self.set(i)
+
+					break
+				}
+			}
+		}
+
+		depSet := s.uni(d.Name).allowing(d.Constraint)
+		inc := s.newIncompatibility(kindDependency, []term{
+			{pkg: pkg, set: self},
+			{pkg: d.Name, set: depSet.not()},
+		})
+		inc.depender, inc.dependency = pkg, d
+		out = append(out, inc)
+	}
+
+	return out
+}
+
+// solve runs PubGrub to completion. It returns the root incompatibility when
+// there is no solution, and an error only for an internal solver failure.
+func (s *solver) solve() (Resolution, *incompatibility, error) {
+	rootTerm := newOutcomes(2)
+	rootTerm.set(1) // root not selected
+	s.addIncompatibility(&incompatibility{kind: kindRoot, terms: []term{{pkg: rootPackage, set: rootTerm}}})
+
+	next := rootPackage
+
+	for {
+		failure, err := s.propagate(next)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if failure != nil {
+			return nil, failure, nil
+		}
+
+		pkg, done := s.choose()
+		if done {
+			break
+		}
+
+		next = pkg
+	}
+
+	res := make(Resolution, len(s.decided))
+
+	for pkg, idx := range s.decided {
+		if pkg != rootPackage {
+			res[pkg] = s.uni(pkg).entries[idx].Version
+		}
+	}
+
+	return res, nil, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,255 @@
+package packagemanager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// explainer renders the derivation tree of a failed resolution as numbered
+// English sentences, following the reporting scheme of PubGrub: derived
+// incompatibilities referenced more than once get a line number so later
+// sentences can refer back to them instead of repeating the derivation.
+type explainer struct {
+	s       *solver
+	refs    map[*incompatibility]int
+	numbers map[*incompatibility]int
+	lines   []string
+	next    int
+}
+
+// explain returns a multi-line explanation of why failure holds.
+func (s *solver) explain(failure *incompatibility) string {
+	e := &explainer{s: s, refs: make(map[*incompatibility]int), numbers: make(map[*incompatibility]int)}
+	e.count(failure)
+
+	if failure.kind != kindDerived {
+		return "Because " + e.describe(failure) + ", version solving failed."
+	}
+
+	e.visit(failure, false)
+
+	return strings.Join(e.lines, "\n")
+}
+
+func (e *explainer) count(inc *incompatibility) {
+	e.refs[inc]++
+	if e.refs[inc] > 1 || inc.kind != kindDerived {
+		return
+	}
+
+	e.count(inc.left)
+	e.count(inc.right)
+}
+
+func (e *explainer) write(inc *incompatibility, line string, numbered bool) {
+	if numbered {
+		e.next++
+		e.numbers[inc] = e.next
+		line = fmt.Sprintf("%s (%d)", line, e.next)
+	}
+
+	e.lines = append(e.lines, line)
+}
+
+func (e *explainer) ref(inc *incompatibility) string {
+	return fmt.Sprintf("%s (%d)", e.describe(inc), e.numbers[inc])
+}
+
+// visit writes the derivation of inc; numbered forces a line number so the
+// caller can refer to the conclusion.
+func (e *explainer) visit(inc *incompatibility, numbered bool) {
+	numbered = numbered || e.refs[inc] > 1
+	left, right := inc.left, inc.right
+	conclusion := e.describe(inc)
+
+	_, leftDone := e.numbers[left]
+	_, rightDone := e.numbers[right]
+
+	switch {
+	case left.kind == kindDerived && right.kind == kindDerived:
+		switch {
+		case leftDone && rightDone:
+			e.write(inc, fmt.Sprintf("Because %s and %s, %s.", e.ref(left), e.ref(right), conclusion), numbered)
+		case leftDone || rightDone:
+			done, todo := left, right
+			if rightDone {
+				done, todo = right, left
+			}
+
+			e.visit(todo, false)
+			e.write(inc, fmt.Sprintf("And because %s, %s.", e.ref(done), conclusion), numbered)
+		default:
+			e.visit(left, true)
+			e.visit(right, false)
+			e.write(inc, fmt.Sprintf("Thus, %s.", conclusion), numbered)
+		}
+	case left.kind == kindDerived || right.kind == kindDerived:
+		derived, external := left, right
+		if right.kind == kindDerived {
+			derived, external = right, left
+		}
+
+		if _, ok := e.numbers[derived]; ok {
+			e.write(inc, fmt.Sprintf("Because %s and %s, %s.", e.describe(external), e.ref(derived), conclusion), numbered)
+		} else {
+			e.visit(derived, false)
+			e.write(inc, fmt.Sprintf("And because %s, %s.", e.describe(external), conclusion), numbered)
+		}
+	default:
+		e.write(inc, fmt.Sprintf("Because %s and %s, %s.", e.describe(left), e.describe(right), conclusion), numbered)
+	}
+}
+
+// describe renders an incompatibility as a clause.
+func (e *explainer) describe(inc *incompatibility) string {
+	switch inc.kind {
+	case kindRoot:
+		return "the project is required"
+	case kindDependency:
+		dep := inc.dependency
+		target := e.packageText(dep.Name, e.s.uni(dep.Name).allowing(dep.Constraint))
+		suffix := ""
+
+		switch {
+		case len(e.s.uni(dep.Name).versions) == 0:
+			suffix = " which doesn't exist"
+		case e.s.uni(dep.Name).allowing(dep.Constraint).empty():
+			suffix = " which doesn't match any versions"
+		}
+
+		return fmt.Sprintf("%s depends on %s%s", e.dependerText(inc), target, suffix)
+	}
+
+	// The root term only says "the project is selected"; it is implied.
+	var pos, neg []term
+
+	for _, t := range inc.terms {
+		switch {
+		case t.pkg == rootPackage:
+		case t.set.has(e.s.uni(t.pkg).none()):
+			neg = append(neg, t)
+		default:
+			pos = append(pos, t)
+		}
+	}
+
+	switch {
+	case len(pos)+len(neg) == 0:
+		return "version solving failed"
+	case len(pos) == 1 && len(neg) == 0:
+		return e.termText(pos[0]) + " is forbidden"
+	case len(neg) == 1 && len(pos) == 0:
+		return e.termText(neg[0]) + " is required"
+	case len(pos) == 1 && len(neg) == 1:
+		return fmt.Sprintf("%s requires %s", e.termText(pos[0]), e.termText(neg[0]))
+	case len(neg) == 0:
+		parts := make([]string, len(pos))
+		for i, t := range pos {
+			parts[i] = e.termText(t)
+		}
+
+		return strings.Join(parts, " is incompatible with ")
+	default:
+		parts := make([]string, len(pos))
+		for i, t := range pos {
+			parts[i] = e.termText(t)
+		}
+
+		req := make([]string, len(neg))
+		for i, t := range neg {
+			req[i] = e.termText(t)
+		}
+
+		return fmt.Sprintf("if %s then %s", strings.Join(parts, " and "), strings.Join(req, " or "))
+	}
+}
+
+func (e *explainer) dependerText(inc *incompatibility) string {
+	if inc.depender == rootPackage {
+		return "the project"
+	}
+
+	for _, t := range inc.terms {
+		if t.pkg == inc.depender {
+			return e.packageText(t.pkg, t.set)
+		}
+	}
+
+	return string(inc.depender)
+}
+
+// termText renders the versions a term is about: the allowed versions of a
+// positive term, the excluded versions of a negative one.
+func (e *explainer) termText(t term) string {
+	set := t.set
+	if set.has(e.s.uni(t.pkg).none()) {
+		set = set.not()
+	}
+
+	return e.packageText(t.pkg, set)
+}
+
+// packageText renders "name range" for a positive set of versions.
+func (e *explainer) packageText(pkg PackageID, set outcomes) string {
+	if pkg == rootPackage {
+		return "the project"
+	}
+
+	return string(pkg) + " " + e.rangeText(pkg, set)
+}
+
+// rangeText prefers the constraint text the set came from; otherwise it
+// describes the set as runs of consecutive published versions.
+func (e *explainer) rangeText(pkg PackageID, set outcomes) string {
+	u := e.s.uni(pkg)
+
+	// Drop the "not selected" outcome; only versions are described.
+	positive := set.and(u.any())
+	positive.w[u.none()/64] &^= 1 << (uint(u.none()) % 64)
+
+	if label, ok := u.labels[positive.key()]; ok {
+		return label
+	}
+
+	var runs []string
+
+	all := true
+
+	for i := 0; i < len(u.versions); i++ {
+		if !positive.has(i) {
+			all = false
+
+			continue
+		}
+
+		j := i
+		for j+1 < len(u.versions) && positive.has(j+1) {
+			j++
+		}
+
+		switch {
+		case i == j:
+			runs = append(runs, u.versions[i].Original())
+		case i == 0 && j == len(u.versions)-1:
+			runs = append(runs, "any")
+		case i == 0:
+			runs = append(runs, "<"+u.versions[j+1].Original())
+		case j == len(u.versions)-1:
+			runs = append(runs, ">="+u.versions[i].Original())
+		default:
+			runs = append(runs, fmt.Sprintf(">=%s, <%s", u.versions[i].Original(), u.versions[j+1].Original()))
+		}
+
+		i = j
+	}
+
+	if all && len(u.versions) > 0 {
+		return "any"
+	}
+
+	if len(runs) == 0 {
+		return "(no versions)"
+	}
+
+	return strings.Join(runs, " || ")
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"sort"
 	"sync"
 
@@ -26,6 +27,17 @@ type PackageManifest struct {
 	Name         PackageID
 	Version      Version
 	Dependencies []Dependency
+	// Files lists per-file digests for blobs in the package archive format;
+	// it is empty for opaque blobs.
+	Files []FileDigest `json:",omitempty"`
+	// License is the SPDX license expression declared by the publisher.
+	License string `json:",omitempty"`
+	// Features maps feature names to what they enable; see ValidateFeatures.
+	Features map[string][]string `json:",omitempty"`
+	// Yanked and Deprecated are registry metadata reported by List, Find and
+	// All; they are not part of the published content.
+	Yanked     bool   `json:",omitempty"`
+	Deprecated string `json:",omitempty"`
 }
 
 // PackageBlob bundles the manifest with an opaque payload (e.g., tarball bytes).
@@ -113,12 +125,18 @@ func (r *InMemoryRegistry) Publish(ctx context.Context, blob PackageBlob) (CID,
 		return "", errors.New("empty data")
 	}
 
+	if len(blob.Manifest.Files) > 0 {
+		if err := VerifyArchive(blob.Data, blob.Manifest.Files); err != nil {
+			return "", fmt.Errorf("publish %s@%s: %w", blob.Manifest.Name, blob.Manifest.Version, err)
+		}
+	}
+
 	id := ComputeCID(blob.Data)
 
 	r.mu.Lock()
 	if _, exists := r.blobs[id]; !exists {
 		r.blobs[id] = blob
-		pv := PackageVersion{Name: blob.Manifest.Name, Version: blob.Manifest.Version, Dependencies: blob.Manifest.Dependencies}
+		pv := PackageVersion{Name: blob.Manifest.Name, Version: blob.Manifest.Version, Dependencies: blob.Manifest.Dependencies, Features: blob.Manifest.Features}
 		r.index[blob.Manifest.Name] = append(r.index[blob.Manifest.Name], pv)
 		sort.Sort(versionList(r.index[blob.Manifest.Name]))
 	}
@@ -143,7 +161,7 @@ func (r *InMemoryRegistry) replicate(id CID, blob PackageBlob) {
 	r.mu.Lock()
 	if _, exists := r.blobs[id]; !exists {
 		r.blobs[id] = blob
-		pv := PackageVersion{Name: blob.Manifest.Name, Version: blob.Manifest.Version, Dependencies: blob.Manifest.Dependencies}
+		pv := PackageVersion{Name: blob.Manifest.Name, Version: blob.Manifest.Version, Dependencies: blob.Manifest.Dependencies, Features: blob.Manifest.Features}
 		r.index[blob.Manifest.Name] = append(r.index[blob.Manifest.Name], pv)
 		sort.Sort(versionList(r.index[blob.Manifest.Name]))
 	}
@@ -277,7 +295,7 @@ func (r *InMemoryRegistry) List(ctx context.Context, name PackageID) ([]PackageM
 	appendFrom := func(list []PackageVersion, sourceBlobs map[CID]PackageBlob) {
 		for _, pv := range list {
 			// Try to reconstruct manifest directly.
-			out = append(out, PackageManifest(pv))
+			out = append(out, pv.manifest())
 		}
 	}
 	appendFrom(local, blobs)
@@ -290,7 +308,7 @@ func (r *InMemoryRegistry) List(ctx context.Context, name PackageID) ([]PackageM
 		}
 		p.mu.RLock()
 		for _, pv := range p.index[name] {
-			out = append(out, PackageManifest(pv))
+			out = append(out, pv.manifest())
 		}
 		p.mu.RUnlock()
 	}
@@ -321,6 +339,33 @@ func (r *InMemoryRegistry) List(ctx context.Context, name PackageID) ([]PackageM
 	return uniq, nil
 }
 
+// CIDs returns the CID of every version of name across local and peer
+// registries; see CIDLister.
+func (r *InMemoryRegistry) CIDs(ctx context.Context, name PackageID) (map[Version]CID, error) {
+	r.mu.RLock()
+	regs := append([]*InMemoryRegistry{r}, r.peers...)
+	r.mu.RUnlock()
+
+	out := make(map[Version]CID)
+
+	for _, p := range regs {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		p.mu.RLock()
+		for cid, blob := range p.blobs {
+			if _, ok := out[blob.Manifest.Version]; blob.Manifest.Name == name && !ok {
+				out[blob.Manifest.Version] = cid
+			}
+		}
+		p.mu.RUnlock()
+	}
+
+	return out, nil
+}
+
 // All returns every manifest across local and peer registries.
 func (r *InMemoryRegistry) All(ctx context.Context) ([]PackageManifest, error) {
 	r.mu.RLock()
@@ -18,6 +18,9 @@ type Version string
 type Dependency struct {
 	Name       PackageID
 	Constraint string // SemVer constraint (e.g., ">=1.2.0, <2.0.0")
+	// Optional dependencies are only resolved when an enabled feature
+	// pulls them in.
+	Optional bool `json:",omitempty"`
 }
 
 // PackageVersion represents one concrete version of a package and its deps.
@@ -25,6 +28,12 @@ type PackageVersion struct {
 	Name         PackageID
 	Version      Version
 	Dependencies []Dependency
+	Features     map[string][]string
+}
+
+// manifest returns the index entry as a manifest (without file digests).
+func (pv PackageVersion) manifest() PackageManifest {
+	return PackageManifest{Name: pv.Name, Version: pv.Version, Dependencies: pv.Dependencies, Features: pv.Features}
 }
 
 // PackageIndex lists all published versions per package.
@@ -34,6 +43,8 @@ type PackageIndex map[PackageID][]PackageVersion
 type Requirement struct {
 	Name       PackageID
 	Constraint string
+	// Features are enabled on the package in addition to its default feature.
+	Features []string
 }
 
 // Resolution is the final mapping of package -> pinned version.
@@ -43,7 +54,8 @@ type Resolution map[PackageID]Version
 type ResolveOptions struct {
 	// PreferHigher, when true, picks highest versions that satisfy constraints; otherwise lowest.
 	PreferHigher bool
-	// MaxDepth guards against infinite recursion; 0 means unlimited.
+	// MaxDepth limits how far below the requirements a resolved package may
+	// sit; 0 means unlimited.
 	MaxDepth int
 }
 
@@ -51,9 +63,16 @@ type ResolveOptions struct {
 type ConflictError struct {
 	Package PackageID
 	Reason  string
+	// Explanation derives the conflict from the dependency declarations,
+	// one sentence per line, when the solver produced one.
+	Explanation string
 }
 
 func (e *ConflictError) Error() string {
+	if e.Explanation != "" {
+		return fmt.Sprintf("resolution conflict for %s: %s\n%s", e.Package, e.Reason, e.Explanation)
+	}
+
 	return fmt.Sprintf("resolution conflict for %s: %s", e.Package, e.Reason)
 }
 
@@ -71,7 +90,10 @@ func (e *CycleError) Error() string {
 	return fmt.Sprintf("dependency cycle detected: %s", strings.Join(parts, " -> "))
 }
 
-// Resolver performs version constraint resolution with backtracking.
+// Resolver performs version constraint resolution using the PubGrub
+// algorithm. It finds a solution whenever one exists in the index, and on
+// failure returns a *ConflictError whose Explanation derives the conflict
+// step by step.
 type Resolver struct {
 	index PackageIndex
 	opts  ResolveOptions
@@ -84,144 +106,200 @@ func NewResolver(index PackageIndex, opts ResolveOptions) *Resolver {
 
 // Resolve computes a version assignment satisfying all requirements and dependencies.
 func (r *Resolver) Resolve(reqs []Requirement) (Resolution, error) {
-	// Normalize requirements by package.
-	// We merge multiple constraints for the same package via intersection.
-	merged := make(map[PackageID]*semver.Constraints)
+	res, _, err := r.ResolveFeatures(reqs)
+
+	return res, err
+}
+
+// ResolveFeatures resolves like Resolve and also unifies features across the
+// graph. Optional dependencies only take part in resolution once an enabled
+// feature pulls them in, so resolution is repeated until the set of enabled
+// optional dependencies stops growing.
+func (r *Resolver) ResolveFeatures(reqs []Requirement) (Resolution, FeatureSet, error) {
+	root := make([]Dependency, 0, len(reqs))
 
 	for _, q := range reqs {
-		c, err := parseConstraint(q.Constraint)
+		if _, err := parseConstraint(q.Constraint); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", q.Name, err)
+		}
+
+		root = append(root, Dependency{Name: q.Name, Constraint: q.Constraint})
+	}
+
+	sort.SliceStable(root, func(i, j int) bool { return root[i].Name < root[j].Name })
+
+	enabled := make(optionalDeps)
+
+	for {
+		idx := r.index.withOptional(enabled)
+
+		s, err := newSolver(idx, root, r.opts.PreferHigher)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", q.Name, err)
+			return nil, nil, err
 		}
 
-		if ex, ok := merged[q.Name]; ok {
-			// Masterminds/semver does not expose a direct Intersect API universally,
-			// so we AND-join the textual constraints and re-parse.
-			cc, err := semver.NewConstraint(ex.String() + ", " + c.String())
-			if err != nil {
-				return nil, fmt.Errorf("%s: %w", q.Name, err)
-			}
+		res, failure, err := s.solve()
+		if err != nil {
+			return nil, nil, err
+		}
 
-			merged[q.Name] = cc
-		} else {
-			merged[q.Name] = c
+		if failure != nil {
+			return nil, nil, s.conflictError(failure)
 		}
-	}
 
-	result := make(Resolution)
-	visiting := make(map[PackageID]bool)
+		features, deps, err := activateFeatures(r.index, res, reqs)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	// Determine initial worklist sorted by name for determinism.
-	roots := make([]PackageID, 0, len(merged))
-	for id := range merged {
-		roots = append(roots, id)
+		if enabled.merge(deps) {
+			continue
+		}
+
+		if cyc := idx.findCycle(res); cyc != nil {
+			return nil, nil, &CycleError{Stack: cyc}
+		}
+
+		if r.opts.MaxDepth > 0 {
+			if pkg, ok := idx.exceedsDepth(reqs, res, r.opts.MaxDepth); ok {
+				return nil, nil, &ConflictError{Package: pkg, Reason: "max depth exceeded"}
+			}
+		}
+
+		return res, features, nil
 	}
+}
 
-	sort.Slice(roots, func(i, j int) bool { return string(roots[i]) < string(roots[j]) })
+// conflictError wraps a failed resolution; Package names the first package
+// of the root cause.
+func (s *solver) conflictError(failure *incompatibility) *ConflictError {
+	ce := &ConflictError{Reason: "no solution satisfies the requirements", Explanation: s.explain(failure)}
 
-	for _, root := range roots {
-		if _, ok := result[root]; ok {
-			continue
+	for inc := failure; ce.Package == ""; {
+		for _, t := range inc.terms {
+			if t.pkg != rootPackage {
+				ce.Package = t.pkg
+
+				break
+			}
 		}
 
-		if err := r.selectVersion(root, merged[root], result, visiting, 0); err != nil {
-			return nil, err
+		if ce.Package != "" {
+			break
+		}
+
+		switch {
+		case inc.kind == kindDependency:
+			ce.Package = inc.dependency.Name
+		case inc.kind == kindDerived:
+			inc = inc.right
+
+			continue
+		default:
+			ce.Package = rootPackage
 		}
 	}
 
-	return result, nil
+	return ce
 }
 
-// selectVersion chooses a version for pkg that satisfies con plus transitive deps.
-func (r *Resolver) selectVersion(pkg PackageID, con *semver.Constraints, out Resolution, visiting map[PackageID]bool, depth int) error {
-	if r.opts.MaxDepth > 0 && depth > r.opts.MaxDepth {
-		return &ConflictError{Package: pkg, Reason: "max depth exceeded"}
+// dependenciesOf returns the dependencies declared by the resolved version of pkg.
+func (idx PackageIndex) dependenciesOf(pkg PackageID, res Resolution) []Dependency {
+	for _, pv := range idx[pkg] {
+		if pv.Version == res[pkg] {
+			return pv.Dependencies
+		}
 	}
 
-	if visiting[pkg] { // cycle
-		// Build cycle stack for error message.
-		stack := make([]PackageID, 0, len(visiting)+1)
+	return nil
+}
+
+// findCycle reports a dependency cycle among the resolved packages.
+func (idx PackageIndex) findCycle(res Resolution) []PackageID {
+	const (
+		unvisited = iota
+		active
+		finished
+	)
+
+	state := make(map[PackageID]int, len(res))
+
+	var stack []PackageID
+
+	var visit func(p PackageID) []PackageID
 
-		for id, v := range visiting {
-			if v {
-				stack = append(stack, id)
+	visit = func(p PackageID) []PackageID {
+		switch state[p] {
+		case active:
+			for i, q := range stack {
+				if q == p {
+					return append(append([]PackageID(nil), stack[i:]...), p)
+				}
 			}
+		case finished:
+			return nil
 		}
 
-		stack = append(stack, pkg)
-		sort.Slice(stack, func(i, j int) bool { return string(stack[i]) < string(stack[j]) })
+		state[p] = active
+		stack = append(stack, p)
 
-		return &CycleError{Stack: stack}
-	}
-	// Already pinned: verify compatibility.
-	if v, ok := out[pkg]; ok {
-		sv, err := semver.NewVersion(string(v))
-		if err != nil {
-			return fmt.Errorf("%s pinned invalid version: %w", pkg, err)
+		for _, d := range idx.dependenciesOf(p, res) {
+			if cyc := visit(d.Name); cyc != nil {
+				return cyc
+			}
 		}
 
-		if con != nil && !con.Check(sv) {
-			return &ConflictError{Package: pkg, Reason: fmt.Sprintf("pinned %s violates %s", v, con.String())}
-		}
+		stack = stack[:len(stack)-1]
+		state[p] = finished
 
 		return nil
 	}
 
-	candidates := r.index[pkg]
-	if len(candidates) == 0 {
-		return &ConflictError{Package: pkg, Reason: "no versions in index"}
+	names := make([]PackageID, 0, len(res))
+	for p := range res {
+		names = append(names, p)
 	}
-	// Sort by semver per preference.
-	sort.Slice(candidates, func(i, j int) bool {
-		vi := mustSemver(candidates[i].Version)
-		vj := mustSemver(candidates[j].Version)
-
-		if r.opts.PreferHigher {
-			return vi.GreaterThan(vj)
-		}
 
-		return vi.LessThan(vj)
-	})
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
 
-	// Try candidates until one fits.
-	for _, pv := range candidates {
-		sv := mustSemver(pv.Version)
-		if con != nil && !con.Check(sv) {
-			continue
+	for _, p := range names {
+		if cyc := visit(p); cyc != nil {
+			return cyc
 		}
-		// Tentatively pin.
-		out[pkg] = pv.Version
-		visiting[pkg] = true
-		// Resolve dependencies.
-		depsOK := true
+	}
 
-		for _, d := range pv.Dependencies {
-			dc, err := parseConstraint(d.Constraint)
-			if err != nil {
-				depsOK = false
+	return nil
+}
 
-				break
-			}
+// exceedsDepth reports a package whose shortest path from the roots is longer than MaxDepth.
+func (idx PackageIndex) exceedsDepth(reqs []Requirement, res Resolution, maxDepth int) (PackageID, bool) {
+	depth := make(map[PackageID]int, len(res))
+	queue := make([]PackageID, 0, len(reqs))
 
-			if err := r.selectVersion(d.Name, dc, out, visiting, depth+1); err != nil {
-				depsOK = false
-				// Unwind tentative selection for the dependency to permit other choices.
-				// Note: selections are overwritten in recursive calls; here we simply mark as failed.
-				// We will backtrack by trying next candidate version of pkg.
-				break
-			}
+	for _, q := range reqs {
+		if _, ok := depth[q.Name]; !ok {
+			depth[q.Name] = 0
+			queue = append(queue, q.Name)
 		}
+	}
 
-		visiting[pkg] = false
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
 
-		if depsOK {
-			return nil
+		if depth[p] > maxDepth {
+			return p, true
+		}
+
+		for _, d := range idx.dependenciesOf(p, res) {
+			if _, ok := depth[d.Name]; !ok {
+				depth[d.Name] = depth[p] + 1
+				queue = append(queue, d.Name)
+			}
 		}
-		// Backtrack this candidate.
-		delete(out, pkg)
 	}
 
-	return &ConflictError{Package: pkg, Reason: fmt.Sprintf("no candidate satisfies %s", humanConstraint(con))}
+	return "", false
 }
 
 func parseConstraint(expr string) (*semver.Constraints, error) {
@@ -241,11 +319,3 @@ func mustSemver(v Version) *semver.Version {
 
 	return sv
 }
-
-func humanConstraint(c *semver.Constraints) string {
-	if c == nil {
-		return "<any>"
-	}
-
-	return c.String()
-}
@@ -1,6 +1,10 @@
 package packagemanager
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestResolver_SimpleGraph(t *testing.T) {
 	idx := PackageIndex{
@@ -41,3 +45,109 @@ func TestResolver_Conflict(t *testing.T) {
 		t.Fatalf("expected conflict error")
 	}
 }
+
+func TestResolver_BacktracksAcrossPackages(t *testing.T) {
+	// The newest A needs C 2.x, but B (required alongside A) only works
+	// with C 1.x; a solution exists only with the older A.
+	idx := PackageIndex{
+		"A": {
+			{Name: "A", Version: "1.0.0", Dependencies: []Dependency{{Name: "C", Constraint: "^1.0.0"}}},
+			{Name: "A", Version: "2.0.0", Dependencies: []Dependency{{Name: "C", Constraint: "^2.0.0"}}},
+		},
+		"B": {
+			{Name: "B", Version: "1.0.0", Dependencies: []Dependency{{Name: "C", Constraint: "^1.0.0"}}},
+		},
+		"C": {
+			{Name: "C", Version: "1.0.0"},
+			{Name: "C", Version: "1.5.0"},
+			{Name: "C", Version: "2.0.0"},
+		},
+	}
+	r := NewResolver(idx, ResolveOptions{PreferHigher: true})
+
+	res, err := r.Resolve([]Requirement{{Name: "A", Constraint: ">=1.0.0"}, {Name: "B", Constraint: "*"}})
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	if res["A"] != "1.0.0" || res["B"] != "1.0.0" || res["C"] != "1.5.0" {
+		t.Fatalf("unexpected resolution: %v", res)
+	}
+}
+
+func TestResolver_ConflictExplanation(t *testing.T) {
+	idx := PackageIndex{
+		"a": {
+			{Name: "a", Version: "1.0.0", Dependencies: []Dependency{{Name: "b", Constraint: "^2.0.0"}}},
+			{Name: "a", Version: "1.1.0", Dependencies: []Dependency{{Name: "b", Constraint: "^2.0.0"}}},
+		},
+		"b": {{Name: "b", Version: "1.0.0"}, {Name: "b", Version: "2.0.0"}},
+		"c": {{Name: "c", Version: "1.0.0", Dependencies: []Dependency{{Name: "b", Constraint: "^1.0.0"}}}},
+	}
+	r := NewResolver(idx, ResolveOptions{PreferHigher: true})
+
+	_, err := r.Resolve([]Requirement{{Name: "a", Constraint: "^1.0.0"}, {Name: "c", Constraint: "^1.0.0"}})
+
+	var ce *ConflictError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+
+	for _, want := range []string{"a ^1.0.0 depends on b ^2.0.0", "c ^1.0.0 depends on b ^1.0.0", "version solving failed"} {
+		if !strings.Contains(ce.Explanation, want) {
+			t.Fatalf("explanation missing %q:\n%s", want, ce.Explanation)
+		}
+	}
+}
+
+func TestResolver_MissingDependencyExplained(t *testing.T) {
+	idx := PackageIndex{
+		"a": {{Name: "a", Version: "1.0.0", Dependencies: []Dependency{{Name: "ghost", Constraint: "^1.0.0"}}}},
+	}
+
+	_, err := NewResolver(idx, ResolveOptions{}).Resolve([]Requirement{{Name: "a", Constraint: "*"}})
+
+	var ce *ConflictError
+	if !errors.As(err, &ce) || !strings.Contains(ce.Explanation, "ghost ^1.0.0 which doesn't exist") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestResolver_PreferLowerAndCycle(t *testing.T) {
+	idx := PackageIndex{
+		"A": {
+			{Name: "A", Version: "1.0.0", Dependencies: []Dependency{{Name: "B", Constraint: "*"}}},
+			{Name: "A", Version: "1.2.0", Dependencies: []Dependency{{Name: "B", Constraint: "*"}}},
+		},
+		"B": {{Name: "B", Version: "0.1.0", Dependencies: []Dependency{{Name: "A", Constraint: "*"}}}},
+	}
+
+	_, err := NewResolver(idx, ResolveOptions{}).Resolve([]Requirement{{Name: "A", Constraint: "*"}})
+
+	var ce *CycleError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+
+	idx["B"][0].Dependencies = nil
+
+	res, err := NewResolver(idx, ResolveOptions{}).Resolve([]Requirement{{Name: "A", Constraint: "*"}})
+	if err != nil || res["A"] != "1.0.0" {
+		t.Fatalf("expected lowest A, got %v (%v)", res, err)
+	}
+}
+
+func TestResolver_InconsistentSolverStateIsAnError(t *testing.T) {
+	s, err := newSolver(PackageIndex{"A": {{Name: "A", Version: "1.0.0"}}}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is assigned yet, so no assignment can satisfy "A is 1.0.0".
+	selected := s.uni("A").allowing("=1.0.0")
+	inc := s.newIncompatibility(kindDerived, []term{{pkg: "A", set: selected}})
+
+	if _, _, err := s.resolveConflict(inc); err == nil || !strings.Contains(err.Error(), "internal error") {
+		t.Fatalf("expected an internal error, got %v", err)
+	}
+}
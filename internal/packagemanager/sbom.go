@@ -0,0 +1,364 @@
+package packagemanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SBOM output formats.
+const (
+	SBOMFormatSPDX      = "spdx-json"
+	SBOMFormatCycloneDX = "cyclonedx-json"
+)
+
+// SBOMSubject describes the package an SBOM is generated for.
+type SBOMSubject struct {
+	Name    PackageID
+	Version Version
+	License string
+	// Dependencies are the direct dependencies of the subject.
+	Dependencies []PackageID
+}
+
+// GenerateSBOM renders a software bill of materials for the locked packages
+// in the given format. Package hashes are the SHA-256 digests carried by the
+// CIDs, so the SBOM can be checked against the registry without refetching.
+// The output depends only on its inputs; pass a fixed created time (e.g. from
+// SOURCE_DATE_EPOCH) for reproducible documents.
+func GenerateSBOM(subject SBOMSubject, lf Lockfile, format string, created time.Time) ([]byte, error) {
+	entries := append([]LockEntry(nil), lf.Entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	subject.Dependencies = append([]PackageID(nil), subject.Dependencies...)
+	sort.Slice(subject.Dependencies, func(i, j int) bool { return subject.Dependencies[i] < subject.Dependencies[j] })
+
+	for _, e := range entries {
+		if _, err := cidDigest(e.CID); err != nil {
+			return nil, fmt.Errorf("%s@%s: %w", e.Name, e.Version, err)
+		}
+	}
+
+	switch format {
+	case SBOMFormatSPDX:
+		return marshalCanonicalJSON(spdxDocument(subject, entries, created.UTC()))
+	case SBOMFormatCycloneDX:
+		return marshalCanonicalJSON(cycloneDXDocument(subject, entries, created.UTC()))
+	default:
+		return nil, fmt.Errorf("unknown SBOM format %q (want %s or %s)", format, SBOMFormatSPDX, SBOMFormatCycloneDX)
+	}
+}
+
+// cidDigest returns the hex SHA-256 digest a CID is made of.
+func cidDigest(id CID) (string, error) {
+	digest := strings.TrimPrefix(string(id), "oz1-")
+	if len(digest) != 64 || digest == string(id) {
+		return "", fmt.Errorf("CID %q does not carry a SHA-256 digest", id)
+	}
+
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", fmt.Errorf("CID %q does not carry a SHA-256 digest", id)
+	}
+
+	return digest, nil
+}
+
+// packageURL returns the purl of an Orizon package.
+func packageURL(name PackageID, version Version) string {
+	return fmt.Sprintf("pkg:orizon/%s@%s", name, version)
+}
+
+// sbomFingerprint identifies the locked set, for document namespaces and serial numbers.
+func sbomFingerprint(subject SBOMSubject, entries []LockEntry) [32]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s@%s\n", subject.Name, subject.Version)
+
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s@%s %s\n", e.Name, e.Version, e.CID)
+	}
+
+	var sum [32]byte
+
+	copy(sum[:], h.Sum(nil))
+
+	return sum
+}
+
+// dependencyEdges maps each locked package to the locked packages it depends on.
+func dependencyEdges(entries []LockEntry) map[PackageID][]PackageID {
+	locked := make(map[PackageID]bool, len(entries))
+	for _, e := range entries {
+		locked[e.Name] = true
+	}
+
+	edges := make(map[PackageID][]PackageID, len(entries))
+
+	for _, e := range entries {
+		for _, d := range e.Dependencies {
+			if locked[d.Name] {
+				edges[e.Name] = append(edges[e.Name], d.Name)
+			}
+		}
+	}
+
+	return edges
+}
+
+// ---- SPDX 2.3 ----.
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxID returns a valid SPDX element ID for a package.
+func spdxID(name PackageID, version Version) string {
+	clean := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+				return r
+			}
+
+			return '-'
+		}, s)
+	}
+
+	return "SPDXRef-Package-" + clean(string(name)) + "-" + clean(string(version))
+}
+
+func spdxLicense(license string) string {
+	if license == "" {
+		return "NOASSERTION"
+	}
+
+	return license
+}
+
+func spdxDocument(subject SBOMSubject, entries []LockEntry, created time.Time) spdxDoc {
+	fp := sbomFingerprint(subject, entries)
+	rootID := spdxID(subject.Name, subject.Version)
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              fmt.Sprintf("%s@%s", subject.Name, subject.Version),
+		DocumentNamespace: fmt.Sprintf("https://orizon-lang.org/spdx/%s-%s-%x", subject.Name, subject.Version, fp[:8]),
+		CreationInfo:      spdxCreationInfo{Created: created.Format(time.RFC3339), Creators: []string{"Tool: orizon-pkg"}},
+		Packages: []spdxPackage{{
+			Name:             string(subject.Name),
+			SPDXID:           rootID,
+			VersionInfo:      string(subject.Version),
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  spdxLicense(subject.License),
+			CopyrightText:    "NOASSERTION",
+			ExternalRefs:     []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: packageURL(subject.Name, subject.Version)}},
+		}},
+		Relationships: []spdxRelationship{{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: rootID}},
+	}
+
+	ids := make(map[PackageID]string, len(entries))
+
+	for _, e := range entries {
+		digest, _ := cidDigest(e.CID)
+		ids[e.Name] = spdxID(e.Name, e.Version)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name:             string(e.Name),
+			SPDXID:           ids[e.Name],
+			VersionInfo:      string(e.Version),
+			DownloadLocation: "NOASSERTION",
+			Checksums:        []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: digest}},
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  spdxLicense(e.License),
+			CopyrightText:    "NOASSERTION",
+			ExternalRefs: []spdxExternalRef{
+				{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: packageURL(e.Name, e.Version)},
+				{ReferenceCategory: "OTHER", ReferenceType: "orizon-cid", ReferenceLocator: string(e.CID)},
+			},
+		})
+	}
+
+	for _, d := range subject.Dependencies {
+		if id, ok := ids[d]; ok {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: rootID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id})
+		}
+	}
+
+	edges := dependencyEdges(entries)
+	for _, e := range entries {
+		for _, d := range edges[e.Name] {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: ids[e.Name], RelationshipType: "DEPENDS_ON", RelatedSPDXElement: ids[d]})
+		}
+	}
+
+	return doc
+}
+
+// ---- CycloneDX 1.5 ----.
+
+type cdxDoc struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	Hashes     []cdxHash     `json:"hashes,omitempty"`
+	Licenses   []cdxLicense  `json:"licenses,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicense struct {
+	Expression string `json:"expression"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+func cdxLicenses(license string) []cdxLicense {
+	if license == "" {
+		return nil
+	}
+
+	return []cdxLicense{{Expression: license}}
+}
+
+func cycloneDXDocument(subject SBOMSubject, entries []LockEntry, created time.Time) cdxDoc {
+	fp := sbomFingerprint(subject, entries)
+	// A name-based (version 5 style) UUID keeps the serial number stable for identical inputs.
+	fp[6] = fp[6]&0x0f | 0x50
+	fp[8] = fp[8]&0x3f | 0x80
+	rootRef := packageURL(subject.Name, subject.Version)
+	doc := cdxDoc{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", fp[0:4], fp[4:6], fp[6:8], fp[8:10], fp[10:16]),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: created.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "orizon-pkg"}}},
+			Component: cdxComponent{
+				Type:     "application",
+				BOMRef:   rootRef,
+				Name:     string(subject.Name),
+				Version:  string(subject.Version),
+				Licenses: cdxLicenses(subject.License),
+				PURL:     rootRef,
+			},
+		},
+		Components: make([]cdxComponent, 0, len(entries)),
+	}
+
+	refs := make(map[PackageID]string, len(entries))
+
+	for _, e := range entries {
+		digest, _ := cidDigest(e.CID)
+		refs[e.Name] = packageURL(e.Name, e.Version)
+		doc.Components = append(doc.Components, cdxComponent{
+			Type:       "library",
+			BOMRef:     refs[e.Name],
+			Name:       string(e.Name),
+			Version:    string(e.Version),
+			Hashes:     []cdxHash{{Alg: "SHA-256", Content: digest}},
+			Licenses:   cdxLicenses(e.License),
+			PURL:       refs[e.Name],
+			Properties: []cdxProperty{{Name: "orizon:cid", Value: string(e.CID)}},
+		})
+	}
+
+	root := cdxDependency{Ref: rootRef, DependsOn: []string{}}
+	for _, d := range subject.Dependencies {
+		if ref, ok := refs[d]; ok {
+			root.DependsOn = append(root.DependsOn, ref)
+		}
+	}
+
+	doc.Dependencies = append(doc.Dependencies, root)
+
+	edges := dependencyEdges(entries)
+	for _, e := range entries {
+		dep := cdxDependency{Ref: refs[e.Name], DependsOn: []string{}}
+		for _, d := range edges[e.Name] {
+			dep.DependsOn = append(dep.DependsOn, refs[d])
+		}
+
+		doc.Dependencies = append(doc.Dependencies, dep)
+	}
+
+	return doc
+}
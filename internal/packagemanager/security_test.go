@@ -158,6 +158,11 @@ func TestInputValidator_ValidateCID(t *testing.T) {
 			input:   "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
 			wantErr: false,
 		},
+		{
+			name:    "prefixed CID",
+			input:   "oz1-1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
+			wantErr: false,
+		},
 		{
 			name:    "CID too short",
 			input:   "1234567890abcdef",
@@ -128,7 +128,7 @@ func SelfSignRoot(subject string, pub ed25519.PublicKey, priv ed25519.PrivateKey
 		PublicKey: append([]byte(nil), pub...),
 		NotBefore: time.Now().Add(-time.Minute),
 		NotAfter:  time.Now().Add(validity),
-		KeyUsage:  []string{"cert-sign", "package-sign", "lockfile-sign"},
+		KeyUsage:  []string{"cert-sign", "package-sign", "lockfile-sign", "advisory-sign", "log-sign"},
 	}
 
 	tbs, err := cert.tbsCertificate()
@@ -276,26 +276,37 @@ func SignDescriptor(desc PackageDescriptor, signerPriv ed25519.PrivateKey, chain
 		return SignatureBundle{}, err
 	}
 
-	sig := ed25519.Sign(signerPriv, b)
-
-	var leafPub ed25519.PublicKey
+	return signBytes(b, signerPriv, chain)
+}
 
+// signBytes produces a detached ed25519 signature bundle over b.
+func signBytes(b []byte, signerPriv ed25519.PrivateKey, chain []Certificate) (SignatureBundle, error) {
 	if len(chain) == 0 {
 		return SignatureBundle{}, errors.New("missing certificate chain")
 	}
 
-	leafPub = ed25519.PublicKey(chain[0].PublicKey)
+	leafPub := ed25519.PublicKey(chain[0].PublicKey)
 
 	return SignatureBundle{
 		Algorithm: "ed25519",
 		KeyID:     Fingerprint(leafPub),
-		Signature: sig,
+		Signature: ed25519.Sign(signerPriv, b),
 		Chain:     append([]Certificate(nil), chain...),
 	}, nil
 }
 
 // VerifyDescriptor verifies the descriptor against the signature bundle using the trust store.
 func (ts *TrustStore) VerifyDescriptor(desc PackageDescriptor, bundle SignatureBundle) error {
+	b, err := descriptorBytes(desc)
+	if err != nil {
+		return err
+	}
+
+	return ts.verifyBytes(b, bundle)
+}
+
+// verifyBytes checks bundle's chain against the trust store and its signature over b.
+func (ts *TrustStore) verifyBytes(b []byte, bundle SignatureBundle) error {
 	if bundle.Algorithm != "ed25519" {
 		return errors.New("unsupported algorithm")
 	}
@@ -307,11 +318,6 @@ func (ts *TrustStore) VerifyDescriptor(desc PackageDescriptor, bundle SignatureB
 	if err := ts.VerifyChain(bundle.Chain); err != nil {
 		return err
 	}
-	// Verify signature.
-	b, err := descriptorBytes(desc)
-	if err != nil {
-		return err
-	}
 
 	leafPub := ed25519.PublicKey(bundle.Chain[0].PublicKey)
 	if !ed25519.Verify(leafPub, b, bundle.Signature) {
@@ -0,0 +1,341 @@
+package packagemanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// IndexFile is the sparse index document of one package, served at
+// /index/<name>: every published version with what resolution needs. Clients
+// fetch one file per package instead of calling /list and /find, and
+// revalidate it with If-None-Match.
+type IndexFile struct {
+	Name     PackageID    `json:"name"`
+	Versions []IndexEntry `json:"versions"`
+}
+
+// IndexEntry describes one version in an IndexFile.
+type IndexEntry struct {
+	Version      Version             `json:"version"`
+	CID          CID                 `json:"cid"`
+	Dependencies []Dependency        `json:"dependencies,omitempty"`
+	Features     map[string][]string `json:"features,omitempty"`
+	Yanked       bool                `json:"yanked,omitempty"`
+	Deprecated   string              `json:"deprecated,omitempty"`
+}
+
+// CIDLister is implemented by registries that can return the CIDs of every
+// version of a package in one lookup. BuildIndexFile uses it when available
+// instead of a Find per version. Wrappers return a nil map and no error when
+// the registry they wrap cannot list CIDs, and BuildIndexFile falls back to
+// Find.
+type CIDLister interface {
+	CIDs(ctx context.Context, name PackageID) (map[Version]CID, error)
+}
+
+// BuildIndexFile assembles the index file of name from reg, versions in
+// ascending order. A package without versions yields an empty file. Versions
+// that are not valid semver cannot be resolved and are left out.
+func BuildIndexFile(ctx context.Context, reg Registry, name PackageID) (IndexFile, error) {
+	mans, err := reg.List(ctx, name)
+	if err != nil {
+		return IndexFile{}, err
+	}
+
+	var cids map[Version]CID
+	if l, ok := reg.(CIDLister); ok {
+		if cids, err = l.CIDs(ctx, name); err != nil {
+			return IndexFile{}, fmt.Errorf("index %s: %w", name, err)
+		}
+	}
+
+	f := IndexFile{Name: name, Versions: make([]IndexEntry, 0, len(mans))}
+	parsed := make(map[Version]*semver.Version, len(mans))
+
+	for _, mf := range mans {
+		sv, err := semver.NewVersion(string(mf.Version))
+		if err != nil {
+			continue
+		}
+
+		id, ok := cids[mf.Version]
+		if !ok {
+			if cids != nil {
+				return IndexFile{}, fmt.Errorf("index %s@%s: %w", name, mf.Version, ErrNotFound)
+			}
+
+			c, err := semver.NewConstraint("=" + string(mf.Version))
+			if err != nil {
+				return IndexFile{}, fmt.Errorf("%s@%s: %w", name, mf.Version, err)
+			}
+
+			if id, _, err = reg.Find(ctx, name, c); err != nil {
+				return IndexFile{}, fmt.Errorf("index %s@%s: %w", name, mf.Version, err)
+			}
+		}
+
+		parsed[mf.Version] = sv
+		f.Versions = append(f.Versions, IndexEntry{
+			Version:      mf.Version,
+			CID:          id,
+			Dependencies: mf.Dependencies,
+			Features:     mf.Features,
+			Yanked:       mf.Yanked,
+			Deprecated:   mf.Deprecated,
+		})
+	}
+
+	sort.Slice(f.Versions, func(i, j int) bool {
+		return parsed[f.Versions[i].Version].LessThan(parsed[f.Versions[j].Version])
+	})
+
+	return f, nil
+}
+
+// Manifests returns the versions of the file as manifests.
+func (f IndexFile) Manifests() []PackageManifest {
+	out := make([]PackageManifest, 0, len(f.Versions))
+	for _, e := range f.Versions {
+		out = append(out, e.manifest(f.Name))
+	}
+
+	return out
+}
+
+func (e IndexEntry) manifest(name PackageID) PackageManifest {
+	return PackageManifest{
+		Name:         name,
+		Version:      e.Version,
+		Dependencies: e.Dependencies,
+		Features:     e.Features,
+		Yanked:       e.Yanked,
+		Deprecated:   e.Deprecated,
+	}
+}
+
+// Find picks the highest version satisfying constraint with the same rules
+// as the registries: versions that are not yanked are preferred and a yanked
+// one is only returned when nothing else matches.
+func (f IndexFile) Find(constraint *semver.Constraints) (CID, PackageManifest, error) {
+	best := -1
+
+	var bestVer *semver.Version
+
+	for i, e := range f.Versions {
+		sv, err := semver.NewVersion(string(e.Version))
+		if err != nil || (constraint != nil && !constraint.Check(sv)) {
+			continue
+		}
+
+		if best == -1 {
+			best, bestVer = i, sv
+
+			continue
+		}
+
+		cur, cand := f.Versions[best].Yanked, e.Yanked
+		if (cur && !cand) || (cur == cand && sv.GreaterThan(bestVer)) {
+			best, bestVer = i, sv
+		}
+	}
+
+	if best < 0 {
+		return "", PackageManifest{}, ErrNotFound
+	}
+
+	return f.Versions[best].CID, f.Versions[best].manifest(f.Name), nil
+}
+
+// errNoSparseIndex reports a server that does not serve /index.
+var errNoSparseIndex = errors.New("registry has no sparse index")
+
+// SetIndexCacheDir makes the client keep index files and their ETags under
+// dir, in a subdirectory per registry URL, so that later processes only
+// revalidate them. An empty dir keeps them in memory only.
+func (r *HTTPRegistry) SetIndexCacheDir(dir string) {
+	if dir != "" {
+		sum := sha256.Sum256([]byte(r.base))
+		dir = filepath.Join(dir, hex.EncodeToString(sum[:8]))
+	}
+
+	r.mu.Lock()
+	r.indexDir = dir
+	r.mu.Unlock()
+}
+
+// cachedIndex is the on-disk form of a fetched index file.
+type cachedIndex struct {
+	ETag  string    `json:"etag"`
+	Index IndexFile `json:"index"`
+}
+
+func (r *HTTPRegistry) indexPath(name PackageID) string {
+	r.mu.RLock()
+	dir := r.indexDir
+	r.mu.RUnlock()
+
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, url.PathEscape(string(name))+".json")
+}
+
+// sparseIndex returns the index file of name, from memory while it is fresh
+// and otherwise with a conditional request against the disk cache. It
+// returns errNoSparseIndex when the server predates the sparse protocol.
+func (r *HTTPRegistry) sparseIndex(ctx context.Context, name PackageID) (IndexFile, error) {
+	if r.noSparse.Load() {
+		return IndexFile{}, errNoSparseIndex
+	}
+
+	r.mu.RLock()
+	if c, ok := r.indexCache[name]; ok && time.Since(c.at) < r.ttl {
+		r.mu.RUnlock()
+
+		return c.file, nil
+	}
+	r.mu.RUnlock()
+
+	v, err, _ := r.sf.Do("index:"+string(name), func() (any, error) {
+		f, err := r.fetchIndex(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		r.indexCache[name] = struct {
+			at   time.Time
+			file IndexFile
+		}{at: time.Now(), file: f}
+		r.mu.Unlock()
+
+		r.prefetch(ctx, f)
+
+		return f, nil
+	})
+	if err != nil {
+		return IndexFile{}, err
+	}
+
+	return v.(IndexFile), nil
+}
+
+func (r *HTTPRegistry) fetchIndex(ctx context.Context, name PackageID) (IndexFile, error) {
+	path := r.indexPath(name)
+
+	var cached cachedIndex
+
+	haveCached := false
+
+	if path != "" {
+		if b, err := os.ReadFile(path); err == nil && json.Unmarshal(b, &cached) == nil && cached.Index.Name == name {
+			haveCached = true
+		}
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/index/"+string(name), http.NoBody)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	if haveCached && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := r.doWithRetry(req)
+	if err != nil {
+		return IndexFile{}, err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && haveCached:
+		return cached.Index, nil
+	case resp.StatusCode == http.StatusNotFound:
+		r.noSparse.Store(true)
+
+		return IndexFile{}, errNoSparseIndex
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(resp.Body)
+
+		return IndexFile{}, fmt.Errorf("index %s failed: %s", name, string(body))
+	}
+
+	var f IndexFile
+	if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+		return IndexFile{}, err
+	}
+
+	if f.Name != name {
+		return IndexFile{}, fmt.Errorf("index %s: server returned the index of %q", name, f.Name)
+	}
+
+	if path != "" {
+		// the cache is an optimisation; failing to write it is not an error.
+		if b, err := json.Marshal(cachedIndex{ETag: resp.Header.Get("ETag"), Index: f}); err == nil {
+			if os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+				_ = os.WriteFile(path, b, 0o644)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// prefetch starts loading the index files of every dependency named in f in
+// the background, so they are usually there by the time the resolver asks
+// for them. At most ioConcurrency requests run at once.
+func (r *HTTPRegistry) prefetch(ctx context.Context, f IndexFile) {
+	ctx = context.WithoutCancel(ctx)
+
+	var names []PackageID
+
+	r.mu.Lock()
+	for _, e := range f.Versions {
+		for _, d := range e.Dependencies {
+			if r.prefetched[d.Name] {
+				continue
+			}
+
+			if _, ok := r.indexCache[d.Name]; ok {
+				continue
+			}
+
+			r.prefetched[d.Name] = true
+			names = append(names, d.Name)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, name := range names {
+		go func() {
+			r.prefetchSem <- struct{}{}
+			defer func() { <-r.prefetchSem }()
+
+			_, _ = r.sparseIndex(ctx, name)
+		}()
+	}
+}
+
+// dropIndex forgets the cached index file of name after a change.
+func (r *HTTPRegistry) dropIndex(name PackageID) {
+	r.mu.Lock()
+	delete(r.indexCache, name)
+	delete(r.prefetched, name)
+	r.mu.Unlock()
+}
@@ -0,0 +1,267 @@
+package packagemanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+func TestSparseIndex_ResolvesWithoutListOrFindAndRevalidates(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("ORIZON_REGISTRY_TOKEN", "")
+	t.Setenv("ORIZON_REGISTRY_USERS", "")
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publish := func(name PackageID, v Version, deps ...Dependency) {
+		blob := PackageBlob{Manifest: PackageManifest{Name: name, Version: v, Dependencies: deps}, Data: []byte(string(name) + string(v))}
+		if _, err := fr.Publish(ctx, blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish("c", "1.0.0")
+	publish("b", "1.0.0", Dependency{Name: "c", Constraint: "^1.0.0"})
+	publish("a", "1.0.0", Dependency{Name: "b", Constraint: "^1.0.0"})
+	publish("a", "1.1.0", Dependency{Name: "b", Constraint: "^1.0.0"})
+
+	if err := fr.Yank(ctx, "a", "1.1.0", true); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	mux := buildHTTPMux(fr)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusWriter{rw: w, code: http.StatusOK}
+		mux.ServeHTTP(rec, r)
+
+		mu.Lock()
+		requests = append(requests, r.URL.Path+" "+http.StatusText(rec.code))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	resolve := func() map[PackageID]Version {
+		client := NewHTTPRegistryWithAuth(srv.URL, "")
+		client.SetIndexCacheDir(cacheDir)
+
+		res, err := NewManager(client).ResolveAndFetch(ctx, []Requirement{{Name: "a", Constraint: "^1.0.0"}}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := make(map[PackageID]Version, len(res))
+		for name, r := range res {
+			out[name] = r.Version
+		}
+
+		return out
+	}
+
+	res := resolve()
+	if res["a"] != "1.0.0" || res["b"] != "1.0.0" || res["c"] != "1.0.0" {
+		t.Fatalf("unexpected resolution %v", res)
+	}
+
+	count := func(prefix, status string) int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		n := 0
+
+		for _, r := range requests {
+			if strings.HasPrefix(r, prefix) && strings.HasSuffix(r, status) {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	if n := count("/list", "") + count("/find", ""); n != 0 {
+		t.Fatalf("expected no /list or /find requests, got %d: %v", n, requests)
+	}
+
+	if n := count("/index/", "OK"); n != 3 {
+		t.Fatalf("expected one index fetch per package, got %d: %v", n, requests)
+	}
+
+	// A new client revalidates its disk cache and gets 304s.
+	mu.Lock()
+	requests = nil
+	mu.Unlock()
+
+	if again := resolve(); again["a"] != "1.0.0" {
+		t.Fatalf("unexpected resolution %v", again)
+	}
+
+	if n := count("/index/", "Not Modified"); n != 3 {
+		t.Fatalf("expected cached index files to be revalidated, got %v", requests)
+	}
+}
+
+func TestSparseIndex_FallsBackWithoutIndexEndpoint(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("ORIZON_REGISTRY_TOKEN", "")
+	t.Setenv("ORIZON_REGISTRY_USERS", "")
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fr.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: "1.0.0"}, Data: []byte("lib")}); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := buildHTTPMux(fr)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/index/") {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	client := NewHTTPRegistryWithAuth(srv.URL, "")
+
+	list, err := client.List(ctx, "lib")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list: %v %v", list, err)
+	}
+
+	if _, mf, err := client.Find(ctx, "lib", nil); err != nil || mf.Version != "1.0.0" {
+		t.Fatalf("find: %v %v", mf, err)
+	}
+}
+
+// bogusVersionRegistry lists a version that is not semver and counts Find.
+type bogusVersionRegistry struct {
+	*FileRegistry
+	finds int
+}
+
+func (r *bogusVersionRegistry) List(ctx context.Context, name PackageID) ([]PackageManifest, error) {
+	list, err := r.FileRegistry.List(ctx, name)
+
+	return append(list, PackageManifest{Name: name, Version: "latest"}), err
+}
+
+func (r *bogusVersionRegistry) Find(ctx context.Context, name PackageID, c *semver.Constraints) (CID, PackageManifest, error) {
+	r.finds++
+
+	return r.FileRegistry.Find(ctx, name, c)
+}
+
+func TestBuildIndexFile_OneCIDLookupAndSkipsInvalidVersions(t *testing.T) {
+	ctx := context.Background()
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cids := map[Version]CID{}
+
+	for _, v := range []Version{"1.10.0", "1.2.0", "0.9.0"} {
+		id, err := fr.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: v}, Data: []byte("lib" + string(v))})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cids[v] = id
+	}
+
+	reg := &bogusVersionRegistry{FileRegistry: fr}
+
+	f, err := BuildIndexFile(ctx, reg, "lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reg.finds != 0 {
+		t.Fatalf("expected a single CID lookup, got %d Find calls", reg.finds)
+	}
+
+	var got []string
+	for _, e := range f.Versions {
+		got = append(got, string(e.Version))
+
+		if e.CID != cids[e.Version] {
+			t.Fatalf("%s: CID %s, want %s", e.Version, e.CID, cids[e.Version])
+		}
+	}
+
+	if strings.Join(got, " ") != "0.9.0 1.2.0 1.10.0" {
+		t.Fatalf("unexpected versions %v", got)
+	}
+}
+
+func TestBuildIndexFile_OneCIDLookupThroughWrappers(t *testing.T) {
+	ctx := context.Background()
+
+	fr, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewFileRegistry(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []Version{"1.0.0", "1.1.0"} {
+		if _, err := fr.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: v}, Data: []byte("lib" + string(v))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reg := &bogusVersionRegistry{FileRegistry: fr}
+
+	for name, wrapped := range map[string]Registry{
+		"logged":  NewLoggedRegistry(reg, nil),
+		"caching": NewCachingRegistry(reg, cache),
+		"both":    NewLoggedRegistry(NewCachingRegistry(reg, cache), nil),
+	} {
+		reg.finds = 0
+
+		f, err := BuildIndexFile(ctx, wrapped, "lib")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if reg.finds != 0 || len(f.Versions) != 2 {
+			t.Fatalf("%s: %d versions with %d Find calls, want 2 with none", name, len(f.Versions), reg.finds)
+		}
+	}
+
+	// A wrapped registry that cannot list CIDs is indexed with Find.
+	reg.finds = 0
+
+	f, err := BuildIndexFile(ctx, NewLoggedRegistry(struct{ Registry }{reg}, nil), "lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reg.finds != 2 || len(f.Versions) != 2 {
+		t.Fatalf("%d versions with %d Find calls, want 2 with 2", len(f.Versions), reg.finds)
+	}
+}
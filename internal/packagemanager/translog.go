@@ -0,0 +1,607 @@
+package packagemanager
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The transparency log is an append-only Merkle tree over published package
+// versions, hashed as in RFC 9162: leaves are SHA-256(0x00 || entry) and
+// interior nodes SHA-256(0x01 || left || right). Tree heads are signed, and
+// clients use inclusion proofs to check that what they install is the version
+// everyone else sees, and consistency proofs to check that the log never
+// rewrites history. A registry that shows different logs to different users
+// (a split view) cannot produce a consistency proof between them.
+
+// logKeyUsage must be present on the leaf certificate that signs tree heads.
+const logKeyUsage = "log-sign"
+
+// ErrSplitView is returned (wrapped) when two tree heads of the same log
+// cannot both be honest.
+var ErrSplitView = errors.New("transparency log split view")
+
+// LogEntry is one leaf of the transparency log.
+type LogEntry struct {
+	Name    PackageID `json:"name"`
+	Version Version   `json:"version"`
+	CID     CID       `json:"cid"`
+}
+
+// LeafHash returns the Merkle leaf hash of the entry.
+func (e LogEntry) LeafHash() []byte {
+	b, _ := json.Marshal(e)
+
+	return hashLeaf(b)
+}
+
+// TreeHead is the size and root hash of the log at one point in time.
+type TreeHead struct {
+	Timestamp time.Time `json:"timestamp"`
+	RootHash  []byte    `json:"root_hash"`
+	TreeSize  uint64    `json:"tree_size"`
+}
+
+// SignedTreeHead is a tree head signed by the log.
+type SignedTreeHead struct {
+	TreeHead
+	Signature SignatureBundle `json:"signature"`
+}
+
+// InclusionProof proves that Entry is leaf Index of the tree of size TreeSize.
+type InclusionProof struct {
+	Entry    LogEntry `json:"entry"`
+	Hashes   [][]byte `json:"hashes"`
+	Index    uint64   `json:"index"`
+	TreeSize uint64   `json:"tree_size"`
+}
+
+// ConsistencyProof proves that the tree of size First is a prefix of the tree of size Second.
+type ConsistencyProof struct {
+	Hashes [][]byte `json:"hashes"`
+	First  uint64   `json:"first"`
+	Second uint64   `json:"second"`
+}
+
+// LogReceipt is returned by a logging registry when a package is published.
+type LogReceipt struct {
+	Inclusion InclusionProof `json:"inclusion"`
+	Head      SignedTreeHead `json:"head"`
+}
+
+// TransparencyLogProvider is implemented by registries that keep (or proxy) a transparency log.
+type TransparencyLogProvider interface {
+	// TreeHead returns the current signed tree head, or ErrNotFound if the registry keeps no log.
+	TreeHead(ctx context.Context) (SignedTreeHead, error)
+	// InclusionProof proves the entry for name@version against the tree of the given size;
+	// a size of 0 means the current tree.
+	InclusionProof(ctx context.Context, name PackageID, version Version, treeSize uint64) (InclusionProof, error)
+	// ConsistencyProof proves that the tree of size first is a prefix of the tree of size second.
+	ConsistencyProof(ctx context.Context, first, second uint64) (ConsistencyProof, error)
+}
+
+// ReceiptPublisher is implemented by registries that return a log receipt on publish.
+// The receipt is nil when the registry keeps no log.
+type ReceiptPublisher interface {
+	PublishWithReceipt(ctx context.Context, blob PackageBlob) (CID, *LogReceipt, error)
+}
+
+// TransparencyLog is an append-only Merkle log persisted as JSON lines.
+type TransparencyLog struct {
+	signer  ed25519.PrivateKey
+	index   map[string]uint64
+	path    string
+	entries []LogEntry
+	leaves  [][]byte
+	chain   []Certificate
+	mu      sync.RWMutex
+}
+
+// NewTransparencyLog opens the log stored at path (created on first append;
+// an empty path keeps the log in memory). Tree heads are signed with signer,
+// whose certificate chain must carry the "log-sign" usage.
+func NewTransparencyLog(path string, signer ed25519.PrivateKey, chain []Certificate) (*TransparencyLog, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("transparency log: missing certificate chain")
+	}
+
+	l := &TransparencyLog{path: path, signer: signer, chain: chain, index: make(map[string]uint64)}
+	if path == "" {
+		return l, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		var e LogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("transparency log %s: entry %d: %w", path, len(l.entries), err)
+		}
+
+		l.add(e)
+	}
+
+	return l, sc.Err()
+}
+
+// OpenTransparencyLog opens the log kept in dir, creating a signing key and a
+// self-signed root on first use. The root certificate is written to
+// dir/root.json for clients to trust.
+func OpenTransparencyLog(dir string) (*TransparencyLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var key struct {
+		Private ed25519.PrivateKey `json:"private_key"`
+		Chain   []Certificate      `json:"chain"`
+	}
+
+	keyPath := filepath.Join(dir, "key.json")
+
+	b, err := os.ReadFile(keyPath)
+
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &key); err != nil {
+			return nil, fmt.Errorf("transparency log key: %w", err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		pub, priv, err := GenerateEd25519Keypair()
+		if err != nil {
+			return nil, err
+		}
+
+		root, err := SelfSignRoot("orizon transparency log", pub, priv, 10*365*24*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+
+		key.Private, key.Chain = priv, []Certificate{root}
+
+		kb, err := json.MarshalIndent(key, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(keyPath, kb, 0o600); err != nil {
+			return nil, err
+		}
+
+		rb, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "root.json"), rb, 0o644); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	return NewTransparencyLog(filepath.Join(dir, "entries.jsonl"), key.Private, key.Chain)
+}
+
+func logKey(name PackageID, version Version) string { return string(name) + "@" + string(version) }
+
+// add appends e in memory; callers hold the write lock or own l exclusively.
+func (l *TransparencyLog) add(e LogEntry) uint64 {
+	idx := uint64(len(l.entries))
+	l.entries = append(l.entries, e)
+	l.leaves = append(l.leaves, e.LeafHash())
+	l.index[logKey(e.Name, e.Version)] = idx
+
+	return idx
+}
+
+// Size returns the number of entries in the log.
+func (l *TransparencyLog) Size() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return uint64(len(l.entries))
+}
+
+// Lookup returns the log entry for name@version.
+func (l *TransparencyLog) Lookup(name PackageID, version Version) (LogEntry, uint64, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	idx, ok := l.index[logKey(name, version)]
+	if !ok {
+		return LogEntry{}, 0, false
+	}
+
+	return l.entries[idx], idx, true
+}
+
+// Append adds e to the log and returns its index. Appending an entry that is
+// already logged is a no-op; logging different content for a name@version
+// that is already logged is an error, since log entries are permanent.
+func (l *TransparencyLog) Append(e LogEntry) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if idx, ok := l.index[logKey(e.Name, e.Version)]; ok {
+		if prev := l.entries[idx]; prev.CID != e.CID {
+			return 0, fmt.Errorf("transparency log: %s@%s is already logged as %s", e.Name, e.Version, prev.CID)
+		}
+
+		return idx, nil
+	}
+
+	if l.path != "" {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return 0, err
+		}
+
+		_, werr := f.Write(append(b, '\n'))
+		if cerr := f.Close(); werr == nil {
+			werr = cerr
+		}
+
+		if werr != nil {
+			return 0, werr
+		}
+	}
+
+	return l.add(e), nil
+}
+
+// Head signs and returns the current tree head.
+func (l *TransparencyLog) Head() (SignedTreeHead, error) {
+	l.mu.RLock()
+	head := TreeHead{TreeSize: uint64(len(l.leaves)), RootHash: merkleRoot(l.leaves), Timestamp: time.Now().UTC()}
+	l.mu.RUnlock()
+
+	payload, err := json.Marshal(head)
+	if err != nil {
+		return SignedTreeHead{}, err
+	}
+
+	bundle, err := signBytes(payload, l.signer, l.chain)
+	if err != nil {
+		return SignedTreeHead{}, err
+	}
+
+	return SignedTreeHead{TreeHead: head, Signature: bundle}, nil
+}
+
+// InclusionProof proves the entry for name@version against the tree of
+// treeSize entries (0 for the current size).
+func (l *TransparencyLog) InclusionProof(name PackageID, version Version, treeSize uint64) (InclusionProof, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if treeSize == 0 {
+		treeSize = uint64(len(l.leaves))
+	}
+
+	idx, ok := l.index[logKey(name, version)]
+	if !ok {
+		return InclusionProof{}, fmt.Errorf("transparency log: %s@%s: %w", name, version, ErrNotFound)
+	}
+
+	if treeSize > uint64(len(l.leaves)) || idx >= treeSize {
+		return InclusionProof{}, fmt.Errorf("transparency log: %s@%s is not in a tree of size %d", name, version, treeSize)
+	}
+
+	return InclusionProof{
+		Entry:    l.entries[idx],
+		Index:    idx,
+		TreeSize: treeSize,
+		Hashes:   inclusionPath(idx, l.leaves[:treeSize]),
+	}, nil
+}
+
+// ConsistencyProof proves that the tree of size first is a prefix of the tree of size second.
+func (l *TransparencyLog) ConsistencyProof(first, second uint64) (ConsistencyProof, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if first > second || second > uint64(len(l.leaves)) {
+		return ConsistencyProof{}, fmt.Errorf("transparency log: no consistency proof from %d to %d (size %d)", first, second, len(l.leaves))
+	}
+
+	p := ConsistencyProof{First: first, Second: second}
+	if first > 0 && first < second {
+		p.Hashes = subproof(first, l.leaves[:second], true)
+	}
+
+	return p, nil
+}
+
+// VerifyTreeHead checks the head's signature against the trust store.
+func (ts *TrustStore) VerifyTreeHead(sth SignedTreeHead) error {
+	payload, err := json.Marshal(sth.TreeHead)
+	if err != nil {
+		return err
+	}
+
+	if err := ts.verifyBytes(payload, sth.Signature); err != nil {
+		return fmt.Errorf("tree head: %w", err)
+	}
+
+	if !hasUsage(sth.Signature.Chain[0], logKeyUsage) {
+		return fmt.Errorf("tree head: signing certificate lacks %q usage", logKeyUsage)
+	}
+
+	return nil
+}
+
+// Verify checks the proof against the root hash of a tree of p.TreeSize entries.
+func (p InclusionProof) Verify(root []byte) error {
+	if p.Index >= p.TreeSize {
+		return fmt.Errorf("inclusion proof: index %d outside tree of size %d", p.Index, p.TreeSize)
+	}
+
+	fn, sn := p.Index, p.TreeSize-1
+	r := p.Entry.LeafHash()
+
+	for _, h := range p.Hashes {
+		if sn == 0 {
+			return errors.New("inclusion proof: too many hashes")
+		}
+
+		if fn&1 == 1 || fn == sn {
+			r = hashChildren(h, r)
+
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hashChildren(r, h)
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 || !bytes.Equal(r, root) {
+		return fmt.Errorf("inclusion proof: %s@%s does not match the tree head", p.Entry.Name, p.Entry.Version)
+	}
+
+	return nil
+}
+
+// Verify checks the proof against the root hashes of the two trees.
+func (p ConsistencyProof) Verify(firstRoot, secondRoot []byte) error {
+	switch {
+	case p.First > p.Second:
+		return fmt.Errorf("consistency proof: tree shrank from %d to %d: %w", p.First, p.Second, ErrSplitView)
+	case p.First == p.Second:
+		if len(p.Hashes) != 0 || !bytes.Equal(firstRoot, secondRoot) {
+			return fmt.Errorf("consistency proof: different roots for tree size %d: %w", p.First, ErrSplitView)
+		}
+
+		return nil
+	case p.First == 0:
+		return nil
+	}
+
+	path := p.Hashes
+	if p.First&(p.First-1) == 0 {
+		path = append([][]byte{firstRoot}, path...)
+	}
+
+	if len(path) == 0 {
+		return fmt.Errorf("consistency proof: empty proof from %d to %d: %w", p.First, p.Second, ErrSplitView)
+	}
+
+	fn, sn := p.First-1, p.Second-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := path[0], path[0]
+
+	for _, c := range path[1:] {
+		if sn == 0 {
+			return fmt.Errorf("consistency proof: too many hashes: %w", ErrSplitView)
+		}
+
+		if fn&1 == 1 || fn == sn {
+			fr = hashChildren(c, fr)
+			sr = hashChildren(c, sr)
+
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = hashChildren(sr, c)
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 || !bytes.Equal(fr, firstRoot) || !bytes.Equal(sr, secondRoot) {
+		return fmt.Errorf("consistency proof: tree of size %d is not a prefix of tree of size %d: %w", p.First, p.Second, ErrSplitView)
+	}
+
+	return nil
+}
+
+// CheckConsistent verifies that two tree heads of the same log agree, using a
+// consistency proof from p when their sizes differ.
+func CheckConsistent(ctx context.Context, p TransparencyLogProvider, a, b SignedTreeHead) error {
+	if a.TreeSize > b.TreeSize {
+		a, b = b, a
+	}
+
+	if a.TreeSize == b.TreeSize {
+		if !bytes.Equal(a.RootHash, b.RootHash) {
+			return fmt.Errorf("two different trees of size %d: %w", a.TreeSize, ErrSplitView)
+		}
+
+		return nil
+	}
+
+	proof, err := p.ConsistencyProof(ctx, a.TreeSize, b.TreeSize)
+	if err != nil {
+		return fmt.Errorf("consistency proof %d -> %d: %w", a.TreeSize, b.TreeSize, err)
+	}
+
+	if proof.First != a.TreeSize || proof.Second != b.TreeSize {
+		return fmt.Errorf("consistency proof for %d -> %d answered for %d -> %d", a.TreeSize, b.TreeSize, proof.First, proof.Second)
+	}
+
+	return proof.Verify(a.RootHash, b.RootHash)
+}
+
+// VerifyLockfileInLog checks every lockfile entry against the registry's
+// transparency log: the tree head must be signed by a trusted log key and be
+// consistent with each of the previously seen heads, and each pinned CID must
+// be the one the log records for its name@version, with a valid inclusion
+// proof. It returns the verified head, which callers should keep for the
+// next check.
+func VerifyLockfileInLog(ctx context.Context, p TransparencyLogProvider, ts *TrustStore, lf Lockfile, seen ...SignedTreeHead) (SignedTreeHead, error) {
+	head, err := p.TreeHead(ctx)
+	if err != nil {
+		return SignedTreeHead{}, err
+	}
+
+	if err := ts.VerifyTreeHead(head); err != nil {
+		return SignedTreeHead{}, err
+	}
+
+	for _, prev := range seen {
+		if err := ts.VerifyTreeHead(prev); err != nil {
+			return SignedTreeHead{}, fmt.Errorf("previously seen %w", err)
+		}
+
+		if err := CheckConsistent(ctx, p, prev, head); err != nil {
+			return SignedTreeHead{}, err
+		}
+	}
+
+	for _, e := range lf.Entries {
+		proof, err := p.InclusionProof(ctx, e.Name, e.Version, head.TreeSize)
+		if err != nil {
+			return SignedTreeHead{}, fmt.Errorf("%s@%s: %w", e.Name, e.Version, err)
+		}
+
+		if proof.Entry.Name != e.Name || proof.Entry.Version != e.Version || proof.TreeSize != head.TreeSize {
+			return SignedTreeHead{}, fmt.Errorf("%s@%s: registry answered with a proof for %s@%s in tree %d", e.Name, e.Version, proof.Entry.Name, proof.Entry.Version, proof.TreeSize)
+		}
+
+		if proof.Entry.CID != e.CID {
+			return SignedTreeHead{}, fmt.Errorf("%s@%s: log records %s but lockfile pins %s", e.Name, e.Version, proof.Entry.CID, e.CID)
+		}
+
+		if err := proof.Verify(head.RootHash); err != nil {
+			return SignedTreeHead{}, err
+		}
+	}
+
+	return head, nil
+}
+
+func hashLeaf(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+
+	return h.Sum(nil)
+}
+
+func hashChildren(l, r []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(l)
+	h.Write(r)
+
+	return h.Sum(nil)
+}
+
+// splitPoint returns the largest power of two smaller than n (n > 1).
+func splitPoint(n uint64) uint64 {
+	k := uint64(1)
+	for k<<1 < n {
+		k <<= 1
+	}
+
+	return k
+}
+
+// merkleRoot computes MTH over leaf hashes.
+func merkleRoot(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+
+	k := splitPoint(uint64(len(leaves)))
+
+	return hashChildren(merkleRoot(leaves[:k]), merkleRoot(leaves[k:]))
+}
+
+// inclusionPath computes PATH(m, D[n]) from RFC 9162.
+func inclusionPath(m uint64, leaves [][]byte) [][]byte {
+	n := uint64(len(leaves))
+	if n <= 1 {
+		return nil
+	}
+
+	k := splitPoint(n)
+	if m < k {
+		return append(inclusionPath(m, leaves[:k]), merkleRoot(leaves[k:]))
+	}
+
+	return append(inclusionPath(m-k, leaves[k:]), merkleRoot(leaves[:k]))
+}
+
+// subproof computes SUBPROOF(m, D[n], b) from RFC 9162.
+func subproof(m uint64, leaves [][]byte, complete bool) [][]byte {
+	n := uint64(len(leaves))
+	if m == n {
+		if complete {
+			return nil
+		}
+
+		return [][]byte{merkleRoot(leaves)}
+	}
+
+	k := splitPoint(n)
+	if m <= k {
+		return append(subproof(m, leaves[:k], complete), merkleRoot(leaves[k:]))
+	}
+
+	return append(subproof(m-k, leaves[k:], false), merkleRoot(leaves[:k]))
+}
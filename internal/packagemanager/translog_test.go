@@ -0,0 +1,158 @@
+package packagemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestLog(t *testing.T, path string) (*TransparencyLog, *TrustStore) {
+	t.Helper()
+
+	pub, priv, err := GenerateEd25519Keypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := SelfSignRoot("log", pub, priv, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewTransparencyLog(path, priv, []Certificate{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := NewTrustStore()
+	ts.AddRoot(pub)
+
+	return l, ts
+}
+
+func logEntry(i int) LogEntry {
+	return LogEntry{Name: "pkg", Version: Version(fmt.Sprintf("1.0.%d", i)), CID: ComputeCID([]byte{byte(i)})}
+}
+
+func TestTransparencyLog_InclusionAndConsistencyProofs(t *testing.T) {
+	l, _ := newTestLog(t, "")
+
+	var roots [][]byte
+
+	for n := 1; n <= 17; n++ {
+		if _, err := l.Append(logEntry(n - 1)); err != nil {
+			t.Fatal(err)
+		}
+
+		roots = append(roots, merkleRoot(l.leaves))
+
+		for m := 0; m < n; m++ {
+			e := logEntry(m)
+
+			p, err := l.InclusionProof(e.Name, e.Version, uint64(n))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := p.Verify(roots[n-1]); err != nil {
+				t.Fatalf("inclusion %d/%d: %v", m, n, err)
+			}
+
+			p.Entry.CID = "oz1-forged"
+			if p.Verify(roots[n-1]) == nil {
+				t.Fatalf("forged inclusion %d/%d verified", m, n)
+			}
+		}
+	}
+
+	for second := 1; second <= 17; second++ {
+		for first := 1; first <= second; first++ {
+			p, err := l.ConsistencyProof(uint64(first), uint64(second))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := p.Verify(roots[first-1], roots[second-1]); err != nil {
+				t.Fatalf("consistency %d->%d: %v", first, second, err)
+			}
+
+			if first < second && p.Verify(roots[first-1], roots[first-1]) == nil {
+				t.Fatalf("consistency %d->%d verified against wrong root", first, second)
+			}
+		}
+	}
+
+	if _, err := l.Append(LogEntry{Name: "pkg", Version: "1.0.0", CID: "oz1-other"}); err == nil {
+		t.Fatalf("expected re-logging a version with different content to fail")
+	}
+}
+
+func TestTransparencyLog_DetectsSplitView(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	honest, ts := newTestLog(t, filepath.Join(dir, "entries.jsonl"))
+	reg := NewLoggedRegistry(NewInMemoryRegistry(), honest)
+
+	var lock Lockfile
+
+	for _, v := range []Version{"1.0.0", "1.1.0", "1.2.0"} {
+		id, receipt, err := reg.PublishWithReceipt(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: v}, Data: []byte("lib-" + v)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := receipt.Inclusion.Verify(receipt.Head.RootHash); err != nil {
+			t.Fatalf("receipt: %v", err)
+		}
+
+		lock.Entries = append(lock.Entries, LockEntry{Name: "lib", Version: v, CID: id})
+	}
+
+	seen, err := VerifyLockfileInLog(ctx, reg, ts, lock)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// The log survives a restart and keeps growing consistently.
+	reopened, err := NewTransparencyLog(filepath.Join(dir, "entries.jsonl"), honest.signer, honest.chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg = NewLoggedRegistry(NewInMemoryRegistry(), reopened)
+	if _, err := reg.Publish(ctx, PackageBlob{Manifest: PackageManifest{Name: "lib", Version: "2.0.0"}, Data: []byte("lib-2")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyLockfileInLog(ctx, reg, ts, lock, seen); err != nil {
+		t.Fatalf("verify after growth: %v", err)
+	}
+
+	// A forked log shows another user a different 1.1.0 under the same key.
+	forked, _ := newTestLog(t, "")
+	forked.signer, forked.chain = honest.signer, honest.chain
+
+	for _, e := range []LogEntry{lockLogEntry(lock.Entries[0]), {Name: "lib", Version: "1.1.0", CID: "oz1-evil"}, lockLogEntry(lock.Entries[2])} {
+		if _, err := forked.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = VerifyLockfileInLog(ctx, NewLoggedRegistry(NewInMemoryRegistry(), forked), ts, lock, seen)
+	if !errors.Is(err, ErrSplitView) {
+		t.Fatalf("expected split view, got %v", err)
+	}
+
+	lock.Entries[1].CID = "oz1-evil"
+	if _, err := VerifyLockfileInLog(ctx, reg, ts, lock); err == nil {
+		t.Fatalf("expected lockfile CID that differs from the log to fail")
+	}
+}
+
+func lockLogEntry(e LockEntry) LogEntry {
+	return LogEntry{Name: e.Name, Version: e.Version, CID: e.CID}
+}
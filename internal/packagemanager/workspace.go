@@ -0,0 +1,364 @@
+package packagemanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// ManifestFile is the file name of a package manifest inside a package directory.
+const ManifestFile = "orizon.json"
+
+// workspaceManifest is the subset of orizon.json read when loading a workspace.
+type workspaceManifest struct {
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	Workspace    *struct {
+		Members []string `json:"members"`
+	} `json:"workspace,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// PathDependency is a dependency on another local package directory.
+type PathDependency struct {
+	Name PackageID
+	// Dir is the dependency directory relative to the workspace root, slash-separated.
+	Dir string
+}
+
+// WorkspaceMember is one local package of a workspace.
+type WorkspaceMember struct {
+	Name    PackageID
+	Version Version
+	// Dir is the member directory relative to the workspace root, slash-separated.
+	Dir string
+	// Dependencies are the registry dependencies declared by the member.
+	Dependencies []Dependency
+	// PathDependencies point at other members.
+	PathDependencies []PathDependency
+}
+
+// Workspace is a set of local packages that are resolved together against
+// the registry and share one lockfile at the workspace root.
+type Workspace struct {
+	byName  map[PackageID]*WorkspaceMember
+	Root    string
+	Members []*WorkspaceMember
+}
+
+// IsPathDependency reports whether a manifest dependency spec is a local path
+// ("./x", "../x" or an absolute path) rather than a version constraint.
+func IsPathDependency(spec string) bool {
+	return strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || filepath.IsAbs(spec)
+}
+
+// LoadWorkspace reads the manifest at root and every package reachable from it:
+// the members listed (as glob patterns) in its "workspace" section and,
+// transitively, the targets of path dependencies. The root manifest is itself
+// a member when it names a package or declares dependencies.
+func LoadWorkspace(root string) (*Workspace, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{Root: abs, byName: make(map[PackageID]*WorkspaceMember)}
+
+	rootMan, err := readWorkspaceManifest(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]bool)
+	queue := []string{}
+
+	if rootMan.Name != "" || len(rootMan.Dependencies) > 0 {
+		queue = append(queue, abs)
+	}
+
+	if rootMan.Workspace != nil {
+		for _, pattern := range rootMan.Workspace.Members {
+			matches, err := filepath.Glob(filepath.Join(abs, filepath.FromSlash(pattern)))
+			if err != nil {
+				return nil, fmt.Errorf("workspace member pattern %q: %w", pattern, err)
+			}
+
+			sort.Strings(matches)
+
+			for _, m := range matches {
+				if _, err := os.Stat(filepath.Join(m, ManifestFile)); err == nil {
+					queue = append(queue, m)
+				}
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+
+		if dirs[dir] {
+			continue
+		}
+
+		dirs[dir] = true
+
+		man := rootMan
+		if dir != abs {
+			if man, err = readWorkspaceManifest(dir); err != nil {
+				return nil, err
+			}
+		}
+
+		member, targets, err := w.newMember(dir, man)
+		if err != nil {
+			return nil, err
+		}
+
+		if prev, ok := w.byName[member.Name]; ok {
+			return nil, fmt.Errorf("workspace: package %s is defined in both %s and %s", member.Name, prev.Dir, member.Dir)
+		}
+
+		w.byName[member.Name] = member
+		w.Members = append(w.Members, member)
+		queue = append(queue, targets...)
+	}
+
+	sort.Slice(w.Members, func(i, j int) bool { return w.Members[i].Name < w.Members[j].Name })
+
+	// Path dependencies must name the package found at the path.
+	for _, m := range w.Members {
+		for _, pd := range m.PathDependencies {
+			target := w.memberAt(pd.Dir)
+			if target == nil || target.Name != pd.Name {
+				return nil, fmt.Errorf("workspace: %s depends on %s at %s, which contains a different package", m.Name, pd.Name, pd.Dir)
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// FindWorkspaceRoot returns the root of the workspace that dir belongs to:
+// the nearest directory, dir itself or one of its parents, whose manifest
+// has a "workspace" section listing dir (or that is dir). When no such
+// directory exists dir is its own root. The result is absolute.
+func FindWorkspaceRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for cur := abs; ; {
+		if _, err := os.Stat(filepath.Join(cur, ManifestFile)); err == nil {
+			man, err := readWorkspaceManifest(cur)
+			if err != nil {
+				return "", err
+			}
+
+			if man.Workspace != nil && (cur == abs || man.lists(cur, abs)) {
+				return cur, nil
+			}
+		}
+
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return abs, nil
+		}
+
+		cur = parent
+	}
+}
+
+// lists reports whether one of the member patterns of the workspace rooted
+// at root matches dir.
+func (man workspaceManifest) lists(root, dir string) bool {
+	for _, pattern := range man.Workspace.Members {
+		if ok, _ := filepath.Match(filepath.Join(root, filepath.FromSlash(pattern)), dir); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readWorkspaceManifest(dir string) (workspaceManifest, error) {
+	var man workspaceManifest
+
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return man, fmt.Errorf("workspace: no %s in %s", ManifestFile, dir)
+		}
+
+		return man, err
+	}
+
+	if err := json.Unmarshal(b, &man); err != nil {
+		return man, fmt.Errorf("workspace: parse %s: %w", filepath.Join(dir, ManifestFile), err)
+	}
+
+	return man, nil
+}
+
+// newMember converts a manifest into a member and returns the absolute
+// directories of its path dependencies.
+func (w *Workspace) newMember(dir string, man workspaceManifest) (*WorkspaceMember, []string, error) {
+	rel, err := filepath.Rel(w.Root, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := &WorkspaceMember{Name: PackageID(man.Name), Version: Version(man.Version), Dir: filepath.ToSlash(rel)}
+	if m.Name == "" {
+		return nil, nil, fmt.Errorf("workspace: package in %s has no name", m.Dir)
+	}
+
+	if m.Version == "" {
+		m.Version = "0.0.0"
+	}
+
+	if _, err := semver.NewVersion(string(m.Version)); err != nil {
+		return nil, nil, fmt.Errorf("workspace: %s: invalid version %q", m.Name, m.Version)
+	}
+
+	names := make([]string, 0, len(man.Dependencies))
+	for name := range man.Dependencies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var targets []string
+
+	for _, name := range names {
+		spec := man.Dependencies[name]
+		if !IsPathDependency(spec) {
+			m.Dependencies = append(m.Dependencies, Dependency{Name: PackageID(name), Constraint: spec})
+
+			continue
+		}
+
+		target := filepath.Clean(filepath.Join(dir, filepath.FromSlash(spec)))
+		if filepath.IsAbs(spec) {
+			target = filepath.Clean(spec)
+		}
+
+		targetRel, err := filepath.Rel(w.Root, target)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m.PathDependencies = append(m.PathDependencies, PathDependency{Name: PackageID(name), Dir: filepath.ToSlash(targetRel)})
+		targets = append(targets, target)
+	}
+
+	return m, targets, nil
+}
+
+func (w *Workspace) memberAt(dir string) *WorkspaceMember {
+	for _, m := range w.Members {
+		if m.Dir == dir {
+			return m
+		}
+	}
+
+	return nil
+}
+
+// Member returns the member with the given name.
+func (w *Workspace) Member(name PackageID) (*WorkspaceMember, bool) {
+	m, ok := w.byName[name]
+
+	return m, ok
+}
+
+// Index returns the members as resolver entries. Path dependencies become
+// exact requirements on the member's local version.
+func (w *Workspace) Index() PackageIndex {
+	idx := make(PackageIndex, len(w.Members))
+
+	for _, m := range w.Members {
+		deps := append([]Dependency(nil), m.Dependencies...)
+		for _, pd := range m.PathDependencies {
+			target := w.byName[pd.Name]
+			deps = append(deps, Dependency{Name: pd.Name, Constraint: "=" + string(target.Version)})
+		}
+
+		idx[m.Name] = []PackageVersion{{Name: m.Name, Version: m.Version, Dependencies: deps}}
+	}
+
+	return idx
+}
+
+// Requirements pins every member to its local version, so resolving them
+// resolves the registry dependencies of the whole workspace at once.
+func (w *Workspace) Requirements() []Requirement {
+	reqs := make([]Requirement, 0, len(w.Members))
+	for _, m := range w.Members {
+		reqs = append(reqs, Requirement{Name: m.Name, Constraint: "=" + string(m.Version)})
+	}
+
+	return reqs
+}
+
+// RegistryDependencies returns the registry dependencies declared by any
+// member, with the constraints of all members that use a package combined.
+func (w *Workspace) RegistryDependencies() map[PackageID]string {
+	out := make(map[PackageID]string)
+
+	for _, m := range w.Members {
+		for _, d := range m.Dependencies {
+			if _, local := w.byName[d.Name]; local {
+				continue
+			}
+
+			switch prev, ok := out[d.Name]; {
+			case !ok:
+				out[d.Name] = d.Constraint
+			case !strings.Contains(", "+prev+",", ", "+d.Constraint+","):
+				out[d.Name] = prev + ", " + d.Constraint
+			}
+		}
+	}
+
+	return out
+}
+
+// PublishDependencies returns the dependencies of member name as they are
+// published: path dependencies are rewritten to caret requirements on the
+// target member's current version.
+func (w *Workspace) PublishDependencies(name PackageID) ([]Dependency, error) {
+	m, ok := w.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("workspace: no member %s", name)
+	}
+
+	deps := append([]Dependency(nil), m.Dependencies...)
+	for _, pd := range m.PathDependencies {
+		deps = append(deps, Dependency{Name: pd.Name, Constraint: "^" + string(w.byName[pd.Name].Version)})
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+
+	return deps, nil
+}
+
+// ResolveWorkspace resolves the registry dependencies of all members together;
+// extra adds requirements such as lockfile pins. Members take precedence over
+// registry packages of the same name and are not part of the result, which
+// lists only the registry packages to lock.
+func (m *Manager) ResolveWorkspace(ctx context.Context, w *Workspace, extra []Requirement, preferHigher bool) (map[PackageID]struct {
+	Version Version
+	CID     CID
+}, error,
+) {
+	return m.resolveAndFetch(ctx, append(w.Requirements(), extra...), w.Index(), preferHigher)
+}
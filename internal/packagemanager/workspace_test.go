@@ -0,0 +1,110 @@
+package packagemanager
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspace_ResolvesMembersTogether(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"orizon.json":                `{"workspace":{"members":["packages/*"]}}`,
+		"packages/app/orizon.json":   `{"name":"app","version":"0.3.0","dependencies":{"core":"../core","json":"^1.0.0"}}`,
+		"packages/core/orizon.json":  `{"name":"core","version":"1.4.0","dependencies":{"json":">=1.1.0","util":"../../libs/util"}}`,
+		"libs/util/orizon.json":      `{"name":"util","version":"0.1.0"}`,
+		"packages/notes/README.md":   "not a package",
+		"packages/tools/orizon.json": `{"name":"tools","version":"0.0.1","dependencies":{"log":"~2.0.0"}}`,
+	})
+
+	ws, err := LoadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ws.Members) != 4 {
+		t.Fatalf("expected 4 members (util via path), got %d", len(ws.Members))
+	}
+
+	reg := NewInMemoryRegistry()
+	ctx := context.Background()
+
+	for _, mf := range []PackageManifest{
+		{Name: "json", Version: "1.0.0"},
+		{Name: "json", Version: "1.2.0"},
+		{Name: "json", Version: "2.0.0"},
+		{Name: "log", Version: "2.0.3"},
+		// A registry package that shares a member's name must not be used.
+		{Name: "core", Version: "9.0.0", Dependencies: []Dependency{{Name: "missing", Constraint: "*"}}},
+	} {
+		if _, err := reg.Publish(ctx, PackageBlob{Manifest: mf, Data: []byte(string(mf.Name) + string(mf.Version))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := NewManager(reg).ResolveWorkspace(ctx, ws, nil, true)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	if len(res) != 2 || res["json"].Version != "1.2.0" || res["log"].Version != "2.0.3" {
+		t.Fatalf("unexpected resolution: %v", res)
+	}
+
+	pinned, err := NewManager(reg).ResolveWorkspace(ctx, ws, []Requirement{{Name: "json", Constraint: "=1.0.0"}}, true)
+	if err == nil {
+		t.Fatalf("expected pin outside core's range to fail, got %v", pinned)
+	}
+
+	deps := ws.RegistryDependencies()
+	if deps["json"] != "^1.0.0, >=1.1.0" || deps["log"] != "~2.0.0" || len(deps) != 2 {
+		t.Fatalf("unexpected registry dependencies: %v", deps)
+	}
+
+	pub, err := ws.PublishDependencies("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pub) != 2 || pub[0].Name != "core" || pub[0].Constraint != "^1.4.0" {
+		t.Fatalf("path dependency not rewritten: %+v", pub)
+	}
+}
+
+func TestWorkspace_PathDependencyNameMismatch(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"orizon.json":     `{"name":"app","version":"1.0.0","dependencies":{"core":"./lib"}}`,
+		"lib/orizon.json": `{"name":"other","version":"1.0.0"}`,
+	})
+
+	if _, err := LoadWorkspace(root); err == nil {
+		t.Fatalf("expected mismatch between dependency name and package at path")
+	}
+}
+
+func TestWorkspace_FindRootFromMember(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"orizon.json":                `{"workspace":{"members":["packages/*"]}}`,
+		"packages/app/orizon.json":   `{"name":"app","version":"0.3.0","dependencies":{"json":"^1.0.0"}}`,
+		"packages/app/src/main.oriz": "",
+		"tools/gen/orizon.json":      `{"name":"gen","version":"0.1.0"}`,
+	})
+
+	for dir, want := range map[string]string{
+		".":                root,
+		"packages/app":     root,
+		"packages/app/src": filepath.Join(root, "packages/app", "src"),
+		"tools/gen":        filepath.Join(root, "tools", "gen"),
+	} {
+		got, err := FindWorkspaceRoot(filepath.Join(root, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("%s: root %s, want %s", dir, got, want)
+		}
+	}
+}
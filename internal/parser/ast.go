@@ -102,6 +102,7 @@ type FunctionDeclaration struct {
 	Parameters  []*Parameter
 	Generics    []*GenericParameter
 	WhereClause []*WherePredicate
+	Attributes  []string // names of the #[...] attributes in front of the function, e.g. "test"
 	Span        Span
 	IsPublic    bool
 	IsAsync     bool
@@ -0,0 +1,226 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/lexer"
+)
+
+// CfgSet holds the configuration flags that #[cfg(...)] attributes test:
+// bare names such as "unix" and key/value pairs such as `feature="json"`.
+type CfgSet map[string]bool
+
+// NewCfgSet builds a CfgSet from flags written as name, name="value" or
+// name=value.
+func NewCfgSet(flags ...string) CfgSet {
+	cfg := make(CfgSet, len(flags))
+
+	for _, f := range flags {
+		name, value, ok := strings.Cut(strings.TrimSpace(f), "=")
+		if !ok {
+			cfg[name] = true
+
+			continue
+		}
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		cfg[cfgKey(strings.TrimSpace(name), value)] = true
+	}
+
+	return cfg
+}
+
+// FeatureCfg returns a CfgSet enabling the given package features.
+func FeatureCfg(features ...string) CfgSet {
+	cfg := make(CfgSet, len(features))
+	for _, f := range features {
+		cfg[cfgKey("feature", f)] = true
+	}
+
+	return cfg
+}
+
+func cfgKey(name, value string) string {
+	return name + "=" + strconv.Quote(value)
+}
+
+// SetCfg sets the flags that #[cfg(...)] attributes on declarations are
+// evaluated against. Without flags every cfg predicate on a name is false.
+func (p *Parser) SetCfg(cfg CfgSet) {
+	p.cfg = cfg
+}
+
+// parseOuterAttributes parses the #[...] attributes in front of a
+// declaration, starting at '#', and leaves the parser on the first token of
+// the declaration. It returns the names of the attributes other than cfg and
+// reports whether all cfg attributes hold; ok is false after a syntax error.
+// Arguments of other attributes are skipped.
+func (p *Parser) parseOuterAttributes() (attrs []string, active bool, ok bool) {
+	active = true
+
+	for p.currentTokenIs(lexer.TokenHash) && p.peekTokenIs(lexer.TokenLBracket) {
+		p.nextToken()
+
+		if !p.expectPeek(lexer.TokenIdentifier) {
+			p.addError(TokenToPosition(p.current), "expected identifier in attribute", "attribute parsing")
+
+			return nil, false, false
+		}
+
+		if p.current.Literal == "cfg" {
+			if !p.expectPeek(lexer.TokenLParen) {
+				p.addError(TokenToPosition(p.current), "expected '(' after cfg", "attribute parsing")
+
+				return nil, false, false
+			}
+
+			p.nextToken()
+
+			holds, ok := p.parseCfgPredicate()
+			if !ok {
+				return nil, false, false
+			}
+
+			if !p.expectPeek(lexer.TokenRParen) {
+				p.addError(TokenToPosition(p.current), "expected ')' to close cfg", "attribute parsing")
+
+				return nil, false, false
+			}
+
+			active = active && holds
+		} else {
+			attrs = append(attrs, p.current.Literal)
+
+			if !p.skipAttributeArguments() {
+				return nil, false, false
+			}
+		}
+
+		if !p.expectPeek(lexer.TokenRBracket) {
+			p.addError(TokenToPosition(p.current), "expected ']' to close attribute", "attribute parsing")
+
+			return nil, false, false
+		}
+
+		p.nextToken()
+
+		for p.currentTokenIs(lexer.TokenNewline) {
+			p.nextToken()
+		}
+	}
+
+	return attrs, active, true
+}
+
+// skipAttributeArguments skips a parenthesized argument list after an
+// attribute name, if there is one.
+func (p *Parser) skipAttributeArguments() bool {
+	if !p.peekTokenIs(lexer.TokenLParen) {
+		return true
+	}
+
+	depth := 0
+
+	for {
+		p.nextToken()
+
+		switch p.current.Type {
+		case lexer.TokenLParen:
+			depth++
+		case lexer.TokenRParen:
+			depth--
+			if depth == 0 {
+				return true
+			}
+		case lexer.TokenEOF:
+			p.addError(TokenToPosition(p.current), "unterminated attribute arguments", "attribute parsing")
+
+			return false
+		}
+	}
+}
+
+// parseCfgPredicate evaluates one cfg predicate starting at the current
+// token: name, name = "value", not(p), all(p, ...) or any(p, ...).
+func (p *Parser) parseCfgPredicate() (bool, bool) {
+	if !p.currentTokenIs(lexer.TokenIdentifier) {
+		p.addError(TokenToPosition(p.current), "expected cfg predicate", "attribute parsing")
+
+		return false, false
+	}
+
+	name := p.current.Literal
+
+	switch {
+	case p.peekTokenIs(lexer.TokenLParen) && (name == "not" || name == "all" || name == "any"):
+		p.nextToken()
+
+		var args []bool
+
+		for !p.peekTokenIs(lexer.TokenRParen) {
+			p.nextToken()
+
+			v, ok := p.parseCfgPredicate()
+			if !ok {
+				return false, false
+			}
+
+			args = append(args, v)
+
+			if !p.peekTokenIs(lexer.TokenComma) {
+				break
+			}
+
+			p.nextToken()
+		}
+
+		if !p.expectPeek(lexer.TokenRParen) {
+			p.addError(TokenToPosition(p.current), "expected ')' in cfg predicate", "attribute parsing")
+
+			return false, false
+		}
+
+		switch name {
+		case "not":
+			if len(args) != 1 {
+				p.addError(TokenToPosition(p.current), "cfg not() takes exactly one predicate", "attribute parsing")
+
+				return false, false
+			}
+
+			return !args[0], true
+		case "all":
+			for _, v := range args {
+				if !v {
+					return false, true
+				}
+			}
+
+			return true, true
+		default:
+			for _, v := range args {
+				if v {
+					return true, true
+				}
+			}
+
+			return false, true
+		}
+	case p.peekTokenIs(lexer.TokenAssign):
+		p.nextToken()
+
+		if !p.expectPeek(lexer.TokenString) {
+			p.addError(TokenToPosition(p.current), "expected string value in cfg predicate", "attribute parsing")
+
+			return false, false
+		}
+
+		return p.cfg[cfgKey(name, p.current.Literal)], true
+	default:
+		return p.cfg[name], true
+	}
+}
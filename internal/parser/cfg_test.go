@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/orizon-lang/orizon/internal/lexer"
+)
+
+// Test that #[cfg(...)] attributes keep or drop the declaration that follows.
+func TestCfgAttributesOnDeclarations(t *testing.T) {
+	source := `
+#[cfg(feature = "net")]
+func connect() {}
+
+#[cfg(not(feature = "net"))]
+func offline() {}
+
+#[cfg(all(unix, any(feature = "json", feature = "yaml")))]
+#[inline]
+func decode() {}
+
+#[cfg(windows)]
+let handle = 1;
+
+func always() {}
+`
+
+	cases := []struct {
+		name string
+		cfg  CfgSet
+		want []string
+	}{
+		{name: "no flags", cfg: nil, want: []string{"offline", "always"}},
+		{name: "net", cfg: FeatureCfg("net"), want: []string{"connect", "always"}},
+		{name: "unix+yaml", cfg: NewCfgSet("unix", `feature="yaml"`), want: []string{"offline", "decode", "always"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewParser(lexer.NewWithFilename(source, "cfg.oriz"), "cfg.oriz")
+			p.SetCfg(tc.cfg)
+
+			prog, errs := p.Parse()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			var got []string
+
+			for _, d := range prog.Declarations {
+				fn, ok := d.(*FunctionDeclaration)
+				if !ok {
+					t.Fatalf("unexpected declaration %T", d)
+				}
+
+				got = append(got, fn.Name.Value)
+			}
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("declarations = %v, want %v", got, tc.want)
+			}
+
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("declarations = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCfgAttributeSyntaxError(t *testing.T) {
+	p := NewParser(lexer.NewWithFilename("#[cfg(not(a, b))]\nfunc f() {}\n", "bad.oriz"), "bad.oriz")
+
+	if _, errs := p.Parse(); len(errs) == 0 {
+		t.Fatalf("expected an error for not() with two predicates")
+	}
+}
+
+// Test that attributes other than cfg are recorded on the function they precede.
+func TestOuterAttributesRecordedOnFunctions(t *testing.T) {
+	source := "#[test]\n#[should_panic(expected = \"boom\")]\nfunc t() {}\n\n#[cfg(test)]\nfunc helper() {}\n"
+
+	p := NewParser(lexer.NewWithFilename(source, "attrs.oriz"), "attrs.oriz")
+	p.SetCfg(NewCfgSet("test"))
+
+	prog, errs := p.Parse()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if len(prog.Declarations) != 2 {
+		t.Fatalf("expected 2 declarations, got %d", len(prog.Declarations))
+	}
+
+	fn := prog.Declarations[0].(*FunctionDeclaration)
+	if len(fn.Attributes) != 2 || fn.Attributes[0] != "test" || fn.Attributes[1] != "should_panic" {
+		t.Fatalf("attributes = %v", fn.Attributes)
+	}
+
+	if helper := prog.Declarations[1].(*FunctionDeclaration); len(helper.Attributes) != 0 {
+		t.Fatalf("cfg should not be recorded, got %v", helper.Attributes)
+	}
+}
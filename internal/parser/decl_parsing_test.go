@@ -228,6 +228,28 @@ func TestDeclarationParsingAndHIR(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "Trait with self receivers",
+			source: "trait Store { func get(&self, k: int) -> int; func put(&mut self, k: int); func take(mut self) -> int; }",
+			check: func(t *testing.T, prog *Program, hir *HIRModule) {
+				td, ok := prog.Declarations[0].(*TraitDeclaration)
+				if !ok || len(td.Methods) != 3 {
+					t.Fatalf("expected trait with 3 methods, got %T", prog.Declarations[0])
+				}
+				want := []string{"&Self", "&mut Self", "Self"}
+				for i, m := range td.Methods {
+					if len(m.Parameters) == 0 || m.Parameters[0].Name.Value != "self" {
+						t.Fatalf("method %s: expected self receiver", m.Name.Value)
+					}
+					if got := m.Parameters[0].TypeSpec.String(); got != want[i] {
+						t.Fatalf("method %s: receiver type %s, want %s", m.Name.Value, got, want[i])
+					}
+				}
+				if !td.Methods[2].Parameters[0].IsMut {
+					t.Fatalf("expected mut self receiver on take")
+				}
+			},
+		},
 		{
 			name:   "Impl block with one method",
 			source: "impl Point { func norm() -> int { return 0; } }",
@@ -45,3 +45,34 @@ func TestParseLoopBasic(t *testing.T) {
 		t.Fatalf("expected true literal condition, got %#v", ws.Condition)
 	}
 }
+
+func TestParseForHeaderWithParenthesizedClauses(t *testing.T) {
+	input := `
+    func f() {
+        for (let i = 0; (i < n(3)); i = (i + 1)) {
+            g(i);
+        }
+    }`
+
+	p := NewParser(lexer.New(input), "test_for.oriz")
+
+	program, errs := p.Parse()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected parser errors: %v", errs)
+	}
+
+	fn := program.Declarations[0].(*FunctionDeclaration)
+
+	fs, ok := fn.Body.Statements[0].(*ForStatement)
+	if !ok {
+		t.Fatalf("expected ForStatement, got %T", fn.Body.Statements[0])
+	}
+
+	if fs.Init == nil || fs.Condition == nil || fs.Update == nil {
+		t.Fatalf("expected init, condition and update, got %#v", fs)
+	}
+
+	if len(fs.Body.Statements) != 1 {
+		t.Fatalf("expected 1 statement in body, got %d", len(fs.Body.Statements))
+	}
+}
@@ -37,6 +37,9 @@ type Parser struct {
 	slicePool     *sync.Pool // Pool for slices
 	identifierPool *sync.Pool // Pool for identifier nodes
 	literalPool    *sync.Pool // Pool for literal nodes
+
+	// cfg holds the flags #[cfg(...)] attributes are evaluated against
+	cfg CfgSet
 }
 
 // ParseError represents a parsing error with enhanced context and recovery hints.
@@ -706,9 +709,35 @@ func (p *Parser) parseProgram() *Program {
 			continue
 		}
 
+		// Leading #[cfg(...)] attributes decide whether the declaration is kept;
+		// an inactive declaration is still parsed so that errors are reported.
+		// Other attributes such as #[test] are recorded on functions.
+		active := true
+
+		var attrs []string
+
+		if p.currentTokenIs(lexer.TokenHash) && p.peekTokenIs(lexer.TokenLBracket) {
+			var ok bool
+			if attrs, active, ok = p.parseOuterAttributes(); !ok {
+				p.skipToNextTopLevelDecl()
+
+				continue
+			}
+
+			if p.currentTokenIs(lexer.TokenEOF) {
+				break
+			}
+		}
+
 		// Parse declaration.
 		if decl := p.parseDeclaration(); decl != nil {
-			declarations = append(declarations, decl)
+			if fn, ok := decl.(*FunctionDeclaration); ok && len(attrs) > 0 {
+				fn.Attributes = attrs
+			}
+
+			if active {
+				declarations = append(declarations, decl)
+			}
 			// After a successful declaration, we're at the closing token of the decl.
 			// Advance once to move past it and continue.
 			p.nextToken()
@@ -2030,6 +2059,10 @@ func (p *Parser) parseParameterList() []*Parameter {
 // parseParameter parses a single parameter.
 func (p *Parser) parseParameter() *Parameter {
 	startPos := TokenToPosition(p.current)
+	// Method receivers: &self and &mut self.
+	if p.currentTokenIs(lexer.TokenBitAnd) {
+		return p.parseSelfReceiver(startPos)
+	}
 	// Optional 'mut' modifier on parameters.
 	isMut := false
 	if p.currentTokenIs(lexer.TokenMut) {
@@ -2038,6 +2071,18 @@ func (p *Parser) parseParameter() *Parameter {
 		p.nextToken()
 	}
 
+	// By-value receivers: self and mut self.
+	if p.currentTokenIs(lexer.TokenIdentifier) && p.current.Literal == "self" && !p.peekTokenIs(lexer.TokenColon) {
+		span := TokenToSpan(p.current)
+
+		return &Parameter{
+			Span:     SpanBetween(startPos, span.End),
+			Name:     NewIdentifier(span, "self"),
+			TypeSpec: &BasicType{Span: span, Name: "Self"},
+			IsMut:    isMut,
+		}
+	}
+
 	if !p.currentTokenIs(lexer.TokenIdentifier) {
 		p.addError(TokenToPosition(p.current),
 			"expected parameter name", "parameter parsing")
@@ -2065,6 +2110,32 @@ func (p *Parser) parseParameter() *Parameter {
 	}
 }
 
+// parseSelfReceiver parses '&self' or '&mut self' starting at '&' as a
+// parameter named self of type &Self or &mut Self.
+func (p *Parser) parseSelfReceiver(start Position) *Parameter {
+	isMut := false
+	if p.peekTokenIs(lexer.TokenMut) {
+		isMut = true
+
+		p.nextToken()
+	}
+
+	if !p.expectPeek(lexer.TokenIdentifier) || p.current.Literal != "self" {
+		p.addError(TokenToPosition(p.current), "expected 'self' after '&' in parameter list", "parameter parsing")
+
+		return nil
+	}
+
+	span := TokenToSpan(p.current)
+	self := &BasicType{Span: span, Name: "Self"}
+
+	return &Parameter{
+		Span:     SpanBetween(start, span.End),
+		Name:     NewIdentifier(span, "self"),
+		TypeSpec: &ReferenceType{Span: SpanBetween(start, span.End), Inner: self, IsMutable: isMut},
+	}
+}
+
 // parseVariableDeclaration parses a variable declaration.
 func (p *Parser) parseVariableDeclaration() *VariableDeclaration {
 	startPos := TokenToPosition(p.current)
@@ -3086,6 +3157,11 @@ func (p *Parser) parseForStatement() Statement {
 
 			if !p.currentTokenIs(lexer.TokenSemicolon) && !p.currentTokenIs(lexer.TokenRParen) {
 				cond = p.parseExpression(LOWEST)
+				// step onto the ';' here: a condition ending in ')' would
+				// otherwise be mistaken for the end of the header below.
+				if p.peekTokenIs(lexer.TokenSemicolon) {
+					p.nextToken()
+				}
 			}
 		}
 	}
@@ -3255,7 +3331,14 @@ func (p *Parser) parseSimpleStatementUntilRParen() Statement {
 		return stmt
 	default:
 		expr := p.parseExpression(LOWEST)
-		// advance until ')'.
+		// advance until ')'. The expression itself may end in ')', so look
+		// at the next token first.
+		if p.peekTokenIs(lexer.TokenRParen) {
+			p.nextToken()
+
+			return &ExpressionStatement{Span: TokenToSpan(p.current), Expression: expr}
+		}
+
 		for !p.currentTokenIs(lexer.TokenRParen) && !p.currentTokenIs(lexer.TokenEOF) {
 			if p.peekTokenIs(lexer.TokenRParen) {
 				p.nextToken()
@@ -4776,6 +4859,10 @@ func (p *Parser) parseArrayLiteral() Expression {
 
 // parseReferenceOrBitwiseExpression parses reference (&) or bitwise expressions.
 func (p *Parser) parseReferenceOrBitwiseExpression() Expression {
+	if p.current.Type == lexer.TokenBitAnd {
+		return p.parseReferenceExpression()
+	}
+
 	start := TokenToPosition(p.current)
 	operator := p.current.Literal
 
@@ -4793,6 +4880,27 @@ func (p *Parser) parseReferenceOrBitwiseExpression() Expression {
 	return NewIdentifier(span, operator+operand.String())
 }
 
+// parseReferenceExpression parses &x and &mut x as unary expressions with
+// the operator & or &mut.
+func (p *Parser) parseReferenceExpression() Expression {
+	start := TokenToPosition(p.current)
+	operator := NewOperator(TokenToSpan(p.current), "&", 0, RightAssociative, UnaryOp)
+
+	if p.peekTokenIs(lexer.TokenMut) {
+		p.nextToken()
+		operator.Value = "&mut"
+	}
+
+	p.nextToken()
+
+	operand := p.parseExpression(PREFIX)
+	if operand == nil {
+		return nil
+	}
+
+	return &UnaryExpression{Span: SpanBetween(start, operand.GetSpan().End), Operator: operator, Operand: operand}
+}
+
 // parseForExpression parses for expressions/statements as expressions.
 func (p *Parser) parseForExpression() Expression {
 	span := TokenToSpan(p.current)
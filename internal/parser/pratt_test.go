@@ -178,6 +178,21 @@ func TestUnaryOperators(t *testing.T) {
 			input:    "-a ** b;",
 			expected: "(-(a ** b))",
 		},
+		{
+			name:     "Reference",
+			input:    "&a.b;",
+			expected: "(&(a . b))",
+		},
+		{
+			name:     "Mutable reference",
+			input:    "&mut a.b;",
+			expected: "(&mut(a . b))",
+		},
+		{
+			name:     "Reference operand of bitwise and",
+			input:    "a & &b;",
+			expected: "(a & (&b))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -54,6 +54,7 @@ type ActorSystem struct {
 	}
 	cancel         context.CancelFunc
 	tracer         *MessageTracer
+	spans          *spanPipeline
 	scheduler      *ActorScheduler
 	dispatcher     *MessageDispatcher
 	registry       *ActorRegistry
@@ -92,7 +93,9 @@ type Actor struct {
 	State         ActorState
 	Type          ActorType
 	ID            ActorID
+	activeSpan    atomic.Pointer[SpanContext]
 	mutex         sync.RWMutex
+	dispatchMu    sync.Mutex
 	RestartCount  uint32
 }
 
@@ -169,6 +172,7 @@ type ActorScheduler struct {
 	ctx             context.Context
 	queues          map[ActorPriority]*ActorQueue
 	process         func(ActorID)
+	simulate        func(ActorID)
 	resolvePriority func(ActorID) ActorPriority
 	roundRobin      []ActorID
 	workers         []*SchedulerWorker
@@ -364,6 +368,9 @@ const (
 
 // Actor system configuration.
 type ActorSystemConfig struct {
+	// Simulation, when set, runs the system deterministically on the given
+	// simulator instead of worker goroutines and wall-clock tickers.
+	Simulation            *Simulator
 	DefaultIOWatchOptions IOWatchOptions
 	HeartbeatInterval     time.Duration
 	GCInterval            time.Duration
@@ -584,6 +591,7 @@ type (
 	ActorTimer      struct {
 		Callback func()
 		timer    *time.Timer
+		cancel   func() bool
 		ID       string
 		Interval time.Duration
 	}
@@ -595,22 +603,40 @@ func (ctx *ActorContext) StartTimer(id string, interval time.Duration, cb func()
 		ctx.Timers = make(map[string]*ActorTimer)
 	}
 	// Stop existing.
-	if t, ok := ctx.Timers[id]; ok && t != nil && t.timer != nil {
-		t.timer.Stop()
+	if t, ok := ctx.Timers[id]; ok && t != nil {
+		t.stop()
+	}
+
+	if ctx.System != nil && ctx.System.config.Simulation != nil {
+		cancel := ctx.System.config.Simulation.AfterFunc(interval, cb)
+		ctx.Timers[id] = &ActorTimer{ID: id, Interval: interval, Callback: cb, cancel: cancel}
+
+		return
 	}
 
 	timer := time.AfterFunc(interval, cb)
 	ctx.Timers[id] = &ActorTimer{ID: id, Interval: interval, Callback: cb, timer: timer}
 }
 
+// stop cancels the underlying wall-clock or simulated timer.
+func (t *ActorTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
 // StopTimer stops and removes a named timer.
 func (ctx *ActorContext) StopTimer(id string) {
 	if ctx.Timers == nil {
 		return
 	}
 
-	if t, ok := ctx.Timers[id]; ok && t != nil && t.timer != nil {
-		t.timer.Stop()
+	if t, ok := ctx.Timers[id]; ok && t != nil {
+		t.stop()
 	}
 
 	delete(ctx.Timers, id)
@@ -702,6 +728,11 @@ func NewActorSystem(config ActorSystemConfig) (*ActorSystem, error) {
 		LoadBalancingEnabled: true,
 	}
 	system.scheduler = NewActorScheduler(schedulerConfig)
+	if sim := config.Simulation; sim != nil {
+		system.scheduler.simulate = func(aid ActorID) {
+			sim.Enqueue(fmt.Sprintf("actor %d", aid), func() { system.scheduler.process(aid) })
+		}
+	}
 	// Wire scheduler worker callback to process actor mailboxes.
 	system.scheduler.process = func(aid ActorID) {
 		system.mutex.RLock()
@@ -711,12 +742,21 @@ func NewActorSystem(config ActorSystemConfig) (*ActorSystem, error) {
 		if actor == nil {
 			return
 		}
-		// Drain one message if available and process.
-		if msg, ok := actor.Mailbox.Dequeue(); ok {
-			if err := actor.ProcessMessage(msg); err != nil {
-				// Delegate to supervisor strategy.
-				system.handleFailure(actor, err)
-			}
+		// Drain one message if available and process. Dequeue and processing
+		// are serialized per actor so messages are handled in mailbox order
+		// even when several workers pick up the same actor.
+		actor.dispatchMu.Lock()
+		msg, ok := actor.Mailbox.Dequeue()
+
+		var err error
+		if ok {
+			err = actor.ProcessMessage(msg)
+		}
+		actor.dispatchMu.Unlock()
+
+		if err != nil {
+			// Delegate to supervisor strategy.
+			system.handleFailure(actor, err)
 		}
 	}
 
@@ -863,9 +903,11 @@ func (as *ActorSystem) Start() error {
 		return fmt.Errorf("actor system is already running")
 	}
 
-	// Start scheduler.
-	if err := as.scheduler.Start(as.ctx); err != nil {
-		return fmt.Errorf("failed to start scheduler: %w", err)
+	// Start scheduler. Simulated systems dispatch through the simulator instead.
+	if as.config.Simulation == nil {
+		if err := as.scheduler.Start(as.ctx); err != nil {
+			return fmt.Errorf("failed to start scheduler: %w", err)
+		}
 	}
 
 	// Start dispatcher.
@@ -882,6 +924,13 @@ func (as *ActorSystem) Start() error {
 
 	as.running = true
 
+	if sim := as.config.Simulation; sim != nil {
+		sim.every(as.config.HeartbeatInterval, "heartbeat", as.simulatedTick(as.checkHeartbeats))
+		sim.every(as.config.GCInterval, "gc", as.simulatedTick(as.performGC))
+
+		return nil
+	}
+
 	// Start system maintenance routines.
 	go as.runHeartbeatMonitor()
 	go as.runGarbageCollector()
@@ -889,6 +938,42 @@ func (as *ActorSystem) Start() error {
 	return nil
 }
 
+// simulatedTick wraps a maintenance function as a periodic simulator event
+// that stops re-arming once the system stops.
+func (as *ActorSystem) simulatedTick(fn func()) func() bool {
+	return func() bool {
+		as.mutex.RLock()
+		running := as.running
+		as.mutex.RUnlock()
+
+		if running {
+			fn()
+		}
+
+		return running
+	}
+}
+
+// afterFunc runs fn after d on the simulator when simulating, otherwise on a wall-clock timer.
+func (as *ActorSystem) afterFunc(d time.Duration, fn func()) {
+	if as.config.Simulation != nil {
+		as.config.Simulation.AfterFunc(d, fn)
+
+		return
+	}
+
+	time.AfterFunc(d, fn)
+}
+
+// Now returns the system clock: virtual time under simulation, wall time otherwise.
+func (as *ActorSystem) Now() time.Time {
+	if as != nil && as.config.Simulation != nil {
+		return as.config.Simulation.Now()
+	}
+
+	return time.Now()
+}
+
 // Stop stops the actor system.
 func (as *ActorSystem) Stop() error {
 	// Snapshot state under lock, but do not hold the lock while stopping components.
@@ -935,6 +1020,17 @@ func (as *ActorSystem) Stop() error {
 		dispatcher.Stop()
 	}
 
+	// Export spans still buffered before shutting down.
+	flushTimeout := as.config.ShutdownTimeout
+	if flushTimeout <= 0 {
+		flushTimeout = 5 * time.Second
+	}
+
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), flushTimeout)
+	_ = as.FlushSpans(flushCtx)
+
+	flushCancel()
+
 	// Cancel context to stop maintenance goroutines.
 	if cancel != nil {
 		cancel()
@@ -1383,7 +1479,7 @@ func (as *ActorSystem) SendMessageWithPriority(senderID, receiverID ActorID, mes
 		Receiver:   receiverID,
 		Payload:    payload,
 		Priority:   prio,
-		Timestamp:  time.Now(),
+		Timestamp:  as.Now(),
 		TTL:        time.Minute * 5,
 		Headers:    make(map[string]interface{}),
 		Persistent: false,
@@ -1435,6 +1531,9 @@ func (as *ActorSystem) CreateActor(name string, actorType ActorType, behavior Ac
 
 	// Set system reference.
 	actor.Context.System = as
+	if as.config.Simulation != nil {
+		actor.CreateTime, actor.LastHeartbeat = as.Now(), as.Now()
+	}
 
 	// Call PreStart.
 	if err := actor.Behavior.PreStart(actor.Context); err != nil {
@@ -1506,6 +1605,10 @@ func (as *ActorSystem) CreateActorUnder(supervisor *Supervisor, name string, act
 	}
 
 	actor.Context.System = as
+	if as.config.Simulation != nil {
+		actor.CreateTime, actor.LastHeartbeat = as.Now(), as.Now()
+	}
+
 	if err := actor.Behavior.PreStart(actor.Context); err != nil {
 		return nil, fmt.Errorf("PreStart failed: %w", err)
 	}
@@ -1530,7 +1633,7 @@ func (as *ActorSystem) SendMessage(senderID, receiverID ActorID, messageType Mes
 		Receiver:   receiverID,
 		Payload:    payload,
 		Priority:   NormalPriority,
-		Timestamp:  time.Now(),
+		Timestamp:  as.Now(),
 		TTL:        time.Minute * 5,
 		Headers:    make(map[string]interface{}),
 		Persistent: false,
@@ -1540,6 +1643,34 @@ func (as *ActorSystem) SendMessage(senderID, receiverID ActorID, messageType Mes
 	return as.deliverMessage(message)
 }
 
+// SendMessageWithHeaders sends a message carrying the given string headers,
+// e.g. a traceparent received from a remote node.
+func (as *ActorSystem) SendMessageWithHeaders(senderID, receiverID ActorID, messageType MessageType, payload interface{}, headers map[string]string) error {
+	if !as.running {
+		return fmt.Errorf("actor system is not running")
+	}
+
+	message := Message{
+		ID:         MessageID(atomic.AddUint64(&globalMessageID, 1)),
+		Type:       messageType,
+		Sender:     senderID,
+		Receiver:   receiverID,
+		Payload:    payload,
+		Priority:   NormalPriority,
+		Timestamp:  as.Now(),
+		TTL:        time.Minute * 5,
+		Headers:    make(map[string]interface{}, len(headers)),
+		Persistent: false,
+		Delivered:  false,
+	}
+
+	for k, v := range headers {
+		message.Headers[k] = v
+	}
+
+	return as.deliverMessage(message)
+}
+
 // SendToName delivers a message to an actor by its registered name. If a Remote is attached and
 // the name is qualified as node:name (e.g., "nodeA:svc"), it will attempt remote delivery.
 func (as *ActorSystem) SendToName(senderID ActorID, qualifiedName string, messageType MessageType, payload interface{}) error {
@@ -1551,6 +1682,15 @@ func (as *ActorSystem) SendToName(senderID ActorID, qualifiedName string, messag
 		node := qualifiedName[:idx]
 		name := qualifiedName[idx+1:]
 
+		// Propagate the sender's span when the transport can carry headers.
+		if hs, ok := as.Remote.(interface {
+			SendWithHeaders(remoteAddrOrNode, receiverName string, msgType uint32, payload interface{}, headers map[string]string) error
+		}); ok {
+			if tp, ok := as.TraceparentFor(senderID); ok {
+				return hs.SendWithHeaders(node, name, uint32(messageType), payload, map[string]string{TraceparentHeader: tp})
+			}
+		}
+
 		return as.Remote.Send(node, name, uint32(messageType), payload)
 	}
 	// Local lookup.
@@ -1589,10 +1729,12 @@ func (a *Actor) ProcessMessage(msg Message) error {
 	a.Context.Sender = msg.Sender
 
 	// Update heartbeat before processing.
-	a.LastHeartbeat = time.Now()
+	a.LastHeartbeat = a.Context.System.Now()
 
 	// Process message.
+	span := a.Context.System.beginSpan(a, msg)
 	err := a.Behavior.Receive(a.Context, msg)
+	a.Context.System.endSpan(a, span, err)
 
 	// Update statistics.
 	a.Statistics.MessagesReceived++
@@ -1602,7 +1744,7 @@ func (a *Actor) ProcessMessage(msg Message) error {
 		a.Statistics.MessagesProcessed++
 	}
 
-	a.Statistics.LastActivity = time.Now()
+	a.Statistics.LastActivity = a.Context.System.Now()
 	a.State = ActorIdle
 
 	return err
@@ -1667,7 +1809,7 @@ func (as *ActorSystem) handleFailure(failed *Actor, reason error) {
 
 			if d := failed.Config.RestartDelay; d > 0 {
 				// Schedule asynchronous restart to avoid blocking supervisor loop.
-				time.AfterFunc(d, func() { _ = failed.Restart(reason) })
+				as.afterFunc(d, func() { _ = failed.Restart(reason) })
 			} else {
 				_ = failed.Restart(reason)
 			}
@@ -1681,7 +1823,7 @@ func (as *ActorSystem) handleFailure(failed *Actor, reason error) {
 				}
 
 				if d := child.Config.RestartDelay; d > 0 {
-					time.AfterFunc(d, func(ch *Actor) func() {
+					as.afterFunc(d, func(ch *Actor) func() {
 						return func() { _ = ch.Restart(reason) }
 					}(child))
 				} else {
@@ -1710,7 +1852,7 @@ func (as *ActorSystem) handleFailure(failed *Actor, reason error) {
 						}
 
 						if d := c.Config.RestartDelay; d > 0 {
-							time.AfterFunc(d, func(act *Actor) func() {
+							as.afterFunc(d, func(act *Actor) func() {
 								return func() { _ = act.Restart(reason) }
 							}(c))
 						} else {
@@ -1723,7 +1865,7 @@ func (as *ActorSystem) handleFailure(failed *Actor, reason error) {
 					_ = as.stopActor(failed)
 				} else {
 					if d := failed.Config.RestartDelay; d > 0 {
-						time.AfterFunc(d, func() { _ = failed.Restart(reason) })
+						as.afterFunc(d, func() { _ = failed.Restart(reason) })
 					} else {
 						_ = failed.Restart(reason)
 					}
@@ -1734,7 +1876,7 @@ func (as *ActorSystem) handleFailure(failed *Actor, reason error) {
 				_ = as.stopActor(failed)
 			} else {
 				if d := failed.Config.RestartDelay; d > 0 {
-					time.AfterFunc(d, func() { _ = failed.Restart(reason) })
+					as.afterFunc(d, func() { _ = failed.Restart(reason) })
 				} else {
 					_ = failed.Restart(reason)
 				}
@@ -1799,7 +1941,7 @@ func (as *ActorSystem) canRestart(sup *Supervisor, child *Actor) bool {
 	}
 
 	hist := sup.restartTrack[child.ID]
-	now := time.Now()
+	now := as.Now()
 	cutoff := now.Add(-sup.RetryPeriod)
 	filtered := hist[:0]
 
@@ -1925,6 +2067,8 @@ func (m *Mailbox) Len() int {
 
 // deliverMessage delivers a message to its destination.
 func (as *ActorSystem) deliverMessage(msg Message) error {
+	as.propagateSpan(&msg)
+
 	// Interceptors / transformers and routing
 	as.dispatcher.mutex.RLock()
 	interceptors := append([]MessageInterceptor(nil), as.dispatcher.interceptors...)
@@ -2120,6 +2264,41 @@ func (as *ActorSystem) stopActor(actor *Actor) error {
 	return nil
 }
 
+// StopActor stops an actor, detaches it from its supervisor and releases its
+// registered name so that a new actor may later be created under the same name.
+func (as *ActorSystem) StopActor(id ActorID) error {
+	as.mutex.Lock()
+	actor, exists := as.actors[id]
+
+	if exists {
+		delete(as.actors, id)
+
+		if actor.Mailbox != nil {
+			delete(as.mailboxes, actor.Mailbox.ID)
+		}
+
+		if sup := actor.Supervisor; sup != nil {
+			delete(sup.Children, id)
+
+			for i, cid := range sup.childOrder {
+				if cid == id {
+					sup.childOrder = append(sup.childOrder[:i], sup.childOrder[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	as.mutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("actor not found: %d", id)
+	}
+
+	as.registry.Unregister(actor.Name, id)
+
+	return as.stopActor(actor)
+}
+
 // Watch registers the current actor as a watcher of the target actor. When the
 // target terminates, a SystemTerminated message with payload=targetID is sent.
 // to the watcher.
@@ -2200,15 +2379,30 @@ func (as *ActorSystem) runGarbageCollector() {
 
 // checkHeartbeats checks actor heartbeats.
 func (as *ActorSystem) checkHeartbeats() {
-	now := time.Now()
+	now := as.Now()
 	timeout := as.config.HeartbeatInterval * 3
 
 	as.mutex.RLock()
-	defer as.mutex.RUnlock()
+
+	var stale []*Actor
 
 	for _, actor := range as.actors {
-		if now.Sub(actor.LastHeartbeat) > timeout {
-			// Actor may be dead, handle accordingly.
+		actor.mutex.RLock()
+		last := actor.LastHeartbeat
+		actor.mutex.RUnlock()
+
+		if now.Sub(last) > timeout {
+			stale = append(stale, actor)
+		}
+	}
+	as.mutex.RUnlock()
+
+	for _, actor := range stale {
+		// Actor may be dead, handle accordingly. Simulated systems stay on the
+		// simulator goroutine so the run remains deterministic.
+		if as.config.Simulation != nil {
+			as.handleDeadActor(actor)
+		} else {
 			go as.handleDeadActor(actor)
 		}
 	}
@@ -2364,6 +2558,17 @@ func (ar *ActorRegistry) Register(name string, actorID ActorID) error {
 	return nil
 }
 
+// Unregister removes name if it is still bound to actorID.
+func (ar *ActorRegistry) Unregister(name string, actorID ActorID) {
+	ar.mutex.Lock()
+	defer ar.mutex.Unlock()
+
+	if id, ok := ar.nameToID[name]; ok && id == actorID {
+		delete(ar.nameToID, name)
+		ar.statistics.Evictions++
+	}
+}
+
 func (ar *ActorRegistry) Lookup(name string) (ActorID, bool) {
 	ar.mutex.RLock()
 	defer ar.mutex.RUnlock()
@@ -2454,6 +2659,12 @@ func (as *ActorScheduler) ScheduleWithAffinity(actorID ActorID, cpuMask uint64)
 }
 
 func (as *ActorScheduler) scheduleInternal(actorID ActorID, actorMask uint64) {
+	if as.simulate != nil {
+		as.simulate(actorID)
+
+		return
+	}
+
 	if !as.running {
 		return
 	}
@@ -1070,3 +1070,42 @@ func TestRegistry_Lookup(t *testing.T) {
 		t.Fatalf("lookup mismatch: %v %v", got, ok)
 	}
 }
+
+func TestActorSystem_StopActor_ReleasesName(t *testing.T) {
+	system, err := NewActorSystem(DefaultActorSystemConfig)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if err := system.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	defer system.Stop()
+
+	a, err := system.CreateActor("transient", UserActor, &testBehavior{received: make(chan Message, 1), name: "t"}, DefaultActorConfig)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := system.StopActor(a.ID); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	if _, ok := system.LookupActorID("transient"); ok {
+		t.Fatal("name still registered after StopActor")
+	}
+
+	if err := system.StopActor(a.ID); err == nil {
+		t.Fatal("expected error stopping an unknown actor")
+	}
+
+	b, err := system.CreateActor("transient", UserActor, &testBehavior{received: make(chan Message, 1), name: "t"}, DefaultActorConfig)
+	if err != nil {
+		t.Fatalf("recreate: %v", err)
+	}
+
+	if id, ok := system.LookupActorID("transient"); !ok || id != b.ID {
+		t.Fatalf("name bound to %d, want %d", id, b.ID)
+	}
+}
@@ -21,9 +21,32 @@ func OrizonPrintln(s string) {
 
 //go:linkname OrizonExit orizon.exit
 func OrizonExit(code int) {
+	// Programs built with coverage leave their counters behind.
+	if err := DumpCoverage(); err != nil {
+		fmt.Fprintf(os.Stderr, "coverage: %v\n", err)
+	}
+
 	os.Exit(code)
 }
 
+// OrizonCovInit is called on entry to main in programs built with coverage,
+// with the number of counters.
+//
+//go:linkname OrizonCovInit orizon.cov_init
+func OrizonCovInit(n int) {
+	EnableCoverage(n, CoverageProfilePath())
+}
+
+// OrizonCovHit is called at the start of every basic block of code lowered
+// with coverage.
+//
+//go:linkname OrizonCovHit orizon.cov_hit
+func OrizonCovHit(id int) {
+	if c := coverage.counters.Load(); c != nil {
+		c.Hit(id)
+	}
+}
+
 // Use unsafe to satisfy import requirement.
 var _ = unsafe.Pointer(nil)
 
@@ -65,6 +88,18 @@ orizon_println:
 
 newline:
 	db 10               ; ASCII newline
+`,
+	"cov_init": `
+orizon_cov_init:
+	; the compiler emits orizon_cov_counters zeroed
+	ret
+`,
+	"cov_hit": `
+orizon_cov_hit:
+	; rcx = counter index, counters are 8 bytes each
+	lea rax, [orizon_cov_counters]
+	lock inc qword ptr [rax + rcx*8]
+	ret
 `,
 	"exit": `
 orizon_exit:
@@ -0,0 +1,176 @@
+package runtime
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// CoverageProfileHeader is the first line of a coverage profile. The lines
+// after it are "<counter> <count>" pairs for the counters that were hit.
+const CoverageProfileHeader = "orizon-coverage 1"
+
+// CoverageProfileEnv names the environment variable holding the path a
+// program built with coverage writes its profile to at exit; the default
+// is DefaultCoverageProfile in the working directory.
+const CoverageProfileEnv = "ORIZON_COVERPROFILE"
+
+// DefaultCoverageProfile is the profile path used when CoverageProfileEnv
+// is not set.
+const DefaultCoverageProfile = "orizon.cover"
+
+// CoverageCounters are the per-block execution counters of a program lowered
+// with coverage (see codegen.LowerToMIRWithCoverage); the counter of a block
+// is incremented each time the block is entered. Safe for concurrent use.
+type CoverageCounters struct {
+	counts []atomic.Uint64
+}
+
+// NewCoverageCounters creates n zeroed counters.
+func NewCoverageCounters(n int) *CoverageCounters {
+	return &CoverageCounters{counts: make([]atomic.Uint64, n)}
+}
+
+// Len returns the number of counters.
+func (c *CoverageCounters) Len() int {
+	return len(c.counts)
+}
+
+// Hit increments counter id. Unknown counters are ignored.
+func (c *CoverageCounters) Hit(id int) {
+	if id >= 0 && id < len(c.counts) {
+		c.counts[id].Add(1)
+	}
+}
+
+// Counts returns a copy of the counters.
+func (c *CoverageCounters) Counts() []uint64 {
+	out := make([]uint64, len(c.counts))
+	for i := range c.counts {
+		out[i] = c.counts[i].Load()
+	}
+
+	return out
+}
+
+// WriteProfile writes the counters in the coverage profile format.
+func (c *CoverageCounters) WriteProfile(w io.Writer) error {
+	return WriteCoverageProfile(w, c.Counts())
+}
+
+// WriteCoverageProfile writes counts, indexed by counter, in the coverage
+// profile format.
+func WriteCoverageProfile(w io.Writer, counts []uint64) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, CoverageProfileHeader)
+
+	for id, n := range counts {
+		if n > 0 {
+			fmt.Fprintf(bw, "%d %d\n", id, n)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ReadCoverageProfile parses a coverage profile into counts indexed by
+// counter. Profiles of several runs may be concatenated; their counts add up.
+func ReadCoverageProfile(r io.Reader) ([]uint64, error) {
+	var counts []uint64
+
+	sc := bufio.NewScanner(r)
+	line := 0
+
+	for sc.Scan() {
+		line++
+
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || text == CoverageProfileHeader {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("coverage profile line %d: want \"<counter> <count>\", got %q", line, text)
+		}
+
+		id, err := strconv.Atoi(fields[0])
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("coverage profile line %d: bad counter %q", line, fields[0])
+		}
+
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("coverage profile line %d: bad count %q", line, fields[1])
+		}
+
+		for len(counts) <= id {
+			counts = append(counts, 0)
+		}
+
+		counts[id] += n
+	}
+
+	return counts, sc.Err()
+}
+
+// coverage is the process-wide state behind the cov_init and cov_hit
+// builtins.
+var coverage struct {
+	counters atomic.Pointer[CoverageCounters]
+	path     string
+	mu       sync.Mutex
+}
+
+// EnableCoverage installs n counters for OrizonCovHit and arranges for
+// OrizonExit to write them to path. It returns the installed counters.
+func EnableCoverage(n int, path string) *CoverageCounters {
+	coverage.mu.Lock()
+	defer coverage.mu.Unlock()
+
+	c := NewCoverageCounters(n)
+	coverage.counters.Store(c)
+	coverage.path = path
+
+	return c
+}
+
+// CoverageProfilePath returns the path programs built with coverage write
+// their profile to.
+func CoverageProfilePath() string {
+	if p := os.Getenv(CoverageProfileEnv); p != "" {
+		return p
+	}
+
+	return DefaultCoverageProfile
+}
+
+// DumpCoverage writes the installed counters to the path given to
+// EnableCoverage. It does nothing when coverage is not enabled.
+func DumpCoverage() error {
+	coverage.mu.Lock()
+	defer coverage.mu.Unlock()
+
+	c := coverage.counters.Load()
+	if c == nil || coverage.path == "" {
+		return nil
+	}
+
+	f, err := os.Create(coverage.path)
+	if err != nil {
+		return err
+	}
+
+	if err := c.WriteProfile(f); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,710 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BinaryContentType identifies payloads and envelopes encoded with BinaryCodec.
+const BinaryContentType = "application/x-orizon-binary"
+
+// Wire types of the binary format. Every field on the wire is prefixed by a
+// varint key of (tag << 3 | wireType) so that decoders can skip fields they do
+// not know about.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// implicitTagBase is the lowest tag assigned to struct fields without an
+// explicit `remote:"<n>"` tag. Implicit tags are derived from the field name so
+// that adding or removing other fields does not shift them.
+const implicitTagBase = 1 << 20
+
+var (
+	errWireMismatch = errors.New("remote: wire type mismatch")
+	errTruncated    = errors.New("remote: truncated binary data")
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+// BinaryCodec is a compact varint-tagged codec. Struct fields are identified by
+// numeric tags (`remote:"3"`), zero values are omitted, and unknown or
+// mismatched fields are skipped on decode, so producers and consumers may add
+// or remove fields independently.
+type BinaryCodec struct{}
+
+func (BinaryCodec) ContentType() string { return BinaryContentType }
+
+// Marshal encodes v. Structs are encoded as a sequence of tagged fields; any
+// other value is encoded as field 1 of an implicit wrapper message.
+func (BinaryCodec) Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	var err error
+	if isMessage(rv.Type()) {
+		err = encodeMessage(buf, rv)
+	} else {
+		err = encodeField(buf, 1, rv)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// Unmarshal decodes data into the value pointed to by v.
+func (BinaryCodec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("remote: unmarshal target must be a non-nil pointer, got %T", v)
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() == reflect.Interface {
+		return fmt.Errorf("remote: cannot decode binary data into %s without a registered type", rv.Type())
+	}
+
+	if isMessage(rv.Type()) {
+		return decodeMessage(data, rv)
+	}
+
+	return decodeWrapped(data, rv)
+}
+
+func isMessage(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// fieldInfo describes one encodable struct field.
+type fieldInfo struct {
+	index []int
+	tag   uint64
+}
+
+// structInfo caches the field layout of a struct type.
+type structInfo struct {
+	byTag  map[uint64]fieldInfo
+	fields []fieldInfo
+}
+
+var structCache sync.Map // reflect.Type -> *structInfo
+
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if cached, ok := structCache.Load(t); ok {
+		return cached.(*structInfo), nil
+	}
+
+	info := &structInfo{byTag: make(map[uint64]fieldInfo)}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tagStr := f.Tag.Get("remote")
+		if tagStr == "-" {
+			continue
+		}
+
+		var tag uint64
+
+		if tagStr != "" {
+			n, err := strconv.ParseUint(strings.Split(tagStr, ",")[0], 10, 32)
+			if err != nil || n == 0 || n >= implicitTagBase {
+				return nil, fmt.Errorf("remote: invalid tag %q on %s.%s", tagStr, t.Name(), f.Name)
+			}
+
+			tag = n
+		} else {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(f.Name))
+			tag = implicitTagBase + uint64(h.Sum32()%(1<<28-implicitTagBase))
+		}
+
+		if prev, dup := info.byTag[tag]; dup {
+			return nil, fmt.Errorf("remote: duplicate tag %d on %s (fields %v and %v)", tag, t.Name(), prev.index, f.Index)
+		}
+
+		fi := fieldInfo{index: f.Index, tag: tag}
+		info.byTag[tag] = fi
+		info.fields = append(info.fields, fi)
+	}
+
+	sort.Slice(info.fields, func(i, j int) bool { return info.fields[i].tag < info.fields[j].tag })
+	actual, _ := structCache.LoadOrStore(t, info)
+
+	return actual.(*structInfo), nil
+}
+
+func encodeMessage(buf *bytes.Buffer, rv reflect.Value) error {
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range info.fields {
+		fv := rv.FieldByIndex(f.index)
+		if fv.IsZero() {
+			continue
+		}
+
+		if err := encodeField(buf, f.tag, fv); err != nil {
+			return fmt.Errorf("field %d: %w", f.tag, err)
+		}
+	}
+
+	return nil
+}
+
+func encodeField(buf *bytes.Buffer, tag uint64, rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	wt, err := wireTypeOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	putUvarint(buf, tag<<3|wt)
+
+	return encodeRaw(buf, rv)
+}
+
+func wireTypeOf(t reflect.Type) (uint64, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return wireVarint, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return wireVarint, nil
+	case reflect.Float32, reflect.Float64:
+		return wireFixed64, nil
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return wireBytes, nil
+	default:
+		return 0, fmt.Errorf("remote: unsupported type %s", t)
+	}
+}
+
+// encodeRaw writes the untagged representation of rv for its wire type.
+func encodeRaw(buf *bytes.Buffer, rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv = reflect.Zero(rv.Type().Elem())
+			continue
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Type() == timeType {
+		putUvarint(buf, zigzag(rv.Interface().(time.Time).UnixNano()))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			putUvarint(buf, 1)
+		} else {
+			putUvarint(buf, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		putUvarint(buf, zigzag(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		putUvarint(buf, rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(rv.Float()))
+		buf.Write(b[:])
+	case reflect.String:
+		putUvarint(buf, uint64(rv.Len()))
+		buf.WriteString(rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			putUvarint(buf, uint64(rv.Len()))
+
+			if rv.Kind() == reflect.Slice {
+				buf.Write(rv.Bytes())
+			} else {
+				for i := 0; i < rv.Len(); i++ {
+					buf.WriteByte(byte(rv.Index(i).Uint()))
+				}
+			}
+
+			return nil
+		}
+
+		inner := getBuffer()
+		defer putBuffer(inner)
+
+		putUvarint(inner, uint64(rv.Len()))
+
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeRaw(inner, rv.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		putUvarint(buf, uint64(inner.Len()))
+		buf.Write(inner.Bytes())
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			return encodeStringMap(buf, rv)
+		}
+
+		// Entries are sorted by their encoded key so output is deterministic.
+		type entry struct{ key, val []byte }
+
+		entries := make([]entry, 0, rv.Len())
+		iter := rv.MapRange()
+
+		for iter.Next() {
+			var kb, vb bytes.Buffer
+			if err := encodeRaw(&kb, iter.Key()); err != nil {
+				return err
+			}
+
+			if err := encodeRaw(&vb, iter.Value()); err != nil {
+				return err
+			}
+
+			entries = append(entries, entry{kb.Bytes(), vb.Bytes()})
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+
+		var inner bytes.Buffer
+
+		putUvarint(&inner, uint64(len(entries)))
+
+		for _, e := range entries {
+			inner.Write(e.key)
+			inner.Write(e.val)
+		}
+
+		putUvarint(buf, uint64(inner.Len()))
+		buf.Write(inner.Bytes())
+	case reflect.Struct:
+		inner := getBuffer()
+		defer putBuffer(inner)
+
+		if err := encodeMessage(inner, rv); err != nil {
+			return err
+		}
+
+		putUvarint(buf, uint64(inner.Len()))
+		buf.Write(inner.Bytes())
+	default:
+		return fmt.Errorf("remote: unsupported type %s", rv.Type())
+	}
+
+	return nil
+}
+
+// encodeStringMap writes a map with string keys, sorted by key.
+func encodeStringMap(buf *bytes.Buffer, rv reflect.Value) error {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	inner := getBuffer()
+	defer putBuffer(inner)
+
+	putUvarint(inner, uint64(len(keys)))
+
+	for _, k := range keys {
+		if err := encodeRaw(inner, k); err != nil {
+			return err
+		}
+
+		if err := encodeRaw(inner, rv.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+
+	putUvarint(buf, uint64(inner.Len()))
+	buf.Write(inner.Bytes())
+
+	return nil
+}
+
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) { bufferPool.Put(b) }
+
+// decoder is a cursor over a binary buffer.
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) done() bool { return d.pos >= len(d.data) }
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errTruncated
+	}
+
+	d.pos += n
+
+	return v, nil
+}
+
+func (d *decoder) fixed64() (uint64, error) {
+	if len(d.data)-d.pos < 8 {
+		return 0, errTruncated
+	}
+
+	v := binary.LittleEndian.Uint64(d.data[d.pos:])
+	d.pos += 8
+
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(d.data)-d.pos) < n {
+		return nil, errTruncated
+	}
+
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return b, nil
+}
+
+func (d *decoder) skip(wt uint64) error {
+	var err error
+
+	switch wt {
+	case wireVarint:
+		_, err = d.uvarint()
+	case wireFixed64:
+		_, err = d.fixed64()
+	case wireBytes:
+		_, err = d.bytes()
+	default:
+		err = fmt.Errorf("remote: unknown wire type %d", wt)
+	}
+
+	return err
+}
+
+func decodeMessage(data []byte, rv reflect.Value) error {
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	d := &decoder{data: data}
+
+	for !d.done() {
+		key, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+
+		tag, wt := key>>3, key&7
+
+		f, known := info.byTag[tag]
+		if !known {
+			if err := d.skip(wt); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		fv := rv.FieldByIndex(f.index)
+
+		expected, err := wireTypeOf(fv.Type())
+		if err != nil {
+			return err
+		}
+
+		// A field whose type changed incompatibly is treated like an unknown field.
+		if expected != wt {
+			if err := d.skip(wt); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := decodeRaw(d, wt, fv); err != nil {
+			return fmt.Errorf("field %d: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeWrapped(data []byte, rv reflect.Value) error {
+	d := &decoder{data: data}
+
+	for !d.done() {
+		key, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+
+		tag, wt := key>>3, key&7
+		if tag != 1 {
+			if err := d.skip(wt); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		expected, err := wireTypeOf(rv.Type())
+		if err != nil {
+			return err
+		}
+
+		if expected != wt {
+			return fmt.Errorf("%w: cannot decode into %s", errWireMismatch, rv.Type())
+		}
+
+		if err := decodeRaw(d, wt, rv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeRaw reads one untagged value of wire type wt into rv.
+func decodeRaw(d *decoder, wt uint64, rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		return decodeRaw(d, wt, rv.Elem())
+	}
+
+	if rv.Type() == timeType {
+		v, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+
+		rv.Set(reflect.ValueOf(time.Unix(0, unzigzag(v))))
+
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		v, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+
+		rv.SetBool(v != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+
+		rv.SetInt(unzigzag(v))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v, err := d.uvarint()
+		if err != nil {
+			return err
+		}
+
+		rv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := d.fixed64()
+		if err != nil {
+			return err
+		}
+
+		rv.SetFloat(math.Float64frombits(v))
+	case reflect.String:
+		b, err := d.bytes()
+		if err != nil {
+			return err
+		}
+
+		rv.SetString(string(b))
+	case reflect.Slice, reflect.Array:
+		b, err := d.bytes()
+		if err != nil {
+			return err
+		}
+
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			if rv.Kind() == reflect.Slice {
+				rv.SetBytes(append([]byte(nil), b...))
+			} else {
+				reflect.Copy(rv, reflect.ValueOf(b))
+			}
+
+			return nil
+		}
+
+		return decodeSequence(&decoder{data: b}, rv)
+	case reflect.Map:
+		b, err := d.bytes()
+		if err != nil {
+			return err
+		}
+
+		return decodeMap(&decoder{data: b}, rv)
+	case reflect.Struct:
+		b, err := d.bytes()
+		if err != nil {
+			return err
+		}
+
+		return decodeMessage(b, rv)
+	default:
+		return fmt.Errorf("remote: unsupported type %s", rv.Type())
+	}
+
+	return nil
+}
+
+func decodeSequence(d *decoder, rv reflect.Value) error {
+	n, err := d.uvarint()
+	if err != nil {
+		return err
+	}
+
+	if n > uint64(len(d.data)) {
+		return errTruncated
+	}
+
+	elemWT, err := wireTypeOf(rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	if rv.Kind() == reflect.Slice {
+		rv.Set(reflect.MakeSlice(rv.Type(), int(n), int(n)))
+	}
+
+	for i := 0; i < int(n); i++ {
+		if i >= rv.Len() {
+			if err := d.skip(elemWT); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := decodeRaw(d, elemWT, rv.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeMap(d *decoder, rv reflect.Value) error {
+	n, err := d.uvarint()
+	if err != nil {
+		return err
+	}
+
+	if n > uint64(len(d.data)) {
+		return errTruncated
+	}
+
+	t := rv.Type()
+
+	keyWT, err := wireTypeOf(t.Key())
+	if err != nil {
+		return err
+	}
+
+	valWT, err := wireTypeOf(t.Elem())
+	if err != nil {
+		return err
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(t, int(n)))
+	}
+
+	for i := 0; i < int(n); i++ {
+		k := reflect.New(t.Key()).Elem()
+		if err := decodeRaw(d, keyWT, k); err != nil {
+			return err
+		}
+
+		v := reflect.New(t.Elem()).Elem()
+		if err := decodeRaw(d, valWT, v); err != nil {
+			return err
+		}
+
+		rv.SetMapIndex(k, v)
+	}
+
+	return nil
+}
+
+func putUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	buf.Write(b[:n])
+}
+
+func zigzag(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) }
+
+func unzigzag(v uint64) int64 { return int64(v>>1) ^ -int64(v&1) }
@@ -0,0 +1,356 @@
+package remote
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+)
+
+type orderV1 struct {
+	Items    map[string]int `remote:"3" json:"items"`
+	ID       string         `remote:"1" json:"id"`
+	Legacy   string         `remote:"4" json:"legacy"`
+	Tags     []string       `remote:"5" json:"tags"`
+	Quantity int64          `remote:"2" json:"quantity"`
+}
+
+// orderV2 drops Legacy (4) and adds Price (6) and Note (7).
+type orderV2 struct {
+	Items    map[string]int `remote:"3"`
+	ID       string         `remote:"1"`
+	Note     *string        `remote:"7"`
+	Tags     []string       `remote:"5"`
+	Quantity int64          `remote:"2"`
+	Price    float64        `remote:"6"`
+}
+
+func TestBinaryCodec_RoundTrip(t *testing.T) {
+	type nested struct {
+		When  time.Time
+		Inner []orderV1
+		Raw   []byte
+		Flag  bool
+		Small int8
+		Big   uint64
+		Ratio float32
+	}
+
+	in := nested{
+		When:  time.Unix(1700000000, 42),
+		Inner: []orderV1{{ID: "a", Quantity: -3, Items: map[string]int{"x": 1, "y": 2}, Tags: []string{"t1", "t2"}}},
+		Raw:   []byte{0, 1, 2},
+		Flag:  true,
+		Small: -7,
+		Big:   1 << 60,
+		Ratio: 0.5,
+	}
+
+	data, err := BinaryCodec{}.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var out nested
+	if err := (BinaryCodec{}).Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if !out.When.Equal(in.When) {
+		t.Fatalf("time mismatch: %v vs %v", out.When, in.When)
+	}
+
+	out.When = in.When
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch:\n in=%+v\nout=%+v", in, out)
+	}
+}
+
+func TestBinaryCodec_SchemaEvolution(t *testing.T) {
+	v1 := orderV1{ID: "o-1", Quantity: 5, Legacy: "gone", Tags: []string{"a"}}
+
+	data, err := BinaryCodec{}.Marshal(v1)
+	if err != nil {
+		t.Fatalf("marshal v1: %v", err)
+	}
+
+	var v2 orderV2
+	if err := (BinaryCodec{}).Unmarshal(data, &v2); err != nil {
+		t.Fatalf("v1 -> v2: %v", err)
+	}
+
+	if v2.ID != "o-1" || v2.Quantity != 5 || v2.Price != 0 || v2.Note != nil || len(v2.Tags) != 1 {
+		t.Fatalf("unexpected v2: %+v", v2)
+	}
+
+	note := "hi"
+	v2.Price = 9.5
+	v2.Note = &note
+
+	data, err = BinaryCodec{}.Marshal(v2)
+	if err != nil {
+		t.Fatalf("marshal v2: %v", err)
+	}
+
+	var back orderV1
+	if err := (BinaryCodec{}).Unmarshal(data, &back); err != nil {
+		t.Fatalf("v2 -> v1: %v", err)
+	}
+
+	if back.ID != "o-1" || back.Quantity != 5 || back.Legacy != "" {
+		t.Fatalf("unexpected v1: %+v", back)
+	}
+}
+
+func TestBinaryCodec_TypeChangeSkipped(t *testing.T) {
+	type before struct {
+		Count string `remote:"1"`
+		Name  string `remote:"2"`
+	}
+
+	type after struct {
+		Count int64  `remote:"1"`
+		Name  string `remote:"2"`
+	}
+
+	data, _ := BinaryCodec{}.Marshal(before{Count: "many", Name: "n"})
+
+	var out after
+	if err := (BinaryCodec{}).Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if out.Count != 0 || out.Name != "n" {
+		t.Fatalf("unexpected: %+v", out)
+	}
+}
+
+func TestEnvelope_EncodeDecode(t *testing.T) {
+	env := Envelope{
+		Headers:       map[string]string{HeaderContentType: BinaryContentType},
+		SenderNode:    "A",
+		ReceiverName:  "svc",
+		PayloadBytes:  []byte("payload"),
+		MessageType:   7,
+		TimestampUnix: 123,
+	}
+
+	for _, codec := range []Codec{BinaryCodec{}, JSONCodec{}} {
+		data, err := EncodeEnvelope(codec, env)
+		if err != nil {
+			t.Fatalf("%s encode: %v", codec.ContentType(), err)
+		}
+
+		got, ct, err := DecodeEnvelope(data)
+		if err != nil {
+			t.Fatalf("%s decode: %v", codec.ContentType(), err)
+		}
+
+		if ct != codec.ContentType() || !reflect.DeepEqual(got, env) {
+			t.Fatalf("%s mismatch: ct=%s env=%+v", codec.ContentType(), ct, got)
+		}
+	}
+}
+
+type typedBehavior struct{ got chan interface{} }
+
+func (b *typedBehavior) Receive(ctx *rt.ActorContext, msg rt.Message) error {
+	b.got <- msg.Payload
+	return nil
+}
+func (b *typedBehavior) PreStart(*rt.ActorContext) error                       { return nil }
+func (b *typedBehavior) PostStop(*rt.ActorContext) error                       { return nil }
+func (b *typedBehavior) PreRestart(*rt.ActorContext, error, *rt.Message) error { return nil }
+func (b *typedBehavior) PostRestart(*rt.ActorContext, error) error             { return nil }
+func (b *typedBehavior) GetBehaviorName() string                               { return "typed" }
+
+func TestRemote_Negotiation_AndTypedDelivery(t *testing.T) {
+	a, _ := rt.NewActorSystem(rt.DefaultActorSystemConfig)
+	_ = a.Start()
+
+	defer a.Stop()
+
+	tb := &typedBehavior{got: make(chan interface{}, 4)}
+	if _, err := a.CreateActor("orders", rt.UserActor, tb, rt.DefaultActorConfig); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	types := NewTypeRegistry()
+	if err := types.Register(42, orderV1{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	disc := NewStaticDiscovery()
+
+	// Node N1 prefers binary but also speaks JSON.
+	n1 := &RemoteSystem{
+		Trans: &InMemoryTransport{}, Default: BinaryCodec{}, Codecs: map[string]Codec{"application/json": JSONCodec{}},
+		Local: adapter{a}, Resolver: regAdapter{a}, Discover: disc, Types: types,
+	}
+	if err := n1.Start("N1", "N1"); err != nil {
+		t.Fatalf("n1 start: %v", err)
+	}
+	defer n1.Stop()
+
+	// Node N2 speaks JSON only.
+	n2 := &RemoteSystem{Trans: &InMemoryTransport{}, Default: JSONCodec{}, Local: adapter{a}, Resolver: regAdapter{a}, Discover: disc, Types: types}
+	if err := n2.Start("N2", "N2"); err != nil {
+		t.Fatalf("n2 start: %v", err)
+	}
+	defer n2.Stop()
+
+	want := orderV1{ID: "o-9", Quantity: 2}
+
+	// JSON-only node talks first; N1 learns that N2 cannot decode binary.
+	if err := n2.SendWithRetry("N1", "orders", 42, want, 1, 1); err != nil {
+		t.Fatalf("n2 -> n1: %v", err)
+	}
+
+	if ct, ok := n1.NegotiatedContentType("N2"); !ok || ct != "application/json" {
+		t.Fatalf("n1 negotiated %q (%v), want application/json", ct, ok)
+	}
+
+	if err := n1.SendWithRetry("N2", "orders", 42, want, 1, 1); err != nil {
+		t.Fatalf("n1 -> n2: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case p := <-tb.got:
+			if got, ok := p.(orderV1); !ok || got.ID != want.ID || got.Quantity != want.Quantity {
+				t.Fatalf("unexpected payload %#v", p)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("typed delivery timed out")
+		}
+	}
+}
+
+func TestRemote_Negotiation_BinaryNodeSpeaksFirst(t *testing.T) {
+	a, _ := rt.NewActorSystem(rt.DefaultActorSystemConfig)
+	_ = a.Start()
+
+	defer a.Stop()
+
+	tb := &typedBehavior{got: make(chan interface{}, 4)}
+	if _, err := a.CreateActor("orders", rt.UserActor, tb, rt.DefaultActorConfig); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	types := NewTypeRegistry()
+	if err := types.Register(42, orderV1{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	disc := NewStaticDiscovery()
+	node := func(name string, codec Codec) *RemoteSystem {
+		rs := &RemoteSystem{Trans: &InMemoryTransport{}, Default: codec, Local: adapter{a}, Resolver: regAdapter{a}, Discover: disc, Types: types}
+		if err := rs.Start(name, "mem-"+name); err != nil {
+			t.Fatalf("%s start: %v", name, err)
+		}
+
+		t.Cleanup(func() { _ = rs.Stop() })
+
+		return rs
+	}
+
+	b1, b2, j1 := node("B1", BinaryCodec{}), node("B2", BinaryCodec{}), node("J1", JSONCodec{})
+	want := orderV1{ID: "o-1", Quantity: 3}
+
+	// Binary-only nodes open conversations, addressing peers by address.
+	if err := b1.SendWithRetry("mem-J1", "orders", 42, want, 1, 1); err != nil {
+		t.Fatalf("b1 -> j1: %v", err)
+	}
+
+	if err := b1.SendWithRetry("mem-B2", "orders", 42, want, 1, 1); err != nil {
+		t.Fatalf("b1 -> b2: %v", err)
+	}
+
+	if ct, ok := j1.NegotiatedContentType("B1"); !ok || ct != "application/json" {
+		t.Fatalf("j1 negotiated %q (%v), want application/json", ct, ok)
+	}
+
+	if ct, ok := b2.NegotiatedContentType("mem-B1"); !ok || ct != BinaryContentType {
+		t.Fatalf("b2 negotiated %q (%v), want %s", ct, ok, BinaryContentType)
+	}
+
+	if err := j1.SendWithRetry("mem-B1", "orders", 42, want, 1, 1); err != nil {
+		t.Fatalf("j1 -> b1: %v", err)
+	}
+
+	if ct, ok := b1.NegotiatedContentType("mem-J1"); !ok || ct != "application/json" {
+		t.Fatalf("b1 negotiated %q (%v) for j1 by address, want application/json", ct, ok)
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case p := <-tb.got:
+			if got, ok := p.(orderV1); !ok || got.ID != want.ID || got.Quantity != want.Quantity {
+				t.Fatalf("unexpected payload %#v", p)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("typed delivery timed out")
+		}
+	}
+}
+
+func benchmarkCodec(b *testing.B, c Codec) {
+	msg := orderV1{
+		ID:       "order-123456",
+		Quantity: 42,
+		Items:    map[string]int{"apple": 3, "banana": 12, "cherry": 7},
+		Tags:     []string{"priority", "gift", "express"},
+	}
+
+	b.ReportAllocs()
+
+	var size int
+
+	for i := 0; i < b.N; i++ {
+		data, err := c.Marshal(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var out orderV1
+		if err := c.Unmarshal(data, &out); err != nil {
+			b.Fatal(err)
+		}
+
+		size = len(data)
+	}
+
+	b.ReportMetric(float64(size), "bytes/msg")
+}
+
+func BenchmarkCodec_Binary(b *testing.B) { benchmarkCodec(b, BinaryCodec{}) }
+func BenchmarkCodec_JSON(b *testing.B)   { benchmarkCodec(b, JSONCodec{}) }
+
+func benchmarkEnvelope(b *testing.B, c Codec) {
+	env := Envelope{
+		Headers:       map[string]string{HeaderContentType: c.ContentType()},
+		SenderNode:    "node-a",
+		ReceiverNode:  "node-b",
+		ReceiverName:  "orders",
+		PayloadBytes:  make([]byte, 256),
+		TimestampUnix: NowUnix(),
+		MessageType:   42,
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		data, err := EncodeEnvelope(c, env)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, _, err := DecodeEnvelope(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEnvelope_Binary(b *testing.B) { benchmarkEnvelope(b, BinaryCodec{}) }
+func BenchmarkEnvelope_JSON(b *testing.B)   { benchmarkEnvelope(b, JSONCodec{}) }
@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// envelopeMagic prefixes binary-encoded envelopes so that byte-stream
+// transports can accept both binary and JSON peers on the same listener.
+var envelopeMagic = []byte{0xB0, 'O', 'Z', 0x01}
+
+// EncodeEnvelope serializes env for byte-oriented transports using the wire
+// format of codec. Codecs other than BinaryCodec fall back to JSON.
+func EncodeEnvelope(codec Codec, env Envelope) ([]byte, error) {
+	if codec != nil && codec.ContentType() == BinaryContentType {
+		body, err := BinaryCodec{}.Marshal(env)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(append(make([]byte, 0, len(envelopeMagic)+len(body)), envelopeMagic...), body...), nil
+	}
+
+	return json.Marshal(env)
+}
+
+// DecodeEnvelope parses an envelope produced by EncodeEnvelope and reports the
+// content type it was encoded with.
+func DecodeEnvelope(data []byte) (Envelope, string, error) {
+	var env Envelope
+
+	if bytes.HasPrefix(data, envelopeMagic) {
+		if err := (BinaryCodec{}).Unmarshal(data[len(envelopeMagic):], &env); err != nil {
+			return Envelope{}, "", fmt.Errorf("decode binary envelope: %w", err)
+		}
+
+		return env, BinaryContentType, nil
+	}
+
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, "", fmt.Errorf("decode json envelope: %w", err)
+	}
+
+	return env, JSONCodec{}.ContentType(), nil
+}
@@ -0,0 +1,136 @@
+package remote
+
+import (
+	"sort"
+	"strings"
+)
+
+// Envelope headers used for per-peer content-type negotiation.
+const (
+	// HeaderContentType names the codec used for Envelope.PayloadBytes.
+	HeaderContentType = "content-type"
+	// HeaderAccept lists the content types the sender can decode, most preferred first.
+	HeaderAccept = "accept"
+)
+
+// acceptList returns the content types this node can decode in preference
+// order: the default codec first, then any additional codecs sorted by name.
+// JSON is always decodable and comes last unless configured otherwise.
+func (rs *RemoteSystem) acceptList() []string {
+	out := make([]string, 0, len(rs.Codecs)+2)
+	if rs.Default != nil {
+		out = append(out, rs.Default.ContentType())
+	}
+
+	extra := make([]string, 0, len(rs.Codecs))
+	for ct := range rs.Codecs {
+		if rs.Default == nil || ct != rs.Default.ContentType() {
+			extra = append(extra, ct)
+		}
+	}
+
+	sort.Strings(extra)
+	out = append(out, extra...)
+
+	jsonType := JSONCodec{}.ContentType()
+	for _, ct := range out {
+		if ct == jsonType {
+			return out
+		}
+	}
+
+	return append(out, jsonType)
+}
+
+// codecByType returns the local codec for a content type. JSON is decodable
+// by every node, so it is available even when not configured.
+func (rs *RemoteSystem) codecByType(ct string) (Codec, bool) {
+	if ct == "" || (rs.Default != nil && rs.Default.ContentType() == ct) {
+		return rs.Default, rs.Default != nil
+	}
+
+	if c, ok := rs.Codecs[ct]; ok {
+		return c, true
+	}
+
+	if ct == (JSONCodec{}).ContentType() {
+		return JSONCodec{}, true
+	}
+
+	return nil, false
+}
+
+// codecForPeer returns the codec negotiated with the peer node. Until the
+// peer has advertised what it accepts, messages go out as JSON, which every
+// node can decode.
+// Callers must hold rs.mutex.
+func (rs *RemoteSystem) codecForPeer(node string) Codec {
+	if ct, ok := rs.peerCodecs[node]; ok {
+		if c, ok := rs.codecByType(ct); ok {
+			return c
+		}
+	}
+
+	return JSONCodec{}
+}
+
+// peerNode returns the node name behind an address or node name, so codecs
+// learned from a node's messages apply however it is addressed.
+func (rs *RemoteSystem) peerNode(addrOrNode string) string {
+	if rs.Discover == nil {
+		return addrOrNode
+	}
+
+	if _, ok := rs.Discover.Resolve(addrOrNode); ok {
+		return addrOrNode
+	}
+
+	for node, addr := range rs.Discover.Members() {
+		if addr == addrOrNode {
+			return node
+		}
+	}
+
+	return addrOrNode
+}
+
+// learnPeer records the best codec for talking to peer from the accept header
+// it advertised: the first of our own preferences that the peer can decode.
+func (rs *RemoteSystem) learnPeer(peer, accept string) {
+	if peer == "" || accept == "" {
+		return
+	}
+
+	theirs := make(map[string]bool)
+	for _, ct := range strings.Split(accept, ",") {
+		theirs[strings.TrimSpace(ct)] = true
+	}
+
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	for _, ct := range rs.acceptList() {
+		if theirs[ct] {
+			if rs.peerCodecs == nil {
+				rs.peerCodecs = make(map[string]string)
+			}
+
+			rs.peerCodecs[peer] = ct
+
+			return
+		}
+	}
+}
+
+// NegotiatedContentType reports the content type chosen for peer, given by
+// node name or address, if any.
+func (rs *RemoteSystem) NegotiatedContentType(peer string) (string, bool) {
+	node := rs.peerNode(peer)
+
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	ct, ok := rs.peerCodecs[node]
+
+	return ct, ok
+}
@@ -0,0 +1,63 @@
+package remote
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// SimScheduler is the part of a deterministic simulator (runtime.Simulator)
+// that SimTransport needs to defer deliveries and pick latencies.
+type SimScheduler interface {
+	Schedule(delay time.Duration, fn func())
+	Rand() *rand.Rand
+}
+
+// SimTransport is an in-process transport for simulation runs. Instead of
+// invoking the destination handler synchronously, each Send becomes a
+// simulator event after a seeded latency in [MinLatency, MaxLatency], and may
+// be dropped with probability DropRate. Handler errors are discarded, as a
+// network would.
+type SimTransport struct {
+	Sim SimScheduler
+	InMemoryTransport
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	DropRate   float64
+}
+
+func (t *SimTransport) Send(to string, env Envelope) error {
+	if t.Sim == nil {
+		return fmt.Errorf("sim transport has no scheduler")
+	}
+
+	registryMutex.RLock()
+	dst := registry[to]
+	registryMutex.RUnlock()
+
+	if dst == nil {
+		return fmt.Errorf("destination not found: %s", to)
+	}
+
+	rng := t.Sim.Rand()
+	if t.DropRate > 0 && rng.Float64() < t.DropRate {
+		return nil
+	}
+
+	latency := t.MinLatency
+	if span := t.MaxLatency - t.MinLatency; span > 0 {
+		latency += time.Duration(rng.Int63n(int64(span) + 1))
+	}
+
+	t.Sim.Schedule(latency, func() {
+		dst.mutex.RLock()
+		handler := dst.handler
+		dst.mutex.RUnlock()
+
+		if handler != nil {
+			_ = handler(env)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+	"github.com/orizon-lang/orizon/internal/testrunner/concurrency"
+)
+
+type recordBehavior struct{ got *[]string }
+
+func (b *recordBehavior) Receive(ctx *rt.ActorContext, msg rt.Message) error {
+	p, _ := msg.Payload.([]byte)
+	*b.got = append(*b.got, string(p))
+
+	return nil
+}
+func (b *recordBehavior) PreStart(*rt.ActorContext) error                       { return nil }
+func (b *recordBehavior) PostStop(*rt.ActorContext) error                       { return nil }
+func (b *recordBehavior) PreRestart(*rt.ActorContext, error, *rt.Message) error { return nil }
+func (b *recordBehavior) PostRestart(*rt.ActorContext, error) error             { return nil }
+func (b *recordBehavior) GetBehaviorName() string                               { return "record" }
+
+// raceScenario has two nodes send to a third; it fails whenever the message
+// from node B overtakes the one from node A.
+func raceScenario(seed int64, got *[]string) error {
+	return rt.RunSimulation(seed, func(sim *rt.Simulator) error {
+		cfg := rt.DefaultActorSystemConfig
+		cfg.Simulation = sim
+
+		sys, err := rt.NewActorSystem(cfg)
+		if err != nil {
+			return err
+		}
+
+		if err := sys.Start(); err != nil {
+			return err
+		}
+
+		defer sys.Stop()
+
+		if _, err := sys.CreateActor("sink", rt.UserActor, &recordBehavior{got: got}, rt.DefaultActorConfig); err != nil {
+			return err
+		}
+
+		disc := NewStaticDiscovery()
+		nodes := map[string]*RemoteSystem{}
+
+		for _, name := range []string{"A", "B", "C"} {
+			rs := &RemoteSystem{
+				Trans:   &SimTransport{Sim: sim, MinLatency: time.Millisecond, MaxLatency: 20 * time.Millisecond},
+				Default: BinaryCodec{}, Local: adapter{sys}, Resolver: regAdapter{sys}, Discover: disc, RetryMaxAttempts: 1,
+			}
+			if err := rs.Start(name, fmt.Sprintf("sim-%d/%s", seed, name)); err != nil {
+				return err
+			}
+
+			defer rs.Stop()
+
+			nodes[name] = rs
+		}
+
+		if err := nodes["A"].Send("C", "sink", 1, []byte("from-A")); err != nil {
+			return err
+		}
+
+		if err := nodes["B"].Send("C", "sink", 1, []byte("from-B")); err != nil {
+			return err
+		}
+
+		if err := sim.RunUntilIdle(); err != nil {
+			return err
+		}
+
+		if len(*got) != 2 {
+			return fmt.Errorf("delivered %d of 2 messages", len(*got))
+		}
+
+		if (*got)[0] != "from-A" {
+			return errors.New("message from B overtook message from A")
+		}
+
+		return nil
+	})
+}
+
+func TestSimTransport_ExploreAndReplay(t *testing.T) {
+	failures := concurrency.ExploreSeeds(1, 200, true, func(seed int64) error {
+		var got []string
+
+		return raceScenario(seed, &got)
+	})
+
+	if len(failures) == 0 {
+		t.Fatal("exploration did not find the reordering")
+	}
+
+	seed := failures[0].Seed
+
+	var simErr *rt.SimulationError
+	if !errors.As(failures[0], &simErr) || simErr.Seed != seed {
+		t.Fatalf("failure does not carry its seed: %v", failures[0])
+	}
+
+	for i := 0; i < 3; i++ {
+		var got []string
+		if err := raceScenario(seed, &got); err == nil {
+			t.Fatalf("replay of seed %d did not reproduce the failure", seed)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package remote
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,6 +22,8 @@ type RemoteSystem struct {
 	Local             LocalDispatcher
 	Discover          Discovery
 	Codecs            map[string]Codec
+	Types             *TypeRegistry
+	peerCodecs        map[string]string
 	Address           string
 	NodeName          string
 	RetryMaxAttempts  int
@@ -98,13 +101,30 @@ func (rs *RemoteSystem) Stop() error {
 	return nil
 }
 
+// HeaderDispatcher is implemented by local dispatchers that can attach
+// envelope headers (such as a W3C traceparent) to delivered messages.
+type HeaderDispatcher interface {
+	SendMessageWithHeaders(senderID uint64, receiverID uint64, msgType uint32, payload interface{}, headers map[string]string) error
+}
+
+// propagatedHeaders lists envelope headers forwarded to the local actor message.
+var propagatedHeaders = []string{"traceparent", "tracestate"}
+
 // Send delivers a message to a remote node.
 func (rs *RemoteSystem) Send(remoteAddrOrNode, receiverName string, msgType uint32, payload interface{}) error {
+	return rs.SendWithHeaders(remoteAddrOrNode, receiverName, msgType, payload, nil)
+}
+
+// SendWithHeaders is like Send but also carries extra envelope headers.
+func (rs *RemoteSystem) SendWithHeaders(remoteAddrOrNode, receiverName string, msgType uint32, payload interface{}, headers map[string]string) error {
+	peer := rs.peerNode(remoteAddrOrNode)
+
 	rs.mutex.RLock()
-	codec := rs.Default
+	codec := rs.codecForPeer(peer)
 	node := rs.NodeName
+	accept := strings.Join(rs.acceptList(), ",")
 	rs.mutex.RUnlock()
-	// For byte payloads, wrap in JSON so Unmarshal to []byte works symmetrically.
+	// For byte payloads, wrap with the codec so Unmarshal to []byte works symmetrically.
 	var b []byte
 
 	var err error
@@ -119,6 +139,10 @@ func (rs *RemoteSystem) Send(remoteAddrOrNode, receiverName string, msgType uint
 	}
 
 	env := Envelope{
+		Headers: map[string]string{
+			HeaderContentType: codec.ContentType(),
+			HeaderAccept:      accept,
+		},
 		SenderNode:    node,
 		ReceiverNode:  remoteAddrOrNode,
 		ReceiverName:  receiverName,
@@ -126,6 +150,12 @@ func (rs *RemoteSystem) Send(remoteAddrOrNode, receiverName string, msgType uint
 		PayloadBytes:  b,
 		TimestampUnix: NowUnix(),
 	}
+
+	for k, v := range headers {
+		if _, reserved := env.Headers[k]; !reserved {
+			env.Headers[k] = v
+		}
+	}
 	// If a discovery is available, allow passing node name instead of address.
 	target := remoteAddrOrNode
 
@@ -221,14 +251,55 @@ func (rs *RemoteSystem) receive(env Envelope) error {
 		return fmt.Errorf("local actor not found: %s", env.ReceiverName)
 	}
 
+	codec, ok := rs.codecByType(env.Headers[HeaderContentType])
+	if !ok {
+		return fmt.Errorf("unsupported content type: %s", env.Headers[HeaderContentType])
+	}
+
+	rs.learnPeer(env.SenderNode, env.Headers[HeaderAccept])
+
+	if rs.Types != nil {
+		if payload, registered, err := rs.Types.Decode(codec, env.MessageType, env.PayloadBytes); registered {
+			if err != nil {
+				return fmt.Errorf("decode message type %d: %w", env.MessageType, err)
+			}
+
+			return rs.deliver(id, env, payload)
+		}
+	}
+
 	var payload interface{}
-	// Attempt to decode with default codec into raw bytes; fall back to raw envelope payload on error.
+	// Attempt to decode into raw bytes; fall back to raw envelope payload on error.
 	var raw []byte
-	if err := rs.Default.Unmarshal(env.PayloadBytes, &raw); err == nil {
+	if err := codec.Unmarshal(env.PayloadBytes, &raw); err == nil {
 		payload = raw
 	} else {
 		payload = env.PayloadBytes
 	}
 
+	return rs.deliver(id, env, payload)
+}
+
+// deliver hands a decoded payload to the local system, forwarding trace
+// headers when the dispatcher supports them.
+func (rs *RemoteSystem) deliver(id uint64, env Envelope, payload interface{}) error {
+	if hd, ok := rs.Local.(HeaderDispatcher); ok {
+		var headers map[string]string
+
+		for _, k := range propagatedHeaders {
+			if v, ok := env.Headers[k]; ok {
+				if headers == nil {
+					headers = make(map[string]string)
+				}
+
+				headers[k] = v
+			}
+		}
+
+		if headers != nil {
+			return hd.SendMessageWithHeaders(0, id, env.MessageType, payload, headers)
+		}
+	}
+
 	return rs.Local.SendMessage(0, id, env.MessageType, payload)
 }
@@ -29,6 +29,10 @@ func (a adapter) SendMessage(sid uint64, rid uint64, mt uint32, p interface{}) e
 	return a.sys.SendMessage(rt.ActorID(sid), rt.ActorID(rid), rt.MessageType(mt), p)
 }
 
+func (a adapter) SendMessageWithHeaders(sid uint64, rid uint64, mt uint32, p interface{}, h map[string]string) error {
+	return a.sys.SendMessageWithHeaders(rt.ActorID(sid), rt.ActorID(rid), rt.MessageType(mt), p, h)
+}
+
 func (a adapter) LookupActorID(name string) (uint64, bool) {
 	id, ok := a.sys.LookupActorID(name)
 
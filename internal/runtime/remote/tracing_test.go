@@ -0,0 +1,162 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+)
+
+// forwardBehavior relays every message to a qualified remote name.
+type forwardBehavior struct{ target string }
+
+func (f *forwardBehavior) Receive(ctx *rt.ActorContext, msg rt.Message) error {
+	return ctx.System.SendToName(ctx.ActorID, f.target, msg.Type, msg.Payload)
+}
+func (f *forwardBehavior) PreStart(*rt.ActorContext) error                       { return nil }
+func (f *forwardBehavior) PostStop(*rt.ActorContext) error                       { return nil }
+func (f *forwardBehavior) PreRestart(*rt.ActorContext, error, *rt.Message) error { return nil }
+func (f *forwardBehavior) PostRestart(*rt.ActorContext, error) error             { return nil }
+func (f *forwardBehavior) GetBehaviorName() string                               { return "forward" }
+
+// otlpCollector is a minimal OTLP/HTTP JSON endpoint recording span ids.
+type otlpCollector struct {
+	spans map[string]map[string]string // name -> traceId/spanId/parentSpanId
+	mu    sync.Mutex
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+
+		return
+	}
+
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				c.spans[s.Name] = map[string]string{"trace": s.TraceID, "span": s.SpanID, "parent": s.ParentSpanID}
+			}
+		}
+	}
+}
+
+func (c *otlpCollector) get(name string) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.spans[name]
+}
+
+func TestRemote_TraceparentPropagation_OTLP(t *testing.T) {
+	collector := &otlpCollector{spans: map[string]map[string]string{}}
+	srv := httptest.NewServer(collector)
+
+	defer srv.Close()
+
+	newSystem := func() *rt.ActorSystem {
+		sys, _ := rt.NewActorSystem(rt.DefaultActorSystemConfig)
+		sys.SetSpanExporter(rt.NewOTLPHTTPExporter(srv.URL, "test"), 1)
+		_ = sys.Start()
+
+		return sys
+	}
+
+	a := newSystem()
+	defer a.Stop()
+
+	b := newSystem()
+	defer b.Stop()
+
+	disc := NewStaticDiscovery()
+
+	rsA := &RemoteSystem{Trans: &InMemoryTransport{}, Default: JSONCodec{}, Local: adapter{a}, Resolver: regAdapter{a}, Discover: disc}
+	if err := rsA.Start("A", "A"); err != nil {
+		t.Fatalf("rsA start: %v", err)
+	}
+	defer rsA.Stop()
+
+	rsB := &RemoteSystem{Trans: &InMemoryTransport{}, Default: JSONCodec{}, Local: adapter{b}, Resolver: regAdapter{b}, Discover: disc}
+	if err := rsB.Start("B", "B"); err != nil {
+		t.Fatalf("rsB start: %v", err)
+	}
+	defer rsB.Stop()
+
+	a.Remote = rsA
+
+	front, err := a.CreateActor("front", rt.UserActor, &forwardBehavior{target: "B:back"}, rt.DefaultActorConfig)
+	if err != nil {
+		t.Fatalf("create front: %v", err)
+	}
+
+	eb := &echoBehavior{got: make(chan []byte, 1)}
+	if _, err := b.CreateActor("back", rt.UserActor, eb, rt.DefaultActorConfig); err != nil {
+		t.Fatalf("create back: %v", err)
+	}
+
+	corr := rt.NewCorrelationID()
+	if err := a.SendMessageWithCorrelation(0, front.ID, 7, []byte("hi"), corr); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	select {
+	case <-eb.got:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("remote actor did not receive the message")
+	}
+
+	var parent, child map[string]string
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		parent, child = collector.get("front receive 7"), collector.get("back receive 7")
+		if parent != nil && child != nil {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if parent == nil || child == nil {
+		t.Fatalf("spans not exported: front=%v back=%v", parent, child)
+	}
+
+	if parent["trace"] != corr {
+		t.Fatalf("trace id %s should derive from correlation id %s", parent["trace"], corr)
+	}
+
+	if child["trace"] != parent["trace"] || child["parent"] != parent["span"] {
+		t.Fatalf("remote span not linked to sender: front=%v back=%v", parent, child)
+	}
+
+	if err := a.FlushSpans(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+}
@@ -4,16 +4,18 @@ package remote
 import "time"
 
 // Envelope is a transport-level message wrapper for remote delivery.
+// The remote tags give each field a stable number in the binary encoding;
+// new fields must take an unused number.
 type Envelope struct {
-	Headers       map[string]string `json:"headers,omitempty"`
-	SenderNode    string            `json:"senderNode"`
-	ReceiverNode  string            `json:"receiverNode"`
-	ReceiverName  string            `json:"receiverName"`
-	CorrelationID string            `json:"correlationId,omitempty"`
-	PayloadBytes  []byte            `json:"payload"`
-	ReceiverID    uint64            `json:"receiverId"`
-	TimestampUnix int64             `json:"timestampUnix"`
-	MessageType   uint32            `json:"messageType"`
+	Headers       map[string]string `json:"headers,omitempty" remote:"1"`
+	SenderNode    string            `json:"senderNode" remote:"2"`
+	ReceiverNode  string            `json:"receiverNode" remote:"3"`
+	ReceiverName  string            `json:"receiverName" remote:"4"`
+	CorrelationID string            `json:"correlationId,omitempty" remote:"5"`
+	PayloadBytes  []byte            `json:"payload" remote:"6"`
+	ReceiverID    uint64            `json:"receiverId" remote:"7"`
+	TimestampUnix int64             `json:"timestampUnix" remote:"8"`
+	MessageType   uint32            `json:"messageType" remote:"9"`
 }
 
 // Handler is invoked by a Transport upon message arrival.
@@ -0,0 +1,72 @@
+package remote
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TypeRegistry maps envelope message types to the Go types their payloads
+// decode into, so receivers can deliver typed values instead of raw bytes.
+type TypeRegistry struct {
+	types map[uint32]reflect.Type
+	mu    sync.RWMutex
+}
+
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{types: make(map[uint32]reflect.Type)}
+}
+
+// Register associates msgType with the dynamic type of prototype.
+// Re-registering the same type is a no-op; registering a different one is an error.
+func (r *TypeRegistry) Register(msgType uint32, prototype interface{}) error {
+	t := reflect.TypeOf(prototype)
+	if t == nil {
+		return fmt.Errorf("remote: nil prototype for message type %d", msgType)
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if _, err := wireTypeOf(t); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if prev, ok := r.types[msgType]; ok && prev != t {
+		return fmt.Errorf("remote: message type %d already registered as %s", msgType, prev)
+	}
+
+	r.types[msgType] = t
+
+	return nil
+}
+
+// Lookup returns the type registered for msgType.
+func (r *TypeRegistry) Lookup(msgType uint32) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.types[msgType]
+
+	return t, ok
+}
+
+// Decode unmarshals data with codec into a fresh value of the type registered
+// for msgType and returns it by value.
+func (r *TypeRegistry) Decode(codec Codec, msgType uint32, data []byte) (interface{}, bool, error) {
+	t, ok := r.Lookup(msgType)
+	if !ok {
+		return nil, false, nil
+	}
+
+	ptr := reflect.New(t)
+	if err := codec.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, true, err
+	}
+
+	return ptr.Elem().Interface(), true, nil
+}
@@ -0,0 +1,175 @@
+package sharding
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/runtime/remote"
+)
+
+// DefaultNumShards is used when a Coordinator is created with numShards <= 0.
+const DefaultNumShards = 128
+
+// Allocation maps every shard to the node that owns it.
+type Allocation map[ShardID]string
+
+// Coordinator owns the shard-to-node allocation for a cluster. Allocation is a
+// pure function of the membership, so independent coordinators on different
+// nodes agree without talking to each other as long as they see the same members.
+type Coordinator struct {
+	discovery    remote.Discovery
+	alloc        Allocation
+	listeners    []func(prev, next Allocation)
+	members      []string
+	numShards    int
+	virtualNodes int
+	mu           sync.RWMutex
+}
+
+// NewCoordinator creates a coordinator that reads membership from discovery.
+// The initial allocation is computed immediately.
+func NewCoordinator(discovery remote.Discovery, numShards int) *Coordinator {
+	if numShards <= 0 {
+		numShards = DefaultNumShards
+	}
+
+	c := &Coordinator{
+		discovery:    discovery,
+		numShards:    numShards,
+		virtualNodes: DefaultVirtualNodes,
+		alloc:        Allocation{},
+	}
+	c.Refresh()
+
+	return c
+}
+
+// NumShards returns the fixed number of shards.
+func (c *Coordinator) NumShards() int { return c.numShards }
+
+// ShardFor returns the shard of entityID.
+func (c *Coordinator) ShardFor(entityID string) ShardID { return ShardFor(entityID, c.numShards) }
+
+// Refresh re-reads membership from discovery and rebalances if it changed.
+func (c *Coordinator) Refresh() bool {
+	if c.discovery == nil {
+		return false
+	}
+
+	members := c.discovery.Members()
+	names := make([]string, 0, len(members))
+
+	for name := range members {
+		names = append(names, name)
+	}
+
+	return c.SetMembers(names)
+}
+
+// SetMembers replaces the membership and rebalances shards when it differs
+// from the current one. Listeners are notified outside the coordinator lock.
+func (c *Coordinator) SetMembers(members []string) bool {
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+
+	c.mu.Lock()
+	if equalStrings(sorted, c.members) {
+		c.mu.Unlock()
+
+		return false
+	}
+
+	ring := NewHashRing(sorted, c.virtualNodes)
+	next := make(Allocation, c.numShards)
+
+	for s := 0; s < c.numShards; s++ {
+		next[ShardID(s)] = ring.Owner("shard-" + strconv.Itoa(s))
+	}
+
+	prev := c.alloc
+	c.alloc = next
+	c.members = sorted
+	listeners := append([]func(prev, next Allocation){}, c.listeners...)
+	c.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(prev, next)
+	}
+
+	return true
+}
+
+// OwnerOf returns the node that owns shard.
+func (c *Coordinator) OwnerOf(shard ShardID) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	owner, ok := c.alloc[shard]
+
+	return owner, ok && owner != ""
+}
+
+// Allocation returns a copy of the current allocation.
+func (c *Coordinator) Allocation() Allocation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make(Allocation, len(c.alloc))
+	for k, v := range c.alloc {
+		out[k] = v
+	}
+
+	return out
+}
+
+// Members returns the sorted membership the allocation was computed from.
+func (c *Coordinator) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]string(nil), c.members...)
+}
+
+// Subscribe registers fn to be called after every rebalance.
+func (c *Coordinator) Subscribe(fn func(prev, next Allocation)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.listeners = append(c.listeners, fn)
+}
+
+// Run polls discovery every interval until ctx is cancelled.
+func (c *Coordinator) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Refresh()
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,371 @@
+package sharding
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+	"github.com/orizon-lang/orizon/internal/runtime/remote"
+)
+
+// MsgShardEnvelope is the message type regions use to forward entity messages
+// to each other.
+const MsgShardEnvelope rt.MessageType = 0xFFFF0101
+
+// maxForwardHops bounds re-forwarding while nodes disagree on the allocation;
+// a message forwarded that often is dropped with an error.
+const maxForwardHops = 3
+
+// ShardEnvelope carries an entity message between regions.
+type ShardEnvelope struct {
+	EntityID    string `json:"entityId" remote:"1"`
+	Payload     []byte `json:"payload,omitempty" remote:"2"`
+	MessageType uint32 `json:"messageType" remote:"3"`
+	Hops        uint32 `json:"hops,omitempty" remote:"4"`
+	Raw         bool   `json:"raw,omitempty" remote:"5"`
+}
+
+// EntityFactory creates the behavior for a newly spawned entity.
+type EntityFactory func(entityID string) rt.ActorBehavior
+
+// RegionConfig configures a Region.
+type RegionConfig struct {
+	// Remote forwards messages for shards owned by other nodes. It may be nil
+	// for single-node use.
+	Remote      *remote.RemoteSystem
+	System      *rt.ActorSystem
+	Coordinator *Coordinator
+	Factory     EntityFactory
+	// Codec encodes payloads of forwarded messages; defaults to Remote.Default.
+	Codec remote.Codec
+	// Types decodes forwarded payloads into typed values by message type;
+	// defaults to Remote.Types. Unregistered payloads are delivered as bytes.
+	Types    *remote.TypeRegistry
+	TypeName string
+	NodeName string
+	// IdleTimeout passivates entities that received no message for this long.
+	// Zero disables automatic passivation.
+	IdleTimeout time.Duration
+	ActorConfig rt.ActorConfig
+}
+
+// Region hosts the entities of one entity type on the local node.
+type Region struct {
+	entities map[string]*entity
+	stop     chan struct{}
+	cfg      RegionConfig
+	spawned  uint64
+	regionID rt.ActorID
+	mu       sync.Mutex
+	started  bool
+}
+
+type entity struct {
+	lastActive time.Time
+	id         rt.ActorID
+	shard      ShardID
+}
+
+// RegionActorName is the registered name of the region actor for typeName.
+func RegionActorName(typeName string) string { return "sharding/" + typeName }
+
+// EntityActorName is the registered name of an entity actor.
+func EntityActorName(typeName, entityID string) string {
+	return "sharding/" + typeName + "/" + entityID
+}
+
+// NewRegion validates cfg and returns a region that is not yet started.
+func NewRegion(cfg RegionConfig) (*Region, error) {
+	if cfg.System == nil || cfg.Coordinator == nil || cfg.Factory == nil {
+		return nil, fmt.Errorf("sharding: region requires System, Coordinator and Factory")
+	}
+
+	if cfg.TypeName == "" || cfg.NodeName == "" {
+		return nil, fmt.Errorf("sharding: region requires TypeName and NodeName")
+	}
+
+	if cfg.Remote != nil {
+		if cfg.Codec == nil {
+			cfg.Codec = cfg.Remote.Default
+		}
+
+		if cfg.Types == nil {
+			cfg.Types = cfg.Remote.Types
+		}
+	}
+
+	if cfg.Codec == nil {
+		cfg.Codec = remote.JSONCodec{}
+	}
+
+	if cfg.ActorConfig.MailboxCapacity == 0 {
+		cfg.ActorConfig = rt.DefaultActorConfig
+	}
+
+	return &Region{cfg: cfg, entities: make(map[string]*entity)}, nil
+}
+
+// Start registers the region actor, subscribes to rebalances and starts the
+// passivation loop.
+func (r *Region) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started {
+		return fmt.Errorf("sharding: region %s already started", r.cfg.TypeName)
+	}
+
+	if r.cfg.Remote != nil && r.cfg.Remote.Types != nil {
+		if err := r.cfg.Remote.Types.Register(uint32(MsgShardEnvelope), ShardEnvelope{}); err != nil {
+			return err
+		}
+	}
+
+	actor, err := r.cfg.System.CreateActor(RegionActorName(r.cfg.TypeName), rt.SystemActor, &regionBehavior{region: r}, rt.DefaultActorConfig)
+	if err != nil {
+		return fmt.Errorf("sharding: create region actor: %w", err)
+	}
+
+	r.regionID = actor.ID
+	r.started = true
+	r.stop = make(chan struct{})
+
+	r.cfg.Coordinator.Subscribe(r.onRebalance)
+
+	if r.cfg.IdleTimeout > 0 {
+		go r.passivationLoop(r.stop)
+	}
+
+	return nil
+}
+
+// Stop passivates all local entities and stops the region actor.
+func (r *Region) Stop() error {
+	r.mu.Lock()
+	if !r.started {
+		r.mu.Unlock()
+
+		return nil
+	}
+
+	r.started = false
+	close(r.stop)
+
+	for id := range r.entities {
+		r.passivateLocked(id)
+	}
+
+	regionID := r.regionID
+	r.mu.Unlock()
+
+	return r.cfg.System.StopActor(regionID)
+}
+
+// Tell routes a message to entityID, spawning it on its owning node if needed.
+func (r *Region) Tell(entityID string, msgType rt.MessageType, payload interface{}) error {
+	return r.route(entityID, msgType, payload, 0)
+}
+
+func (r *Region) route(entityID string, msgType rt.MessageType, payload interface{}, hops uint32) error {
+	shard := r.cfg.Coordinator.ShardFor(entityID)
+
+	owner, ok := r.cfg.Coordinator.OwnerOf(shard)
+	if !ok {
+		return fmt.Errorf("sharding: no owner for shard %d", shard)
+	}
+
+	if owner == r.cfg.NodeName {
+		return r.deliverLocal(entityID, shard, msgType, payload)
+	}
+
+	// Spawning here would start a second instance of the entity next to
+	// the owner's.
+	if hops >= maxForwardHops {
+		return fmt.Errorf("sharding: dropped message for %s after %d forwards: shard %d is owned by %s", entityID, hops, shard, owner)
+	}
+
+	if r.cfg.Remote == nil {
+		return fmt.Errorf("sharding: shard %d is owned by %s but region has no remote", shard, owner)
+	}
+
+	env := ShardEnvelope{EntityID: entityID, MessageType: uint32(msgType), Hops: hops + 1}
+
+	if b, isBytes := payload.([]byte); isBytes {
+		env.Payload, env.Raw = b, true
+	} else if payload != nil {
+		b, err := r.cfg.Codec.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("sharding: encode payload: %w", err)
+		}
+
+		env.Payload = b
+	}
+
+	return r.cfg.Remote.Send(owner, RegionActorName(r.cfg.TypeName), uint32(MsgShardEnvelope), env)
+}
+
+// receiveEnvelope handles a message forwarded by another region.
+func (r *Region) receiveEnvelope(env ShardEnvelope) error {
+	var payload interface{} = env.Payload
+
+	if !env.Raw && r.cfg.Types != nil {
+		v, registered, err := r.cfg.Types.Decode(r.cfg.Codec, env.MessageType, env.Payload)
+		if err != nil {
+			return fmt.Errorf("sharding: decode payload for %s: %w", env.EntityID, err)
+		}
+
+		if registered {
+			payload = v
+		}
+	}
+
+	return r.route(env.EntityID, rt.MessageType(env.MessageType), payload, env.Hops)
+}
+
+func (r *Region) deliverLocal(entityID string, shard ShardID, msgType rt.MessageType, payload interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started {
+		return fmt.Errorf("sharding: region %s is not running", r.cfg.TypeName)
+	}
+
+	e, ok := r.entities[entityID]
+	if !ok {
+		actor, err := r.cfg.System.CreateActor(EntityActorName(r.cfg.TypeName, entityID), rt.UserActor, r.cfg.Factory(entityID), r.cfg.ActorConfig)
+		if err != nil {
+			return fmt.Errorf("sharding: spawn entity %s: %w", entityID, err)
+		}
+
+		e = &entity{id: actor.ID, shard: shard}
+		r.entities[entityID] = e
+		r.spawned++
+	}
+
+	e.lastActive = time.Now()
+
+	return r.cfg.System.SendMessage(r.regionID, e.id, msgType, payload)
+}
+
+// Passivate stops a local entity. It is spawned again on its next message.
+func (r *Region) Passivate(entityID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.passivateLocked(entityID)
+}
+
+// PassivateIdle stops entities idle since before now-IdleTimeout and returns
+// how many were stopped.
+func (r *Region) PassivateIdle(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+
+	for id, e := range r.entities {
+		if now.Sub(e.lastActive) >= r.cfg.IdleTimeout && r.passivateLocked(id) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (r *Region) passivateLocked(entityID string) bool {
+	e, ok := r.entities[entityID]
+	if !ok {
+		return false
+	}
+
+	delete(r.entities, entityID)
+	_ = r.cfg.System.StopActor(e.id)
+
+	return true
+}
+
+// onRebalance hands off shards that moved away from this node by
+// passivating their entities; the new owner spawns them on demand.
+func (r *Region) onRebalance(_, next Allocation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, e := range r.entities {
+		if next[e.shard] != r.cfg.NodeName {
+			r.passivateLocked(id)
+		}
+	}
+}
+
+func (r *Region) passivationLoop(stop <-chan struct{}) {
+	interval := r.cfg.IdleTimeout / 2
+	if interval <= 0 {
+		interval = r.cfg.IdleTimeout
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			r.PassivateIdle(now)
+		}
+	}
+}
+
+// Entities returns the IDs of entities currently alive on this node.
+func (r *Region) Entities() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]string, 0, len(r.entities))
+	for id := range r.entities {
+		out = append(out, id)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// SpawnCount returns how many entities this region has spawned in total.
+func (r *Region) SpawnCount() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.spawned
+}
+
+// regionBehavior receives envelopes forwarded from other nodes.
+type regionBehavior struct{ region *Region }
+
+func (b *regionBehavior) Receive(_ *rt.ActorContext, msg rt.Message) error {
+	if msg.Type != MsgShardEnvelope {
+		return nil
+	}
+
+	switch p := msg.Payload.(type) {
+	case ShardEnvelope:
+		return b.region.receiveEnvelope(p)
+	case []byte:
+		var env ShardEnvelope
+		if err := b.region.cfg.Codec.Unmarshal(p, &env); err != nil {
+			return fmt.Errorf("sharding: decode envelope: %w", err)
+		}
+
+		return b.region.receiveEnvelope(env)
+	default:
+		return fmt.Errorf("sharding: unexpected envelope payload %T", msg.Payload)
+	}
+}
+
+func (b *regionBehavior) PreStart(*rt.ActorContext) error                       { return nil }
+func (b *regionBehavior) PostStop(*rt.ActorContext) error                       { return nil }
+func (b *regionBehavior) PreRestart(*rt.ActorContext, error, *rt.Message) error { return nil }
+func (b *regionBehavior) PostRestart(*rt.ActorContext, error) error             { return nil }
+func (b *regionBehavior) GetBehaviorName() string                               { return "shard-region" }
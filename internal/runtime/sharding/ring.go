@@ -0,0 +1,99 @@
+// Package sharding distributes entity actors across the nodes of a cluster.
+//
+// Entity IDs are hashed onto a fixed number of shards, shards are placed on
+// nodes by a consistent hash ring, and every node runs a Region that spawns
+// entities lazily on first message, passivates idle ones, and forwards
+// messages for shards it does not own through remote.RemoteSystem.
+package sharding
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// ShardID identifies a shard.
+type ShardID uint32
+
+// DefaultVirtualNodes is the number of ring points per member.
+const DefaultVirtualNodes = 64
+
+// ShardFor maps an entity ID onto one of numShards shards.
+func ShardFor(entityID string, numShards int) ShardID {
+	if numShards <= 0 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(entityID))
+
+	return ShardID(h.Sum32() % uint32(numShards))
+}
+
+// HashRing is an immutable consistent hash ring over node names.
+// Adding or removing a member only moves the keys adjacent to its points.
+type HashRing struct {
+	owners map[uint64]string
+	points []uint64
+}
+
+// NewHashRing builds a ring with virtualNodes points per member.
+func NewHashRing(members []string, virtualNodes int) *HashRing {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+
+	r := &HashRing{owners: make(map[uint64]string, len(members)*virtualNodes)}
+
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+
+	for _, m := range sorted {
+		for v := 0; v < virtualNodes; v++ {
+			p := hash64(m + "#" + strconv.Itoa(v))
+			// On the (unlikely) collision keep the lexicographically first owner
+			// so every node computes the same ring.
+			if _, taken := r.owners[p]; taken {
+				continue
+			}
+
+			r.owners[p] = m
+			r.points = append(r.points, p)
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+
+	return r
+}
+
+// Owner returns the member responsible for key, or "" for an empty ring.
+func (r *HashRing) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	h := hash64(key)
+
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[r.points[i]]
+}
+
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	// FNV-1a clusters nearby inputs; a final avalanche step spreads ring points.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
@@ -0,0 +1,310 @@
+package sharding
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+	"github.com/orizon-lang/orizon/internal/runtime/remote"
+)
+
+type delivery struct {
+	node    string
+	entity  string
+	payload interface{}
+}
+
+type entityBehavior struct {
+	out    chan<- delivery
+	node   string
+	entity string
+}
+
+func (b *entityBehavior) Receive(_ *rt.ActorContext, msg rt.Message) error {
+	b.out <- delivery{node: b.node, entity: b.entity, payload: msg.Payload}
+	return nil
+}
+func (b *entityBehavior) PreStart(*rt.ActorContext) error                       { return nil }
+func (b *entityBehavior) PostStop(*rt.ActorContext) error                       { return nil }
+func (b *entityBehavior) PreRestart(*rt.ActorContext, error, *rt.Message) error { return nil }
+func (b *entityBehavior) PostRestart(*rt.ActorContext, error) error             { return nil }
+func (b *entityBehavior) GetBehaviorName() string                               { return "entity" }
+
+type dispatcher struct{ sys *rt.ActorSystem }
+
+func (d dispatcher) SendMessage(sid, rid uint64, mt uint32, p interface{}) error {
+	return d.sys.SendMessage(rt.ActorID(sid), rt.ActorID(rid), rt.MessageType(mt), p)
+}
+
+func (d dispatcher) LookupActorID(name string) (uint64, bool) {
+	id, ok := d.sys.LookupActorID(name)
+	return uint64(id), ok
+}
+
+func (d dispatcher) Lookup(name string) (uint64, bool) { return d.LookupActorID(name) }
+
+type counter struct {
+	Count int `remote:"1"`
+}
+
+type testNode struct {
+	sys    *rt.ActorSystem
+	rs     *remote.RemoteSystem
+	coord  *Coordinator
+	region *Region
+	name   string
+}
+
+func startNode(t *testing.T, name string, disc remote.Discovery, out chan<- delivery) *testNode {
+	t.Helper()
+
+	sys, err := rt.NewActorSystem(rt.DefaultActorSystemConfig)
+	if err != nil {
+		t.Fatalf("%s: new system: %v", name, err)
+	}
+
+	if err := sys.Start(); err != nil {
+		t.Fatalf("%s: start system: %v", name, err)
+	}
+
+	types := remote.NewTypeRegistry()
+	if err := types.Register(7, counter{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	rs := &remote.RemoteSystem{
+		Trans: &remote.InMemoryTransport{}, Default: remote.BinaryCodec{}, Local: dispatcher{sys},
+		Resolver: dispatcher{sys}, Discover: disc, Types: types, RetryMaxAttempts: 1,
+	}
+	if err := rs.Start(name, "sharding-test/"+name); err != nil {
+		t.Fatalf("%s: start remote: %v", name, err)
+	}
+
+	sys.Remote = rs
+
+	n := &testNode{sys: sys, rs: rs, name: name, coord: NewCoordinator(disc, 32)}
+
+	n.region, err = NewRegion(RegionConfig{
+		System: sys, Remote: rs, Coordinator: n.coord, TypeName: "counter", NodeName: name,
+		Factory: func(id string) rt.ActorBehavior { return &entityBehavior{out: out, node: name, entity: id} },
+	})
+	if err != nil {
+		t.Fatalf("%s: new region: %v", name, err)
+	}
+
+	if err := n.region.Start(); err != nil {
+		t.Fatalf("%s: start region: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		_ = n.region.Stop()
+		_ = rs.Stop()
+		_ = sys.Stop()
+	})
+
+	return n
+}
+
+func collect(t *testing.T, ch <-chan delivery, n int) []delivery {
+	t.Helper()
+
+	out := make([]delivery, 0, n)
+
+	for len(out) < n {
+		select {
+		case d := <-ch:
+			out = append(out, d)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d of %d deliveries", len(out), n)
+		}
+	}
+
+	return out
+}
+
+func refreshAll(nodes ...*testNode) {
+	for _, n := range nodes {
+		n.coord.Refresh()
+	}
+}
+
+func TestSharding_RoutesSpawnsAndRebalances(t *testing.T) {
+	disc := remote.NewStaticDiscovery()
+	out := make(chan delivery, 256)
+
+	a := startNode(t, "A", disc, out)
+	b := startNode(t, "B", disc, out)
+	c := startNode(t, "C", disc, out)
+	refreshAll(a, b, c)
+
+	if len(a.region.Entities())+len(b.region.Entities())+len(c.region.Entities()) != 0 {
+		t.Fatal("entities must be spawned lazily")
+	}
+
+	const numEntities = 40
+
+	for i := 0; i < numEntities; i++ {
+		if err := a.region.Tell(fmt.Sprintf("user-%d", i), 7, counter{Count: i}); err != nil {
+			t.Fatalf("tell: %v", err)
+		}
+	}
+
+	byNode := map[string]int{}
+
+	for _, d := range collect(t, out, numEntities) {
+		shard := a.coord.ShardFor(d.entity)
+		if owner, _ := a.coord.OwnerOf(shard); owner != d.node {
+			t.Fatalf("%s delivered on %s, owner is %s", d.entity, d.node, owner)
+		}
+
+		if _, ok := d.payload.(counter); !ok {
+			t.Fatalf("payload not decoded to counter: %T", d.payload)
+		}
+
+		byNode[d.node]++
+	}
+
+	if len(byNode) < 2 {
+		t.Fatalf("entities not spread across nodes: %v", byNode)
+	}
+
+	// A node joins; only shards that move to it may change owner.
+	before := a.coord.Allocation()
+	d := startNode(t, "D", disc, out)
+	refreshAll(a, b, c, d)
+
+	after := a.coord.Allocation()
+	moved := 0
+
+	for s, owner := range after {
+		if owner != before[s] {
+			if owner != "D" {
+				t.Fatalf("shard %d moved %s -> %s, expected only moves to D", s, before[s], owner)
+			}
+
+			moved++
+		}
+	}
+
+	if moved == 0 {
+		t.Fatal("no shards rebalanced to the new node")
+	}
+
+	for _, n := range []*testNode{a, b, c} {
+		for _, id := range n.region.Entities() {
+			if owner, _ := n.coord.OwnerOf(n.coord.ShardFor(id)); owner != n.name {
+				t.Fatalf("%s still hosts %s owned by %s", n.name, id, owner)
+			}
+		}
+	}
+
+	for i := 0; i < numEntities; i++ {
+		if err := b.region.Tell(fmt.Sprintf("user-%d", i), 7, []byte("raw")); err != nil {
+			t.Fatalf("tell after rebalance: %v", err)
+		}
+	}
+
+	onD := 0
+
+	for _, dl := range collect(t, out, numEntities) {
+		if owner, _ := b.coord.OwnerOf(b.coord.ShardFor(dl.entity)); owner != dl.node {
+			t.Fatalf("%s delivered on %s after rebalance, owner is %s", dl.entity, dl.node, owner)
+		}
+
+		if b, _ := dl.payload.([]byte); string(b) != "raw" {
+			t.Fatalf("raw payload altered: %v", dl.payload)
+		}
+
+		if dl.node == "D" {
+			onD++
+		}
+	}
+
+	if onD == 0 || len(d.region.Entities()) != onD {
+		t.Fatalf("new node hosts %d entities, received %d messages", len(d.region.Entities()), onD)
+	}
+}
+
+func TestSharding_Passivation(t *testing.T) {
+	disc := remote.NewStaticDiscovery()
+	out := make(chan delivery, 16)
+
+	n := startNode(t, "P", disc, out)
+	n.region.cfg.IdleTimeout = time.Minute
+	n.coord.Refresh()
+
+	if err := n.region.Tell("session-1", 7, counter{Count: 1}); err != nil {
+		t.Fatalf("tell: %v", err)
+	}
+
+	collect(t, out, 1)
+
+	if got := n.region.PassivateIdle(time.Now()); got != 0 {
+		t.Fatalf("active entity passivated early: %d", got)
+	}
+
+	if got := n.region.PassivateIdle(time.Now().Add(2 * time.Minute)); got != 1 {
+		t.Fatalf("expected 1 passivated entity, got %d", got)
+	}
+
+	if _, ok := n.sys.LookupActorID(EntityActorName("counter", "session-1")); ok {
+		t.Fatal("passivated entity still registered")
+	}
+
+	if err := n.region.Tell("session-1", 7, counter{Count: 2}); err != nil {
+		t.Fatalf("tell after passivation: %v", err)
+	}
+
+	collect(t, out, 1)
+
+	if n.region.SpawnCount() != 2 {
+		t.Fatalf("expected respawn, spawn count %d", n.region.SpawnCount())
+	}
+}
+
+func TestHashRing_Stable(t *testing.T) {
+	r1 := NewHashRing([]string{"a", "b", "c"}, 0)
+	r2 := NewHashRing([]string{"c", "a", "b"}, 0)
+
+	counts := map[string]int{}
+
+	for i := 0; i < 1000; i++ {
+		k := fmt.Sprintf("k%d", i)
+		if r1.Owner(k) != r2.Owner(k) {
+			t.Fatalf("rings disagree on %s", k)
+		}
+
+		counts[r1.Owner(k)]++
+	}
+
+	for _, m := range []string{"a", "b", "c"} {
+		if counts[m] < 150 {
+			t.Fatalf("poor balance: %v", counts)
+		}
+	}
+}
+
+func TestSharding_ForwardLimitDoesNotSpawnOnNonOwner(t *testing.T) {
+	disc := remote.NewStaticDiscovery()
+	out := make(chan delivery, 16)
+
+	n := startNode(t, "P", disc, out)
+	n.coord.SetMembers([]string{"P", "Q"})
+
+	entity := ""
+	for i := 0; entity == ""; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		if owner, _ := n.coord.OwnerOf(n.coord.ShardFor(id)); owner == "Q" {
+			entity = id
+		}
+	}
+
+	if err := n.region.route(entity, 7, counter{Count: 1}, maxForwardHops); err == nil {
+		t.Fatal("expected an error once the forward limit is reached")
+	}
+
+	if n.region.SpawnCount() != 0 || len(n.region.Entities()) != 0 {
+		t.Fatalf("entity spawned on a node that does not own it: %v", n.region.Entities())
+	}
+}
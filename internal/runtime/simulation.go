@@ -0,0 +1,356 @@
+package runtime
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Deterministic simulation support.
+//
+// When ActorSystemConfig.Simulation is set, the actor system starts no worker,
+// heartbeat or GC goroutines. Message dispatch, actor timers and maintenance
+// ticks become events of a Simulator, which runs them one at a time on the
+// caller's goroutine against a virtual clock. Which runnable actor goes next is
+// chosen by a seeded PRNG, so a failing interleaving is replayed by rerunning
+// with the same seed.
+
+// DefaultSimulationMaxSteps bounds a simulation run to catch livelocks.
+const DefaultSimulationMaxSteps = 1_000_000
+
+// simEpoch is the virtual time at which every simulation starts.
+var simEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Simulator is a seeded, single-threaded event loop with a virtual clock.
+// It is not safe for concurrent use; all simulated systems must be driven
+// from the goroutine that calls Step or Run*.
+type Simulator struct {
+	now      time.Time
+	rng      *rand.Rand
+	timers   simTimerHeap
+	runnable []simTask
+	trace    []string
+	seed     int64
+	seq      uint64
+	steps    uint64
+	// MaxSteps aborts Run* with an error after this many events.
+	MaxSteps uint64
+	// TraceLimit caps the number of recorded event labels (0 disables tracing).
+	TraceLimit int
+}
+
+type simTask struct {
+	fn    func()
+	label string
+}
+
+type simTimer struct {
+	at        time.Time
+	fn        func()
+	label     string
+	seq       uint64
+	index     int
+	daemon    bool
+	cancelled bool
+}
+
+// NewSimulator creates a simulator whose scheduling decisions derive from seed.
+func NewSimulator(seed int64) *Simulator {
+	return &Simulator{
+		seed:       seed,
+		rng:        rand.New(rand.NewSource(seed)),
+		now:        simEpoch,
+		MaxSteps:   DefaultSimulationMaxSteps,
+		TraceLimit: 256,
+	}
+}
+
+// Seed returns the seed the simulator was created with.
+func (s *Simulator) Seed() int64 { return s.seed }
+
+// Now returns the current virtual time.
+func (s *Simulator) Now() time.Time { return s.now }
+
+// Rand exposes the simulator PRNG so scenarios can make seeded choices too.
+func (s *Simulator) Rand() *rand.Rand { return s.rng }
+
+// Steps returns the number of events executed so far.
+func (s *Simulator) Steps() uint64 { return s.steps }
+
+// Trace returns the labels of the most recently executed events.
+func (s *Simulator) Trace() []string { return append([]string(nil), s.trace...) }
+
+// Enqueue adds an immediately runnable task. Runnable tasks are picked in
+// seeded random order.
+func (s *Simulator) Enqueue(label string, fn func()) {
+	s.runnable = append(s.runnable, simTask{label: label, fn: fn})
+}
+
+// Schedule runs fn once the virtual clock has advanced by delay.
+func (s *Simulator) Schedule(delay time.Duration, fn func()) {
+	s.addTimer(delay, "event", false, fn)
+}
+
+// AfterFunc is the virtual-time analogue of time.AfterFunc. The returned
+// function cancels the timer and reports whether it was still pending.
+func (s *Simulator) AfterFunc(delay time.Duration, fn func()) (stop func() bool) {
+	t := s.addTimer(delay, "timer", false, fn)
+
+	return func() bool {
+		if t.cancelled || t.index < 0 {
+			return false
+		}
+
+		t.cancelled = true
+
+		return true
+	}
+}
+
+// every arms a periodic daemon timer; daemon timers do not keep RunUntilIdle going.
+func (s *Simulator) every(interval time.Duration, label string, fn func() bool) {
+	if interval <= 0 {
+		return
+	}
+
+	var tick func()
+
+	tick = func() {
+		if fn() {
+			s.addTimer(interval, label, true, tick)
+		}
+	}
+
+	s.addTimer(interval, label, true, tick)
+}
+
+func (s *Simulator) addTimer(delay time.Duration, label string, daemon bool, fn func()) *simTimer {
+	if delay < 0 {
+		delay = 0
+	}
+
+	s.seq++
+	t := &simTimer{at: s.now.Add(delay), fn: fn, label: label, seq: s.seq, daemon: daemon}
+	heap.Push(&s.timers, t)
+
+	return t
+}
+
+// Step executes one event: a random runnable task if there is one, otherwise
+// the earliest timer, advancing the virtual clock to its deadline.
+// It reports false when nothing is left to do.
+func (s *Simulator) Step() bool {
+	if len(s.runnable) > 0 {
+		i := s.rng.Intn(len(s.runnable))
+		task := s.runnable[i]
+		last := len(s.runnable) - 1
+		s.runnable[i] = s.runnable[last]
+		s.runnable = s.runnable[:last]
+		s.record(task.label)
+		task.fn()
+
+		return true
+	}
+
+	for s.timers.Len() > 0 {
+		t := heap.Pop(&s.timers).(*simTimer)
+		if t.cancelled {
+			continue
+		}
+
+		if t.at.After(s.now) {
+			s.now = t.at
+		}
+
+		s.record(t.label)
+		t.fn()
+
+		return true
+	}
+
+	return false
+}
+
+func (s *Simulator) record(label string) {
+	s.steps++
+
+	if s.TraceLimit <= 0 {
+		return
+	}
+
+	if len(s.trace) >= s.TraceLimit {
+		s.trace = s.trace[1:]
+	}
+
+	s.trace = append(s.trace, fmt.Sprintf("%d@%s %s", s.steps, s.now.Sub(simEpoch), label))
+}
+
+// pendingWork reports whether runnable tasks or non-daemon timers remain.
+func (s *Simulator) pendingWork() bool {
+	if len(s.runnable) > 0 {
+		return true
+	}
+
+	for _, t := range s.timers {
+		if !t.daemon && !t.cancelled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunUntilIdle runs until only periodic maintenance timers remain.
+func (s *Simulator) RunUntilIdle() error {
+	for s.pendingWork() {
+		if err := s.checkBudget(); err != nil {
+			return err
+		}
+
+		s.Step()
+	}
+
+	return nil
+}
+
+// RunFor runs every event due within the next d of virtual time and leaves
+// the clock at now+d.
+func (s *Simulator) RunFor(d time.Duration) error {
+	deadline := s.now.Add(d)
+
+	for {
+		if err := s.checkBudget(); err != nil {
+			return err
+		}
+
+		if len(s.runnable) == 0 {
+			next, ok := s.nextTimer()
+			if !ok || next.After(deadline) {
+				break
+			}
+		}
+
+		s.Step()
+	}
+
+	s.now = deadline
+
+	return nil
+}
+
+// RunUntil runs until cond holds, failing if it does not within limit of
+// virtual time or the simulation runs out of work.
+func (s *Simulator) RunUntil(cond func() bool, limit time.Duration) error {
+	deadline := s.now.Add(limit)
+
+	for !cond() {
+		if err := s.checkBudget(); err != nil {
+			return err
+		}
+
+		if len(s.runnable) == 0 {
+			next, ok := s.nextTimer()
+			if !ok {
+				return fmt.Errorf("simulation idle before condition held")
+			}
+
+			if next.After(deadline) {
+				return fmt.Errorf("condition did not hold within %s of virtual time", limit)
+			}
+		}
+
+		s.Step()
+	}
+
+	return nil
+}
+
+func (s *Simulator) nextTimer() (time.Time, bool) {
+	for s.timers.Len() > 0 {
+		if t := s.timers[0]; !t.cancelled {
+			return t.at, true
+		}
+
+		heap.Pop(&s.timers)
+	}
+
+	return time.Time{}, false
+}
+
+func (s *Simulator) checkBudget() error {
+	if s.MaxSteps > 0 && s.steps >= s.MaxSteps {
+		return fmt.Errorf("simulation exceeded %d steps (possible livelock)", s.MaxSteps)
+	}
+
+	return nil
+}
+
+// SimulationError reports a failed simulation together with the seed needed
+// to replay it.
+type SimulationError struct {
+	Err   error
+	Trace []string
+	Seed  int64
+	Steps uint64
+}
+
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("simulation failed (seed=%d, steps=%d): %v", e.Seed, e.Steps, e.Err)
+}
+
+func (e *SimulationError) Unwrap() error { return e.Err }
+
+// RunSimulation creates a simulator for seed, runs scenario and wraps any
+// failure, including a panic, in a *SimulationError carrying the seed.
+func RunSimulation(seed int64, scenario func(sim *Simulator) error) (err error) {
+	sim := NewSimulator(seed)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = &SimulationError{Seed: seed, Steps: sim.steps, Trace: sim.Trace(), Err: fmt.Errorf("panic: %v", r)}
+		}
+	}()
+
+	if e := scenario(sim); e != nil {
+		return &SimulationError{Seed: seed, Steps: sim.steps, Trace: sim.Trace(), Err: e}
+	}
+
+	return nil
+}
+
+// simTimerHeap orders timers by deadline, then by creation order.
+type simTimerHeap []*simTimer
+
+func (h simTimerHeap) Len() int { return len(h) }
+
+func (h simTimerHeap) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+
+	return h[i].at.Before(h[j].at)
+}
+
+func (h simTimerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *simTimerHeap) Push(x interface{}) {
+	t := x.(*simTimer)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+
+func (h *simTimerHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*h = old[:n-1]
+
+	return t
+}
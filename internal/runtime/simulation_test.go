@@ -0,0 +1,188 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// orderBehavior appends every received payload to a shared log.
+type orderBehavior struct {
+	log  *[]string
+	name string
+}
+
+func (b *orderBehavior) Receive(ctx *ActorContext, msg Message) error {
+	*b.log = append(*b.log, fmt.Sprintf("%s:%v", b.name, msg.Payload))
+
+	return nil
+}
+
+func (b *orderBehavior) PreStart(ctx *ActorContext) error { return nil }
+func (b *orderBehavior) PostStop(ctx *ActorContext) error { return nil }
+func (b *orderBehavior) PreRestart(ctx *ActorContext, reason error, message *Message) error {
+	return nil
+}
+func (b *orderBehavior) PostRestart(ctx *ActorContext, reason error) error { return nil }
+func (b *orderBehavior) GetBehaviorName() string                           { return b.name }
+
+func simulatedOrder(t *testing.T, seed int64) []string {
+	t.Helper()
+
+	var log []string
+
+	err := RunSimulation(seed, func(sim *Simulator) error {
+		cfg := DefaultActorSystemConfig
+		cfg.Simulation = sim
+
+		system, err := NewActorSystem(cfg)
+		if err != nil {
+			return err
+		}
+
+		if err := system.Start(); err != nil {
+			return err
+		}
+
+		defer system.Stop()
+
+		ids := make([]ActorID, 3)
+
+		for i := range ids {
+			a, err := system.CreateActor(fmt.Sprintf("a%d", i), UserActor, &orderBehavior{log: &log, name: fmt.Sprintf("a%d", i)}, DefaultActorConfig)
+			if err != nil {
+				return err
+			}
+
+			ids[i] = a.ID
+		}
+
+		for n := 0; n < 5; n++ {
+			for _, id := range ids {
+				if err := system.SendMessage(0, id, 1, n); err != nil {
+					return err
+				}
+			}
+		}
+
+		return sim.RunUntilIdle()
+	})
+	if err != nil {
+		t.Fatalf("simulation: %v", err)
+	}
+
+	return log
+}
+
+func TestSimulation_ReplayableBySeed(t *testing.T) {
+	first := simulatedOrder(t, 42)
+	if len(first) != 15 {
+		t.Fatalf("expected 15 deliveries, got %d", len(first))
+	}
+
+	if again := simulatedOrder(t, 42); strings.Join(again, ",") != strings.Join(first, ",") {
+		t.Fatalf("same seed produced different interleavings:\n%v\n%v", first, again)
+	}
+
+	differs := false
+
+	for seed := int64(1); seed < 20 && !differs; seed++ {
+		differs = strings.Join(simulatedOrder(t, seed), ",") != strings.Join(first, ",")
+	}
+
+	if !differs {
+		t.Fatal("different seeds never changed the interleaving")
+	}
+
+	// Per-actor FIFO order must hold under every interleaving.
+	next := map[string]int{}
+
+	for _, entry := range first {
+		parts := strings.SplitN(entry, ":", 2)
+		if want := fmt.Sprint(next[parts[0]]); parts[1] != want {
+			t.Fatalf("%s received %s, want %s", parts[0], parts[1], want)
+		}
+
+		next[parts[0]]++
+	}
+}
+
+func TestSimulation_VirtualTimersAndHeartbeats(t *testing.T) {
+	sim := NewSimulator(7)
+
+	cfg := DefaultActorSystemConfig
+	cfg.Simulation = sim
+	cfg.HeartbeatInterval = time.Second
+
+	system, err := NewActorSystem(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	if err := system.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	defer system.Stop()
+
+	var log []string
+
+	a, err := system.CreateActor("timed", UserActor, &orderBehavior{log: &log, name: "timed"}, DefaultActorConfig)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	fired := time.Time{}
+	a.Context.StartTimer("tick", time.Hour, func() { fired = sim.Now() })
+
+	start := time.Now()
+
+	if err := sim.RunFor(2 * time.Hour); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("virtual time must not wait on the wall clock")
+	}
+
+	if got := fired.Sub(simEpoch); got != time.Hour {
+		t.Fatalf("timer fired at +%s, want +1h", got)
+	}
+
+	heartbeats := 0
+
+	for _, ev := range sim.Trace() {
+		if strings.HasSuffix(ev, " heartbeat") {
+			heartbeats++
+		}
+	}
+
+	if heartbeats == 0 {
+		t.Fatal("heartbeat monitor did not run in virtual time")
+	}
+
+	a.Context.StartTimer("never", time.Minute, func() { t.Error("stopped timer fired") })
+	a.Context.StopTimer("never")
+
+	if err := sim.RunFor(time.Hour); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+}
+
+func TestRunSimulation_ReportsSeed(t *testing.T) {
+	boom := errors.New("invariant violated")
+
+	err := RunSimulation(99, func(sim *Simulator) error { return boom })
+
+	var simErr *SimulationError
+	if !errors.As(err, &simErr) || simErr.Seed != 99 || !errors.Is(err, boom) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = RunSimulation(5, func(sim *Simulator) error { panic("kaboom") })
+	if !errors.As(err, &simErr) || simErr.Seed != 5 {
+		t.Fatalf("panic not reported with seed: %v", err)
+	}
+}
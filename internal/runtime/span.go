@@ -0,0 +1,340 @@
+package runtime
+
+import (
+	"context"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C Trace Context header carried in Message.Headers
+// and remote envelope headers.
+const TraceparentHeader = "traceparent"
+
+// SpanContext identifies a span within a trace (W3C Trace Context).
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both identifiers are non-zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent formats sc as a version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value.
+func ParseTraceparent(v string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+
+	// Version 00 has exactly four fields; later versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, sc.IsValid()
+}
+
+// Span describes the handling of one message by one actor.
+type Span struct {
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]string
+	Name          string
+	CorrelationID string
+	Error         string
+	TraceID       [16]byte
+	SpanID        [8]byte
+	ParentSpanID  [8]byte
+	Actor         ActorID
+	Sender        ActorID
+	MessageID     MessageID
+	MessageType   MessageType
+}
+
+// HasParent reports whether the span has a parent span.
+func (s Span) HasParent() bool { return s.ParentSpanID != [8]byte{} }
+
+// Context returns the span's own SpanContext.
+func (s Span) Context() SpanContext {
+	return SpanContext{TraceID: s.TraceID, SpanID: s.SpanID, Sampled: true}
+}
+
+// SpanExporter receives finished spans in batches.
+type SpanExporter interface {
+	ExportSpans(ctx context.Context, spans []Span) error
+}
+
+// spanPipeline batches finished spans for an exporter.
+type spanPipeline struct {
+	exporter  SpanExporter
+	batch     []Span
+	batchSize int
+	mu        sync.Mutex
+}
+
+// SetSpanExporter enables span generation for every handled message and sends
+// finished spans to exp in batches of batchSize (default 512). Spans still
+// buffered are exported by FlushSpans and when the system stops. Passing nil
+// disables span generation.
+func (as *ActorSystem) SetSpanExporter(exp SpanExporter, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = 512
+	}
+
+	var p *spanPipeline
+	if exp != nil {
+		p = &spanPipeline{exporter: exp, batchSize: batchSize}
+	}
+
+	as.mutex.Lock()
+	as.spans = p
+	as.mutex.Unlock()
+}
+
+// FlushSpans exports all buffered spans synchronously.
+func (as *ActorSystem) FlushSpans(ctx context.Context) error {
+	as.mutex.RLock()
+	p := as.spans
+	as.mutex.RUnlock()
+
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	batch := p.batch
+	p.batch = nil
+	p.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return p.exporter.ExportSpans(ctx, batch)
+}
+
+func (as *ActorSystem) spanPipeline() *spanPipeline {
+	as.mutex.RLock()
+	defer as.mutex.RUnlock()
+
+	return as.spans
+}
+
+// currentSpanContext returns the span the given actor is handling right now.
+func (as *ActorSystem) currentSpanContext(aid ActorID) (SpanContext, bool) {
+	if aid == 0 {
+		return SpanContext{}, false
+	}
+
+	as.mutex.RLock()
+	actor := as.actors[aid]
+	as.mutex.RUnlock()
+
+	if actor == nil {
+		return SpanContext{}, false
+	}
+
+	if sc := actor.activeSpan.Load(); sc != nil {
+		return *sc, true
+	}
+
+	return SpanContext{}, false
+}
+
+// propagateSpan attaches the sender's active span as traceparent so the
+// receiver's span becomes its child.
+func (as *ActorSystem) propagateSpan(msg *Message) {
+	if as.spanPipeline() == nil {
+		return
+	}
+
+	if _, ok := msg.Headers[TraceparentHeader]; ok {
+		return
+	}
+
+	if sc, ok := as.currentSpanContext(msg.Sender); ok {
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]interface{})
+		}
+
+		msg.Headers[TraceparentHeader] = sc.Traceparent()
+	}
+}
+
+// TraceparentFor returns the traceparent of the span sender is currently
+// handling, for propagation over transports that carry string headers.
+func (as *ActorSystem) TraceparentFor(sender ActorID) (string, bool) {
+	if as.spanPipeline() == nil {
+		return "", false
+	}
+
+	sc, ok := as.currentSpanContext(sender)
+	if !ok {
+		return "", false
+	}
+
+	return sc.Traceparent(), true
+}
+
+// beginSpan starts a span for msg on actor a, or returns nil when span export
+// is disabled. The trace is inherited from the traceparent header if present,
+// else derived from the correlation id, else freshly generated.
+func (as *ActorSystem) beginSpan(a *Actor, msg Message) *Span {
+	if as == nil || as.spanPipeline() == nil {
+		return nil
+	}
+
+	span := &Span{
+		StartTime:     as.Now(),
+		Name:          fmt.Sprintf("%s receive %d", a.Name, msg.Type),
+		CorrelationID: msg.CorrelationID,
+		Actor:         a.ID,
+		Sender:        msg.Sender,
+		MessageID:     msg.ID,
+		MessageType:   msg.Type,
+		Attributes: map[string]string{
+			"actor.name":       a.Name,
+			"actor.id":         fmt.Sprint(a.ID),
+			"message.type":     fmt.Sprint(msg.Type),
+			"message.id":       fmt.Sprint(msg.ID),
+			"message.sender":   fmt.Sprint(msg.Sender),
+			"actor.behavior":   a.Behavior.GetBehaviorName(),
+			"messaging.system": "orizon",
+		},
+	}
+
+	if msg.CorrelationID != "" {
+		span.Attributes["correlation.id"] = msg.CorrelationID
+	}
+
+	parentSet := false
+
+	if v, ok := msg.Headers[TraceparentHeader].(string); ok {
+		if parent, ok := ParseTraceparent(v); ok {
+			span.TraceID, span.ParentSpanID = parent.TraceID, parent.SpanID
+			parentSet = true
+		}
+	}
+
+	if !parentSet {
+		span.TraceID = as.traceIDFor(msg.CorrelationID)
+	}
+
+	as.randomBytes(span.SpanID[:])
+
+	sc := span.Context()
+	a.activeSpan.Store(&sc)
+
+	return span
+}
+
+// endSpan finishes span and hands it to the export pipeline.
+func (as *ActorSystem) endSpan(a *Actor, span *Span, err error) {
+	if span == nil {
+		return
+	}
+
+	a.activeSpan.Store(nil)
+
+	span.EndTime = as.Now()
+	if err != nil {
+		span.Error = err.Error()
+	}
+
+	p := as.spanPipeline()
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.batch = append(p.batch, *span)
+
+	var full []Span
+	if len(p.batch) >= p.batchSize {
+		full, p.batch = p.batch, nil
+	}
+	p.mu.Unlock()
+
+	if full == nil {
+		return
+	}
+
+	// Simulated systems export inline to stay on the simulator goroutine.
+	if as.config.Simulation != nil {
+		_ = p.exporter.ExportSpans(context.Background(), full)
+
+		return
+	}
+
+	go func() { _ = p.exporter.ExportSpans(context.Background(), full) }()
+}
+
+// traceIDFor maps a correlation id onto a trace id. Correlation ids from
+// NewCorrelationID are 32 hex characters and are used verbatim.
+func (as *ActorSystem) traceIDFor(correlationID string) [16]byte {
+	var id [16]byte
+
+	if correlationID == "" {
+		as.randomBytes(id[:])
+
+		return id
+	}
+
+	if len(correlationID) == 32 {
+		if _, err := hex.Decode(id[:], []byte(correlationID)); err == nil && id != [16]byte{} {
+			return id
+		}
+	}
+
+	sum := sha256.Sum256([]byte(correlationID))
+	copy(id[:], sum[:16])
+
+	return id
+}
+
+// randomBytes fills b from the simulator PRNG when simulating, otherwise from crypto/rand.
+func (as *ActorSystem) randomBytes(b []byte) {
+	if as.config.Simulation != nil {
+		as.config.Simulation.Rand().Read(b)
+	} else {
+		_, _ = crand.Read(b)
+	}
+
+	if b[0] == 0 {
+		b[0] = 1 // all-zero ids are invalid
+	}
+}
@@ -0,0 +1,331 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPHTTPExporter sends spans to an OpenTelemetry collector using the
+// OTLP/HTTP JSON encoding (POST {Endpoint}/v1/traces).
+type OTLPHTTPExporter struct {
+	// Client is used for requests; http.DefaultClient with a 10s timeout when nil.
+	Client *http.Client
+	// Headers are added to every request (e.g. authentication).
+	Headers map[string]string
+	// Endpoint is the collector base URL, e.g. "http://localhost:4318".
+	Endpoint string
+	// ServiceName populates the service.name resource attribute.
+	ServiceName string
+}
+
+// NewOTLPHTTPExporter creates an exporter for the collector at endpoint.
+func NewOTLPHTTPExporter(endpoint, serviceName string) *OTLPHTTPExporter {
+	return &OTLPHTTPExporter{Endpoint: endpoint, ServiceName: serviceName}
+}
+
+// OTLP span kind and status codes used by the exporter.
+const (
+	otlpSpanKindConsumer = 5
+	otlpStatusCodeError  = 2
+)
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Value otlpAnyValue `json:"value"`
+	Key   string       `json:"key"`
+}
+
+type otlpStatus struct {
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+type otlpSpan struct {
+	Status            *otlpStatus    `json:"status,omitempty"`
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Kind              int            `json:"kind"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// ExportSpans implements SpanExporter.
+func (e *OTLPHTTPExporter) ExportSpans(ctx context.Context, spans []Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("otlp: encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(e.Endpoint, "/")+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("otlp: build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp: export: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp: collector returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (e *OTLPHTTPExporter) request(spans []Span) otlpTracesRequest {
+	service := e.ServiceName
+	if service == "" {
+		service = "orizon"
+	}
+
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              otlpSpanKindConsumer,
+			StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+		}
+
+		if s.HasParent() {
+			o.ParentSpanID = hex.EncodeToString(s.ParentSpanID[:])
+		}
+
+		if s.Error != "" {
+			o.Status = &otlpStatus{Code: otlpStatusCodeError, Message: s.Error}
+		}
+
+		out = append(out, o)
+	}
+
+	return otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(map[string]string{"service.name": service})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "orizon.runtime"}, Spans: out}},
+	}}}
+}
+
+// otlpAttributes converts attrs into key-sorted OTLP key/value pairs.
+func otlpAttributes(attrs map[string]string) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: otlpAnyValue{StringValue: attrs[k]}})
+	}
+
+	return kvs
+}
+
+// ChromeTraceExporter collects spans and writes them in the Chrome
+// trace-event JSON format understood by chrome://tracing and Perfetto.
+// Each actor is rendered as its own thread; parent/child spans are linked
+// with flow events.
+type ChromeTraceExporter struct {
+	spans []Span
+	mu    sync.Mutex
+}
+
+// ExportSpans implements SpanExporter by buffering spans in memory.
+func (c *ChromeTraceExporter) ExportSpans(_ context.Context, spans []Span) error {
+	c.mu.Lock()
+	c.spans = append(c.spans, spans...)
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Spans returns a copy of the collected spans.
+func (c *ChromeTraceExporter) Spans() []Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Span(nil), c.spans...)
+}
+
+type chromeTraceEvent struct {
+	Args map[string]string `json:"args,omitempty"`
+	Name string            `json:"name"`
+	Cat  string            `json:"cat,omitempty"`
+	Ph   string            `json:"ph"`
+	ID   string            `json:"id,omitempty"`
+	BP   string            `json:"bp,omitempty"`
+	TS   int64             `json:"ts"`
+	Dur  int64             `json:"dur,omitempty"`
+	PID  int               `json:"pid"`
+	TID  uint64            `json:"tid"`
+}
+
+// WriteTo writes the collected spans as a Chrome trace-event document.
+func (c *ChromeTraceExporter) WriteTo(w io.Writer) (int64, error) {
+	return WriteChromeTrace(w, c.Spans())
+}
+
+// WriteChromeTrace writes spans as a Chrome trace-event JSON document.
+// Timestamps are microseconds relative to the earliest span.
+func WriteChromeTrace(w io.Writer, spans []Span) (int64, error) {
+	spans = append([]Span(nil), spans...)
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].StartTime.Before(spans[j].StartTime) })
+
+	var origin time.Time
+	if len(spans) > 0 {
+		origin = spans[0].StartTime
+	}
+
+	micros := func(t time.Time) int64 { return t.Sub(origin).Microseconds() }
+
+	events := make([]chromeTraceEvent, 0, len(spans)*2)
+	threads := make(map[ActorID]string)
+	byID := make(map[[8]byte]Span, len(spans))
+
+	for _, s := range spans {
+		byID[s.SpanID] = s
+		if _, ok := threads[s.Actor]; !ok {
+			name := s.Attributes["actor.name"]
+			if name == "" {
+				name = fmt.Sprintf("actor-%d", s.Actor)
+			}
+
+			threads[s.Actor] = name
+		}
+	}
+
+	actors := make([]ActorID, 0, len(threads))
+	for id := range threads {
+		actors = append(actors, id)
+	}
+
+	sort.Slice(actors, func(i, j int) bool { return actors[i] < actors[j] })
+
+	for _, id := range actors {
+		events = append(events, chromeTraceEvent{
+			Name: "thread_name", Ph: "M", PID: 1, TID: uint64(id),
+			Args: map[string]string{"name": threads[id]},
+		})
+	}
+
+	for _, s := range spans {
+		dur := s.EndTime.Sub(s.StartTime).Microseconds()
+		if dur <= 0 {
+			dur = 1
+		}
+
+		args := make(map[string]string, len(s.Attributes)+3)
+		for k, v := range s.Attributes {
+			args[k] = v
+		}
+
+		args["trace.id"] = hex.EncodeToString(s.TraceID[:])
+		args["span.id"] = hex.EncodeToString(s.SpanID[:])
+
+		if s.Error != "" {
+			args["error"] = s.Error
+		}
+
+		events = append(events, chromeTraceEvent{
+			Name: s.Name, Cat: "actor", Ph: "X", PID: 1, TID: uint64(s.Actor),
+			TS: micros(s.StartTime), Dur: dur, Args: args,
+		})
+
+		// Link a span to its parent when both ends are in this trace.
+		if parent, ok := byID[s.ParentSpanID]; ok && s.HasParent() {
+			flowID := hex.EncodeToString(s.SpanID[:])
+			events = append(events,
+				chromeTraceEvent{Name: "message", Cat: "flow", Ph: "s", ID: flowID, PID: 1, TID: uint64(parent.Actor), TS: micros(parent.StartTime)},
+				chromeTraceEvent{Name: "message", Cat: "flow", Ph: "f", BP: "e", ID: flowID, PID: 1, TID: uint64(s.Actor), TS: micros(s.StartTime)},
+			)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	enc := json.NewEncoder(cw)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(struct {
+		DisplayTimeUnit string             `json:"displayTimeUnit"`
+		TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	}{DisplayTimeUnit: "ms", TraceEvents: events}); err != nil {
+		return cw.n, err
+	}
+
+	return cw.n, bw.Flush()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,121 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// relayBehavior forwards each message to next, if set.
+type relayBehavior struct {
+	next *ActorID
+	name string
+}
+
+func (b *relayBehavior) Receive(ctx *ActorContext, msg Message) error {
+	if b.next != nil {
+		return ctx.Tell(*b.next, msg.Type, msg.Payload)
+	}
+
+	return nil
+}
+
+func (b *relayBehavior) PreStart(ctx *ActorContext) error { return nil }
+func (b *relayBehavior) PostStop(ctx *ActorContext) error { return nil }
+func (b *relayBehavior) PreRestart(ctx *ActorContext, reason error, message *Message) error {
+	return nil
+}
+func (b *relayBehavior) PostRestart(ctx *ActorContext, reason error) error { return nil }
+func (b *relayBehavior) GetBehaviorName() string                           { return b.name }
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || !sc.Sampled {
+		t.Fatalf("valid traceparent rejected: %+v", sc)
+	}
+
+	if got := sc.Traceparent(); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("round trip mismatch: %s", got)
+	}
+
+	for _, bad := range []string{"", "00-0000-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"} {
+		if _, ok := ParseTraceparent(bad); ok {
+			t.Fatalf("invalid traceparent accepted: %q", bad)
+		}
+	}
+}
+
+func TestSpans_ChainInSimulation_ChromeTrace(t *testing.T) {
+	exp := &ChromeTraceExporter{}
+
+	err := RunSimulation(7, func(sim *Simulator) error {
+		cfg := DefaultActorSystemConfig
+		cfg.Simulation = sim
+
+		system, err := NewActorSystem(cfg)
+		if err != nil {
+			return err
+		}
+
+		system.SetSpanExporter(exp, 1)
+
+		if err := system.Start(); err != nil {
+			return err
+		}
+
+		defer system.Stop()
+
+		last, err := system.CreateActor("last", UserActor, &relayBehavior{name: "last"}, DefaultActorConfig)
+		if err != nil {
+			return err
+		}
+
+		first, err := system.CreateActor("first", UserActor, &relayBehavior{name: "first", next: &last.ID}, DefaultActorConfig)
+		if err != nil {
+			return err
+		}
+
+		if err := system.SendMessageWithCorrelation(0, first.ID, 3, "x", NewCorrelationID()); err != nil {
+			return err
+		}
+
+		return sim.RunUntilIdle()
+	})
+	if err != nil {
+		t.Fatalf("simulation: %v", err)
+	}
+
+	spans := exp.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	parent, child := spans[0], spans[1]
+	if parent.HasParent() || child.ParentSpanID != parent.SpanID || child.TraceID != parent.TraceID {
+		t.Fatalf("child span not linked to parent: %+v / %+v", parent, child)
+	}
+
+	var buf bytes.Buffer
+	if _, err := exp.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var doc struct {
+		TraceEvents []struct {
+			Ph string `json:"ph"`
+		} `json:"traceEvents"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid chrome trace: %v", err)
+	}
+
+	phases := map[string]int{}
+	for _, ev := range doc.TraceEvents {
+		phases[ev.Ph]++
+	}
+
+	if phases["X"] != 2 || phases["M"] != 2 || phases["s"] != 1 || phases["f"] != 1 {
+		t.Fatalf("unexpected event phases: %v", phases)
+	}
+}
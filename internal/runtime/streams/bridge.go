@@ -0,0 +1,168 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/runtime/asyncio"
+	"github.com/orizon-lang/orizon/internal/runtime/channels"
+)
+
+// FromSlice returns a source emitting items in order.
+func FromSlice(items ...interface{}) Source {
+	items = append([]interface{}(nil), items...)
+
+	return Source{name: "slice", open: func() puller {
+		i := 0
+
+		return puller{pull: func(context.Context) (interface{}, bool, error) {
+			if i >= len(items) {
+				return nil, false, nil
+			}
+
+			i++
+
+			return items[i-1], true, nil
+		}}
+	}}
+}
+
+// FromFunc returns a source that calls next for every element it needs;
+// next returns ok=false when exhausted. next runs off the actor goroutine
+// and may block until ctx is cancelled.
+func FromFunc(next func(ctx context.Context) (interface{}, bool, error)) Source {
+	return Source{name: "func", open: func() puller {
+		return puller{pull: next, blocking: true}
+	}}
+}
+
+// FromChannel returns a source that receives from ch until it is closed.
+// Nothing is received while downstream has no demand, so senders on ch
+// block once its buffer is full.
+func FromChannel[T any](ch *channels.Channel[T]) Source {
+	return Source{name: "channel", open: func() puller {
+		return puller{blocking: true, pull: func(ctx context.Context) (interface{}, bool, error) {
+			v, ok, err := ch.Recv(ctx)
+			if err != nil || !ok {
+				return nil, false, err
+			}
+
+			return v, true, nil
+		}}
+	}}
+}
+
+// ToChannel returns a sink that sends every element to ch, blocking on a
+// full channel; ch is closed when the stream terminates. Elements that are
+// not of type T fail the stream.
+func ToChannel[T any](ch *channels.Channel[T]) Sink {
+	return Sink{name: "channel", open: func() consumer {
+		return consumer{
+			blocking: true,
+			close:    ch.Close,
+			consume: func(ctx context.Context, elem interface{}) error {
+				v, ok := elem.(T)
+				if !ok {
+					return fmt.Errorf("streams: channel sink: unexpected element type %T", elem)
+				}
+
+				return ch.Send(ctx, v)
+			},
+		}
+	}}
+}
+
+// DefaultChunkSize is the read size used by FromConn when chunk <= 0.
+const DefaultChunkSize = 32 << 10
+
+// FromConn returns a source of []byte chunks read from conn until EOF. Reads
+// are only issued while downstream demand is outstanding, so TCP flow
+// control pushes back on the remote writer. Read buffers come from the
+// asyncio byte pool; emitted chunks are private copies. Cancelling the
+// stream interrupts a pending read by expiring the read deadline; conn
+// itself is left open.
+func FromConn(conn net.Conn, chunk int) Source {
+	if chunk <= 0 {
+		chunk = DefaultChunkSize
+	}
+
+	return Source{name: "conn", open: func() puller {
+		pool := asyncio.DefaultBytePool()
+
+		return puller{
+			blocking: true,
+			close:    func() { _ = conn.SetReadDeadline(time.Now()) },
+			pull: func(ctx context.Context) (interface{}, bool, error) {
+				buf := pool.Get(chunk)
+				defer pool.Put(buf)
+
+				for {
+					n, err := conn.Read(buf[:chunk])
+					if n > 0 {
+						return append([]byte(nil), buf[:n]...), true, nil
+					}
+
+					if err == nil {
+						continue
+					}
+
+					if errors.Is(err, io.EOF) || ctx.Err() != nil {
+						return nil, false, nil
+					}
+
+					return nil, false, err
+				}
+			},
+		}
+	}}
+}
+
+// ToConn returns a sink writing []byte or string elements to conn. Demand is
+// only renewed after a write completes, so a slow peer slows the stream.
+func ToConn(conn net.Conn) Sink {
+	return Sink{name: "conn", open: func() consumer {
+		return consumer{blocking: true, consume: func(_ context.Context, elem interface{}) error {
+			var b []byte
+
+			switch v := elem.(type) {
+			case []byte:
+				b = v
+			case string:
+				b = []byte(v)
+			default:
+				return fmt.Errorf("streams: conn sink: unexpected element type %T", elem)
+			}
+
+			_, err := conn.Write(b)
+
+			return err
+		}}
+	}}
+}
+
+// ForEach returns a sink calling fn for every element on the sink actor.
+// An error from fn cancels the stream and is reported by Materialized.Err.
+func ForEach(fn func(elem interface{}) error) Sink {
+	return Sink{name: "foreach", open: func() consumer {
+		return consumer{consume: func(_ context.Context, elem interface{}) error { return fn(elem) }}
+	}}
+}
+
+// Collect returns a sink appending every element to dst. dst must not be
+// read before the stream has terminated.
+func Collect(dst *[]interface{}) Sink {
+	return ForEach(func(elem interface{}) error {
+		*dst = append(*dst, elem)
+
+		return nil
+	})
+}
+
+// Ignore returns a sink that discards every element.
+func Ignore() Sink {
+	return ForEach(func(interface{}) error { return nil })
+}
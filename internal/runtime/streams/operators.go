@@ -0,0 +1,165 @@
+package streams
+
+import (
+	"time"
+
+	timex "github.com/orizon-lang/orizon/internal/stdlib/time"
+)
+
+// identityLogic passes elements through unchanged; used by Merge.
+type identityLogic struct{}
+
+func (identityLogic) push(h flowHost, elem interface{}) error {
+	h.emit(elem)
+
+	return nil
+}
+
+func (identityLogic) finish(flowHost) {}
+
+type mapLogic struct {
+	fn func(interface{}) (interface{}, error)
+}
+
+func (m mapLogic) push(h flowHost, elem interface{}) error {
+	v, err := m.fn(elem)
+	if err != nil {
+		return err
+	}
+
+	h.emit(v)
+
+	return nil
+}
+
+func (mapLogic) finish(flowHost) {}
+
+// Map returns a stage that transforms every element with fn. An error from
+// fn fails the stream.
+func Map(fn func(interface{}) (interface{}, error)) Flow {
+	return Flow{name: "map", newLogic: func() flowLogic { return mapLogic{fn: fn} }}
+}
+
+type filterLogic struct {
+	pred func(interface{}) bool
+}
+
+func (f filterLogic) push(h flowHost, elem interface{}) error {
+	if f.pred(elem) {
+		h.emit(elem)
+	}
+
+	return nil
+}
+
+func (filterLogic) finish(flowHost) {}
+
+// Filter returns a stage that keeps the elements for which pred is true.
+func Filter(pred func(interface{}) bool) Flow {
+	return Flow{name: "filter", newLogic: func() flowLogic { return filterLogic{pred: pred} }}
+}
+
+type batchLogic struct {
+	cur    []interface{}
+	size   int
+	window time.Duration
+}
+
+func (b *batchLogic) push(h flowHost, elem interface{}) error {
+	if len(b.cur) == 0 && b.window > 0 {
+		h.after(b.window)
+	}
+
+	b.cur = append(b.cur, elem)
+	if len(b.cur) >= b.size {
+		b.flush(h)
+	}
+
+	return nil
+}
+
+func (b *batchLogic) tick(h flowHost) { b.flush(h) }
+
+func (b *batchLogic) finish(h flowHost) { b.flush(h) }
+
+func (b *batchLogic) flush(h flowHost) {
+	if len(b.cur) == 0 {
+		return
+	}
+
+	h.emit(b.cur)
+	b.cur = make([]interface{}, 0, b.size)
+}
+
+// Batch returns a stage that groups elements into []interface{} slices of
+// up to size elements. When window is positive a partial batch is emitted
+// once window has elapsed since its first element.
+func Batch(size int, window time.Duration) Flow {
+	if size <= 0 {
+		size = 1
+	}
+
+	return Flow{name: "batch", newLogic: func() flowLogic {
+		return &batchLogic{size: size, window: window, cur: make([]interface{}, 0, size)}
+	}}
+}
+
+type throttleLogic struct {
+	bucket *timex.TokenBucket
+	retry  time.Duration
+}
+
+func (throttleLogic) push(h flowHost, elem interface{}) error {
+	h.emit(elem)
+
+	return nil
+}
+
+func (throttleLogic) finish(flowHost) {}
+
+func (t throttleLogic) gate() time.Duration {
+	if t.bucket.Allow(1) {
+		return 0
+	}
+
+	return t.retry
+}
+
+// Throttle returns a stage that emits at most ratePerSec elements per second
+// with bursts of up to burst elements. Demand is not propagated faster than
+// the bucket refills, so upstream stages are slowed down as well.
+func Throttle(ratePerSec float64, burst int) Flow {
+	if ratePerSec <= 0 {
+		ratePerSec = 1
+	}
+
+	if burst <= 0 {
+		burst = 1
+	}
+
+	retry := time.Duration(float64(time.Second) / ratePerSec)
+
+	return Flow{name: "throttle", newLogic: func() flowLogic {
+		return throttleLogic{bucket: timex.NewTokenBucket(burst, ratePerSec), retry: retry}
+	}}
+}
+
+// ThrottleBucket is like Throttle but draws tokens from a shared bucket, so
+// several streams can be limited together. When the bucket is empty the
+// stage retries after retry.
+func ThrottleBucket(bucket *timex.TokenBucket, retry time.Duration) Flow {
+	if retry <= 0 {
+		retry = 10 * time.Millisecond
+	}
+
+	return Flow{name: "throttle", newLogic: func() flowLogic {
+		return throttleLogic{bucket: bucket, retry: retry}
+	}}
+}
+
+// Merge returns a source that emits the elements of all sources as they
+// arrive. It completes once every input has completed and fails as soon as
+// any input fails.
+func Merge(sources ...Source) Source {
+	return Source{name: "merge", inputs: append([]Source(nil), sources...)}
+}
@@ -0,0 +1,515 @@
+package streams
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+)
+
+// stageBase provides the lifecycle hooks shared by every stage actor.
+type stageBase struct{}
+
+func (stageBase) PreStart(*rt.ActorContext) error                       { return nil }
+func (stageBase) PreRestart(*rt.ActorContext, error, *rt.Message) error { return nil }
+func (stageBase) PostRestart(*rt.ActorContext, error) error             { return nil }
+
+// pullResult is the payload of msgPulled.
+type pullResult struct {
+	elem interface{}
+	err  error
+	ok   bool
+}
+
+// sourceStage emits elements from a puller while downstream demand lasts.
+type sourceStage struct {
+	stageBase
+	ctx     context.Context
+	cancel  context.CancelFunc
+	puller  puller
+	closer  sync.Once
+	demand  int64
+	self    rt.ActorID
+	down    rt.ActorID
+	pulling bool
+	done    bool
+}
+
+func (s *sourceStage) setDown(id rt.ActorID) { s.down = id }
+
+func (s *sourceStage) GetBehaviorName() string { return "stream-source" }
+
+func (s *sourceStage) Receive(ctx *rt.ActorContext, msg rt.Message) error {
+	if s.done {
+		return nil
+	}
+
+	switch msg.Type {
+	case MsgRequest:
+		if n, _ := msg.Payload.(int64); n > 0 {
+			s.demand += n
+		}
+	case MsgCancel:
+		s.terminate()
+
+		return nil
+	case msgPulled:
+		s.pulling = false
+		res, _ := msg.Payload.(pullResult)
+		s.emit(ctx.System, res)
+	}
+
+	s.pump(ctx.System)
+
+	return nil
+}
+
+func (s *sourceStage) pump(sys *rt.ActorSystem) {
+	for s.demand > 0 && !s.done && !s.pulling {
+		if s.puller.blocking {
+			s.pulling = true
+
+			go func() {
+				elem, ok, err := s.puller.pull(s.ctx)
+				_ = sys.SendMessage(s.self, s.self, msgPulled, pullResult{elem: elem, ok: ok, err: err})
+			}()
+
+			return
+		}
+
+		elem, ok, err := s.puller.pull(s.ctx)
+		s.emit(sys, pullResult{elem: elem, ok: ok, err: err})
+	}
+}
+
+func (s *sourceStage) emit(sys *rt.ActorSystem, res pullResult) {
+	switch {
+	case res.err != nil:
+		_ = sys.SendMessage(s.self, s.down, MsgOnError, res.err)
+		s.terminate()
+	case !res.ok:
+		_ = sys.SendMessage(s.self, s.down, MsgOnComplete, nil)
+		s.terminate()
+	default:
+		s.demand--
+		_ = sys.SendMessage(s.self, s.down, MsgOnNext, res.elem)
+	}
+}
+
+func (s *sourceStage) terminate() {
+	s.done = true
+	s.closer.Do(func() {
+		s.cancel()
+
+		if s.puller.close != nil {
+			s.puller.close()
+		}
+	})
+}
+
+func (s *sourceStage) PostStop(*rt.ActorContext) error {
+	s.terminate()
+
+	return nil
+}
+
+// flowHost is the view of a flow stage offered to its logic.
+type flowHost interface {
+	// emit queues an element for downstream delivery.
+	emit(elem interface{})
+	// after arranges for tick to be called once d has elapsed.
+	after(d time.Duration)
+}
+
+// flowLogic transforms upstream elements into downstream elements.
+type flowLogic interface {
+	// push handles one upstream element.
+	push(h flowHost, elem interface{}) error
+	// finish flushes held state after every upstream has completed.
+	finish(h flowHost)
+}
+
+// tickLogic is implemented by logic that arms timers with flowHost.after.
+type tickLogic interface {
+	tick(h flowHost)
+}
+
+// gateLogic is implemented by logic that limits the emission rate. gate
+// returns zero when an element may be emitted now, otherwise how long to
+// wait before trying again.
+type gateLogic interface {
+	gate() time.Duration
+}
+
+type upstreamLink struct {
+	id       rt.ActorID
+	inFlight int
+	done     bool
+}
+
+// tick payloads for msgTick.
+const (
+	tickTimer = iota
+	tickGate
+)
+
+// flowStage runs a flowLogic between one or more upstream stages and one
+// downstream stage. Input is processed only while downstream demand is
+// outstanding, and every upstream link is kept at most buffer elements ahead.
+type flowStage struct {
+	stageBase
+	logic      flowLogic
+	ctx        *rt.ActorContext
+	ups        []*upstreamLink
+	inbuf      []interface{}
+	out        []interface{}
+	demand     int64
+	buffer     int
+	self       rt.ActorID
+	down       rt.ActorID
+	gateWait   bool
+	finished   bool
+	terminated bool
+}
+
+func (f *flowStage) setDown(id rt.ActorID) { f.down = id }
+
+func (f *flowStage) GetBehaviorName() string { return "stream-flow" }
+
+func (f *flowStage) PostStop(ctx *rt.ActorContext) error {
+	ctx.StopTimer("tick")
+	ctx.StopTimer("gate")
+
+	return nil
+}
+
+func (f *flowStage) emit(elem interface{}) { f.out = append(f.out, elem) }
+
+func (f *flowStage) after(d time.Duration) {
+	sys, self := f.ctx.System, f.self
+	f.ctx.StartTimer("tick", d, func() { _ = sys.SendMessage(self, self, msgTick, tickTimer) })
+}
+
+func (f *flowStage) link(id rt.ActorID) *upstreamLink {
+	for _, l := range f.ups {
+		if l.id == id {
+			return l
+		}
+	}
+
+	return nil
+}
+
+func (f *flowStage) Receive(ctx *rt.ActorContext, msg rt.Message) error {
+	if f.terminated {
+		return nil
+	}
+
+	f.ctx = ctx
+
+	switch msg.Type {
+	case MsgRequest:
+		if n, _ := msg.Payload.(int64); n > 0 {
+			f.demand += n
+		}
+	case MsgCancel:
+		f.cancelUpstream()
+		f.terminated = true
+
+		return nil
+	case MsgOnNext:
+		if l := f.link(msg.Sender); l != nil {
+			l.inFlight--
+			f.inbuf = append(f.inbuf, msg.Payload)
+		}
+	case MsgOnComplete:
+		if l := f.link(msg.Sender); l != nil {
+			l.done = true
+		}
+	case MsgOnError:
+		err, _ := msg.Payload.(error)
+		f.fail(err)
+
+		return nil
+	case msgTick:
+		if kind, _ := msg.Payload.(int); kind == tickGate {
+			f.gateWait = false
+		} else if tl, ok := f.logic.(tickLogic); ok {
+			tl.tick(f)
+		}
+	}
+
+	f.pump()
+
+	return nil
+}
+
+func (f *flowStage) pump() {
+	sys := f.ctx.System
+
+	for !f.terminated {
+		// Deliver processed output first, honouring the rate gate.
+		if len(f.out) > 0 && f.demand > 0 {
+			if g, ok := f.logic.(gateLogic); ok {
+				if wait := g.gate(); wait > 0 {
+					if !f.gateWait {
+						f.gateWait = true
+						self := f.self
+						f.ctx.StartTimer("gate", wait, func() { _ = sys.SendMessage(self, self, msgTick, tickGate) })
+					}
+
+					break
+				}
+			}
+
+			elem := f.out[0]
+			f.out[0] = nil
+			f.out = f.out[1:]
+			f.demand--
+			_ = sys.SendMessage(f.self, f.down, MsgOnNext, elem)
+
+			continue
+		}
+
+		// Pull-through: only transform input while downstream wants output.
+		if len(f.out) == 0 && f.demand > 0 && len(f.inbuf) > 0 {
+			elem := f.inbuf[0]
+			f.inbuf[0] = nil
+			f.inbuf = f.inbuf[1:]
+
+			if err := f.logic.push(f, elem); err != nil {
+				f.fail(err)
+
+				return
+			}
+
+			continue
+		}
+
+		if !f.finished && len(f.inbuf) == 0 && f.upstreamDone() {
+			f.finished = true
+			f.logic.finish(f)
+
+			continue
+		}
+
+		break
+	}
+
+	if f.terminated {
+		return
+	}
+
+	if f.finished && len(f.out) == 0 {
+		f.terminated = true
+		f.ctx.StopTimer("tick")
+		f.ctx.StopTimer("gate")
+		_ = sys.SendMessage(f.self, f.down, MsgOnComplete, nil)
+
+		return
+	}
+
+	f.requestMore()
+}
+
+// requestMore tops up each live upstream link once it is half drained.
+func (f *flowStage) requestMore() {
+	if len(f.inbuf) >= f.buffer {
+		return
+	}
+
+	for _, l := range f.ups {
+		if l.done || l.inFlight > f.buffer/2 {
+			continue
+		}
+
+		n := f.buffer - l.inFlight
+		l.inFlight += n
+		_ = f.ctx.System.SendMessage(f.self, l.id, MsgRequest, int64(n))
+	}
+}
+
+func (f *flowStage) upstreamDone() bool {
+	for _, l := range f.ups {
+		if !l.done {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *flowStage) cancelUpstream() {
+	for _, l := range f.ups {
+		if !l.done {
+			l.done = true
+			_ = f.ctx.System.SendMessage(f.self, l.id, MsgCancel, nil)
+		}
+	}
+}
+
+func (f *flowStage) fail(err error) {
+	if err == nil {
+		err = fmt.Errorf("streams: upstream failed")
+	}
+
+	f.cancelUpstream()
+	f.terminated = true
+	f.ctx.StopTimer("tick")
+	f.ctx.StopTimer("gate")
+	_ = f.ctx.System.SendMessage(f.self, f.down, MsgOnError, err)
+}
+
+// sinkStage drives demand from the end of the stream and consumes elements.
+type sinkStage struct {
+	stageBase
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mat         *Materialized
+	consumer    consumer
+	queue       []interface{}
+	inflight    sync.WaitGroup
+	outstanding int
+	buffer      int
+	self        rt.ActorID
+	up          rt.ActorID
+	busy        bool
+	upDone      bool
+}
+
+func (s *sinkStage) GetBehaviorName() string { return "stream-sink" }
+
+func (s *sinkStage) Receive(ctx *rt.ActorContext, msg rt.Message) error {
+	if s.isDone() {
+		return nil
+	}
+
+	sys := ctx.System
+
+	switch msg.Type {
+	case msgStart:
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.outstanding = s.buffer
+		_ = sys.SendMessage(s.self, s.up, MsgRequest, int64(s.buffer))
+	case MsgCancel:
+		_ = sys.SendMessage(s.self, s.up, MsgCancel, nil)
+		s.finish(ErrCancelled)
+	case MsgOnNext:
+		s.outstanding--
+
+		if !s.consumer.blocking {
+			if err := s.consumer.consume(s.ctx, msg.Payload); err != nil {
+				_ = sys.SendMessage(s.self, s.up, MsgCancel, nil)
+				s.finish(err)
+
+				return nil
+			}
+		} else {
+			s.queue = append(s.queue, msg.Payload)
+			s.drain(sys)
+		}
+
+		s.requestMore(sys)
+	case msgConsumed:
+		s.busy = false
+
+		if err, _ := msg.Payload.(error); err != nil {
+			_ = sys.SendMessage(s.self, s.up, MsgCancel, nil)
+			s.finish(err)
+
+			return nil
+		}
+
+		s.drain(sys)
+		s.requestMore(sys)
+	case MsgOnComplete:
+		s.upDone = true
+	case MsgOnError:
+		err, _ := msg.Payload.(error)
+		if err == nil {
+			err = fmt.Errorf("streams: upstream failed")
+		}
+
+		s.finish(err)
+
+		return nil
+	}
+
+	if s.upDone && !s.busy && len(s.queue) == 0 {
+		s.finish(nil)
+	}
+
+	return nil
+}
+
+// drain hands the next queued element to a blocking consumer.
+func (s *sinkStage) drain(sys *rt.ActorSystem) {
+	if s.busy || len(s.queue) == 0 {
+		return
+	}
+
+	elem := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	s.busy = true
+	s.inflight.Add(1)
+
+	go func() {
+		defer s.inflight.Done()
+
+		err := s.consumer.consume(s.ctx, elem)
+		_ = sys.SendMessage(s.self, s.self, msgConsumed, err)
+	}()
+}
+
+func (s *sinkStage) requestMore(sys *rt.ActorSystem) {
+	if s.upDone {
+		return
+	}
+
+	held := s.outstanding + len(s.queue)
+	if held > s.buffer/2 {
+		return
+	}
+
+	n := s.buffer - held
+	s.outstanding += n
+	_ = sys.SendMessage(s.self, s.up, MsgRequest, int64(n))
+}
+
+func (s *sinkStage) isDone() bool {
+	select {
+	case <-s.mat.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// finish records the terminal result and releases the consumer.
+func (s *sinkStage) finish(err error) {
+	s.mat.once.Do(func() {
+		if s.cancel != nil {
+			s.cancel()
+		}
+
+		// Close only once a pending blocking consume has returned.
+		if s.consumer.close != nil {
+			go func() {
+				s.inflight.Wait()
+				s.consumer.close()
+			}()
+		}
+
+		s.mat.err = err
+		close(s.mat.done)
+	})
+}
+
+func (s *sinkStage) PostStop(*rt.ActorContext) error {
+	s.finish(ErrCancelled)
+
+	return nil
+}
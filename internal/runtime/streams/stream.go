@@ -0,0 +1,340 @@
+// Package streams implements demand-driven reactive streams on top of the
+// actor runtime.
+//
+// A stream is described by a Source, any number of Flow stages and a Sink.
+// Running the graph materializes every stage as an actor in an ActorSystem.
+// Elements only travel downstream after the consumer has signalled demand
+// with a request(n) message, so a slow sink slows the whole pipeline down
+// instead of overflowing mailboxes.
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+)
+
+// Stream protocol messages exchanged between stage actors.
+const (
+	// MsgRequest signals n (int64 payload) more elements of demand upstream.
+	MsgRequest rt.MessageType = 0xFFFF0201 + iota
+	// MsgCancel asks the upstream stage to stop producing.
+	MsgCancel
+	// MsgOnNext carries one element downstream.
+	MsgOnNext
+	// MsgOnComplete signals that the upstream stage has finished.
+	MsgOnComplete
+	// MsgOnError carries a terminal error (error payload) downstream.
+	MsgOnError
+
+	msgStart    // materializer -> sink: issue initial demand
+	msgPulled   // blocking source pull finished
+	msgConsumed // blocking sink consume finished
+	msgTick     // timer fired inside a flow stage
+)
+
+// DefaultBufferSize is the per-stage demand window.
+const DefaultBufferSize = 16
+
+// ErrCancelled is reported by Materialized.Err after Cancel.
+var ErrCancelled = errors.New("streams: cancelled")
+
+// Settings control how a graph is materialized.
+type Settings struct {
+	// ActorConfig is used for every stage actor.
+	ActorConfig rt.ActorConfig
+	// BufferSize bounds the number of elements requested but not yet
+	// consumed on every link (DefaultBufferSize when <= 0).
+	BufferSize int
+}
+
+// DefaultSettings returns settings with the default buffer and actor config.
+func DefaultSettings() Settings {
+	return Settings{ActorConfig: rt.DefaultActorConfig, BufferSize: DefaultBufferSize}
+}
+
+// puller produces elements for a source stage. Pull returns ok=false once the
+// source is exhausted.
+type puller struct {
+	pull  func(ctx context.Context) (interface{}, bool, error)
+	close func()
+	// blocking pulls run on a helper goroutine so the stage actor never blocks.
+	blocking bool
+}
+
+// consumer receives elements in a sink stage.
+type consumer struct {
+	consume func(ctx context.Context, elem interface{}) error
+	close   func()
+	// blocking consumers run on a helper goroutine, one element at a time.
+	blocking bool
+}
+
+// Source is a blueprint for a stream producer. A Source value is immutable
+// and can be materialized any number of times.
+type Source struct {
+	open   func() puller
+	name   string
+	inputs []Source
+	flows  []Flow
+}
+
+// Flow is a blueprint for a processing stage with one output.
+type Flow struct {
+	newLogic func() flowLogic
+	name     string
+}
+
+// Sink is a blueprint for a stream consumer.
+type Sink struct {
+	open func() consumer
+	name string
+}
+
+// RunnableGraph is a Source connected to a Sink.
+type RunnableGraph struct {
+	sink   Sink
+	source Source
+}
+
+// Via appends a processing stage to the source.
+func (s Source) Via(f Flow) Source {
+	out := s
+	out.flows = append(append([]Flow(nil), s.flows...), f)
+
+	return out
+}
+
+// Map transforms every element with fn.
+func (s Source) Map(fn func(interface{}) (interface{}, error)) Source { return s.Via(Map(fn)) }
+
+// Filter keeps the elements for which pred returns true.
+func (s Source) Filter(pred func(interface{}) bool) Source { return s.Via(Filter(pred)) }
+
+// To connects the source to sink.
+func (s Source) To(sink Sink) RunnableGraph { return RunnableGraph{source: s, sink: sink} }
+
+// Run materializes the graph with default settings.
+func (g RunnableGraph) Run(sys *rt.ActorSystem) (*Materialized, error) {
+	return g.RunWith(sys, DefaultSettings())
+}
+
+// RunWith materializes every stage as an actor of sys and starts the flow of
+// demand from the sink.
+func (g RunnableGraph) RunWith(sys *rt.ActorSystem, settings Settings) (*Materialized, error) {
+	if sys == nil {
+		return nil, fmt.Errorf("streams: nil actor system")
+	}
+
+	if g.source.open == nil && len(g.source.inputs) == 0 {
+		return nil, fmt.Errorf("streams: graph has no source")
+	}
+
+	if g.sink.open == nil {
+		return nil, fmt.Errorf("streams: graph has no sink")
+	}
+
+	if settings.BufferSize <= 0 {
+		settings.BufferSize = DefaultBufferSize
+	}
+
+	if settings.ActorConfig.MailboxCapacity == 0 {
+		settings.ActorConfig = rt.DefaultActorConfig
+	}
+
+	m := &materializer{
+		sys:      sys,
+		settings: settings,
+		prefix:   fmt.Sprintf("stream-%d", atomic.AddUint64(&streamSeq, 1)),
+	}
+
+	mat := &Materialized{sys: sys, done: make(chan struct{})}
+
+	sink := &sinkStage{consumer: g.sink.open(), buffer: settings.BufferSize, mat: mat}
+
+	sinkID, err := m.spawn(g.sink.name, sink)
+	if err != nil {
+		m.stopAll()
+
+		return nil, err
+	}
+
+	up, err := m.materialize(g.source)
+	if err != nil {
+		m.stopAll()
+
+		return nil, err
+	}
+
+	up.stage.setDown(sinkID)
+	sink.self, sink.up = sinkID, up.id
+	mat.sink = sinkID
+	mat.stages = m.ids
+
+	// Stage actors are torn down once the sink has terminated.
+	go func() {
+		<-mat.done
+		m.stopAll()
+	}()
+
+	if err := sys.SendMessage(0, sinkID, msgStart, nil); err != nil {
+		sink.finish(fmt.Errorf("streams: start: %w", err))
+
+		return nil, err
+	}
+
+	return mat, nil
+}
+
+var streamSeq uint64
+
+type materializer struct {
+	sys      *rt.ActorSystem
+	prefix   string
+	ids      []rt.ActorID
+	settings Settings
+}
+
+func (m *materializer) spawn(name string, b rt.ActorBehavior) (rt.ActorID, error) {
+	a, err := m.sys.CreateActor(fmt.Sprintf("%s/%d-%s", m.prefix, len(m.ids), name), rt.UserActor, b, m.settings.ActorConfig)
+	if err != nil {
+		return 0, fmt.Errorf("streams: spawn %s: %w", name, err)
+	}
+
+	m.ids = append(m.ids, a.ID)
+
+	return a.ID, nil
+}
+
+// outlet is a materialized stage whose output is not connected yet.
+type outlet struct {
+	stage interface{ setDown(rt.ActorID) }
+	id    rt.ActorID
+}
+
+// materialize spawns the stages of src and returns its last stage. Links are
+// wired before the sink issues its first request, so no stage ever sees a
+// message before its neighbours are known.
+func (m *materializer) materialize(src Source) (outlet, error) {
+	var last outlet
+
+	if len(src.inputs) > 0 {
+		ups := make([]outlet, 0, len(src.inputs))
+
+		for _, in := range src.inputs {
+			up, err := m.materialize(in)
+			if err != nil {
+				return outlet{}, err
+			}
+
+			ups = append(ups, up)
+		}
+
+		var err error
+		if last, err = m.spawnFlow("merge", identityLogic{}, ups); err != nil {
+			return outlet{}, err
+		}
+	} else {
+		st := &sourceStage{puller: src.open()}
+		st.ctx, st.cancel = context.WithCancel(context.Background())
+
+		id, err := m.spawn(src.name, st)
+		if err != nil {
+			st.cancel()
+
+			return outlet{}, err
+		}
+
+		st.self = id
+		last = outlet{stage: st, id: id}
+	}
+
+	for _, f := range src.flows {
+		var err error
+		if last, err = m.spawnFlow(f.name, f.newLogic(), []outlet{last}); err != nil {
+			return outlet{}, err
+		}
+	}
+
+	return last, nil
+}
+
+func (m *materializer) spawnFlow(name string, logic flowLogic, ups []outlet) (outlet, error) {
+	st := &flowStage{logic: logic, buffer: m.settings.BufferSize}
+
+	id, err := m.spawn(name, st)
+	if err != nil {
+		return outlet{}, err
+	}
+
+	st.self = id
+	st.ups = make([]*upstreamLink, len(ups))
+
+	for i, up := range ups {
+		up.stage.setDown(id)
+		st.ups[i] = &upstreamLink{id: up.id}
+	}
+
+	return outlet{stage: st, id: id}, nil
+}
+
+func (m *materializer) stopAll() {
+	for _, id := range m.ids {
+		_ = m.sys.StopActor(id)
+	}
+}
+
+// Materialized is a handle on a running stream.
+type Materialized struct {
+	err    error
+	sys    *rt.ActorSystem
+	done   chan struct{}
+	stages []rt.ActorID
+	once   sync.Once
+	sink   rt.ActorID
+}
+
+// Done is closed when the stream has completed, failed or been cancelled.
+func (m *Materialized) Done() <-chan struct{} { return m.done }
+
+// Err returns the terminal error once Done is closed; nil on completion.
+func (m *Materialized) Err() error {
+	select {
+	case <-m.done:
+		return m.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the stream terminates or ctx is done.
+func (m *Materialized) Wait(ctx context.Context) error {
+	select {
+	case <-m.done:
+		return m.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel stops the stream from the sink side; upstream stages are cancelled
+// and Err reports ErrCancelled.
+func (m *Materialized) Cancel() {
+	_ = m.sys.SendMessage(0, m.sink, MsgCancel, nil)
+}
+
+// Stages returns the actor IDs of the materialized stages.
+func (m *Materialized) Stages() []rt.ActorID { return append([]rt.ActorID(nil), m.stages...) }
+
+// Batch groups elements into slices; see the Batch flow.
+func (s Source) Batch(size int, window time.Duration) Source { return s.Via(Batch(size, window)) }
+
+// Throttle limits the element rate; see the Throttle flow.
+func (s Source) Throttle(ratePerSec float64, burst int) Source {
+	return s.Via(Throttle(ratePerSec, burst))
+}
@@ -0,0 +1,298 @@
+package streams
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rt "github.com/orizon-lang/orizon/internal/runtime"
+	"github.com/orizon-lang/orizon/internal/runtime/channels"
+)
+
+func newSystem(t *testing.T) *rt.ActorSystem {
+	t.Helper()
+
+	sys, err := rt.NewActorSystem(rt.DefaultActorSystemConfig)
+	if err != nil {
+		t.Fatalf("system: %v", err)
+	}
+
+	if err := sys.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	t.Cleanup(func() { _ = sys.Stop() })
+
+	return sys
+}
+
+func run(t *testing.T, sys *rt.ActorSystem, g RunnableGraph) error {
+	t.Helper()
+
+	m, err := g.Run(sys)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return m.Wait(ctx)
+}
+
+func ints(n int) []interface{} {
+	out := make([]interface{}, n)
+	for i := range out {
+		out[i] = i
+	}
+
+	return out
+}
+
+func TestStreams_MapFilterBatch(t *testing.T) {
+	sys := newSystem(t)
+
+	var got []interface{}
+
+	src := FromSlice(ints(100)...).
+		Filter(func(v interface{}) bool { return v.(int)%2 == 0 }).
+		Map(func(v interface{}) (interface{}, error) { return v.(int) * 10, nil }).
+		Batch(8, 0)
+
+	if err := run(t, sys, src.To(Collect(&got))); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if len(got) != 7 {
+		t.Fatalf("expected 7 batches, got %d", len(got))
+	}
+
+	next := 0
+
+	for i, b := range got {
+		batch := b.([]interface{})
+		if i < 6 && len(batch) != 8 {
+			t.Fatalf("batch %d has %d elements", i, len(batch))
+		}
+
+		for _, v := range batch {
+			if v.(int) != next*20 {
+				t.Fatalf("out of order: got %v want %d", v, next*20)
+			}
+
+			next++
+		}
+	}
+
+	if next != 50 {
+		t.Fatalf("expected 50 elements, got %d", next)
+	}
+}
+
+func TestStreams_BackpressureBoundsProduction(t *testing.T) {
+	sys := newSystem(t)
+
+	var produced int64
+
+	src := FromFunc(func(context.Context) (interface{}, bool, error) {
+		return atomic.AddInt64(&produced, 1), true, nil
+	}).Map(func(v interface{}) (interface{}, error) { return v, nil })
+
+	// Nobody reads from out, so the sink stalls after one element.
+	out := channels.New[int64](0)
+
+	m, err := src.To(ToChannel(out)).RunWith(sys, Settings{BufferSize: 4})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	// Each link holds at most BufferSize requested elements.
+	if n := atomic.LoadInt64(&produced); n > 3*4+1 {
+		t.Fatalf("producer ran ahead of demand: %d elements", n)
+	}
+
+	m.Cancel()
+
+	select {
+	case <-m.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("cancel did not terminate the stream")
+	}
+
+	if !errors.Is(m.Err(), ErrCancelled) {
+		t.Fatalf("expected ErrCancelled, got %v", m.Err())
+	}
+}
+
+func TestStreams_Merge(t *testing.T) {
+	sys := newSystem(t)
+
+	var got []interface{}
+
+	src := Merge(FromSlice(ints(50)...), FromSlice(ints(30)...), FromSlice())
+	if err := run(t, sys, src.To(Collect(&got))); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	if len(got) != 80 {
+		t.Fatalf("expected 80 elements, got %d", len(got))
+	}
+
+	vals := make([]int, len(got))
+	for i, v := range got {
+		vals[i] = v.(int)
+	}
+
+	sort.Ints(vals)
+
+	if vals[0] != 0 || vals[79] != 49 {
+		t.Fatalf("unexpected merged values: %v", vals)
+	}
+}
+
+func TestStreams_Throttle(t *testing.T) {
+	sys := newSystem(t)
+
+	start := time.Now()
+
+	if err := run(t, sys, FromSlice(ints(6)...).Throttle(50, 1).To(Ignore())); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	// One token up front, then one every 20ms.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("throttle too fast: %s", elapsed)
+	}
+}
+
+func TestStreams_ErrorPropagates(t *testing.T) {
+	sys := newSystem(t)
+
+	boom := errors.New("boom")
+
+	src := FromSlice(ints(10)...).Map(func(v interface{}) (interface{}, error) {
+		if v.(int) == 5 {
+			return nil, boom
+		}
+
+		return v, nil
+	})
+
+	if err := run(t, sys, src.To(Ignore())); !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+}
+
+func TestStreams_ChannelBridge(t *testing.T) {
+	sys := newSystem(t)
+
+	in := channels.New[int](4)
+	out := channels.New[int](0)
+
+	src := FromChannel(in).Map(func(v interface{}) (interface{}, error) { return v.(int) + 1, nil })
+
+	m, err := src.To(ToChannel(out)).Run(sys)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			_ = in.Send(context.Background(), i)
+		}
+
+		in.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for want := 1; ; want++ {
+		v, ok, err := out.Recv(ctx)
+		if err != nil {
+			t.Fatalf("recv: %v", err)
+		}
+
+		if !ok {
+			if want != 21 {
+				t.Fatalf("channel closed after %d elements", want-1)
+			}
+
+			break
+		}
+
+		if v != want {
+			t.Fatalf("got %d want %d", v, want)
+		}
+	}
+
+	if err := m.Wait(ctx); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+}
+
+func TestStreams_ConnBridge(t *testing.T) {
+	sys := newSystem(t)
+
+	clientIn, serverIn := net.Pipe()
+	serverOut, clientOut := net.Pipe()
+
+	defer clientIn.Close()
+	defer serverOut.Close()
+
+	// Upper-case echo: serverIn -> map -> serverOut.
+	upper := func(v interface{}) (interface{}, error) { return bytes.ToUpper(v.([]byte)), nil }
+
+	m, err := FromConn(serverIn, 4).Map(upper).To(ToConn(serverOut)).Run(sys)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	go func() {
+		_, _ = clientIn.Write([]byte("hello streams"))
+		_ = clientIn.Close()
+	}()
+
+	var buf bytes.Buffer
+
+	readDone := make(chan struct{})
+
+	go func() {
+		tmp := make([]byte, 64)
+
+		for buf.Len() < len("hello streams") {
+			n, err := clientOut.Read(tmp)
+			buf.Write(tmp[:n])
+
+			if err != nil {
+				break
+			}
+		}
+
+		close(readDone)
+	}()
+
+	select {
+	case <-readDone:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out reading echoed data")
+	}
+
+	if buf.String() != "HELLO STREAMS" {
+		t.Fatalf("unexpected echo %q", buf.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := m.Wait(ctx); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+}
@@ -0,0 +1,470 @@
+package concurrency
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// strategy picks the task to run at a scheduling point, as an index into
+// enabled, or -1 to abandon the run. It is called with s.mu held.
+type strategy interface {
+	pick(s *Scheduler, enabled []int64) int
+	name() string
+}
+
+type randomStrategy struct{}
+
+func (randomStrategy) pick(s *Scheduler, enabled []int64) int { return s.r.Intn(len(enabled)) }
+
+func (randomStrategy) name() string { return "random" }
+
+// stay keeps running the last task when it can, otherwise the lowest id,
+// so that runs preempt as little as possible.
+func stay(s *Scheduler, enabled []int64) int {
+	best := 0
+
+	for i, id := range enabled {
+		if id == s.last {
+			return i
+		}
+
+		if id < enabled[best] {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// replayStrategy follows recorded choices, then runs without preemption.
+type replayStrategy struct {
+	steps []int64
+}
+
+func (r *replayStrategy) pick(s *Scheduler, enabled []int64) int {
+	n := len(s.steps)
+	if n >= len(r.steps) {
+		return stay(s, enabled)
+	}
+
+	for i, id := range enabled {
+		if id == r.steps[n] {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (*replayStrategy) name() string { return "replay" }
+
+// Trace is a recorded schedule: the task run at every scheduling point.
+// Test and Dir locate the test that produced it, for orizon-repro.
+type Trace struct {
+	Strategy string  `json:"strategy"`
+	Test     string  `json:"test,omitempty"`
+	Dir      string  `json:"dir,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Steps    []int64 `json:"steps"`
+	Seed     int64   `json:"seed"`
+}
+
+// ScheduleEnv names a trace file to replay. When it is set, ExplorePCT and
+// ExploreDPOR run only that schedule; orizon-repro sets it.
+const ScheduleEnv = "ORIZON_SCHEDULE"
+
+// WriteFile saves t as JSON. An empty Dir is set to the working directory,
+// which under go test is the directory of the package being tested.
+func (t *Trace) WriteFile(path string) error {
+	if t.Dir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			t.Dir = wd
+		}
+	}
+
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// ReadTrace loads a trace saved by WriteFile.
+func ReadTrace(path string) (*Trace, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var t Trace
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("trace %s: %w", path, err)
+	}
+
+	return &t, nil
+}
+
+// ScheduleError is a failed exploration run with the schedule that led to
+// it.
+type ScheduleError struct {
+	Err   error
+	Trace *Trace
+	Run   int
+}
+
+func (e *ScheduleError) Error() string {
+	return fmt.Sprintf("run %d (%s, %d steps): %v", e.Run, e.Trace.Strategy, len(e.Trace.Steps), e.Err)
+}
+
+func (e *ScheduleError) Unwrap() error { return e.Err }
+
+// Trial registers tasks on s, calls s.Run and checks the outcome. It must
+// be deterministic apart from the schedule.
+type Trial func(s *Scheduler) error
+
+func runTrial(s *Scheduler, run int, trial Trial) *ScheduleError {
+	if err := trial(s); err != nil {
+		t := s.Trace()
+		t.Error = err.Error()
+
+		return &ScheduleError{Err: err, Trace: t, Run: run}
+	}
+
+	return nil
+}
+
+// Replay runs trial once under the schedule saved at path.
+func Replay(path string, trial Trial) *ScheduleError {
+	t, err := ReadTrace(path)
+	if err != nil {
+		return &ScheduleError{Err: err, Trace: &Trace{Strategy: "replay"}}
+	}
+
+	return runTrial(New(Options{Seed: t.Seed, Replay: t}), 0, trial)
+}
+
+// PCTOptions configures ExplorePCT.
+type PCTOptions struct {
+	Seed     int64 // seed of the first run; run i uses Seed+i
+	Runs     int   // number of runs; default 100
+	Depth    int   // bug depth d; default 3
+	MaxSteps int   // scheduling points per run k; 0 learns it from the runs
+}
+
+// pctStrategy is probabilistic concurrency testing: tasks get random
+// distinct priorities of at least depth, the highest-priority task always
+// runs, and at depth-1 random steps the running task drops to priority
+// 1..depth-1.
+type pctStrategy struct {
+	prio    map[int64]int
+	changes map[int]int
+}
+
+func (p *pctStrategy) pick(s *Scheduler, enabled []int64) int {
+	if low, ok := p.changes[len(s.steps)]; ok && s.last != 0 {
+		p.prio[s.last] = low
+	}
+
+	best := -1
+
+	for i, id := range enabled {
+		if _, ok := p.prio[id]; !ok {
+			p.prio[id] = len(p.changes) + 1 + s.r.Intn(1<<30)
+		}
+
+		if best < 0 || p.prio[id] > p.prio[enabled[best]] {
+			best = i
+		}
+	}
+
+	return best
+}
+
+func (*pctStrategy) name() string { return "pct" }
+
+// ExplorePCT runs trial under the PCT strategy of Burckhardt et al. For a
+// bug that needs d ordering constraints between n tasks in runs of at most
+// k scheduling points, each run finds it with probability at least
+// 1/(n*k^(d-1)), so the number of runs needed is known in advance. It
+// returns the first failure, or nil.
+func ExplorePCT(opts PCTOptions, trial Trial) *ScheduleError {
+	if path := os.Getenv(ScheduleEnv); path != "" {
+		return Replay(path, trial)
+	}
+
+	if opts.Runs <= 0 {
+		opts.Runs = 100
+	}
+
+	if opts.Depth <= 0 {
+		opts.Depth = 3
+	}
+
+	k := max(opts.MaxSteps, 1)
+
+	for run := 0; run < opts.Runs; run++ {
+		s := New(Options{Seed: opts.Seed + int64(run)})
+
+		p := &pctStrategy{prio: make(map[int64]int), changes: make(map[int]int)}
+		for i := 1; i < opts.Depth; i++ {
+			p.changes[s.r.Intn(k)] = i
+		}
+
+		s.strategy = p
+
+		if err := runTrial(s, run, trial); err != nil {
+			return err
+		}
+
+		if opts.MaxSteps <= 0 {
+			k = max(k, len(s.steps))
+		}
+	}
+
+	return nil
+}
+
+// DPOROptions configures ExploreDPOR.
+type DPOROptions struct {
+	MaxRuns  int // bound on the runs; default 1000
+	MaxSteps int // scheduling points per run that are explored; default 200
+}
+
+// DPORStats summarizes an ExploreDPOR search.
+type DPORStats struct {
+	Runs     int  // distinct interleavings executed
+	Complete bool // every interleaving within MaxSteps was covered
+}
+
+// dporNode is a scheduling point of the search: the tasks enabled there,
+// those that must still be tried and those already tried.
+type dporNode struct {
+	enabled   []int64
+	backtrack map[int64]bool
+	done      map[int64]bool
+}
+
+// dporStrategy replays a prefix of choices, then avoids preemptions.
+type dporStrategy struct {
+	prefix []int64
+}
+
+func (d *dporStrategy) pick(s *Scheduler, enabled []int64) int {
+	if n := len(s.steps); n < len(d.prefix) {
+		for i, id := range enabled {
+			if id == d.prefix[n] {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	return stay(s, enabled)
+}
+
+func (*dporStrategy) name() string { return "dpor" }
+
+// ExploreDPOR enumerates the interleavings of trial with dynamic
+// partial-order reduction (Flanagan and Godefroid): after every run, each
+// pair of conflicting steps of different tasks that are not ordered by
+// happens-before schedules the opposite order at the earlier step. Steps
+// conflict when they access a common key, one of them writing it, as
+// recorded by Scheduler.Access or an attached HBDetector. Runs stay
+// distinct, so a complete search covers every behaviour up to MaxSteps. It
+// returns the first failure, or nil.
+func ExploreDPOR(opts DPOROptions, trial Trial) (*ScheduleError, DPORStats) {
+	if path := os.Getenv(ScheduleEnv); path != "" {
+		return Replay(path, trial), DPORStats{Runs: 1}
+	}
+
+	if opts.MaxRuns <= 0 {
+		opts.MaxRuns = 1000
+	}
+
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = 200
+	}
+
+	var (
+		stack  []*dporNode
+		prefix []int64
+		stats  DPORStats
+	)
+
+	for stats.Runs < opts.MaxRuns {
+		s := New(Options{Seed: 1})
+		s.strategy = &dporStrategy{prefix: prefix}
+
+		run := stats.Runs
+		stats.Runs++
+
+		if err := runTrial(s, run, trial); err != nil {
+			return err, stats
+		}
+
+		steps := s.steps
+		if len(steps) > opts.MaxSteps {
+			steps = steps[:opts.MaxSteps]
+		}
+
+		for i := len(stack); i < len(steps); i++ {
+			id := steps[i].task
+			stack = append(stack, &dporNode{
+				enabled:   steps[i].enabled,
+				backtrack: map[int64]bool{id: true},
+				done:      map[int64]bool{id: true},
+			})
+		}
+
+		addBacktracks(stack, steps, s.parents())
+
+		// continue from the deepest point with a task left to try.
+		next := -1
+
+		var choice int64
+
+		for i := len(stack) - 1; i >= 0 && next < 0; i-- {
+			var todo []int64
+
+			for id := range stack[i].backtrack {
+				if !stack[i].done[id] {
+					todo = append(todo, id)
+				}
+			}
+
+			if len(todo) > 0 {
+				sort.Slice(todo, func(a, b int) bool { return todo[a] < todo[b] })
+				next, choice = i, todo[0]
+			}
+		}
+
+		if next < 0 {
+			stats.Complete = true
+
+			return nil, stats
+		}
+
+		stack[next].done[choice] = true
+		stack = stack[:next+1]
+
+		prefix = make([]int64, next+1)
+		for i := 0; i < next; i++ {
+			prefix[i] = steps[i].task
+		}
+
+		prefix[next] = choice
+	}
+
+	return nil, stats
+}
+
+// parents maps every task to the step that started it, -1 if none.
+func (s *Scheduler) parents() map[int64]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[int64]int, len(s.tasks))
+	for _, t := range s.tasks {
+		out[t.id] = t.parent
+	}
+
+	return out
+}
+
+// addBacktracks finds, for every step j, the last earlier step i of
+// another task that conflicts with it and does not happen before the task
+// of j reached j, and asks for that task to be tried at i.
+func addBacktracks(stack []*dporNode, steps []step, parent map[int64]int) {
+	anyAccess := false
+
+	for _, st := range steps {
+		anyAccess = anyAccess || len(st.accesses) > 0
+	}
+
+	conflict := func(a, b step) bool {
+		if a.task == b.task {
+			return false
+		}
+
+		if !anyAccess {
+			return true
+		}
+
+		for _, x := range a.accesses {
+			for _, y := range b.accesses {
+				if x.key == y.key && (x.write || y.write) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	// hb[j] holds the steps that happen before step j, j included.
+	hb := make([][]bool, len(steps))
+	prev := make([]int, len(steps)) // previous step of the same task, or the parent step
+	lastOf := make(map[int64]int)
+
+	for j, st := range steps {
+		hb[j] = make([]bool, len(steps))
+		hb[j][j] = true
+
+		p, ok := lastOf[st.task]
+		if !ok {
+			p = parent[st.task]
+		}
+
+		prev[j] = p
+		lastOf[st.task] = j
+
+		if p >= 0 {
+			orInto(hb[j], hb[p])
+		}
+
+		for i := 0; i < j; i++ {
+			if conflict(steps[i], st) {
+				orInto(hb[j], hb[i])
+			}
+		}
+	}
+
+	for j, st := range steps {
+		for i := j - 1; i >= 0; i-- {
+			if !conflict(steps[i], st) || (prev[j] >= 0 && hb[prev[j]][i]) {
+				continue
+			}
+
+			n := stack[i]
+			if contains(n.enabled, st.task) {
+				n.backtrack[st.task] = true
+			} else {
+				for _, id := range n.enabled {
+					n.backtrack[id] = true
+				}
+			}
+
+			break
+		}
+	}
+}
+
+func orInto(dst, src []bool) {
+	for i, v := range src {
+		dst[i] = dst[i] || v
+	}
+}
+
+func contains(ids []int64, id int64) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,221 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+// counterTrial runs n tasks that increment a shared counter, yielding
+// between the read and the write when racy is set.
+func counterTrial(n int, racy bool) Trial {
+	return func(s *Scheduler) error {
+		var x int
+
+		key := uintptr(unsafe.Pointer(&x))
+
+		for i := 0; i < n; i++ {
+			s.Go(func(_ context.Context, s *Scheduler) {
+				s.Access(key, false)
+				v := x
+
+				if racy {
+					s.Yield()
+				}
+
+				s.Access(key, true)
+				x = v + 1
+				s.Yield()
+			})
+		}
+
+		if err := s.Run(context.Background()); err != nil {
+			return err
+		}
+
+		if x != n {
+			return fmt.Errorf("lost update: counter is %d, want %d", x, n)
+		}
+
+		return nil
+	}
+}
+
+func TestExploreDPOR_FindsLostUpdate(t *testing.T) {
+	failure, stats := ExploreDPOR(DPOROptions{}, counterTrial(2, true))
+	if failure == nil {
+		t.Fatalf("expected the lost update to be found in %d runs", stats.Runs)
+	}
+
+	if again := Replay(writeTrace(t, failure.Trace), counterTrial(2, true)); again == nil || again.Err.Error() != failure.Err.Error() {
+		t.Fatalf("replay did not reproduce %v: %v", failure, again)
+	}
+}
+
+func TestExploreDPOR_PrunesIndependentSteps(t *testing.T) {
+	failure, stats := ExploreDPOR(DPOROptions{}, counterTrial(3, false))
+	if failure != nil {
+		t.Fatalf("unexpected failure: %v", failure)
+	}
+
+	if !stats.Complete {
+		t.Fatalf("expected a complete search, got %+v", stats)
+	}
+
+	// three tasks with one conflicting step each have 3! orders.
+	if stats.Runs != 6 {
+		t.Fatalf("expected 6 runs, got %d", stats.Runs)
+	}
+
+	// without recorded accesses every step conflicts, which adds the
+	// orders of the final steps.
+	_, all := ExploreDPOR(DPOROptions{}, func(s *Scheduler) error {
+		for i := 0; i < 3; i++ {
+			s.Go(func(_ context.Context, s *Scheduler) { s.Yield() })
+		}
+
+		return s.Run(context.Background())
+	})
+	if !all.Complete || all.Runs <= stats.Runs {
+		t.Fatalf("expected more runs without accesses, got %+v", all)
+	}
+}
+
+func TestExploreDPOR_UsesDetectorAccesses(t *testing.T) {
+	trial := func(s *Scheduler) error {
+		det := NewHBDetector()
+		s.Attach(det)
+
+		m := NewTrackedMutex(1, det)
+
+		var x int
+
+		addr := uintptr(unsafe.Pointer(&x))
+
+		for i := 0; i < 2; i++ {
+			s.Go(func(_ context.Context, s *Scheduler) {
+				m.Lock(s.Current())
+				det.Read(s.Current(), addr)
+				v := x
+				m.Unlock(s.Current())
+				s.Yield()
+				m.Lock(s.Current())
+				x = v + 1
+				det.Write(s.Current(), addr)
+				m.Unlock(s.Current())
+			})
+		}
+
+		if err := s.Run(context.Background()); err != nil {
+			return err
+		}
+
+		if x != 2 {
+			return errors.New("lost update")
+		}
+
+		return nil
+	}
+
+	if failure, stats := ExploreDPOR(DPOROptions{}, trial); failure == nil {
+		t.Fatalf("expected the lost update to be found, %+v", stats)
+	}
+}
+
+// orderTrial fails only if two tasks of two steps each alternate
+// strictly, which PCT reaches with two priority changes.
+func orderTrial(s *Scheduler) error {
+	var log []int
+
+	for id := 1; id <= 2; id++ {
+		s.Go(func(_ context.Context, s *Scheduler) {
+			log = append(log, id)
+			s.Yield()
+			log = append(log, id)
+		})
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		return err
+	}
+
+	if fmt.Sprint(log) == "[1 2 1 2]" {
+		return errors.New("bad order")
+	}
+
+	return nil
+}
+
+func TestExplorePCT_FindsOrderingBug(t *testing.T) {
+	failure := ExplorePCT(PCTOptions{Seed: 1, Runs: 500, Depth: 3}, orderTrial)
+	if failure == nil {
+		t.Fatalf("expected the ordering bug to be found")
+	}
+
+	if failure.Trace.Strategy != "pct" || fmt.Sprint(failure.Trace.Steps) != "[1 2 1 2]" {
+		t.Fatalf("unexpected trace %+v", failure.Trace)
+	}
+
+	// with ORIZON_SCHEDULE set, exploration only replays the trace.
+	t.Setenv(ScheduleEnv, writeTrace(t, failure.Trace))
+
+	runs := 0
+
+	again := ExplorePCT(PCTOptions{Runs: 500}, func(s *Scheduler) error {
+		runs++
+
+		return orderTrial(s)
+	})
+	if again == nil || runs != 1 || again.Trace.Strategy != "replay" {
+		t.Fatalf("expected one replayed failure, got %v after %d runs", again, runs)
+	}
+}
+
+func TestReplay_Diverged(t *testing.T) {
+	path := writeTrace(t, &Trace{Strategy: "pct", Steps: []int64{1, 7}})
+
+	var log []int
+
+	failure := Replay(path, func(s *Scheduler) error {
+		for id := 1; id <= 2; id++ {
+			s.Go(func(_ context.Context, s *Scheduler) {
+				log = append(log, id)
+				s.Yield()
+				log = append(log, id)
+			})
+		}
+
+		return s.Run(context.Background())
+	})
+	if failure == nil || !errors.Is(failure, ErrScheduleDiverged) {
+		t.Fatalf("expected a diverged schedule, got %v", failure)
+	}
+
+	// both tasks finished, one at a time, before Replay returned.
+	if fmt.Sprint(log) != "[1 2 1 2]" {
+		t.Fatalf("unexpected order after divergence: %v", log)
+	}
+}
+
+func writeTrace(t *testing.T, tr *Trace) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "trace.json")
+	if err := tr.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadTrace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Dir == "" || fmt.Sprint(read.Steps) != fmt.Sprint(tr.Steps) {
+		t.Fatalf("trace did not round-trip: %+v", read)
+	}
+
+	return path
+}
@@ -0,0 +1,389 @@
+package concurrency
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// HBDetector is a happens-before race detector in the style of FastTrack.
+// Every thread carries a vector clock; locks, channel messages, wait
+// groups, atomics and message stamps carry clocks between threads, so only
+// accesses that are truly unordered are reported. Unlike RaceDetector it
+// has no false positives for code synchronized by other means than locks,
+// as long as that synchronization is reported to it.
+//
+// Accesses are recorded with Read and Write. Synchronization is recorded
+// with OnLock and OnUnlock (see TrackedMutex), Fork and Join, Send and Recv,
+// AtomicLoad and AtomicStore, or the TrackedChannel and TrackedWaitGroup
+// wrappers.
+type HBDetector struct {
+	threads map[int64]vectorClock
+	syncs   map[syncKey]vectorClock
+	vars    map[uintptr]*hbVar
+	races   []Race
+	dedup   map[string]struct{}
+	observe func(gid int64, key syncKey, write bool)
+	seed    int64
+	objects uint64
+	mu      sync.Mutex
+}
+
+// vectorClock maps a thread to the number of its synchronization epochs.
+type vectorClock map[int64]uint64
+
+func (vc vectorClock) join(o vectorClock) {
+	for t, c := range o {
+		if c > vc[t] {
+			vc[t] = c
+		}
+	}
+}
+
+func (vc vectorClock) clone() vectorClock {
+	out := make(vectorClock, len(vc))
+	for t, c := range vc {
+		out[t] = c
+	}
+
+	return out
+}
+
+// epoch is the clock of one thread at one access. The zero epoch precedes
+// everything, since thread clocks start at 1.
+type epoch struct {
+	tid   int64
+	clock uint64
+}
+
+func (e epoch) before(vc vectorClock) bool { return e.clock <= vc[e.tid] }
+
+// access is an epoch with the stack that performed it.
+type access struct {
+	stack []uintptr
+	epoch
+}
+
+// hbVar is the shadow state of a variable: the last write, and either the
+// last read or, once reads are concurrent, the last read of every thread.
+type hbVar struct {
+	shared map[int64]access
+	write  access
+	read   access
+}
+
+// syncKind separates the id spaces of synchronization objects.
+type syncKind uint8
+
+const (
+	syncLock syncKind = iota
+	syncAddr
+	syncObject
+	syncVar  // plain variables, for access observers only
+	syncUser // keys passed to Scheduler.Access
+)
+
+type syncKey struct {
+	id   uint64
+	kind syncKind
+}
+
+// Stamp is the clock of a thread at a message send. Attach it to the
+// message, for example an actor message payload, and hand it to Recv.
+type Stamp struct{ vc vectorClock }
+
+// maxStackDepth bounds the frames recorded per access.
+const maxStackDepth = 32
+
+// NewHBDetector creates a new detector instance.
+func NewHBDetector() *HBDetector {
+	return &HBDetector{
+		threads: make(map[int64]vectorClock),
+		syncs:   make(map[syncKey]vectorClock),
+		vars:    make(map[uintptr]*hbVar),
+		dedup:   make(map[string]struct{}),
+	}
+}
+
+// clock returns the vector clock of gid; callers hold d.mu.
+func (d *HBDetector) clock(gid int64) vectorClock {
+	vc := d.threads[gid]
+	if vc == nil {
+		vc = vectorClock{gid: 1}
+		d.threads[gid] = vc
+	}
+
+	return vc
+}
+
+func (d *HBDetector) acquire(gid int64, k syncKey) {
+	d.touch(gid, k, true)
+
+	if l := d.syncs[k]; l != nil {
+		d.clock(gid).join(l)
+	}
+}
+
+func (d *HBDetector) release(gid int64, k syncKey) {
+	d.touch(gid, k, true)
+
+	vc := d.clock(gid)
+
+	l := d.syncs[k]
+	if l == nil {
+		l = make(vectorClock)
+		d.syncs[k] = l
+	}
+
+	l.join(vc)
+	vc[gid]++
+}
+
+// OnLock records that thread gid acquired the lock lid.
+func (d *HBDetector) OnLock(gid, lid int64) {
+	d.mu.Lock()
+	d.acquire(gid, syncKey{kind: syncLock, id: uint64(lid)})
+	d.mu.Unlock()
+}
+
+// OnUnlock records that thread gid is releasing the lock lid. It must be
+// called before the lock is actually released.
+func (d *HBDetector) OnUnlock(gid, lid int64) {
+	d.mu.Lock()
+	d.release(gid, syncKey{kind: syncLock, id: uint64(lid)})
+	d.mu.Unlock()
+}
+
+// Fork records that parent started child: everything parent did so far
+// happens before anything child does.
+func (d *HBDetector) Fork(parent, child int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pc := d.clock(parent)
+	d.clock(child).join(pc)
+	pc[parent]++
+}
+
+// Join records that gid waited for child to finish.
+func (d *HBDetector) Join(gid, child int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cc := d.clock(child)
+	d.clock(gid).join(cc)
+	cc[child]++
+}
+
+// Send returns the stamp of a message sent by gid.
+func (d *HBDetector) Send(gid int64) Stamp {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	vc := d.clock(gid)
+	s := Stamp{vc: vc.clone()}
+	vc[gid]++
+
+	return s
+}
+
+// Recv records that gid received a message sent with stamp s.
+func (d *HBDetector) Recv(gid int64, s Stamp) {
+	d.mu.Lock()
+	d.clock(gid).join(s.vc)
+	d.mu.Unlock()
+}
+
+// AtomicLoad runs op, an atomic load from addr, and records that it
+// observes every atomic store to addr recorded so far.
+func (d *HBDetector) AtomicLoad(gid int64, addr uintptr, op func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	op()
+	d.acquire(gid, syncKey{kind: syncAddr, id: uint64(addr)})
+}
+
+// AtomicStore runs op, an atomic store, swap, add or compare-and-swap on
+// addr, and records it as synchronizing with later atomic operations on
+// addr. op runs under the detector lock so that the record and the
+// operation take place in the same order.
+func (d *HBDetector) AtomicStore(gid int64, addr uintptr, op func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	op()
+
+	k := syncKey{kind: syncAddr, id: uint64(addr)}
+	d.acquire(gid, k)
+	d.release(gid, k)
+}
+
+// Read marks a read access to the variable at address addr by thread gid.
+func (d *HBDetector) Read(gid int64, addr uintptr) {
+	stack := callers()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.touch(gid, syncKey{kind: syncVar, id: uint64(addr)}, false)
+
+	vc := d.clock(gid)
+	cur := access{epoch: epoch{tid: gid, clock: vc[gid]}, stack: stack}
+	v := d.variable(addr)
+
+	if v.read.epoch == cur.epoch || v.shared[gid].epoch == cur.epoch {
+		return
+	}
+
+	if !v.write.before(vc) {
+		d.report(RaceWriteRead, addr, v.write, cur)
+	}
+
+	switch {
+	case v.shared != nil:
+		v.shared[gid] = cur
+	case v.read.before(vc):
+		v.read = cur
+	default:
+		v.shared = map[int64]access{v.read.tid: v.read, gid: cur}
+		v.read = access{}
+	}
+}
+
+// Write marks a write access to the variable at address addr by thread gid.
+func (d *HBDetector) Write(gid int64, addr uintptr) {
+	stack := callers()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.touch(gid, syncKey{kind: syncVar, id: uint64(addr)}, true)
+
+	vc := d.clock(gid)
+	cur := access{epoch: epoch{tid: gid, clock: vc[gid]}, stack: stack}
+	v := d.variable(addr)
+
+	if v.write.epoch == cur.epoch {
+		return
+	}
+
+	if !v.write.before(vc) {
+		d.report(RaceWriteWrite, addr, v.write, cur)
+	}
+
+	if v.shared != nil {
+		for _, r := range v.shared {
+			if !r.before(vc) {
+				d.report(RaceReadWrite, addr, r, cur)
+			}
+		}
+
+		v.shared = nil
+	} else if !v.read.before(vc) {
+		d.report(RaceReadWrite, addr, v.read, cur)
+	}
+
+	v.read = access{}
+	v.write = cur
+}
+
+func (d *HBDetector) variable(addr uintptr) *hbVar {
+	v := d.vars[addr]
+	if v == nil {
+		v = &hbVar{}
+		d.vars[addr] = v
+	}
+
+	return v
+}
+
+// report records a race between an earlier access and the current one,
+// once per variable, thread pair and kind.
+func (d *HBDetector) report(kind RaceKind, addr uintptr, prev, cur access) {
+	key := fmt.Sprintf("%x|%d|%d|%s", addr, prev.tid, cur.tid, kind)
+	if _, dup := d.dedup[key]; dup {
+		return
+	}
+
+	d.dedup[key] = struct{}{}
+	d.races = append(d.races, Race{
+		Kind:    kind,
+		VarAddr: addr,
+		Thread1: prev.tid,
+		Thread2: cur.tid,
+		Stack1:  frames(prev.stack),
+		Stack2:  frames(cur.stack),
+		Seed:    d.seed,
+	})
+}
+
+// Races returns a snapshot of all detected races. Thread1 made the earlier
+// access and Thread2 the later one.
+func (d *HBDetector) Races() []Race {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make([]Race, len(d.races))
+	copy(out, d.races)
+
+	return out
+}
+
+// HasRace reports whether any race has been detected.
+func (d *HBDetector) HasRace() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.races) > 0
+}
+
+// setObserver reports every access and synchronization to fn; see
+// Scheduler.Access.
+func (d *HBDetector) setObserver(fn func(gid int64, key syncKey, write bool)) {
+	d.mu.Lock()
+	d.observe = fn
+	d.mu.Unlock()
+}
+
+// touch passes an access to the observer; callers hold d.mu.
+func (d *HBDetector) touch(gid int64, key syncKey, write bool) {
+	if d.observe != nil {
+		d.observe(gid, key, write)
+	}
+}
+
+// setSeed stamps later reports with the seed of the scheduler in use.
+func (d *HBDetector) setSeed(seed int64) {
+	d.mu.Lock()
+	d.seed = seed
+	d.mu.Unlock()
+}
+
+// callers records the stack of the caller of Read or Write.
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+
+	return pcs[:runtime.Callers(3, pcs)]
+}
+
+func frames(pcs []uintptr) []string {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var out []string
+
+	fs := runtime.CallersFrames(pcs)
+
+	for {
+		f, more := fs.Next()
+		out = append(out, fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line))
+
+		if !more {
+			break
+		}
+	}
+
+	return out
+}
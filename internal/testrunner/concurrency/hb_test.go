@@ -0,0 +1,288 @@
+package concurrency
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+)
+
+func TestHBDetector_ReportsUnorderedWritesWithStacks(t *testing.T) {
+	det := NewHBDetector()
+
+	var shared int64
+	addr := uintptr(unsafe.Pointer(&shared))
+
+	det.Fork(0, 1)
+	det.Fork(0, 2)
+	det.Write(1, addr)
+	det.Write(2, addr)
+
+	races := det.Races()
+	if len(races) != 1 || races[0].Kind != RaceWriteWrite || races[0].Thread1 != 1 || races[0].Thread2 != 2 {
+		t.Fatalf("expected one write-write race between 1 and 2, got %+v", races)
+	}
+
+	for _, st := range [][]string{races[0].Stack1, races[0].Stack2} {
+		if len(st) == 0 || !strings.Contains(st[0], "TestHBDetector_ReportsUnorderedWritesWithStacks") {
+			t.Fatalf("expected the test function on top of the stack, got %v", st)
+		}
+	}
+}
+
+func TestHBDetector_ChannelHandOff(t *testing.T) {
+	hb := NewHBDetector()
+	ls := NewRaceDetector()
+	ch := NewTrackedChannel[int](0, hb)
+
+	var shared int64
+	addr := uintptr(unsafe.Pointer(&shared))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		v, ok, err := ch.Recv(context.Background(), 2)
+		if err != nil || !ok || v != 7 {
+			t.Errorf("recv: %d %v %v", v, ok, err)
+		}
+
+		shared++
+
+		hb.Write(2, addr)
+		ls.Write(2, addr)
+	}()
+
+	shared = 1
+
+	hb.Write(1, addr)
+	ls.Write(1, addr)
+
+	if err := ch.Send(context.Background(), 1, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	<-done
+
+	if hb.HasRace() {
+		t.Fatalf("did not expect a race after a channel hand-off, got: %+v", hb.Races())
+	}
+
+	// the lockset detector cannot see the hand-off.
+	if !ls.HasRace() {
+		t.Fatalf("expected the lockset detector to report the hand-off")
+	}
+}
+
+func TestHBDetector_WaitGroupAtomicsAndStamps(t *testing.T) {
+	det := NewHBDetector()
+
+	var a, b, c, flag int64
+	wg := NewTrackedWaitGroup(det)
+
+	// wait group: workers write before Done, the waiter reads after Wait.
+	for gid := int64(1); gid <= 2; gid++ {
+		wg.Add(1)
+
+		go func(gid int64) {
+			atomic.AddInt64(&a, 1)
+			det.Write(gid, uintptr(unsafe.Pointer(&a))+uintptr(gid))
+			wg.Done(gid)
+		}(gid)
+	}
+
+	wg.Wait(0)
+	det.Read(0, uintptr(unsafe.Pointer(&a))+1)
+	det.Read(0, uintptr(unsafe.Pointer(&a))+2)
+
+	// atomic flag publishes b.
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for {
+			var set int64
+
+			det.AtomicLoad(4, uintptr(unsafe.Pointer(&flag)), func() { set = atomic.LoadInt64(&flag) })
+
+			if set == 1 {
+				det.Read(4, uintptr(unsafe.Pointer(&b)))
+
+				return
+			}
+		}
+	}()
+
+	b = 1
+	det.Write(3, uintptr(unsafe.Pointer(&b)))
+	det.AtomicStore(3, uintptr(unsafe.Pointer(&flag)), func() { atomic.StoreInt64(&flag, 1) })
+	<-done
+
+	// a message stamp, as carried by an actor message.
+	c = 1
+	det.Write(5, uintptr(unsafe.Pointer(&c)))
+	stamp := det.Send(5)
+	det.Recv(6, stamp)
+	det.Write(6, uintptr(unsafe.Pointer(&c)))
+
+	if det.HasRace() {
+		t.Fatalf("did not expect races, got: %+v", det.Races())
+	}
+
+	// without the stamp the accesses are unordered.
+	det.Write(7, uintptr(unsafe.Pointer(&c)))
+
+	if races := det.Races(); len(races) != 1 || races[0].Thread1 != 6 || races[0].Thread2 != 7 {
+		t.Fatalf("expected a race between 6 and 7, got %+v", races)
+	}
+}
+
+func TestHBDetector_TrackedMutex(t *testing.T) {
+	det := NewHBDetector()
+	m := NewTrackedMutex(100, det)
+
+	var shared int64
+	addr := uintptr(unsafe.Pointer(&shared))
+	done := make(chan struct{}, 2)
+
+	for gid := int64(1); gid <= 2; gid++ {
+		go func(gid int64) {
+			for i := 0; i < 500; i++ {
+				m.Lock(gid)
+				det.Read(gid, addr)
+				shared++
+
+				det.Write(gid, addr)
+				m.Unlock(gid)
+			}
+			done <- struct{}{}
+		}(gid)
+	}
+
+	<-done
+	<-done
+
+	if det.HasRace() {
+		t.Fatalf("did not expect a race under mutex, got: %+v", det.Races())
+	}
+}
+
+// publishRaces runs two tasks where the second reads x only if it sees the
+// flag set by the first, which writes x after releasing the lock. Whether
+// the race happens depends on the schedule.
+func publishRaces(seed int64) []Race {
+	det := NewHBDetector()
+	s := New(Options{Seed: seed, Detector: det})
+	m := NewTrackedMutex(1, det)
+
+	var flag bool
+
+	var x int
+
+	addr := uintptr(unsafe.Pointer(&x))
+
+	s.Go(func(_ context.Context, s *Scheduler) {
+		s.Yield()
+		m.Lock(s.Current())
+		flag = true
+		m.Unlock(s.Current())
+		s.Yield()
+		x = 1
+		det.Write(s.Current(), addr)
+	})
+	s.Go(func(_ context.Context, s *Scheduler) {
+		s.Yield()
+		m.Lock(s.Current())
+		seen := flag
+		m.Unlock(s.Current())
+		s.Yield()
+
+		if seen {
+			_ = x
+			det.Read(s.Current(), addr)
+		}
+	})
+
+	if err := s.Run(context.Background()); err != nil {
+		panic(err)
+	}
+
+	// after Run every task happens before the caller.
+	det.Write(0, addr)
+
+	return det.Races()
+}
+
+func TestScheduler_RacesReproduceFromSeed(t *testing.T) {
+	racy, clean := 0, 0
+
+	for seed := int64(1); seed <= 40; seed++ {
+		first := publishRaces(seed)
+		again := publishRaces(seed)
+
+		if len(first) != len(again) {
+			t.Fatalf("seed %d: %d races, then %d", seed, len(first), len(again))
+		}
+
+		for i := range first {
+			if first[i].Kind != again[i].Kind || first[i].Thread1 != again[i].Thread1 || first[i].Thread2 != again[i].Thread2 {
+				t.Fatalf("seed %d: race %v, then %v", seed, first[i], again[i])
+			}
+
+			if first[i].Seed != seed {
+				t.Fatalf("seed %d: race reports seed %d", seed, first[i].Seed)
+			}
+		}
+
+		if len(first) > 0 {
+			racy++
+		} else {
+			clean++
+		}
+	}
+
+	if racy == 0 || clean == 0 {
+		t.Fatalf("expected both racy and clean schedules, got %d racy and %d clean", racy, clean)
+	}
+}
+
+func TestScheduler_CancelFinishesTasksOneAtATime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := New(Options{Seed: 1})
+
+	// steps is not synchronized: -race fails if tasks overlap.
+	steps, canceled := 0, 0
+
+	for range 3 {
+		s.Go(func(tctx context.Context, s *Scheduler) {
+			for range 3 {
+				steps++
+				if steps == 2 {
+					cancel()
+				}
+
+				s.Yield()
+			}
+
+			if tctx.Err() != nil {
+				canceled++
+			}
+		})
+	}
+
+	if err := s.Run(ctx); err != context.Canceled {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if steps != 9 || canceled != 3 {
+		t.Fatalf("Run returned after %d steps with %d tasks canceled, want 9 and 3", steps, canceled)
+	}
+
+	if n := len(s.Trace().Steps); n > 3 {
+		t.Fatalf("trace has %d steps after the cancel", n)
+	}
+}
@@ -0,0 +1,126 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+
+	"github.com/orizon-lang/orizon/internal/runtime/channels"
+)
+
+// newObject allocates the id of a synchronization object.
+func (d *HBDetector) newObject() syncKey {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.objects++
+
+	return syncKey{kind: syncObject, id: d.objects}
+}
+
+// TrackedChannel wraps a runtime channel so that every value carries the
+// clock of its sender: a send happens before the receive that gets its
+// value, and Close happens before receives that observe it.
+type TrackedChannel[T any] struct {
+	det    *HBDetector
+	ch     *channels.Channel[stamped[T]]
+	closed syncKey
+}
+
+type stamped[T any] struct {
+	v     T
+	stamp Stamp
+}
+
+// NewTrackedChannel creates a channel with the given capacity.
+func NewTrackedChannel[T any](capacity int, det *HBDetector) *TrackedChannel[T] {
+	return &TrackedChannel[T]{det: det, ch: channels.New[stamped[T]](capacity), closed: det.newObject()}
+}
+
+// Send sends v from gid, blocking like channels.Channel.Send.
+func (c *TrackedChannel[T]) Send(ctx context.Context, gid int64, v T) error {
+	return c.ch.Send(ctx, stamped[T]{v: v, stamp: c.det.Send(gid)})
+}
+
+// TrySend sends v from gid without blocking.
+func (c *TrackedChannel[T]) TrySend(gid int64, v T) bool {
+	return c.ch.TrySend(stamped[T]{v: v, stamp: c.det.Send(gid)})
+}
+
+// Recv receives a value into gid, blocking like channels.Channel.Recv.
+func (c *TrackedChannel[T]) Recv(ctx context.Context, gid int64) (T, bool, error) {
+	m, ok, err := c.ch.Recv(ctx)
+	if err == nil {
+		c.received(gid, m, ok)
+	}
+
+	return m.v, ok, err
+}
+
+// TryRecv receives a value into gid without blocking.
+func (c *TrackedChannel[T]) TryRecv(gid int64) (T, bool) {
+	m, ok := c.ch.TryRecv()
+	if ok {
+		c.received(gid, m, ok)
+	}
+
+	return m.v, ok
+}
+
+func (c *TrackedChannel[T]) received(gid int64, m stamped[T], ok bool) {
+	if ok {
+		c.det.Recv(gid, m.stamp)
+
+		return
+	}
+
+	c.det.mu.Lock()
+	c.det.acquire(gid, c.closed)
+	c.det.mu.Unlock()
+}
+
+// Close closes the channel on behalf of gid.
+func (c *TrackedChannel[T]) Close(gid int64) {
+	c.det.mu.Lock()
+	c.det.release(gid, c.closed)
+	c.det.mu.Unlock()
+
+	c.ch.Close()
+}
+
+// Len returns the number of elements queued in the buffer.
+func (c *TrackedChannel[T]) Len() int { return c.ch.Len() }
+
+// TrackedWaitGroup is a sync.WaitGroup whose Done calls happen before the
+// return of Wait.
+type TrackedWaitGroup struct {
+	det *HBDetector
+	wg  sync.WaitGroup
+	key syncKey
+}
+
+// NewTrackedWaitGroup creates a wait group reporting to det.
+func NewTrackedWaitGroup(det *HBDetector) *TrackedWaitGroup {
+	return &TrackedWaitGroup{det: det, key: det.newObject()}
+}
+
+// Add adds delta to the counter.
+func (w *TrackedWaitGroup) Add(delta int) { w.wg.Add(delta) }
+
+// Done decrements the counter on behalf of gid.
+func (w *TrackedWaitGroup) Done(gid int64) {
+	w.det.mu.Lock()
+	w.det.release(gid, w.key)
+	w.det.mu.Unlock()
+
+	w.wg.Done()
+}
+
+// Wait blocks until the counter is zero and records that gid observed every
+// Done.
+func (w *TrackedWaitGroup) Wait(gid int64) {
+	w.wg.Wait()
+
+	w.det.mu.Lock()
+	w.det.acquire(gid, w.key)
+	w.det.mu.Unlock()
+}
@@ -1,6 +1,8 @@
 package concurrency
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -14,11 +16,39 @@ const (
 )
 
 // Race describes a single data race between goroutines on a variable address.
+// Stacks and Seed are only filled in by HBDetector.
 type Race struct {
 	Kind    RaceKind
+	Stack1  []string // stack of the access by Thread1, innermost frame first
+	Stack2  []string // stack of the access by Thread2
 	VarAddr uintptr
 	Thread1 int64
 	Thread2 int64
+	Seed    int64 // seed of the Scheduler the detector was attached to
+}
+
+func (r Race) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s race on %#x between threads %d and %d", r.Kind, r.VarAddr, r.Thread1, r.Thread2)
+
+	if r.Seed != 0 {
+		fmt.Fprintf(&b, " (seed %d)", r.Seed)
+	}
+
+	for i, st := range [][]string{r.Stack1, r.Stack2} {
+		if len(st) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\naccess by thread %d:", [2]int64{r.Thread1, r.Thread2}[i])
+
+		for _, f := range st {
+			b.WriteString("\n  " + strings.ReplaceAll(f, "\n", "\n  "))
+		}
+	}
+
+	return b.String()
 }
 
 // accessKind is a bitset for read/write kinds.
@@ -248,32 +278,40 @@ func itoa64(n int64) string {
 	return string(buf[i:])
 }
 
-// TrackedMutex is a mutex that reports lock/unlock to a RaceDetector.
+// LockObserver is notified of lock acquisitions and releases; both
+// RaceDetector and HBDetector implement it.
+type LockObserver interface {
+	OnLock(gid, lid int64)
+	OnUnlock(gid, lid int64)
+}
+
+// TrackedMutex is a mutex that reports lock/unlock to a race detector.
 type TrackedMutex struct {
-	det *RaceDetector
+	det LockObserver
 	id  int64
 	mu  sync.Mutex
 }
 
 // NewTrackedMutex creates a mutex with a stable logical ID associated with the detector.
-func NewTrackedMutex(id int64, det *RaceDetector) *TrackedMutex {
+func NewTrackedMutex(id int64, det LockObserver) *TrackedMutex {
 	return &TrackedMutex{id: id, det: det}
 }
 
-// Lock records the attempt by gid and acquires the underlying mutex.
+// Lock acquires the underlying mutex and records the acquisition by gid.
 func (m *TrackedMutex) Lock(gid int64) {
+	m.mu.Lock()
+
 	if m.det != nil {
 		m.det.OnLock(gid, m.id)
 	}
-
-	m.mu.Lock()
 }
 
-// Unlock releases the mutex and updates the detector state.
+// Unlock records the release by gid, then releases the mutex, so that the
+// next holder is recorded after it.
 func (m *TrackedMutex) Unlock(gid int64) {
-	m.mu.Unlock()
-
 	if m.det != nil {
 		m.det.OnUnlock(gid, m.id)
 	}
+
+	m.mu.Unlock()
 }
@@ -2,6 +2,8 @@ package concurrency
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/rand"
 	"runtime"
 	"sync"
@@ -12,26 +14,60 @@ import (
 // Scheduler provides a controllable scheduling environment for exploring.
 // different interleavings of concurrent tasks. It is inspired by PCT-style
 // randomized schedulers and is suitable for small-to-medium concurrency tests.
+//
+// Tasks run one at a time: a task keeps running until it calls Yield or
+// returns, and the scheduler then picks the next task with its seeded
+// random source. Interleavings, and any race found under them, are
+// therefore reproducible from the seed, provided tasks only wait for each
+// other through Yield and not by blocking. Every choice is recorded in a
+// Trace, which can be replayed exactly with Options.Replay; ExplorePCT and
+// ExploreDPOR pick tasks systematically instead of at random.
 type Scheduler struct {
-	r       *rand.Rand
-	ready   chan *task
-	doneCh  chan struct{}
-	wg      sync.WaitGroup
-	seed    int64
-	quantum int
-	stopped atomic.Uint32
+	r        *rand.Rand
+	det      *HBDetector
+	strategy strategy
+	current  *task
+	parked   chan struct{}
+	runnable []*task
+	tasks    []*task
+	steps    []step
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	seed     int64
+	lastID   int64
+	last     int64
+	cancel   context.CancelFunc
+	ctx      context.Context
+	quantum  int
+	stopped  atomic.Uint32
 }
 
 type task struct {
-	step chan struct{}
-	run  func(ctx context.Context, s *Scheduler)
-	id   int
+	step   chan struct{}
+	id     int64
+	parent int // step that started the task; -1 before Run
+	yields int
+}
+
+// step is one scheduling decision: the tasks that could run, the one that
+// did, and what it accessed until it yielded.
+type step struct {
+	enabled  []int64
+	accesses []stepAccess
+	task     int64
+}
+
+type stepAccess struct {
+	key   syncKey
+	write bool
 }
 
 // Options configures the Scheduler behavior.
 type Options struct {
-	Seed    int64
-	Quantum int // number of yields between random steals; default 1
+	Detector *HBDetector // optional; tasks are reported to it as threads
+	Replay   *Trace      // optional; follow a recorded schedule
+	Seed     int64
+	Quantum  int // number of yields between random steals; default 1
 }
 
 // New creates a new Scheduler with the specified options.
@@ -44,124 +80,281 @@ func New(opts Options) *Scheduler {
 		opts.Quantum = 1
 	}
 
-	return &Scheduler{
-		seed:    opts.Seed,
-		r:       rand.New(rand.NewSource(opts.Seed)),
-		ready:   make(chan *task, 1024),
-		quantum: opts.Quantum,
-		doneCh:  make(chan struct{}),
+	s := &Scheduler{
+		seed:     opts.Seed,
+		r:        rand.New(rand.NewSource(opts.Seed)),
+		strategy: randomStrategy{},
+		parked:   make(chan struct{}),
+		quantum:  opts.Quantum,
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	if opts.Replay != nil {
+		s.strategy = &replayStrategy{steps: opts.Replay.Steps}
+	}
+
+	if opts.Detector != nil {
+		s.Attach(opts.Detector)
+	}
+
+	return s
+}
+
+// Attach reports tasks to det as threads, stamps its races with the seed
+// and feeds its accesses to ExploreDPOR. Call it before Go.
+func (s *Scheduler) Attach(det *HBDetector) {
+	s.det = det
+	det.setSeed(s.seed)
+	det.setObserver(func(gid int64, key syncKey, write bool) {
+		if gid == s.Current() {
+			s.access(key, write)
+		}
+	})
+}
+
+// Access records that the running task touched key, for ExploreDPOR:
+// steps of different tasks are only reordered when they access a common
+// key and one of them writes it. Accesses reported to an attached
+// HBDetector are recorded automatically. Without any recorded access,
+// every pair of steps counts as dependent.
+func (s *Scheduler) Access(key uintptr, write bool) {
+	s.access(syncKey{kind: syncUser, id: uint64(key)}, write)
+}
+
+func (s *Scheduler) access(key syncKey, write bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil || len(s.steps) == 0 {
+		return
 	}
+
+	st := &s.steps[len(s.steps)-1]
+	st.accesses = append(st.accesses, stepAccess{key: key, write: write})
+}
+
+// Trace returns the schedule taken so far.
+func (s *Scheduler) Trace() *Trace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &Trace{Strategy: s.strategy.name(), Seed: s.seed, Steps: make([]int64, len(s.steps))}
+	for i, st := range s.steps {
+		t.Steps[i] = st.task
+	}
+
+	return t
 }
 
 // Seed returns the scheduler seed.
 func (s *Scheduler) Seed() int64 { return s.seed }
 
-// Go registers and starts a new task under scheduler control.
+// Current returns the id of the running task, or 0 outside of tasks. Task
+// ids are 1, 2, ... in the order of Go calls, so they can serve as thread
+// ids for a detector.
+func (s *Scheduler) Current() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return 0
+	}
+
+	return s.current.id
+}
+
+// Go registers a new task under scheduler control. It may be called before
+// Run or from a running task.
 func (s *Scheduler) Go(fn func(ctx context.Context, sched *Scheduler)) {
-	t := &task{id: s.r.Int(), step: make(chan struct{}, 1), run: fn}
+	s.mu.Lock()
+	s.lastID++
+	t := &task{id: s.lastID, step: make(chan struct{}, 1), parent: -1}
+
+	var parent int64
+	if s.current != nil {
+		parent = s.current.id
+		t.parent = len(s.steps) - 1
+	}
+
+	s.runnable = append(s.runnable, t)
+	s.tasks = append(s.tasks, t)
+	s.mu.Unlock()
+
+	if s.det != nil {
+		s.det.Fork(parent, t.id)
+	}
+
 	s.wg.Add(1)
 
 	go func() {
 		defer s.wg.Done()
 
-		ctx, cancel := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(s.ctx)
 		defer cancel()
 		// first admission requires step token.
-		s.ready <- t
 		<-t.step
 		fn(ctx, s)
+		s.park(nil)
 	}()
 }
 
-// Run executes until all tasks finish or the context cancels.
+// ErrScheduleDiverged is returned by Run when a replayed trace names a
+// task that cannot run, which means the test is not deterministic.
+var ErrScheduleDiverged = errors.New("schedule diverged from the replayed trace")
+
+// Run executes until all tasks finish or the context cancels. When a
+// detector is attached, the caller of Run joins every task at the end.
+//
+// If the context cancels or a replayed trace diverges, the contexts passed
+// to the tasks are canceled and the remaining tasks are run one at a time,
+// in the order they became runnable, without being recorded in the trace.
+// Run returns the error once every task has finished.
 func (s *Scheduler) Run(ctx context.Context) error {
 	if ctx == nil {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithCancel(context.Background())
-		defer cancel()
+		ctx = context.Background()
 	}
-	// notify when all tasks complete.
-	go func() { s.wg.Wait(); close(s.doneCh) }()
-	// scheduler loop.
-	yieldCount := 0
-	idleStreak := 0
+
+	var err error
 
 	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-s.doneCh:
-			// Drain any residual ready steps for fairness; then exit when queue is quiet.
-			if drained(s.ready) {
-				return nil
-			}
-		case t := <-s.ready:
-			// allow task to make progress, then decide next step.
-			s.allow(t)
+		s.mu.Lock()
+		if len(s.runnable) == 0 {
+			s.mu.Unlock()
+
+			break
+		}
 
-			yieldCount++
-			if yieldCount%s.quantum == 0 {
-				runtime.Gosched()
+		i := 0
+
+		if err == nil {
+			enabled := make([]int64, len(s.runnable))
+			for j, t := range s.runnable {
+				enabled[j] = t.id
 			}
-		case <-time.After(1 * time.Millisecond):
-			// back off to avoid spinning.
-			idleStreak++
-			if idleStreak > 5 {
-				// if done and repeatedly idle, exit.
-				select {
-				case <-s.doneCh:
-					return nil
-				default:
-				}
 
-				idleStreak = 0
+			if i = s.strategy.pick(s, enabled); i < 0 {
+				err = fmt.Errorf("step %d: %w", len(s.steps), ErrScheduleDiverged)
+				i = 0
+
+				s.cancel()
+			} else {
+				s.steps = append(s.steps, step{enabled: enabled, task: s.runnable[i].id})
 			}
 		}
+
+		t := s.runnable[i]
+		s.runnable = append(s.runnable[:i], s.runnable[i+1:]...)
+		s.current = t
+		s.last = t.id
+		s.mu.Unlock()
+
+		t.step <- struct{}{}
+
+		if err != nil {
+			<-s.parked
+
+			continue
+		}
+
+		select {
+		case <-s.parked:
+		case <-ctx.Done():
+			err = ctx.Err()
+			s.cancel()
+
+			// the running task still hands control back.
+			<-s.parked
+		}
+	}
+
+	s.wg.Wait()
+
+	if err != nil {
+		return err
+	}
+
+	if s.det != nil {
+		s.mu.Lock()
+		tasks := append([]*task(nil), s.tasks...)
+		s.mu.Unlock()
+
+		for _, t := range tasks {
+			s.det.Join(0, t.id)
+		}
 	}
+
+	return nil
 }
 
-// allow grants a step token to the chosen task.
-func (s *Scheduler) allow(t *task) {
-	select {
-	case t.step <- struct{}{}:
-	default:
+// park hands control back to the scheduler. A nil t means the running
+// task has finished.
+func (s *Scheduler) park(t *task) {
+	if t != nil && s.stopped.Load() == 1 {
+		return
+	}
+
+	s.mu.Lock()
+	s.current = nil
+
+	if t != nil {
+		s.runnable = append(s.runnable, t)
+	}
+	s.mu.Unlock()
+
+	s.parked <- struct{}{}
+
+	if t != nil {
+		<-t.step
 	}
 }
 
 // Yield should be called by tasks to hand control back to the scheduler.
+// Every Quantum-th call lets the scheduler pick another task.
 func (s *Scheduler) Yield() {
 	if s.stopped.Load() == 1 {
 		return
 	}
-	// requeue current goroutine as a task by recovering its parked token.
-	// The technique: block until scheduler grants next step token.
-	// Callers should ensure cooperative yield points.
-	current := &task{step: make(chan struct{}, 1)}
-	s.ready <- current
-	<-current.step
+
+	s.mu.Lock()
+	t := s.current
+	s.mu.Unlock()
+
+	if t == nil {
+		return
+	}
+
+	t.yields++
+	if t.yields%s.quantum != 0 {
+		return
+	}
+
+	s.park(t)
 }
 
 // Park blocks the current task for a duration to model external waiting.
 func (s *Scheduler) Park(d time.Duration) { time.Sleep(d) }
 
-// Stop requests the scheduler to cease scheduling new steps.
+// Stop requests the scheduler to cease scheduling new steps; Yield then
+// returns immediately and tasks run to completion.
 func (s *Scheduler) Stop() { s.stopped.Store(1) }
 
 // Wait waits for all tasks to finish.
 func (s *Scheduler) Wait() { s.wg.Wait() }
 
-// anyRunning checks if worker goroutines are still active.
-func drained[T any](ch <-chan T) bool {
-	select {
-	case <-ch:
-		return false
-	default:
-		return true
-	}
+// SeedError records the seed of a failed exploration trial so it can be replayed.
+type SeedError struct {
+	Err  error
+	Seed int64
 }
 
+func (e *SeedError) Error() string { return fmt.Sprintf("seed %d: %v", e.Seed, e.Err) }
+
+func (e *SeedError) Unwrap() error { return e.Err }
+
 // Explore runs the provided factory multiple times with different seeds.
 // The factory should register tasks and return a function to wait for completion.
+// Failed trials are reported as *SeedError.
 func Explore(trials int, factory func(seed int64) (wait func() error)) []error {
 	if trials <= 0 {
 		trials = runtime.GOMAXPROCS(0)
@@ -183,7 +376,9 @@ func Explore(trials int, factory func(seed int64) (wait func() error)) []error {
 
 			wait := factory(seed)
 			if wait != nil {
-				errs[i] = wait()
+				if err := wait(); err != nil {
+					errs[i] = &SeedError{Seed: seed, Err: err}
+				}
 			}
 		}(i)
 	}
@@ -192,3 +387,24 @@ func Explore(trials int, factory func(seed int64) (wait func() error)) []error {
 
 	return errs
 }
+
+// ExploreSeeds runs trial for the consecutive seeds first..first+trials-1,
+// sequentially and in order, so a search is itself reproducible. It is meant
+// for deterministic trials such as runtime.RunSimulation; a failing seed can be
+// replayed by calling trial with it directly. If stopOnFailure is set the
+// search ends at the first failure.
+func ExploreSeeds(first int64, trials int, stopOnFailure bool, trial func(seed int64) error) []*SeedError {
+	var failures []*SeedError
+
+	for i := 0; i < trials; i++ {
+		seed := first + int64(i)
+		if err := trial(seed); err != nil {
+			failures = append(failures, &SeedError{Seed: seed, Err: err})
+			if stopOnFailure {
+				break
+			}
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,233 @@
+package coverage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      float64            `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+}
+
+type coberturaClass struct {
+	Name       string            `xml:"name,attr"`
+	Filename   string            `xml:"filename,attr"`
+	Methods    []coberturaMethod `xml:"methods>method"`
+	Lines      []coberturaLine   `xml:"lines>line"`
+	LineRate   float64           `xml:"line-rate,attr"`
+	BranchRate float64           `xml:"branch-rate,attr"`
+	Complexity float64           `xml:"complexity,attr"`
+}
+
+type coberturaMethod struct {
+	Name       string          `xml:"name,attr"`
+	Signature  string          `xml:"signature,attr"`
+	Lines      []coberturaLine `xml:"lines>line"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+}
+
+type coberturaLine struct {
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+	Number            int    `xml:"number,attr"`
+	Hits              uint64 `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+}
+
+// WriteCobertura writes the report as Cobertura XML.
+func (r *Report) WriteCobertura(path string) error {
+	f, err := create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.EncodeCobertura(f); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// EncodeCobertura writes the report as Cobertura XML, with a package per
+// directory and a class per file.
+func (r *Report) EncodeCobertura(w io.Writer) error {
+	t := r.Totals()
+	doc := coberturaCoverage{
+		Sources:         []string{"."},
+		LineRate:        t.LineRate(),
+		BranchRate:      t.BranchRate(),
+		LinesCovered:    t.LinesHit,
+		LinesValid:      t.Lines,
+		BranchesCovered: t.BranchesHit,
+		BranchesValid:   t.Branches,
+		Version:         "orizon",
+		Timestamp:       time.Now().Unix(),
+	}
+
+	byDir := make(map[string]*Report)
+
+	for _, path := range r.Paths() {
+		dir := filepath.ToSlash(filepath.Dir(path))
+		if byDir[dir] == nil {
+			byDir[dir] = NewReport()
+		}
+
+		byDir[dir].Files[path] = r.Files[path]
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		pr := byDir[dir]
+		pt := pr.Totals()
+		pkg := coberturaPackage{Name: dir, LineRate: pt.LineRate(), BranchRate: pt.BranchRate()}
+
+		for _, path := range pr.Paths() {
+			pkg.Classes = append(pkg.Classes, coberturaClassOf(pr.Files[path]))
+		}
+
+		doc.Packages = append(doc.Packages, pkg)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func coberturaClassOf(f *File) coberturaClass {
+	t := f.Totals()
+	base := filepath.Base(f.Path)
+	class := coberturaClass{
+		Name:       strings.TrimSuffix(base, filepath.Ext(base)),
+		Filename:   filepath.ToSlash(f.Path),
+		LineRate:   t.LineRate(),
+		BranchRate: t.BranchRate(),
+	}
+
+	for _, fn := range f.sortedFunctions() {
+		m := coberturaMethod{Name: fn.Name, BranchRate: 1}
+		if fn.Hits > 0 {
+			m.LineRate = 1
+		}
+
+		m.Lines = []coberturaLine{{Number: fn.Line, Hits: fn.Hits}}
+		class.Methods = append(class.Methods, m)
+	}
+
+	arms := make(map[int][2]int)
+	for br, n := range f.Branches {
+		a := arms[br.Line]
+		a[1]++
+
+		if n > 0 {
+			a[0]++
+		}
+
+		arms[br.Line] = a
+	}
+
+	for _, line := range f.sortedLines() {
+		l := coberturaLine{Number: line, Hits: f.Lines[line]}
+		if a, ok := arms[line]; ok {
+			l.Branch = true
+			l.ConditionCoverage = fmt.Sprintf("%d%% (%d/%d)", 100*a[0]/a[1], a[0], a[1])
+		}
+
+		class.Lines = append(class.Lines, l)
+	}
+
+	return class
+}
+
+// ReadCobertura parses a Cobertura XML report. Cobertura only keeps how
+// many of the branch arms on a line were taken, so each line gets that many
+// arms counted once, in block -1.
+func ReadCobertura(r io.Reader) (*Report, error) {
+	var doc coberturaCoverage
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("cobertura: %w", err)
+	}
+
+	rep := NewReport()
+
+	for _, pkg := range doc.Packages {
+		for _, class := range pkg.Classes {
+			f := rep.File(filepath.FromSlash(class.Filename))
+
+			for _, m := range class.Methods {
+				fn := f.function(m.Name)
+				if len(m.Lines) > 0 {
+					fn.Line = m.Lines[0].Number
+					fn.Hits += m.Lines[0].Hits
+				}
+			}
+
+			for _, l := range class.Lines {
+				f.Lines[l.Number] += l.Hits
+
+				if !l.Branch {
+					continue
+				}
+
+				var taken, total int
+				if i := strings.IndexByte(l.ConditionCoverage, '('); i >= 0 {
+					fmt.Sscanf(l.ConditionCoverage[i:], "(%d/%d)", &taken, &total)
+				}
+
+				for arm := 0; arm < total; arm++ {
+					var n uint64
+					if arm < taken {
+						n = 1
+					}
+
+					f.Branches[Branch{Line: l.Number, Block: -1, Arm: arm}] += n
+				}
+			}
+		}
+	}
+
+	return rep, nil
+}
@@ -0,0 +1,809 @@
+// Package coverage measures line, branch and function coverage of Orizon
+// code run by the native test runner. The functions under test are lowered
+// to MIR with a counter per basic block (codegen.LowerToMIRWithCoverage);
+// while the tests run, a tracer follows the interpreter's control flow
+// through those blocks and counts them in runtime.CoverageCounters. The
+// counters map back to source lines through the debug.SourceMap the
+// lowering fills in, and are reported as LCOV, Cobertura XML and HTML.
+//
+// Programs built with orizon-compiler --cover are lowered by Lower instead;
+// they count the blocks themselves and write a profile at exit, which
+// ReadReport maps back through the source map written next to it.
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/orizon-lang/orizon/internal/ast"
+	"github.com/orizon-lang/orizon/internal/astbridge"
+	"github.com/orizon-lang/orizon/internal/codegen"
+	"github.com/orizon-lang/orizon/internal/debug"
+	"github.com/orizon-lang/orizon/internal/hir"
+	"github.com/orizon-lang/orizon/internal/mir"
+	"github.com/orizon-lang/orizon/internal/parser"
+	"github.com/orizon-lang/orizon/internal/runtime"
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
+)
+
+// Report file names written by Collector.WriteReports.
+const (
+	ProfileFile   = "orizon.cover"
+	SourceMapFile = "sourcemap.json"
+	LCOVFile      = "lcov.info"
+	CoberturaFile = "cobertura.xml"
+	HTMLFile      = "index.html"
+)
+
+// Collector instruments the packages of a test run and gathers their
+// counters. Counters are numbered across packages, so one source map and
+// one profile describe the whole run. It is safe for concurrent use.
+type Collector struct {
+	tracers map[string]*tracer
+	skipped []Skipped
+	sm      debug.SourceMap
+	mu      sync.Mutex
+}
+
+// NewCollector creates an empty collector.
+func NewCollector() *Collector {
+	return &Collector{tracers: make(map[string]*tracer), sm: debug.SourceMap{Version: 1}}
+}
+
+// Instrument lowers the functions of pkg other than its tests and
+// benchmarks with coverage counters and sets pkg.Tracer to count the
+// blocks the tests run through. Instrumenting a directory again, as
+// retries do, reuses its counters.
+func (c *Collector) Instrument(pkg *native.Package) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.tracers[pkg.Dir]; ok {
+		pkg.Tracer = t
+		return
+	}
+
+	cases := make(map[string]bool, len(pkg.Cases))
+	for _, tc := range pkg.Cases {
+		cases[tc.Name] = true
+	}
+
+	base := len(c.sm.Blocks)
+
+	for _, file := range pkg.Files {
+		_, skipped := lowerFile(&c.sm, file, pkg.Program(file), cases)
+		c.skipped = append(c.skipped, skipped...)
+	}
+
+	t := &tracer{
+		blocks:   append([]debug.BlockRange(nil), c.sm.Blocks[base:]...),
+		base:     base,
+		entries:  make(map[string]int),
+		conds:    make(map[condKey]int),
+		counters: runtime.NewCoverageCounters(len(c.sm.Blocks) - base),
+	}
+
+	for _, b := range t.blocks {
+		if _, ok := t.entries[b.Function]; !ok {
+			t.entries[b.Function] = b.Counter
+		}
+
+		if b.Cond != nil && len(b.Succs) == 2 {
+			t.conds[condKey{b.Function, b.Cond.Line, b.Cond.Column}] = b.Counter
+		}
+	}
+
+	c.tracers[pkg.Dir] = t
+	pkg.Tracer = t
+}
+
+// Skipped is a function coverage could not instrument. It is reported as
+// never called, with its statement lines not covered, so that it still
+// counts in the totals.
+type Skipped struct {
+	File     string
+	Function string
+	Reason   string
+	Lines    []int
+	Line     int
+}
+
+// Lower lowers the functions of prog, the program of file compiled on its
+// own, with coverage counters numbered from zero. It returns the module,
+// the source map of its counters and the functions left out of both.
+func Lower(file string, prog *parser.Program) (*mir.Module, debug.SourceMap, []Skipped) {
+	sm := debug.SourceMap{Version: 1}
+	m, skipped := lowerFile(&sm, file, prog, nil)
+
+	return m, sm, skipped
+}
+
+// lowerFile lowers the functions of one file other than those in exclude
+// into sm. The bridge leaves function bodies out for the compiler, so they
+// are converted here. A function the bridge, the HIR converter or the
+// lowering cannot handle yet is skipped on its own; the others are lowered
+// together so that calls between them resolve.
+func lowerFile(sm *debug.SourceMap, file string, prog *parser.Program, exclude map[string]bool) (*mir.Module, []Skipped) {
+	if prog == nil {
+		return nil, nil
+	}
+
+	var (
+		fns     []*parser.FunctionDeclaration
+		skipped []Skipped
+		merged  = &ast.Program{}
+	)
+
+	for _, decl := range prog.Declarations {
+		fn, ok := decl.(*parser.FunctionDeclaration)
+		if !ok || exclude[fn.Name.Value] {
+			continue
+		}
+
+		one, err := bridgeFunction(prog, fn)
+		if err == nil {
+			err = checkFunction(one)
+		}
+
+		if err != nil {
+			skipped = append(skipped, skip(file, fn, err))
+			continue
+		}
+
+		fns = append(fns, fn)
+		merged.Declarations = append(merged.Declarations, one.Declarations...)
+	}
+
+	if len(merged.Declarations) == 0 {
+		return nil, skipped
+	}
+
+	var (
+		hp *hir.HIRProgram
+		m  *mir.Module
+	)
+
+	first := len(sm.Blocks)
+
+	err := safely(func() {
+		hp, _ = hir.NewASTToHIRConverter().ConvertProgram(merged)
+		m = codegen.LowerToMIRWithCoverage(hp, sm)
+	})
+	if err != nil {
+		sm.Blocks = sm.Blocks[:first]
+
+		for _, fn := range fns {
+			skipped = append(skipped, skip(file, fn, err))
+		}
+
+		return nil, skipped
+	}
+
+	lowered := make(map[string]bool, len(fns))
+
+	// The parser leaves file names out of positions.
+	for i := first; i < len(sm.Blocks); i++ {
+		b := &sm.Blocks[i]
+		b.File = file
+		lowered[b.Function] = true
+
+		if b.Cond != nil {
+			b.Cond.File = file
+		}
+	}
+
+	for _, fn := range fns {
+		if !lowered[fn.Name.Value] {
+			skipped = append(skipped, skip(file, fn, errors.New("not lowered to MIR")))
+		}
+	}
+
+	var fsm debug.SourceMap
+
+	if safely(func() { fsm, err = debug.GenerateSourceMap(hp) }) == nil && err == nil {
+		for _, fr := range fsm.Functions {
+			for i := range fr.Mappings {
+				fr.Mappings[i].File = file
+			}
+
+			sm.Functions = append(sm.Functions, fr)
+		}
+	}
+
+	sm.Files = append(sm.Files, file)
+
+	return m, skipped
+}
+
+// bridgeFunction converts fn, body included, to a program of its own.
+func bridgeFunction(prog *parser.Program, fn *parser.FunctionDeclaration) (*ast.Program, error) {
+	one, err := astbridge.FromParserProgram(&parser.Program{Span: prog.Span, Declarations: []parser.Declaration{fn}})
+	if err != nil {
+		return nil, err
+	}
+
+	if fn.Body != nil {
+		body, err := astbridge.FromParserBlock(fn.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		one.Declarations[0].(*ast.FunctionDeclaration).Body = body
+	}
+
+	return one, nil
+}
+
+// checkFunction converts and lowers the program of a single function and
+// reports the first type error or crash. Names it cannot resolve are left
+// to the lowering of the whole file, where the other functions are known.
+func checkFunction(one *ast.Program) error {
+	var errs []hir.ConversionError
+
+	err := safely(func() {
+		var hp *hir.HIRProgram
+
+		hp, errs = hir.NewASTToHIRConverter().ConvertProgram(one)
+		codegen.LowerToMIRWithCoverage(hp, &debug.SourceMap{})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range errs {
+		// A call of an unresolved name is reported twice.
+		if e.Kind != hir.ErrorKindNameResolution && e.Message != "attempt to call non-function" {
+			return errors.New(e.Message)
+		}
+	}
+
+	return nil
+}
+
+// safely runs f and turns a panic into an error.
+func safely(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal compiler error: %v", r)
+		}
+	}()
+
+	f()
+
+	return nil
+}
+
+// skip describes fn, left out of the coverage of file for err.
+func skip(file string, fn *parser.FunctionDeclaration, err error) Skipped {
+	s := Skipped{File: file, Function: fn.Name.Value, Reason: err.Error(), Line: fn.Span.Start.Line}
+	if fn.Body != nil {
+		s.Lines = statementLines(fn.Body, nil)
+	}
+
+	return s
+}
+
+// statementLines appends the lines of the statements in stmt.
+func statementLines(stmt parser.Statement, lines []int) []int {
+	switch s := stmt.(type) {
+	case nil:
+	case *parser.BlockStatement:
+		if s == nil {
+			break
+		}
+
+		for _, st := range s.Statements {
+			lines = statementLines(st, lines)
+		}
+	case *parser.IfStatement:
+		lines = append(lines, s.Span.Start.Line)
+		lines = statementLines(s.ThenStmt, lines)
+		lines = statementLines(s.ElseStmt, lines)
+	case *parser.WhileStatement:
+		lines = append(lines, s.Span.Start.Line)
+		lines = statementLines(s.Body, lines)
+	case *parser.ForStatement:
+		lines = append(lines, s.Span.Start.Line)
+		lines = statementLines(s.Body, lines)
+	case *parser.ForInStatement:
+		lines = append(lines, s.Span.Start.Line)
+		lines = statementLines(s.Body, lines)
+	default:
+		lines = append(lines, stmt.GetSpan().Start.Line)
+	}
+
+	return lines
+}
+
+// Skipped returns the functions that could not be instrumented, in the
+// order they were found.
+func (c *Collector) Skipped() []Skipped {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Skipped(nil), c.skipped...)
+}
+
+// addSkipped reports the skipped functions as never called.
+func (c *Collector) addSkipped(rep *Report) {
+	for _, s := range c.Skipped() {
+		f := rep.File(s.File)
+
+		fn := f.function(s.Function)
+		if fn.Line == 0 {
+			fn.Line = s.Line
+		}
+
+		for _, line := range s.Lines {
+			if _, ok := f.Lines[line]; !ok {
+				f.Lines[line] = 0
+			}
+		}
+	}
+}
+
+// SourceMap returns the source map of the instrumented code.
+func (c *Collector) SourceMap() debug.SourceMap {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sm := c.sm
+	sm.Blocks = append([]debug.BlockRange(nil), c.sm.Blocks...)
+
+	return sm
+}
+
+// Counts returns the counters of all packages, indexed by counter.
+func (c *Collector) Counts() []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make([]uint64, len(c.sm.Blocks))
+	for _, t := range c.tracers {
+		copy(counts[t.base:], t.counters.Counts())
+	}
+
+	return counts
+}
+
+// Report builds the coverage report of what ran so far.
+func (c *Collector) Report() *Report {
+	rep := FromSourceMap(c.SourceMap(), c.Counts())
+	c.addSkipped(rep)
+
+	return rep
+}
+
+// WriteReports writes the profile, source map, LCOV, Cobertura and HTML
+// reports to dir and returns the report.
+func (c *Collector) WriteReports(dir string) (*Report, error) {
+	sm, counts := c.SourceMap(), c.Counts()
+	rep := FromSourceMap(sm, counts)
+	c.addSkipped(rep)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(filepath.Join(dir, ProfileFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := runtime.WriteCoverageProfile(f, counts); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	js, err := debug.SerializeSourceMap(sm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SourceMapFile), append(js, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+
+	if err := rep.WriteLCOV(filepath.Join(dir, LCOVFile)); err != nil {
+		return nil, err
+	}
+
+	if err := rep.WriteCobertura(filepath.Join(dir, CoberturaFile)); err != nil {
+		return nil, err
+	}
+
+	if err := rep.WriteHTML(filepath.Join(dir, HTMLFile)); err != nil {
+		return nil, err
+	}
+
+	return rep, nil
+}
+
+type condKey struct {
+	fn        string
+	line, col int
+}
+
+// tracer counts the blocks of one package. The interpreter reports
+// function entries and branch outcomes; the blocks reached from there by
+// unconditional jumps are counted along, up to the next conditional branch.
+type tracer struct {
+	counters *runtime.CoverageCounters
+	entries  map[string]int
+	conds    map[condKey]int
+	blocks   []debug.BlockRange
+	base     int
+}
+
+func (t *tracer) Enter(fn string) {
+	if id, ok := t.entries[fn]; ok {
+		t.enter(id)
+	}
+}
+
+func (t *tracer) Branch(fn string, at parser.Position, value bool) {
+	id, ok := t.conds[condKey{fn, at.Line, at.Column}]
+	if !ok {
+		return
+	}
+
+	succs := t.blocks[id-t.base].Succs
+	if value {
+		t.enter(succs[0])
+	} else {
+		t.enter(succs[1])
+	}
+}
+
+func (t *tracer) enter(id int) {
+	// A loop without a condition jumps back forever; count it once.
+	for range t.blocks {
+		t.counters.Hit(id - t.base)
+
+		b := t.blocks[id-t.base]
+		if len(b.Succs) != 1 {
+			return
+		}
+
+		id = b.Succs[0]
+	}
+}
+
+// Report is line, branch and function coverage per source file.
+type Report struct {
+	Files map[string]*File
+}
+
+// File is the coverage of one source file.
+type File struct {
+	Lines     map[int]uint64
+	Branches  map[Branch]uint64
+	Functions map[string]*Function
+	Path      string
+}
+
+// Branch identifies one outcome of a conditional branch on Line. Block
+// tells branches on one line apart; Arm 0 is taken when the condition
+// holds and Arm 1 when it does not.
+type Branch struct {
+	Line, Block, Arm int
+}
+
+// Function is the number of calls of a function starting on Line.
+type Function struct {
+	Name string
+	Line int
+	Hits uint64
+}
+
+// NewReport creates an empty report.
+func NewReport() *Report {
+	return &Report{Files: make(map[string]*File)}
+}
+
+// File returns the coverage of path, creating it when missing.
+func (r *Report) File(path string) *File {
+	f, ok := r.Files[path]
+	if !ok {
+		f = &File{Path: path, Lines: make(map[int]uint64), Branches: make(map[Branch]uint64), Functions: make(map[string]*Function)}
+		r.Files[path] = f
+	}
+
+	return f
+}
+
+// Paths returns the paths of the files in the report, sorted.
+func (r *Report) Paths() []string {
+	paths := make([]string, 0, len(r.Files))
+	for p := range r.Files {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// Merge adds the counts of o to r. Branches read from Cobertura only say
+// how many arms of a line were taken, not which; they are added to the arms
+// of the other report that were taken most.
+func (r *Report) Merge(o *Report) {
+	for _, of := range o.Files {
+		f := r.File(of.Path)
+
+		for line, n := range of.Lines {
+			f.Lines[line] += n
+		}
+
+		for line, obrs := range of.branchesByLine() {
+			brs := f.branchesByLine()[line]
+			if len(brs) != len(obrs) || (brs[0].Block == -1) == (obrs[0].Block == -1) {
+				for _, br := range obrs {
+					f.Branches[br] += of.Branches[br]
+				}
+
+				continue
+			}
+
+			byHits(brs, f.Branches)
+			byHits(obrs, of.Branches)
+
+			for i, br := range brs {
+				n := f.Branches[br] + of.Branches[obrs[i]]
+				if br.Block == -1 {
+					delete(f.Branches, br)
+					br = obrs[i]
+				}
+
+				f.Branches[br] = n
+			}
+		}
+
+		for name, ofn := range of.Functions {
+			fn := f.function(name)
+			if fn.Line == 0 {
+				fn.Line = ofn.Line
+			}
+
+			fn.Hits += ofn.Hits
+		}
+	}
+}
+
+// byHits orders brs by decreasing count.
+func byHits(brs []Branch, counts map[Branch]uint64) {
+	sort.SliceStable(brs, func(i, j int) bool { return counts[brs[i]] > counts[brs[j]] })
+}
+
+// FromSourceMap builds the report of counts, indexed by counter, for the
+// blocks of sm. A line counts as often as the most executed block holding
+// one of its statements. Branch arms count the block they lead to; an arm
+// into a block other edges lead to as well counts the branching block minus
+// the other arm.
+func FromSourceMap(sm debug.SourceMap, counts []uint64) *Report {
+	count := func(id int) uint64 {
+		if id >= 0 && id < len(counts) {
+			return counts[id]
+		}
+
+		return 0
+	}
+
+	preds := make(map[int]int)
+	for _, b := range sm.Blocks {
+		for _, s := range b.Succs {
+			preds[s]++
+		}
+	}
+
+	funcLines := make(map[string]int)
+	for _, fr := range sm.Functions {
+		for _, m := range fr.Mappings {
+			funcLines[m.File+"\x00"+fr.Name] = m.StartLine
+		}
+	}
+
+	rep := NewReport()
+
+	for _, b := range sm.Blocks {
+		if b.File == "" {
+			continue
+		}
+
+		f := rep.File(b.File)
+		n := count(b.Counter)
+
+		for _, line := range b.Lines {
+			f.Lines[line] = max(f.Lines[line], n)
+		}
+
+		if _, ok := f.Functions[b.Function]; !ok {
+			line := funcLines[b.File+"\x00"+b.Function]
+			if line == 0 && len(b.Lines) > 0 {
+				line = b.Lines[0]
+			}
+
+			// The first block of a function is its entry.
+			f.Functions[b.Function] = &Function{Name: b.Function, Line: line, Hits: n}
+		}
+
+		if b.Cond == nil || len(b.Succs) != 2 {
+			continue
+		}
+
+		var (
+			taken [2]uint64
+			exact [2]bool
+		)
+
+		for i, s := range b.Succs {
+			taken[i], exact[i] = count(s), preds[s] == 1
+		}
+
+		for i := range taken {
+			switch {
+			case exact[i]:
+			case exact[1-i]:
+				taken[i] = n - min(n, taken[1-i])
+			default:
+				taken[i] = min(n, taken[i])
+			}
+		}
+
+		for i := range taken {
+			f.Branches[Branch{Line: b.Cond.Line, Block: b.Counter, Arm: i}] = taken[i]
+		}
+	}
+
+	return rep
+}
+
+// Totals counts the instrumented and the executed lines, branch arms and
+// functions.
+type Totals struct {
+	Lines, LinesHit         int
+	Branches, BranchesHit   int
+	Functions, FunctionsHit int
+}
+
+// Totals sums the coverage of the file.
+func (f *File) Totals() Totals {
+	var t Totals
+
+	for _, n := range f.Lines {
+		t.Lines++
+
+		if n > 0 {
+			t.LinesHit++
+		}
+	}
+
+	for _, n := range f.Branches {
+		t.Branches++
+
+		if n > 0 {
+			t.BranchesHit++
+		}
+	}
+
+	for _, fn := range f.Functions {
+		t.Functions++
+
+		if fn.Hits > 0 {
+			t.FunctionsHit++
+		}
+	}
+
+	return t
+}
+
+// Totals sums the coverage of every file.
+func (r *Report) Totals() Totals {
+	var t Totals
+
+	for _, f := range r.Files {
+		ft := f.Totals()
+		t.Lines += ft.Lines
+		t.LinesHit += ft.LinesHit
+		t.Branches += ft.Branches
+		t.BranchesHit += ft.BranchesHit
+		t.Functions += ft.Functions
+		t.FunctionsHit += ft.FunctionsHit
+	}
+
+	return t
+}
+
+// LineRate, BranchRate and FunctionRate are fractions in [0, 1]; 1 when
+// there is nothing to cover.
+func (t Totals) LineRate() float64     { return rate(t.LinesHit, t.Lines) }
+func (t Totals) BranchRate() float64   { return rate(t.BranchesHit, t.Branches) }
+func (t Totals) FunctionRate() float64 { return rate(t.FunctionsHit, t.Functions) }
+
+func (t Totals) String() string {
+	return fmt.Sprintf("lines %.1f%% (%d/%d), branches %.1f%% (%d/%d), functions %.1f%% (%d/%d)",
+		100*t.LineRate(), t.LinesHit, t.Lines,
+		100*t.BranchRate(), t.BranchesHit, t.Branches,
+		100*t.FunctionRate(), t.FunctionsHit, t.Functions)
+}
+
+func rate(hit, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+
+	return float64(hit) / float64(total)
+}
+
+// sortedLines returns the line numbers of f in order.
+func (f *File) sortedLines() []int {
+	lines := make([]int, 0, len(f.Lines))
+	for l := range f.Lines {
+		lines = append(lines, l)
+	}
+
+	sort.Ints(lines)
+
+	return lines
+}
+
+// sortedBranches returns the branches of f by line, block and arm.
+func (f *File) sortedBranches() []Branch {
+	brs := make([]Branch, 0, len(f.Branches))
+	for b := range f.Branches {
+		brs = append(brs, b)
+	}
+
+	sort.Slice(brs, func(i, j int) bool {
+		a, b := brs[i], brs[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		if a.Block != b.Block {
+			return a.Block < b.Block
+		}
+
+		return a.Arm < b.Arm
+	})
+
+	return brs
+}
+
+// branchesByLine groups the branches of f by line, each in block and arm
+// order.
+func (f *File) branchesByLine() map[int][]Branch {
+	byLine := make(map[int][]Branch)
+	for _, br := range f.sortedBranches() {
+		byLine[br.Line] = append(byLine[br.Line], br)
+	}
+
+	return byLine
+}
+
+// sortedFunctions returns the functions of f by line and name.
+func (f *File) sortedFunctions() []*Function {
+	fns := make([]*Function, 0, len(f.Functions))
+	for _, fn := range f.Functions {
+		fns = append(fns, fn)
+	}
+
+	sort.Slice(fns, func(i, j int) bool {
+		if fns[i].Line != fns[j].Line {
+			return fns[i].Line < fns[j].Line
+		}
+
+		return fns[i].Name < fns[j].Name
+	})
+
+	return fns
+}
@@ -0,0 +1,277 @@
+package coverage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/orizon-lang/orizon/internal/debug"
+	"github.com/orizon-lang/orizon/internal/runtime"
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
+)
+
+const libSource = `func clamp(x: int, lo: int, hi: int) -> int {
+    if x < lo {
+        return lo;
+    }
+    if x > hi {
+        return hi;
+    }
+    return x;
+}
+
+func unused() -> int {
+    return 1;
+}
+`
+
+const testSource = `#[test]
+func test_clamp() {
+    assert_eq(clamp(5, 0, 10), 5);
+    assert_eq(clamp(-1, 0, 10), 0);
+}
+`
+
+// run instruments a package made of the lib and test sources, runs its
+// tests and returns the collector and the lib path.
+func run(t *testing.T) (*Collector, string) {
+	t.Helper()
+
+	return runSources(t, libSource, testSource)
+}
+
+func runSources(t *testing.T, libSource, testSource string) (*Collector, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.oriz")
+
+	for name, src := range map[string]string{lib: libSource, filepath.Join(dir, "lib_test.oriz"): testSource} {
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkg, err := native.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCollector()
+	c.Instrument(pkg)
+
+	for _, tc := range pkg.Cases {
+		if err := pkg.Run(context.Background(), tc, io.Discard); err != nil {
+			t.Fatalf("%s: %v", tc.Name, err)
+		}
+	}
+
+	return c, lib
+}
+
+func TestCollectorCountsLinesBranchesAndFunctions(t *testing.T) {
+	c, lib := run(t)
+	rep := c.Report()
+
+	f := rep.Files[lib]
+	if f == nil {
+		t.Fatalf("no coverage for %s in %v", lib, rep.Paths())
+	}
+
+	for line, want := range map[int]uint64{2: 2, 3: 1, 5: 1, 6: 0, 8: 1, 12: 0} {
+		if got, ok := f.Lines[line]; !ok || got != want {
+			t.Errorf("line %d: got %d (instrumented %v), want %d", line, got, ok, want)
+		}
+	}
+
+	if fn := f.Functions["clamp"]; fn == nil || fn.Hits != 2 || fn.Line != 1 {
+		t.Errorf("clamp: got %+v, want 2 hits on line 1", fn)
+	}
+
+	if fn := f.Functions["unused"]; fn == nil || fn.Hits != 0 {
+		t.Errorf("unused: got %+v, want 0 hits", fn)
+	}
+
+	if _, ok := f.Functions["test_clamp"]; ok {
+		t.Error("tests should not be instrumented")
+	}
+
+	arms := map[int][2]uint64{}
+	for br, n := range f.Branches {
+		a := arms[br.Line]
+		a[br.Arm] = n
+		arms[br.Line] = a
+	}
+
+	if want := map[int][2]uint64{2: {1, 1}, 5: {0, 1}}; len(arms) != 2 || arms[2] != want[2] || arms[5] != want[5] {
+		t.Errorf("branches: got %v, want %v", arms, want)
+	}
+
+	if got := rep.Totals().String(); got != "lines 66.7% (4/6), branches 75.0% (3/4), functions 50.0% (1/2)" {
+		t.Errorf("totals: %s", got)
+	}
+}
+
+func TestReportsRoundTripAndMerge(t *testing.T) {
+	c, lib := run(t)
+
+	out := t.TempDir()
+
+	rep, err := c.WriteReports(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{ProfileFile, SourceMapFile, LCOVFile, CoberturaFile, HTMLFile} {
+		if _, err := os.Stat(filepath.Join(out, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	lcov, err := ReadReport(filepath.Join(out, LCOVFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := lcov.Totals(), rep.Totals(); got != want {
+		t.Errorf("lcov round trip: got %s, want %s", got, want)
+	}
+
+	cob, err := ReadReport(filepath.Join(out, CoberturaFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cob.Totals(), rep.Totals(); got != want {
+		t.Errorf("cobertura round trip: got %s, want %s", got, want)
+	}
+
+	lcov.Merge(cob)
+
+	if got := lcov.Files[lib].Lines[2]; got != 4 {
+		t.Errorf("merged line 2: got %d, want 4", got)
+	}
+
+	if got, want := lcov.Totals(), rep.Totals(); got != want {
+		t.Errorf("merged totals: got %s, want %s", got, want)
+	}
+
+	html, err := os.ReadFile(filepath.Join(out, HTMLFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(html, []byte(`<tr class="uncovered"><td class="num">6</td>`)) || !strings.Contains(string(html), "return hi;") {
+		t.Errorf("html does not mark line 6 uncovered:\n%s", html)
+	}
+}
+
+func TestCollectorReportsFunctionsItCannotInstrument(t *testing.T) {
+	const lib = `func greet() -> int {
+    let name: String = "x";
+    return 1;
+}
+
+func sum(xs: int) -> int {
+    let mut s: int = 0;
+    for x in xs {
+        s = s + x;
+    }
+    return s;
+}
+
+func one() -> int {
+    return 1;
+}
+`
+
+	c, path := runSources(t, lib, "#[test]\nfunc test_one() {\n    assert_eq(one(), 1);\n}\n")
+
+	var names []string
+	for _, s := range c.Skipped() {
+		names = append(names, s.Function)
+	}
+
+	if got := strings.Join(names, ","); got != "greet,sum" {
+		t.Fatalf("skipped: got %s, want greet,sum", got)
+	}
+
+	var buf bytes.Buffer
+
+	if err := c.Report().EncodeLCOV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"FN:6,sum\n", "FNDA:0,sum\n", "DA:9,0\n", "FN:1,greet\n", "FNDA:1,one\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("lcov has no %q:\n%s", strings.TrimSpace(want), buf.String())
+		}
+	}
+
+	if got := c.Report().Files[path].Totals().String(); got != "lines 14.3% (1/7), branches 100.0% (0/0), functions 33.3% (1/3)" {
+		t.Errorf("totals: %s", got)
+	}
+}
+
+func TestLowerAndReadProfileOfCompiledProgram(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.oriz")
+
+	if err := os.WriteFile(src, []byte(libSource+"\nfunc main() -> int {\n    return clamp(5, 0, 10);\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := native.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, sm, skipped := Lower(src, pkg.Program(src))
+	if m == nil || len(skipped) != 0 {
+		t.Fatalf("Lower: module %v, skipped %v", m, skipped)
+	}
+
+	js, err := debug.SerializeSourceMap(sm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, SourceMapFile), js, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Run main by hand: its entry block, then clamp's blocks for x = 5.
+	profile := filepath.Join(dir, ProfileFile)
+	runtime.EnableCoverage(len(sm.Blocks), profile)
+
+	for _, b := range sm.Blocks {
+		if b.Function == "main" || (b.Function == "clamp" && !slices.Contains(b.Lines, 3) && !slices.Contains(b.Lines, 6)) {
+			runtime.OrizonCovHit(b.Counter)
+		}
+	}
+
+	if err := runtime.DumpCoverage(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := ReadReport(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := rep.Files[src]
+	if f == nil {
+		t.Fatalf("no coverage for %s in %v", src, rep.Paths())
+	}
+
+	for line, want := range map[int]uint64{2: 1, 3: 0, 5: 1, 6: 0, 8: 1, 12: 0, 16: 1} {
+		if got, ok := f.Lines[line]; !ok || got != want {
+			t.Errorf("line %d: got %d (instrumented %v), want %d", line, got, ok, want)
+		}
+	}
+}
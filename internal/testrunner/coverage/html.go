@@ -0,0 +1,136 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var htmlReport = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+table.source td { border: none; padding: 0 8px; font-family: monospace; white-space: pre; }
+td.num, td.hits { color: #888; text-align: right; }
+.covered { background: #dfd; }
+.uncovered { background: #fdd; }
+.partial { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>Coverage report</h1>
+<p>{{.Totals}}</p>
+<table>
+<tr><th>File</th><th>Lines</th><th>Branches</th><th>Functions</th></tr>
+{{range .Files}}<tr><td><a href="#{{.Anchor}}">{{.Path}}</a></td><td>{{.Totals.LinesHit}}/{{.Totals.Lines}}</td><td>{{.Totals.BranchesHit}}/{{.Totals.Branches}}</td><td>{{.Totals.FunctionsHit}}/{{.Totals.Functions}}</td></tr>
+{{end}}</table>
+{{range .Files}}<h2 id="{{.Anchor}}">{{.Path}}</h2>
+{{if .Source}}<table class="source">
+{{range .Source}}<tr class="{{.Class}}"><td class="num">{{.Number}}</td><td class="hits">{{.Hits}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>{{else}}<p>Source not available.</p>{{end}}
+{{end}}</body>
+</html>
+`))
+
+type htmlFile struct {
+	Path   string
+	Anchor string
+	Source []htmlLine
+	Totals Totals
+}
+
+type htmlLine struct {
+	Text   string
+	Hits   string
+	Class  string
+	Number int
+}
+
+// WriteHTML writes a self-contained HTML page with the totals per file and
+// each file's source annotated with its hit counts. Sources are read from
+// the paths in the report.
+func (r *Report) WriteHTML(path string) error {
+	data := struct {
+		Files  []htmlFile
+		Totals Totals
+	}{Totals: r.Totals()}
+
+	for i, p := range r.Paths() {
+		f := r.Files[p]
+		data.Files = append(data.Files, htmlFile{
+			Path:   p,
+			Anchor: fmt.Sprintf("file%d", i),
+			Source: annotate(f),
+			Totals: f.Totals(),
+		})
+	}
+
+	out, err := create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := htmlReport.Execute(out, data); err != nil {
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+// annotate pairs the source lines of f with their counts. A line with a
+// branch arm never taken is marked partial.
+func annotate(f *File) []htmlLine {
+	src, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil
+	}
+
+	partial := make(map[int]bool)
+
+	for br, n := range f.Branches {
+		if n == 0 {
+			partial[br.Line] = true
+		}
+	}
+
+	text := strings.Split(strings.TrimRight(string(src), "\n"), "\n")
+	lines := make([]htmlLine, len(text))
+
+	for i, t := range text {
+		l := htmlLine{Number: i + 1, Text: strings.TrimRight(t, "\r")}
+
+		if n, ok := f.Lines[l.Number]; ok {
+			l.Hits = fmt.Sprint(n)
+
+			switch {
+			case n == 0:
+				l.Class = "uncovered"
+			case partial[l.Number]:
+				l.Class = "partial"
+			default:
+				l.Class = "covered"
+			}
+		}
+
+		lines[i] = l
+	}
+
+	return lines
+}
+
+func create(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return os.Create(path)
+}
@@ -0,0 +1,242 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/debug"
+	"github.com/orizon-lang/orizon/internal/runtime"
+)
+
+// WriteLCOV writes the report as an LCOV tracefile.
+func (r *Report) WriteLCOV(path string) error {
+	f, err := create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.EncodeLCOV(f); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// EncodeLCOV writes the report in the LCOV tracefile format, one record per
+// file.
+func (r *Report) EncodeLCOV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "TN:")
+
+	for _, path := range r.Paths() {
+		f := r.Files[path]
+		t := f.Totals()
+
+		fmt.Fprintf(bw, "SF:%s\n", path)
+
+		fns := f.sortedFunctions()
+		for _, fn := range fns {
+			fmt.Fprintf(bw, "FN:%d,%s\n", fn.Line, fn.Name)
+		}
+
+		for _, fn := range fns {
+			fmt.Fprintf(bw, "FNDA:%d,%s\n", fn.Hits, fn.Name)
+		}
+
+		fmt.Fprintf(bw, "FNF:%d\nFNH:%d\n", t.Functions, t.FunctionsHit)
+
+		for _, br := range f.sortedBranches() {
+			fmt.Fprintf(bw, "BRDA:%d,%d,%d,%d\n", br.Line, br.Block, br.Arm, f.Branches[br])
+		}
+
+		fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", t.Branches, t.BranchesHit)
+
+		for _, line := range f.sortedLines() {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, f.Lines[line])
+		}
+
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", t.Lines, t.LinesHit)
+	}
+
+	return bw.Flush()
+}
+
+// ReadLCOV parses an LCOV tracefile. Records of the same file add up.
+func ReadLCOV(r io.Reader) (*Report, error) {
+	rep := NewReport()
+
+	var cur *File
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	line := 0
+
+	for sc.Scan() {
+		line++
+
+		text := strings.TrimSpace(sc.Text())
+		if text == "end_of_record" {
+			cur = nil
+
+			continue
+		}
+
+		key, val, ok := strings.Cut(text, ":")
+		if !ok {
+			continue
+		}
+
+		if key == "SF" {
+			cur = rep.File(val)
+
+			continue
+		}
+
+		if cur == nil {
+			continue
+		}
+
+		fields := strings.Split(val, ",")
+
+		var err error
+
+		switch key {
+		case "FN":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("lcov line %d: bad FN record %q", line, text)
+			}
+
+			var n int
+			if n, err = strconv.Atoi(fields[0]); err == nil {
+				cur.function(strings.Join(fields[1:], ",")).Line = n
+			}
+		case "FNDA":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("lcov line %d: bad FNDA record %q", line, text)
+			}
+
+			var n uint64
+			if n, err = strconv.ParseUint(fields[0], 10, 64); err == nil {
+				cur.function(strings.Join(fields[1:], ",")).Hits += n
+			}
+		case "BRDA":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("lcov line %d: bad BRDA record %q", line, text)
+			}
+
+			var br Branch
+
+			var n uint64
+
+			if br.Line, err = strconv.Atoi(fields[0]); err != nil {
+				break
+			}
+
+			if br.Block, err = strconv.Atoi(fields[1]); err != nil {
+				break
+			}
+
+			if br.Arm, err = strconv.Atoi(fields[2]); err != nil {
+				break
+			}
+
+			// "-" marks a branch whose block never ran.
+			if fields[3] != "-" {
+				if n, err = strconv.ParseUint(fields[3], 10, 64); err != nil {
+					break
+				}
+			}
+
+			cur.Branches[br] += n
+		case "DA":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("lcov line %d: bad DA record %q", line, text)
+			}
+
+			var (
+				l int
+				n uint64
+			)
+
+			if l, err = strconv.Atoi(fields[0]); err != nil {
+				break
+			}
+
+			if n, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+				break
+			}
+
+			cur.Lines[l] += n
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("lcov line %d: %w", line, err)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return rep, nil
+}
+
+// function returns the function called name, creating it when missing.
+func (f *File) function(name string) *Function {
+	fn, ok := f.Functions[name]
+	if !ok {
+		fn = &Function{Name: name}
+		f.Functions[name] = fn
+	}
+
+	return fn
+}
+
+// ReadReport reads an LCOV tracefile, a Cobertura XML report or a coverage
+// profile, telling them apart by content. A profile is mapped to source
+// lines through the SourceMapFile in its directory.
+func ReadReport(path string) (*Report, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(string(b), runtime.CoverageProfileHeader) {
+		return readProfile(path, b)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "<") {
+		return ReadCobertura(strings.NewReader(string(b)))
+	}
+
+	return ReadLCOV(strings.NewReader(string(b)))
+}
+
+func readProfile(path string, b []byte) (*Report, error) {
+	counts, err := runtime.ReadCoverageProfile(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := os.ReadFile(filepath.Join(filepath.Dir(path), SourceMapFile))
+	if err != nil {
+		return nil, fmt.Errorf("source map of profile %s: %w", path, err)
+	}
+
+	var sm debug.SourceMap
+	if err := json.Unmarshal(js, &sm); err != nil {
+		return nil, fmt.Errorf("source map of profile %s: %w", path, err)
+	}
+
+	return FromSourceMap(sm, counts), nil
+}
@@ -0,0 +1,438 @@
+package fuzz
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/orizon-lang/orizon/internal/lexer"
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// ASTMutator returns a mutator that edits programs as syntax trees rather
+// than bytes. Each call parses the input and applies one of: swapping two
+// expression or statement subtrees, splicing in a subtree from a corpus
+// program, changing a literal, regrowing an expression from g, or deleting
+// or duplicating a statement. The result is printed with FormatProgram, so
+// it stays syntactically valid. Inputs that do not parse are replaced by a
+// program derived from g, or mutated as bytes when g is nil.
+func ASTMutator(corpus []CorpusEntry, g *Grammar, maxDepth int) Mutator {
+	if maxDepth <= 0 {
+		maxDepth = DefaultGenDepth
+	}
+
+	bytesMut := DefaultMutator()
+
+	return func(r *rand.Rand, in []byte) []byte {
+		fresh := func() []byte {
+			if g == nil {
+				return bytesMut(r, in)
+			}
+
+			return []byte(g.Generate(r, maxDepth, 1<<12))
+		}
+
+		prog, ok := parseProgram(in)
+		if !ok {
+			return fresh()
+		}
+
+		m := &astMutation{r: r, g: g, maxDepth: maxDepth}
+		m.target = collectSlots(prog)
+
+		if len(corpus) > 0 {
+			if donor, ok := parseProgram(corpus[r.Intn(len(corpus))]); ok {
+				m.donor = collectSlots(donor)
+			}
+		}
+
+		// try a few operations in case the chosen one does not apply.
+		changed := false
+		for i := 0; i < 8 && !changed; i++ {
+			changed = m.apply()
+		}
+
+		if !changed {
+			return fresh()
+		}
+
+		out, err := FormatProgram(prog)
+		if err != nil {
+			return fresh()
+		}
+
+		return []byte(out)
+	}
+}
+
+func parseProgram(src []byte) (*parser.Program, bool) {
+	p := parser.NewParser(lexer.New(string(src)), "fuzz.oriz")
+
+	prog, errs := p.Parse()
+	if len(errs) > 0 || prog == nil {
+		return nil, false
+	}
+
+	return prog, true
+}
+
+// exprSlot is a place in the tree that holds an expression. end is the
+// index of the first slot after the ones nested inside this one.
+type exprSlot struct {
+	ref *parser.Expression
+	end int
+}
+
+// stmtSlot is a statement in a block.
+type stmtSlot struct {
+	block *parser.BlockStatement
+	index int
+	end   int
+}
+
+type slots struct {
+	exprs  []exprSlot
+	stmts  []stmtSlot
+	blocks []*parser.BlockStatement
+}
+
+// collectSlots walks prog in pre-order and records every expression and
+// statement that can be replaced without breaking the syntax. Assignment
+// targets, callees and member names are not slots.
+func collectSlots(prog *parser.Program) *slots {
+	s := &slots{}
+
+	for _, decl := range prog.Declarations {
+		switch d := decl.(type) {
+		case *parser.FunctionDeclaration:
+			s.block(d.Body)
+		case *parser.VariableDeclaration:
+			s.exprRef(&d.Initializer)
+		}
+	}
+
+	return s
+}
+
+func (s *slots) block(b *parser.BlockStatement) {
+	if b == nil {
+		return
+	}
+
+	s.blocks = append(s.blocks, b)
+
+	for i := range b.Statements {
+		idx := len(s.stmts)
+		s.stmts = append(s.stmts, stmtSlot{block: b, index: i})
+		s.stmt(b.Statements[i])
+		s.stmts[idx].end = len(s.stmts)
+	}
+}
+
+func (s *slots) stmt(st parser.Statement) {
+	switch st := st.(type) {
+	case *parser.BlockStatement:
+		s.block(st)
+	case *parser.ExpressionStatement:
+		s.exprRef(&st.Expression)
+	case *parser.VariableDeclaration:
+		s.exprRef(&st.Initializer)
+	case *parser.ReturnStatement:
+		s.exprRef(&st.Value)
+	case *parser.IfStatement:
+		s.exprRef(&st.Condition)
+		s.stmt(st.ThenStmt)
+		s.stmt(st.ElseStmt)
+	case *parser.WhileStatement:
+		s.exprRef(&st.Condition)
+		s.stmt(st.Body)
+	case *parser.ForStatement:
+		s.stmt(st.Init)
+		s.exprRef(&st.Condition)
+		s.stmt(st.Update)
+		s.block(st.Body)
+	case *parser.ForInStatement:
+		s.exprRef(&st.Iterable)
+		s.block(st.Body)
+	}
+}
+
+// exprRef records the expression held at ref, unless it is empty or an
+// assignment, which is only valid at statement level.
+func (s *slots) exprRef(ref *parser.Expression) {
+	if *ref == nil {
+		return
+	}
+
+	if a, ok := (*ref).(*parser.AssignmentExpression); ok {
+		s.exprRef(&a.Right)
+
+		return
+	}
+
+	idx := len(s.exprs)
+	s.exprs = append(s.exprs, exprSlot{ref: ref})
+	s.children(*ref)
+	s.exprs[idx].end = len(s.exprs)
+}
+
+func (s *slots) children(e parser.Expression) {
+	switch e := e.(type) {
+	case *parser.UnaryExpression:
+		s.exprRef(&e.Operand)
+	case *parser.BinaryExpression:
+		if e.Operator.Value == "." {
+			s.exprRef(&e.Left)
+
+			return
+		}
+
+		s.exprRef(&e.Left)
+		s.exprRef(&e.Right)
+	case *parser.TernaryExpression:
+		s.exprRef(&e.Condition)
+		s.exprRef(&e.TrueExpr)
+		s.exprRef(&e.FalseExpr)
+	case *parser.CallExpression:
+		if b, ok := e.Function.(*parser.BinaryExpression); ok && b.Operator.Value == "." {
+			s.exprRef(&b.Left)
+		}
+
+		for i := range e.Arguments {
+			s.exprRef(&e.Arguments[i])
+		}
+	case *parser.ArrayExpression:
+		for i := range e.Elements {
+			s.exprRef(&e.Elements[i])
+		}
+	case *parser.IndexExpression:
+		s.exprRef(&e.Object)
+		s.exprRef(&e.Index)
+	}
+}
+
+type astMutation struct {
+	r         *rand.Rand
+	g         *Grammar
+	target    *slots
+	donor     *slots
+	maxDepth  int
+	smallInts bool // keep integer literals in 1..9
+}
+
+// apply performs one randomly chosen mutation and reports whether it
+// changed the tree.
+func (m *astMutation) apply() bool {
+	switch m.r.Intn(7) {
+	case 0:
+		return m.swapExprs()
+	case 1:
+		return m.swapStmts()
+	case 2:
+		return m.spliceExpr()
+	case 3:
+		return m.spliceStmt()
+	case 4:
+		return m.regrowExpr()
+	case 5:
+		return m.deleteOrDuplicateStmt()
+	default:
+		return m.mutateLiteral()
+	}
+}
+
+// disjoint picks two slot indexes whose subtrees do not overlap.
+func disjoint(r *rand.Rand, n int, end func(int) int) (int, int, bool) {
+	if n < 2 {
+		return 0, 0, false
+	}
+
+	i, j := r.Intn(n), r.Intn(n)
+	if i > j {
+		i, j = j, i
+	}
+
+	if i == j || j < end(i) {
+		return 0, 0, false
+	}
+
+	return i, j, true
+}
+
+func (m *astMutation) swapExprs() bool {
+	es := m.target.exprs
+
+	i, j, ok := disjoint(m.r, len(es), func(k int) int { return es[k].end })
+	if !ok {
+		return false
+	}
+
+	*es[i].ref, *es[j].ref = *es[j].ref, *es[i].ref
+
+	return true
+}
+
+func (m *astMutation) swapStmts() bool {
+	ss := m.target.stmts
+
+	i, j, ok := disjoint(m.r, len(ss), func(k int) int { return ss[k].end })
+	if !ok {
+		return false
+	}
+
+	a, b := ss[i], ss[j]
+	a.block.Statements[a.index], b.block.Statements[b.index] = b.block.Statements[b.index], a.block.Statements[a.index]
+
+	return true
+}
+
+func (m *astMutation) spliceExpr() bool {
+	if m.donor == nil || len(m.donor.exprs) == 0 || len(m.target.exprs) == 0 {
+		return false
+	}
+
+	*m.target.exprs[m.r.Intn(len(m.target.exprs))].ref = *m.donor.exprs[m.r.Intn(len(m.donor.exprs))].ref
+
+	return true
+}
+
+// spliceStmt replaces a statement with one from the donor, or inserts the
+// donor's statement into any block, empty ones included.
+func (m *astMutation) spliceStmt() bool {
+	if m.donor == nil || len(m.donor.stmts) == 0 {
+		return false
+	}
+
+	d := m.donor.stmts[m.r.Intn(len(m.donor.stmts))]
+	st := d.block.Statements[d.index]
+
+	if len(m.target.stmts) > 0 && m.r.Intn(2) == 0 {
+		t := m.target.stmts[m.r.Intn(len(m.target.stmts))]
+		t.block.Statements[t.index] = st
+
+		return true
+	}
+
+	if len(m.target.blocks) == 0 {
+		return false
+	}
+
+	b := m.target.blocks[m.r.Intn(len(m.target.blocks))]
+	b.Statements = insertStmt(b.Statements, m.r.Intn(len(b.Statements)+1), st)
+
+	return true
+}
+
+// regrowExpr replaces an expression with a new one derived from <expr>.
+func (m *astMutation) regrowExpr() bool {
+	if m.g == nil || len(m.target.exprs) == 0 {
+		return false
+	}
+
+	src := m.g.GenerateFrom(m.r, "<expr>", m.maxDepth/2, 256)
+	if src == "" {
+		return false
+	}
+
+	prog, ok := parseProgram([]byte("let __regrow = " + src + ";"))
+	if !ok {
+		return false
+	}
+
+	v, ok := prog.Declarations[0].(*parser.VariableDeclaration)
+	if !ok || v.Initializer == nil {
+		return false
+	}
+
+	*m.target.exprs[m.r.Intn(len(m.target.exprs))].ref = v.Initializer
+
+	return true
+}
+
+func (m *astMutation) deleteOrDuplicateStmt() bool {
+	if len(m.target.stmts) == 0 {
+		return false
+	}
+
+	t := m.target.stmts[m.r.Intn(len(m.target.stmts))]
+	stmts := t.block.Statements
+
+	if m.r.Intn(3) == 0 {
+		t.block.Statements = append(stmts[:t.index:t.index], stmts[t.index+1:]...)
+	} else {
+		t.block.Statements = insertStmt(stmts, t.index, stmts[t.index])
+	}
+
+	return true
+}
+
+func insertStmt(stmts []parser.Statement, i int, st parser.Statement) []parser.Statement {
+	out := make([]parser.Statement, 0, len(stmts)+1)
+	out = append(out, stmts[:i]...)
+	out = append(out, st)
+
+	return append(out, stmts[i:]...)
+}
+
+var (
+	interestingIntValues   = []int64{0, 1, -1, 2, 7, 8, 16, 31, 32, 63, 64, 127, 128, 255, 256, math.MaxInt32, math.MinInt32, math.MaxUint32, math.MaxInt64, math.MinInt64}
+	interestingFloatValues = []float64{0, -0.5, 0.5, 1, 3.14, 1e-300, 5e-324, 1e300, math.MaxFloat64, -math.MaxFloat64}
+	interestingRawStrings  = []string{"", "a", "\\n", "\\\\", "\\\"", "\\u{1F600}", "日本語", "%s%d", "0", "true"}
+)
+
+// mutateLiteral replaces a literal with another value of the same kind.
+// String values are written the way they appear in source, escapes
+// included.
+func (m *astMutation) mutateLiteral() bool {
+	var lits []*parser.Literal
+
+	for _, s := range m.target.exprs {
+		if l, ok := (*s.ref).(*parser.Literal); ok {
+			lits = append(lits, l)
+		}
+	}
+
+	if len(lits) == 0 {
+		return false
+	}
+
+	old := lits[m.r.Intn(len(lits))]
+	l := *old
+
+	switch v := l.Value.(type) {
+	case int64:
+		switch {
+		case m.smallInts:
+			l.Value = int64(1 + m.r.Intn(9))
+		case m.r.Intn(3) == 0:
+			l.Value = interestingIntValues[m.r.Intn(len(interestingIntValues))]
+		case m.r.Intn(2) == 0:
+			l.Value = v + int64(m.r.Intn(33)-16)
+		default:
+			l.Value = v * 2
+		}
+	case float64:
+		if m.r.Intn(2) == 0 {
+			l.Value = interestingFloatValues[m.r.Intn(len(interestingFloatValues))]
+		} else if w := v * float64(m.r.Intn(5)-2); !math.IsInf(w, 0) {
+			l.Value = w
+		}
+	case string:
+		if m.r.Intn(2) == 0 || len(v) > 64 {
+			l.Value = interestingRawStrings[m.r.Intn(len(interestingRawStrings))]
+		} else {
+			l.Value = v + v
+		}
+	case bool:
+		l.Value = !v
+	default:
+		return false
+	}
+
+	for _, s := range m.target.exprs {
+		if *s.ref == parser.Expression(old) {
+			*s.ref = &l
+		}
+	}
+
+	return true
+}
@@ -20,6 +20,10 @@ type Mutator func(r *rand.Rand, in []byte) []byte
 // Target is the fuzz target. Returning an error or panicking indicates a crash.
 type Target func(data []byte) error
 
+// CoverageHook receives an input that reached new coverage together with
+// the features it reached first.
+type CoverageHook func(data []byte, fresh []uint64)
+
 // Options controls the fuzzing loop.
 type Options struct {
 	Duration          time.Duration // total fuzz time
@@ -30,12 +34,15 @@ type Options struct {
 	MutationIntensity float64       // mutation intensity factor (1.0=default). <=0 uses default
 	AutoTune          bool          // adapt intensity based on crash rate
 	MaxExecs          uint64        // optional cap on total executions across workers (0=unlimited)
+	Coverage          *GoCoverage   // optional Go coverage feedback; forces a single worker
+	OnNewCoverage     CoverageHook  // called for inputs that reach new coverage
 }
 
 // Stats captures aggregate counters for a fuzzing run.
 type Stats struct {
-	Executions uint64
-	Crashes    uint64
+	Executions  uint64
+	Crashes     uint64
+	NewCoverage uint64 // inputs that reached new Go coverage counters
 }
 
 // DefaultMutator implements a simple byte-level mutation strategy.
@@ -145,7 +152,9 @@ func RunWithStats(opts Options, corpus []CorpusEntry, target Target, mut Mutator
 		opts.MaxInput = 1 << 12
 	}
 
-	if opts.Concurrency <= 0 {
+	if opts.Concurrency <= 0 || opts.Coverage != nil {
+		// coverage counters are global to the process, so with coverage
+		// feedback nothing else may run while the target does.
 		opts.Concurrency = 1
 	}
 	// Defer mutator selection until after intensity/autotune configuration
@@ -170,6 +179,8 @@ func RunWithStats(opts Options, corpus []CorpusEntry, target Target, mut Mutator
 
 	var crashCount uint64
 
+	var newCovCount uint64
+
 	var quit uint32
 	// configure mutator (adaptive if requested).
 	var level atomic.Uint64
@@ -192,6 +203,9 @@ func RunWithStats(opts Options, corpus []CorpusEntry, target Target, mut Mutator
 		go func(r *rand.Rand) {
 			cur := []byte("ORIZON")
 
+			// inputs that reached new coverage, the parents of later mutations.
+			var interesting [][]byte
+
 			for time.Now().Before(stop) {
 				if atomic.LoadUint32(&quit) == 1 {
 					return
@@ -207,21 +221,22 @@ func RunWithStats(opts Options, corpus []CorpusEntry, target Target, mut Mutator
 					cand = cand[:opts.MaxInput]
 				}
 
-				var err error
+				var res outcome
 
 				if opts.InputBudget > 0 {
-					ch := make(chan error, 1)
-					go func(d []byte) { ch <- callTargetSafe(target, d) }(cand)
+					ch := make(chan outcome, 1)
+					go func(d []byte) { ch <- execute(opts.Coverage, target, d) }(cand)
 					select {
-					case e := <-ch:
-						err = e
+					case res = <-ch:
 					case <-time.After(opts.InputBudget):
-						err = io.EOF // report as failure to trigger crash output
+						res.err = io.EOF // report as failure to trigger crash output
 					}
 				} else {
-					err = callTargetSafe(target, cand)
+					res = execute(opts.Coverage, target, cand)
 				}
 
+				err := res.err
+
 				newExec := atomic.AddUint64(&execCount, 1)
 
 				if err != nil {
@@ -258,7 +273,21 @@ func RunWithStats(opts Options, corpus []CorpusEntry, target Target, mut Mutator
 					return
 				}
 
-				cur = cand
+				switch {
+				case opts.Coverage == nil:
+					cur = cand
+				case len(res.fresh) > 0 && err == nil:
+					atomic.AddUint64(&newCovCount, 1)
+
+					if opts.OnNewCoverage != nil {
+						opts.OnNewCoverage(cand, res.fresh)
+					}
+
+					interesting = append(interesting, cand)
+					cur = cand
+				case len(interesting) > 0:
+					cur = interesting[r.Intn(len(interesting))]
+				}
 			}
 		}(r)
 	}
@@ -272,7 +301,33 @@ func RunWithStats(opts Options, corpus []CorpusEntry, target Target, mut Mutator
 		time.Sleep(10 * time.Millisecond)
 	}
 
-	return Stats{Executions: atomic.LoadUint64(&execCount), Crashes: atomic.LoadUint64(&crashCount)}
+	return Stats{
+		Executions:  atomic.LoadUint64(&execCount),
+		Crashes:     atomic.LoadUint64(&crashCount),
+		NewCoverage: atomic.LoadUint64(&newCovCount),
+	}
+}
+
+// outcome is the result of one execution of the target.
+type outcome struct {
+	err   error
+	fresh []uint64 // features not reached by earlier inputs
+}
+
+// execute runs target on data, measuring its coverage when cov is set.
+func execute(cov *GoCoverage, target Target, data []byte) outcome {
+	if cov == nil {
+		return outcome{err: callTargetSafe(target, data)}
+	}
+
+	var res outcome
+
+	sig, err := cov.Run(func() { res.err = callTargetSafe(target, data) })
+	if err == nil {
+		res.fresh = cov.Merge(sig)
+	}
+
+	return res
 }
 
 // callTargetSafe invokes the target and converts panics into errors for recording.
@@ -299,6 +354,39 @@ func derive(base int64, salt int) int64 {
 // Minimize attempts to reduce input while preserving failure (target returns non-nil).
 // It applies a greedy delta-debugging inspired process within the given time budget.
 func Minimize(seed int64, in []byte, target Target, budget time.Duration) []byte {
+	return minimize(seed, in, func(b []byte) bool { return target(b) != nil }, budget)
+}
+
+// MinimizeCoverage is like Minimize, but also preserves the coverage
+// signature: a smaller input is kept only if it still fails and reaches
+// the same Go coverage counters as in. Without cov it is Minimize.
+func MinimizeCoverage(seed int64, in []byte, target Target, cov *GoCoverage, budget time.Duration) []byte {
+	if cov == nil {
+		return Minimize(seed, in, target, budget)
+	}
+
+	run := func(b []byte) (Signature, bool) {
+		var err error
+
+		sig, cerr := cov.Run(func() { err = callTargetSafe(target, b) })
+
+		return sig, cerr == nil && err != nil
+	}
+
+	want, failed := run(in)
+	if !failed {
+		return append([]byte(nil), in...)
+	}
+
+	return minimize(seed, in, func(b []byte) bool {
+		sig, failed := run(b)
+
+		return failed && sig.SameCounters(want)
+	}, budget)
+}
+
+// minimize shrinks in as long as keep accepts the smaller input.
+func minimize(seed int64, in []byte, keep func([]byte) bool, budget time.Duration) []byte {
 	if seed == 0 {
 		seed = time.Now().UnixNano()
 	}
@@ -307,7 +395,7 @@ func Minimize(seed int64, in []byte, target Target, budget time.Duration) []byte
 	start := time.Now()
 
 	best := append([]byte(nil), in...)
-	if target(best) == nil {
+	if !keep(best) {
 		return best
 	}
 	// Strategies: delete chunks, flip bits, replace bytes, shrink tail.
@@ -329,7 +417,7 @@ func Minimize(seed int64, in []byte, target Target, budget time.Duration) []byte
 					continue
 				}
 
-				if target(cand) != nil {
+				if keep(cand) {
 					best = cand
 					progressed = true
 
@@ -348,7 +436,7 @@ func Minimize(seed int64, in []byte, target Target, budget time.Duration) []byte
 		// Try truncating tail.
 		if len(best) > 1 {
 			cand := append([]byte(nil), best[:len(best)-1]...)
-			if target(cand) != nil {
+			if keep(cand) {
 				best = cand
 
 				continue
@@ -362,14 +450,14 @@ func Minimize(seed int64, in []byte, target Target, budget time.Duration) []byte
 			cand := append([]byte(nil), best...)
 			cand[idx] = b ^ (1 << uint(r.Intn(8)))
 
-			if target(cand) != nil {
+			if keep(cand) {
 				best = cand
 
 				continue
 			}
 			// replace.
 			cand[idx] = byte(r.Intn(256))
-			if target(cand) != nil {
+			if keep(cand) {
 				best = cand
 
 				continue
@@ -381,7 +469,7 @@ func Minimize(seed int64, in []byte, target Target, budget time.Duration) []byte
 			cand := append([]byte(nil), best[:i]...)
 			cand = append(cand, best[i+1:]...)
 
-			if len(cand) > 0 && target(cand) != nil {
+			if len(cand) > 0 && keep(cand) {
 				best = cand
 
 				continue
@@ -0,0 +1,263 @@
+package fuzz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime/coverage"
+	"sort"
+	"sync"
+)
+
+// GoCoverage measures which code of the running binary each input reaches,
+// using the counters the Go toolchain inserts when a binary is built with
+// -cover. The binary must use -covermode=atomic, since counters are reset
+// before every execution, and -coverpkg should name the main package and
+// the packages under test, for example:
+//
+//	go build -cover -covermode=atomic \
+//	    -coverpkg=./cmd/orizon-fuzz,./internal/lexer,./internal/parser ./cmd/orizon-fuzz
+//
+// Executions are serialized, because the counters are global to the
+// process.
+type GoCoverage struct {
+	seen map[uint64]struct{}
+	buf  bytes.Buffer
+	mu   sync.Mutex
+}
+
+// NewGoCoverage returns an error when the binary was not built with
+// -cover -covermode=atomic.
+func NewGoCoverage() (*GoCoverage, error) {
+	if err := coverage.ClearCounters(); err != nil {
+		return nil, err
+	}
+
+	return &GoCoverage{seen: make(map[uint64]struct{})}, nil
+}
+
+// Signature lists the features an execution reached, sorted. A feature is
+// a coverage counter together with a bucket of its hit count (1, 2, 3,
+// 4-7, 8-15, 16-31, 32-127, 128+), so that reaching a branch more often
+// than before also counts as new behaviour.
+type Signature []uint64
+
+// Counters returns the number of distinct counters in s.
+func (s Signature) Counters() int {
+	n := 0
+
+	for i := range s {
+		if i == 0 || s[i]>>4 != s[i-1]>>4 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// SameCounters reports whether s and o reach the same counters, whatever
+// the hit counts.
+func (s Signature) SameCounters(o Signature) bool {
+	i, j := 0, 0
+
+	for i < len(s) && j < len(o) {
+		if s[i]>>4 != o[j]>>4 {
+			return false
+		}
+
+		for i+1 < len(s) && s[i+1]>>4 == s[i]>>4 {
+			i++
+		}
+
+		for j+1 < len(o) && o[j+1]>>4 == o[j]>>4 {
+			j++
+		}
+
+		i++
+		j++
+	}
+
+	return i == len(s) && j == len(o)
+}
+
+// Run calls fn with fresh counters and returns the signature of what it
+// reached. fn must not panic.
+func (c *GoCoverage) Run(fn func()) (Signature, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := coverage.ClearCounters(); err != nil {
+		return nil, err
+	}
+
+	fn()
+
+	c.buf.Reset()
+
+	if err := coverage.WriteCounters(&c.buf); err != nil {
+		return nil, err
+	}
+
+	return decodeSignature(c.buf.Bytes())
+}
+
+// Merge adds the features of sig to the ones seen so far and returns
+// those that are new.
+func (c *GoCoverage) Merge(sig Signature) []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var fresh []uint64
+
+	for _, f := range sig {
+		if _, ok := c.seen[f]; !ok {
+			c.seen[f] = struct{}{}
+			fresh = append(fresh, f)
+		}
+	}
+
+	return fresh
+}
+
+// Features returns the number of distinct features seen so far.
+func (c *GoCoverage) Features() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.seen)
+}
+
+// hitBucket maps a hit count to the bucket stored in a feature.
+func hitBucket(n uint32) uint64 {
+	switch {
+	case n <= 3:
+		return uint64(n)
+	case n < 8:
+		return 4
+	case n < 16:
+		return 5
+	case n < 32:
+		return 6
+	case n < 128:
+		return 7
+	default:
+		return 8
+	}
+}
+
+// The layout of counter data files written by runtime/coverage; see
+// internal/coverage in the Go distribution.
+var counterMagic = [4]byte{0x00, 0x63, 0x77, 0x6d}
+
+const (
+	counterHeaderSize  = 32 // magic, version, meta hash, flavor, endianness, padding
+	segmentHeaderSize  = 16 // function entries, string table and args lengths
+	counterFooterSize  = 16
+	counterFlavorRaw   = 1
+	counterFlavorULEB  = 2
+	maxCountersPerFunc = 1 << 20
+)
+
+var errBadCounterData = errors.New("malformed coverage counter data")
+
+// decodeSignature turns a counter data file into the signature of its
+// nonzero counters. A counter is identified by package, function and
+// index, which are stable within one process.
+func decodeSignature(data []byte) (Signature, error) {
+	if len(data) < counterHeaderSize+counterFooterSize || !bytes.Equal(data[:4], counterMagic[:]) {
+		return nil, errBadCounterData
+	}
+
+	flavor := data[24]
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[25] != 0 {
+		order = binary.BigEndian
+	}
+
+	footer := data[len(data)-counterFooterSize:]
+	if !bytes.Equal(footer[:4], counterMagic[:]) {
+		return nil, errBadCounterData
+	}
+
+	segments := order.Uint32(footer[8:12])
+	d := &counterDecoder{data: data[:len(data)-counterFooterSize], pos: counterHeaderSize, order: order, flavor: flavor}
+
+	var sig Signature
+
+	for s := uint32(0); s < segments; s++ {
+		if d.pos+segmentHeaderSize > len(d.data) {
+			return nil, errBadCounterData
+		}
+
+		entries := order.Uint64(d.data[d.pos:])
+		skip := int(order.Uint32(d.data[d.pos+8:])) + int(order.Uint32(d.data[d.pos+12:]))
+		d.pos += segmentHeaderSize + skip
+
+		for e := uint64(0); e < entries; e++ {
+			n, pkg, fn := d.next(), d.next(), d.next()
+			if d.err != nil || n > maxCountersPerFunc {
+				return nil, errBadCounterData
+			}
+
+			for i := uint32(0); i < n; i++ {
+				if v := d.next(); v != 0 {
+					key := uint64(pkg)<<40 | uint64(fn&0xfffff)<<20 | uint64(i&0xfffff)
+					sig = append(sig, key<<4|hitBucket(v))
+				}
+			}
+		}
+
+		if d.err != nil {
+			return nil, d.err
+		}
+	}
+
+	sort.Slice(sig, func(i, j int) bool { return sig[i] < sig[j] })
+
+	return sig, nil
+}
+
+type counterDecoder struct {
+	err    error
+	order  binary.ByteOrder
+	data   []byte
+	pos    int
+	flavor byte
+}
+
+func (d *counterDecoder) next() uint32 {
+	if d.err != nil {
+		return 0
+	}
+
+	switch d.flavor {
+	case counterFlavorRaw:
+		if d.pos+4 > len(d.data) {
+			d.err = errBadCounterData
+
+			return 0
+		}
+
+		v := d.order.Uint32(d.data[d.pos:])
+		d.pos += 4
+
+		return v
+	case counterFlavorULEB:
+		v, n := binary.Uvarint(d.data[d.pos:])
+		if n <= 0 || v > 1<<32-1 {
+			d.err = errBadCounterData
+
+			return 0
+		}
+
+		d.pos += n
+
+		return uint32(v)
+	default:
+		d.err = fmt.Errorf("%w: unknown counter flavor %d", errBadCounterData, d.flavor)
+
+		return 0
+	}
+}
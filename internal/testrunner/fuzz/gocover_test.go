@@ -0,0 +1,89 @@
+package fuzz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// counterFile builds a counter data file with one segment holding the
+// given functions, each a list of counter values.
+func counterFile(flavor byte, funcs map[[2]uint32][]uint32) []byte {
+	var b bytes.Buffer
+
+	b.Write(counterMagic[:])
+	binary.Write(&b, binary.LittleEndian, uint32(1))
+	b.Write(make([]byte, 16)) // meta hash
+	b.Write([]byte{flavor, 0, 0, 0, 0, 0, 0, 0})
+
+	binary.Write(&b, binary.LittleEndian, uint64(len(funcs)))
+	binary.Write(&b, binary.LittleEndian, uint32(3)) // string table
+	binary.Write(&b, binary.LittleEndian, uint32(5)) // args and padding
+	b.Write(make([]byte, 8))
+
+	put := func(v uint32) {
+		if flavor == counterFlavorRaw {
+			binary.Write(&b, binary.LittleEndian, v)
+
+			return
+		}
+
+		b.Write(binary.AppendUvarint(nil, uint64(v)))
+	}
+
+	for id, ctrs := range funcs {
+		put(uint32(len(ctrs)))
+		put(id[0])
+		put(id[1])
+
+		for _, c := range ctrs {
+			put(c)
+		}
+	}
+
+	b.Write(counterMagic[:])
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.LittleEndian, uint32(1))
+	b.Write(make([]byte, 4))
+
+	return b.Bytes()
+}
+
+func TestDecodeSignature(t *testing.T) {
+	funcs := map[[2]uint32][]uint32{
+		{1, 2}: {0, 1, 300},
+		{3, 0}: {5},
+	}
+
+	for _, flavor := range []byte{counterFlavorRaw, counterFlavorULEB} {
+		sig, err := decodeSignature(counterFile(flavor, funcs))
+		if err != nil {
+			t.Fatalf("flavor %d: %v", flavor, err)
+		}
+
+		if len(sig) != 3 || sig.Counters() != 3 {
+			t.Fatalf("flavor %d: signature %x", flavor, sig)
+		}
+
+		if sig[0] != (1<<40|2<<20|1)<<4|1 || sig[1] != (1<<40|2<<20|2)<<4|8 || sig[2] != (3<<40)<<4|4 {
+			t.Fatalf("flavor %d: signature %x", flavor, sig)
+		}
+	}
+
+	if _, err := decodeSignature([]byte("not counters")); err == nil {
+		t.Fatalf("expected an error for malformed data")
+	}
+}
+
+func TestSignatureSameCounters(t *testing.T) {
+	a := Signature{1<<4 | 1, 2<<4 | 1, 2<<4 | 3}
+	b := Signature{1<<4 | 2, 2<<4 | 5}
+
+	if !a.SameCounters(b) || !b.SameCounters(a) {
+		t.Fatalf("expected the same counters")
+	}
+
+	if a.SameCounters(Signature{1<<4 | 1}) || a.SameCounters(Signature{1<<4 | 1, 3<<4 | 1}) {
+		t.Fatalf("expected different counters")
+	}
+}
@@ -0,0 +1,417 @@
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Grammar is a context-free grammar used to generate structured inputs.
+// Each rule maps a nonterminal to alternative productions; a production is
+// a sequence of symbols. Symbols written <name> are nonterminals, the
+// built-in lexical symbols <IDENT>, <INT>, <FLOAT> and <STRING> produce a
+// random token, and every other symbol is emitted verbatim.
+type Grammar struct {
+	rules map[string][]production
+	cost  map[string]int
+	start string
+}
+
+type production []symbol
+
+// symbol is a terminal, emitted as is, or a nonterminal to expand.
+type symbol struct {
+	text        string
+	nonterminal bool
+}
+
+// builtinSymbols are the lexical nonterminals the generator fills in itself.
+var builtinSymbols = map[string]func(r *rand.Rand) string{
+	"<IDENT>":  genIdent,
+	"<INT>":    genInt,
+	"<FLOAT>":  genFloat,
+	"<STRING>": genString,
+}
+
+// ParseGrammar reads a grammar in a small BNF dialect:
+//
+//	# comment
+//	<rule> ::= <other> "text" | "alternative"
+//	         | "continued alternative"
+//
+// Terminals are Go string literals. The first rule is the start symbol.
+// Every nonterminal must be defined and must be able to terminate.
+func ParseGrammar(src string) (*Grammar, error) {
+	g := &Grammar{rules: make(map[string][]production)}
+
+	var current string
+
+	for n, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, rest, ok := strings.Cut(line, "::="); ok {
+			current = strings.TrimSpace(name)
+			if !isNonterminal(current) {
+				return nil, fmt.Errorf("grammar line %d: rule name %q must be written <name>", n+1, current)
+			}
+
+			if _, dup := g.rules[current]; dup {
+				return nil, fmt.Errorf("grammar line %d: rule %s defined twice", n+1, current)
+			}
+
+			if g.start == "" {
+				g.start = current
+			}
+
+			g.rules[current] = nil
+			line = rest
+		} else if current == "" || !strings.HasPrefix(line, "|") {
+			return nil, fmt.Errorf("grammar line %d: expected <rule> ::= ...", n+1)
+		}
+
+		alts, err := parseAlternatives(line)
+		if err != nil {
+			return nil, fmt.Errorf("grammar line %d: %w", n+1, err)
+		}
+
+		g.rules[current] = append(g.rules[current], alts...)
+	}
+
+	if g.start == "" {
+		return nil, fmt.Errorf("grammar has no rules")
+	}
+
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// MustParseGrammar is like ParseGrammar but panics on error. It is meant
+// for grammars compiled into the binary.
+func MustParseGrammar(src string) *Grammar {
+	g, err := ParseGrammar(src)
+	if err != nil {
+		panic(err)
+	}
+
+	return g
+}
+
+func isNonterminal(s string) bool {
+	return len(s) > 2 && s[0] == '<' && s[len(s)-1] == '>'
+}
+
+// parseAlternatives splits the right-hand side of a rule at '|'.
+func parseAlternatives(s string) ([]production, error) {
+	var (
+		alts []production
+		cur  production
+		seen bool // whether cur has started
+	)
+
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+
+		switch {
+		case s[0] == '|':
+			if seen {
+				alts = append(alts, cur)
+			}
+
+			cur, seen, s = nil, true, s[1:]
+		case s[0] == '"':
+			lit, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("bad terminal at %q", s)
+			}
+
+			text, _ := strconv.Unquote(lit)
+			if text != "" {
+				cur = append(cur, symbol{text: text})
+			}
+
+			seen, s = true, s[len(lit):]
+		case s[0] == '<':
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated nonterminal at %q", s)
+			}
+
+			cur, seen, s = append(cur, symbol{text: s[:end+1], nonterminal: true}), true, s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", s)
+		}
+	}
+
+	if seen {
+		alts = append(alts, cur)
+	}
+
+	return alts, nil
+}
+
+// check verifies that all nonterminals are defined and computes for every
+// rule the depth of its shallowest derivation, which generation uses to
+// finish once the depth budget is spent.
+func (g *Grammar) check() error {
+	names := make([]string, 0, len(g.rules))
+	for name, alts := range g.rules {
+		names = append(names, name)
+
+		if len(alts) == 0 {
+			return fmt.Errorf("grammar rule %s has no alternatives", name)
+		}
+
+		for _, alt := range alts {
+			for _, sym := range alt {
+				if !sym.nonterminal || builtinSymbols[sym.text] != nil {
+					continue
+				}
+
+				if _, ok := g.rules[sym.text]; !ok {
+					return fmt.Errorf("grammar rule %s refers to undefined %s", name, sym.text)
+				}
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	g.cost = make(map[string]int, len(g.rules))
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, name := range names {
+			for _, alt := range g.rules[name] {
+				c, ok := g.productionCost(alt)
+				if !ok {
+					continue
+				}
+
+				if old, seen := g.cost[name]; !seen || c < old {
+					g.cost[name] = c
+					changed = true
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := g.cost[name]; !ok {
+			return fmt.Errorf("grammar rule %s never terminates", name)
+		}
+	}
+
+	return nil
+}
+
+func (g *Grammar) productionCost(alt production) (int, bool) {
+	c := 1
+
+	for _, sym := range alt {
+		if !sym.nonterminal || builtinSymbols[sym.text] != nil {
+			continue
+		}
+
+		sc, ok := g.cost[sym.text]
+		if !ok {
+			return 0, false
+		}
+
+		c = max(c, sc+1)
+	}
+
+	return c, true
+}
+
+// Generate derives a random sentence from the start symbol. Beyond
+// maxDepth nested rules, or once the output exceeds maxLen bytes, only the
+// alternatives with the shallowest derivations are chosen.
+func (g *Grammar) Generate(r *rand.Rand, maxDepth, maxLen int) string {
+	return g.GenerateFrom(r, g.start, maxDepth, maxLen)
+}
+
+// GenerateFrom is like Generate but starts from the nonterminal sym, such
+// as "<expr>". It returns "" when the grammar does not define sym.
+func (g *Grammar) GenerateFrom(r *rand.Rand, sym string, maxDepth, maxLen int) string {
+	if _, ok := g.rules[sym]; !ok && builtinSymbols[sym] == nil {
+		return ""
+	}
+
+	var b strings.Builder
+
+	g.expand(r, &b, symbol{text: sym, nonterminal: true}, 0, maxDepth, maxLen)
+
+	return b.String()
+}
+
+func (g *Grammar) expand(r *rand.Rand, b *strings.Builder, sym symbol, depth, maxDepth, maxLen int) {
+	if !sym.nonterminal {
+		b.WriteString(sym.text)
+
+		return
+	}
+
+	if gen := builtinSymbols[sym.text]; gen != nil {
+		b.WriteString(gen(r))
+
+		return
+	}
+
+	alts := g.rules[sym.text]
+
+	if depth >= maxDepth || b.Len() >= maxLen {
+		cheapest := alts[:0:0]
+		best := -1
+
+		for _, alt := range alts {
+			c, _ := g.productionCost(alt)
+			if best == -1 || c < best {
+				cheapest, best = cheapest[:0], c
+			}
+
+			if c == best {
+				cheapest = append(cheapest, alt)
+			}
+		}
+
+		alts = cheapest
+	}
+
+	for _, s := range alts[r.Intn(len(alts))] {
+		g.expand(r, b, s, depth+1, maxDepth, maxLen)
+	}
+}
+
+// identPool keeps generated programs referring to the same few names.
+var identPool = []string{"a", "b", "c", "n", "x", "y", "s", "v", "f", "g", "main", "len"}
+
+func genIdent(r *rand.Rand) string {
+	if r.Intn(8) == 0 {
+		return fmt.Sprintf("id%d", r.Intn(100))
+	}
+
+	return identPool[r.Intn(len(identPool))]
+}
+
+var interestingInts = []string{"0", "1", "2", "7", "10", "42", "127", "128", "255", "256", "65535", "2147483647", "2147483648", "4294967295", "9223372036854775807", "0x7f", "0xff"}
+
+func genInt(r *rand.Rand) string {
+	if r.Intn(4) == 0 {
+		return strconv.Itoa(r.Intn(1000))
+	}
+
+	return interestingInts[r.Intn(len(interestingInts))]
+}
+
+var interestingFloats = []string{"0.0", "0.5", "1.0", "3.14", "1e10", "2.5e-3", "1e308", "4.9e-324"}
+
+func genFloat(r *rand.Rand) string {
+	return interestingFloats[r.Intn(len(interestingFloats))]
+}
+
+// Strings are emitted the way they appear in source, escapes included.
+var interestingStrings = []string{`""`, `"a"`, `"hello"`, `"a\nb"`, `"tab\t"`, `"q\"q"`, `"back\\slash"`, `"é日本"`, `"{}"`, `"%d"`}
+
+func genString(r *rand.Rand) string {
+	return interestingStrings[r.Intn(len(interestingStrings))]
+}
+
+// orizonGrammar describes the productions of parser.Parser that the
+// structured fuzzer exercises: declarations with attributes, statements
+// and the full operator set. Every sentence it derives parses without
+// errors, though names and types need not agree.
+const orizonGrammar = `
+<program> ::= <decl> | <decl> "\n" <program>
+<decl> ::= <function> | <function> | <global> | <struct> | <attribute> "\n" <function>
+<attribute> ::= "#[test]" | "#[inline]" | "#[cfg(" <cfg> ")]"
+<cfg> ::= <IDENT> | "feature = " <STRING> | "not(" <cfg> ")" | "all(" <cfg> ", " <cfg> ")" | "any(" <cfg> ")"
+<global> ::= "let " <IDENT> " = " <expr> ";" | "let " <IDENT> ": " <type> " = " <expr> ";"
+<struct> ::= "struct " <IDENT> " { " <fields> " }"
+<fields> ::= <IDENT> ": " <type> | <IDENT> ": " <type> ", " <fields>
+<function> ::= <visibility> "func " <IDENT> "(" <params> ")" <result> " " <block>
+<visibility> ::= "" | "pub "
+<params> ::= "" | <paramlist>
+<paramlist> ::= <param> | <param> ", " <paramlist>
+<param> ::= <IDENT> ": " <type> | "mut " <IDENT> ": " <type>
+<result> ::= "" | " -> " <type>
+<type> ::= "int" | "bool" | "string" | "float" | "i32" | "u8" | "[" <type> "]"
+<block> ::= "{\n" <stmts> "}"
+<stmts> ::= "" | <stmt> <stmts>
+<stmt> ::= <local> ";\n" | <simple> ";\n" | <if> "\n" | <loop> "\n" | <block> "\n"
+         | "return;\n" | "return " <expr> ";\n" | "break;\n" | "continue;\n"
+<local> ::= "let " <IDENT> " = " <expr> | "let mut " <IDENT> " = " <expr>
+          | "var " <IDENT> ": " <type> " = " <expr> | "let " <IDENT> ": " <type> " = " <expr>
+<simple> ::= <call> | <place> " " <assign> " " <expr>
+<place> ::= <IDENT> | <IDENT> "[" <expr> "]"
+<assign> ::= "=" | "+=" | "-=" | "*=" | "/=" | "%="
+<if> ::= "if " <expr> " " <block> | "if " <expr> " " <block> " else " <block> | "if " <expr> " " <block> " else " <if>
+<loop> ::= "while " <expr> " " <block> | "loop " <block>
+         | "for " <IDENT> " in " <expr> " " <block>
+         | "for (" <local> "; " <expr> "; " <simple> ") " <block>
+<expr> ::= <primary> | <primary> | <expr> " " <binop> " " <expr> | <unop> " " <primary>
+         | "(" <expr> ")" | <expr> " ? " <expr> " : " <expr>
+<primary> ::= <IDENT> | <INT> | <FLOAT> | <STRING> | "true" | "false" | <call> | <array> | <IDENT> "[" <expr> "]"
+<call> ::= <IDENT> "(" <args> ")" | <IDENT> "." <IDENT> "(" <args> ")"
+<args> ::= "" | <arglist>
+<arglist> ::= <expr> | <expr> ", " <arglist>
+<array> ::= "[" <args> "]"
+<binop> ::= "+" | "-" | "*" | "/" | "%" | "==" | "!=" | "<" | "<=" | ">" | ">=" | "&&" | "||"
+          | "&" | "|" | "^" | "<<" | ">>"
+<unop> ::= "-" | "!" | "~"
+`
+
+// OrizonGrammar returns the grammar of Orizon programs used by
+// GrammarMutator.
+func OrizonGrammar() *Grammar {
+	return MustParseGrammar(orizonGrammar)
+}
+
+// DefaultGenDepth is the default depth budget for generated programs.
+const DefaultGenDepth = 10
+
+// GrammarMutator returns a mutator that ignores its input and derives a
+// fresh program from g for every execution, so that every input gets past
+// the lexer and the parser's error recovery.
+func GrammarMutator(g *Grammar, maxDepth int) Mutator {
+	if maxDepth <= 0 {
+		maxDepth = DefaultGenDepth
+	}
+
+	return func(r *rand.Rand, _ []byte) []byte {
+		return []byte(g.Generate(r, maxDepth, 1<<12))
+	}
+}
+
+// BoundedMutator retries m until its output fits in maxLen bytes, so that
+// the fuzzing loop does not cut a structured input in the middle of a
+// token. After a few attempts the shortest output is returned.
+func BoundedMutator(m Mutator, maxLen int) Mutator {
+	return func(r *rand.Rand, in []byte) []byte {
+		var best []byte
+
+		for i := 0; i < 8; i++ {
+			out := m(r, in)
+			if len(out) <= maxLen {
+				return out
+			}
+
+			if best == nil || len(out) < len(best) {
+				best = out
+			}
+		}
+
+		return best
+	}
+}
@@ -0,0 +1,143 @@
+package fuzz
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
+)
+
+func TestParseGrammar_Generate(t *testing.T) {
+	g, err := ParseGrammar(`
+# a list of a's ending in b
+<s> ::= "a" <s>
+      | "b"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		out := g.Generate(r, 5, 100)
+		if strings.Trim(out, "a") != "b" || len(out) > 6 {
+			t.Fatalf("unexpected sentence %q", out)
+		}
+	}
+}
+
+func TestParseGrammar_Errors(t *testing.T) {
+	cases := map[string]string{
+		"undefined":        `<s> ::= <t>`,
+		"never terminates": `<s> ::= "a" <s>`,
+		"bad terminal":     `<s> ::= "a`,
+		"no rule":          `| "a"`,
+		"bad name":         `s ::= "a"`,
+		"duplicate":        "<s> ::= \"a\"\n<s> ::= \"b\"",
+	}
+
+	for name, src := range cases {
+		if _, err := ParseGrammar(src); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOrizonGrammar_ProgramsParseAndRoundTrip(t *testing.T) {
+	g := OrizonGrammar()
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		src := g.Generate(r, DefaultGenDepth, 1<<12)
+
+		prog, ok := parseProgram([]byte(src))
+		if !ok {
+			t.Fatalf("generated program does not parse:\n%s", src)
+		}
+
+		out, err := FormatProgram(prog)
+		if err != nil {
+			t.Fatalf("format: %v\n%s", err, src)
+		}
+
+		again, ok := parseProgram([]byte(out))
+		if !ok {
+			t.Fatalf("printed program does not parse:\n%s", out)
+		}
+
+		if out2, _ := FormatProgram(again); out2 != out {
+			t.Fatalf("printing is not stable:\n%s\n---\n%s", out, out2)
+		}
+	}
+}
+
+func TestASTMutator_KeepsProgramsValid(t *testing.T) {
+	g := OrizonGrammar()
+	r := rand.New(rand.NewSource(2))
+
+	corpus := []CorpusEntry{
+		CorpusEntry("func f(a: int) -> int { let b = a * 2; return b + 1; }"),
+		CorpusEntry(g.Generate(r, DefaultGenDepth, 1<<12)),
+	}
+	mut := ASTMutator(corpus, g, DefaultGenDepth)
+
+	in := []byte("func main() { let x = 1; if x > 0 { println(\"pos\"); } }")
+	changed := 0
+
+	for i := 0; i < 300; i++ {
+		out := mut(r, in)
+		if _, ok := parseProgram(out); !ok {
+			t.Fatalf("mutated program does not parse:\n%s", out)
+		}
+
+		if string(out) != string(in) {
+			changed++
+		}
+
+		// restart before programs grow large.
+		if len(out) > 1<<13 {
+			out = corpus[0]
+		}
+
+		in = out
+	}
+
+	if changed < 250 {
+		t.Fatalf("only %d of 300 mutations changed the program", changed)
+	}
+
+	// inputs that do not parse are replaced by generated programs.
+	if _, ok := parseProgram(mut(r, []byte("func ("))); !ok {
+		t.Fatalf("expected a valid program for an invalid input")
+	}
+}
+
+func TestGenerateTyped_RunsWithoutFailures(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+
+	for i := 0; i < 200; i++ {
+		src := GenerateTyped(r, DefaultGenDepth)
+
+		pkg, err := native.LoadSource("typed.oriz", src)
+		if err != nil {
+			t.Fatalf("load: %v\n%s", err, src)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		in, err := pkg.NewInterpreter(ctx, io.Discard)
+		if err == nil {
+			_, err = in.Call("main")
+		}
+
+		cancel()
+
+		if err != nil {
+			t.Fatalf("run: %v\n%s", err, src)
+		}
+	}
+}
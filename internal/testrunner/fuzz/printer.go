@@ -0,0 +1,449 @@
+package fuzz
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// FormatProgram prints prog back as source text that parses to the same
+// tree. Binary and ternary expressions are fully parenthesized, so the
+// output does not depend on operator precedence. Only the subset of the
+// language that the structured fuzzer generates is supported; other nodes
+// are reported as errors.
+func FormatProgram(prog *parser.Program) (string, error) {
+	pr := &printer{}
+
+	for i, decl := range prog.Declarations {
+		if i > 0 {
+			pr.b.WriteString("\n")
+		}
+
+		pr.decl(decl)
+	}
+
+	if pr.err != nil {
+		return "", pr.err
+	}
+
+	return pr.b.String(), nil
+}
+
+// FormatExpression prints a single expression the way FormatProgram does.
+func FormatExpression(e parser.Expression) (string, error) {
+	pr := &printer{}
+	pr.expr(e)
+
+	if pr.err != nil {
+		return "", pr.err
+	}
+
+	return pr.b.String(), nil
+}
+
+type printer struct {
+	err    error
+	b      strings.Builder
+	indent int
+}
+
+func (pr *printer) fail(format string, args ...any) {
+	if pr.err == nil {
+		pr.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (pr *printer) line(s string) {
+	pr.b.WriteString(strings.Repeat("    ", pr.indent))
+	pr.b.WriteString(s)
+}
+
+func (pr *printer) decl(d parser.Declaration) {
+	switch d := d.(type) {
+	case *parser.FunctionDeclaration:
+		pr.function(d)
+	case *parser.VariableDeclaration:
+		pr.local(d)
+		pr.b.WriteString(";\n")
+	case *parser.StructDeclaration:
+		if len(d.Generics) > 0 || len(d.WhereClause) > 0 {
+			pr.fail("printing generic struct %s is not supported", d.Name.Value)
+
+			return
+		}
+
+		if d.IsPublic {
+			pr.b.WriteString("pub ")
+		}
+
+		pr.b.WriteString("struct " + d.Name.Value + " {")
+
+		for i, f := range d.Fields {
+			if i > 0 {
+				pr.b.WriteString(",")
+			}
+
+			pr.b.WriteString(" " + f.Name.Value + ": ")
+			pr.typ(f.Type)
+		}
+
+		pr.b.WriteString(" }\n")
+	default:
+		pr.fail("printing declaration %T is not supported", d)
+	}
+}
+
+func (pr *printer) function(fn *parser.FunctionDeclaration) {
+	if len(fn.Generics) > 0 || len(fn.WhereClause) > 0 || fn.Effects != nil || fn.IsAsync {
+		pr.fail("printing function %s is not supported: generics, effects and async are not printed", fn.Name.Value)
+
+		return
+	}
+
+	for _, attr := range fn.Attributes {
+		pr.b.WriteString("#[" + attr + "]\n")
+	}
+
+	if fn.IsPublic {
+		pr.b.WriteString("pub ")
+	}
+
+	pr.b.WriteString("func " + fn.Name.Value + "(")
+
+	for i, param := range fn.Parameters {
+		if i > 0 {
+			pr.b.WriteString(", ")
+		}
+
+		if param.IsMut {
+			pr.b.WriteString("mut ")
+		}
+
+		pr.b.WriteString(param.Name.Value + ": ")
+		pr.typ(param.TypeSpec)
+	}
+
+	pr.b.WriteString(")")
+
+	if fn.ReturnType != nil {
+		pr.b.WriteString(" -> ")
+		pr.typ(fn.ReturnType)
+	}
+
+	pr.b.WriteString(" ")
+	pr.block(fn.Body)
+	pr.b.WriteString("\n")
+}
+
+func (pr *printer) typ(t parser.Type) {
+	switch t := t.(type) {
+	case *parser.BasicType:
+		pr.b.WriteString(t.Name)
+	case *parser.ArrayType:
+		if t.Size != nil {
+			pr.fail("printing sized array types is not supported")
+
+			return
+		}
+
+		pr.b.WriteString("[")
+		pr.typ(t.ElementType)
+		pr.b.WriteString("]")
+	default:
+		pr.fail("printing type %T is not supported", t)
+	}
+}
+
+// block prints a braced block; the caller positions the opening brace and
+// ends the line after the closing one.
+func (pr *printer) block(b *parser.BlockStatement) {
+	if b == nil {
+		pr.fail("missing block")
+
+		return
+	}
+
+	pr.b.WriteString("{\n")
+	pr.indent++
+
+	for _, s := range b.Statements {
+		pr.stmt(s)
+	}
+
+	pr.indent--
+	pr.line("}")
+}
+
+// body prints the body of a control statement, which must be a block.
+func (pr *printer) body(s parser.Statement) {
+	b, ok := s.(*parser.BlockStatement)
+	if !ok {
+		pr.fail("printing unbraced %T body is not supported", s)
+
+		return
+	}
+
+	pr.block(b)
+}
+
+func (pr *printer) stmt(s parser.Statement) {
+	switch s := s.(type) {
+	case *parser.BlockStatement:
+		pr.line("")
+		pr.block(s)
+		pr.b.WriteString("\n")
+	case *parser.ExpressionStatement:
+		pr.line("")
+		pr.expr(s.Expression)
+		pr.b.WriteString(";\n")
+	case *parser.VariableDeclaration:
+		pr.line("")
+		pr.local(s)
+		pr.b.WriteString(";\n")
+	case *parser.ReturnStatement:
+		pr.line("return")
+
+		if s.Value != nil {
+			pr.b.WriteString(" ")
+			pr.expr(s.Value)
+		}
+
+		pr.b.WriteString(";\n")
+	case *parser.IfStatement:
+		pr.line("")
+		pr.ifStmt(s)
+		pr.b.WriteString("\n")
+	case *parser.WhileStatement:
+		pr.line("while ")
+		pr.expr(s.Condition)
+		pr.b.WriteString(" ")
+		pr.body(s.Body)
+		pr.b.WriteString("\n")
+	case *parser.ForStatement:
+		if s.Label != nil {
+			pr.fail("printing labelled loops is not supported")
+
+			return
+		}
+
+		pr.line("for (")
+		pr.simple(s.Init)
+		pr.b.WriteString("; ")
+
+		if s.Condition != nil {
+			pr.expr(s.Condition)
+		}
+
+		pr.b.WriteString("; ")
+		pr.simple(s.Update)
+		pr.b.WriteString(") ")
+		pr.block(s.Body)
+		pr.b.WriteString("\n")
+	case *parser.ForInStatement:
+		pr.line("for " + s.Variable.Value + " in ")
+		pr.expr(s.Iterable)
+		pr.b.WriteString(" ")
+		pr.block(s.Body)
+		pr.b.WriteString("\n")
+	case *parser.BreakStatement:
+		if s.Label != nil {
+			pr.fail("printing labelled break is not supported")
+		}
+
+		pr.line("break;\n")
+	case *parser.ContinueStatement:
+		if s.Label != nil {
+			pr.fail("printing labelled continue is not supported")
+		}
+
+		pr.line("continue;\n")
+	default:
+		pr.fail("printing statement %T is not supported", s)
+	}
+}
+
+func (pr *printer) ifStmt(s *parser.IfStatement) {
+	pr.b.WriteString("if ")
+	pr.expr(s.Condition)
+	pr.b.WriteString(" ")
+	pr.body(s.ThenStmt)
+
+	switch e := s.ElseStmt.(type) {
+	case nil:
+	case *parser.IfStatement:
+		pr.b.WriteString(" else ")
+		pr.ifStmt(e)
+	default:
+		pr.b.WriteString(" else ")
+		pr.body(e)
+	}
+}
+
+// simple prints the init or update clause of a C-style for loop.
+func (pr *printer) simple(s parser.Statement) {
+	switch s := s.(type) {
+	case nil:
+	case *parser.VariableDeclaration:
+		pr.local(s)
+	case *parser.ExpressionStatement:
+		pr.expr(s.Expression)
+	default:
+		pr.fail("printing %T in a for clause is not supported", s)
+	}
+}
+
+// local prints a variable declaration without its semicolon.
+func (pr *printer) local(v *parser.VariableDeclaration) {
+	if v.IsPublic {
+		pr.b.WriteString("pub ")
+	}
+
+	pr.b.WriteString("let ")
+
+	if v.IsMutable {
+		pr.b.WriteString("mut ")
+	}
+
+	pr.b.WriteString(v.Name.Value)
+
+	if v.TypeSpec != nil {
+		pr.b.WriteString(": ")
+		pr.typ(v.TypeSpec)
+	}
+
+	if v.Initializer != nil {
+		pr.b.WriteString(" = ")
+		pr.expr(v.Initializer)
+	}
+}
+
+func (pr *printer) expr(e parser.Expression) {
+	switch e := e.(type) {
+	case *parser.Identifier:
+		pr.b.WriteString(e.Value)
+	case *parser.Literal:
+		pr.literal(e)
+	case *parser.UnaryExpression:
+		// "!(" does not lex as a prefix operator followed by a group, so
+		// the operand is always separated by a space.
+		pr.b.WriteString("(" + e.Operator.Value + " ")
+		pr.expr(e.Operand)
+		pr.b.WriteString(")")
+	case *parser.BinaryExpression:
+		if e.Operator.Value == "." {
+			pr.operand(e.Left)
+			pr.b.WriteString(".")
+			pr.expr(e.Right)
+
+			return
+		}
+
+		pr.b.WriteString("(")
+		pr.expr(e.Left)
+		pr.b.WriteString(" " + e.Operator.Value + " ")
+		pr.expr(e.Right)
+		pr.b.WriteString(")")
+	case *parser.TernaryExpression:
+		pr.b.WriteString("(")
+		pr.expr(e.Condition)
+		pr.b.WriteString(" ? ")
+		pr.expr(e.TrueExpr)
+		pr.b.WriteString(" : ")
+		pr.expr(e.FalseExpr)
+		pr.b.WriteString(")")
+	case *parser.AssignmentExpression:
+		pr.expr(e.Left)
+		pr.b.WriteString(" " + e.Operator.Value + " ")
+		pr.expr(e.Right)
+	case *parser.CallExpression:
+		if id, ok := e.Function.(*parser.Identifier); ok && id.Value == "Array" {
+			// the parser lowers array literals to calls of Array.
+			pr.b.WriteString("[")
+			pr.list(e.Arguments)
+			pr.b.WriteString("]")
+
+			return
+		}
+
+		pr.operand(e.Function)
+		pr.b.WriteString("(")
+		pr.list(e.Arguments)
+		pr.b.WriteString(")")
+	case *parser.ArrayExpression:
+		pr.b.WriteString("[")
+		pr.list(e.Elements)
+		pr.b.WriteString("]")
+	case *parser.IndexExpression:
+		pr.operand(e.Object)
+		pr.b.WriteString("[")
+		pr.expr(e.Index)
+		pr.b.WriteString("]")
+	default:
+		pr.fail("printing expression %T is not supported", e)
+	}
+}
+
+// operand prints the callee or receiver of a postfix expression, adding
+// parentheses unless it is a name, a call or already parenthesized.
+func (pr *printer) operand(e parser.Expression) {
+	switch e.(type) {
+	case *parser.Identifier, *parser.CallExpression, *parser.BinaryExpression,
+		*parser.UnaryExpression, *parser.TernaryExpression:
+		pr.expr(e)
+	default:
+		pr.b.WriteString("(")
+		pr.expr(e)
+		pr.b.WriteString(")")
+	}
+}
+
+func (pr *printer) list(es []parser.Expression) {
+	for i, e := range es {
+		if i > 0 {
+			pr.b.WriteString(", ")
+		}
+
+		pr.expr(e)
+	}
+}
+
+func (pr *printer) literal(l *parser.Literal) {
+	switch v := l.Value.(type) {
+	case int64:
+		switch {
+		case v == math.MinInt64:
+			pr.b.WriteString("((- 9223372036854775807) - 1)")
+		case v < 0:
+			pr.b.WriteString("(- " + strconv.FormatInt(-v, 10) + ")")
+		default:
+			pr.b.WriteString(strconv.FormatInt(v, 10))
+		}
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			pr.fail("float literal %v has no source form", v)
+
+			return
+		}
+
+		s := strconv.FormatFloat(math.Abs(v), 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+
+		if math.Signbit(v) {
+			s = "(- " + s + ")"
+		}
+
+		pr.b.WriteString(s)
+	case string:
+		// string literals keep their escapes as written.
+		pr.b.WriteString(`"` + v + `"`)
+	case bool:
+		pr.b.WriteString(strconv.FormatBool(v))
+	default:
+		pr.fail("printing %T literal is not supported", l.Value)
+	}
+}
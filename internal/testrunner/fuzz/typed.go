@@ -0,0 +1,438 @@
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// valueType is a type of the typed generator.
+type valueType int
+
+const (
+	typeInt valueType = iota
+	typeBool
+	typeString
+	typeIntArray
+	numValueTypes
+)
+
+func (t valueType) String() string {
+	switch t {
+	case typeInt:
+		return "int"
+	case typeBool:
+		return "bool"
+	case typeString:
+		return "string"
+	default:
+		return "[int]"
+	}
+}
+
+type typedVar struct {
+	name    string
+	typ     valueType
+	mutable bool
+}
+
+type typedFunc struct {
+	name   string
+	params []valueType
+	result valueType
+}
+
+// typedGen derives programs in which every expression has the type its
+// context expects, so they get past type checking and run to completion:
+// functions only call functions declared before them, loops run a bounded
+// number of times and cannot change their own counters, divisors are
+// nonzero literals and arrays are never empty.
+type typedGen struct {
+	r        *rand.Rand
+	b        strings.Builder
+	funcs    []typedFunc
+	scopes   [][]typedVar
+	maxDepth int
+	indent   int
+	nextVar  int
+	loops    int // loop counters and variables named so far
+	nesting  int // loops around the current statement
+}
+
+// GenerateTyped derives a well-typed program with helper functions and a
+// parameterless main.
+func GenerateTyped(r *rand.Rand, maxDepth int) string {
+	if maxDepth <= 0 {
+		maxDepth = DefaultGenDepth
+	}
+
+	g := &typedGen{r: r, maxDepth: maxDepth}
+
+	for i, n := 0, r.Intn(4); i < n; i++ {
+		g.function(typedFunc{name: fmt.Sprintf("f%d", i), result: valueType(r.Intn(int(numValueTypes)))}, true)
+	}
+
+	g.function(typedFunc{name: "main", result: -1}, false)
+
+	return g.b.String()
+}
+
+// TypedMutator returns a mutator that derives a new well-typed program for
+// every execution, or, for half of the inputs that already parse, changes
+// one literal to another of the same type. Integers stay between 1 and 9
+// so that divisors stay nonzero and loop bounds small.
+func TypedMutator(maxDepth int) Mutator {
+	return func(r *rand.Rand, in []byte) []byte {
+		if prog, ok := parseProgram(in); ok && r.Intn(2) == 0 {
+			m := &astMutation{r: r, target: collectSlots(prog), smallInts: true}
+			if m.mutateLiteral() {
+				if out, err := FormatProgram(prog); err == nil {
+					return []byte(out)
+				}
+			}
+		}
+
+		return []byte(GenerateTyped(r, maxDepth))
+	}
+}
+
+func (g *typedGen) line(s string) {
+	g.b.WriteString(strings.Repeat("    ", g.indent))
+	g.b.WriteString(s)
+	g.b.WriteString("\n")
+}
+
+// function emits fn, choosing parameters when withParams is set. A
+// negative result type means the function returns nothing.
+func (g *typedGen) function(fn typedFunc, withParams bool) {
+	g.scopes = [][]typedVar{nil}
+	g.nextVar, g.loops, g.nesting = 0, 0, 0
+
+	var params []string
+
+	if withParams {
+		for i, n := 0, g.r.Intn(4); i < n; i++ {
+			t := valueType(g.r.Intn(int(numValueTypes)))
+			v := g.declare(t, g.r.Intn(3) == 0)
+			fn.params = append(fn.params, t)
+
+			p := v.name + ": " + t.String()
+			if v.mutable {
+				p = "mut " + p
+			}
+
+			params = append(params, p)
+		}
+	}
+
+	sig := "func " + fn.name + "(" + strings.Join(params, ", ") + ")"
+	if fn.result >= 0 {
+		sig += " -> " + fn.result.String()
+	}
+
+	g.line(sig + " {")
+	g.indent++
+
+	for i, n := 0, 1+g.r.Intn(5); i < n; i++ {
+		g.stmt(0)
+	}
+
+	if fn.result >= 0 {
+		g.line("return " + g.expr(fn.result, 0) + ";")
+	}
+
+	g.indent--
+	g.line("}")
+
+	// later functions may call this one; main is never called.
+	if fn.name != "main" {
+		g.funcs = append(g.funcs, fn)
+	}
+}
+
+func (g *typedGen) declare(t valueType, mutable bool) typedVar {
+	v := typedVar{name: fmt.Sprintf("v%d", g.nextVar), typ: t, mutable: mutable}
+	g.nextVar++
+	g.scopes[len(g.scopes)-1] = append(g.scopes[len(g.scopes)-1], v)
+
+	return v
+}
+
+// vars returns the variables in scope of type t, optionally only the
+// mutable ones.
+func (g *typedGen) vars(t valueType, mutable bool) []typedVar {
+	var out []typedVar
+
+	for _, scope := range g.scopes {
+		for _, v := range scope {
+			if v.typ == t && (v.mutable || !mutable) {
+				out = append(out, v)
+			}
+		}
+	}
+
+	return out
+}
+
+func (g *typedGen) block(depth int, body func()) {
+	g.scopes = append(g.scopes, nil)
+	g.indent++
+
+	if body != nil {
+		body()
+	}
+
+	for i, n := 0, g.r.Intn(3); i < n; i++ {
+		g.stmt(depth + 1)
+	}
+
+	g.indent--
+	g.scopes = g.scopes[:len(g.scopes)-1]
+}
+
+func (g *typedGen) stmt(depth int) {
+	// past the depth budget stay with straight-line statements, and nest
+	// at most two loops so that calls in loops stay cheap.
+	choice := g.r.Intn(9)
+	if (depth >= g.maxDepth/2 && choice >= 4) || (g.nesting >= maxLoopNesting && choice >= 6) {
+		choice %= 4
+	}
+
+	switch choice {
+	case 0, 1:
+		t := valueType(g.r.Intn(int(numValueTypes)))
+		init := g.expr(t, depth)
+
+		v := g.declare(t, g.r.Intn(2) == 0)
+		if v.mutable {
+			g.line("let mut " + v.name + ": " + t.String() + " = " + init + ";")
+		} else {
+			g.line("let " + v.name + " = " + init + ";")
+		}
+	case 2:
+		g.assign(depth)
+	case 3:
+		g.line("println(" + g.expr(valueType(g.r.Intn(int(numValueTypes))), depth) + ");")
+	case 4, 5:
+		g.line("if " + g.expr(typeBool, depth) + " {")
+		g.block(depth, nil)
+
+		if g.r.Intn(2) == 0 {
+			g.line("} else {")
+			g.block(depth, nil)
+		}
+
+		g.line("}")
+	case 6:
+		// the counter is not declared in g.scopes, so the body never
+		// assigns it.
+		i := fmt.Sprintf("i%d", g.loops)
+		g.loops++
+		g.line(fmt.Sprintf("for (let mut %s = 0; %s < %d; %s += 1) {", i, i, g.r.Intn(4), i))
+		g.loop(depth, nil)
+		g.line("}")
+	case 7:
+		i := fmt.Sprintf("i%d", g.loops)
+		g.loops++
+		g.line(fmt.Sprintf("let mut %s = 0;", i))
+		g.line(fmt.Sprintf("while %s < %d {", i, g.r.Intn(4)))
+		// the increment comes first so that continue cannot skip it.
+		g.loop(depth, func() { g.line(i + " += 1;") })
+		g.line("}")
+	default:
+		x := typedVar{name: fmt.Sprintf("x%d", g.loops), typ: typeInt}
+		g.loops++
+		g.line("for " + x.name + " in " + g.expr(typeIntArray, depth) + " {")
+		g.loop(depth, func() { g.scopes[len(g.scopes)-1] = append(g.scopes[len(g.scopes)-1], x) })
+		g.line("}")
+	}
+}
+
+// maxLoopNesting bounds how deeply loops of typed programs nest.
+const maxLoopNesting = 2
+
+// loop emits a loop body that starts with prologue and sometimes with a
+// guarded break or continue.
+func (g *typedGen) loop(depth int, prologue func()) {
+	g.nesting++
+	g.block(depth, func() {
+		if prologue != nil {
+			prologue()
+		}
+
+		g.jump()
+	})
+	g.nesting--
+}
+
+// jump sometimes emits a guarded break or continue.
+func (g *typedGen) jump() {
+	switch g.r.Intn(6) {
+	case 0:
+		g.line("if " + g.expr(typeBool, g.maxDepth) + " { break; }")
+	case 1:
+		g.line("if " + g.expr(typeBool, g.maxDepth) + " { continue; }")
+	}
+}
+
+func (g *typedGen) assign(depth int) {
+	t := valueType(g.r.Intn(int(numValueTypes)))
+
+	vs := g.vars(t, true)
+	if len(vs) == 0 {
+		g.line("println(" + g.expr(t, depth) + ");")
+
+		return
+	}
+
+	v := vs[g.r.Intn(len(vs))]
+
+	switch {
+	case t == typeInt && g.r.Intn(2) == 0:
+		ops := []string{"+=", "-=", "*="}
+		g.line(v.name + " " + ops[g.r.Intn(len(ops))] + " " + g.expr(typeInt, depth) + ";")
+	case t == typeIntArray && g.r.Intn(2) == 0:
+		g.line(v.name + ".push(" + g.expr(typeInt, depth) + ");")
+	case t == typeIntArray:
+		g.line(v.name + "[0] = " + g.expr(typeInt, depth) + ";")
+	default:
+		g.line(v.name + " = " + g.expr(t, depth) + ";")
+	}
+}
+
+// expr returns an expression of type t. Compound expressions are always
+// parenthesized and prefix operators are followed by a space.
+func (g *typedGen) expr(t valueType, depth int) string {
+	leaf := depth >= g.maxDepth || g.r.Intn(2) == 0
+
+	if !leaf && g.r.Intn(6) == 0 {
+		if call, ok := g.call(t, depth); ok {
+			return call
+		}
+	}
+
+	if !leaf && g.r.Intn(8) == 0 {
+		return "(" + g.expr(typeBool, depth+1) + " ? " + g.expr(t, depth+1) + " : " + g.expr(t, depth+1) + ")"
+	}
+
+	switch t {
+	case typeInt:
+		return g.intExpr(leaf, depth)
+	case typeBool:
+		return g.boolExpr(leaf, depth)
+	case typeString:
+		if !leaf && g.r.Intn(2) == 0 {
+			return "(" + g.expr(typeString, depth+1) + " + " + g.expr(typeString, depth+1) + ")"
+		}
+
+		if vs := g.vars(typeString, false); len(vs) > 0 && g.r.Intn(2) == 0 {
+			return vs[g.r.Intn(len(vs))].name
+		}
+
+		return genString(g.r)
+	default:
+		if vs := g.vars(typeIntArray, false); len(vs) > 0 && (leaf || g.r.Intn(2) == 0) {
+			return vs[g.r.Intn(len(vs))].name
+		}
+
+		elems := make([]string, 1+g.r.Intn(3))
+		for i := range elems {
+			elems[i] = g.expr(typeInt, depth+1)
+		}
+
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+}
+
+func (g *typedGen) intExpr(leaf bool, depth int) string {
+	if leaf {
+		if vs := g.vars(typeInt, false); len(vs) > 0 && g.r.Intn(2) == 0 {
+			return vs[g.r.Intn(len(vs))].name
+		}
+
+		return strconv.Itoa(g.r.Intn(100))
+	}
+
+	switch g.r.Intn(7) {
+	case 0, 1:
+		ops := []string{"+", "-", "*", "&", "|", "^"}
+
+		return "(" + g.expr(typeInt, depth+1) + " " + ops[g.r.Intn(len(ops))] + " " + g.expr(typeInt, depth+1) + ")"
+	case 2:
+		ops := []string{"/", "%"}
+
+		return "(" + g.expr(typeInt, depth+1) + " " + ops[g.r.Intn(len(ops))] + " " + strconv.Itoa(1+g.r.Intn(9)) + ")"
+	case 3:
+		ops := []string{"<<", ">>"}
+
+		return "(" + g.expr(typeInt, depth+1) + " " + ops[g.r.Intn(len(ops))] + " " + strconv.Itoa(g.r.Intn(64)) + ")"
+	case 4:
+		return "(- " + g.expr(typeInt, depth+1) + ")"
+	case 5:
+		if g.r.Intn(2) == 0 {
+			return "len(" + g.expr(typeString, depth+1) + ")"
+		}
+
+		return g.expr(typeIntArray, depth+1) + ".len()"
+	default:
+		// arrays are never empty, so index 0 is always in range.
+		a := g.expr(typeIntArray, depth+1)
+		if strings.HasPrefix(a, "[") {
+			a = "(" + a + ")"
+		}
+
+		return a + "[0]"
+	}
+}
+
+func (g *typedGen) boolExpr(leaf bool, depth int) string {
+	if leaf {
+		if vs := g.vars(typeBool, false); len(vs) > 0 && g.r.Intn(2) == 0 {
+			return vs[g.r.Intn(len(vs))].name
+		}
+
+		return strconv.FormatBool(g.r.Intn(2) == 0)
+	}
+
+	switch g.r.Intn(4) {
+	case 0:
+		ops := []string{"<", "<=", ">", ">=", "==", "!="}
+
+		return "(" + g.expr(typeInt, depth+1) + " " + ops[g.r.Intn(len(ops))] + " " + g.expr(typeInt, depth+1) + ")"
+	case 1:
+		ops := []string{"==", "!="}
+
+		return "(" + g.expr(typeString, depth+1) + " " + ops[g.r.Intn(len(ops))] + " " + g.expr(typeString, depth+1) + ")"
+	case 2:
+		ops := []string{"&&", "||"}
+
+		return "(" + g.expr(typeBool, depth+1) + " " + ops[g.r.Intn(len(ops))] + " " + g.expr(typeBool, depth+1) + ")"
+	default:
+		return "(! " + g.expr(typeBool, depth+1) + ")"
+	}
+}
+
+// call returns a call of an earlier function whose result has type t.
+func (g *typedGen) call(t valueType, depth int) (string, bool) {
+	var fns []typedFunc
+
+	for _, fn := range g.funcs {
+		if fn.result == t {
+			fns = append(fns, fn)
+		}
+	}
+
+	if len(fns) == 0 {
+		return "", false
+	}
+
+	fn := fns[g.r.Intn(len(fns))]
+	args := make([]string, len(fn.params))
+
+	for i, p := range fn.params {
+		args[i] = g.expr(p, depth+1)
+	}
+
+	return fn.name + "(" + strings.Join(args, ", ") + ")", true
+}
@@ -0,0 +1,601 @@
+package mockgen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/lexer"
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// TraitOptions controls mock generation for Orizon traits.
+type TraitOptions struct {
+	// Trait to mock.
+	TraitName string
+	// Name of the generated struct. If empty, use the trait name + "Mock".
+	MockName string
+	// Destination path for writing the generated file. If empty, only return the string.
+	Destination string
+	// Orizon source files searched for the trait declaration.
+	Sources []string
+}
+
+// GenerateTrait produces an Orizon mock struct for the specified trait.
+//
+// The mock implements the trait, records every call, matches calls against
+// expectations set up with expect_<method> and answers them with the
+// configured value or closure. verify fails on the first expectation that
+// was not met and is meant to be called at the end of a test. The output
+// only uses syntax the Orizon parser accepts.
+//
+// Recording a call mutates the mock, so every method must take &mut self;
+// Orizon has no interior mutability to record calls through &self.
+// Associated functions are stubbed out with a panic.
+func GenerateTrait(opts TraitOptions) (string, error) {
+	if strings.TrimSpace(opts.TraitName) == "" {
+		return "", errors.New("TraitName is required")
+	}
+
+	if len(opts.Sources) == 0 {
+		return "", errors.New("at least one .oriz source is required")
+	}
+
+	var (
+		trait    *parser.TraitDeclaration
+		firstErr error
+	)
+
+	for _, path := range opts.Sources {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		prog, errs := parser.NewParser(lexer.NewWithFilename(string(src), path), path).Parse()
+		if len(errs) > 0 && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", path, errs[0])
+		}
+
+		if trait = findTrait(prog, opts.TraitName); trait != nil {
+			break
+		}
+	}
+
+	if trait == nil {
+		if firstErr != nil {
+			return "", fmt.Errorf("trait %q not found: %w", opts.TraitName, firstErr)
+		}
+
+		return "", fmt.Errorf("trait %q not found in provided sources", opts.TraitName)
+	}
+
+	mockName := opts.MockName
+	if mockName == "" {
+		mockName = trait.Name.Value + "Mock"
+	}
+
+	code, err := renderTraitMock(mockName, trait)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Destination != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.Destination), 0o755); err != nil {
+			return "", err
+		}
+
+		if err := os.WriteFile(opts.Destination, []byte(code), 0o644); err != nil {
+			return "", err
+		}
+	}
+
+	return code, nil
+}
+
+func findTrait(prog *parser.Program, name string) *parser.TraitDeclaration {
+	if prog == nil {
+		return nil
+	}
+
+	for _, d := range prog.Declarations {
+		if t, ok := d.(*parser.TraitDeclaration); ok && t.Name != nil && t.Name.Value == name {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// traitMethod is a trait method reduced to what the mock needs. Arguments
+// are recorded as owned values: a &T argument is recorded as T. Types spell
+// Self::Item as Item, the mock parameter.
+type traitMethod struct {
+	name     string
+	receiver string // "&mut self", or "" for associated functions
+	args     []traitArg
+	ret      string // return type with Self:: dropped
+}
+
+type traitArg struct {
+	name  string
+	typ   string // declared type
+	owned string // recorded type
+	ref   bool
+}
+
+func collectTraitMethods(trait *parser.TraitDeclaration) ([]traitMethod, error) {
+	var ms []traitMethod
+
+	for _, m := range trait.Methods {
+		if len(m.Generics) > 0 {
+			return nil, fmt.Errorf("method %s.%s is generic and cannot be mocked", trait.Name.Value, m.Name.Value)
+		}
+
+		tm := traitMethod{name: m.Name.Value}
+		if m.ReturnType != nil {
+			tm.ret = unself(m.ReturnType.String())
+		}
+
+		for i, p := range m.Parameters {
+			if i == 0 && p.Name.Value == "self" {
+				tm.receiver = receiverString(p)
+				if tm.receiver != "&mut self" {
+					return nil, fmt.Errorf("method %s.%s takes %s; mocks record calls and need &mut self",
+						trait.Name.Value, m.Name.Value, tm.receiver)
+				}
+
+				continue
+			}
+
+			a := traitArg{name: p.Name.Value, typ: p.TypeSpec.String(), owned: p.TypeSpec.String()}
+			if r, ok := p.TypeSpec.(*parser.ReferenceType); ok {
+				a.ref = true
+				a.owned = ownedType(r.Inner)
+			}
+
+			a.owned = unself(a.owned)
+
+			tm.args = append(tm.args, a)
+		}
+
+		ms = append(ms, tm)
+	}
+
+	return ms, nil
+}
+
+func unself(t string) string { return strings.ReplaceAll(t, "Self::", "") }
+
+func receiverString(p *parser.Parameter) string {
+	if r, ok := p.TypeSpec.(*parser.ReferenceType); ok {
+		if r.IsMutable {
+			return "&mut self"
+		}
+
+		return "&self"
+	}
+
+	if p.IsMut {
+		return "mut self"
+	}
+
+	return "self"
+}
+
+// ownedType is the type to_owned returns for a reference to t.
+func ownedType(t parser.Type) string {
+	switch tt := t.(type) {
+	case *parser.BasicType:
+		if tt.Name == "str" {
+			return "String"
+		}
+	case *parser.ArrayType:
+		if tt.IsDynamic {
+			return "[" + tt.ElementType.String() + "]"
+		}
+	}
+
+	return t.String()
+}
+
+func renderTraitMock(mockName string, trait *parser.TraitDeclaration) (string, error) {
+	methods, err := collectTraitMethods(trait)
+	if err != nil {
+		return "", err
+	}
+
+	// Trait generics and associated types both become parameters of the
+	// mock, so that a test picks concrete types when it creates one.
+	var params, names []string
+
+	for _, g := range trait.Generics {
+		params = append(params, g.String())
+		if g.Kind == parser.GenericParamLifetime {
+			names = append(names, "'"+g.Lifetime)
+		} else {
+			names = append(names, g.Name.Value)
+		}
+	}
+
+	for _, at := range trait.AssociatedTypes {
+		p := at.Name.Value
+		if len(at.Bounds) > 0 {
+			bounds := make([]string, len(at.Bounds))
+			for i, b := range at.Bounds {
+				bounds[i] = b.String()
+			}
+
+			p += ": " + strings.Join(bounds, " + ")
+		}
+
+		params = append(params, p)
+		names = append(names, at.Name.Value)
+	}
+
+	decl, use := "", ""
+	if len(params) > 0 {
+		decl = "<" + strings.Join(params, ", ") + ">"
+		use = "<" + strings.Join(names, ", ") + ">"
+	}
+
+	traitUse := trait.Name.Value
+	if len(trait.Generics) > 0 {
+		traitUse += "<" + strings.Join(names[:len(trait.Generics)], ", ") + ">"
+	}
+
+	argName := mockName + "Arg"
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "// Code generated by orizon mockgen. DO NOT EDIT.\n\n")
+
+	// Matchers.
+	fmt.Fprintf(&b, "// %s matches an argument of a call to %s.\n", argName, mockName)
+	fmt.Fprintf(&b, "pub enum %s<T> {\n", argName)
+	b.WriteString("    // Any matches every value.\n    Any,\n")
+	b.WriteString("    // Eq matches values equal to the one given.\n    Eq(T),\n")
+	b.WriteString("    // Where matches values for which the predicate holds.\n    Where(func(&T) -> bool),\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "impl<T: PartialEq> %s<T> {\n", argName)
+	b.WriteString("    func matches(&self, v: &T) -> bool {\n")
+	b.WriteString("        match (self) {\n")
+	fmt.Fprintf(&b, "            %s::Any => return true,\n", argName)
+	fmt.Fprintf(&b, "            %s::Eq(want) => return want == v,\n", argName)
+	fmt.Fprintf(&b, "            %s::Where(pred) => return pred(v),\n", argName)
+	b.WriteString("        }\n    }\n}\n\n")
+
+	// Call records and expectations.
+	for _, m := range methods {
+		if m.receiver == "" {
+			continue
+		}
+
+		call, exp := mockName+camel(m.name)+"Call", mockName+camel(m.name)
+		cdecl, _ := genericsUsed(params, names, argTypes(m)...)
+		edecl, euse := genericsUsed(params, names, append(argTypes(m), m.ret)...)
+
+		fmt.Fprintf(&b, "// %s records one call to %s.\n", call, m.name)
+
+		if len(m.args) == 0 {
+			fmt.Fprintf(&b, "pub struct %s {}\n\n", call)
+		} else {
+			fmt.Fprintf(&b, "pub struct %s%s {\n", call, cdecl)
+
+			for _, a := range m.args {
+				fmt.Fprintf(&b, "    pub %s: %s,\n", a.name, a.owned)
+			}
+
+			b.WriteString("}\n\n")
+		}
+
+		fmt.Fprintf(&b, "// %s is an expected call to %s.\n", exp, m.name)
+		fmt.Fprintf(&b, "pub struct %s%s {\n", exp, edecl)
+
+		for _, a := range m.args {
+			fmt.Fprintf(&b, "    %s: %s<%s>,\n", a.name, argName, a.owned)
+		}
+
+		if m.ret != "" {
+			fmt.Fprintf(&b, "    returns: Option<%s>,\n", m.ret)
+		}
+
+		fmt.Fprintf(&b, "    returning: Option<func(%s) -> %s>,\n", closureParams(m), retOrUnit(m))
+		b.WriteString("    times: i32, // -1 for any number of calls\n")
+		b.WriteString("    calls: i32,\n")
+		b.WriteString("}\n\n")
+
+		fmt.Fprintf(&b, "impl%s %s%s {\n", edecl, exp, euse)
+
+		if m.ret != "" {
+			b.WriteString("    // returns answers matching calls with a clone of value.\n")
+			fmt.Fprintf(&b, "    func returns(&mut self, value: %s) -> &mut Self {\n", m.ret)
+			b.WriteString("        self.returns = Some(value);\n        return self;\n    }\n\n")
+		}
+
+		b.WriteString("    // returning answers matching calls with the result of f.\n")
+		fmt.Fprintf(&b, "    func returning(&mut self, f: func(%s) -> %s) -> &mut Self {\n", closureParams(m), retOrUnit(m))
+		b.WriteString("        self.returning = Some(f);\n        return self;\n    }\n\n")
+		b.WriteString("    // times expects exactly n matching calls; the default is one.\n")
+		b.WriteString("    func times(&mut self, n: i32) -> &mut Self {\n")
+		b.WriteString("        self.times = n;\n        return self;\n    }\n\n")
+		b.WriteString("    // any_times accepts any number of matching calls, including none.\n")
+		b.WriteString("    func any_times(&mut self) -> &mut Self {\n")
+		b.WriteString("        self.times = -1;\n        return self;\n    }\n")
+		b.WriteString("}\n\n")
+	}
+
+	// The mock itself.
+	fmt.Fprintf(&b, "// %s is a test double for %s.\n", mockName, trait.Name.Value)
+	fmt.Fprintf(&b, "pub struct %s%s {\n", mockName, decl)
+
+	for _, m := range methods {
+		if m.receiver == "" {
+			continue
+		}
+
+		_, cuse := genericsUsed(params, names, argTypes(m)...)
+		_, euse := genericsUsed(params, names, append(argTypes(m), m.ret)...)
+
+		fmt.Fprintf(&b, "    %s_calls: [%s%s],\n", m.name, mockName+camel(m.name)+"Call", cuse)
+		fmt.Fprintf(&b, "    %s_expectations: [%s%s],\n", m.name, mockName+camel(m.name), euse)
+	}
+
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(&b, "impl%s %s%s {\n", decl, mockName, use)
+	b.WriteString("    func new() -> Self {\n")
+	fmt.Fprintf(&b, "        var mock: %s%s;\n", mockName, use)
+
+	for _, m := range methods {
+		if m.receiver == "" {
+			continue
+		}
+
+		fmt.Fprintf(&b, "        mock.%s_calls = [];\n", m.name)
+		fmt.Fprintf(&b, "        mock.%s_expectations = [];\n", m.name)
+	}
+
+	b.WriteString("        return mock;\n    }\n")
+
+	for _, m := range methods {
+		if m.receiver == "" {
+			continue
+		}
+
+		exp := mockName + camel(m.name)
+		_, cuse := genericsUsed(params, names, argTypes(m)...)
+		_, euse := genericsUsed(params, names, append(argTypes(m), m.ret)...)
+
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "    // expect_%s expects a call to %s with matching arguments.\n", m.name, m.name)
+
+		ps := make([]string, len(m.args))
+		for i, a := range m.args {
+			ps[i] = fmt.Sprintf("%s: %s<%s>", a.name, argName, a.owned)
+		}
+
+		fmt.Fprintf(&b, "    func expect_%s(&mut self%s) -> &mut %s%s {\n", m.name, prefixComma(ps), exp, euse)
+		fmt.Fprintf(&b, "        var e: %s%s;\n", exp, euse)
+
+		for _, a := range m.args {
+			fmt.Fprintf(&b, "        e.%s = %s;\n", a.name, a.name)
+		}
+
+		if m.ret != "" {
+			b.WriteString("        e.returns = None;\n")
+		}
+
+		b.WriteString("        e.returning = None;\n        e.times = 1;\n        e.calls = 0;\n")
+		fmt.Fprintf(&b, "        self.%s_expectations.push(e);\n", m.name)
+		fmt.Fprintf(&b, "        return &mut self.%s_expectations[len(self.%s_expectations) - 1];\n    }\n\n", m.name, m.name)
+
+		fmt.Fprintf(&b, "    // %s_calls returns the recorded calls to %s in order.\n", m.name, m.name)
+		fmt.Fprintf(&b, "    func %s_calls(&self) -> &[%s%s] {\n", m.name, exp+"Call", cuse)
+		fmt.Fprintf(&b, "        return &self.%s_calls;\n    }\n", m.name)
+	}
+
+	b.WriteString("\n    // verify fails unless every expectation got its number of calls.\n")
+	b.WriteString("    func verify(&self) {\n")
+
+	for _, m := range methods {
+		if m.receiver == "" {
+			continue
+		}
+
+		fmt.Fprintf(&b, "        for e in self.%s_expectations {\n", m.name)
+		b.WriteString("            if e.times >= 0 {\n")
+		fmt.Fprintf(&b, "                assert_eq(e.calls, e.times, \"%s: calls to %s\");\n", mockName, m.name)
+		b.WriteString("            }\n        }\n")
+	}
+
+	b.WriteString("    }\n}\n\n")
+
+	// Trait implementation. Impl blocks cannot bind associated types, so
+	// Self::T is spelled as the mock's parameter T.
+	fmt.Fprintf(&b, "impl%s %s for %s%s {\n", decl, traitUse, mockName, use)
+
+	for _, at := range trait.AssociatedTypes {
+		fmt.Fprintf(&b, "    // Self::%s is the %s parameter of %s.\n", at.Name.Value, at.Name.Value, mockName)
+	}
+
+	for i, m := range methods {
+		if i > 0 || len(trait.AssociatedTypes) > 0 {
+			b.WriteString("\n")
+		}
+
+		renderTraitMethod(&b, mockName, m)
+	}
+
+	b.WriteString("}\n")
+
+	return splitAngles(b.String()), nil
+}
+
+// splitAngles separates the closing brackets of nested generic arguments,
+// which the lexer would otherwise read as a >> operator. The mock contains
+// no shift operators.
+func splitAngles(code string) string {
+	for strings.Contains(code, ">>") {
+		code = strings.ReplaceAll(code, ">>", "> >")
+	}
+
+	return code
+}
+
+func renderTraitMethod(b *strings.Builder, mockName string, m traitMethod) {
+	ps := make([]string, 0, len(m.args)+1)
+	if m.receiver != "" {
+		ps = append(ps, m.receiver)
+	}
+
+	for _, a := range m.args {
+		ps = append(ps, a.name+": "+unself(a.typ))
+	}
+
+	sig := fmt.Sprintf("    func %s(%s)", m.name, strings.Join(ps, ", "))
+	if m.ret != "" {
+		sig += " -> " + m.ret
+	}
+
+	b.WriteString(sig + " {\n")
+
+	if m.receiver == "" {
+		fmt.Fprintf(b, "        panic(\"%s: %s has no receiver and cannot be mocked\");\n    }\n", mockName, m.name)
+
+		return
+	}
+
+	fmt.Fprintf(b, "        var call: %s;\n", mockName+camel(m.name)+"Call")
+
+	for _, a := range m.args {
+		if a.ref {
+			fmt.Fprintf(b, "        call.%s = %s.to_owned();\n", a.name, a.name)
+		} else {
+			fmt.Fprintf(b, "        call.%s = %s;\n", a.name, a.name)
+		}
+	}
+
+	conds := []string{"e.times < 0 || e.calls < e.times"}
+	if len(m.args) > 0 {
+		conds[0] = "(" + conds[0] + ")"
+	}
+
+	for _, a := range m.args {
+		conds = append(conds, fmt.Sprintf("e.%s.matches(&call.%s)", a.name, a.name))
+	}
+
+	// The call moves into the record; closures get its arguments from there.
+	refs := make([]string, len(m.args))
+	for i, a := range m.args {
+		refs[i] = "&c." + a.name
+	}
+
+	b.WriteString("        var i = 0;\n")
+	fmt.Fprintf(b, "        while i < len(self.%s_expectations) {\n", m.name)
+	fmt.Fprintf(b, "            let e = &mut self.%s_expectations[i];\n", m.name)
+	fmt.Fprintf(b, "            if %s {\n", strings.Join(conds, " && "))
+	b.WriteString("                e.calls += 1;\n")
+	fmt.Fprintf(b, "                self.%s_calls.push(call);\n", m.name)
+
+	if len(m.args) > 0 {
+		fmt.Fprintf(b, "                let c = &self.%s_calls[len(self.%s_calls) - 1];\n", m.name, m.name)
+	}
+
+	b.WriteString("                match (e.returning) {\n")
+
+	if m.ret != "" {
+		fmt.Fprintf(b, "                    Some(f) => return f(%s),\n", strings.Join(refs, ", "))
+		b.WriteString("                    None => {},\n")
+		b.WriteString("                }\n")
+		b.WriteString("                match (e.returns) {\n")
+		b.WriteString("                    Some(v) => return v.clone(),\n")
+		fmt.Fprintf(b, "                    None => panic(\"%s: no return value for %s\"),\n", mockName, m.name)
+		b.WriteString("                }\n")
+	} else {
+		fmt.Fprintf(b, "                    Some(f) => f(%s),\n", strings.Join(refs, ", "))
+		b.WriteString("                    None => {},\n")
+		b.WriteString("                }\n")
+		b.WriteString("                return;\n")
+	}
+
+	b.WriteString("            }\n\n            i += 1;\n        }\n\n")
+	fmt.Fprintf(b, "        panic(\"%s: unexpected call to %s\");\n", mockName, m.name)
+	b.WriteString("    }\n")
+}
+
+func argTypes(m traitMethod) []string {
+	ts := make([]string, len(m.args))
+	for i, a := range m.args {
+		ts[i] = a.owned
+	}
+
+	return ts
+}
+
+// genericsUsed narrows the mock parameters to those the types mention, for
+// a helper struct, and returns its declaration and use lists.
+func genericsUsed(params, names []string, types ...string) (decl, use string) {
+	var ps, ns []string
+
+	for i, n := range names {
+		re := regexp.MustCompile(`(^|[^\w'])` + regexp.QuoteMeta(n) + `\b`)
+
+		for _, t := range types {
+			if re.MatchString(t) {
+				ps, ns = append(ps, params[i]), append(ns, n)
+
+				break
+			}
+		}
+	}
+
+	if len(ps) == 0 {
+		return "", ""
+	}
+
+	return "<" + strings.Join(ps, ", ") + ">", "<" + strings.Join(ns, ", ") + ">"
+}
+
+func closureParams(m traitMethod) string {
+	ps := make([]string, len(m.args))
+	for i, a := range m.args {
+		ps[i] = "&" + a.owned
+	}
+
+	return strings.Join(ps, ", ")
+}
+
+func retOrUnit(m traitMethod) string {
+	if m.ret == "" {
+		return "()"
+	}
+
+	return m.ret
+}
+
+func prefixComma(ps []string) string {
+	if len(ps) == 0 {
+		return ""
+	}
+
+	return ", " + strings.Join(ps, ", ")
+}
+
+// camel turns a snake_case method name into CamelCase.
+func camel(s string) string {
+	var b strings.Builder
+
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,238 @@
+package mockgen
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/orizon-lang/orizon/internal/lexer"
+	"github.com/orizon-lang/orizon/internal/parser"
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
+)
+
+func writeOriz(t *testing.T, src string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "storage.oriz")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// mustParse fails the test unless the generated mock is valid Orizon.
+func mustParse(t *testing.T, code string) {
+	t.Helper()
+
+	if _, errs := parser.NewParser(lexer.NewWithFilename(code, "mock.oriz"), "mock.oriz").Parse(); len(errs) > 0 {
+		t.Fatalf("generated mock does not parse: %v\n%s", errs, code)
+	}
+}
+
+func TestGenerateTrait_Storage(t *testing.T) {
+	src := writeOriz(t, `pub trait Storage {
+    func get(&mut self, key: &str) -> Option<String>;
+    func put(&mut self, key: String, value: &[u8]) -> bool;
+    func clear(&mut self);
+}
+`)
+	dest := filepath.Join(t.TempDir(), "mocks", "storage_mock.oriz")
+
+	code, err := GenerateTrait(TraitOptions{TraitName: "Storage", Sources: []string{src}, Destination: dest})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"pub enum StorageMockArg<T> {",
+		"impl Storage for StorageMock {",
+		// signatures are copied from the trait.
+		"    func get(&mut self, key: &str) -> Option<String> {",
+		"    func put(&mut self, key: String, value: &[u8]) -> bool {",
+		"    func clear(&mut self) {",
+		// references are recorded as owned values.
+		"        call.key = key.to_owned();",
+		"        call.value = value.to_owned();",
+		// the call moves into the record and closures read it back.
+		"                self.put_calls.push(call);",
+		"                let c = &self.put_calls[len(self.put_calls) - 1];",
+		"                    Some(f) => return f(&c.key, &c.value),",
+		"                self.clear_calls.push(call);",
+		"    pub key: String,",
+		"    pub value: [u8],",
+		"    func expect_get(&mut self, key: StorageMockArg<String>) -> &mut StorageMockGet {",
+		"    func expect_put(&mut self, key: StorageMockArg<String>, value: StorageMockArg<[u8]>) -> &mut StorageMockPut {",
+		"    func returns(&mut self, value: Option<String>) -> &mut Self {",
+		"    func returning(&mut self, f: func(&String, &[u8]) -> bool) -> &mut Self {",
+		"            if (e.times < 0 || e.calls < e.times) && e.key.matches(&call.key) && e.value.matches(&call.value) {",
+		"    func put_calls(&self) -> &[StorageMockPutCall] {",
+		`                assert_eq(e.calls, e.times, "StorageMock: calls to clear");`,
+		`        panic("StorageMock: unexpected call to get");`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated mock lacks %q", want)
+		}
+	}
+
+	mustParse(t, code)
+
+	// clear has no return value to configure.
+	if strings.Contains(code, "no return value for clear") {
+		t.Errorf("unit method should not require a return value")
+	}
+
+	// Call structs have no Clone impl.
+	if strings.Contains(code, "call.clone()") {
+		t.Errorf("generated mock clones its call record")
+	}
+
+	written, err := os.ReadFile(dest)
+	if err != nil || string(written) != code {
+		t.Fatalf("destination not written: %v", err)
+	}
+}
+
+func TestGenerateTrait_GenericsAndAssociatedTypes(t *testing.T) {
+	src := writeOriz(t, `trait Source<T: Clone> {
+    type Item: Display;
+    func next(&mut self) -> Option<Self::Item>;
+    func peek(&mut self, n: T) -> bool;
+    func reset();
+}
+`)
+
+	code, err := GenerateTrait(TraitOptions{TraitName: "Source", MockName: "FakeSource", Sources: []string{src}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"pub struct FakeSource<T: Clone, Item: Display> {",
+		"impl<T: Clone, Item: Display> Source<T> for FakeSource<T, Item> {",
+		"    // Self::Item is the Item parameter of FakeSource.",
+		"    func next(&mut self) -> Option<Item> {",
+		// helper structs only take the parameters they use.
+		"pub struct FakeSourceNextCall {}",
+		"pub struct FakeSourceNext<Item: Display> {",
+		"pub struct FakeSourcePeekCall<T: Clone> {",
+		// owned arguments are moved, not cloned.
+		"        call.n = n;",
+		`panic("FakeSource: reset has no receiver and cannot be mocked");`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated mock lacks %q", want)
+		}
+	}
+
+	mustParse(t, code)
+}
+
+func TestGenerateTrait_Errors(t *testing.T) {
+	src := writeOriz(t, "trait Visitor { func visit<T>(&self, node: T); }\n")
+
+	if _, err := GenerateTrait(TraitOptions{TraitName: "Visitor", Sources: []string{src}}); err == nil || !strings.Contains(err.Error(), "generic") {
+		t.Fatalf("expected generic method error, got %v", err)
+	}
+
+	if _, err := GenerateTrait(TraitOptions{TraitName: "Missing", Sources: []string{src}}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	// A mock cannot record calls through a shared reference.
+	src = writeOriz(t, "trait Reader { func read(&self) -> i32; }\n")
+
+	if _, err := GenerateTrait(TraitOptions{TraitName: "Reader", Sources: []string{src}}); err == nil || !strings.Contains(err.Error(), "takes &self; mocks record calls and need &mut self") {
+		t.Fatalf("expected receiver error, got %v", err)
+	}
+}
+
+func TestGenerateTrait_RunsUnderTheTestRunner(t *testing.T) {
+	const trait = `pub trait Storage {
+    func get(&mut self, key: &str) -> Option<String>;
+    func put(&mut self, key: String, value: i32) -> bool;
+    func clear(&mut self);
+}
+`
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "storage.oriz")
+
+	if err := os.WriteFile(src, []byte(trait), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GenerateTrait(TraitOptions{TraitName: "Storage", Sources: []string{src}, Destination: filepath.Join(dir, "storage_mock.oriz")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := `func big(key: &String, value: &i32) -> bool {
+    return value > 10;
+}
+
+#[test]
+func test_expectations() {
+    let mut mock = StorageMock::new();
+    mock.expect_get(StorageMockArg::Eq("a")).returns(Some("x"));
+    mock.expect_put(StorageMockArg::Any, StorageMockArg::Eq(5)).returns(true).times(2);
+    mock.expect_put(StorageMockArg::Any, StorageMockArg::Any).returning(big);
+    mock.expect_clear().any_times();
+    assert_eq(mock.get("a"), Some("x"));
+    assert(mock.put("k", 5));
+    assert(mock.put("k", 5));
+    assert(mock.put("j", 11));
+    assert_eq(len(mock.put_calls()), 3);
+    assert_eq(mock.put_calls()[2].key, "j");
+    assert_eq(mock.put_calls()[2].value, 11);
+    mock.verify();
+}
+
+#[test]
+func test_unmet_expectation() {
+    let mut mock = StorageMock::new();
+    mock.expect_put(StorageMockArg::Any, StorageMockArg::Any).returns(true).times(2);
+    mock.put("k", 1);
+    mock.verify();
+}
+
+#[test]
+func test_unexpected_call() {
+    let mut mock = StorageMock::new();
+    mock.expect_get(StorageMockArg::Where(is_empty)).returns(None);
+    mock.get("a");
+}
+
+func is_empty(key: &String) -> bool {
+    return len(key) == 0;
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "storage_test.oriz"), []byte(tests), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := native.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make(map[string]error)
+	for _, c := range pkg.Cases {
+		results[c.Name] = pkg.Run(context.Background(), c, io.Discard)
+	}
+
+	if err := results["test_expectations"]; err != nil {
+		t.Fatalf("test_expectations: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"test_unmet_expectation": "StorageMock: calls to put",
+		"test_unexpected_call":   "StorageMock: unexpected call to get",
+	} {
+		if err := results[name]; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected a failure with %q, got %v", name, want, err)
+		}
+	}
+}
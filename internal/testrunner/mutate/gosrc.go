@@ -0,0 +1,329 @@
+package mutate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	goast "go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goPackage is the part of `go list -json` output the Go driver needs.
+type goPackage struct {
+	Dir          string
+	ImportPath   string
+	GoFiles      []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+}
+
+// goComparisonFlips and goArithmeticSwaps mirror the operator tables of
+// internal/ast for go/token operators.
+var goComparisonFlips = map[token.Token]token.Token{
+	token.EQL: token.NEQ, token.NEQ: token.EQL,
+	token.LSS: token.GEQ, token.GEQ: token.LSS,
+	token.GTR: token.LEQ, token.LEQ: token.GTR,
+}
+
+var goArithmeticSwaps = map[token.Token]token.Token{
+	token.ADD: token.SUB, token.SUB: token.ADD,
+	token.MUL: token.QUO, token.QUO: token.MUL, token.REM: token.MUL,
+	token.ADD_ASSIGN: token.SUB_ASSIGN, token.SUB_ASSIGN: token.ADD_ASSIGN,
+	token.MUL_ASSIGN: token.QUO_ASSIGN, token.QUO_ASSIGN: token.MUL_ASSIGN, token.REM_ASSIGN: token.MUL_ASSIGN,
+	token.INC: token.DEC, token.DEC: token.INC,
+}
+
+// goSite is a mutation site in a Go file.
+type goSite struct {
+	apply       func()
+	function    string
+	operator    string
+	original    string
+	replacement string
+	pos         token.Pos
+}
+
+// goJobs enumerates the mutants of the non-test files of the Go packages
+// matched by the patterns. A mutant runs the tests of its package, with the
+// mutated file swapped in through go build's -overlay.
+func goJobs(ctx context.Context, opts Options) ([]job, []string, error) {
+	pkgs, err := goList(ctx, opts.Packages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		jobs    []job
+		skipped []string
+	)
+
+	for _, pkg := range pkgs {
+		if len(pkg.TestGoFiles)+len(pkg.XTestGoFiles) == 0 {
+			skipped = append(skipped, pkg.ImportPath+": no test files")
+
+			continue
+		}
+
+		start := time.Now()
+
+		if out, err := goTest(ctx, pkg.Dir, "", opts.Timeout); err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: tests fail without mutations: %s", pkg.ImportPath, firstLine(out)))
+
+			continue
+		}
+
+		timeout := min(opts.Timeout, 10*time.Since(start)+10*time.Second)
+
+		for _, name := range pkg.GoFiles {
+			path := filepath.Join(pkg.Dir, name)
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			fset := token.NewFileSet()
+
+			file, err := goparser.ParseFile(fset, path, src, goparser.ParseComments)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for i, site := range goSites(fset, file) {
+				pos := fset.Position(site.pos)
+				index := i
+				jobs = append(jobs, job{
+					mutant: Mutant{
+						Package:     pkg.ImportPath,
+						File:        path,
+						Function:    site.function,
+						Operator:    site.operator,
+						Original:    site.original,
+						Replacement: site.replacement,
+						Line:        pos.Line,
+						Col:         pos.Column,
+					},
+					timeout: timeout,
+					run: func(ctx context.Context) (Status, string) {
+						return runGoMutant(ctx, pkg.Dir, path, src, index, timeout)
+					},
+				})
+			}
+		}
+	}
+
+	return jobs, skipped, nil
+}
+
+func goList(ctx context.Context, patterns []string) ([]goPackage, error) {
+	args := append([]string{"list", "-json"}, patterns...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+
+	var stderr bytes.Buffer
+
+	cmd.Stderr = &stderr
+
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []goPackage
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for dec.More() {
+		var p goPackage
+		if err := dec.Decode(&p); err != nil {
+			return nil, err
+		}
+
+		pkgs = append(pkgs, p)
+	}
+
+	return pkgs, nil
+}
+
+// goSites lists the mutation sites inside the function bodies of file, in
+// source order.
+func goSites(fset *token.FileSet, file *goast.File) []goSite {
+	var sites []goSite
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*goast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = nodeString(fset, fn.Recv.List[0].Type) + "." + name
+		}
+
+		add := func(s goSite) {
+			s.function = name
+			sites = append(sites, s)
+		}
+
+		goast.Inspect(fn.Body, func(n goast.Node) bool {
+			switch n := n.(type) {
+			case *goast.BlockStmt:
+				for i, st := range n.List {
+					if _, ok := st.(*goast.ExprStmt); ok {
+						block, idx := n, i
+						add(goSite{pos: st.Pos(), operator: "drop-statement", original: nodeString(fset, st), apply: func() {
+							block.List = append(block.List[:idx:idx], block.List[idx+1:]...)
+						}})
+					}
+				}
+			case *goast.BinaryExpr:
+				if repl, ok := goComparisonFlips[n.Op]; ok {
+					add(goSite{pos: n.OpPos, operator: "flip-comparison", original: n.Op.String(), replacement: repl.String(), apply: func() { n.Op = repl }})
+				} else if repl, ok := goArithmeticSwaps[n.Op]; ok {
+					add(goSite{pos: n.OpPos, operator: "swap-arithmetic", original: n.Op.String(), replacement: repl.String(), apply: func() { n.Op = repl }})
+				}
+			case *goast.AssignStmt:
+				if repl, ok := goArithmeticSwaps[n.Tok]; ok {
+					add(goSite{pos: n.TokPos, operator: "swap-arithmetic", original: n.Tok.String(), replacement: repl.String(), apply: func() { n.Tok = repl }})
+				}
+			case *goast.IncDecStmt:
+				repl := goArithmeticSwaps[n.Tok]
+				add(goSite{pos: n.TokPos, operator: "swap-arithmetic", original: n.Tok.String(), replacement: repl.String(), apply: func() { n.Tok = repl }})
+			case *goast.BasicLit:
+				if n.Kind != token.INT {
+					break
+				}
+
+				v, err := strconv.ParseInt(n.Value, 0, 64)
+				if err != nil {
+					break
+				}
+
+				repl := strconv.FormatInt(v+1, 10)
+				add(goSite{pos: n.Pos(), operator: "change-constant", original: n.Value, replacement: repl, apply: func() { n.Value = repl }})
+			case *goast.Ident:
+				if n.Name == "true" || n.Name == "false" {
+					repl := strconv.FormatBool(n.Name != "true")
+					add(goSite{pos: n.Pos(), operator: "change-constant", original: n.Name, replacement: repl, apply: func() { n.Name = repl }})
+				}
+			}
+
+			return true
+		})
+	}
+
+	return sites
+}
+
+// runGoMutant writes the index-th mutant of the file at path to a temporary
+// file and runs the package tests against it.
+func runGoMutant(ctx context.Context, dir, path string, src []byte, index int, timeout time.Duration) (Status, string) {
+	fset := token.NewFileSet()
+
+	file, err := goparser.ParseFile(fset, path, src, goparser.ParseComments)
+	if err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	sites := goSites(fset, file)
+	if index >= len(sites) {
+		return StatusInvalid, fmt.Sprintf("mutation %d out of range (%d sites)", index, len(sites))
+	}
+
+	sites[index].apply()
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	tmp, err := os.MkdirTemp("", "orizon-mutant-")
+	if err != nil {
+		return StatusInvalid, err.Error()
+	}
+	defer os.RemoveAll(tmp)
+
+	mutated := filepath.Join(tmp, filepath.Base(path))
+	if err := os.WriteFile(mutated, buf.Bytes(), 0o644); err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {path: mutated}})
+	if err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	overlayPath := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	out, err := goTest(ctx, dir, overlayPath, timeout)
+
+	switch {
+	case err == nil:
+		return StatusSurvived, ""
+	case ctx.Err() != nil:
+		return StatusTimeout, ""
+	case strings.Contains(out, "[build failed]") || strings.Contains(out, "[setup failed]"):
+		return StatusInvalid, firstLine(out)
+	default:
+		return StatusKilled, firstFailure(out)
+	}
+}
+
+// goTest runs the tests of the package in dir, with an optional overlay.
+func goTest(ctx context.Context, dir, overlay string, timeout time.Duration) (string, error) {
+	args := []string{"test", "-count=1", "-failfast", "-timeout=" + timeout.String()}
+	if overlay != "" {
+		args = append(args, "-overlay="+overlay)
+	}
+
+	args = append(args, ".")
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.WaitDelay = time.Second
+
+	out, err := cmd.CombinedOutput()
+
+	return string(out), err
+}
+
+func nodeString(fset *token.FileSet, n goast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, n); err != nil {
+		return ""
+	}
+
+	return buf.String()
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
+
+// firstFailure returns the first "--- FAIL" line of go test output.
+func firstFailure(out string) string {
+	for _, l := range strings.Split(out, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(l), "--- FAIL") {
+			return strings.TrimSpace(l)
+		}
+	}
+
+	return firstLine(out)
+}
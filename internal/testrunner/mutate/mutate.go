@@ -0,0 +1,262 @@
+// Package mutate implements mutation testing: it applies small rewrites
+// (flipped comparisons, swapped arithmetic, dropped statements, changed
+// constants) to the code under test one at a time and checks that the
+// tests notice. Orizon sources are mutated through internal/ast and run by
+// the native test interpreter; Go packages are mutated with go/ast and run
+// by go test through an -overlay.
+package mutate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a single mutant when Options.Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// Options configures a mutation run.
+type Options struct {
+	// JSONPath and HTMLPath, when set, receive the report.
+	JSONPath string
+	HTMLPath string
+	// Packages are package patterns: .oriz directories with Native, Go
+	// package patterns otherwise.
+	Packages []string
+	// Parallel is the number of mutants run at once (default GOMAXPROCS).
+	Parallel int
+	// Timeout is the upper bound for running the tests of one mutant.
+	Timeout time.Duration
+	Native  bool
+}
+
+// Status is the outcome of a mutant.
+type Status string
+
+const (
+	// StatusKilled means a test failed against the mutant.
+	StatusKilled Status = "killed"
+	// StatusSurvived means every affected test passed against the mutant.
+	StatusSurvived Status = "survived"
+	// StatusTimeout means the tests did not finish; counted as killed.
+	StatusTimeout Status = "timeout"
+	// StatusUncovered means no test reaches the mutated code.
+	StatusUncovered Status = "uncovered"
+	// StatusInvalid means the mutant does not compile.
+	StatusInvalid Status = "invalid"
+)
+
+// Mutant is one mutation and its outcome.
+type Mutant struct {
+	Package     string        `json:"package"`
+	File        string        `json:"file"`
+	Function    string        `json:"function"`
+	Operator    string        `json:"operator"`
+	Original    string        `json:"original"`
+	Replacement string        `json:"replacement"`
+	Status      Status        `json:"status"`
+	Detail      string        `json:"detail,omitempty"`
+	Elapsed     time.Duration `json:"elapsed"`
+	ID          int           `json:"id"`
+	Line        int           `json:"line"`
+	Col         int           `json:"col"`
+}
+
+// Location returns file:line:col.
+func (m Mutant) Location() string {
+	return fmt.Sprintf("%s:%d:%d", m.File, m.Line, m.Col)
+}
+
+// Report is the result of a mutation run.
+type Report struct {
+	Mutants []Mutant `json:"mutants"`
+	// Skipped lists code that could not be mutated, with the reason.
+	Skipped   []string `json:"skipped,omitempty"`
+	Killed    int      `json:"killed"`
+	Survived  int      `json:"survived"`
+	TimedOut  int      `json:"timed_out"`
+	Uncovered int      `json:"uncovered"`
+	Invalid   int      `json:"invalid"`
+}
+
+// Score returns the share of valid mutants the tests detected, in percent.
+// Uncovered mutants count as undetected.
+func (r *Report) Score() float64 {
+	valid := len(r.Mutants) - r.Invalid
+	if valid == 0 {
+		return 100
+	}
+
+	return 100 * float64(r.Killed+r.TimedOut) / float64(valid)
+}
+
+// Survivors returns the mutants the tests did not detect.
+func (r *Report) Survivors() []Mutant {
+	var out []Mutant
+
+	for _, m := range r.Mutants {
+		if m.Status == StatusSurvived || m.Status == StatusUncovered {
+			out = append(out, m)
+		}
+	}
+
+	return out
+}
+
+// job is one mutant waiting to run. run reports its status and a detail
+// line; it must honour ctx, which carries the mutant's deadline.
+type job struct {
+	run     func(ctx context.Context) (Status, string)
+	mutant  Mutant
+	timeout time.Duration
+}
+
+// Run mutates the selected packages, runs the affected tests of every
+// mutant and writes a summary with the surviving mutants to out.
+func Run(ctx context.Context, opts Options, out io.Writer) (*Report, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.Parallel <= 0 {
+		opts.Parallel = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		jobs    []job
+		skipped []string
+		err     error
+	)
+
+	if opts.Native {
+		jobs, skipped, err = orizonJobs(ctx, opts)
+	} else {
+		jobs, skipped, err = goJobs(ctx, opts)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{Skipped: skipped, Mutants: execute(ctx, jobs, opts.Parallel)}
+	rep.count()
+
+	printSummary(out, rep)
+
+	if opts.JSONPath != "" {
+		if err := rep.WriteJSON(opts.JSONPath); err != nil {
+			return rep, err
+		}
+	}
+
+	if opts.HTMLPath != "" {
+		if err := rep.WriteHTML(opts.HTMLPath); err != nil {
+			return rep, err
+		}
+	}
+
+	return rep, nil
+}
+
+// execute runs the jobs on parallel workers and returns the mutants in job
+// order.
+func execute(ctx context.Context, jobs []job, parallel int) []Mutant {
+	out := make([]Mutant, len(jobs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range next {
+				j := jobs[i]
+				m := j.mutant
+				m.ID = i + 1
+
+				if m.Status == "" {
+					mctx, cancel := context.WithTimeout(ctx, j.timeout)
+					start := time.Now()
+					m.Status, m.Detail = j.run(mctx)
+					m.Elapsed = time.Since(start)
+
+					if m.Status != StatusKilled && m.Status != StatusInvalid && mctx.Err() == context.DeadlineExceeded {
+						m.Status = StatusTimeout
+					}
+
+					cancel()
+				}
+
+				out[i] = m
+			}
+		}()
+	}
+
+	for i := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		next <- i
+	}
+
+	close(next)
+	wg.Wait()
+
+	// Mutants never started because ctx ended are left out.
+	done := out[:0]
+
+	for _, m := range out {
+		if m.ID != 0 {
+			done = append(done, m)
+		}
+	}
+
+	return done
+}
+
+func (r *Report) count() {
+	for _, m := range r.Mutants {
+		switch m.Status {
+		case StatusKilled:
+			r.Killed++
+		case StatusSurvived:
+			r.Survived++
+		case StatusTimeout:
+			r.TimedOut++
+		case StatusUncovered:
+			r.Uncovered++
+		case StatusInvalid:
+			r.Invalid++
+		}
+	}
+}
+
+func printSummary(out io.Writer, r *Report) {
+	survivors := r.Survivors()
+	sort.SliceStable(survivors, func(i, j int) bool {
+		if survivors[i].File != survivors[j].File {
+			return survivors[i].File < survivors[j].File
+		}
+
+		return survivors[i].Line < survivors[j].Line
+	})
+
+	for _, m := range survivors {
+		fmt.Fprintf(out, "SURVIVED %s: %s %q -> %q in %s (%s)\n", m.Location(), m.Operator, m.Original, m.Replacement, m.Function, m.Status)
+	}
+
+	for _, s := range r.Skipped {
+		fmt.Fprintf(out, "skipped %s\n", s)
+	}
+
+	fmt.Fprintf(out, "mutants: %d  killed: %d  timeout: %d  survived: %d  uncovered: %d  invalid: %d  score: %.1f%%\n",
+		len(r.Mutants), r.Killed, r.TimedOut, r.Survived, r.Uncovered, r.Invalid, r.Score())
+}
@@ -0,0 +1,197 @@
+package mutate
+
+import (
+	"context"
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+const orizonLib = `
+func clamp(x: int, hi: int) -> int {
+    if x > hi { return hi; }
+    return x;
+}
+
+func add(a: int, b: int) -> int {
+    return a + b;
+}
+
+func count(n: int) -> int {
+    var i = 0;
+    while i < n { i += 1; }
+    return i;
+}
+
+func untested(a: int) -> int {
+    return a * 2;
+}
+`
+
+const orizonTests = `
+#[test]
+func test_clamp() {
+    assert_eq(clamp(5, 3), 3);
+    assert_eq(clamp(1, 3), 1);
+}
+
+#[test]
+func test_add() {
+    assert_eq(add(2, 0), 2);
+}
+
+#[test]
+func test_count() {
+    assert_eq(count(3), 3);
+    assert_eq(count(0), 0);
+}
+`
+
+func TestRunOrizon(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"lib.oriz": orizonLib, "lib_test.oriz": orizonTests})
+	reports := t.TempDir()
+
+	var out strings.Builder
+
+	rep, err := Run(context.Background(), Options{
+		Packages: []string{dir},
+		Native:   true,
+		Timeout:  5 * time.Second,
+		JSONPath: filepath.Join(reports, "mutants.json"),
+		HTMLPath: filepath.Join(reports, "mutants.html"),
+	}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Status)
+	for _, m := range rep.Mutants {
+		got[m.Function+" "+m.Original+" -> "+m.Replacement] = m.Status
+	}
+
+	for key, want := range map[string]Status{
+		"clamp > -> <=":       StatusKilled,
+		"add + -> -":          StatusSurvived,
+		"count < -> >=":       StatusKilled,
+		"count 0 -> 1":        StatusKilled,
+		"count += -> -=":      StatusTimeout,
+		"untested * -> /":     StatusUncovered,
+		"untested 2 -> 3":     StatusUncovered,
+		"count (i += 1); -> ": StatusTimeout,
+	} {
+		if got[key] != want {
+			t.Errorf("%s: status %q, want %q", key, got[key], want)
+		}
+	}
+
+	if !strings.Contains(out.String(), "SURVIVED "+filepath.Join(dir, "lib.oriz")+":8:") {
+		t.Errorf("summary lacks the surviving mutant location:\n%s", out.String())
+	}
+
+	b, err := os.ReadFile(filepath.Join(reports, "mutants.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Survivors []Mutant `json:"survivors"`
+		Total     int      `json:"total"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Total != len(rep.Mutants) || len(decoded.Survivors) != len(rep.Survivors()) {
+		t.Errorf("json report: total %d, survivors %d", decoded.Total, len(decoded.Survivors))
+	}
+
+	html, err := os.ReadFile(filepath.Join(reports, "mutants.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(html), "<td>add</td>") {
+		t.Errorf("html report lacks the surviving mutant")
+	}
+}
+
+func TestGoSites(t *testing.T) {
+	const src = `package p
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	log(a)
+	a += 10
+	return b
+}
+`
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, s := range goSites(fset, file) {
+		got = append(got, s.operator+" "+s.original+" -> "+s.replacement)
+	}
+
+	want := "drop-statement log(a) -> |flip-comparison > -> <=|swap-arithmetic += -> -=|change-constant 10 -> 11"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("sites = %s", strings.Join(got, "|"))
+	}
+}
+
+func TestRunGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a Go package per mutant")
+	}
+
+	dir := writeFiles(t, map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.21\n",
+		"max.go":      "package m\n\nfunc Max(a, b int) int {\n\tif a > b {\n\t\treturn a\n\t}\n\treturn b\n}\n",
+		"max_test.go": "package m\n\nimport \"testing\"\n\nfunc TestMax(t *testing.T) {\n\tif Max(2, 1) != 2 || Max(1, 2) != 2 {\n\t\tt.Fatal(\"max\")\n\t}\n}\n",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var out strings.Builder
+
+	rep, err := Run(context.Background(), Options{Packages: []string{"./..."}, Timeout: time.Minute}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rep.Mutants) != 1 || rep.Mutants[0].Status != StatusKilled || rep.Mutants[0].Line != 4 {
+		t.Fatalf("unexpected mutants: %+v\n%s", rep.Mutants, out.String())
+	}
+}
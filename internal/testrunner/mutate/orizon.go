@@ -0,0 +1,175 @@
+package mutate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/ast"
+	"github.com/orizon-lang/orizon/internal/astbridge"
+	"github.com/orizon-lang/orizon/internal/parser"
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
+)
+
+// orizonJobs enumerates the mutants of the non-test functions of the .oriz
+// packages. Function bodies go through astbridge into internal/ast, where
+// the mutation operators live, and come back as parser trees the native
+// interpreter runs. Each mutant runs the tests whose call graph reaches the
+// mutated function.
+func orizonJobs(ctx context.Context, opts Options) ([]job, []string, error) {
+	dirs, err := native.FindPackages(opts.Packages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		jobs    []job
+		skipped []string
+	)
+
+	for _, dir := range dirs {
+		pkg, err := native.Load(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		isCase := make(map[string]bool, len(pkg.Cases))
+		for _, c := range pkg.Cases {
+			isCase[c.Name] = true
+		}
+
+		base := &baseline{pkg: pkg, runs: make(map[string]baselineRun)}
+
+		for _, name := range pkg.FunctionNames() {
+			file := pkg.FunctionFile(name)
+			if isCase[name] || strings.HasSuffix(file, "_test.oriz") {
+				continue
+			}
+
+			fn := pkg.Function(name)
+
+			body, err := astbridge.FromParserBlock(fn.Body)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s:%d: %s: %v", file, fn.Span.Start.Line, name, err))
+
+				continue
+			}
+
+			sites := ast.MutationSites(body)
+			if len(sites) == 0 {
+				continue
+			}
+
+			cases, elapsed := base.cases(ctx, name, opts.Timeout)
+			timeout := min(opts.Timeout, 10*elapsed+time.Second)
+
+			for i, site := range sites {
+				m := Mutant{
+					Package:     dir,
+					File:        file,
+					Function:    name,
+					Operator:    site.Kind.String(),
+					Original:    site.Original,
+					Replacement: site.Replacement,
+					Line:        site.Span.Start.Line,
+					Col:         site.Span.Start.Column,
+				}
+				if len(cases) == 0 {
+					m.Status = StatusUncovered
+				}
+
+				index := i
+				jobs = append(jobs, job{
+					mutant:  m,
+					timeout: timeout,
+					run: func(ctx context.Context) (Status, string) {
+						return runOrizonMutant(ctx, pkg, fn, index, cases)
+					},
+				})
+			}
+		}
+	}
+
+	return jobs, skipped, nil
+}
+
+func runOrizonMutant(ctx context.Context, pkg *native.Package, fn *parser.FunctionDeclaration, index int, cases []native.Case) (Status, string) {
+	// Each mutant starts from a fresh conversion; the transformer rewrites
+	// in place.
+	body, err := astbridge.FromParserBlock(fn.Body)
+	if err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	if _, err := (&ast.MutationTransformer{Index: index}).Transform(body); err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	mutated, err := astbridge.ToParserBlock(body)
+	if err != nil {
+		return StatusInvalid, err.Error()
+	}
+
+	decl := *fn
+	decl.Body = mutated
+	variant := pkg.WithFunction(&decl)
+
+	for _, c := range cases {
+		if err := variant.Run(ctx, c, io.Discard); err != nil {
+			if ctx.Err() != nil {
+				return StatusTimeout, c.Name
+			}
+
+			return StatusKilled, fmt.Sprintf("%s: %v", c.Name, err)
+		}
+	}
+
+	return StatusSurvived, ""
+}
+
+// baseline runs each test once against the unmodified package. Tests that
+// already fail cannot tell mutants apart and are left out.
+type baseline struct {
+	pkg  *native.Package
+	runs map[string]baselineRun
+}
+
+type baselineRun struct {
+	elapsed time.Duration
+	passed  bool
+}
+
+// cases returns the passing tests that reach fn and their total run time.
+func (b *baseline) cases(ctx context.Context, fn string, timeout time.Duration) ([]native.Case, time.Duration) {
+	var (
+		out   []native.Case
+		total time.Duration
+	)
+
+	for _, c := range b.pkg.CasesReaching(fn) {
+		if c.Kind != native.KindTest || c.Ignored {
+			continue
+		}
+
+		run, ok := b.runs[c.Name]
+		if !ok {
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			start := time.Now()
+			err := b.pkg.Run(cctx, c, io.Discard)
+			run = baselineRun{elapsed: time.Since(start), passed: err == nil}
+
+			cancel()
+
+			b.runs[c.Name] = run
+		}
+
+		if run.passed {
+			out = append(out, c)
+			total += run.elapsed
+		}
+	}
+
+	return out, total
+}
@@ -0,0 +1,100 @@
+package mutate
+
+import (
+	"encoding/json"
+	"html/template"
+	"os"
+	"path/filepath"
+)
+
+// jsonReport is the on-disk form of a Report, with the derived figures.
+type jsonReport struct {
+	*Report
+	Survivors []Mutant `json:"survivors"`
+	Score     float64  `json:"score"`
+	Total     int      `json:"total"`
+}
+
+// WriteJSON writes the report, including every mutant, as JSON.
+func (r *Report) WriteJSON(path string) error {
+	b, err := json.MarshalIndent(jsonReport{Report: r, Survivors: r.Survivors(), Score: r.Score(), Total: len(r.Mutants)}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, append(b, '\n'))
+}
+
+var htmlReport = template.Must(template.New("mutation").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mutation testing report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.code { font-family: monospace; white-space: pre; }
+.survived, .uncovered { background: #fdd; }
+.killed, .timeout { background: #dfd; }
+.invalid { color: #888; }
+</style>
+</head>
+<body>
+<h1>Mutation testing report</h1>
+<p>Score: <b>{{printf "%.1f" .Score}}%</b> &mdash;
+{{len .Mutants}} mutants, {{.Killed}} killed, {{.TimedOut}} timed out, {{.Survived}} survived, {{.Uncovered}} uncovered, {{.Invalid}} invalid</p>
+<h2>Surviving mutants</h2>
+{{if .Survivors}}<table>
+<tr><th>Location</th><th>Function</th><th>Operator</th><th>Original</th><th>Mutant</th><th>Status</th></tr>
+{{range .Survivors}}<tr class="{{.Status}}"><td>{{.Location}}</td><td>{{.Function}}</td><td>{{.Operator}}</td><td class="code">{{.Original}}</td><td class="code">{{.Replacement}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+<h2>All mutants</h2>
+<table>
+<tr><th>#</th><th>Location</th><th>Operator</th><th>Original</th><th>Mutant</th><th>Status</th><th>Detail</th></tr>
+{{range .Mutants}}<tr class="{{.Status}}"><td>{{.ID}}</td><td>{{.Location}}</td><td>{{.Operator}}</td><td class="code">{{.Original}}</td><td class="code">{{.Replacement}}</td><td>{{.Status}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{if .Skipped}}<h2>Skipped</h2>
+<ul>{{range .Skipped}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes a self-contained HTML page listing the surviving mutants
+// first, then every mutant.
+func (r *Report) WriteHTML(path string) error {
+	f, err := create(path)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		*Report
+		Survivors []Mutant
+		Score     float64
+	}{r, r.Survivors(), r.Score()}
+
+	if err := htmlReport.Execute(f, data); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+func create(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return os.Create(path)
+}
+
+func writeFile(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o644)
+}
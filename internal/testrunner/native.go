@@ -0,0 +1,200 @@
+package testrunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
+)
+
+// runNative runs the #[test] functions of the .oriz files in dir, and the
+// #[bench] functions when BenchPattern selects them. Outcomes are reported
+// as the events `go test -json` would produce, so retries, fail-fast, JUnit
+// and the summaries treat both kinds of package alike.
+func (r *Runner) runNative(ctx context.Context, dir string, out io.Writer) (PackageResult, error) {
+	runRe, err := compileOptional(r.opts.RunPattern)
+	if err != nil {
+		return PackageResult{}, err
+	}
+
+	benchRe, err := compileOptional(r.opts.BenchPattern)
+	if err != nil {
+		return PackageResult{}, err
+	}
+
+	pkg, err := native.Load(dir)
+	if err != nil {
+		return PackageResult{}, err
+	}
+
+	if r.cover != nil {
+		r.cover.Instrument(pkg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+
+	c := r.newPackageCollector(dir, out)
+	emit := func(action, test, output string, elapsed time.Duration) {
+		c.add(Event{Time: time.Now(), Action: action, Package: dir, Test: test, Output: output, Elapsed: elapsed.Seconds()})
+	}
+
+	start := time.Now()
+	ran, failed := 0, false
+
+	for _, tc := range pkg.Cases {
+		if tc.Kind == native.KindBench && (benchRe == nil || !benchRe.MatchString(tc.Name)) {
+			continue
+		}
+
+		if tc.Kind == native.KindTest && runRe != nil && !runRe.MatchString(tc.Name) {
+			continue
+		}
+
+		if ctx.Err() != nil || (failed && r.opts.FailFast) {
+			break
+		}
+
+		ran++
+
+		emit("run", tc.Name, "", 0)
+		emit("output", tc.Name, fmt.Sprintf("=== RUN   %s\n", tc.Name), 0)
+
+		if tc.Ignored {
+			emit("output", tc.Name, fmt.Sprintf("--- SKIP: %s (0.00s)\n", tc.Name), 0)
+			emit("skip", tc.Name, "", 0)
+
+			continue
+		}
+
+		began := time.Now()
+
+		var (
+			buf    bytes.Buffer
+			runErr error
+		)
+
+		if tc.Kind == native.KindBench {
+			var res native.BenchResult
+			if res, runErr = pkg.Bench(ctx, tc, r.opts.BenchTime); runErr == nil {
+				fmt.Fprintf(&buf, "%s\t%s\n", tc.Name, res)
+			}
+		} else {
+			runErr = pkg.Run(ctx, tc, &buf)
+		}
+
+		elapsed := time.Since(began)
+
+		for _, line := range splitLines(buf.String()) {
+			emit("output", tc.Name, line, 0)
+		}
+
+		if runErr != nil {
+			failed = true
+
+			for _, line := range splitLines(runErr.Error() + "\n") {
+				emit("output", tc.Name, "    "+line, 0)
+			}
+
+			emit("output", tc.Name, fmt.Sprintf("--- FAIL: %s (%.2fs)\n", tc.Name, elapsed.Seconds()), 0)
+			emit("fail", tc.Name, "", elapsed)
+
+			continue
+		}
+
+		emit("output", tc.Name, fmt.Sprintf("--- PASS: %s (%.2fs)\n", tc.Name, elapsed.Seconds()), 0)
+		emit("pass", tc.Name, "", elapsed)
+	}
+
+	elapsed := time.Since(start)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		emit("output", "", fmt.Sprintf("panic: test timed out after %s\n", r.opts.Timeout), 0)
+		emit("output", "", fmt.Sprintf("FAIL\t%s\t%.3fs\n", dir, elapsed.Seconds()), 0)
+		emit("fail", "", "", elapsed)
+
+		// make the package fail even when the timeout hit between tests.
+		if !failed {
+			c.pr.Failed++
+		}
+	case ran == 0:
+		emit("output", "", fmt.Sprintf("?   \t%s\t[no tests to run]\n", dir), 0)
+		emit("skip", "", "", elapsed)
+	case failed:
+		emit("output", "", "FAIL\n", 0)
+		emit("output", "", fmt.Sprintf("FAIL\t%s\t%.3fs\n", dir, elapsed.Seconds()), 0)
+		emit("fail", "", "", elapsed)
+	default:
+		emit("output", "", "PASS\n", 0)
+		emit("output", "", fmt.Sprintf("ok  \t%s\t%.3fs\n", dir, elapsed.Seconds()), 0)
+		emit("pass", "", "", elapsed)
+	}
+
+	return c.pr, nil
+}
+
+// listNativeTests prints the names of the tests and benchmarks in dir.
+func (r *Runner) listNativeTests(dir string, out io.Writer) {
+	if out == nil {
+		return
+	}
+
+	pkg, err := native.Load(dir)
+	if err != nil {
+		fmt.Fprintf(out, "%s: %v\n", dir, err)
+
+		return
+	}
+
+	for _, tc := range pkg.Cases {
+		fmt.Fprintln(out, tc.Name)
+	}
+
+	fmt.Fprintf(out, "ok  \t%s\t0.000s\n", dir)
+}
+
+// nativeHasMatchingFile reports whether dir holds a .oriz file whose path
+// matches re.
+func nativeHasMatchingFile(dir string, re *regexp.Regexp) (bool, error) {
+	files, err := native.SourceFiles(dir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, f := range files {
+		if re.MatchString(f) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func compileOptional(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// splitLines splits s into lines that keep their trailing newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,127 @@
+package native
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+type builtin func(in *Interpreter, args []Value, at parser.Position) (Value, error)
+
+// builtins are the functions available to Orizon tests without a
+// declaration. A function of the package with the same name shadows them.
+var builtins = map[string]builtin{
+	"Array":     builtinArray,
+	"assert":    builtinAssert,
+	"assert_eq": builtinAssertEq,
+	"assert_ne": builtinAssertNe,
+	"panic":     builtinPanic,
+	"print":     builtinPrint,
+	"println":   builtinPrintln,
+	"len":       builtinLen,
+}
+
+// The parser turns array literals into calls of Array.
+func builtinArray(_ *Interpreter, args []Value, _ parser.Position) (Value, error) {
+	return &Array{Elems: args}, nil
+}
+
+// assert(cond) and assert(cond, message).
+func builtinAssert(_ *Interpreter, args []Value, at parser.Position) (Value, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("assert takes 1 or 2 arguments, got %d", len(args))}
+	}
+
+	ok, isBool := args[0].(bool)
+	if !isBool {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("assert condition is %s, not bool", typeName(args[0]))}
+	}
+
+	if ok {
+		return nil, nil
+	}
+
+	msg := "assertion failed"
+	if len(args) == 2 {
+		msg += ": " + display(args[1])
+	}
+
+	return nil, &Failure{Pos: at, Message: msg}
+}
+
+// assert_eq(left, right) and assert_eq(left, right, message).
+func builtinAssertEq(_ *Interpreter, args []Value, at parser.Position) (Value, error) {
+	return nil, compare("assert_eq", "==", true, args, at)
+}
+
+// assert_ne(left, right) and assert_ne(left, right, message).
+func builtinAssertNe(_ *Interpreter, args []Value, at parser.Position) (Value, error) {
+	return nil, compare("assert_ne", "!=", false, args, at)
+}
+
+func compare(name, op string, wantEqual bool, args []Value, at parser.Position) error {
+	if len(args) != 2 && len(args) != 3 {
+		return &Failure{Pos: at, Message: fmt.Sprintf("%s takes 2 or 3 arguments, got %d", name, len(args))}
+	}
+
+	if Equal(args[0], args[1]) == wantEqual {
+		return nil
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "assertion `left %s right` failed", op)
+
+	if len(args) == 3 {
+		b.WriteString(": " + display(args[2]))
+	}
+
+	fmt.Fprintf(&b, "\n  left: %s\n right: %s", Format(args[0]), Format(args[1]))
+
+	return &Failure{Pos: at, Message: b.String()}
+}
+
+func builtinPanic(_ *Interpreter, args []Value, at parser.Position) (Value, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = display(a)
+	}
+
+	msg := "explicit panic"
+	if len(parts) > 0 {
+		msg = "panicked: " + strings.Join(parts, " ")
+	}
+
+	return nil, &Failure{Pos: at, Message: msg}
+}
+
+func builtinPrint(in *Interpreter, args []Value, _ parser.Position) (Value, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = display(a)
+	}
+
+	_, err := io.WriteString(in.out, strings.Join(parts, " "))
+
+	return nil, err
+}
+
+func builtinPrintln(in *Interpreter, args []Value, at parser.Position) (Value, error) {
+	if _, err := builtinPrint(in, args, at); err != nil {
+		return nil, err
+	}
+
+	_, err := io.WriteString(in.out, "\n")
+
+	return nil, err
+}
+
+func builtinLen(_ *Interpreter, args []Value, at parser.Position) (Value, error) {
+	if len(args) != 1 {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("len takes 1 argument, got %d", len(args))}
+	}
+
+	return length(args[0], at)
+}
@@ -0,0 +1,215 @@
+package native
+
+import (
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// Function returns the declaration of the named function, or nil.
+func (p *Package) Function(name string) *parser.FunctionDeclaration {
+	return p.funcs[name]
+}
+
+// FunctionFile returns the file that declares the named function.
+func (p *Package) FunctionFile(name string) string {
+	if fn, ok := p.funcs[name]; ok {
+		return p.files[fn]
+	}
+
+	return ""
+}
+
+// WithFunction returns a copy of the package in which fn replaces the
+// function of the same name. The receiver is left untouched, so variants of
+// one function can run side by side.
+func (p *Package) WithFunction(fn *parser.FunctionDeclaration) *Package {
+	name := fn.Name.Value
+
+	q := *p
+	q.funcs = make(map[string]*parser.FunctionDeclaration, len(p.funcs))
+	q.files = make(map[parser.Node]string, len(p.files)+1)
+
+	for k, v := range p.funcs {
+		q.funcs[k] = v
+	}
+
+	for k, v := range p.files {
+		q.files[k] = v
+	}
+
+	if prev, ok := p.funcs[name]; ok {
+		q.files[fn] = p.files[prev]
+	}
+
+	q.funcs[name] = fn
+	q.Cases = make([]Case, len(p.Cases))
+
+	for i, c := range p.Cases {
+		if c.Name == name {
+			c.decl = fn
+		}
+
+		q.Cases[i] = c
+	}
+
+	return &q
+}
+
+// CasesReaching returns the cases that may call the named function,
+// directly or through other functions and methods of the package. A
+// function is taken to be reached wherever its name is referenced, and a
+// method wherever a method of that name is called on any value, which
+// over-approximates calls through function values.
+func (p *Package) CasesReaching(name string) []Case {
+	var out []Case
+
+	for _, c := range p.Cases {
+		if p.reaches(c.Name, name) {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+func (p *Package) reaches(from, to string) bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur == to {
+			return true
+		}
+
+		fn := p.lookup(cur)
+		if fn == nil {
+			continue
+		}
+
+		for _, callee := range p.references(fn.Body) {
+			if !seen[callee] {
+				seen[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+
+	return false
+}
+
+// lookup returns the function or, for Type::name, the method of that name.
+func (p *Package) lookup(name string) *parser.FunctionDeclaration {
+	if typ, method, ok := strings.Cut(name, "::"); ok {
+		return p.methods[typ][method]
+	}
+
+	return p.funcs[name]
+}
+
+// references lists the package functions named in body, and as Type::name
+// the methods it names or calls.
+func (p *Package) references(body *parser.BlockStatement) []string {
+	var names []string
+
+	var expr func(e parser.Expression)
+
+	var stmt func(s parser.Statement)
+
+	stmt = func(s parser.Statement) {
+		switch s := s.(type) {
+		case *parser.BlockStatement:
+			if s != nil {
+				for _, st := range s.Statements {
+					stmt(st)
+				}
+			}
+		case *parser.ExpressionStatement:
+			expr(s.Expression)
+		case *parser.VariableDeclaration:
+			expr(s.Initializer)
+		case *parser.ReturnStatement:
+			expr(s.Value)
+		case *parser.IfStatement:
+			expr(s.Condition)
+			stmt(s.ThenStmt)
+			stmt(s.ElseStmt)
+		case *parser.WhileStatement:
+			expr(s.Condition)
+			stmt(s.Body)
+		case *parser.ForStatement:
+			stmt(s.Init)
+			expr(s.Condition)
+			stmt(s.Update)
+			stmt(s.Body)
+		case *parser.ForInStatement:
+			expr(s.Iterable)
+			stmt(s.Body)
+		case *parser.MatchStatement:
+			expr(s.Expression)
+
+			for _, arm := range s.Arms {
+				expr(arm.Guard)
+				stmt(arm.Body)
+			}
+		}
+	}
+
+	// method adds every method called name.
+	method := func(name string) {
+		for typ, methods := range p.methods {
+			if _, ok := methods[name]; ok {
+				names = append(names, typ+"::"+name)
+			}
+		}
+	}
+
+	expr = func(e parser.Expression) {
+		switch e := e.(type) {
+		case *parser.Identifier:
+			if p.lookup(e.Value) != nil {
+				names = append(names, e.Value)
+			}
+		case *parser.UnaryExpression:
+			expr(e.Operand)
+		case *parser.BinaryExpression:
+			expr(e.Left)
+
+			if e.Operator.Value != "." {
+				expr(e.Right)
+			} else if id, ok := e.Right.(*parser.Identifier); ok {
+				method(id.Value)
+			}
+		case *parser.MemberExpression:
+			expr(e.Object)
+			method(e.Member.Value)
+		case *parser.AssignmentExpression:
+			expr(e.Left)
+			expr(e.Right)
+		case *parser.TernaryExpression:
+			expr(e.Condition)
+			expr(e.TrueExpr)
+			expr(e.FalseExpr)
+		case *parser.CallExpression:
+			expr(e.Function)
+
+			for _, a := range e.Arguments {
+				expr(a)
+			}
+		case *parser.ArrayExpression:
+			for _, el := range e.Elements {
+				expr(el)
+			}
+		case *parser.IndexExpression:
+			expr(e.Object)
+			expr(e.Index)
+		}
+	}
+
+	stmt(body)
+
+	return names
+}
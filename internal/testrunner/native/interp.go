@@ -0,0 +1,1072 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// Value is a runtime value of the interpreter: nil (unit), int64, float64,
+// string, bool, *Array, *Struct, *Variant or *Function.
+type Value any
+
+// Array is a mutable array value; arrays are shared by reference.
+type Array struct {
+	Elems []Value
+}
+
+// Function is a function value.
+type Function struct {
+	Decl *parser.FunctionDeclaration
+}
+
+// Failure is a failed assertion or a runtime error raised while running
+// Orizon code. It makes the test that raised it fail.
+type Failure struct {
+	Message string
+	Pos     parser.Position
+}
+
+func (f *Failure) Error() string {
+	if f.Pos.Line == 0 {
+		return f.Message
+	}
+
+	return f.Pos.String() + ": " + f.Message
+}
+
+// maxCallDepth bounds recursion so that runaway tests fail instead of
+// exhausting the Go stack.
+const maxCallDepth = 2048
+
+// control tells enclosing statements how execution left a statement.
+type control int
+
+const (
+	ctlNone control = iota
+	ctlReturn
+	ctlBreak
+	ctlContinue
+)
+
+type binding struct {
+	value   Value
+	mutable bool
+}
+
+type scope struct {
+	parent *scope
+	vars   map[string]*binding
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, vars: make(map[string]*binding)}
+}
+
+func (s *scope) lookup(name string) *binding {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.vars[name]; ok {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// Interpreter evaluates the functions of one package. It is not safe for
+// concurrent use; every test gets its own interpreter.
+type Interpreter struct {
+	ctx     context.Context
+	out     io.Writer
+	tracer  Tracer
+	pkg     *Package
+	funcs   map[string]*parser.FunctionDeclaration
+	globals *scope
+	label   string
+	fn      string // function being executed, for the tracer
+	depth   int
+}
+
+// Tracer observes the control flow of an interpreter, the way coverage
+// counters in compiled code would. Implementations must be safe for
+// concurrent use: tests of a package may run in parallel.
+type Tracer interface {
+	// Enter is called when function fn starts running.
+	Enter(fn string)
+	// Branch is called with the value of every condition operand the
+	// interpreter evaluates in fn: a condition itself, or the operands of
+	// the !, && and || it is built from. at is where the operand starts.
+	Branch(fn string, at parser.Position, value bool)
+}
+
+// NewInterpreter creates an interpreter for the package and evaluates its
+// top-level variables in order. println and print write to out.
+func (p *Package) NewInterpreter(ctx context.Context, out io.Writer) (*Interpreter, error) {
+	if out == nil {
+		out = io.Discard
+	}
+
+	in := &Interpreter{ctx: ctx, out: out, tracer: p.Tracer, pkg: p, funcs: p.funcs, globals: newScope(nil)}
+
+	for _, g := range p.globals {
+		if _, _, err := in.exec(g, in.globals); err != nil {
+			return nil, p.locate(err, g)
+		}
+	}
+
+	return in, nil
+}
+
+// Call calls the function name with args.
+func (in *Interpreter) Call(name string, args ...Value) (Value, error) {
+	fn, ok := in.funcs[name]
+	if !ok {
+		return nil, &Failure{Message: fmt.Sprintf("undefined function %s", name)}
+	}
+
+	v, err := in.call(fn, args, fn.Span.Start)
+
+	return v, in.pkg.locate(err, fn)
+}
+
+func (in *Interpreter) call(fn *parser.FunctionDeclaration, args []Value, at parser.Position) (Value, error) {
+	if len(args) != len(fn.Parameters) {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s takes %d arguments, got %d", fn.Name.Value, len(fn.Parameters), len(args))}
+	}
+
+	if in.depth >= maxCallDepth {
+		return nil, &Failure{Pos: at, Message: "stack overflow"}
+	}
+
+	if err := in.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if fn.Body == nil {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s has no body", fn.Name.Value)}
+	}
+
+	in.depth++
+	caller := in.fn
+	in.fn = fn.Name.Value
+
+	defer func() {
+		in.depth--
+		in.fn = caller
+	}()
+
+	if in.tracer != nil {
+		in.tracer.Enter(in.fn)
+	}
+
+	env := newScope(in.globals)
+	for i, p := range fn.Parameters {
+		env.vars[p.Name.Value] = &binding{value: args[i], mutable: p.IsMut}
+	}
+
+	ctl, v, err := in.execBlock(fn.Body, env)
+	if err != nil {
+		return nil, in.pkg.locate(err, fn)
+	}
+
+	if ctl == ctlReturn {
+		return v, nil
+	}
+
+	return nil, nil
+}
+
+func (in *Interpreter) execBlock(b *parser.BlockStatement, env *scope) (control, Value, error) {
+	inner := newScope(env)
+
+	for _, st := range b.Statements {
+		ctl, v, err := in.exec(st, inner)
+		if err != nil || ctl != ctlNone {
+			return ctl, v, err
+		}
+	}
+
+	return ctlNone, nil, nil
+}
+
+func (in *Interpreter) exec(st parser.Statement, env *scope) (control, Value, error) {
+	switch s := st.(type) {
+	case *parser.BlockStatement:
+		return in.execBlock(s, env)
+	case *parser.ExpressionStatement:
+		_, err := in.eval(s.Expression, env)
+
+		return ctlNone, nil, err
+	case *parser.VariableDeclaration:
+		var v Value
+
+		if s.Initializer != nil {
+			var err error
+			if v, err = in.eval(s.Initializer, env); err != nil {
+				return ctlNone, nil, err
+			}
+		} else if s.TypeSpec != nil {
+			v = in.zero(s.TypeSpec)
+		}
+
+		env.vars[s.Name.Value] = &binding{value: v, mutable: s.IsMutable}
+
+		return ctlNone, nil, nil
+	case *parser.ReturnStatement:
+		if s.Value == nil {
+			return ctlReturn, nil, nil
+		}
+
+		v, err := in.eval(s.Value, env)
+
+		return ctlReturn, v, err
+	case *parser.IfStatement:
+		ok, err := in.condition(s.Condition, env)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+
+		if ok {
+			return in.exec(s.ThenStmt, env)
+		}
+
+		if s.ElseStmt != nil {
+			return in.exec(s.ElseStmt, env)
+		}
+
+		return ctlNone, nil, nil
+	case *parser.WhileStatement:
+		for {
+			ok, err := in.condition(s.Condition, env)
+			if err != nil || !ok {
+				return ctlNone, nil, err
+			}
+
+			if ctl, v, done, err := in.loopBody(s.Body, env, ""); done || err != nil {
+				return ctl, v, err
+			}
+		}
+	case *parser.ForStatement:
+		return in.execFor(s, env)
+	case *parser.ForInStatement:
+		return in.execForIn(s, env)
+	case *parser.MatchStatement:
+		return in.execMatch(s, env)
+	case *parser.BreakStatement:
+		in.label = labelOf(s.Label)
+
+		return ctlBreak, nil, nil
+	case *parser.ContinueStatement:
+		in.label = labelOf(s.Label)
+
+		return ctlContinue, nil, nil
+	default:
+		return ctlNone, nil, &Failure{Pos: st.GetSpan().Start, Message: fmt.Sprintf("unsupported statement %s", st.String())}
+	}
+}
+
+func labelOf(id *parser.Identifier) string {
+	if id == nil {
+		return ""
+	}
+
+	return id.Value
+}
+
+// loopBody runs one iteration of a loop labelled label. done reports that
+// the loop must stop, in which case ctl and v are what it completes with.
+func (in *Interpreter) loopBody(body parser.Statement, env *scope, label string) (ctl control, v Value, done bool, err error) {
+	if err := in.ctx.Err(); err != nil {
+		return ctlNone, nil, true, err
+	}
+
+	ctl, v, err = in.exec(body, env)
+	if err != nil {
+		return ctlNone, nil, true, err
+	}
+
+	switch ctl {
+	case ctlReturn:
+		return ctl, v, true, nil
+	case ctlBreak, ctlContinue:
+		if in.label != "" && in.label != label {
+			// a labelled jump to an outer loop.
+			return ctl, nil, true, nil
+		}
+
+		in.label = ""
+
+		return ctlNone, nil, ctl == ctlBreak, nil
+	}
+
+	return ctlNone, nil, false, nil
+}
+
+func (in *Interpreter) execFor(s *parser.ForStatement, env *scope) (control, Value, error) {
+	loop := newScope(env)
+
+	if s.Init != nil {
+		if _, _, err := in.exec(s.Init, loop); err != nil {
+			return ctlNone, nil, err
+		}
+	}
+
+	for {
+		if s.Condition != nil {
+			ok, err := in.condition(s.Condition, loop)
+			if err != nil || !ok {
+				return ctlNone, nil, err
+			}
+		}
+
+		if ctl, v, done, err := in.loopBody(s.Body, loop, labelOf(s.Label)); done || err != nil {
+			return ctl, v, err
+		}
+
+		if s.Update != nil {
+			if _, _, err := in.exec(s.Update, loop); err != nil {
+				return ctlNone, nil, err
+			}
+		}
+	}
+}
+
+func (in *Interpreter) execForIn(s *parser.ForInStatement, env *scope) (control, Value, error) {
+	it, err := in.eval(s.Iterable, env)
+	if err != nil {
+		return ctlNone, nil, err
+	}
+
+	var items []Value
+
+	switch c := it.(type) {
+	case *Array:
+		items = append(items, c.Elems...)
+	case string:
+		for _, r := range c {
+			items = append(items, string(r))
+		}
+	default:
+		return ctlNone, nil, &Failure{Pos: s.Iterable.GetSpan().Start, Message: fmt.Sprintf("cannot iterate over %s", typeName(it))}
+	}
+
+	for _, item := range items {
+		loop := newScope(env)
+		loop.vars[s.Variable.Value] = &binding{value: item}
+
+		if ctl, v, done, err := in.loopBody(s.Body, loop, ""); done || err != nil {
+			return ctl, v, err
+		}
+	}
+
+	return ctlNone, nil, nil
+}
+
+func (in *Interpreter) condition(e parser.Expression, env *scope) (bool, error) {
+	if in.tracer != nil {
+		return in.traceCondition(e, env)
+	}
+
+	return in.truth(e, env)
+}
+
+// traceCondition evaluates e like condition and reports the operands it
+// branches on to the tracer.
+func (in *Interpreter) traceCondition(e parser.Expression, env *scope) (bool, error) {
+	switch x := e.(type) {
+	case *parser.UnaryExpression:
+		if x.Operator.Value == "!" {
+			v, err := in.traceCondition(x.Operand, env)
+
+			return !v, err
+		}
+	case *parser.BinaryExpression:
+		if op := x.Operator.Value; op == "&&" || op == "||" {
+			l, err := in.traceCondition(x.Left, env)
+			if err != nil || l != (op == "&&") {
+				return l, err
+			}
+
+			return in.traceCondition(x.Right, env)
+		}
+	}
+
+	v, err := in.truth(e, env)
+	if err == nil {
+		in.tracer.Branch(in.fn, e.GetSpan().Start, v)
+	}
+
+	return v, err
+}
+
+func (in *Interpreter) truth(e parser.Expression, env *scope) (bool, error) {
+	v, err := in.eval(e, env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, &Failure{Pos: e.GetSpan().Start, Message: fmt.Sprintf("condition is %s, not bool", typeName(v))}
+	}
+
+	return b, nil
+}
+
+func (in *Interpreter) eval(e parser.Expression, env *scope) (Value, error) {
+	switch x := e.(type) {
+	case *parser.Literal:
+		return literalValue(x), nil
+	case *parser.Identifier:
+		if b := env.lookup(x.Value); b != nil {
+			return b.value, nil
+		}
+
+		if fn, ok := in.funcs[x.Value]; ok {
+			return &Function{Decl: fn}, nil
+		}
+
+		if v, ok := in.path(x.Value); ok {
+			return v, nil
+		}
+
+		return nil, &Failure{Pos: x.Span.Start, Message: fmt.Sprintf("undefined: %s", x.Value)}
+	case *parser.UnaryExpression:
+		v, err := in.eval(x.Operand, env)
+		if err != nil {
+			return nil, err
+		}
+
+		// References are the values they refer to: arrays and structs are
+		// shared already.
+		if op := x.Operator.Value; op == "&" || op == "&mut" {
+			return v, nil
+		}
+
+		return unary(x.Operator.Value, v, x.Span.Start)
+	case *parser.MemberExpression:
+		obj, err := in.eval(x.Object, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return field(obj, x.Member.Value, x.Span.Start)
+	case *parser.BinaryExpression:
+		return in.evalBinary(x, env)
+	case *parser.TernaryExpression:
+		ok, err := in.condition(x.Condition, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return in.eval(x.TrueExpr, env)
+		}
+
+		return in.eval(x.FalseExpr, env)
+	case *parser.AssignmentExpression:
+		return in.evalAssign(x, env)
+	case *parser.CallExpression:
+		return in.evalCall(x, env)
+	case *parser.ArrayExpression:
+		return in.evalArray(x.Elements, env)
+	case *parser.IndexExpression:
+		obj, err := in.eval(x.Object, env)
+		if err != nil {
+			return nil, err
+		}
+
+		idx, err := in.eval(x.Index, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return index(obj, idx, x.Span.Start)
+	default:
+		return nil, &Failure{Pos: e.GetSpan().Start, Message: fmt.Sprintf("unsupported expression %s", e.String())}
+	}
+}
+
+func literalValue(l *parser.Literal) Value {
+	switch v := l.Value.(type) {
+	case int:
+		return int64(v)
+	case nil:
+		return nil
+	default:
+		return v
+	}
+}
+
+func (in *Interpreter) evalArray(elems []parser.Expression, env *scope) (Value, error) {
+	arr := &Array{Elems: make([]Value, 0, len(elems))}
+
+	for _, el := range elems {
+		v, err := in.eval(el, env)
+		if err != nil {
+			return nil, err
+		}
+
+		arr.Elems = append(arr.Elems, v)
+	}
+
+	return arr, nil
+}
+
+func (in *Interpreter) evalBinary(x *parser.BinaryExpression, env *scope) (Value, error) {
+	op := x.Operator.Value
+	if op == "." {
+		id, ok := x.Right.(*parser.Identifier)
+		if !ok {
+			return nil, &Failure{Pos: x.Span.Start, Message: fmt.Sprintf("unsupported field access %s", x.String())}
+		}
+
+		obj, err := in.eval(x.Left, env)
+		if err != nil {
+			return nil, err
+		}
+
+		return field(obj, id.Value, x.Span.Start)
+	}
+
+	left, err := in.eval(x.Left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "&&" || op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, &Failure{Pos: x.Span.Start, Message: fmt.Sprintf("operand of %s is %s, not bool", op, typeName(left))}
+		}
+
+		if (op == "&&") != l {
+			return l, nil
+		}
+
+		ok, err := in.condition(x.Right, env)
+
+		return ok, err
+	}
+
+	right, err := in.eval(x.Right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return binary(op, left, right, x.Span.Start)
+}
+
+func (in *Interpreter) evalAssign(x *parser.AssignmentExpression, env *scope) (Value, error) {
+	v, err := in.eval(x.Right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	op := strings.TrimSuffix(x.Operator.Value, "=")
+
+	switch target := x.Left.(type) {
+	case *parser.Identifier:
+		b := env.lookup(target.Value)
+		if b == nil {
+			return nil, &Failure{Pos: target.Span.Start, Message: fmt.Sprintf("undefined: %s", target.Value)}
+		}
+
+		if !b.mutable {
+			return nil, &Failure{Pos: target.Span.Start, Message: fmt.Sprintf("cannot assign to immutable variable %s", target.Value)}
+		}
+
+		if op != "" {
+			if v, err = binary(op, b.value, v, x.Span.Start); err != nil {
+				return nil, err
+			}
+		}
+
+		b.value = v
+
+		return nil, nil
+	case *parser.IndexExpression:
+		return in.assignIndex(target.Object, target.Index, op, v, env, x.Span.Start)
+	case *parser.MemberExpression:
+		return in.assignField(target.Object, target.Member.Value, op, v, env, x.Span.Start)
+	case *parser.BinaryExpression:
+		if id, ok := target.Right.(*parser.Identifier); ok && target.Operator.Value == "." {
+			return in.assignField(target.Left, id.Value, op, v, env, x.Span.Start)
+		}
+	case *parser.CallExpression:
+		// a[i] is parsed as a call of a with one argument.
+		if len(target.Arguments) == 1 {
+			return in.assignIndex(target.Function, target.Arguments[0], op, v, env, x.Span.Start)
+		}
+	}
+
+	return nil, &Failure{Pos: x.Span.Start, Message: fmt.Sprintf("cannot assign to %s", x.Left.String())}
+}
+
+func (in *Interpreter) assignIndex(object, idxExpr parser.Expression, op string, v Value, env *scope, at parser.Position) (Value, error) {
+	obj, err := in.eval(object, env)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := obj.(*Array)
+	if !ok {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("cannot index-assign %s", typeName(obj))}
+	}
+
+	idx, err := in.eval(idxExpr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := arrayIndex(arr, idx, at)
+	if err != nil {
+		return nil, err
+	}
+
+	if op != "" {
+		if v, err = binary(op, arr.Elems[i], v, at); err != nil {
+			return nil, err
+		}
+	}
+
+	arr.Elems[i] = v
+
+	return nil, nil
+}
+
+func (in *Interpreter) evalCall(x *parser.CallExpression, env *scope) (Value, error) {
+	at := x.Span.Start
+
+	// Method calls are parsed as calls of a '.' expression.
+	var (
+		recv   parser.Expression
+		method string
+	)
+
+	switch f := x.Function.(type) {
+	case *parser.BinaryExpression:
+		if id, ok := f.Right.(*parser.Identifier); ok && f.Operator.Value == "." {
+			recv, method = f.Left, id.Value
+		}
+	case *parser.MemberExpression:
+		recv, method = f.Object, f.Member.Value
+	case *parser.Identifier:
+		if env.lookup(f.Value) == nil {
+			if _, ok := in.funcs[f.Value]; !ok {
+				if enum, name, n, ok := in.constructor(f.Value); ok {
+					return in.construct(enum, name, n, x.Arguments, env, at)
+				}
+
+				if b, ok := builtins[f.Value]; ok {
+					args, err := in.evalArgs(x.Arguments, env)
+					if err != nil {
+						return nil, err
+					}
+
+					return b(in, args, at)
+				}
+			}
+		}
+	}
+
+	if recv != nil {
+		r, err := in.eval(recv, env)
+		if err != nil {
+			return nil, err
+		}
+
+		args, err := in.evalArgs(x.Arguments, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if fn, ok := in.method(r, method); ok && len(fn.Parameters) == len(args)+1 {
+			return in.call(fn, append([]Value{r}, args...), at)
+		}
+
+		// s.f[i] is parsed as a call of method f of s, and a struct may have
+		// a method named like its field.
+		if s, ok := r.(*Struct); ok && s.has(method) && len(args) == 1 {
+			return index(s.Fields[method], args[0], at)
+		}
+
+		if fn, ok := in.method(r, method); ok {
+			return in.call(fn, append([]Value{r}, args...), at)
+		}
+
+		return callMethod(r, method, args, at)
+	}
+
+	callee, err := in.eval(x.Function, env)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := in.evalArgs(x.Arguments, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := callee.(type) {
+	case *Function:
+		return in.call(c.Decl, args, at)
+	case *Array, string:
+		// a[i] is parsed as a call of a with one argument.
+		if len(args) == 1 {
+			return index(c, args[0], at)
+		}
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("cannot call %s", typeName(callee))}
+}
+
+func (in *Interpreter) evalArgs(exprs []parser.Expression, env *scope) ([]Value, error) {
+	args := make([]Value, 0, len(exprs))
+
+	for _, a := range exprs {
+		v, err := in.eval(a, env)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, v)
+	}
+
+	return args, nil
+}
+
+func callMethod(recv Value, method string, args []Value, at parser.Position) (Value, error) {
+	switch method {
+	case "len":
+		if len(args) == 0 {
+			return length(recv, at)
+		}
+	case "push":
+		if arr, ok := recv.(*Array); ok && len(args) == 1 {
+			arr.Elems = append(arr.Elems, args[0])
+
+			return nil, nil
+		}
+	case "clone", "to_owned":
+		if len(args) == 0 {
+			return clone(recv), nil
+		}
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s has no method %s with %d arguments", typeName(recv), method, len(args))}
+}
+
+func length(v Value, at parser.Position) (Value, error) {
+	switch c := v.(type) {
+	case *Array:
+		return int64(len(c.Elems)), nil
+	case string:
+		return int64(len(c)), nil
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s has no length", typeName(v))}
+}
+
+func index(obj, idx Value, at parser.Position) (Value, error) {
+	switch c := obj.(type) {
+	case *Array:
+		i, err := arrayIndex(c, idx, at)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.Elems[i], nil
+	case string:
+		i, ok := idx.(int64)
+		if !ok || i < 0 || i >= int64(len(c)) {
+			return nil, &Failure{Pos: at, Message: fmt.Sprintf("index %s out of range for string of length %d", Format(idx), len(c))}
+		}
+
+		return c[i : i+1], nil
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("cannot index %s", typeName(obj))}
+}
+
+func arrayIndex(arr *Array, idx Value, at parser.Position) (int, error) {
+	i, ok := idx.(int64)
+	if !ok {
+		return 0, &Failure{Pos: at, Message: fmt.Sprintf("array index is %s, not int", typeName(idx))}
+	}
+
+	if i < 0 || i >= int64(len(arr.Elems)) {
+		return 0, &Failure{Pos: at, Message: fmt.Sprintf("index out of bounds: the len is %d but the index is %d", len(arr.Elems), i)}
+	}
+
+	return int(i), nil
+}
+
+func unary(op string, v Value, at parser.Position) (Value, error) {
+	switch op {
+	case "-":
+		switch n := v.(type) {
+		case int64:
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+	case "!":
+		if b, ok := v.(bool); ok {
+			return !b, nil
+		}
+	case "~":
+		if n, ok := v.(int64); ok {
+			return ^n, nil
+		}
+	case "+":
+		switch v.(type) {
+		case int64, float64:
+			return v, nil
+		}
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("invalid operation: %s%s", op, typeName(v))}
+}
+
+func binary(op string, l, r Value, at parser.Position) (Value, error) {
+	switch op {
+	case "==":
+		return Equal(l, r), nil
+	case "!=":
+		return !Equal(l, r), nil
+	}
+
+	switch a := l.(type) {
+	case int64:
+		switch b := r.(type) {
+		case int64:
+			return intOp(op, a, b, at)
+		case float64:
+			return floatOp(op, float64(a), b, at)
+		}
+	case float64:
+		switch b := r.(type) {
+		case int64:
+			return floatOp(op, a, float64(b), at)
+		case float64:
+			return floatOp(op, a, b, at)
+		}
+	case string:
+		if b, ok := r.(string); ok {
+			switch op {
+			case "+":
+				return a + b, nil
+			case "<":
+				return a < b, nil
+			case "<=":
+				return a <= b, nil
+			case ">":
+				return a > b, nil
+			case ">=":
+				return a >= b, nil
+			}
+		}
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("invalid operation: %s %s %s", typeName(l), op, typeName(r))}
+}
+
+func intOp(op string, a, b int64, at parser.Position) (Value, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/", "%":
+		if b == 0 {
+			return nil, &Failure{Pos: at, Message: "attempt to divide by zero"}
+		}
+
+		if op == "/" {
+			return a / b, nil
+		}
+
+		return a % b, nil
+	case "&":
+		return a & b, nil
+	case "|":
+		return a | b, nil
+	case "^":
+		return a ^ b, nil
+	case "<<", ">>":
+		if b < 0 || b > 63 {
+			return nil, &Failure{Pos: at, Message: fmt.Sprintf("shift count %d out of range", b)}
+		}
+
+		if op == "<<" {
+			return a << b, nil
+		}
+
+		return a >> b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("invalid operation: int %s int", op)}
+}
+
+func floatOp(op string, a, b float64, at parser.Position) (Value, error) {
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	case "%":
+		return math.Mod(a, b), nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	}
+
+	return nil, &Failure{Pos: at, Message: fmt.Sprintf("invalid operation: float %s float", op)}
+}
+
+// Equal reports whether two values are equal. Arrays, structs and variants
+// compare element-wise and an int equals a float with the same value.
+func Equal(a, b Value) bool {
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(float64); ok {
+			return float64(x) == y
+		}
+	case float64:
+		if y, ok := b.(int64); ok {
+			return x == float64(y)
+		}
+	case *Array:
+		y, ok := b.(*Array)
+		if !ok || len(x.Elems) != len(y.Elems) {
+			return false
+		}
+
+		for i := range x.Elems {
+			if !Equal(x.Elems[i], y.Elems[i]) {
+				return false
+			}
+		}
+
+		return true
+	case *Struct:
+		y, ok := b.(*Struct)
+		if !ok || x.Type != y.Type {
+			return false
+		}
+
+		for _, n := range x.Names {
+			if !Equal(x.Fields[n], y.Fields[n]) {
+				return false
+			}
+		}
+
+		return true
+	case *Variant:
+		y, ok := b.(*Variant)
+		if !ok || x.Enum != y.Enum || x.Name != y.Name || len(x.Fields) != len(y.Fields) {
+			return false
+		}
+
+		for i := range x.Fields {
+			if !Equal(x.Fields[i], y.Fields[i]) {
+				return false
+			}
+		}
+
+		return true
+	case *Function:
+		y, ok := b.(*Function)
+
+		return ok && x.Decl == y.Decl
+	}
+
+	return a == b
+}
+
+// Format renders a value the way assertion messages show it.
+func Format(v Value) string {
+	switch x := v.(type) {
+	case nil:
+		return "()"
+	case string:
+		return strconv.Quote(x)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case *Array:
+		parts := make([]string, len(x.Elems))
+		for i, el := range x.Elems {
+			parts[i] = Format(el)
+		}
+
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *Struct:
+		return formatStruct(x)
+	case *Variant:
+		return formatVariant(x)
+	case *Function:
+		return "func " + x.Decl.Name.Value
+	}
+
+	return fmt.Sprint(v)
+}
+
+// display renders a value for println: like Format, but strings are
+// written without quotes.
+func display(v Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return Format(v)
+}
+
+func typeName(v Value) string {
+	switch x := v.(type) {
+	case nil:
+		return "unit"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *Array:
+		return "array"
+	case *Struct:
+		return x.Type
+	case *Variant:
+		return x.Enum
+	case *Function:
+		return "function"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
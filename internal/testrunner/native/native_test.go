@@ -0,0 +1,325 @@
+package native
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+const libSource = `
+let SCALE = 3;
+
+func scale(x: int) -> int {
+    return x * SCALE;
+}
+
+func sum(xs: [int]) -> int {
+    let mut total = 0;
+    for x in xs { total += x; }
+    return total;
+}
+
+#[cfg(not(test))]
+func only_in_release() -> int { return 1; }
+`
+
+const testSource = `
+#[test]
+func test_scale() {
+    assert_eq(scale(2), 6);
+    println("scaled");
+}
+
+#[test]
+func test_control_flow() {
+    let xs = [1, 2, 3, 4];
+    xs[0] = 10;
+    xs.push(5);
+    assert_eq(sum(xs), 24, "sum");
+
+    var n = 0;
+    while true {
+        n += 1;
+        if n == 3 { continue; }
+        if n >= 5 { break; }
+    }
+    assert(n == 5);
+    assert_ne("a" + "b", "ba");
+}
+
+#[test]
+func test_fails() {
+    assert_eq(scale(1), 4, "scale");
+}
+
+#[test]
+#[ignore]
+func test_ignored() {}
+
+#[test]
+#[should_panic]
+func test_divides_by_zero() {
+    let x = 1 / 0;
+}
+
+#[bench]
+func bench_scale() { scale(7); }
+`
+
+func TestLoadCollectsCasesAcrossFiles(t *testing.T) {
+	dir := writePackage(t, map[string]string{"lib.oriz": libSource, "lib_test.oriz": testSource})
+
+	pkg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range pkg.Cases {
+		names = append(names, c.Name)
+	}
+
+	want := "test_scale test_control_flow test_fails test_ignored test_divides_by_zero bench_scale"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("cases = %s, want %s", got, want)
+	}
+
+	if _, ok := pkg.funcs["only_in_release"]; ok {
+		t.Fatalf("#[cfg(not(test))] function should be dropped")
+	}
+
+	if !pkg.Cases[3].Ignored || !pkg.Cases[4].ShouldPanic || pkg.Cases[5].Kind != KindBench {
+		t.Fatalf("unexpected attributes: %+v", pkg.Cases)
+	}
+}
+
+func TestRunReportsAssertionsAndOutput(t *testing.T) {
+	dir := writePackage(t, map[string]string{"lib.oriz": libSource, "lib_test.oriz": testSource})
+
+	pkg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	results := make(map[string]error)
+
+	var out strings.Builder
+
+	for _, c := range pkg.Cases {
+		if c.Kind == KindTest {
+			results[c.Name] = pkg.Run(ctx, c, &out)
+		}
+	}
+
+	for _, name := range []string{"test_scale", "test_control_flow", "test_divides_by_zero"} {
+		if results[name] != nil {
+			t.Fatalf("%s: %v", name, results[name])
+		}
+	}
+
+	var f *Failure
+	if !errors.As(results["test_fails"], &f) {
+		t.Fatalf("test_fails: expected a failure, got %v", results["test_fails"])
+	}
+
+	if f.Pos.File != filepath.Join(dir, "lib_test.oriz") || f.Pos.Line != 27 {
+		t.Fatalf("failure position = %s", f.Pos)
+	}
+
+	if !strings.Contains(f.Message, "left: 3") || !strings.Contains(f.Message, "right: 4") || !strings.Contains(f.Message, "scale") {
+		t.Fatalf("unexpected message %q", f.Message)
+	}
+
+	if out.String() != "scaled\n" {
+		t.Fatalf("output = %q", out.String())
+	}
+}
+
+func TestRunStopsOnContextAndDeepRecursion(t *testing.T) {
+	dir := writePackage(t, map[string]string{"t.oriz": `
+func down(n: int) -> int { return down(n + 1); }
+
+#[test]
+func test_forever() { while true {} }
+
+#[test]
+func test_recursion() { down(0); }
+`})
+
+	pkg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := pkg.Run(ctx, pkg.Cases[0], nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to stop the loop, got %v", err)
+	}
+
+	err = pkg.Run(context.Background(), pkg.Cases[1], nil)
+	if err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Fatalf("expected a stack overflow failure, got %v", err)
+	}
+}
+
+func TestFindPackages(t *testing.T) {
+	root := t.TempDir()
+
+	for _, dir := range []string{"a", "a/b", "testdata/x", ".hidden", "empty"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if dir != "empty" {
+			if err := os.WriteFile(filepath.Join(root, dir, "f.oriz"), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	dirs, err := FindPackages([]string{filepath.Join(root, "...")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "a/b")}
+	if strings.Join(dirs, ",") != strings.Join(want, ",") {
+		t.Fatalf("dirs = %v, want %v", dirs, want)
+	}
+
+	if _, err := FindPackages([]string{filepath.Join(root, "empty")}); err == nil {
+		t.Fatalf("expected an error for a directory without .oriz files")
+	}
+}
+
+func TestCasesReachingAndWithFunction(t *testing.T) {
+	dir := writePackage(t, map[string]string{"lib.oriz": libSource, "lib_test.oriz": testSource})
+
+	pkg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, c := range pkg.CasesReaching("scale") {
+		names = append(names, c.Name)
+	}
+
+	if got := strings.Join(names, " "); got != "test_scale test_fails bench_scale" {
+		t.Fatalf("cases reaching scale = %s", got)
+	}
+
+	alt, err := LoadSource(filepath.Join(dir, "alt.oriz"), "func scale(x: int) -> int { return x * 2; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variant := pkg.WithFunction(alt.Function("scale"))
+	ctx := context.Background()
+
+	if err := variant.Run(ctx, variant.Cases[0], io.Discard); err == nil {
+		t.Fatalf("test_scale should fail against the replaced scale")
+	}
+
+	if err := pkg.Run(ctx, pkg.Cases[0], io.Discard); err != nil {
+		t.Fatalf("original package changed: %v", err)
+	}
+}
+
+func TestRunStructsEnumsAndMatch(t *testing.T) {
+	dir := writePackage(t, map[string]string{"shapes.oriz": `
+enum Shape { Square(int), Rect(int, int), Empty, }
+
+impl Shape {
+    func area(&self) -> int {
+        match (self) {
+            Shape::Square(s) => return s * s,
+            Shape::Rect(w, h) if w == h => return square(w),
+            Shape::Rect(w, h) => return w * h,
+            _ => return 0,
+        }
+    }
+}
+
+func square(n: int) -> int { return n * n; }
+
+struct Counter { hits: [Shape], total: int, }
+
+impl Counter {
+    func new() -> Self {
+        var c: Counter;
+        c.hits = [];
+        c.total = 0;
+        return c;
+    }
+
+    func add(&mut self, s: Shape) -> &mut Self {
+        self.hits.push(s.clone());
+        self.total += s.area();
+        return self;
+    }
+}
+
+#[test]
+func test_shapes() {
+    let mut c = Counter::new();
+    c.add(Shape::Square(3)).add(Shape::Rect(2, 5)).add(Shape::Empty);
+    assert_eq(c.total, 19);
+    assert_eq(len(c.hits), 3);
+    assert_eq(c.hits[1], Shape::Rect(2, 5));
+    let last = &mut c.hits[2];
+    assert_eq(last.area(), 0);
+    match (Some(c.total)) {
+        Some(n) => assert_eq(n, 19),
+        None => panic("no total"),
+    }
+}
+
+#[test]
+func test_mismatch() {
+    assert_eq(Shape::Rect(4, 4), Shape::Square(4));
+}
+`})
+
+	pkg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := pkg.Run(ctx, pkg.Cases[0], io.Discard); err != nil {
+		t.Fatalf("test_shapes: %v", err)
+	}
+
+	err = pkg.Run(ctx, pkg.Cases[1], io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "Shape::Rect(4, 4)") || !strings.Contains(err.Error(), "Shape::Square(4)") {
+		t.Fatalf("test_mismatch: expected the variants in the failure, got %v", err)
+	}
+
+	// square is only called from a method.
+	if cases := pkg.CasesReaching("square"); len(cases) != 1 || cases[0].Name != "test_shapes" {
+		t.Fatalf("cases reaching square = %v, want test_shapes", cases)
+	}
+}
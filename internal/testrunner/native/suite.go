@@ -0,0 +1,379 @@
+// Package native discovers and runs tests written in Orizon itself:
+// functions marked #[test] or #[bench] in the .oriz files of a directory.
+// They run on a tree-walking interpreter over the parser's AST, with the
+// assert, assert_eq and assert_ne builtins.
+package native
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/lexer"
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// Extension is the file extension of Orizon source files.
+const Extension = ".oriz"
+
+// Kind distinguishes tests from benchmarks.
+type Kind int
+
+const (
+	KindTest Kind = iota
+	KindBench
+)
+
+// Case is one #[test] or #[bench] function.
+type Case struct {
+	decl        *parser.FunctionDeclaration
+	Name        string
+	File        string
+	Kind        Kind
+	Ignored     bool // #[ignore]: reported as skipped
+	ShouldPanic bool // #[should_panic]: passes only if it fails
+}
+
+// Package holds the parsed .oriz files of one directory. Tests share the
+// functions and top-level variables of every file in it.
+type Package struct {
+	// Tracer, when set, observes every interpreter of the package.
+	Tracer  Tracer
+	funcs   map[string]*parser.FunctionDeclaration
+	structs map[string]*parser.StructDeclaration
+	enums   map[string]*parser.EnumDeclaration
+	methods map[string]map[string]*parser.FunctionDeclaration // by type, then name
+	files   map[parser.Node]string
+	progs   map[string]*parser.Program
+	Dir     string
+	Files   []string
+	Cases   []Case
+	globals []*parser.VariableDeclaration
+}
+
+// Load parses the .oriz files of dir with the "test" cfg flag set and
+// collects its tests and benchmarks.
+func Load(dir string) (*Package, error) {
+	files, err := SourceFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := newPackage(dir)
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := pkg.add(file, string(src)); err != nil {
+			return nil, err
+		}
+	}
+
+	return pkg, nil
+}
+
+// LoadSource is like Load for a single file held in memory.
+func LoadSource(file, src string) (*Package, error) {
+	pkg := newPackage(filepath.Dir(file))
+	if err := pkg.add(file, src); err != nil {
+		return nil, err
+	}
+
+	return pkg, nil
+}
+
+func newPackage(dir string) *Package {
+	return &Package{
+		Dir:     dir,
+		funcs:   make(map[string]*parser.FunctionDeclaration),
+		structs: make(map[string]*parser.StructDeclaration),
+		enums:   make(map[string]*parser.EnumDeclaration),
+		methods: make(map[string]map[string]*parser.FunctionDeclaration),
+		files:   make(map[parser.Node]string),
+		progs:   make(map[string]*parser.Program),
+	}
+}
+
+// add parses the source of file and adds its declarations.
+func (p *Package) add(file, src string) error {
+	ps := parser.NewParser(lexer.NewWithFilename(src, file), file)
+	ps.SetCfg(parser.NewCfgSet("test"))
+
+	prog, errs := ps.Parse()
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	p.Files = append(p.Files, file)
+	p.progs[file] = prog
+
+	for _, decl := range prog.Declarations {
+		switch d := decl.(type) {
+		case *parser.FunctionDeclaration:
+			if prev, ok := p.funcs[d.Name.Value]; ok {
+				return fmt.Errorf("%s:%d: %s redeclared, previous declaration at %s:%d",
+					file, d.Span.Start.Line, d.Name.Value, p.files[prev], prev.Span.Start.Line)
+			}
+
+			p.funcs[d.Name.Value] = d
+			p.files[d] = file
+
+			if c, ok := caseOf(d, file); ok {
+				p.Cases = append(p.Cases, c)
+			}
+		case *parser.VariableDeclaration:
+			p.globals = append(p.globals, d)
+			p.files[d] = file
+		case *parser.StructDeclaration:
+			p.structs[d.Name.Value] = d
+		case *parser.EnumDeclaration:
+			p.enums[d.Name.Value] = d
+		case *parser.ImplBlock:
+			if err := p.addImpl(file, d); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Program returns the parsed source of file, or nil if file is not part of
+// the package.
+func (p *Package) Program(file string) *parser.Program {
+	return p.progs[file]
+}
+
+// FunctionNames returns the names of the functions in the package, sorted.
+func (p *Package) FunctionNames() []string {
+	names := make([]string, 0, len(p.funcs))
+	for name := range p.funcs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// locate fills in the file of a failure raised in decl; the parser leaves
+// it out of positions.
+func (p *Package) locate(err error, decl parser.Node) error {
+	var f *Failure
+	if errors.As(err, &f) && f.Pos.File == "" && f.Pos.Line > 0 {
+		f.Pos.File = p.files[decl]
+	}
+
+	return err
+}
+
+func caseOf(fn *parser.FunctionDeclaration, file string) (Case, bool) {
+	c := Case{decl: fn, Name: fn.Name.Value, File: file}
+	found := false
+
+	for _, attr := range fn.Attributes {
+		switch attr {
+		case "test":
+			c.Kind, found = KindTest, true
+		case "bench":
+			c.Kind, found = KindBench, true
+		case "ignore":
+			c.Ignored = true
+		case "should_panic":
+			c.ShouldPanic = true
+		}
+	}
+
+	return c, found
+}
+
+// SourceFiles returns the .oriz files directly in dir, sorted.
+func SourceFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), Extension) {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// FindPackages expands patterns into the directories that contain .oriz
+// files. A pattern is a directory, or a directory followed by /... to
+// include every directory below it; like the go tool, the walk skips
+// testdata and directories whose name starts with '.' or '_'.
+func FindPackages(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	var dirs []string
+
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+		if pattern == "..." {
+			root, recursive = ".", true
+		}
+
+		root = filepath.Clean(filepath.FromSlash(root))
+
+		if !recursive {
+			files, err := SourceFiles(root)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(files) == 0 {
+				return nil, fmt.Errorf("no %s files in %s", Extension, root)
+			}
+
+			add(root)
+
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				return nil
+			}
+
+			name := d.Name()
+			if path != root && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+
+			files, err := SourceFiles(path)
+			if err != nil {
+				return err
+			}
+
+			if len(files) > 0 {
+				add(path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
+
+// Run runs the test c in a fresh interpreter and writes what it prints to
+// out. It returns a *Failure when an assertion does not hold or the code
+// fails at run time, and the context's error when ctx ends first.
+func (p *Package) Run(ctx context.Context, c Case, out io.Writer) error {
+	if len(c.decl.Parameters) > 0 {
+		return &Failure{Pos: p.position(c), Message: fmt.Sprintf("test function %s must not take parameters", c.Name)}
+	}
+
+	in, err := p.NewInterpreter(ctx, out)
+	if err == nil {
+		_, err = in.Call(c.Name)
+	}
+
+	if !c.ShouldPanic {
+		return err
+	}
+
+	var f *Failure
+	if errors.As(err, &f) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return &Failure{Pos: p.position(c), Message: "test did not panic as expected"}
+}
+
+func (p *Package) position(c Case) parser.Position {
+	pos := c.decl.Span.Start
+	pos.File = c.File
+
+	return pos
+}
+
+// BenchResult is the outcome of a benchmark.
+type BenchResult struct {
+	N       int
+	Elapsed time.Duration
+}
+
+// NsPerOp returns the mean time of one iteration in nanoseconds.
+func (r BenchResult) NsPerOp() int64 {
+	if r.N <= 0 {
+		return 0
+	}
+
+	return r.Elapsed.Nanoseconds() / int64(r.N)
+}
+
+func (r BenchResult) String() string {
+	return fmt.Sprintf("%8d\t%10d ns/op", r.N, r.NsPerOp())
+}
+
+// maxBenchN bounds the iteration count of a benchmark.
+const maxBenchN = 1 << 30
+
+// Bench runs the benchmark c with a doubling iteration count until one
+// round takes at least benchtime, and reports the last round. What the
+// benchmark prints is discarded.
+func (p *Package) Bench(ctx context.Context, c Case, benchtime time.Duration) (BenchResult, error) {
+	if len(c.decl.Parameters) > 0 {
+		return BenchResult{}, &Failure{Pos: p.position(c), Message: fmt.Sprintf("benchmark function %s must not take parameters", c.Name)}
+	}
+
+	for n := 1; ; n *= 2 {
+		in, err := p.NewInterpreter(ctx, io.Discard)
+		if err != nil {
+			return BenchResult{}, err
+		}
+
+		start := time.Now()
+
+		for i := 0; i < n; i++ {
+			if _, err := in.Call(c.Name); err != nil {
+				return BenchResult{}, err
+			}
+		}
+
+		res := BenchResult{N: n, Elapsed: time.Since(start)}
+		if res.Elapsed >= benchtime || n >= maxBenchN {
+			return res, nil
+		}
+	}
+}
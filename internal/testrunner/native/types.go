@@ -0,0 +1,359 @@
+package native
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/orizon-lang/orizon/internal/parser"
+)
+
+// Struct is a struct value. Like arrays, structs are shared by reference,
+// so a method called on a struct stored in an array updates it in place.
+type Struct struct {
+	Fields map[string]Value
+	Type   string
+	Names  []string // field names in declaration order
+}
+
+// Variant is a value of an enum: the variant Name of Enum with its fields.
+// Option's Some and None are built in.
+type Variant struct {
+	Enum   string
+	Name   string
+	Fields []Value
+}
+
+// optionEnum is the enum of the built-in Some and None.
+const optionEnum = "Option"
+
+// addImpl registers the methods of an impl block under the implementing
+// type.
+func (p *Package) addImpl(file string, impl *parser.ImplBlock) error {
+	typ := declName(impl.ForType)
+	if typ == "" {
+		return fmt.Errorf("%s:%d: cannot implement methods for %s", file, impl.Span.Start.Line, impl.ForType)
+	}
+
+	if p.methods[typ] == nil {
+		p.methods[typ] = make(map[string]*parser.FunctionDeclaration)
+	}
+
+	for _, fn := range impl.Items {
+		if prev, ok := p.methods[typ][fn.Name.Value]; ok {
+			return fmt.Errorf("%s:%d: %s::%s redeclared, previous declaration at %s:%d",
+				file, fn.Span.Start.Line, typ, fn.Name.Value, p.files[prev], prev.Span.Start.Line)
+		}
+
+		p.methods[typ][fn.Name.Value] = fn
+		p.files[fn] = file
+	}
+
+	return nil
+}
+
+// declName returns the name of a struct or enum type, without generic
+// arguments, or "" for other types.
+func declName(t parser.Type) string {
+	switch tt := t.(type) {
+	case *parser.BasicType:
+		return tt.Name
+	case *parser.GenericType:
+		return declName(tt.BaseType)
+	}
+
+	return ""
+}
+
+// zero returns the value of a variable of type t declared without an
+// initializer: a struct with no field set, or unit.
+func (in *Interpreter) zero(t parser.Type) Value {
+	decl, ok := in.pkg.structs[declName(t)]
+	if !ok {
+		return nil
+	}
+
+	s := &Struct{Type: decl.Name.Value, Fields: make(map[string]Value, len(decl.Fields))}
+	for _, f := range decl.Fields {
+		s.Names = append(s.Names, f.Name.Value)
+	}
+
+	return s
+}
+
+// path resolves Type::name to an associated function or a variant without
+// fields, and None to Option's.
+func (in *Interpreter) path(name string) (Value, bool) {
+	if name == "None" {
+		return &Variant{Enum: optionEnum, Name: name}, true
+	}
+
+	typ, member, ok := strings.Cut(name, "::")
+	if !ok {
+		return nil, false
+	}
+
+	if fn, ok := in.pkg.methods[typ][member]; ok {
+		return &Function{Decl: fn}, true
+	}
+
+	if v, ok := in.variant(typ, member); ok && len(v.Fields) == 0 {
+		return &Variant{Enum: typ, Name: member}, true
+	}
+
+	return nil, false
+}
+
+// constructor returns the enum and fields of the variant name builds when
+// called: Some, or Type::Variant of an enum of the package.
+func (in *Interpreter) constructor(name string) (enum, variant string, fields int, ok bool) {
+	if name == "Some" {
+		return optionEnum, name, 1, true
+	}
+
+	typ, member, found := strings.Cut(name, "::")
+	if !found {
+		return "", "", 0, false
+	}
+
+	v, ok := in.variant(typ, member)
+	if !ok || len(v.Fields) == 0 {
+		return "", "", 0, false
+	}
+
+	return typ, member, len(v.Fields), true
+}
+
+func (in *Interpreter) variant(enum, name string) (*parser.EnumVariant, bool) {
+	decl, ok := in.pkg.enums[enum]
+	if !ok {
+		return nil, false
+	}
+
+	for _, v := range decl.Variants {
+		if v.Name.Value == name {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+// field reads field name of obj.
+func field(obj Value, name string, at parser.Position) (Value, error) {
+	s, ok := obj.(*Struct)
+	if !ok {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s has no field %s", typeName(obj), name)}
+	}
+
+	v, ok := s.Fields[name]
+	if !ok && !s.has(name) {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s has no field %s", s.Type, name)}
+	}
+
+	return v, nil
+}
+
+func (s *Struct) has(name string) bool {
+	for _, n := range s.Names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (in *Interpreter) assignField(object parser.Expression, name, op string, v Value, env *scope, at parser.Position) (Value, error) {
+	obj, err := in.eval(object, env)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := obj.(*Struct)
+	if !ok || !s.has(name) {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s has no field %s", typeName(obj), name)}
+	}
+
+	if op != "" {
+		if v, err = binary(op, s.Fields[name], v, at); err != nil {
+			return nil, err
+		}
+	}
+
+	s.Fields[name] = v
+
+	return nil, nil
+}
+
+// method returns the method name of the type of recv, if it has one.
+func (in *Interpreter) method(recv Value, name string) (*parser.FunctionDeclaration, bool) {
+	var typ string
+
+	switch r := recv.(type) {
+	case *Struct:
+		typ = r.Type
+	case *Variant:
+		typ = r.Enum
+	default:
+		return nil, false
+	}
+
+	fn, ok := in.pkg.methods[typ][name]
+
+	return fn, ok
+}
+
+func (in *Interpreter) execMatch(s *parser.MatchStatement, env *scope) (control, Value, error) {
+	v, err := in.eval(s.Expression, env)
+	if err != nil {
+		return ctlNone, nil, err
+	}
+
+	for _, arm := range s.Arms {
+		armEnv := newScope(env)
+
+		ok, err := in.match(arm.Pattern, v, armEnv)
+		if err != nil {
+			return ctlNone, nil, err
+		}
+
+		if ok && arm.Guard != nil {
+			if ok, err = in.condition(arm.Guard, armEnv); err != nil {
+				return ctlNone, nil, err
+			}
+		}
+
+		if ok {
+			return in.exec(arm.Body, armEnv)
+		}
+	}
+
+	return ctlNone, nil, &Failure{Pos: s.Span.Start, Message: fmt.Sprintf("no match arm for %s", Format(v))}
+}
+
+// match reports whether v matches pattern p and binds the names p
+// introduces in env.
+func (in *Interpreter) match(p parser.Expression, v Value, env *scope) (bool, error) {
+	switch pat := p.(type) {
+	case *parser.Identifier:
+		if pat.Value == "_" {
+			return true, nil
+		}
+
+		if want, ok := in.path(pat.Value); ok {
+			if w, isVariant := want.(*Variant); isVariant {
+				got, ok := v.(*Variant)
+
+				return ok && got.Enum == w.Enum && got.Name == w.Name, nil
+			}
+		}
+
+		if strings.Contains(pat.Value, "::") {
+			return false, &Failure{Pos: pat.Span.Start, Message: fmt.Sprintf("undefined: %s", pat.Value)}
+		}
+
+		env.vars[pat.Value] = &binding{value: v}
+
+		return true, nil
+	case *parser.CallExpression:
+		id, ok := pat.Function.(*parser.Identifier)
+		if !ok {
+			break
+		}
+
+		enum, name, fields, ok := in.constructor(id.Value)
+		if !ok {
+			return false, &Failure{Pos: pat.Span.Start, Message: fmt.Sprintf("undefined: %s", id.Value)}
+		}
+
+		got, isVariant := v.(*Variant)
+		if !isVariant || got.Enum != enum || got.Name != name || len(got.Fields) != fields || len(pat.Arguments) != fields {
+			return false, nil
+		}
+
+		for i, sub := range pat.Arguments {
+			if ok, err := in.match(sub, got.Fields[i], env); err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case *parser.Literal:
+		return Equal(literalValue(pat), v), nil
+	}
+
+	return false, &Failure{Pos: p.GetSpan().Start, Message: fmt.Sprintf("unsupported pattern %s", p.String())}
+}
+
+// clone copies v the way clone and to_owned do: arrays, structs and
+// variants are copied deeply.
+func clone(v Value) Value {
+	switch x := v.(type) {
+	case *Array:
+		c := &Array{Elems: make([]Value, len(x.Elems))}
+		for i, el := range x.Elems {
+			c.Elems[i] = clone(el)
+		}
+
+		return c
+	case *Struct:
+		c := &Struct{Type: x.Type, Names: x.Names, Fields: make(map[string]Value, len(x.Fields))}
+		for k, f := range x.Fields {
+			c.Fields[k] = clone(f)
+		}
+
+		return c
+	case *Variant:
+		c := &Variant{Enum: x.Enum, Name: x.Name, Fields: make([]Value, len(x.Fields))}
+		for i, f := range x.Fields {
+			c.Fields[i] = clone(f)
+		}
+
+		return c
+	}
+
+	return v
+}
+
+func formatStruct(s *Struct) string {
+	parts := make([]string, 0, len(s.Names))
+	for _, n := range s.Names {
+		if v, ok := s.Fields[n]; ok {
+			parts = append(parts, n+": "+Format(v))
+		}
+	}
+
+	return s.Type + " { " + strings.Join(parts, ", ") + " }"
+}
+
+func formatVariant(v *Variant) string {
+	name := v.Name
+	if v.Enum != optionEnum {
+		name = v.Enum + "::" + name
+	}
+
+	if len(v.Fields) == 0 {
+		return name
+	}
+
+	parts := make([]string, len(v.Fields))
+	for i, f := range v.Fields {
+		parts[i] = Format(f)
+	}
+
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// construct builds variant name of enum from its n field arguments.
+func (in *Interpreter) construct(enum, name string, n int, exprs []parser.Expression, env *scope, at parser.Position) (Value, error) {
+	if len(exprs) != n {
+		return nil, &Failure{Pos: at, Message: fmt.Sprintf("%s takes %d fields, got %d", formatVariant(&Variant{Enum: enum, Name: name}), n, len(exprs))}
+	}
+
+	fields, err := in.evalArgs(exprs, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Variant{Enum: enum, Name: name, Fields: fields}, nil
+}
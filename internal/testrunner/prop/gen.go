@@ -0,0 +1,365 @@
+package prop
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Tree is a generated value together with the smaller values it can shrink
+// to. Children are computed on demand, most aggressive shrink first.
+type Tree[T any] struct {
+	Value  T
+	shrink func() []Tree[T]
+}
+
+// NewTree returns a tree for v whose children are produced by shrink, which
+// may be nil.
+func NewTree[T any](v T, shrink func() []Tree[T]) Tree[T] {
+	return Tree[T]{Value: v, shrink: shrink}
+}
+
+// Children returns the shrink candidates of t.
+func (t Tree[T]) Children() []Tree[T] {
+	if t.shrink == nil {
+		return nil
+	}
+
+	return t.shrink()
+}
+
+// Gen produces values with integrated shrinking: every value comes with its
+// own shrink tree, so shrinking respects how the value was built, including
+// Map and Filter. A Generator and Shrinker pair can be lifted with
+// FromGenerator.
+type Gen[T any] func(r *rand.Rand, size int) Tree[T]
+
+// FromGenerator combines a generator with an optional shrinker.
+func FromGenerator[T any](gen Generator[T], shrink Shrinker[T]) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		return unfold(gen(r, size), shrink)
+	}
+}
+
+func unfold[T any](v T, shrink Shrinker[T]) Tree[T] {
+	if shrink == nil {
+		return NewTree(v, nil)
+	}
+
+	return NewTree(v, func() []Tree[T] {
+		cs := shrink(v)
+		out := make([]Tree[T], len(cs))
+
+		for i, c := range cs {
+			out[i] = unfold(c, shrink)
+		}
+
+		return out
+	})
+}
+
+// Int generates integers like GenInt and shrinks them toward zero.
+func Int() Gen[int] {
+	gen := GenInt()
+
+	return func(r *rand.Rand, size int) Tree[int] {
+		return intTree(0, gen(r, size))
+	}
+}
+
+// IntRange generates integers in [lo, hi] and shrinks them toward the
+// value closest to zero.
+func IntRange(lo, hi int) Gen[int] {
+	if lo > hi {
+		panic(fmt.Sprintf("prop: IntRange(%d, %d): empty range", lo, hi))
+	}
+
+	origin := lo
+	if lo < 0 {
+		origin = min(0, hi)
+	}
+
+	return func(r *rand.Rand, _ int) Tree[int] {
+		return intTree(origin, lo+int(r.Int63n(int64(hi-lo)+1)))
+	}
+}
+
+// intTree shrinks x toward dest by halving the distance: dest first, then
+// ever closer to x.
+func intTree(dest, x int) Tree[int] {
+	return NewTree(x, func() []Tree[int] {
+		var out []Tree[int]
+
+		for diff := x - dest; diff != 0; diff /= 2 {
+			out = append(out, intTree(dest, x-diff))
+		}
+
+		return out
+	})
+}
+
+// Bool generates booleans and shrinks true to false.
+func Bool() Gen[bool] {
+	return func(r *rand.Rand, _ int) Tree[bool] {
+		if r.Intn(2) == 0 {
+			return NewTree(false, nil)
+		}
+
+		return NewTree(true, func() []Tree[bool] { return []Tree[bool]{NewTree(false, nil)} })
+	}
+}
+
+// Const always generates v.
+func Const[T any](v T) Gen[T] {
+	return func(*rand.Rand, int) Tree[T] { return NewTree(v, nil) }
+}
+
+// Elements picks one of vs and shrinks toward the earlier ones.
+func Elements[T any](vs ...T) Gen[T] {
+	if len(vs) == 0 {
+		panic("prop: Elements needs at least one value")
+	}
+
+	idx := IntRange(0, len(vs)-1)
+
+	return Map(idx, func(i int) T { return vs[i] })
+}
+
+// SliceOf generates slices of up to size elements. Shrinking removes runs
+// of elements, then shrinks the remaining elements one at a time.
+func SliceOf[T any](elem Gen[T]) Gen[[]T] {
+	return func(r *rand.Rand, size int) Tree[[]T] {
+		elems := make([]Tree[T], r.Intn(max(0, size)+1))
+		for i := range elems {
+			elems[i] = elem(r, size)
+		}
+
+		return sliceTree(elems)
+	}
+}
+
+func sliceTree[T any](elems []Tree[T]) Tree[[]T] {
+	v := make([]T, len(elems))
+	for i, e := range elems {
+		v[i] = e.Value
+	}
+
+	return NewTree(v, func() []Tree[[]T] {
+		var out []Tree[[]T]
+
+		for _, next := range shrinkTrees(elems) {
+			out = append(out, sliceTree(next))
+		}
+
+		return out
+	})
+}
+
+// shrinkTrees lists the ways to shrink a sequence of trees: removing runs
+// of elements, halves first, then replacing one element by a child.
+func shrinkTrees[T any](elems []Tree[T]) [][]Tree[T] {
+	var out [][]Tree[T]
+
+	for k := len(elems); k > 0; k /= 2 {
+		for at := 0; at+k <= len(elems); at += k {
+			out = append(out, append(append([]Tree[T](nil), elems[:at]...), elems[at+k:]...))
+		}
+	}
+
+	for i, e := range elems {
+		for _, c := range e.Children() {
+			next := append([]Tree[T](nil), elems...)
+			next[i] = c
+			out = append(out, next)
+		}
+	}
+
+	return out
+}
+
+// Map transforms generated values; shrinking happens on the input and is
+// mapped through f.
+func Map[A, B any](g Gen[A], f func(A) B) Gen[B] {
+	return func(r *rand.Rand, size int) Tree[B] {
+		return mapTree(g(r, size), f)
+	}
+}
+
+func mapTree[A, B any](t Tree[A], f func(A) B) Tree[B] {
+	return NewTree(f(t.Value), func() []Tree[B] {
+		cs := t.Children()
+		out := make([]Tree[B], len(cs))
+
+		for i, c := range cs {
+			out[i] = mapTree(c, f)
+		}
+
+		return out
+	})
+}
+
+// maxDiscards bounds how often Filter regenerates a value.
+const maxDiscards = 100
+
+// Filter keeps the values satisfying pred, shrinks included. It panics when
+// pred rejects maxDiscards values in a row, which means the filter is too
+// strict for the generator.
+func Filter[T any](g Gen[T], pred func(T) bool) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		for i := 0; i < maxDiscards; i++ {
+			// grow the size a little so that small sizes can escape.
+			if t := g(r, size+i/10); pred(t.Value) {
+				return filterTree(t, pred)
+			}
+		}
+
+		panic(fmt.Sprintf("prop: Filter discarded %d values in a row", maxDiscards))
+	}
+}
+
+func filterTree[T any](t Tree[T], pred func(T) bool) Tree[T] {
+	return NewTree(t.Value, func() []Tree[T] {
+		var out []Tree[T]
+
+		for _, c := range t.Children() {
+			if pred(c.Value) {
+				out = append(out, filterTree(c, pred))
+			}
+		}
+
+		return out
+	})
+}
+
+// OneOf picks one of gs with equal probability.
+func OneOf[T any](gs ...Gen[T]) Gen[T] {
+	if len(gs) == 0 {
+		panic("prop: OneOf needs at least one generator")
+	}
+
+	return func(r *rand.Rand, size int) Tree[T] {
+		return gs[r.Intn(len(gs))](r, size)
+	}
+}
+
+// Weighted is a generator with a relative weight for Frequency.
+type Weighted[T any] struct {
+	Gen    Gen[T]
+	Weight int
+}
+
+// Frequency picks one of ws with probability proportional to its weight.
+func Frequency[T any](ws ...Weighted[T]) Gen[T] {
+	total := 0
+
+	for _, w := range ws {
+		if w.Weight < 0 {
+			panic("prop: Frequency with a negative weight")
+		}
+
+		total += w.Weight
+	}
+
+	if total == 0 {
+		panic("prop: Frequency needs a positive total weight")
+	}
+
+	return func(r *rand.Rand, size int) Tree[T] {
+		n := r.Intn(total)
+
+		for _, w := range ws {
+			if n < w.Weight {
+				return w.Gen(r, size)
+			}
+
+			n -= w.Weight
+		}
+
+		panic("unreachable")
+	}
+}
+
+// Sized builds a generator from the size hint.
+func Sized[T any](f func(size int) Gen[T]) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		return f(size)(r, size)
+	}
+}
+
+// Recursive generates recursive structures such as trees or expressions.
+// branch receives a generator for the substructures, which halves the size
+// on every level; once the size drops to 1 only leaf is used, so generation
+// always terminates.
+func Recursive[T any](leaf Gen[T], branch func(self Gen[T]) Gen[T]) Gen[T] {
+	var self Gen[T]
+
+	smaller := func(r *rand.Rand, size int) Tree[T] { return self(r, size/2) }
+	node := branch(smaller)
+
+	self = func(r *rand.Rand, size int) Tree[T] {
+		if size <= 1 || r.Intn(2) == 0 {
+			return leaf(r, size)
+		}
+
+		return node(r, size)
+	}
+
+	return self
+}
+
+// Tuple2 holds the arguments of a two-argument property.
+type Tuple2[A, B any] struct {
+	A A
+	B B
+}
+
+// Tuple3 holds the arguments of a three-argument property.
+type Tuple3[A, B, C any] struct {
+	A A
+	B B
+	C C
+}
+
+// Tuple4 holds the arguments of a four-argument property.
+type Tuple4[A, B, C, D any] struct {
+	A A
+	B B
+	C C
+	D D
+}
+
+// Zip2 generates pairs; shrinking shrinks one component at a time.
+func Zip2[A, B any](ga Gen[A], gb Gen[B]) Gen[Tuple2[A, B]] {
+	return func(r *rand.Rand, size int) Tree[Tuple2[A, B]] {
+		return zipTree(ga(r, size), gb(r, size), func(a A, b B) Tuple2[A, B] { return Tuple2[A, B]{a, b} })
+	}
+}
+
+// Zip3 generates triples; shrinking shrinks one component at a time.
+func Zip3[A, B, C any](ga Gen[A], gb Gen[B], gc Gen[C]) Gen[Tuple3[A, B, C]] {
+	return Map(Zip2(Zip2(ga, gb), gc), func(t Tuple2[Tuple2[A, B], C]) Tuple3[A, B, C] {
+		return Tuple3[A, B, C]{t.A.A, t.A.B, t.B}
+	})
+}
+
+// Zip4 generates quadruples; shrinking shrinks one component at a time.
+func Zip4[A, B, C, D any](ga Gen[A], gb Gen[B], gc Gen[C], gd Gen[D]) Gen[Tuple4[A, B, C, D]] {
+	return Map(Zip2(Zip2(ga, gb), Zip2(gc, gd)), func(t Tuple2[Tuple2[A, B], Tuple2[C, D]]) Tuple4[A, B, C, D] {
+		return Tuple4[A, B, C, D]{t.A.A, t.A.B, t.B.A, t.B.B}
+	})
+}
+
+func zipTree[A, B, T any](ta Tree[A], tb Tree[B], f func(A, B) T) Tree[T] {
+	return NewTree(f(ta.Value, tb.Value), func() []Tree[T] {
+		var out []Tree[T]
+
+		for _, c := range ta.Children() {
+			out = append(out, zipTree(c, tb, f))
+		}
+
+		for _, c := range tb.Children() {
+			out = append(out, zipTree(ta, c, f))
+		}
+
+		return out
+	})
+}
@@ -0,0 +1,123 @@
+package prop
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestForAll2_ShrinksBothArguments(t *testing.T) {
+	res := ForAll2(IntRange(0, 1000), IntRange(0, 1000), func(a, b int) bool {
+		return a < 10 || b < 20
+	}, Options{Trials: 500, Seed: 1, MaxShrinkTime: 2 * time.Second})
+	if !res.Failed {
+		t.Fatalf("expected a failure")
+	}
+
+	if got := res.ShrunkInput.(Tuple2[int, int]); got != (Tuple2[int, int]{10, 20}) {
+		t.Fatalf("expected {10 20}, got %v", got)
+	}
+}
+
+func TestForAll3_Passes(t *testing.T) {
+	res := ForAll3(Int(), Int(), Int(), func(a, b, c int) bool {
+		return (a+b)+c == a+(b+c)
+	}, Options{Trials: 200, Seed: 1})
+	if res.Failed || res.PassedTrials != 200 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+}
+
+func TestFilter_ShrinksWithinFilter(t *testing.T) {
+	even := Filter(IntRange(0, 1000), func(n int) bool { return n%2 == 0 })
+
+	res := ForAll(even, func(n int) bool { return n < 101 }, Options{Trials: 500, Seed: 2})
+	if !res.Failed {
+		t.Fatalf("expected a failure")
+	}
+
+	if got := res.ShrunkInput.(int); got != 102 {
+		t.Fatalf("expected 102, got %d", got)
+	}
+}
+
+func TestMap_SliceShrinksToMinimal(t *testing.T) {
+	sums := Map(SliceOf(IntRange(0, 100)), func(xs []int) []int { return xs })
+
+	res := ForAll(sums, func(xs []int) bool {
+		sum := 0
+		for _, x := range xs {
+			sum += x
+		}
+
+		return sum < 50
+	}, Options{Trials: 500, Seed: 3, MaxShrinkRounds: 1000})
+	if !res.Failed {
+		t.Fatalf("expected a failure")
+	}
+
+	if got := res.ShrunkInput.([]int); len(got) != 1 || got[0] != 50 {
+		t.Fatalf("expected [50], got %v", got)
+	}
+}
+
+type expr struct {
+	left, right *expr
+	val         int
+}
+
+func (e *expr) depth() int {
+	if e.left == nil {
+		return 1
+	}
+
+	return 1 + max(e.left.depth(), e.right.depth())
+}
+
+func TestRecursive_Terminates(t *testing.T) {
+	leaf := Map(IntRange(0, 9), func(n int) *expr { return &expr{val: n} })
+	gen := Recursive(leaf, func(self Gen[*expr]) Gen[*expr] {
+		return Map(Zip2(self, self), func(p Tuple2[*expr, *expr]) *expr { return &expr{left: p.A, right: p.B} })
+	})
+
+	r := rand.New(rand.NewSource(4))
+	deep := 0
+
+	for i := 0; i < 200; i++ {
+		d := gen(r, 64).Value.depth()
+		if d > 8 {
+			t.Fatalf("depth %d exceeds the size bound", d)
+		}
+
+		deep = max(deep, d)
+	}
+
+	if deep < 3 {
+		t.Fatalf("expected some nesting, max depth %d", deep)
+	}
+}
+
+func TestFrequency_Weights(t *testing.T) {
+	gen := Frequency(Weighted[string]{Gen: Const("a"), Weight: 9}, Weighted[string]{Gen: Const("b"), Weight: 1}, Weighted[string]{Gen: Elements("c"), Weight: 0})
+	r := rand.New(rand.NewSource(5))
+	counts := map[string]int{}
+
+	for i := 0; i < 1000; i++ {
+		counts[gen(r, 10).Value]++
+	}
+
+	if counts["c"] != 0 || counts["a"] < 800 || counts["b"] < 50 {
+		t.Fatalf("unexpected distribution %v", counts)
+	}
+
+	one := OneOf(Const(1), Const(2))
+	seen := map[int]bool{}
+
+	for i := 0; i < 100; i++ {
+		seen[one(r, 10).Value] = true
+	}
+
+	if !seen[1] || !seen[2] {
+		t.Fatalf("OneOf did not use every generator: %v", seen)
+	}
+}
@@ -26,6 +26,8 @@ type Options struct {
 	Parallelism     int           // number of workers; <=0 means GOMAXPROCS
 	MaxShrinkRounds int           // limit for shrinking attempts
 	MaxShrinkTime   time.Duration // wall time limit for shrinking; 0 to disable
+	Branches        int           // parallel branches in CheckParallel; <=0 means 2
+	Repeat          int           // runs of each program in CheckParallel; <=0 means 20
 }
 
 // Result is the outcome of a property check.
@@ -39,8 +41,42 @@ type Result struct {
 	Failed       bool
 }
 
+// Property2 is a binary property predicate.
+type Property2[A, B any] func(a A, b B) bool
+
+// Property3 is a ternary property predicate.
+type Property3[A, B, C any] func(a A, b B, c C) bool
+
+// Property4 is a four-argument property predicate.
+type Property4[A, B, C, D any] func(a A, b B, c C, d D) bool
+
 // ForAll1 checks a unary property with the provided generator and optional shrinker.
 func ForAll1[A any](genA Generator[A], shrinkA Shrinker[A], prop Property1[A], opts Options) Result {
+	return ForAll(FromGenerator(genA, shrinkA), prop, opts)
+}
+
+// ForAll2 checks a binary property. On failure FailingInput and ShrunkInput
+// hold a Tuple2.
+func ForAll2[A, B any](ga Gen[A], gb Gen[B], prop Property2[A, B], opts Options) Result {
+	return ForAll(Zip2(ga, gb), func(t Tuple2[A, B]) bool { return prop(t.A, t.B) }, opts)
+}
+
+// ForAll3 checks a ternary property. On failure FailingInput and
+// ShrunkInput hold a Tuple3.
+func ForAll3[A, B, C any](ga Gen[A], gb Gen[B], gc Gen[C], prop Property3[A, B, C], opts Options) Result {
+	return ForAll(Zip3(ga, gb, gc), func(t Tuple3[A, B, C]) bool { return prop(t.A, t.B, t.C) }, opts)
+}
+
+// ForAll4 checks a four-argument property. On failure FailingInput and
+// ShrunkInput hold a Tuple4.
+func ForAll4[A, B, C, D any](ga Gen[A], gb Gen[B], gc Gen[C], gd Gen[D], prop Property4[A, B, C, D], opts Options) Result {
+	return ForAll(Zip4(ga, gb, gc, gd), func(t Tuple4[A, B, C, D]) bool { return prop(t.A, t.B, t.C, t.D) }, opts)
+}
+
+// ForAll checks a property of values drawn from g. The first failing value
+// is shrunk along its shrink tree: the first child that still fails
+// replaces it, until no child fails or the shrink limits are reached.
+func ForAll[T any](g Gen[T], prop func(T) bool, opts Options) Result {
 	start := time.Now()
 
 	if opts.Trials <= 0 {
@@ -72,7 +108,7 @@ func ForAll1[A any](genA Generator[A], shrinkA Shrinker[A], prop Property1[A], o
 	type task struct{ idx int }
 
 	type outcome struct {
-		a   A
+		t   Tree[T]
 		idx int
 		ok  bool
 	}
@@ -86,10 +122,10 @@ func ForAll1[A any](genA Generator[A], shrinkA Shrinker[A], prop Property1[A], o
 			for t := range tasks {
 				// derive deterministic seed per trial and worker.
 				r := rand.New(rand.NewSource(deriveSeed(opts.Seed, t.idx)))
-				a := genA(r, opts.Size)
-				ok := prop(a)
+				tree := g(r, opts.Size)
+				ok := prop(tree.Value)
 				select {
-				case outs <- outcome{idx: t.idx, a: a, ok: ok}:
+				case outs <- outcome{idx: t.idx, t: tree, ok: ok}:
 				case <-ctx.Done():
 					return
 				}
@@ -124,55 +160,53 @@ func ForAll1[A any](genA Generator[A], shrinkA Shrinker[A], prop Property1[A], o
 		}
 		// first failure: stop generating and shrink.
 		res.Failed = true
-		res.FailingInput = o.a
+		res.FailingInput = o.t.Value
 
 		cancel()
 		// Shrink synchronously.
-		if shrinkA != nil {
-			deadline := time.Time{}
-			if opts.MaxShrinkTime > 0 {
-				deadline = time.Now().Add(opts.MaxShrinkTime)
-			}
-
-			best := o.a
-			rounds := 0
-
-			for {
-				if opts.MaxShrinkTime > 0 && time.Now().After(deadline) {
-					break
-				}
+		deadline := time.Time{}
+		if opts.MaxShrinkTime > 0 {
+			deadline = time.Now().Add(opts.MaxShrinkTime)
+		}
 
-				if rounds >= opts.MaxShrinkRounds {
-					break
-				}
+		best := o.t
+		rounds := 0
 
-				candidates := shrinkA(best)
-				if len(candidates) == 0 {
-					break
-				}
+		for {
+			if opts.MaxShrinkTime > 0 && time.Now().After(deadline) {
+				break
+			}
 
-				progressed := false
+			if rounds >= opts.MaxShrinkRounds {
+				break
+			}
 
-				for _, c := range candidates {
-					if !prop(c) {
-						best = c
-						progressed = true
+			candidates := best.Children()
+			if len(candidates) == 0 {
+				break
+			}
 
-						break
-					}
-				}
+			progressed := false
 
-				rounds++
+			for _, c := range candidates {
+				if !prop(c.Value) {
+					best = c
+					progressed = true
 
-				if !progressed {
 					break
 				}
 			}
 
-			res.ShrunkInput = best
-			res.ShrinkRounds = rounds
+			rounds++
+
+			if !progressed {
+				break
+			}
 		}
 
+		res.ShrunkInput = best.Value
+		res.ShrinkRounds = rounds
+
 		break
 	}
 
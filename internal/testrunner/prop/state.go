@@ -0,0 +1,437 @@
+package prop
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Command is one operation of a stateful test. Run performs it on the
+// system under test and Next on the model; the two results must be equal.
+// Next must return a new model rather than modify m, since model states are
+// reused while shrinking and while searching for linearizations.
+type Command[S, M any] struct {
+	Name string
+	Pre  func(m M) bool // nil means the command may always run
+	Run  func(sys S) any
+	Next func(m M) (M, any) // next model state and expected result
+}
+
+// Machine describes a system under test and a model it must agree with.
+type Machine[S, M any] struct {
+	Init     func() M                     // initial model state
+	New      func() S                     // fresh system under test
+	Cleanup  func(sys S)                  // optional
+	Commands func(m M) Gen[Command[S, M]] // commands that may follow in model state m
+	Equal    func(got, want any) bool     // compares results; nil means reflect.DeepEqual
+}
+
+// Program is a command sequence run from the initial state, followed in
+// parallel tests by branches that run concurrently.
+type Program[S, M any] struct {
+	Prefix   []Command[S, M]
+	Branches [][]Command[S, M]
+}
+
+func (p Program[S, M]) String() string {
+	names := func(cs []Command[S, M]) string {
+		out := make([]string, len(cs))
+		for i, c := range cs {
+			out[i] = c.Name
+		}
+
+		return strings.Join(out, "; ")
+	}
+
+	var b strings.Builder
+
+	b.WriteString(names(p.Prefix))
+
+	if len(p.Branches) > 0 {
+		b.WriteString(" then in parallel:")
+
+		for _, br := range p.Branches {
+			b.WriteString(" [" + names(br) + "]")
+		}
+	}
+
+	return b.String()
+}
+
+// CheckCommands runs random command sequences against fresh systems and
+// the model, failing on the first result that differs. FailingInput and
+// ShrunkInput hold the Program.
+func CheckCommands[S, M any](m Machine[S, M], opts Options) Result {
+	return ForAll(m.programs(0, 0), m.runSequential, opts)
+}
+
+// CheckParallel runs a random prefix, then opts.Branches command sequences
+// concurrently, and checks that the results are linearizable: some order
+// of the concurrent commands that respects real time, each command taking
+// effect between its call and its return, produces the same results on the
+// model. Each program runs opts.Repeat times since interleavings vary.
+// Branches stay short, as the search is exponential in their length.
+// Parallelism defaults to 1 so that the branches get the processors.
+func CheckParallel[S, M any](m Machine[S, M], opts Options) Result {
+	if opts.Branches <= 0 {
+		opts.Branches = 2
+	}
+
+	if opts.Repeat <= 0 {
+		opts.Repeat = 20
+	}
+
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = 1
+	}
+
+	branchLen := 4
+	if opts.Branches > 2 {
+		branchLen = 3
+	}
+
+	return ForAll(m.programs(opts.Branches, branchLen), func(p Program[S, M]) bool {
+		for i := 0; i < opts.Repeat; i++ {
+			if !m.runParallel(p) {
+				return false
+			}
+		}
+
+		return true
+	}, opts)
+}
+
+func (m Machine[S, M]) equal(got, want any) bool {
+	if m.Equal != nil {
+		return m.Equal(got, want)
+	}
+
+	return reflect.DeepEqual(got, want)
+}
+
+// programs generates programs with the given number of branches of up to
+// branchLen commands each; without branches the prefix takes the whole size.
+func (m Machine[S, M]) programs(branches, branchLen int) Gen[Program[S, M]] {
+	return func(r *rand.Rand, size int) Tree[Program[S, M]] {
+		n := r.Intn(max(0, size) + 1)
+		if branches > 0 {
+			n = r.Intn(max(0, size)/3 + 1)
+		}
+
+		var prefix []Tree[Command[S, M]]
+
+		model := m.Init()
+
+		for i := 0; i < n; i++ {
+			t, ok := m.command(r, size, model, func(c Command[S, M]) bool { return c.Pre == nil || c.Pre(model) })
+			if !ok {
+				break
+			}
+
+			prefix = append(prefix, t)
+			model, _ = t.Value.Next(model)
+		}
+
+		bs := make([][]Tree[Command[S, M]], branches)
+
+		for i := 0; i < branches*branchLen; i++ {
+			b := i % branches
+
+			t, ok := m.command(r, size, model, func(c Command[S, M]) bool {
+				cs := make([][]Command[S, M], branches)
+				for j := range bs {
+					cs[j] = values(bs[j])
+				}
+
+				cs[b] = append(cs[b], c)
+
+				return m.interleavingsValid(model, cs)
+			})
+			if ok {
+				bs[b] = append(bs[b], t)
+			}
+		}
+
+		return m.programTree(prefix, bs)
+	}
+}
+
+// command draws a command from the generator for model that satisfies ok.
+func (m Machine[S, M]) command(r *rand.Rand, size int, model M, ok func(Command[S, M]) bool) (Tree[Command[S, M]], bool) {
+	g := m.Commands(model)
+
+	for i := 0; i < maxDiscards; i++ {
+		if t := g(r, size); ok(t.Value) {
+			return t, true
+		}
+	}
+
+	return Tree[Command[S, M]]{}, false
+}
+
+// programTree shrinks the prefix and the branches one at a time, keeping
+// only programs whose preconditions hold.
+func (m Machine[S, M]) programTree(prefix []Tree[Command[S, M]], branches [][]Tree[Command[S, M]]) Tree[Program[S, M]] {
+	p := Program[S, M]{Prefix: values(prefix)}
+	if len(branches) > 0 {
+		p.Branches = make([][]Command[S, M], len(branches))
+		for i, b := range branches {
+			p.Branches[i] = values(b)
+		}
+	}
+
+	return NewTree(p, func() []Tree[Program[S, M]] {
+		var out []Tree[Program[S, M]]
+
+		add := func(pre []Tree[Command[S, M]], bs [][]Tree[Command[S, M]]) {
+			if c := m.programTree(pre, bs); m.valid(c.Value) {
+				out = append(out, c)
+			}
+		}
+
+		for _, pre := range shrinkTrees(prefix) {
+			add(pre, branches)
+		}
+
+		for i, b := range branches {
+			for _, nb := range shrinkTrees(b) {
+				bs := append([][]Tree[Command[S, M]](nil), branches...)
+				bs[i] = nb
+				add(prefix, bs)
+			}
+		}
+
+		return out
+	})
+}
+
+func values[T any](ts []Tree[T]) []T {
+	out := make([]T, len(ts))
+	for i, t := range ts {
+		out[i] = t.Value
+	}
+
+	return out
+}
+
+func (m Machine[S, M]) valid(p Program[S, M]) bool {
+	model := m.Init()
+
+	for _, c := range p.Prefix {
+		if c.Pre != nil && !c.Pre(model) {
+			return false
+		}
+
+		model, _ = c.Next(model)
+	}
+
+	return m.interleavingsValid(model, p.Branches)
+}
+
+// interleavingsValid reports whether the preconditions hold in every
+// interleaving of the branches, starting from model.
+func (m Machine[S, M]) interleavingsValid(model M, branches [][]Command[S, M]) bool {
+	hasPre := false
+
+	for _, b := range branches {
+		for _, c := range b {
+			hasPre = hasPre || c.Pre != nil
+		}
+	}
+
+	if !hasPre {
+		return true
+	}
+
+	pos := make([]int, len(branches))
+
+	var walk func(model M) bool
+
+	walk = func(model M) bool {
+		for i, b := range branches {
+			if pos[i] == len(b) {
+				continue
+			}
+
+			c := b[pos[i]]
+			if c.Pre != nil && !c.Pre(model) {
+				return false
+			}
+
+			next, _ := c.Next(model)
+
+			pos[i]++
+			ok := walk(next)
+			pos[i]--
+
+			if !ok {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return walk(model)
+}
+
+func (m Machine[S, M]) runSequential(p Program[S, M]) bool {
+	sys := m.New()
+	if m.Cleanup != nil {
+		defer m.Cleanup(sys)
+	}
+
+	_, ok := m.runPrefix(sys, p.Prefix)
+
+	return ok
+}
+
+// runPrefix runs cmds one after another and returns the model state after
+// them, or false at the first result that differs from the model.
+func (m Machine[S, M]) runPrefix(sys S, cmds []Command[S, M]) (M, bool) {
+	model := m.Init()
+
+	for _, c := range cmds {
+		var want any
+
+		got := c.Run(sys)
+		if model, want = c.Next(model); !m.equal(got, want) {
+			return model, false
+		}
+	}
+
+	return model, true
+}
+
+// event is a completed concurrent command; call and ret are ticks of a
+// logical clock shared by the branches.
+type event struct {
+	result    any
+	call, ret int64
+}
+
+func (m Machine[S, M]) runParallel(p Program[S, M]) bool {
+	sys := m.New()
+	if m.Cleanup != nil {
+		defer m.Cleanup(sys)
+	}
+
+	model, ok := m.runPrefix(sys, p.Prefix)
+	if !ok {
+		return false
+	}
+
+	var (
+		clock atomic.Int64
+		wg    sync.WaitGroup
+	)
+
+	start := make(chan struct{})
+	events := make([][]event, len(p.Branches))
+
+	for i, b := range p.Branches {
+		events[i] = make([]event, len(b))
+
+		wg.Add(1)
+
+		go func(i int, b []Command[S, M]) {
+			defer wg.Done()
+
+			<-start
+
+			for j, c := range b {
+				call := clock.Add(1)
+				res := c.Run(sys)
+				events[i][j] = event{call: call, ret: clock.Add(1), result: res}
+			}
+		}(i, b)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return m.linearizable(model, p.Branches, events)
+}
+
+// linearizable searches for an order of the branch commands that agrees
+// with the model. A command may go next only if no other pending command
+// returned before it was called.
+func (m Machine[S, M]) linearizable(model M, branches [][]Command[S, M], events [][]event) bool {
+	pos := make([]int, len(branches))
+	left := 0
+
+	for _, b := range branches {
+		left += len(b)
+	}
+
+	var search func(model M, left int) bool
+
+	search = func(model M, left int) bool {
+		if left == 0 {
+			return true
+		}
+
+		for i, b := range branches {
+			if pos[i] == len(b) {
+				continue
+			}
+
+			e := events[i][pos[i]]
+			if !minimal(e, pos, events) {
+				continue
+			}
+
+			c := b[pos[i]]
+			if c.Pre != nil && !c.Pre(model) {
+				continue
+			}
+
+			next, want := c.Next(model)
+			if !m.equal(e.result, want) {
+				continue
+			}
+
+			pos[i]++
+			ok := search(next, left-1)
+			pos[i]--
+
+			if ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return search(model, left)
+}
+
+// minimal reports whether no pending command returned before e was called.
+// Within a branch commands are sequential, so checking the next pending
+// command of every branch is enough.
+func minimal(e event, pos []int, events [][]event) bool {
+	for i, es := range events {
+		if pos[i] < len(es) && es[pos[i]].ret < e.call {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CommandName formats a command and its arguments for Command.Name.
+func CommandName(name string, args ...any) string {
+	if len(args) == 0 {
+		return name
+	}
+
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprint(a)
+	}
+
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
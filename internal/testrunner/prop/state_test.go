@@ -0,0 +1,330 @@
+package prop
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orizon-lang/orizon/internal/runtime/concurrency"
+	"github.com/orizon-lang/orizon/internal/runtime/stm"
+)
+
+type queueCmd = Command[*concurrency.MPMCQueue[int], []int]
+
+const queueCap = 4
+
+// queueMachine models a bounded FIFO queue as a slice.
+func queueMachine() Machine[*concurrency.MPMCQueue[int], []int] {
+	dequeue := queueCmd{
+		Name: "Dequeue",
+		Run: func(q *concurrency.MPMCQueue[int]) any {
+			var v int
+			ok := q.Dequeue(&v)
+
+			return [2]any{v, ok}
+		},
+		Next: func(m []int) ([]int, any) {
+			if len(m) == 0 {
+				return m, [2]any{0, false}
+			}
+
+			return m[1:], [2]any{m[0], true}
+		},
+	}
+
+	enqueue := Map(IntRange(1, 99), func(v int) queueCmd {
+		return queueCmd{
+			Name: CommandName("Enqueue", v),
+			Run:  func(q *concurrency.MPMCQueue[int]) any { return q.Enqueue(v) },
+			Next: func(m []int) ([]int, any) {
+				if len(m) == queueCap {
+					return m, false
+				}
+
+				return append(append([]int(nil), m...), v), true
+			},
+		}
+	})
+
+	return Machine[*concurrency.MPMCQueue[int], []int]{
+		Init:     func() []int { return nil },
+		New:      func() *concurrency.MPMCQueue[int] { return concurrency.NewMPMCQueue[int](queueCap) },
+		Commands: func([]int) Gen[queueCmd] { return OneOf(enqueue, Const(dequeue)) },
+	}
+}
+
+func TestCheckCommands_MPMCQueue(t *testing.T) {
+	res := CheckCommands(queueMachine(), Options{Trials: 200, Seed: 1})
+	if res.Failed {
+		t.Fatalf("queue disagrees with its model: %v", res.ShrunkInput)
+	}
+}
+
+func TestCheckParallel_MPMCQueue(t *testing.T) {
+	res := CheckParallel(queueMachine(), Options{Trials: 50, Seed: 1, Repeat: 10})
+	if res.Failed {
+		t.Fatalf("queue is not linearizable: %v", res.ShrunkInput)
+	}
+}
+
+type mapCmd = Command[*concurrency.LockFreeMap[string, int], map[string]int]
+
+// mapMachine models a map over a few keys so that commands collide.
+func mapMachine() Machine[*concurrency.LockFreeMap[string, int], map[string]int] {
+	keys := Elements("a", "b", "c")
+	with := func(m map[string]int, k string, v int, del bool) map[string]int {
+		out := make(map[string]int, len(m)+1)
+		for k, v := range m {
+			out[k] = v
+		}
+
+		if del {
+			delete(out, k)
+		} else {
+			out[k] = v
+		}
+
+		return out
+	}
+
+	load := Map(keys, func(k string) mapCmd {
+		return mapCmd{
+			Name: CommandName("Load", k),
+			Run: func(m *concurrency.LockFreeMap[string, int]) any {
+				v, ok := m.Load(k)
+
+				return [2]any{v, ok}
+			},
+			Next: func(m map[string]int) (map[string]int, any) {
+				v, ok := m[k]
+
+				return m, [2]any{v, ok}
+			},
+		}
+	})
+
+	store := Map(Zip2(keys, IntRange(1, 9)), func(p Tuple2[string, int]) mapCmd {
+		return mapCmd{
+			Name: CommandName("Store", p.A, p.B),
+			Run: func(m *concurrency.LockFreeMap[string, int]) any {
+				m.Store(p.A, p.B)
+
+				return nil
+			},
+			Next: func(m map[string]int) (map[string]int, any) { return with(m, p.A, p.B, false), nil },
+		}
+	})
+
+	loadOrStore := Map(Zip2(keys, IntRange(1, 9)), func(p Tuple2[string, int]) mapCmd {
+		return mapCmd{
+			Name: CommandName("LoadOrStore", p.A, p.B),
+			Run: func(m *concurrency.LockFreeMap[string, int]) any {
+				v, loaded := m.LoadOrStore(p.A, p.B)
+
+				return [2]any{v, loaded}
+			},
+			Next: func(m map[string]int) (map[string]int, any) {
+				if v, ok := m[p.A]; ok {
+					return m, [2]any{v, true}
+				}
+
+				return with(m, p.A, p.B, false), [2]any{p.B, false}
+			},
+		}
+	})
+
+	del := Map(keys, func(k string) mapCmd {
+		return mapCmd{
+			Name: CommandName("Delete", k),
+			Run:  func(m *concurrency.LockFreeMap[string, int]) any { return m.Delete(k) },
+			Next: func(m map[string]int) (map[string]int, any) {
+				_, ok := m[k]
+
+				return with(m, k, 0, true), ok
+			},
+		}
+	})
+
+	return Machine[*concurrency.LockFreeMap[string, int], map[string]int]{
+		Init: func() map[string]int { return map[string]int{} },
+		// a single bucket so that every key shares one list.
+		New: func() *concurrency.LockFreeMap[string, int] {
+			return concurrency.NewLockFreeMap[string, int](2, func(string) uint64 { return 0 })
+		},
+		Commands: func(map[string]int) Gen[mapCmd] { return OneOf(load, store, loadOrStore, del) },
+	}
+}
+
+func TestCheckCommands_LockFreeMap(t *testing.T) {
+	res := CheckCommands(mapMachine(), Options{Trials: 200, Seed: 1})
+	if res.Failed {
+		t.Fatalf("map disagrees with its model: %v", res.ShrunkInput)
+	}
+}
+
+type tvarCmd = Command[*stm.TVar[int], int]
+
+// tvarMachine models a TVar updated by single-variable transactions.
+func tvarMachine() Machine[*stm.TVar[int], int] {
+	read := tvarCmd{
+		Name: "Read",
+		Run: func(tv *stm.TVar[int]) any {
+			var v int
+
+			_ = stm.Run[int](0, func(tx *stm.Txn[int]) error {
+				v = tx.Read(tv)
+
+				return nil
+			})
+
+			return v
+		},
+		Next: func(m int) (int, any) { return m, m },
+	}
+
+	add := Map(IntRange(1, 9), func(d int) tvarCmd {
+		return tvarCmd{
+			Name: CommandName("Add", d),
+			Run: func(tv *stm.TVar[int]) any {
+				var old int
+
+				_ = stm.Run[int](0, func(tx *stm.Txn[int]) error {
+					old = tx.Read(tv)
+					tx.Write(tv, old+d)
+
+					return nil
+				})
+
+				return old
+			},
+			Next: func(m int) (int, any) { return m + d, m },
+		}
+	})
+
+	write := Map(IntRange(0, 9), func(v int) tvarCmd {
+		return tvarCmd{
+			Name: CommandName("Write", v),
+			Run: func(tv *stm.TVar[int]) any {
+				return stm.Run[int](0, func(tx *stm.Txn[int]) error {
+					tx.Write(tv, v)
+
+					return nil
+				})
+			},
+			Next: func(int) (int, any) { return v, error(nil) },
+		}
+	})
+
+	return Machine[*stm.TVar[int], int]{
+		Init: func() int { return 0 },
+		New:  func() *stm.TVar[int] { return stm.NewTVar(0) },
+		Commands: func(int) Gen[tvarCmd] {
+			return Frequency(Weighted[tvarCmd]{add, 3}, Weighted[tvarCmd]{Const(read), 2}, Weighted[tvarCmd]{write, 1})
+		},
+	}
+}
+
+func TestCheckCommands_TVar(t *testing.T) {
+	res := CheckCommands(tvarMachine(), Options{Trials: 200, Seed: 1})
+	if res.Failed {
+		t.Fatalf("TVar disagrees with its model: %v", res.ShrunkInput)
+	}
+}
+
+func TestCheckParallel_TVar(t *testing.T) {
+	res := CheckParallel(tvarMachine(), Options{Trials: 50, Seed: 1, Branches: 3, Repeat: 10})
+	if res.Failed {
+		t.Fatalf("TVar is not linearizable: %v", res.ShrunkInput)
+	}
+}
+
+// racyCounter reads and writes its count in separate critical sections,
+// so concurrent incr calls can lose updates.
+type racyCounter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *racyCounter) incr() int {
+	c.mu.Lock()
+	n := c.n
+	c.mu.Unlock()
+
+	time.Sleep(50 * time.Microsecond)
+
+	c.mu.Lock()
+	c.n = n + 1
+	c.mu.Unlock()
+
+	return n
+}
+
+func TestCheckParallel_FindsLostUpdate(t *testing.T) {
+	incr := Command[*racyCounter, int]{
+		Name: "Incr",
+		Run:  func(c *racyCounter) any { return c.incr() },
+		Next: func(m int) (int, any) { return m + 1, m },
+	}
+
+	m := Machine[*racyCounter, int]{
+		Init:     func() int { return 0 },
+		New:      func() *racyCounter { return &racyCounter{} },
+		Commands: func(int) Gen[Command[*racyCounter, int]] { return Const(incr) },
+	}
+
+	res := CheckParallel(m, Options{Trials: 20, Seed: 1})
+	if !res.Failed {
+		t.Fatalf("expected the lost update to be found")
+	}
+
+	// the smallest failing program runs one Incr in each of two branches.
+	if got := res.ShrunkInput.(Program[*racyCounter, int]).String(); !strings.HasSuffix(got, "then in parallel: [Incr] [Incr]") {
+		t.Fatalf("unexpected shrunk program %q", got)
+	}
+}
+
+func TestCheckCommands_Preconditions(t *testing.T) {
+	// pop is only valid on a non-empty stack; a broken pop is still found
+	// and the program shrinks to push then pop.
+	type stack = []int
+
+	push := Map(IntRange(1, 9), func(v int) Command[*[]int, stack] {
+		return Command[*[]int, stack]{
+			Name: CommandName("Push", v),
+			Run: func(s *[]int) any {
+				*s = append(*s, v)
+
+				return nil
+			},
+			Next: func(m stack) (stack, any) { return append(append(stack(nil), m...), v), nil },
+		}
+	})
+
+	pop := Command[*[]int, stack]{
+		Name: "Pop",
+		Pre:  func(m stack) bool { return len(m) > 0 },
+		Run: func(s *[]int) any {
+			v := (*s)[0] // bug: pops the bottom
+			*s = (*s)[1:]
+
+			return v
+		},
+		Next: func(m stack) (stack, any) { return m[:len(m)-1], m[len(m)-1] },
+	}
+
+	m := Machine[*[]int, stack]{
+		Init:     func() stack { return nil },
+		New:      func() *[]int { return new([]int) },
+		Commands: func(stack) Gen[Command[*[]int, stack]] { return OneOf(push, Const(pop)) },
+	}
+
+	res := CheckCommands(m, Options{Trials: 200, Seed: 1})
+	if !res.Failed {
+		t.Fatalf("expected the broken pop to be found")
+	}
+
+	if got := res.ShrunkInput.(Program[*[]int, stack]).String(); got != "Push(1); Push(2); Pop" {
+		t.Fatalf("unexpected shrunk program %q", got)
+	}
+}
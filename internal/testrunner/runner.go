@@ -15,6 +15,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/orizon-lang/orizon/internal/testrunner/coverage"
+	"github.com/orizon-lang/orizon/internal/testrunner/native"
 )
 
 // Event mirrors the subset of fields produced by `go test -json`.
@@ -59,6 +62,7 @@ type TestAttempt struct {
 // Options control the behavior of the test runner.
 type Options struct {
 	PackageRegex     string
+	BenchPattern     string
 	RunPattern       string
 	SnapshotDir      string
 	FileRegex        string
@@ -69,6 +73,7 @@ type Options struct {
 	ExtraArgs        []string
 	Parallel         int
 	Timeout          time.Duration
+	BenchTime        time.Duration
 	Retries          int
 	Race             bool
 	FailFast         bool
@@ -81,11 +86,14 @@ type Options struct {
 	JSON             bool
 	CleanupSnapshots bool
 	GoldenTests      bool
+	Native           bool   // run #[test] functions of .oriz files instead of `go test`
+	CoverDir         string // with Native: write Orizon coverage reports here
 }
 
 // Runner executes `go test -json` per package with concurrency and aggregates results.
 type Runner struct {
 	snapshotManager *SnapshotManager
+	cover           *coverage.Collector
 	opts            Options
 }
 
@@ -106,6 +114,10 @@ func New(opts Options) *Runner {
 		opts.Timeout = 10 * time.Minute
 	}
 
+	if opts.BenchTime <= 0 {
+		opts.BenchTime = time.Second
+	}
+
 	// Initialize snapshot manager if snapshot features are enabled.
 	var snapshotManager *SnapshotManager
 
@@ -124,9 +136,15 @@ func New(opts Options) *Runner {
 		snapshotManager = NewSnapshotManager(snapshotOptions)
 	}
 
+	var cover *coverage.Collector
+	if opts.Native && opts.CoverDir != "" {
+		cover = coverage.NewCollector()
+	}
+
 	return &Runner{
 		opts:            opts,
 		snapshotManager: snapshotManager,
+		cover:           cover,
 	}
 }
 
@@ -139,8 +157,19 @@ func (r *Runner) Run(ctx context.Context, out io.Writer) (Result, error) {
 	start := time.Now()
 
 	pkgs := append([]string(nil), r.opts.Packages...)
-	// Resolve ./... expansion via go list for stable ordering
-	expanded, err := r.goList(ctx, pkgs)
+	// Resolve ./... expansion via go list for stable ordering; native tests
+	// are grouped by the directories holding .oriz files instead.
+	var (
+		expanded []string
+		err      error
+	)
+
+	if r.opts.Native {
+		expanded, err = native.FindPackages(pkgs)
+	} else {
+		expanded, err = r.goList(ctx, pkgs)
+	}
+
 	if err != nil {
 		return Result{}, err
 	}
@@ -327,6 +356,25 @@ func (r *Runner) Run(ctx context.Context, out io.Writer) (Result, error) {
 	if !r.opts.JSON && out != nil {
 		r.writeSummary(out, results)
 	}
+	// Coverage reports are written whether or not the tests passed.
+	if r.cover != nil {
+		rep, err := r.cover.WriteReports(r.opts.CoverDir)
+		if err != nil {
+			return results, fmt.Errorf("coverage: %w", err)
+		}
+
+		if !r.opts.JSON && out != nil {
+			fmt.Fprintf(out, "coverage: %s (reports in %s)\n", rep.Totals(), r.opts.CoverDir)
+
+			if skipped := r.cover.Skipped(); len(skipped) > 0 {
+				fmt.Fprintf(out, "coverage: %d function(s) not instrumented, reported as not covered:\n", len(skipped))
+
+				for _, s := range skipped {
+					fmt.Fprintf(out, "  %s:%d %s: %s\n", s.File, s.Line, s.Function, s.Reason)
+				}
+			}
+		}
+	}
 
 	if results.Failed > 0 {
 		return results, errors.New("test failures")
@@ -432,6 +480,10 @@ func (r *Runner) writeSummaryJSON(path string, res Result) error {
 
 // runOne executes `go test -json` for a single package and aggregates its result.
 func (r *Runner) runOne(ctx context.Context, pkg string, out io.Writer) (PackageResult, error) {
+	if r.opts.Native {
+		return r.runNative(ctx, pkg, out)
+	}
+
 	args := []string{"test", "-json"}
 	if r.opts.Short {
 		args = append(args, "-short")
@@ -468,112 +520,138 @@ func (r *Runner) runOne(ctx context.Context, pkg string, out io.Writer) (Package
 		return PackageResult{}, err
 	}
 
-	pr := PackageResult{Name: pkg, Output: make([]Event, 0, 128), Tests: make(map[string][]TestAttempt)}
+	c := r.newPackageCollector(pkg, out)
 	dec := newJSONEventDecoder(pipe)
-	starts := make(map[string]time.Time)
-	outs := make(map[string]*strings.Builder)
 
 	for dec.Next() {
-		ev := dec.Event()
-		pr.Output = append(pr.Output, ev)
-		// Stream raw JSON if requested (optionally augmented with Orizon metadata).
-		if r.opts.JSON && out != nil {
-			// Marshal the original event again to ensure proper framing per line.
-			b, _ := json.Marshal(ev)
-			_, _ = out.Write(b)
-			_, _ = out.Write([]byte("\n"))
-		} else if out != nil && ev.Action == "output" && ev.Output != "" {
-			// In human-readable mode, forward test output lines with optional colors.
-			r.writeLine(out, ev)
-		}
-		// Aggregate counts on pass/fail/skip events and capture attempts
-		switch ev.Action {
-		case "pass":
-			if ev.Test != "" {
-				pr.Passed++
-
-				var dur time.Duration
-				if st, ok := starts[ev.Test]; ok {
-					dur = ev.Time.Sub(st)
-					if dur < 0 {
-						dur = 0
-					}
-				}
+		c.add(dec.Event())
+	}
 
-				var msg string
-				if b := outs[ev.Test]; b != nil {
-					msg = b.String()
-				}
+	if err := dec.Err(); err != nil {
+		_ = cmd.Process.Kill()
 
-				pr.Tests[ev.Test] = append(pr.Tests[ev.Test], TestAttempt{Outcome: "pass", Time: dur, Output: msg})
-				delete(starts, ev.Test)
-			} else if ev.Elapsed > 0 {
-				pr.Duration += time.Duration(ev.Elapsed * float64(time.Second))
-			}
-		case "fail":
-			if ev.Test != "" {
-				pr.Failed++
+		return c.pr, err
+	}
 
-				var dur time.Duration
-				if st, ok := starts[ev.Test]; ok {
-					dur = ev.Time.Sub(st)
-					if dur < 0 {
-						dur = 0
-					}
-				}
+	if err := cmd.Wait(); err != nil {
+		// go test exits non-zero on package failures; allow counts to indicate failure.
+	}
 
-				var msg string
-				if b := outs[ev.Test]; b != nil {
-					msg = b.String()
-				}
+	return c.pr, nil
+}
 
-				pr.Tests[ev.Test] = append(pr.Tests[ev.Test], TestAttempt{Outcome: "fail", Time: dur, Output: msg})
-				delete(starts, ev.Test)
-			} else if ev.Elapsed > 0 {
-				pr.Duration += time.Duration(ev.Elapsed * float64(time.Second))
-			}
-		case "skip":
-			if ev.Test != "" {
-				pr.Skipped++
+// packageCollector folds the test events of one package into a
+// PackageResult, forwarding them to the output as they arrive.
+type packageCollector struct {
+	r      *Runner
+	out    io.Writer
+	starts map[string]time.Time
+	outs   map[string]*strings.Builder
+	pr     PackageResult
+}
 
-				var dur time.Duration
-				if st, ok := starts[ev.Test]; ok {
-					dur = ev.Time.Sub(st)
-					if dur < 0 {
-						dur = 0
-					}
-				}
+func (r *Runner) newPackageCollector(pkg string, out io.Writer) *packageCollector {
+	return &packageCollector{
+		r:      r,
+		out:    out,
+		starts: make(map[string]time.Time),
+		outs:   make(map[string]*strings.Builder),
+		pr:     PackageResult{Name: pkg, Output: make([]Event, 0, 128), Tests: make(map[string][]TestAttempt)},
+	}
+}
 
-				var msg string
-				if b := outs[ev.Test]; b != nil {
-					msg = b.String()
+// add records one event.
+func (c *packageCollector) add(ev Event) {
+	c.pr.Output = append(c.pr.Output, ev)
+	// Stream raw JSON if requested (optionally augmented with Orizon metadata).
+	if c.r.opts.JSON && c.out != nil {
+		// Marshal the original event again to ensure proper framing per line.
+		b, _ := json.Marshal(ev)
+		_, _ = c.out.Write(b)
+		_, _ = c.out.Write([]byte("\n"))
+	} else if c.out != nil && ev.Action == "output" && ev.Output != "" {
+		// In human-readable mode, forward test output lines with optional colors.
+		c.r.writeLine(c.out, ev)
+	}
+	// Aggregate counts on pass/fail/skip events and capture attempts
+	switch ev.Action {
+	case "pass":
+		if ev.Test != "" {
+			c.pr.Passed++
+
+			var dur time.Duration
+			if st, ok := c.starts[ev.Test]; ok {
+				dur = ev.Time.Sub(st)
+				if dur < 0 {
+					dur = 0
 				}
+			}
 
-				pr.Tests[ev.Test] = append(pr.Tests[ev.Test], TestAttempt{Outcome: "skip", Time: dur, Output: msg})
-				delete(starts, ev.Test)
+			var msg string
+			if b := c.outs[ev.Test]; b != nil {
+				msg = b.String()
 			}
-		case "run":
-			if ev.Test != "" {
-				starts[ev.Test] = ev.Time
 
-				if outs[ev.Test] == nil {
-					outs[ev.Test] = &strings.Builder{}
+			c.pr.Tests[ev.Test] = append(c.pr.Tests[ev.Test], TestAttempt{Outcome: "pass", Time: dur, Output: msg})
+			delete(c.starts, ev.Test)
+		} else if ev.Elapsed > 0 {
+			c.pr.Duration += time.Duration(ev.Elapsed * float64(time.Second))
+		}
+	case "fail":
+		if ev.Test != "" {
+			c.pr.Failed++
+
+			var dur time.Duration
+			if st, ok := c.starts[ev.Test]; ok {
+				dur = ev.Time.Sub(st)
+				if dur < 0 {
+					dur = 0
 				}
 			}
+
+			var msg string
+			if b := c.outs[ev.Test]; b != nil {
+				msg = b.String()
+			}
+
+			c.pr.Tests[ev.Test] = append(c.pr.Tests[ev.Test], TestAttempt{Outcome: "fail", Time: dur, Output: msg})
+			delete(c.starts, ev.Test)
+		} else if ev.Elapsed > 0 {
+			c.pr.Duration += time.Duration(ev.Elapsed * float64(time.Second))
 		}
-	}
+	case "skip":
+		if ev.Test != "" {
+			c.pr.Skipped++
+
+			var dur time.Duration
+			if st, ok := c.starts[ev.Test]; ok {
+				dur = ev.Time.Sub(st)
+				if dur < 0 {
+					dur = 0
+				}
+			}
 
-	if err := dec.Err(); err != nil {
-		_ = cmd.Process.Kill()
+			var msg string
+			if b := c.outs[ev.Test]; b != nil {
+				msg = b.String()
+			}
 
-		return pr, err
-	}
+			c.pr.Tests[ev.Test] = append(c.pr.Tests[ev.Test], TestAttempt{Outcome: "skip", Time: dur, Output: msg})
+			delete(c.starts, ev.Test)
+		}
+	case "output":
+		if b := c.outs[ev.Test]; b != nil {
+			b.WriteString(ev.Output)
+		}
+	case "run":
+		if ev.Test != "" {
+			c.starts[ev.Test] = ev.Time
 
-	if err := cmd.Wait(); err != nil {
-		// go test exits non-zero on package failures; allow counts to indicate failure.
+			if c.outs[ev.Test] == nil {
+				c.outs[ev.Test] = &strings.Builder{}
+			}
+		}
 	}
-
-	return pr, nil
 }
 
 // goList expands package patterns using `go list`.
@@ -602,6 +680,10 @@ func (r *Runner) goList(ctx context.Context, patterns []string) ([]string, error
 // packageHasMatchingFile reports whether `go list -json` for the package includes.
 // any file path matching the given regex.
 func (r *Runner) packageHasMatchingFile(ctx context.Context, pkg string, re *regexp.Regexp) (bool, error) {
+	if r.opts.Native {
+		return nativeHasMatchingFile(pkg, re)
+	}
+
 	cmd := exec.CommandContext(ctx, "go", "list", "-json", pkg)
 
 	b, err := cmd.Output()
@@ -631,6 +713,12 @@ func (r *Runner) packageHasMatchingFile(ctx context.Context, pkg string, re *reg
 
 // listTests prints the list of tests in a package without executing them.
 func (r *Runner) listTests(ctx context.Context, pkg string, out io.Writer) {
+	if r.opts.Native {
+		r.listNativeTests(pkg, out)
+
+		return
+	}
+
 	args := []string{"test", "-list", ".", pkg}
 	cmd := exec.CommandContext(ctx, "go", args...)
 
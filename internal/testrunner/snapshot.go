@@ -9,9 +9,13 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/orizon-lang/orizon/internal/testrunner/snapshot"
 )
 
-// SnapshotOptions controls snapshot test behavior.
+// SnapshotOptions controls snapshot test behavior. Format is "text",
+// "json", "binary", "mir" or "lir"; MIR and LIR snapshots are compared per
+// function and block with temporaries renumbered.
 type SnapshotOptions struct {
 	BaseDir  string
 	Format   string
@@ -77,6 +81,10 @@ func (sm *SnapshotManager) VerifySnapshot(testName, actual string) (bool, error)
 				return false, err
 			}
 
+			if err := snapshot.ClearPending(snapshotPath); err != nil {
+				return false, err
+			}
+
 			sm.testResults[testName] = SnapshotResult{
 				TestName:     testName,
 				SnapshotPath: snapshotPath,
@@ -86,11 +94,15 @@ func (sm *SnapshotManager) VerifySnapshot(testName, actual string) (bool, error)
 
 			return true, nil
 		} else {
+			if err := snapshot.WritePending(snapshotPath, actual); err != nil {
+				return false, err
+			}
+
 			sm.testResults[testName] = SnapshotResult{
 				TestName:     testName,
 				SnapshotPath: snapshotPath,
 				Status:       "fail",
-				Diff:         fmt.Sprintf("Snapshot file %s does not exist. Run with --update-snapshots to create it.", snapshotPath),
+				Diff:         fmt.Sprintf("Snapshot file %s does not exist. Run with --update-snapshots to create it, or accept it with orizon test --review.", snapshotPath),
 				Created:      time.Now(),
 			}
 
@@ -101,8 +113,12 @@ func (sm *SnapshotManager) VerifySnapshot(testName, actual string) (bool, error)
 	}
 
 	// Compare actual vs expected.
-	expectedStr := string(expected)
-	if actual == expectedStr {
+	diff := sm.generateDiff(snapshotPath, string(expected), actual)
+	if diff == "" {
+		if err := snapshot.ClearPending(snapshotPath); err != nil {
+			return false, err
+		}
+
 		sm.testResults[testName] = SnapshotResult{
 			TestName:     testName,
 			SnapshotPath: snapshotPath,
@@ -120,6 +136,10 @@ func (sm *SnapshotManager) VerifySnapshot(testName, actual string) (bool, error)
 			return false, err
 		}
 
+		if err := snapshot.ClearPending(snapshotPath); err != nil {
+			return false, err
+		}
+
 		sm.testResults[testName] = SnapshotResult{
 			TestName:     testName,
 			SnapshotPath: snapshotPath,
@@ -129,8 +149,11 @@ func (sm *SnapshotManager) VerifySnapshot(testName, actual string) (bool, error)
 
 		return true, nil
 	} else {
-		// Generate diff.
-		diff := sm.generateDiff(expectedStr, actual)
+		// Keep the new output pending for orizon test --review.
+		if err := snapshot.WritePending(snapshotPath, actual); err != nil {
+			return false, err
+		}
+
 		sm.testResults[testName] = SnapshotResult{
 			TestName:     testName,
 			SnapshotPath: snapshotPath,
@@ -160,17 +183,25 @@ func (sm *SnapshotManager) VerifyGoldenFile(goldenPath, actual string) (bool, er
 				return false, err
 			}
 
+			if err := snapshot.ClearPending(goldenPath); err != nil {
+				return false, err
+			}
+
 			return true, nil
 		} else {
+			if err := snapshot.WritePending(goldenPath, actual); err != nil {
+				return false, err
+			}
+
 			return false, fmt.Errorf("golden file %s does not exist", goldenPath)
 		}
 	} else if err != nil {
 		return false, fmt.Errorf("failed to read golden file %s: %w", goldenPath, err)
 	}
 
-	expectedStr := string(expected)
-	if actual == expectedStr {
-		return true, nil
+	diff := sm.generateDiff(goldenPath, string(expected), actual)
+	if diff == "" {
+		return true, snapshot.ClearPending(goldenPath)
 	}
 
 	if sm.options.Update {
@@ -179,9 +210,11 @@ func (sm *SnapshotManager) VerifyGoldenFile(goldenPath, actual string) (bool, er
 			return false, err
 		}
 
-		return true, nil
+		return true, snapshot.ClearPending(goldenPath)
 	} else {
-		diff := sm.generateDiff(expectedStr, actual)
+		if err := snapshot.WritePending(goldenPath, actual); err != nil {
+			return false, err
+		}
 
 		return false, fmt.Errorf("golden file mismatch for %s:\n%s", goldenPath, diff)
 	}
@@ -300,6 +333,8 @@ func (sm *SnapshotManager) getSnapshotPath(testName string) string {
 		ext = ".json"
 	case "binary":
 		ext = ".bin"
+	case "mir", "lir":
+		ext = "." + sm.options.Format + ".snap"
 	default:
 		ext = ".snap"
 	}
@@ -323,44 +358,17 @@ func (sm *SnapshotManager) writeSnapshot(path, content string) error {
 	}
 }
 
-// generateDiff creates a simple diff between expected and actual content.
-func (sm *SnapshotManager) generateDiff(expected, actual string) string {
-	if sm.options.DiffTool != "" {
+// generateDiff compares expected and actual content of the snapshot at
+// path and returns "" when they match. IR snapshots are diffed per function
+// and block, so renumbered temporaries are not reported.
+func (sm *SnapshotManager) generateDiff(path, expected, actual string) string {
+	diff := snapshot.Compare(path, expected, actual)
+	if diff != "" && sm.options.DiffTool != "" {
 		// TODO: Implement external diff tool support.
 		return fmt.Sprintf("Use %s to view differences", sm.options.DiffTool)
 	}
 
-	// Simple line-by-line diff.
-	expectedLines := strings.Split(expected, "\n")
-	actualLines := strings.Split(actual, "\n")
-
-	var diff strings.Builder
-
-	diff.WriteString("Expected vs Actual:\n")
-
-	maxLines := len(expectedLines)
-	if len(actualLines) > maxLines {
-		maxLines = len(actualLines)
-	}
-
-	for i := 0; i < maxLines; i++ {
-		var expectedLine, actualLine string
-		if i < len(expectedLines) {
-			expectedLine = expectedLines[i]
-		}
-
-		if i < len(actualLines) {
-			actualLine = actualLines[i]
-		}
-
-		if expectedLine != actualLine {
-			diff.WriteString(fmt.Sprintf("Line %d:\n", i+1))
-			diff.WriteString(fmt.Sprintf("- %s\n", expectedLine))
-			diff.WriteString(fmt.Sprintf("+ %s\n", actualLine))
-		}
-	}
-
-	return diff.String()
+	return diff
 }
 
 // HashContent generates a content hash for snapshot comparison.
@@ -0,0 +1,398 @@
+// Package snapshot compares snapshot and golden files and manages pending
+// snapshots awaiting review. MIR and LIR text is compared structurally,
+// per function and per basic block, after temporaries and the block labels
+// derived from them are renumbered, so that shifting the temporary counter
+// does not show up as a change.
+package snapshot
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// IRModule is MIR or LIR text split into functions and blocks.
+type IRModule struct {
+	// Header holds the lines before the first function, e.g. "module main".
+	Header []string
+	Funcs  []IRFunc
+}
+
+// IRFunc is one function of an IR module.
+type IRFunc struct {
+	Name      string
+	Signature string
+	Blocks    []IRBlock
+}
+
+// IRBlock is a labelled basic block. Instructions before the first label
+// land in a block with an empty label.
+type IRBlock struct {
+	Label  string
+	Instrs []string
+}
+
+var (
+	funcHeader = regexp.MustCompile(`^func\s+([^\s(]+)\s*\(.*\)\s*\{$`)
+	blockLabel = regexp.MustCompile(`^([A-Za-z_.$][\w.$]*):$`)
+	// numbered matches temporaries (%t12, %ac3) and labels built from a
+	// temporary (cont_t12, then_t4). Labels share the %t counter.
+	numbered = regexp.MustCompile(`(%t|%ac|\b[A-Za-z]+_t)(\d+)\b`)
+)
+
+// IsIR reports whether path names MIR or LIR text, by its extensions:
+// x.mir, x.lir.txt, x.mir.snap and so on.
+func IsIR(path string) bool {
+	name := filepath.Base(path)
+	for _, ext := range []string{".snap", ".txt", ".new"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return strings.HasSuffix(name, ".mir") || strings.HasSuffix(name, ".lir")
+}
+
+// ParseIR splits the textual form of a mir.Module or lir.Module.
+func ParseIR(text string) *IRModule {
+	m := &IRModule{}
+
+	var fn *IRFunc
+
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		if fn == nil {
+			if sm := funcHeader.FindStringSubmatch(line); sm != nil {
+				m.Funcs = append(m.Funcs, IRFunc{Name: sm[1], Signature: line})
+				fn = &m.Funcs[len(m.Funcs)-1]
+			} else {
+				m.Header = append(m.Header, line)
+			}
+
+			continue
+		}
+
+		switch {
+		case line == "}":
+			fn = nil
+		case blockLabel.MatchString(line):
+			fn.Blocks = append(fn.Blocks, IRBlock{Label: strings.TrimSuffix(line, ":")})
+		default:
+			if len(fn.Blocks) == 0 {
+				fn.Blocks = append(fn.Blocks, IRBlock{})
+			}
+
+			b := &fn.Blocks[len(fn.Blocks)-1]
+			b.Instrs = append(b.Instrs, line)
+		}
+	}
+
+	return m
+}
+
+// Normalize renumbers temporaries and temporary-derived labels of every
+// function in order of first appearance.
+func (m *IRModule) Normalize() {
+	for i := range m.Funcs {
+		f := &m.Funcs[i]
+		next := map[string]int{}
+		seen := map[string]string{}
+
+		rename := func(s string) string {
+			return numbered.ReplaceAllStringFunc(s, func(tok string) string {
+				sm := numbered.FindStringSubmatch(tok)
+				// %tN and label_tN count together, %acN separately.
+				class := "t"
+				if sm[1] == "%ac" {
+					class = "ac"
+				}
+
+				key := class + sm[2]
+
+				id, ok := seen[key]
+				if !ok {
+					id = strconv.Itoa(next[class])
+					next[class]++
+					seen[key] = id
+				}
+
+				return sm[1] + id
+			})
+		}
+
+		f.Signature = rename(f.Signature)
+
+		for j := range f.Blocks {
+			b := &f.Blocks[j]
+			b.Label = rename(b.Label)
+
+			for k := range b.Instrs {
+				b.Instrs[k] = rename(b.Instrs[k])
+			}
+		}
+	}
+}
+
+// NormalizeIR returns text parsed, normalized and printed back.
+func NormalizeIR(text string) string {
+	m := ParseIR(text)
+	m.Normalize()
+
+	return m.String()
+}
+
+// String prints the module in the MIR/LIR layout.
+func (m *IRModule) String() string {
+	var b strings.Builder
+
+	for _, h := range m.Header {
+		b.WriteString(h)
+		b.WriteByte('\n')
+	}
+
+	for _, f := range m.Funcs {
+		b.WriteString(f.Signature)
+		b.WriteByte('\n')
+
+		for _, bb := range f.Blocks {
+			if bb.Label != "" {
+				fmt.Fprintf(&b, "%s:\n", bb.Label)
+			}
+
+			for _, in := range bb.Instrs {
+				fmt.Fprintf(&b, "  %s\n", in)
+			}
+		}
+
+		b.WriteString("}\n\n")
+	}
+
+	return b.String()
+}
+
+// DiffIR compares two IR texts function by function and block by block
+// after normalization. It returns "" when they are equivalent.
+func DiffIR(expected, actual string) string {
+	em, am := ParseIR(expected), ParseIR(actual)
+	em.Normalize()
+	am.Normalize()
+
+	var b strings.Builder
+
+	if d := DiffLines(em.Header, am.Header); len(d) > 0 {
+		b.WriteString("module header:\n")
+		writeIndented(&b, "  ", d)
+	}
+
+	for _, name := range mergeOrder(funcNames(em.Funcs), funcNames(am.Funcs)) {
+		ef, af := findFunc(em.Funcs, name), findFunc(am.Funcs, name)
+
+		switch {
+		case ef == nil:
+			fmt.Fprintf(&b, "+ func %s (added, %d blocks)\n", name, len(af.Blocks))
+		case af == nil:
+			fmt.Fprintf(&b, "- func %s (removed)\n", name)
+		default:
+			diffFunc(&b, ef, af)
+		}
+	}
+
+	return b.String()
+}
+
+func diffFunc(b *strings.Builder, ef, af *IRFunc) {
+	var body strings.Builder
+
+	if ef.Signature != af.Signature {
+		fmt.Fprintf(&body, "  - %s\n  + %s\n", ef.Signature, af.Signature)
+	}
+
+	for _, label := range mergeOrder(blockLabels(ef.Blocks), blockLabels(af.Blocks)) {
+		eb, ab := findBlock(ef.Blocks, label), findBlock(af.Blocks, label)
+		name := label
+		if name == "" {
+			name = "(unlabelled)"
+		}
+
+		switch {
+		case eb == nil:
+			fmt.Fprintf(&body, "  + block %s (added)\n", name)
+			writeIndented(&body, "    + ", ab.Instrs)
+		case ab == nil:
+			fmt.Fprintf(&body, "  - block %s (removed)\n", name)
+		default:
+			if d := DiffLines(eb.Instrs, ab.Instrs); len(d) > 0 {
+				fmt.Fprintf(&body, "  block %s:\n", name)
+				writeIndented(&body, "    ", d)
+			}
+		}
+	}
+
+	if body.Len() > 0 {
+		fmt.Fprintf(b, "func %s:\n%s", ef.Name, body.String())
+	}
+}
+
+// DiffLines returns a line diff of a and b, with "- " and "+ " prefixes on
+// removed and added lines and "  " on the unchanged lines between them.
+// Unchanged lines away from any change are left out; nil means equal.
+func DiffLines(a, b []string) []string {
+	// Longest common subsequence table.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		text string
+		kind byte
+	}
+
+	var ops []op
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{a[i], ' '})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{a[i], '-'})
+			i++
+		default:
+			ops = append(ops, op{b[j], '+'})
+			j++
+		}
+	}
+
+	// Keep one line of context around changes.
+	const context = 1
+
+	var out []string
+
+	changed := false
+
+	for k, o := range ops {
+		if o.kind != ' ' {
+			changed = true
+		}
+
+		near := false
+
+		for d := max(0, k-context); d <= min(len(ops)-1, k+context); d++ {
+			if ops[d].kind != ' ' {
+				near = true
+			}
+		}
+
+		if near {
+			out = append(out, string(o.kind)+" "+o.text)
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return out
+}
+
+func writeIndented(b *strings.Builder, indent string, lines []string) {
+	for _, l := range lines {
+		b.WriteString(indent)
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+}
+
+// mergeOrder lists the names of a followed by those only in b, keeping the
+// order of each side.
+func mergeOrder(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	out := append([]string(nil), a...)
+
+	for _, n := range a {
+		seen[n] = true
+	}
+
+	for _, n := range b {
+		if !seen[n] {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
+
+func funcNames(fs []IRFunc) []string {
+	out := make([]string, len(fs))
+	for i, f := range fs {
+		out[i] = f.Name
+	}
+
+	return out
+}
+
+func blockLabels(bs []IRBlock) []string {
+	out := make([]string, len(bs))
+	for i, b := range bs {
+		out[i] = b.Label
+	}
+
+	return out
+}
+
+func findFunc(fs []IRFunc, name string) *IRFunc {
+	for i := range fs {
+		if fs[i].Name == name {
+			return &fs[i]
+		}
+	}
+
+	return nil
+}
+
+func findBlock(bs []IRBlock, label string) *IRBlock {
+	for i := range bs {
+		if bs[i].Label == label {
+			return &bs[i]
+		}
+	}
+
+	return nil
+}
+
+// Compare compares the expected and actual content of the snapshot at
+// path. IR snapshots are compared structurally (see DiffIR), everything
+// else line by line. It returns "" when they match.
+func Compare(path, expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+
+	if IsIR(path) {
+		return DiffIR(expected, actual)
+	}
+
+	d := DiffLines(strings.Split(expected, "\n"), strings.Split(actual, "\n"))
+	if d == nil {
+		return ""
+	}
+
+	return strings.Join(d, "\n") + "\n"
+}
@@ -0,0 +1,108 @@
+package snapshot
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PendingExt is appended to a snapshot path to name the pending file that
+// holds new, not yet accepted content: x.snap becomes x.snap.new and the
+// golden x.mir.txt becomes x.mir.txt.new.
+const PendingExt = ".new"
+
+// snapshotExts are the extensions of files that may have pending versions.
+var snapshotExts = []string{".snap", ".json", ".bin", ".txt", ".asm", ".golden"}
+
+// PendingPath returns the pending file of the snapshot at target.
+func PendingPath(target string) string {
+	return target + PendingExt
+}
+
+// TargetPath returns the snapshot a pending file belongs to.
+func TargetPath(pending string) string {
+	return strings.TrimSuffix(pending, PendingExt)
+}
+
+// IsPending reports whether path names a pending snapshot.
+func IsPending(path string) bool {
+	if !strings.HasSuffix(path, PendingExt) {
+		return false
+	}
+
+	ext := filepath.Ext(TargetPath(path))
+	for _, e := range snapshotExts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WritePending records content as the pending version of target.
+func WritePending(target, content string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(PendingPath(target), []byte(content), 0o644)
+}
+
+// ClearPending removes a stale pending version of target, if any.
+func ClearPending(target string) error {
+	err := os.Remove(PendingPath(target))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Accept replaces the snapshot with its pending version.
+func Accept(pending string) error {
+	return os.Rename(pending, TargetPath(pending))
+}
+
+// Reject discards a pending version and keeps the snapshot.
+func Reject(pending string) error {
+	return os.Remove(pending)
+}
+
+// FindPending lists the pending snapshots under the given files and
+// directories, sorted. VCS and dependency directories are not searched.
+func FindPending(roots ...string) ([]string, error) {
+	var out []string
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				switch d.Name() {
+				case ".git", "node_modules", "vendor":
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if IsPending(path) {
+				out = append(out, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
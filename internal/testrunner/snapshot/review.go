@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReviewResult lists what a review did with each pending snapshot.
+type ReviewResult struct {
+	Accepted []string
+	Rejected []string
+	Skipped  []string
+}
+
+// Review walks through the pending snapshots, prints the change each one
+// makes and asks on in whether to accept or reject it:
+//
+//	a  accept      r  reject      s  skip (keep pending)
+//	A  accept all  R  reject all  q  quit, skipping the rest
+//
+// Prompts and diffs go to out.
+func Review(pending []string, in io.Reader, out io.Writer) (ReviewResult, error) {
+	var res ReviewResult
+
+	rd := bufio.NewReader(in)
+	all := byte(0)
+
+	for i, p := range pending {
+		target := TargetPath(p)
+
+		newContent, err := os.ReadFile(p)
+		if err != nil {
+			return res, err
+		}
+
+		fmt.Fprintf(out, "\n[%d/%d] %s\n", i+1, len(pending), target)
+
+		old, err := os.ReadFile(target)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Fprintln(out, "new snapshot:")
+			printPrefixed(out, "+ ", string(newContent))
+		case err != nil:
+			return res, err
+		default:
+			if d := Compare(target, string(old), string(newContent)); d != "" {
+				fmt.Fprint(out, d)
+			} else {
+				fmt.Fprintln(out, "no semantic change (only numbering or layout differs)")
+			}
+		}
+
+		choice := all
+		for choice == 0 {
+			fmt.Fprint(out, "accept (a), reject (r), skip (s), accept all (A), reject all (R), quit (q)? ")
+
+			line, err := rd.ReadString('\n')
+			answer := strings.TrimSpace(line)
+
+			if len(answer) == 1 && strings.Contains("arsARq", answer) {
+				choice = answer[0]
+			} else if err != nil {
+				// End of input: leave the rest pending.
+				choice = 'q'
+			}
+		}
+
+		switch choice {
+		case 'A':
+			all = 'a'
+			choice = 'a'
+		case 'R':
+			all = 'r'
+			choice = 'r'
+		}
+
+		switch choice {
+		case 'a':
+			if err := Accept(p); err != nil {
+				return res, err
+			}
+
+			res.Accepted = append(res.Accepted, target)
+		case 'r':
+			if err := Reject(p); err != nil {
+				return res, err
+			}
+
+			res.Rejected = append(res.Rejected, target)
+		case 's':
+			res.Skipped = append(res.Skipped, target)
+		case 'q':
+			for _, rest := range pending[i:] {
+				res.Skipped = append(res.Skipped, TargetPath(rest))
+			}
+
+			return res, nil
+		}
+	}
+
+	return res, nil
+}
+
+func printPrefixed(out io.Writer, prefix, text string) {
+	for _, l := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(out, "%s%s\n", prefix, l)
+	}
+}
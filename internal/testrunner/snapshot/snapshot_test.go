@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mirBefore = `module main
+func add(a, b) {
+entry:
+  %t0 = load %a.addr
+  %t1 = load %b.addr
+  %t2 = add %t0, %t1
+  brcond %t2, then_t3, cont_t4
+then_t3:
+  ret %t2
+cont_t4:
+  ret
+}
+
+func main() {
+entry:
+  ret
+}
+`
+
+// mirRenumbered is mirBefore with every temporary shifted by ten.
+const mirRenumbered = `module main
+func add(a, b) {
+entry:
+  %t10 = load %a.addr
+  %t11 = load %b.addr
+  %t12 = add %t10, %t11
+  brcond %t12, then_t13, cont_t14
+then_t13:
+  ret %t12
+cont_t14:
+  ret
+}
+
+func main() {
+entry:
+  ret
+}
+`
+
+func TestDiffIRIgnoresRenumbering(t *testing.T) {
+	if d := DiffIR(mirBefore, mirRenumbered); d != "" {
+		t.Fatalf("renumbered temporaries reported as a diff:\n%s", d)
+	}
+
+	if d := Compare("x.mir.txt", mirBefore, mirRenumbered); d != "" {
+		t.Fatalf("Compare should treat .mir.txt as IR:\n%s", d)
+	}
+
+	if d := Compare("x.snap", mirBefore, mirRenumbered); d == "" {
+		t.Fatalf("plain snapshots are compared as text")
+	}
+}
+
+func TestDiffIRPerFunctionAndBlock(t *testing.T) {
+	changed := strings.Replace(mirRenumbered, "%t12 = add", "%t12 = sub", 1)
+	changed = strings.Replace(changed, "func main() {\nentry:\n  ret\n}\n", "", 1)
+	changed += "func helper() {\nentry:\n  ret\n}\n"
+
+	got := DiffIR(mirBefore, changed)
+	want := `func add:
+  block entry:
+      %t1 = load %b.addr
+    - %t2 = add %t0, %t1
+    + %t2 = sub %t0, %t1
+      brcond %t2, then_t3, cont_t4
+- func main (removed)
++ func helper (added, 1 blocks)
+`
+	if got != want {
+		t.Fatalf("diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPendingAndReview(t *testing.T) {
+	dir := t.TempDir()
+	changed := filepath.Join(dir, "add.mir.txt")
+	renumbered := filepath.Join(dir, "sub", "main.snap")
+	fresh := filepath.Join(dir, "fresh.snap")
+
+	for path, content := range map[string]string{changed: mirBefore, renumbered: "old\n"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, content := range map[string]string{
+		changed:    strings.Replace(mirBefore, "ret %t2", "ret 0", 1),
+		renumbered: "new\n",
+		fresh:      "hello\n",
+	} {
+		if err := WritePending(path, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Files that merely end in .new are not snapshots.
+	if err := os.WriteFile(filepath.Join(dir, "notes.new"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := FindPending(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 3 || pending[0] != PendingPath(changed) {
+		t.Fatalf("pending = %v", pending)
+	}
+
+	var out strings.Builder
+
+	// An unknown answer is asked again.
+	res, err := Review(pending, strings.NewReader("x\na\ns\nr\n"), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Accepted) != 1 || len(res.Rejected) != 1 || len(res.Skipped) != 1 {
+		t.Fatalf("result = %+v", res)
+	}
+
+	if b, _ := os.ReadFile(changed); !strings.Contains(string(b), "ret 0") {
+		t.Errorf("accepted snapshot not replaced:\n%s", b)
+	}
+
+	if b, _ := os.ReadFile(renumbered); string(b) != "old\n" {
+		t.Errorf("rejected snapshot changed: %q", b)
+	}
+
+	if _, err := os.Stat(PendingPath(renumbered)); !os.IsNotExist(err) {
+		t.Errorf("rejected pending file kept")
+	}
+
+	if _, err := os.Stat(PendingPath(fresh)); err != nil {
+		t.Errorf("skipped pending file removed")
+	}
+
+	for _, want := range []string{"[1/3] " + changed, "  block then_t3:", "- ret %t2", "+ ret 0", "new snapshot:", "+ hello"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("review output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package test_test
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestExamples_EmitMIR runs orizon-compiler --emit-mir over every example.
+// Examples the parser does not support yet may fail with an error, but the
+// compiler must never crash on them.
+func TestExamples_EmitMIR(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short mode")
+	}
+
+	root := filepath.Clean("..")
+
+	outPath := filepath.Join(t.TempDir(), "orizon-compiler")
+	if runtime.GOOS == "windows" {
+		outPath += ".exe"
+	}
+
+	build := exec.Command("go", "build", "-o", outPath, "./cmd/orizon-compiler")
+	build.Dir = root
+
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build orizon-compiler failed: %v\n%s", err, string(out))
+	}
+
+	examples, err := filepath.Glob(filepath.Join(root, "examples", "*.oriz"))
+	if err != nil || len(examples) == 0 {
+		t.Fatalf("no examples found: %v", err)
+	}
+
+	for _, path := range examples {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			out, err := exec.Command(outPath, "--emit-mir", path).CombinedOutput()
+
+			var exit *exec.ExitError
+			if err != nil && !errors.As(err, &exit) {
+				t.Fatalf("run: %v", err)
+			}
+
+			if bytes.Contains(out, []byte("panic:")) || bytes.Contains(out, []byte("goroutine ")) {
+				t.Fatalf("orizon-compiler crashed:\n%s", string(out))
+			}
+		})
+	}
+}